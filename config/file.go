@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read when CONFIG_FILE names none; without it the
+// configuration comes from the environment alone
+const defaultConfigFile = "config.yaml"
+
+// fileSettings are the settings of the config file, with its profile
+// applied, by the environment variable each one stands for
+var fileSettings map[string]string
+
+// loadConfigFile reads the settings of the config file and returns the
+// profile applied. Its sections name the environment variables they set
+// when joined with underscores: server: {port: 8080} sets SERVER_PORT.
+// Lists are joined with commas. Settings under profiles.<name> override
+// the others when APP_PROFILE, or else the file's profile key, names them.
+func loadConfigFile() (string, error) {
+	fileSettings = nil
+
+	path := os.Getenv("CONFIG_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && os.IsNotExist(err) {
+			if profile := os.Getenv("APP_PROFILE"); profile != "" {
+				return "", fmt.Errorf("APP_PROFILE is %s but there is no %s", profile, path)
+			}
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return "", fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	profiles, ok := document["profiles"].(map[string]interface{})
+	if !ok && document["profiles"] != nil {
+		return "", fmt.Errorf("invalid config file %s: profiles must map names to settings", path)
+	}
+	profile := os.Getenv("APP_PROFILE")
+	if profile == "" {
+		profile, _ = document["profile"].(string)
+	}
+	delete(document, "profiles")
+	delete(document, "profile")
+
+	settings := make(map[string]string)
+	if err := flattenSettings("", document, settings); err != nil {
+		return "", fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if profile != "" {
+		overrides, ok := profiles[profile]
+		if !ok {
+			return "", fmt.Errorf("config file %s has no profile %s; it has %s", path, profile, strings.Join(profileNames(profiles), ", "))
+		}
+		if err := flattenSettings("", overrides, settings); err != nil {
+			return "", fmt.Errorf("invalid profile %s of config file %s: %w", profile, path, err)
+		}
+	}
+
+	fileSettings = settings
+	return profile, nil
+}
+
+// flattenSettings adds the settings under a section to settings, by the
+// name of their environment variable.
+func flattenSettings(name string, value interface{}, settings map[string]string) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			key = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+			if name != "" {
+				key = name + "_" + key
+			}
+			if err := flattenSettings(key, child, settings); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("%s: lists may only hold values", name)
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		settings[name] = strings.Join(items, ",")
+	case nil:
+		if name != "" {
+			settings[name] = ""
+		}
+	default:
+		if name == "" {
+			return fmt.Errorf("settings must be a mapping")
+		}
+		settings[name] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+func profileNames(profiles map[string]interface{}) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return []string{"none"}
+	}
+	return names
+}
+
+// getSetting returns a setting from the environment, or else the config
+// file, or else defaultValue. Empty variables count as unset.
+func getSetting(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	if value := fileSettings[key]; value != "" {
+		return value
+	}
+	return defaultValue
+}
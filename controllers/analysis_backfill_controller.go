@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type AnalysisBackfillController struct {
+	backfillService *services.AnalysisBackfillService
+}
+
+func NewAnalysisBackfillController() *AnalysisBackfillController {
+	return &AnalysisBackfillController{
+		backfillService: services.NewAnalysisBackfillService(),
+	}
+}
+
+// @Summary Start analysis backfill
+// @Description Re-analyze, in batches, every clip whose analysis is older than the current analysis pipeline version, so new analysis features apply to existing libraries. The first batch is queued right away and the next one on each run of the advance-analysis-backfills schedule; only one backfill runs at a time (requires admin:tasks)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AnalysisBackfillRequest false "Analysis type and batch size"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/analysis-backfills [post]
+func (c *AnalysisBackfillController) StartBackfill(ctx *gin.Context) {
+	var req models.AnalysisBackfillRequest
+	if ctx.Request.ContentLength != 0 {
+		if !bindJSON(ctx, &req) {
+			return
+		}
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	backfill, err := c.backfillService.StartBackfill(userID, middleware.GetRequestID(ctx), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionBackfillStart, "analysis_backfill", backfill.ID, nil, backfill)
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":  "Analysis backfill started",
+		"backfill": backfill,
+	})
+}
+
+// @Summary List analysis backfills
+// @Description List the 50 most recent analysis backfills with their progress, newest first (requires admin:tasks)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/analysis-backfills [get]
+func (c *AnalysisBackfillController) ListBackfills(ctx *gin.Context) {
+	backfills, err := c.backfillService.ListBackfills()
+	if err != nil {
+		logger.Errorf("Failed to list analysis backfills: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get analysis backfills",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"backfills":        backfills,
+		"analysis_version": models.DefaultAnalysisVersion,
+	})
+}
+
+// @Summary Get analysis backfill
+// @Description Retrieve an analysis backfill with its progress: the clips queued so far out of those that were outdated when it started (requires admin:tasks)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Backfill ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/analysis-backfills/{id} [get]
+func (c *AnalysisBackfillController) GetBackfill(ctx *gin.Context) {
+	backfillID, ok := parseBackfillID(ctx)
+	if !ok {
+		return
+	}
+
+	backfill, err := c.backfillService.GetBackfill(backfillID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"backfill": backfill,
+	})
+}
+
+// @Summary Cancel analysis backfill
+// @Description Stop queuing clips of a running analysis backfill; clips already queued are still analyzed (requires admin:tasks)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Backfill ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/analysis-backfills/{id}/cancel [post]
+func (c *AnalysisBackfillController) CancelBackfill(ctx *gin.Context) {
+	backfillID, ok := parseBackfillID(ctx)
+	if !ok {
+		return
+	}
+
+	backfill, err := c.backfillService.CancelBackfill(backfillID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionBackfillCancel, "analysis_backfill", backfill.ID, nil, backfill)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Analysis backfill cancelled",
+		"backfill": backfill,
+	})
+}
+
+func parseBackfillID(ctx *gin.Context) (uint, bool) {
+	backfillID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid backfill ID",
+		})
+		return 0, false
+	}
+	return uint(backfillID), true
+}
+
+func (c *AnalysisBackfillController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "analysis backfill not found":
+		statusCode = http.StatusNotFound
+	case "an analysis backfill is already running", "analysis backfill is not running":
+		statusCode = http.StatusConflict
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type APIKeyController struct {
+	apiKeyService *services.APIKeyService
+}
+
+func NewAPIKeyController() *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: services.NewAPIKeyService(),
+	}
+}
+
+// @Summary Create API key
+// @Description Create an API key for automation. The key is only returned in this response; send it in the X-API-Key header.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.APIKeyCreateRequest true "Key name, scopes and lifetime"
+// @Success 201 {object} models.APIKeyCreateResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/api-keys [post]
+func (c *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	role, _ := middleware.GetUserRole(ctx)
+
+	var req models.APIKeyCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	apiKey, key, err := c.apiKeyService.CreateAPIKey(userID, role, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionAPIKeyCreate, "api_key", apiKey.ID, nil, apiKey)
+
+	ctx.JSON(http.StatusCreated, models.APIKeyCreateResponse{
+		APIKey: apiKey,
+		Key:    key,
+	})
+}
+
+// @Summary List API keys
+// @Description List the API keys of the current user, without the keys themselves
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/api-keys [get]
+func (c *APIKeyController) ListAPIKeys(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	apiKeys, err := c.apiKeyService.ListAPIKeys(userID)
+	if err != nil {
+		logger.Errorf("Failed to list API keys: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get API keys",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"api_keys": apiKeys,
+		"scopes":   models.APIKeyScopes,
+	})
+}
+
+// @Summary Rotate API key
+// @Description Replace the key of an API key, keeping its name and scopes. The previous key stops working immediately.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.APIKeyCreateResponse
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/api-keys/{id}/rotate [post]
+func (c *APIKeyController) RotateAPIKey(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	keyID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid API key ID",
+		})
+		return
+	}
+
+	apiKey, key, err := c.apiKeyService.RotateAPIKey(uint(keyID), userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionAPIKeyRotate, "api_key", apiKey.ID, nil, apiKey)
+
+	ctx.JSON(http.StatusOK, models.APIKeyCreateResponse{
+		APIKey: apiKey,
+		Key:    key,
+	})
+}
+
+// @Summary Revoke API key
+// @Description Permanently disable an API key
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/api-keys/{id} [delete]
+func (c *APIKeyController) RevokeAPIKey(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	keyID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid API key ID",
+		})
+		return
+	}
+
+	apiKey, err := c.apiKeyService.RevokeAPIKey(uint(keyID), userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionAPIKeyRevoke, "api_key", apiKey.ID, nil, apiKey)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked",
+		"api_key": apiKey,
+	})
+}
+
+func (c *APIKeyController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "API key not found":
+		statusCode = http.StatusNotFound
+	case "invalid API key scope":
+		statusCode = http.StatusBadRequest
+	case "API key scope exceeds your role":
+		statusCode = http.StatusForbidden
+	case "API key is revoked":
+		statusCode = http.StatusConflict
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
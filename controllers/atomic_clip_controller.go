@@ -3,27 +3,40 @@ package controllers
 import (
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"creative-studio-server/config"
 	"creative-studio-server/middleware"
 	"creative-studio-server/models"
 	"creative-studio-server/services"
 	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/validation"
+	"creative-studio-server/pkg/video_engine"
 )
 
 type AtomicClipController struct {
 	atomicClipService *services.AtomicClipService
+	thumbnailService  *services.ThumbnailService
+	transcodeService  *services.TranscodeService
+	ffmpegProcessor   *video_engine.FFmpegProcessor
 }
 
 func NewAtomicClipController() *AtomicClipController {
 	return &AtomicClipController{
 		atomicClipService: services.NewAtomicClipService(),
+		thumbnailService:  services.NewThumbnailService(),
+		transcodeService:  services.NewTranscodeService(),
+		ffmpegProcessor:   video_engine.NewFFmpegProcessor(config.AppConfig),
 	}
 }
 
 // @Summary Create atomic clip
-// @Description Upload and create a new atomic clip
+// @Description Upload and create a new atomic clip. Once it is analyzed, a clip.duplicates event warns when its footage is already in the library
 // @Tags atomic-clips
 // @Accept multipart/form-data
 // @Produce json
@@ -35,10 +48,16 @@ func NewAtomicClipController() *AtomicClipController {
 // @Param mood formData string false "Clip mood"
 // @Param style formData string false "Clip style"
 // @Param color formData string false "Clip color"
+// @Param visibility formData string false "private, unlisted (anyone with the ID can open it) or public (also searchable)" default(private)
 // @Param video formData file true "Video file"
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Param Idempotency-Key header string false "Replays the first response to retries sent with the same key"
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
 // @Router /api/v1/atomic-clips [post]
 func (c *AtomicClipController) CreateAtomicClip(ctx *gin.Context) {
 	userID, exists := middleware.GetUserID(ctx)
@@ -49,97 +68,621 @@ func (c *AtomicClipController) CreateAtomicClip(ctx *gin.Context) {
 		return
 	}
 
-	// Parse multipart form
-	err := ctx.Request.ParseMultipartForm(100 << 20) // 100MB max
+	// Read the form, writing the video straight next to the user's other
+	// uploads
+	uploadDir := filepath.Join(config.AppConfig.Storage.UploadPath, "clips", fmt.Sprint(userID))
+	form := readUpload(ctx, map[string]string{"video": uploadDir})
+	if form == nil {
+		return
+	}
+	defer form.RemoveFiles()
+
+	// Get file
+	file := formFile(ctx, form, "video")
+	if file == nil {
+		return
+	}
+
+	// Validate file type
+	contentType := file.ContentType
+	if contentType != "video/mp4" && contentType != "video/quicktime" && 
+	   contentType != "video/x-msvideo" && contentType != "video/x-matroska" {
+		invalidField(ctx, "video", validation.RuleVideoFile)
+		return
+	}
+
+	// Create request from form data
+	req := &models.AtomicClipCreateRequest{
+		Title:       form.Value("title"),
+		Description: form.Value("description"),
+		Category:    form.Value("category"),
+		Mood:        form.Value("mood"),
+		Style:       form.Value("style"),
+		Color:       form.Value("color"),
+		Visibility:  form.Value("visibility"),
+	}
+
+	// Tags arrive as one comma-separated field
+	if tagsStr := form.Value("tags"); tagsStr != "" {
+		req.Tags = models.ParseTags(tagsStr)
+	}
+
+	// Validate request with the rules of JSON requests
+	if !validateRequest(ctx, req) {
+		return
+	}
+
+	info, err := c.ffmpegProcessor.GetVideoInfo(file.Path)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to parse multipart form",
+			"error": "Not a readable video file",
+		})
+		return
+	}
+	fileInfo := map[string]interface{}{
+		"file_size":  file.Size,
+		"duration":   info.Duration,
+		"resolution": fmt.Sprintf("%dx%d", info.Width, info.Height),
+		"frame_rate": info.FrameRate,
+		"codec":      info.Codec,
+		"bitrate":    info.Bitrate,
+		"format":     info.Format,
+	}
+
+	filePath := filepath.Join(uploadDir, fmt.Sprintf("%d_%s", time.Now().UnixNano(), file.Filename))
+	if err := os.Rename(file.Path, filePath); err != nil {
+		logger.Errorf("Failed to save clip upload: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save file",
+		})
+		return
+	}
+
+	clip, err := c.atomicClipService.CreateAtomicClip(userID, middleware.GetOrganizationID(ctx), req, filePath, fileInfo)
+	if err != nil {
+		os.Remove(filePath)
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "insufficient organization role" {
+			statusCode = http.StatusForbidden
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionClipCreate, "clip", clip.ID, nil, clip)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Atomic clip created successfully",
+		"clip":    clip,
+	})
+}
+
+// @Summary Get atomic clip by ID
+// @Description Retrieve a specific atomic clip by ID; public and unlisted clips can be opened from any workspace
+// @Tags atomic-clips
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param If-None-Match header string false "ETag of the copy already held; answered with 304 Not Modified while unchanged"
+// @Success 200 {object} map[string]interface{}
+// @Success 304 "Not Modified"
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id} [get]
+func (c *AtomicClipController) GetAtomicClip(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+	
+	clip, err := c.atomicClipService.GetAtomicClipByID(uint(clipID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "atomic clip not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"clip": clip,
+	})
+}
+
+// @Summary Get clip analysis
+// @Description Retrieve the latest video analysis of a clip
+// @Tags atomic-clips
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param If-None-Match header string false "ETag of the copy already held; answered with 304 Not Modified while unchanged"
+// @Success 200 {object} models.VideoAnalysis
+// @Success 304 "Not Modified"
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/analysis [get]
+func (c *AtomicClipController) GetClipAnalysis(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	analysis, err := c.atomicClipService.GetClipAnalysis(uint(clipID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "atomic clip not found", "analysis not found":
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"analysis": analysis,
+	})
+}
+
+// @Summary Analyze atomic clip
+// @Description Queue a clip for (re)analysis. The current analysis stays available until the new one completes and records its version.
+// @Tags atomic-clips
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param request body models.ClipAnalysisRequest false "Analysis type (default full) and analyzer version"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/analyze [post]
+func (c *AtomicClipController) AnalyzeClip(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ClipAnalysisRequest
+	if ctx.Request.ContentLength != 0 {
+		if !bindJSON(ctx, &req) {
+			return
+		}
+	}
+
+	if err := c.atomicClipService.RequestAnalysis(uint(clipID), userID, middleware.GetRequestID(ctx), &req); err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "atomic clip not found":
+			statusCode = http.StatusNotFound
+		case "insufficient organization role":
+			statusCode = http.StatusForbidden
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":          "Analysis queued",
+		"clip_id":          clipID,
+		"analysis_type":    req.AnalysisType,
+		"analysis_version": req.Version,
+	})
+}
+
+// @Summary Get clip transcript
+// @Description Retrieve the time-aligned transcript of a clip's speech as JSON, or as SRT or WebVTT subtitles for players; <track> elements may pass the token as access_token
+// @Tags atomic-clips
+// @Produce json,application/x-subrip,text/vtt
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param format query string false "json (default), srt or vtt"
+// @Success 200 {object} models.ClipTranscript
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/transcript [get]
+func (c *AtomicClipController) GetClipTranscript(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+
+	var req models.ClipTranscriptRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	transcript, err := c.atomicClipService.GetClipTranscript(uint(clipID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "atomic clip not found", "transcript not found":
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	switch req.Format {
+	case models.TranscriptFormatSRT:
+		ctx.Data(http.StatusOK, "application/x-subrip; charset=utf-8", []byte(transcript.Segments.SRT()))
+	case models.TranscriptFormatWebVTT:
+		ctx.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(transcript.Segments.WebVTT()))
+	default:
+		ctx.JSON(http.StatusOK, gin.H{
+			"transcript": transcript,
+		})
+	}
+}
+
+// @Summary Transcribe atomic clip
+// @Description Queue a clip's audio to be transcribed again, e.g. in another language. The current transcript stays available until the new one is ready.
+// @Tags atomic-clips
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param request body models.ClipTranscriptionRequest false "Spoken language, detected when omitted"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/transcribe [post]
+func (c *AtomicClipController) TranscribeClip(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ClipTranscriptionRequest
+	if ctx.Request.ContentLength != 0 {
+		if !bindJSON(ctx, &req) {
+			return
+		}
+	}
+
+	if err := c.atomicClipService.RequestTranscription(uint(clipID), userID, middleware.GetRequestID(ctx), &req); err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "atomic clip not found":
+			statusCode = http.StatusNotFound
+		case "insufficient organization role":
+			statusCode = http.StatusForbidden
+		case "transcription is not enabled":
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "Transcription queued",
+		"clip_id": clipID,
+	})
+}
+
+// @Summary Get clip music analysis
+// @Description Retrieve the tempo, beat grid, downbeats, energy curve and energy sections of a music clip, which compositions using it as their soundtrack cut and end on
+// @Tags atomic-clips
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Success 200 {object} models.MusicAnalysis
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/music-analysis [get]
+func (c *AtomicClipController) GetMusicAnalysis(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	analysis, err := c.atomicClipService.GetMusicAnalysis(uint(clipID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "atomic clip not found", "music analysis not found":
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"analysis": analysis,
+	})
+}
+
+// @Summary Analyze clip music
+// @Description Queue a clip's audio to be analyzed as music. Clips imported with the music category are analyzed on their own; the current analysis stays available until the new one completes
+// @Tags atomic-clips
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/analyze-music [post]
+func (c *AtomicClipController) AnalyzeMusic(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	if err := c.atomicClipService.RequestMusicAnalysis(uint(clipID), userID, middleware.GetRequestID(ctx)); err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "atomic clip not found":
+			statusCode = http.StatusNotFound
+		case "insufficient organization role":
+			statusCode = http.StatusForbidden
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "Music analysis queued",
+		"clip_id": clipID,
+	})
+}
+
+// @Summary Suggest clip metadata
+// @Description Write a description and up to five title ideas for a clip from its visual tags, detected objects, on-screen text and transcript, with the configured language model. Suggestions are kept on the clip's analysis as ai_description and suggested_titles, and are written for new uploads on their own; nothing is applied to the clip
+// @Tags atomic-clips
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/suggest-metadata [post]
+func (c *AtomicClipController) SuggestClipMetadata(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	suggestion, err := c.atomicClipService.SuggestClipMetadata(uint(clipID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "atomic clip not found":
+			statusCode = http.StatusNotFound
+		case "insufficient organization role":
+			statusCode = http.StatusForbidden
+		case "metadata suggestions are not enabled":
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"description": suggestion.Description,
+		"titles":      suggestion.Titles,
+	})
+}
+
+// @Summary Stream atomic clip
+// @Description Stream a clip's video for in-browser playback. Supports HTTP Range requests for seeking; browsers may pass the token as access_token
+// @Tags atomic-clips
+// @Produce video/mp4
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param access_token query string false "JWT access token, for players that cannot set headers"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 404 {object} map[string]interface{}
+// @Failure 416 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/stream [get]
+func (c *AtomicClipController) StreamAtomicClip(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	clip, err := c.atomicClipService.GetAtomicClipByID(uint(clipID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "atomic clip not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
 
-	// Get file
-	file, header, err := ctx.Request.FormFile("video")
+	servePlayback(ctx, clip.FilePath)
+}
+
+// @Summary Get clip thumbnail
+// @Description Get a JPEG frame of a clip, scaled to fit w x h with its aspect ratio kept. Thumbnails are generated on first request and cached; send If-None-Match to revalidate. Browsers may pass the token as access_token
+// @Tags atomic-clips
+// @Produce image/jpeg
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param w query int false "Largest width in pixels (16-1920)"
+// @Param h query int false "Largest height in pixels (16-1920)"
+// @Param t query number false "Offset of the frame in seconds; defaults to the clip's chosen or best frame, else 1, or the middle of shorter clips"
+// @Param access_token query string false "JWT access token, for <img> elements that cannot set headers"
+// @Success 200 {file} file
+// @Success 304 "Not modified"
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/thumbnail [get]
+func (c *AtomicClipController) GetClipThumbnail(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Video file is required",
+			"error": "Invalid clip ID",
 		})
 		return
 	}
-	defer file.Close()
 
-	// Validate file type
-	contentType := header.Header.Get("Content-Type")
-	if contentType != "video/mp4" && contentType != "video/quicktime" && 
-	   contentType != "video/x-msvideo" && contentType != "video/x-matroska" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid file type. Only video files are allowed",
-		})
+	var req models.ClipThumbnailRequest
+	if !bindQuery(ctx, &req) {
 		return
 	}
 
-	// Create request from form data
-	req := &models.AtomicClipCreateRequest{
-		Title:       ctx.Request.FormValue("title"),
-		Description: ctx.Request.FormValue("description"),
-		Category:    ctx.Request.FormValue("category"),
-		Mood:        ctx.Request.FormValue("mood"),
-		Style:       ctx.Request.FormValue("style"),
-		Color:       ctx.Request.FormValue("color"),
-	}
+	userID, _ := middleware.GetUserID(ctx)
 
-	// Parse tags if provided
-	if tagsStr := ctx.Request.FormValue("tags"); tagsStr != "" {
-		// In a real implementation, you'd parse comma-separated tags
-		req.Tags = []string{tagsStr}
+	thumbnail, err := c.thumbnailService.GetClipThumbnail(uint(clipID), userID, &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case err.Error() == "atomic clip not found":
+			statusCode = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "invalid"):
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
 
-	// Validate request
-	if req.Title == "" {
+	serveImage(ctx, thumbnail.Data, thumbnail.ETag)
+}
+
+// @Summary Set clip thumbnail
+// @Description Choose the frame of a clip's thumbnail by hand, in place of the one analysis picked as the sharpest, best-exposed frame. A null time goes back to the picked frame
+// @Tags atomic-clips
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param thumbnail body models.ClipThumbnailUpdateRequest true "Offset of the frame in seconds"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/thumbnail [put]
+func (c *AtomicClipController) SetClipThumbnail(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Title is required",
+			"error": "Invalid clip ID",
 		})
 		return
 	}
 
-	// TODO: Process file upload, save to storage, and analyze video
-	// For now, we'll create a placeholder implementation
-	filePath := fmt.Sprintf("/uploads/clips/%d_%s", userID, header.Filename)
-	fileInfo := map[string]interface{}{
-		"file_size":  header.Size,
-		"duration":   60.0, // Placeholder
-		"resolution": "1920x1080", // Placeholder
-		"frame_rate": 30.0, // Placeholder
-		"codec":      "h264", // Placeholder
-		"bitrate":    2000, // Placeholder
-		"format":     "mp4", // Placeholder
+	var req models.ClipThumbnailUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
 	}
 
-	clip, err := c.atomicClipService.CreateAtomicClip(userID, req, filePath, fileInfo)
+	userID, _ := middleware.GetUserID(ctx)
+
+	before, _ := c.atomicClipService.GetAtomicClipByID(uint(clipID), userID)
+	clip, err := c.atomicClipService.SetThumbnailTime(uint(clipID), userID, &req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := http.StatusInternalServerError
+		switch {
+		case err.Error() == "atomic clip not found":
+			statusCode = http.StatusNotFound
+		case err.Error() == "insufficient organization role":
+			statusCode = http.StatusForbidden
+		case strings.HasPrefix(err.Error(), "invalid"):
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
+	middleware.Audit(ctx, models.AuditActionClipUpdate, "clip", clip.ID, before, clip)
 
-	ctx.JSON(http.StatusCreated, gin.H{
-		"message": "Atomic clip created successfully",
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Clip thumbnail updated successfully",
 		"clip":    clip,
 	})
 }
 
-// @Summary Get atomic clip by ID
-// @Description Retrieve a specific atomic clip by ID
+// @Summary Download atomic clip
+// @Description Download a clip's original file, or a variant transcoded to H.264 in the given format and resolution. Variants are transcoded on first request and kept while they are downloaded; when one takes longer than a few seconds the response is 202 with Retry-After, and the same request fetches it once ready. Browsers may pass the token as access_token
 // @Tags atomic-clips
-// @Produce json
+// @Produce octet-stream
 // @Security BearerAuth
 // @Param id path int true "Clip ID"
-// @Success 200 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Param format query string false "mp4, mov or mkv; defaults to mp4 when a resolution or quality is given"
+// @Param resolution query string false "Largest height: 360p, 480p, 720p, 1080p, 1440p or 2160p; smaller clips keep their size"
+// @Param quality query string false "low, medium or high" default(medium)
+// @Param access_token query string false "JWT access token, for links that cannot set headers"
+// @Success 200 {file} file
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
-// @Router /api/v1/atomic-clips/{id} [get]
-func (c *AtomicClipController) GetAtomicClip(ctx *gin.Context) {
+// @Router /api/v1/atomic-clips/{id}/download [get]
+func (c *AtomicClipController) DownloadAtomicClip(ctx *gin.Context) {
 	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -148,9 +691,14 @@ func (c *AtomicClipController) GetAtomicClip(ctx *gin.Context) {
 		return
 	}
 
+	var req models.ClipDownloadRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
 	userID, _ := middleware.GetUserID(ctx)
-	
-	clip, err := c.atomicClipService.GetAtomicClipByID(uint(clipID), userID)
+
+	download, err := c.transcodeService.GetClipDownload(uint(clipID), userID, &req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "atomic clip not found" {
@@ -162,9 +710,22 @@ func (c *AtomicClipController) GetAtomicClip(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"clip": clip,
-	})
+	if download.Path == "" {
+		ctx.Header("Retry-After", "10")
+		ctx.JSON(http.StatusAccepted, gin.H{
+			"message":  "Clip is being transcoded, retry shortly",
+			"filename": download.Filename,
+		})
+		return
+	}
+	if info, err := os.Stat(download.Path); err != nil || info.IsDir() {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "File not found",
+		})
+		return
+	}
+
+	ctx.FileAttachment(download.Path, download.Filename)
 }
 
 // @Summary Update atomic clip
@@ -198,25 +759,26 @@ func (c *AtomicClipController) UpdateAtomicClip(ctx *gin.Context) {
 	}
 
 	var req models.AtomicClipUpdateRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
+	before, _ := c.atomicClipService.GetAtomicClipByID(uint(clipID), userID)
 	clip, err := c.atomicClipService.UpdateAtomicClip(uint(clipID), userID, &req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "atomic clip not found" {
+		switch err.Error() {
+		case "atomic clip not found":
 			statusCode = http.StatusNotFound
+		case "insufficient organization role":
+			statusCode = http.StatusForbidden
 		}
 		ctx.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
+	middleware.Audit(ctx, models.AuditActionClipUpdate, "clip", clip.ID, before, clip)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "Atomic clip updated successfully",
@@ -225,14 +787,16 @@ func (c *AtomicClipController) UpdateAtomicClip(ctx *gin.Context) {
 }
 
 // @Summary Delete atomic clip
-// @Description Delete an atomic clip
+// @Description Delete an atomic clip. A clip on the timeline of a project that is not archived is only deleted with force, and the response then warns of it
 // @Tags atomic-clips
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Clip ID"
+// @Param force query bool false "Delete the clip even if active projects use it"
 // @Success 200 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Router /api/v1/atomic-clips/{id} [delete]
 func (c *AtomicClipController) DeleteAtomicClip(ctx *gin.Context) {
 	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
@@ -251,7 +815,66 @@ func (c *AtomicClipController) DeleteAtomicClip(ctx *gin.Context) {
 		return
 	}
 
-	err = c.atomicClipService.DeleteAtomicClip(uint(clipID), userID)
+	force, _ := strconv.ParseBool(ctx.Query("force"))
+
+	before, _ := c.atomicClipService.GetAtomicClipByID(uint(clipID), userID)
+	activeProjects, err := c.atomicClipService.DeleteAtomicClip(uint(clipID), userID, force)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		response := gin.H{
+			"error": err.Error(),
+		}
+		switch err.Error() {
+		case "atomic clip not found":
+			statusCode = http.StatusNotFound
+		case "insufficient organization role":
+			statusCode = http.StatusForbidden
+		case "atomic clip is used by active projects":
+			statusCode = http.StatusConflict
+			response["active_projects"] = activeProjects
+		}
+		ctx.JSON(statusCode, response)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionClipDelete, "clip", clipID, before, nil)
+
+	response := gin.H{
+		"message": "Atomic clip deleted successfully",
+	}
+	if activeProjects > 0 {
+		response["warning"] = fmt.Sprintf("The clip is still used by %d active projects", activeProjects)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary List clip usages
+// @Description List the projects whose timeline uses a clip and the compositions generated with it, most recent first. Only projects the user can read are listed
+// @Tags atomic-clips
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/usages [get]
+func (c *AtomicClipController) GetClipUsages(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ClipUsageListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	usages, total, err := c.atomicClipService.GetClipUsages(uint(clipID), userID, &req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "atomic clip not found" {
@@ -264,40 +887,186 @@ func (c *AtomicClipController) DeleteAtomicClip(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "Atomic clip deleted successfully",
+		"usages": usages,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Set clip stock
+// @Description Add a clip to the stock library compositions can draw from, or take it out. Team clips go to their organization's stock library and take an organization admin; personal clips must be public, go to the global stock library and take the admin:stock permission. Clips flagged or rejected by moderation cannot be stock
+// @Tags atomic-clips
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param request body models.ClipStockRequest true "Whether the clip is stock"
+// @Success 200 {object} models.AtomicClip
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/stock [put]
+func (c *AtomicClipController) SetStock(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ClipStockRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	role, _ := middleware.GetUserRole(ctx)
+	canApproveGlobal, err := services.NewRoleService().HasPermission(role, models.PermissionAdminStock)
+	if err != nil {
+		logger.Errorf("Failed to check permission %s: %v", models.PermissionAdminStock, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check permissions",
+		})
+		return
+	}
+	if apiKey, ok := middleware.GetAPIKey(ctx); ok && !apiKey.HasScope(models.PermissionAdminStock) {
+		canApproveGlobal = false
+	}
+
+	before, _ := c.atomicClipService.GetAtomicClipByID(uint(clipID), userID)
+	clip, err := c.atomicClipService.SetStock(uint(clipID), userID, canApproveGlobal, &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "atomic clip not found":
+			statusCode = http.StatusNotFound
+		case "insufficient organization role", "insufficient permissions":
+			statusCode = http.StatusForbidden
+		case "only public clips can be global stock":
+			statusCode = http.StatusBadRequest
+		case "clip is blocked by moderation":
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionClipStock, "clip", clip.ID, before, clip)
+
+	ctx.JSON(http.StatusOK, clip)
+}
+
+// @Summary Batch update atomic clips
+// @Description Delete, tag, recategorize or move many clips at once. Nothing changes unless every clip can be changed; the results report each clip
+// @Tags atomic-clips
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param batch body models.AtomicClipBatchRequest true "Batch action"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/batch [post]
+func (c *AtomicClipController) BatchClips(ctx *gin.Context) {
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.AtomicClipBatchRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	results, err := c.atomicClipService.BatchClips(userID, &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case err.Error() == "one or more clips cannot be changed", err.Error() == "tags are required",
+			err.Error() == "nothing to update", err.Error() == "collection_id is required",
+			strings.HasPrefix(err.Error(), "a collection holds at most"):
+			statusCode = http.StatusBadRequest
+		case err.Error() == "collection not found":
+			statusCode = http.StatusNotFound
+		case err.Error() == "insufficient organization role":
+			statusCode = http.StatusForbidden
+		}
+		response := gin.H{
+			"error": err.Error(),
+		}
+		if results != nil {
+			response["results"] = results
+		}
+		ctx.JSON(statusCode, response)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionClipBatch, "clip", nil, nil, gin.H{
+		"action":   req.Action,
+		"clip_ids": req.ClipIDs,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Clip batch applied successfully",
+		"results": results,
 	})
 }
 
 // @Summary Search atomic clips
-// @Description Search and filter atomic clips
+// @Description Search and filter atomic clips. With a search index configured, relevance-ranked searches tolerate typos
 // @Tags atomic-clips
 // @Produce json
 // @Security BearerAuth
 // @Param query query string false "Search query"
+// @Param mode query string false "keyword matches the words of the query; semantic matches clips by the meaning of a description and ranks them by similarity, ignoring sort_by" default(keyword)
+// @Param library query string false "workspace searches the current workspace, public every public clip, stock the stock clips of the user's organizations and the global ones; semantic search covers the workspace only" default(workspace)
 // @Param category query string false "Filter by category"
 // @Param mood query string false "Filter by mood"
 // @Param style query string false "Filter by style"
 // @Param color query string false "Filter by color"
 // @Param duration query string false "Filter by duration (short/medium/long)"
 // @Param resolution query string false "Filter by resolution"
+// @Param sort query string false "Deprecated: newest, rating or favorites; use sort_by"
+// @Param sort_by query string false "created_at, duration, file_size, quality, usage, rating, favorites or relevance; defaults to relevance with a query, created_at otherwise"
+// @Param sort_order query string false "asc or desc" default(desc)
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Cursor of the next page from the previous response; replaces page and skips the total"
+// @Param facets query bool false "Count the clips of each category, mood, style, resolution and duration, each with every filter but its own; skipped with a cursor"
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Param If-None-Match header string false "ETag of the copy already held; answered with 304 Not Modified while unchanged"
 // @Success 200 {object} map[string]interface{}
+// @Success 304 "Not Modified"
 // @Router /api/v1/atomic-clips/search [get]
 func (c *AtomicClipController) SearchAtomicClips(ctx *gin.Context) {
 	var req models.AtomicClipSearchRequest
-	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid query parameters",
-			"details": err.Error(),
-		})
+	if !bindQuery(ctx, &req) {
 		return
 	}
 
 	userID, _ := middleware.GetUserID(ctx)
 	
-	clips, total, err := c.atomicClipService.SearchAtomicClips(&req, userID)
+	result, err := c.atomicClipService.SearchAtomicClips(&req, userID, middleware.GetOrganizationID(ctx))
 	if err != nil {
+		switch err.Error() {
+		case "invalid cursor", "query is required for semantic search", "semantic search is not enabled",
+			"semantic search only covers the workspace":
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		logger.Errorf("Failed to search atomic clips: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to search atomic clips",
@@ -305,25 +1074,28 @@ func (c *AtomicClipController) SearchAtomicClips(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"clips": clips,
-		"pagination": gin.H{
-			"page":  req.Page,
-			"limit": req.Limit,
-			"total": total,
-			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
-		},
-	})
+	response := gin.H{
+		"clips":      result.Clips,
+		"pagination": cursorPagination(req.Page, req.Limit, result.Total, req.Cursor, result.NextCursor),
+	}
+	if result.Facets != nil {
+		response["facets"] = result.Facets
+	}
+	ctx.JSON(http.StatusOK, response)
 }
 
 // @Summary Get user's atomic clips
-// @Description Get all atomic clips for the authenticated user
+// @Description Get the atomic clips of the current workspace: the user's own, or the shared library of the organization
 // @Tags atomic-clips
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Cursor of the next page from the previous response; replaces page and skips the total"
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Param If-None-Match header string false "ETag of the copy already held; answered with 304 Not Modified while unchanged"
 // @Success 200 {object} map[string]interface{}
+// @Success 304 "Not Modified"
 // @Failure 401 {object} map[string]interface{}
 // @Router /api/v1/atomic-clips/my-clips [get]
 func (c *AtomicClipController) GetUserAtomicClips(ctx *gin.Context) {
@@ -335,11 +1107,19 @@ func (c *AtomicClipController) GetUserAtomicClips(ctx *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	var req models.AtomicClipListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
 
-	clips, total, err := c.atomicClipService.GetUserAtomicClips(userID, page, limit)
+	clips, total, nextCursor, err := c.atomicClipService.GetUserAtomicClips(userID, middleware.GetOrganizationID(ctx), &req)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		logger.Errorf("Failed to get user atomic clips: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get atomic clips",
@@ -348,18 +1128,13 @@ func (c *AtomicClipController) GetUserAtomicClips(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"clips": clips,
-		"pagination": gin.H{
-			"page":  page,
-			"limit": limit,
-			"total": total,
-			"pages": (total + int64(limit) - 1) / int64(limit),
-		},
+		"clips":      clips,
+		"pagination": cursorPagination(req.Page, req.Limit, total, req.Cursor, nextCursor),
 	})
 }
 
 // @Summary Get similar clips
-// @Description Get clips similar to the specified clip
+// @Description Get the clips of the clip's library nearest to its content, or sharing its category, mood and style until it has an embedding
 // @Tags atomic-clips
 // @Produce json
 // @Security BearerAuth
@@ -367,6 +1142,7 @@ func (c *AtomicClipController) GetUserAtomicClips(ctx *gin.Context) {
 // @Param limit query int false "Number of similar clips to return" default(10)
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
 // @Router /api/v1/atomic-clips/{id}/similar [get]
 func (c *AtomicClipController) GetSimilarClips(ctx *gin.Context) {
 	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
@@ -382,9 +1158,15 @@ func (c *AtomicClipController) GetSimilarClips(ctx *gin.Context) {
 		limit = 50 // Max limit
 	}
 
-	clips, err := c.atomicClipService.GetSimilarClips(uint(clipID), limit)
+	userID, _ := middleware.GetUserID(ctx)
+
+	clips, err := c.atomicClipService.GetSimilarClips(uint(clipID), userID, limit)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "atomic clip not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -393,4 +1175,46 @@ func (c *AtomicClipController) GetSimilarClips(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{
 		"clips": clips,
 	})
+}
+
+// @Summary Get duplicate clips
+// @Description Get the clips of the clip's library showing the same footage, such as re-uploads, by the perceptual hash computed when the clip is analyzed
+// @Tags atomic-clips
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/duplicates [get]
+func (c *AtomicClipController) GetDuplicateClips(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	duplicates, err := c.atomicClipService.GetDuplicateClips(uint(clipID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "atomic clip not found":
+			statusCode = http.StatusNotFound
+		case "clip has not been hashed":
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"duplicates": duplicates,
+	})
 }
\ No newline at end of file
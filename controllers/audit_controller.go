@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type AuditController struct {
+	auditService *services.AuditService
+}
+
+func NewAuditController() *AuditController {
+	return &AuditController{
+		auditService: services.NewAuditService(),
+	}
+}
+
+// @Summary Query audit log
+// @Description List audit log entries, newest first (requires admin:audit)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param actor_id query int false "User who acted"
+// @Param organization_id query int false "Organization workspace"
+// @Param action query string false "Action, e.g. clip.delete"
+// @Param resource_type query string false "Resource type, e.g. clip"
+// @Param resource_id query string false "Resource ID"
+// @Param from query string false "Earliest time (RFC 3339)"
+// @Param to query string false "Latest time, exclusive (RFC 3339)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Param cursor query string false "Cursor of the next page from the previous response; replaces page and skips the total"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/audit-logs [get]
+func (c *AuditController) ListAuditLogs(ctx *gin.Context) {
+	var query models.AuditLogQuery
+	if !bindQuery(ctx, &query) {
+		return
+	}
+
+	entries, total, nextCursor, err := c.auditService.QueryAuditLogs(&query)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		logger.Errorf("Failed to query audit logs: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get audit logs",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"entries":    entries,
+		"pagination": cursorPagination(query.Page, query.Limit, total, query.Cursor, nextCursor),
+	})
+}
+
+// @Summary Export audit log
+// @Description Download every audit log entry matching the filters, oldest first, as CSV or JSON lines. Exports are audited too (requires admin:audit)
+// @Tags admin
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "csv or jsonl" default(csv)
+// @Param actor_id query int false "User who acted"
+// @Param organization_id query int false "Organization workspace"
+// @Param action query string false "Action, e.g. clip.delete"
+// @Param resource_type query string false "Resource type, e.g. clip"
+// @Param resource_id query string false "Resource ID"
+// @Param from query string false "Earliest time (RFC 3339)"
+// @Param to query string false "Latest time, exclusive (RFC 3339)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/audit-logs/export [get]
+func (c *AuditController) ExportAuditLogs(ctx *gin.Context) {
+	var query models.AuditLogQuery
+	if !bindQuery(ctx, &query) {
+		return
+	}
+
+	var export struct {
+		Format string `form:"format" binding:"omitempty,oneof=csv jsonl"`
+	}
+	if !bindQuery(ctx, &export) {
+		return
+	}
+	format := export.Format
+	if format == "" {
+		format = "csv"
+	}
+
+	middleware.Audit(ctx, models.AuditActionAuditExport, "audit_log", nil, nil, query)
+
+	filename := fmt.Sprintf("audit-log-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var write func(entries []models.AuditLog) error
+	if format == "csv" {
+		ctx.Header("Content-Type", "text/csv")
+		w := csv.NewWriter(ctx.Writer)
+		if err := w.Write(auditCSVHeader); err != nil {
+			return
+		}
+		write = func(entries []models.AuditLog) error {
+			for _, entry := range entries {
+				if err := w.Write(auditCSVRecord(&entry)); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+		}
+	} else {
+		ctx.Header("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(ctx.Writer)
+		write = func(entries []models.AuditLog) error {
+			for _, entry := range entries {
+				if err := encoder.Encode(entry); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	ctx.Status(http.StatusOK)
+	if err := c.auditService.ExportAuditLogs(&query, write); err != nil {
+		// The response has started, so it can only be cut short
+		logger.Errorf("Failed to export audit logs: %v", err)
+	}
+}
+
+var auditCSVHeader = []string{
+	"id", "created_at", "actor_id", "api_key_id", "organization_id", "action",
+	"resource_type", "resource_id", "before", "after", "ip_address", "user_agent", "request_id",
+}
+
+func auditCSVRecord(entry *models.AuditLog) []string {
+	optional := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return fmt.Sprint(*id)
+	}
+
+	return []string{
+		fmt.Sprint(entry.ID),
+		entry.CreatedAt.UTC().Format(time.RFC3339),
+		optional(entry.ActorID),
+		optional(entry.APIKeyID),
+		optional(entry.OrganizationID),
+		entry.Action,
+		entry.ResourceType,
+		entry.ResourceID,
+		strings.TrimSpace(string(entry.Before)),
+		strings.TrimSpace(string(entry.After)),
+		entry.IPAddress,
+		entry.UserAgent,
+		entry.RequestID,
+	}
+}
@@ -5,23 +5,28 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"creative-studio-server/models"
-	"creative-studio-server/pkg/auth"
+	"creative-studio-server/middleware"
 	"creative-studio-server/services"
+	"creative-studio-server/pkg/auth"
 	"creative-studio-server/pkg/logger"
 )
 
 type AuthController struct {
-	userService *services.UserService
+	userService    *services.UserService
+	tokenService   *services.TokenService
+	accountService *services.AccountService
 }
 
 func NewAuthController() *AuthController {
 	return &AuthController{
-		userService: services.NewUserService(),
+		userService:    services.NewUserService(),
+		tokenService:   services.NewTokenService(),
+		accountService: services.NewAccountService(),
 	}
 }
 
 // @Summary Register a new user
-// @Description Create a new user account
+// @Description Create a new user account and email a link to verify the address
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -32,11 +37,7 @@ func NewAuthController() *AuthController {
 // @Router /api/v1/auth/register [post]
 func (c *AuthController) Register(ctx *gin.Context) {
 	var req models.UserCreateRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
@@ -54,10 +55,13 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		return
 	}
 
-	// Generate token
-	token, err := auth.GenerateToken(user.ID, user.Username, user.Email, user.Role)
+	if err := c.accountService.SendVerificationEmail(user); err != nil {
+		logger.Warnf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	// Generate tokens
+	tokens, err := c.tokenService.IssueTokens(user, ctx.Request.UserAgent(), ctx.ClientIP())
 	if err != nil {
-		logger.Errorf("Failed to generate token: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate authentication token",
 		})
@@ -65,14 +69,16 @@ func (c *AuthController) Register(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{
-		"message": "User registered successfully",
-		"user":    user.ToResponse(),
-		"token":   token,
+		"message":       "User registered successfully",
+		"user":          user.ToResponse(),
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
 // @Summary User login
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return JWT token. Users with two-factor authentication get a challenge token instead, to complete at /auth/2fa/verify.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -80,86 +86,132 @@ func (c *AuthController) Register(ctx *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Router /api/v1/auth/login [post]
 func (c *AuthController) Login(ctx *gin.Context) {
 	var req models.UserLoginRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
 	user, err := c.userService.AuthenticateUser(&req)
 	if err != nil {
 		logger.Warnf("Login failed for %s: %v", req.Email, err)
-		ctx.JSON(http.StatusUnauthorized, gin.H{
+		middleware.AuditUser(ctx, 0, models.AuditActionLoginFailed, "user", nil, nil, gin.H{"email": req.Email, "reason": err.Error()})
+		statusCode := http.StatusUnauthorized
+		if err.Error() == "email address is not verified" {
+			statusCode = http.StatusForbidden
+		}
+		ctx.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	// Generate token
-	token, err := auth.GenerateToken(user.ID, user.Username, user.Email, user.Role)
+	// The password alone is not enough: tokens wait for the second factor
+	if user.TwoFactorEnabledAt != nil {
+		challengeToken, err := auth.GenerateChallengeToken(user.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to start two-factor authentication",
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":             "Two-factor authentication required",
+			"two_factor_required": true,
+			"challenge_token":     challengeToken,
+			"expires_in":          int(auth.ChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	// Generate tokens
+	tokens, err := c.tokenService.IssueTokens(user, ctx.Request.UserAgent(), ctx.ClientIP())
 	if err != nil {
-		logger.Errorf("Failed to generate token: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate authentication token",
 		})
 		return
 	}
+	middleware.AuditUser(ctx, user.ID, models.AuditActionLogin, "user", user.ID, nil, nil)
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"user":    user.ToResponse(),
-		"token":   token,
+		"message":       "Login successful",
+		"user":          user.ToResponse(),
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
 // @Summary Refresh token
-// @Description Refresh JWT token if it's close to expiry
+// @Description Exchange a refresh token for a new access token and refresh token. Each refresh token can be used once.
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Security BearerAuth
-// @Success 200 {object} map[string]interface{}
+// @Param request body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} models.TokenPair
+// @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Router /api/v1/auth/refresh [post]
 func (c *AuthController) RefreshToken(ctx *gin.Context) {
-	// Get token from Authorization header
-	authHeader := ctx.GetHeader("Authorization")
-	if authHeader == "" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authorization header required",
-		})
+	var req models.RefreshTokenRequest
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
-	// Extract token
-	tokenString := ""
-	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		tokenString = authHeader[7:]
+	tokens, _, err := c.tokenService.Refresh(req.RefreshToken, ctx.Request.UserAgent(), ctx.ClientIP())
+	if err != nil {
+		statusCode := http.StatusUnauthorized
+		if err.Error() == "failed to refresh token" {
+			statusCode = http.StatusInternalServerError
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
 
-	if tokenString == "" {
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// @Summary Log out
+// @Description Revoke the current access token and its session, or every session of the user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.LogoutRequest false "Session to end"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/auth/logout [post]
+func (c *AuthController) Logout(ctx *gin.Context) {
+	claims, ok := middleware.GetClaims(ctx)
+	if !ok {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid authorization header format",
+			"error": "User not authenticated",
 		})
 		return
 	}
 
-	// Refresh token
-	newToken, err := auth.RefreshToken(tokenString)
-	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid or expired token",
+	var req models.LogoutRequest
+	if ctx.Request.ContentLength != 0 {
+		if !bindJSON(ctx, &req) {
+			return
+		}
+	}
+
+	if err := c.tokenService.Logout(claims, req.RefreshToken, req.AllDevices); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
+	middleware.Audit(ctx, models.AuditActionLogout, "session", claims.SessionID, nil, gin.H{"all_devices": req.AllDevices})
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"token": newToken,
+		"message": "Logged out successfully",
 	})
 }
 
@@ -218,11 +270,7 @@ func (c *AuthController) ChangePassword(ctx *gin.Context) {
 		NewPassword     string `json:"new_password" binding:"required,min=6"`
 	}
 
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
@@ -233,8 +281,152 @@ func (c *AuthController) ChangePassword(ctx *gin.Context) {
 		})
 		return
 	}
+	middleware.Audit(ctx, models.AuditActionPasswordChange, "user", userID, nil, nil)
 
-	ctx.JSON(http.StatusOK, gin.H{
+	// Sign out every other session; this one continues with new tokens
+	if err := c.tokenService.RevokeAllForUser(userID.(uint)); err != nil {
+		logger.Warnf("Failed to revoke sessions after password change: %v", err)
+	}
+	response := gin.H{
 		"message": "Password changed successfully",
+	}
+	if user, err := c.userService.GetUserByID(userID.(uint)); err == nil {
+		if tokens, err := c.tokenService.IssueTokens(user, ctx.Request.UserAgent(), ctx.ClientIP()); err == nil {
+			response["token"] = tokens.AccessToken
+			response["refresh_token"] = tokens.RefreshToken
+			response["expires_in"] = tokens.ExpiresIn
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Verify email address
+// @Description Verify the email address with the token from the verification email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/auth/verify-email [post]
+func (c *AuthController) VerifyEmail(ctx *gin.Context) {
+	var req models.VerifyEmailRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	user, err := c.accountService.VerifyEmail(req.Token)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "invalid or expired token" {
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Email verified successfully",
+		"user":    user.ToResponse(),
+	})
+}
+
+// @Summary Resend verification email
+// @Description Email a new verification link to the current user, at most once a minute
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/auth/resend-verification [post]
+func (c *AuthController) ResendVerification(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	user, err := c.userService.GetUserByID(userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	if err := c.accountService.SendVerificationEmail(user); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "email is already verified" {
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Verification email sent",
+	})
+}
+
+// @Summary Forgot password
+// @Description Email a password reset link. The response is the same whether or not the address has an account.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Router /api/v1/auth/forgot-password [post]
+func (c *AuthController) ForgotPassword(ctx *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	if err := c.accountService.RequestPasswordReset(req.Email); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "If an account exists for this email, a password reset link has been sent",
 	})
-}
\ No newline at end of file
+}
+
+// @Summary Reset password
+// @Description Set a new password with the token from the password reset email. Every session of the user is signed out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/auth/reset-password [post]
+func (c *AuthController) ResetPassword(ctx *gin.Context) {
+	var req models.ResetPasswordRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	user, err := c.accountService.ResetPassword(req.Token, req.NewPassword)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "invalid or expired token" {
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.AuditUser(ctx, user.ID, models.AuditActionPasswordReset, "user", user.ID, nil, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully",
+	})
+}
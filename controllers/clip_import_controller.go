@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type ClipImportController struct {
+	importService *services.ClipImportService
+}
+
+func NewClipImportController() *ClipImportController {
+	return &ClipImportController{
+		importService: services.NewClipImportService(),
+	}
+}
+
+// @Summary Import clips from a ZIP archive
+// @Description Upload a ZIP of videos to create a clip for each, with titles and tags from an optional manifest. The manifest is a JSON array or a CSV file with a header row (file, title, description, category, tags, mood, style, color), sent as its own file or as manifest.json/manifest.csv at the root of the archive. Clips are created in the background; poll the returned import for per-file status.
+// @Tags atomic-clips
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param archive formData file true "ZIP archive of videos"
+// @Param manifest formData file false "Metadata manifest (.json or .csv)"
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Param Idempotency-Key header string false "Replays the first response to retries sent with the same key"
+// @Success 202 {object} models.ClipImport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/import [post]
+func (c *ClipImportController) CreateImport(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	// Both files are written straight to the import directory
+	form := readUpload(ctx, map[string]string{
+		"archive":  services.ImportArchiveDir(),
+		"manifest": services.ImportArchiveDir(),
+	})
+	if form == nil {
+		return
+	}
+	defer form.RemoveFiles()
+
+	archive := formFile(ctx, form, "archive")
+	if archive == nil {
+		return
+	}
+	// The import takes over the archive
+	delete(form.Files, "archive")
+	// The manifest is optional
+	manifest := form.Files["manifest"]
+
+	clipImport, err := c.importService.CreateImport(userID, middleware.GetOrganizationID(ctx), middleware.GetRequestID(ctx), archive, manifest)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case err.Error() == "insufficient organization role":
+			statusCode = http.StatusForbidden
+		case strings.HasPrefix(err.Error(), "invalid archive"), strings.HasPrefix(err.Error(), "invalid manifest"):
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionClipImport, "clip_import", clipImport.ID, nil, gin.H{
+		"file_name":   clipImport.FileName,
+		"total_files": clipImport.TotalFiles,
+	})
+
+	ctx.JSON(http.StatusAccepted, clipImport)
+}
+
+// @Summary Import a clip from a URL
+// @Description Download a video from an HTTP(S) URL into a clip, so footage does not have to be downloaded and uploaded again. The download runs in the background as an import of one file; poll the returned import for downloaded_bytes and total_bytes, or listen for import.progress events. MP4, MOV, AVI and MKV files up to the configured size are accepted, and URLs must resolve to public addresses. The clip is then analyzed like an upload.
+// @Tags atomic-clips
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.ClipURLImportRequest true "Video URL and clip metadata"
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Param Idempotency-Key header string false "Replays the first response to retries sent with the same key"
+// @Success 202 {object} models.ClipImport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/import-url [post]
+func (c *ClipImportController) CreateURLImport(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ClipURLImportRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	clipImport, err := c.importService.CreateURLImport(userID, middleware.GetOrganizationID(ctx), middleware.GetRequestID(ctx), &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case err.Error() == "insufficient organization role":
+			statusCode = http.StatusForbidden
+		case strings.HasPrefix(err.Error(), "invalid url"):
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionClipImport, "clip_import", clipImport.ID, nil, gin.H{
+		"file_name":  clipImport.FileName,
+		"source_url": clipImport.SourceURL,
+	})
+
+	ctx.JSON(http.StatusAccepted, clipImport)
+}
+
+// @Summary List clip imports
+// @Description List the archive imports of the current workspace, newest first
+// @Tags atomic-clips
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/imports [get]
+func (c *ClipImportController) ListImports(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ClipImportListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	imports, total, err := c.importService.ListImports(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		logger.Errorf("Failed to list imports: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get imports",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"imports": imports,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Get clip import
+// @Description Retrieve an archive import with the status of every file and the clips created; files whose footage was already in the library get a duplicate_of_id once analyzed
+// @Tags atomic-clips
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Import ID"
+// @Success 200 {object} models.ClipImport
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/imports/{id} [get]
+func (c *ClipImportController) GetImport(ctx *gin.Context) {
+	importID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid import ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	clipImport, err := c.importService.GetImport(uint(importID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "import not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, clipImport)
+}
@@ -0,0 +1,299 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type CollectionController struct {
+	collectionService *services.CollectionService
+}
+
+func NewCollectionController() *CollectionController {
+	return &CollectionController{
+		collectionService: services.NewCollectionService(),
+	}
+}
+
+// @Summary Create collection
+// @Description Create a collection of clips in the current workspace, optionally holding clips in the given order
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CollectionCreateRequest true "Collection"
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Success 201 {object} models.Collection
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/collections [post]
+func (c *CollectionController) CreateCollection(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.CollectionCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	collection, err := c.collectionService.CreateCollection(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionCollectionCreate, "collection", collection.ID, nil, collection)
+
+	ctx.JSON(http.StatusCreated, collection)
+}
+
+// @Summary List collections
+// @Description List the collections of the current workspace with their clip counts
+// @Tags collections
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/collections [get]
+func (c *CollectionController) ListCollections(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.CollectionListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	collections, total, err := c.collectionService.ListCollections(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		logger.Errorf("Failed to list collections: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get collections",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"collections": collections,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Get collection
+// @Description Retrieve a collection with its clips in order
+// @Tags collections
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Success 200 {object} models.Collection
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/collections/{id} [get]
+func (c *CollectionController) GetCollection(ctx *gin.Context) {
+	collectionID, ok := parseCollectionID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	collection, err := c.collectionService.GetCollection(collectionID, userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, collection)
+}
+
+// @Summary Update collection
+// @Description Rename a collection or change its description
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Param request body models.CollectionUpdateRequest true "Fields to change"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/collections/{id} [patch]
+func (c *CollectionController) UpdateCollection(ctx *gin.Context) {
+	collectionID, ok := parseCollectionID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.CollectionUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	before, _ := c.collectionService.GetCollection(collectionID, userID)
+	collection, err := c.collectionService.UpdateCollection(collectionID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionCollectionUpdate, "collection", collection.ID, before, collection)
+
+	ctx.JSON(http.StatusOK, collection)
+}
+
+// @Summary Delete collection
+// @Description Delete a collection; its clips stay in the library
+// @Tags collections
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/collections/{id} [delete]
+func (c *CollectionController) DeleteCollection(ctx *gin.Context) {
+	collectionID, ok := parseCollectionID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	before, _ := c.collectionService.GetCollection(collectionID, userID)
+	if err := c.collectionService.DeleteCollection(collectionID, userID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionCollectionDelete, "collection", collectionID, before, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Collection deleted",
+	})
+}
+
+// @Summary Add clips to collection
+// @Description Append clips to the end of a collection; clips already in it are skipped
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Param request body models.CollectionClipsRequest true "Clips to add"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/collections/{id}/clips [post]
+func (c *CollectionController) AddClips(ctx *gin.Context) {
+	c.changeClips(ctx, c.collectionService.AddClips)
+}
+
+// @Summary Set collection clips
+// @Description Replace a collection's clips with the given ones in the given order; use it to reorder
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Param request body models.CollectionClipsRequest true "Every clip of the collection, in order"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/collections/{id}/clips [put]
+func (c *CollectionController) SetClips(ctx *gin.Context) {
+	c.changeClips(ctx, c.collectionService.SetClips)
+}
+
+func (c *CollectionController) changeClips(ctx *gin.Context, change func(collectionID, userID uint, clipIDs []uint) (*models.Collection, error)) {
+	collectionID, ok := parseCollectionID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.CollectionClipsRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	collection, err := change(collectionID, userID, req.ClipIDs)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionCollectionUpdate, "collection", collection.ID, nil, gin.H{"clip_ids": req.ClipIDs})
+
+	ctx.JSON(http.StatusOK, collection)
+}
+
+// @Summary Remove clip from collection
+// @Description Take a clip out of a collection; the clip stays in the library
+// @Tags collections
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Param clip_id path int true "Clip ID"
+// @Success 200 {object} models.Collection
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/collections/{id}/clips/{clip_id} [delete]
+func (c *CollectionController) RemoveClip(ctx *gin.Context) {
+	collectionID, ok := parseCollectionID(ctx)
+	if !ok {
+		return
+	}
+	clipID, err := strconv.ParseUint(ctx.Param("clip_id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	collection, err := c.collectionService.RemoveClip(collectionID, userID, uint(clipID))
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionCollectionUpdate, "collection", collection.ID, gin.H{"clip_id": clipID}, nil)
+
+	ctx.JSON(http.StatusOK, collection)
+}
+
+func parseCollectionID(ctx *gin.Context) (uint, bool) {
+	collectionID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid collection ID",
+		})
+		return 0, false
+	}
+	return uint(collectionID), true
+}
+
+func (c *CollectionController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case err.Error() == "collection not found", err.Error() == "clip is not in the collection":
+		statusCode = http.StatusNotFound
+	case err.Error() == "insufficient organization role":
+		statusCode = http.StatusForbidden
+	case err.Error() == "one or more clips not found", strings.HasPrefix(err.Error(), "a collection holds at most"):
+		statusCode = http.StatusBadRequest
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
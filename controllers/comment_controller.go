@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/services"
+)
+
+type CommentController struct {
+	commentService *services.CommentService
+}
+
+func NewCommentController() *CommentController {
+	return &CommentController{
+		commentService: services.NewCommentService(),
+	}
+}
+
+// @Summary List clip comments
+// @Description List the review comments on a clip in video order
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param resolved query bool false "Only resolved (true) or open (false) comments"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/comments [get]
+func (c *CommentController) ListClipComments(ctx *gin.Context) {
+	c.list(ctx, models.CommentResourceClip)
+}
+
+// @Summary Comment on clip
+// @Description Leave a review comment at a moment of a clip, optionally on a region of the frame. Users mentioned with @username are notified.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param request body models.CommentCreateRequest true "Comment"
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/comments [post]
+func (c *CommentController) CreateClipComment(ctx *gin.Context) {
+	c.create(ctx, models.CommentResourceClip)
+}
+
+// @Summary List render comments
+// @Description List the review comments on a render in video order
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Render task ID"
+// @Param resolved query bool false "Only resolved (true) or open (false) comments"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/render-tasks/{id}/comments [get]
+func (c *CommentController) ListRenderComments(ctx *gin.Context) {
+	c.list(ctx, models.CommentResourceRender)
+}
+
+// @Summary Comment on render
+// @Description Leave a review comment at a moment of a render, optionally on a region of the frame. Users mentioned with @username are notified.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Render task ID"
+// @Param request body models.CommentCreateRequest true "Comment"
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/render-tasks/{id}/comments [post]
+func (c *CommentController) CreateRenderComment(ctx *gin.Context) {
+	c.create(ctx, models.CommentResourceRender)
+}
+
+func (c *CommentController) list(ctx *gin.Context, resourceType string) {
+	resourceID, ok := parseCommentID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.CommentListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	comments, total, err := c.commentService.ListComments(resourceType, resourceID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"comments": comments,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+func (c *CommentController) create(ctx *gin.Context, resourceType string) {
+	resourceID, ok := parseCommentID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.CommentCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	comment, err := c.commentService.CreateComment(resourceType, resourceID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, comment)
+}
+
+// @Summary Update comment
+// @Description Change the text, moment or region of your comment
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Param request body models.CommentUpdateRequest true "Fields to change"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/comments/{id} [patch]
+func (c *CommentController) UpdateComment(ctx *gin.Context) {
+	commentID, ok := parseCommentID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.CommentUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	comment, err := c.commentService.UpdateComment(commentID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, comment)
+}
+
+// @Summary Delete comment
+// @Description Delete your comment
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/comments/{id} [delete]
+func (c *CommentController) DeleteComment(ctx *gin.Context) {
+	commentID, ok := parseCommentID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	if err := c.commentService.DeleteComment(commentID, userID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Comment deleted",
+	})
+}
+
+// @Summary Resolve comment
+// @Description Mark a comment as addressed
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Success 200 {object} models.Comment
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/comments/{id}/resolve [post]
+func (c *CommentController) ResolveComment(ctx *gin.Context) {
+	c.setResolved(ctx, true)
+}
+
+// @Summary Reopen comment
+// @Description Mark a resolved comment as open again
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Success 200 {object} models.Comment
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/comments/{id}/unresolve [post]
+func (c *CommentController) UnresolveComment(ctx *gin.Context) {
+	c.setResolved(ctx, false)
+}
+
+func (c *CommentController) setResolved(ctx *gin.Context, resolved bool) {
+	commentID, ok := parseCommentID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	comment, err := c.commentService.SetResolved(commentID, userID, resolved)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, comment)
+}
+
+func parseCommentID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (c *CommentController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "comment not found", "atomic clip not found", "render task not found":
+		statusCode = http.StatusNotFound
+	case "only the author can change a comment":
+		statusCode = http.StatusForbidden
+	case "timestamp is past the end of the video":
+		statusCode = http.StatusBadRequest
+	case "comment is already resolved", "comment is not resolved":
+		statusCode = http.StatusConflict
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
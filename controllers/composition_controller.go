@@ -0,0 +1,330 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type CompositionController struct {
+	compositionService *services.CompositionService
+}
+
+func NewCompositionController() *CompositionController {
+	return &CompositionController{
+		compositionService: services.NewCompositionService(),
+	}
+}
+
+// @Summary Generate compositions
+// @Description Queue smart composition generation for a project, optionally producing several variants. Set collection_id to compose from a collection's clips only.
+// @Tags compositions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param composition body models.CompositionCreateRequest true "Composition requirements"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id}/compositions [post]
+func (c *CompositionController) GenerateComposition(ctx *gin.Context) {
+	projectID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.CompositionCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	composition, err := c.compositionService.GenerateComposition(uint(projectID), userID, middleware.GetRequestID(ctx), &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "project not found" || err.Error() == "collection not found" {
+			statusCode = http.StatusNotFound
+		} else if strings.HasPrefix(err.Error(), "invalid composition") || err.Error() == "collection is empty" {
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":     "Composition generation queued",
+		"composition": composition,
+	})
+}
+
+// @Summary List project compositions
+// @Description List generated compositions for a project
+// @Tags compositions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id}/compositions [get]
+func (c *CompositionController) ListProjectCompositions(ctx *gin.Context) {
+	projectID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	compositions, total, err := c.compositionService.ListProjectCompositions(uint(projectID), userID, page, limit)
+	if err != nil {
+		if err.Error() == "project not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		logger.Errorf("Failed to list compositions: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get compositions",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"compositions": compositions,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// @Summary Get composition
+// @Description Retrieve a composition with its selected clips, timeline and scores
+// @Tags compositions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Composition ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/compositions/{id} [get]
+func (c *CompositionController) GetComposition(ctx *gin.Context) {
+	compositionID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid composition ID",
+		})
+		return
+	}
+
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	composition, err := c.compositionService.GetCompositionByID(uint(compositionID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "composition not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"composition": composition,
+	})
+}
+
+// @Summary Regenerate composition
+// @Description Queue a new composition using the same requirements as an existing one
+// @Tags compositions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Composition ID"
+// @Success 202 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/compositions/{id}/regenerate [post]
+func (c *CompositionController) RegenerateComposition(ctx *gin.Context) {
+	compositionID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid composition ID",
+		})
+		return
+	}
+
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	composition, err := c.compositionService.RegenerateComposition(uint(compositionID), userID, middleware.GetRequestID(ctx))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "composition not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":     "Composition regeneration queued",
+		"composition": composition,
+	})
+}
+
+// @Summary Promote composition
+// @Description Replace the project timeline with a completed composition
+// @Tags compositions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Composition ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/compositions/{id}/promote [post]
+func (c *CompositionController) PromoteComposition(ctx *gin.Context) {
+	compositionID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid composition ID",
+		})
+		return
+	}
+
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	project, err := c.compositionService.PromoteComposition(uint(compositionID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "composition not found", "project not found":
+			statusCode = http.StatusNotFound
+		case "composition is not ready":
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Composition promoted to project timeline",
+		"project": project,
+	})
+}
+
+// @Summary Submit composition feedback
+// @Description Report the final clip order after editing a composition so future compositions learn from it
+// @Tags compositions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Composition ID"
+// @Param feedback body models.CompositionFeedbackRequest true "Final clip order"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/compositions/{id}/feedback [post]
+func (c *CompositionController) SubmitFeedback(ctx *gin.Context) {
+	compositionID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid composition ID",
+		})
+		return
+	}
+
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.CompositionFeedbackRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	feedback, err := c.compositionService.RecordFeedback(uint(compositionID), userID, req.ClipIDs)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "composition not found":
+			statusCode = http.StatusNotFound
+		case "composition is not ready":
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Feedback recorded",
+		"feedback": feedback,
+	})
+}
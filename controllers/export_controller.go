@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/interchange"
+	"creative-studio-server/services"
+)
+
+type ExportController struct {
+	exportService *services.ExportService
+}
+
+func NewExportController() *ExportController {
+	return &ExportController{
+		exportService: services.NewExportService(),
+	}
+}
+
+// @Summary Export project timeline
+// @Description Download the project timeline as a CMX 3600 EDL, FCPXML or OpenTimelineIO file to finish the edit in Premiere, Resolve or Final Cut. Media is referenced by file name.
+// @Tags projects
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param format query string true "Format (edl/fcpxml/otio)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id}/export [get]
+func (c *ExportController) ExportProject(ctx *gin.Context) {
+	c.export(ctx, c.exportService.ExportProject)
+}
+
+// @Summary Export composition
+// @Description Download a completed composition as a CMX 3600 EDL, FCPXML or OpenTimelineIO file to finish the rough cut in an editor. Media is referenced by file name.
+// @Tags compositions
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path int true "Composition ID"
+// @Param format query string true "Format (edl/fcpxml/otio)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/compositions/{id}/export [get]
+func (c *ExportController) ExportComposition(ctx *gin.Context) {
+	c.export(ctx, c.exportService.ExportComposition)
+}
+
+func (c *ExportController) export(ctx *gin.Context, export func(id, userID uint, format string) ([]byte, string, error)) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.TimelineExportRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	data, filename, err := export(uint(id), userID, req.Format)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "project not found", "composition not found":
+			statusCode = http.StatusNotFound
+		case "composition is not ready":
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, interchange.ContentType(req.Format), data)
+}
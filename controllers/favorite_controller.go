@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type FavoriteController struct {
+	favoriteService *services.FavoriteService
+}
+
+func NewFavoriteController() *FavoriteController {
+	return &FavoriteController{
+		favoriteService: services.NewFavoriteService(),
+	}
+}
+
+// @Summary Favorite clip
+// @Description Add a clip to your favorites
+// @Tags favorites
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Success 200 {object} models.Engagement
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/favorite [post]
+func (c *FavoriteController) FavoriteClip(ctx *gin.Context) {
+	c.change(ctx, models.FavoriteTargetClip, func(targetID, userID uint) (*models.Engagement, error) {
+		return c.favoriteService.Favorite(models.FavoriteTargetClip, targetID, userID)
+	})
+}
+
+// @Summary Unfavorite clip
+// @Description Remove a clip from your favorites
+// @Tags favorites
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Success 200 {object} models.Engagement
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/favorite [delete]
+func (c *FavoriteController) UnfavoriteClip(ctx *gin.Context) {
+	c.change(ctx, models.FavoriteTargetClip, func(targetID, userID uint) (*models.Engagement, error) {
+		return c.favoriteService.Unfavorite(models.FavoriteTargetClip, targetID, userID)
+	})
+}
+
+// @Summary Rate clip
+// @Description Give a clip one to five stars, replacing your earlier rating
+// @Tags favorites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param request body models.RatingRequest true "Score"
+// @Success 200 {object} models.Engagement
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/rating [put]
+func (c *FavoriteController) RateClip(ctx *gin.Context) {
+	c.rate(ctx, models.FavoriteTargetClip)
+}
+
+// @Summary Remove clip rating
+// @Description Withdraw your rating of a clip
+// @Tags favorites
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Success 200 {object} models.Engagement
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/rating [delete]
+func (c *FavoriteController) RemoveClipRating(ctx *gin.Context) {
+	c.change(ctx, models.FavoriteTargetClip, func(targetID, userID uint) (*models.Engagement, error) {
+		return c.favoriteService.RemoveRating(models.FavoriteTargetClip, targetID, userID)
+	})
+}
+
+// @Summary Clip engagement
+// @Description Favorite and rating totals of a clip, with your own favorite and rating
+// @Tags favorites
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Success 200 {object} models.Engagement
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/engagement [get]
+func (c *FavoriteController) GetClipEngagement(ctx *gin.Context) {
+	c.change(ctx, models.FavoriteTargetClip, func(targetID, userID uint) (*models.Engagement, error) {
+		return c.favoriteService.GetEngagement(models.FavoriteTargetClip, targetID, userID)
+	})
+}
+
+// @Summary My favorite clips
+// @Description List the clips you favorited, most recent first
+// @Tags favorites
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/favorites [get]
+func (c *FavoriteController) ListFavoriteClips(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.FavoriteListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	clips, total, err := c.favoriteService.ListFavoriteClips(userID, &req)
+	if err != nil {
+		logger.Errorf("Failed to list favorite clips: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get favorite clips",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"clips":      clips,
+		"pagination": favoritePagination(&req, total),
+	})
+}
+
+// @Summary Favorite template
+// @Description Add a template to your favorites
+// @Tags favorites
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.Engagement
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id}/favorite [post]
+func (c *FavoriteController) FavoriteTemplate(ctx *gin.Context) {
+	c.change(ctx, models.FavoriteTargetTemplate, func(targetID, userID uint) (*models.Engagement, error) {
+		return c.favoriteService.Favorite(models.FavoriteTargetTemplate, targetID, userID)
+	})
+}
+
+// @Summary Unfavorite template
+// @Description Remove a template from your favorites
+// @Tags favorites
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.Engagement
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id}/favorite [delete]
+func (c *FavoriteController) UnfavoriteTemplate(ctx *gin.Context) {
+	c.change(ctx, models.FavoriteTargetTemplate, func(targetID, userID uint) (*models.Engagement, error) {
+		return c.favoriteService.Unfavorite(models.FavoriteTargetTemplate, targetID, userID)
+	})
+}
+
+// @Summary Rate template
+// @Description Give a template one to five stars, replacing your earlier rating
+// @Tags favorites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body models.RatingRequest true "Score"
+// @Success 200 {object} models.Engagement
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id}/rating [put]
+func (c *FavoriteController) RateTemplate(ctx *gin.Context) {
+	c.rate(ctx, models.FavoriteTargetTemplate)
+}
+
+// @Summary Remove template rating
+// @Description Withdraw your rating of a template
+// @Tags favorites
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.Engagement
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id}/rating [delete]
+func (c *FavoriteController) RemoveTemplateRating(ctx *gin.Context) {
+	c.change(ctx, models.FavoriteTargetTemplate, func(targetID, userID uint) (*models.Engagement, error) {
+		return c.favoriteService.RemoveRating(models.FavoriteTargetTemplate, targetID, userID)
+	})
+}
+
+// @Summary Template engagement
+// @Description Favorite and rating totals of a template, with your own favorite and rating
+// @Tags favorites
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.Engagement
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id}/engagement [get]
+func (c *FavoriteController) GetTemplateEngagement(ctx *gin.Context) {
+	c.change(ctx, models.FavoriteTargetTemplate, func(targetID, userID uint) (*models.Engagement, error) {
+		return c.favoriteService.GetEngagement(models.FavoriteTargetTemplate, targetID, userID)
+	})
+}
+
+// @Summary My favorite templates
+// @Description List the templates you favorited, most recent first
+// @Tags favorites
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/templates/favorites [get]
+func (c *FavoriteController) ListFavoriteTemplates(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.FavoriteListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	templates, total, err := c.favoriteService.ListFavoriteTemplates(userID, &req)
+	if err != nil {
+		logger.Errorf("Failed to list favorite templates: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get favorite templates",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"templates":  templates,
+		"pagination": favoritePagination(&req, total),
+	})
+}
+
+func (c *FavoriteController) rate(ctx *gin.Context, targetType string) {
+	var req models.RatingRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	c.change(ctx, targetType, func(targetID, userID uint) (*models.Engagement, error) {
+		return c.favoriteService.Rate(targetType, targetID, userID, req.Score)
+	})
+}
+
+// change parses the clip or template ID and answers with its engagement
+// after the given change.
+func (c *FavoriteController) change(ctx *gin.Context, targetType string, apply func(targetID, userID uint) (*models.Engagement, error)) {
+	targetID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid " + targetType + " ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	engagement, err := apply(uint(targetID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "atomic clip not found", "template not found":
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, engagement)
+}
+
+func favoritePagination(req *models.FavoriteListRequest, total int64) gin.H {
+	return gin.H{
+		"page":  req.Page,
+		"limit": req.Limit,
+		"total": total,
+		"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+	}
+}
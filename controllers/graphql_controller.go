@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/graph"
+	"creative-studio-server/middleware"
+)
+
+type GraphQLController struct {
+	resolver *graph.Resolver
+	server   *handler.Server
+}
+
+func NewGraphQLController() *GraphQLController {
+	resolver := graph.NewResolver()
+	return &GraphQLController{
+		resolver: resolver,
+		server:   graph.NewServer(resolver),
+	}
+}
+
+// @Summary GraphQL query
+// @Description Run a GraphQL query over the user, clips with their analyses, projects and render tasks; the schema is in graph/schema.graphqls and can be introspected. Nested fields are batched, and fields beyond clips need the permissions of the matching REST routes
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body map[string]interface{} true "Query, operationName and variables"
+// @Param X-Organization-ID header int false "Organization workspace lists are taken from; omit for the personal one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Router /api/v1/graphql [get]
+// @Router /api/v1/graphql [post]
+func (c *GraphQLController) Query(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	role, _ := middleware.GetUserRole(ctx)
+	viewer := &graph.Viewer{
+		UserID:         userID,
+		Role:           role,
+		OrganizationID: middleware.GetOrganizationID(ctx),
+	}
+	if apiKey, ok := middleware.GetAPIKey(ctx); ok {
+		viewer.APIKey = apiKey
+	}
+
+	request := ctx.Request.WithContext(c.resolver.WithViewer(ctx.Request.Context(), viewer))
+	c.server.ServeHTTP(ctx.Writer, request)
+}
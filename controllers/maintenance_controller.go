@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/services"
+)
+
+type MaintenanceController struct {
+	maintenanceService *services.MaintenanceService
+}
+
+func NewMaintenanceController() *MaintenanceController {
+	return &MaintenanceController{
+		maintenanceService: services.NewMaintenanceService(),
+	}
+}
+
+// @Summary Get maintenance mode
+// @Description Get the current maintenance mode, so clients can warn users before their writes are refused. Served in every mode, without authentication
+// @Tags maintenance
+// @Produce json
+// @Success 200 {object} models.Maintenance
+// @Router /api/v1/maintenance [get]
+func (c *MaintenanceController) GetMaintenance(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.maintenanceService.GetMaintenance())
+}
+
+// @Summary Update maintenance mode
+// @Description Turn maintenance on or off for every instance within seconds. In read_only mode writes are refused with 503 and a Retry-After header; in full mode every request is, except health checks, sign-in and this endpoint (requires admin:maintenance)
+// @Tags maintenance
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MaintenanceUpdateRequest true "Mode, message, retry delay and expiry"
+// @Success 200 {object} models.Maintenance
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/maintenance [put]
+func (c *MaintenanceController) UpdateMaintenance(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.MaintenanceUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	before := c.maintenanceService.GetMaintenance()
+	maintenance, err := c.maintenanceService.SetMaintenance(userID, &req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionMaintenanceUpdate, "maintenance", nil, before, maintenance)
+
+	ctx.JSON(http.StatusOK, maintenance)
+}
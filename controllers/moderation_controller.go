@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type ModerationController struct {
+	moderationService *services.ModerationService
+}
+
+func NewModerationController() *ModerationController {
+	return &ModerationController{
+		moderationService: services.NewModerationService(),
+	}
+}
+
+// @Summary List moderation queue
+// @Description List clips across every library by moderation status, oldest first; flagged clips await review (requires admin:moderation)
+// @Tags moderation
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Moderation status (pending, approved, flagged, rejected)" default(flagged)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/moderation/clips [get]
+func (c *ModerationController) ListQueue(ctx *gin.Context) {
+	var req models.ModerationQueueRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	clips, total, err := c.moderationService.ListQueue(&req)
+	if err != nil {
+		logger.Errorf("Failed to list moderation queue: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get clips",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"clips": clips,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Review clip
+// @Description Approve a clip, making it visible and composable again, or reject it, keeping it out of public view, the stock library and compositions. The decision stands when the clip is moderated again (requires admin:moderation)
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param request body models.ModerationReviewRequest true "Decision"
+// @Success 200 {object} models.AtomicClip
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/moderation/clips/{id}/review [post]
+func (c *ModerationController) ReviewClip(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ModerationReviewRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	clip, err := c.moderationService.Review(uint(clipID), userID, &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "atomic clip not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionClipModerate, "clip", clip.ID, nil, req)
+
+	ctx.JSON(http.StatusOK, clip)
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/realtime"
+)
+
+// heartbeatInterval keeps idle event streams open through proxies
+const heartbeatInterval = 25 * time.Second
+
+type NotificationController struct {
+	hub *realtime.Hub
+}
+
+func NewNotificationController() *NotificationController {
+	return &NotificationController{
+		hub: realtime.Notifications,
+	}
+}
+
+// @Summary Stream notifications
+// @Description Server-sent event stream of render progress, analysis completion and composition-ready events for the current user
+// @Tags notifications
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param access_token query string false "JWT for clients that cannot set headers (EventSource)"
+// @Success 200 {string} string "event stream"
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/events [get]
+func (c *NotificationController) StreamEvents(ctx *gin.Context) {
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	// The server's WriteTimeout would otherwise cut the stream off
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warnf("Failed to clear write deadline for event stream: %v", err)
+	}
+
+	events, unsubscribe := c.hub.Subscribe(userID)
+	defer unsubscribe()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx.SSEvent("connected", gin.H{"user_id": userID})
+	ctx.Writer.Flush()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			ctx.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			ctx.SSEvent("heartbeat", gin.H{"timestamp": time.Now()})
+			return true
+		}
+	})
+}
@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type OrganizationController struct {
+	organizationService *services.OrganizationService
+}
+
+func NewOrganizationController() *OrganizationController {
+	return &OrganizationController{
+		organizationService: services.NewOrganizationService(),
+	}
+}
+
+// @Summary Create organization
+// @Description Create a team workspace owned by the current user. Send its ID in the X-Organization-ID header to work in it.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.OrganizationCreateRequest true "Organization name and slug"
+// @Success 201 {object} models.Organization
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/organizations [post]
+func (c *OrganizationController) CreateOrganization(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.OrganizationCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	organization, err := c.organizationService.CreateOrganization(userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionOrganizationCreate, "organization", organization.ID, nil, organization)
+
+	ctx.JSON(http.StatusCreated, organization)
+}
+
+// @Summary List organizations
+// @Description List the organizations the current user belongs to, with their role in each, for switching workspaces
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/organizations [get]
+func (c *OrganizationController) ListOrganizations(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	memberships, err := c.organizationService.ListMemberships(userID)
+	if err != nil {
+		logger.Errorf("Failed to list organizations: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get organizations",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"memberships": memberships,
+	})
+}
+
+// @Summary Get organization
+// @Description Retrieve an organization and its members
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Success 200 {object} models.Organization
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/organizations/{id} [get]
+func (c *OrganizationController) GetOrganization(ctx *gin.Context) {
+	organizationID, ok := parseOrganizationID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	organization, err := c.organizationService.GetOrganization(organizationID, userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, organization)
+}
+
+// @Summary Update organization
+// @Description Rename the organization or require two-factor authentication from its members (owners and admins only)
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param request body models.OrganizationUpdateRequest true "Fields to change"
+// @Success 200 {object} models.Organization
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/organizations/{id} [patch]
+func (c *OrganizationController) UpdateOrganization(ctx *gin.Context) {
+	organizationID, ok := parseOrganizationID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.OrganizationUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	organization, err := c.organizationService.UpdateOrganization(organizationID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionOrganizationUpdate, "organization", organization.ID, nil, req)
+
+	ctx.JSON(http.StatusOK, organization)
+}
+
+// @Summary Add organization member
+// @Description Add a registered user to the organization (owners and admins only)
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param request body models.MembershipCreateRequest true "User email and role"
+// @Success 201 {object} models.Membership
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/organizations/{id}/members [post]
+func (c *OrganizationController) AddMember(ctx *gin.Context) {
+	organizationID, ok := parseOrganizationID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.MembershipCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	membership, err := c.organizationService.AddMember(organizationID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionMemberAdd, "membership", membership.ID, nil, membership)
+
+	ctx.JSON(http.StatusCreated, membership)
+}
+
+// @Summary Change organization member role
+// @Description Change the role of a member (owners and admins only)
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param user_id path int true "User ID"
+// @Param request body models.MembershipUpdateRequest true "New role"
+// @Success 200 {object} models.Membership
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/organizations/{id}/members/{user_id} [patch]
+func (c *OrganizationController) UpdateMember(ctx *gin.Context) {
+	organizationID, ok := parseOrganizationID(ctx)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseUint(ctx.Param("user_id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.MembershipUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	membership, err := c.organizationService.UpdateMember(organizationID, userID, uint(memberID), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionMemberUpdate, "membership", membership.ID, nil, membership)
+
+	ctx.JSON(http.StatusOK, membership)
+}
+
+// @Summary Remove organization member
+// @Description Remove a member (owners and admins only), or leave the organization by removing yourself. Their content stays with the organization.
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param user_id path int true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/organizations/{id}/members/{user_id} [delete]
+func (c *OrganizationController) RemoveMember(ctx *gin.Context) {
+	organizationID, ok := parseOrganizationID(ctx)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseUint(ctx.Param("user_id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	if err := c.organizationService.RemoveMember(organizationID, userID, uint(memberID)); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionMemberRemove, "organization", organizationID, gin.H{"user_id": memberID}, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Member removed",
+	})
+}
+
+func parseOrganizationID(ctx *gin.Context) (uint, bool) {
+	organizationID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid organization ID",
+		})
+		return 0, false
+	}
+	return uint(organizationID), true
+}
+
+func (c *OrganizationController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "organization not found", "user not found", "member not found":
+		statusCode = http.StatusNotFound
+	case "insufficient organization role":
+		statusCode = http.StatusForbidden
+	case "organization already exists", "user is already a member",
+		"the owner's role cannot be changed", "the owner cannot leave the organization",
+		"enable two-factor authentication before requiring it":
+		statusCode = http.StatusConflict
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// cursorPagination describes a page of a listing that can be paged by
+// number or by cursor. next_cursor resumes the listing after the page and
+// is empty on the last one. Pages fetched with a cursor are not counted,
+// so they have no page number or total.
+func cursorPagination(page, limit int, total int64, cursor, nextCursor string) gin.H {
+	if cursor != "" {
+		return gin.H{
+			"limit":       limit,
+			"next_cursor": nextCursor,
+		}
+	}
+	return gin.H{
+		"page":        page,
+		"limit":       limit,
+		"total":       total,
+		"pages":       (total + int64(limit) - 1) / int64(limit),
+		"next_cursor": nextCursor,
+	}
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+)
+
+// Content types of the video containers we serve; Go's built-in table does
+// not cover most of them.
+var videoContentTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".mkv":  "video/x-matroska",
+	".avi":  "video/x-msvideo",
+	".ts":   "video/mp2t",
+	".m3u8": "application/vnd.apple.mpegurl",
+}
+
+// servePlayback streams a video file inline with Range support, so browser
+// players can seek without downloading the whole file.
+func servePlayback(ctx *gin.Context, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "File not found",
+		})
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "File not found",
+		})
+		return
+	}
+
+	if contentType, ok := videoContentTypes[strings.ToLower(filepath.Ext(path))]; ok {
+		ctx.Header("Content-Type", contentType)
+	}
+	ctx.Header("Accept-Ranges", "bytes")
+	ctx.Header("Content-Disposition", "inline")
+
+	// ServeContent answers Range and If-Range requests with 206 Partial
+	// Content and handles conditional requests via the modification time
+	http.ServeContent(ctx.Writer, ctx.Request, info.Name(), info.ModTime(), file)
+}
+
+// serveImage sends a JPEG the client may keep for a day, answering
+// requests whose If-None-Match carries its ETag with 304 Not Modified.
+// Access to it is checked, so shared caches may not store it.
+func serveImage(ctx *gin.Context, data []byte, etag string) {
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", "private, max-age=86400")
+
+	if middleware.ETagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "image/jpeg", data)
+}
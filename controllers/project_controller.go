@@ -0,0 +1,427 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type ProjectController struct {
+	projectService *services.ProjectService
+}
+
+func NewProjectController() *ProjectController {
+	return &ProjectController{
+		projectService: services.NewProjectService(),
+	}
+}
+
+// @Summary Create project
+// @Description Create a project in the current workspace, optionally from a template
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.ProjectCreateRequest true "Project details"
+// @Success 201 {object} models.Project
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects [post]
+func (c *ProjectController) CreateProject(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ProjectCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	project, err := c.projectService.CreateProject(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionProjectCreate, "project", project.ID, nil, project)
+
+	ctx.JSON(http.StatusCreated, project)
+}
+
+// @Summary List projects
+// @Description List the projects of the current workspace, most recently updated first. Archived projects are only listed with status=archived.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status (draft/active/archived)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Cursor of the next page from the previous response; replaces page and skips the total"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/projects [get]
+func (c *ProjectController) ListProjects(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ProjectListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	projects, total, nextCursor, err := c.projectService.ListProjects(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		logger.Errorf("Failed to list projects: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get projects",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"projects":   projects,
+		"pagination": cursorPagination(req.Page, req.Limit, total, req.Cursor, nextCursor),
+	})
+}
+
+// @Summary Get project
+// @Description Retrieve a project with its timeline and settings
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.Project
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id} [get]
+func (c *ProjectController) GetProject(ctx *gin.Context) {
+	projectID, ok := parseProjectID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	project, err := c.projectService.GetProjectByID(projectID, userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, project)
+}
+
+// @Summary Update project
+// @Description Change the details, canvas, settings, status or timeline of a project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param request body models.ProjectUpdateRequest true "Fields to change"
+// @Success 200 {object} models.Project
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id} [patch]
+func (c *ProjectController) UpdateProject(ctx *gin.Context) {
+	projectID, ok := parseProjectID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ProjectUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	before, _ := c.projectService.GetProjectByID(projectID, userID)
+	project, err := c.projectService.UpdateProject(projectID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionProjectUpdate, "project", project.ID, before, project)
+
+	ctx.JSON(http.StatusOK, project)
+}
+
+// @Summary Update project timeline
+// @Description Replace the timeline of a project. The timeline is validated against the clips it uses; send the project's version to be told when someone else saved meanwhile.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param request body models.ProjectTimelineUpdateRequest true "Timeline and expected version"
+// @Success 200 {object} models.Project
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/projects/{id}/timeline [put]
+func (c *ProjectController) UpdateTimeline(ctx *gin.Context) {
+	projectID, ok := parseProjectID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ProjectTimelineUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	before, _ := c.projectService.GetProjectByID(projectID, userID)
+	project, err := c.projectService.UpdateTimeline(projectID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionProjectUpdate, "project", project.ID, before, project)
+
+	ctx.JSON(http.StatusOK, project)
+}
+
+// @Summary Archive project
+// @Description Hide a project from listings without deleting it
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.Project
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/projects/{id}/archive [post]
+func (c *ProjectController) ArchiveProject(ctx *gin.Context) {
+	projectID, ok := parseProjectID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	project, err := c.projectService.ArchiveProject(projectID, userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionProjectArchive, "project", project.ID, nil, project)
+
+	ctx.JSON(http.StatusOK, project)
+}
+
+// @Summary Delete project
+// @Description Delete a project
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id} [delete]
+func (c *ProjectController) DeleteProject(ctx *gin.Context) {
+	projectID, ok := parseProjectID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	before, _ := c.projectService.GetProjectByID(projectID, userID)
+	if err := c.projectService.DeleteProject(projectID, userID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionProjectDelete, "project", projectID, before, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Project deleted",
+	})
+}
+
+// @Summary List project revisions
+// @Description List the saved timelines of a project, newest first. Timelines are left out; get a revision for its timeline.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id}/revisions [get]
+func (c *ProjectController) ListRevisions(ctx *gin.Context) {
+	projectID, ok := parseProjectID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ProjectRevisionListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	revisions, total, err := c.projectService.ListRevisions(projectID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"revisions": revisions,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Get project revision
+// @Description Retrieve a revision of a project with its timeline
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param version path int true "Revision version"
+// @Success 200 {object} models.ProjectRevision
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id}/revisions/{version} [get]
+func (c *ProjectController) GetRevision(ctx *gin.Context) {
+	projectID, ok := parseProjectID(ctx)
+	if !ok {
+		return
+	}
+	version, ok := parseRevisionVersion(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	revision, err := c.projectService.GetRevision(projectID, userID, version)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, revision)
+}
+
+// @Summary Compare project revisions
+// @Description List the clips and transitions added, removed or changed between two revisions of a project
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param from query int true "Earlier revision version"
+// @Param to query int true "Later revision version"
+// @Success 200 {object} models.ProjectRevisionDiff
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id}/revisions/diff [get]
+func (c *ProjectController) DiffRevisions(ctx *gin.Context) {
+	projectID, ok := parseProjectID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ProjectRevisionDiffRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	diff, err := c.projectService.DiffRevisions(projectID, userID, req.From, req.To)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, diff)
+}
+
+// @Summary Restore project revision
+// @Description Make a prior revision's timeline the project's current timeline. This saves a new revision; later revisions are kept.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param version path int true "Revision version"
+// @Success 200 {object} models.Project
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id}/revisions/{version}/restore [post]
+func (c *ProjectController) RestoreRevision(ctx *gin.Context) {
+	projectID, ok := parseProjectID(ctx)
+	if !ok {
+		return
+	}
+	version, ok := parseRevisionVersion(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	before, _ := c.projectService.GetProjectByID(projectID, userID)
+	project, err := c.projectService.RestoreRevision(projectID, userID, version)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionProjectRestore, "project", project.ID, before, project)
+
+	ctx.JSON(http.StatusOK, project)
+}
+
+func parseRevisionVersion(ctx *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || version < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid revision version",
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+func parseProjectID(ctx *gin.Context) (uint, bool) {
+	projectID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return 0, false
+	}
+	return uint(projectID), true
+}
+
+func (c *ProjectController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case err.Error() == "project not found", err.Error() == "template not found", err.Error() == "revision not found":
+		statusCode = http.StatusNotFound
+	case err.Error() == "insufficient organization role":
+		statusCode = http.StatusForbidden
+	case err.Error() == "project was changed by someone else", err.Error() == "project is already archived":
+		statusCode = http.StatusConflict
+	case strings.HasPrefix(err.Error(), "invalid timeline"):
+		statusCode = http.StatusBadRequest
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/models"
+	"creative-studio-server/services"
+)
+
+type QueueController struct {
+	queueService *services.QueueService
+}
+
+func NewQueueController() *QueueController {
+	return &QueueController{
+		queueService: services.NewQueueService(),
+	}
+}
+
+// @Summary List queue settings
+// @Description List the worker concurrency, prefetch and retry limit of every task queue (requires admin:queues)
+// @Tags queues
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/queues [get]
+func (c *QueueController) ListQueueSettings(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"queues": c.queueService.ListQueueSettings(),
+	})
+}
+
+// @Summary Update queue settings
+// @Description Change the worker settings of a task queue; running workers apply them without a restart (requires admin:queues)
+// @Tags queues
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Queue name"
+// @Param request body models.QueueSettingsUpdateRequest true "Settings to change"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/queues/{name} [patch]
+func (c *QueueController) UpdateQueueSettings(ctx *gin.Context) {
+	var req models.QueueSettingsUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	settings, err := c.queueService.UpdateQueueSettings(ctx.Param("name"), &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "queue not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Queue settings updated",
+		"queue":    ctx.Param("name"),
+		"settings": settings,
+	})
+}
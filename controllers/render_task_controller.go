@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type RenderTaskController struct {
+	renderTaskService *services.RenderTaskService
+}
+
+func NewRenderTaskController() *RenderTaskController {
+	return &RenderTaskController{
+		renderTaskService: services.NewRenderTaskService(),
+	}
+}
+
+// @Summary Create render task
+// @Description Queue a full or preview render of a project
+// @Tags render-tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RenderTaskCreateRequest true "Render settings"
+// @Param Idempotency-Key header string false "Replays the first response to retries sent with the same key"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/render-tasks [post]
+func (c *RenderTaskController) CreateRenderTask(ctx *gin.Context) {
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.RenderTaskCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	task, err := c.renderTaskService.CreateRenderTask(userID, middleware.GetRequestID(ctx), &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "project not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionRenderCreate, "render_task", task.ID, nil, task)
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":     "Render task queued",
+		"render_task": c.renderTaskService.RenderTaskStatus(*task),
+	})
+}
+
+// @Summary Create render group
+// @Description Queue several outputs of a project (e.g. 1080p and 720p mp4, 9:16 vertical, HLS) as one render job with per-output status
+// @Tags render-tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RenderGroupCreateRequest true "Shared render settings and outputs"
+// @Param Idempotency-Key header string false "Replays the first response to retries sent with the same key"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/render-tasks/batch [post]
+func (c *RenderTaskController) CreateRenderGroup(ctx *gin.Context) {
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.RenderGroupCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	tasks, err := c.renderTaskService.CreateRenderGroup(userID, middleware.GetRequestID(ctx), &req)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "project not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	statuses := make([]models.RenderTaskStatus, 0, len(tasks))
+	for _, task := range tasks {
+		middleware.Audit(ctx, models.AuditActionRenderCreate, "render_task", task.ID, nil, task)
+		statuses = append(statuses, c.renderTaskService.RenderTaskStatus(task))
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":      "Render group queued",
+		"group_id":     tasks[0].GroupID,
+		"render_tasks": statuses,
+	})
+}
+
+// @Summary Estimate render
+// @Description Predict render time (and cost, when pricing is configured) for a render request without submitting it
+// @Tags render-tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RenderTaskCreateRequest true "Render settings"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/render-tasks/estimate [post]
+func (c *RenderTaskController) EstimateRender(ctx *gin.Context) {
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.RenderTaskCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	estimate, err := c.renderTaskService.EstimateRender(userID, &req)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		switch err.Error() {
+		case "project not found":
+			statusCode = http.StatusNotFound
+		case "failed to get project", "failed to load timeline clips":
+			statusCode = http.StatusInternalServerError
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"estimate": estimate,
+	})
+}
+
+// @Summary List render tasks
+// @Description List the current user's render tasks with live status and ETA
+// @Tags render-tasks
+// @Produce json
+// @Security BearerAuth
+// @Param project_id query int false "Filter by project"
+// @Param group_id query string false "Filter by render group"
+// @Param status query string false "Filter by status"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Cursor of the next page from the previous response; replaces page and skips the total"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/render-tasks [get]
+func (c *RenderTaskController) ListRenderTasks(ctx *gin.Context) {
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	projectID, _ := strconv.ParseUint(ctx.Query("project_id"), 10, 32)
+
+	cursor := ctx.Query("cursor")
+
+	tasks, total, nextCursor, err := c.renderTaskService.ListRenderTasks(userID, uint(projectID), ctx.Query("group_id"), ctx.Query("status"), page, limit, cursor)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		logger.Errorf("Failed to list render tasks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get render tasks",
+		})
+		return
+	}
+
+	statuses := make([]models.RenderTaskStatus, 0, len(tasks))
+	for _, task := range tasks {
+		statuses = append(statuses, c.renderTaskService.RenderTaskStatus(task))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"render_tasks": statuses,
+		"pagination":   cursorPagination(page, limit, total, cursor, nextCursor),
+	})
+}
+
+// @Summary Get render task
+// @Description Retrieve a render task with its live status, progress and ETA
+// @Tags render-tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Render task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/render-tasks/{id} [get]
+func (c *RenderTaskController) GetRenderTask(ctx *gin.Context) {
+	c.handleTaskAction(ctx, http.StatusOK, "", "", c.renderTaskService.GetRenderTaskByID)
+}
+
+// @Summary Cancel render task
+// @Description Cancel a pending or processing render task
+// @Tags render-tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Render task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/render-tasks/{id}/cancel [post]
+func (c *RenderTaskController) CancelRenderTask(ctx *gin.Context) {
+	c.handleTaskAction(ctx, http.StatusOK, "Render task cancelled", models.AuditActionRenderCancel, c.renderTaskService.CancelRenderTask)
+}
+
+// @Summary Retry render task
+// @Description Requeue a failed or cancelled render task
+// @Tags render-tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Render task ID"
+// @Success 202 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/render-tasks/{id}/retry [post]
+func (c *RenderTaskController) RetryRenderTask(ctx *gin.Context) {
+	c.handleTaskAction(ctx, http.StatusAccepted, "Render task requeued", models.AuditActionRenderRetry, c.renderTaskService.RetryRenderTask)
+}
+
+// handleTaskAction runs an action on the render task of the request,
+// recording it in the audit log unless auditAction is empty.
+func (c *RenderTaskController) handleTaskAction(ctx *gin.Context, successCode int, message, auditAction string, action func(id, userID uint) (*models.RenderTask, error)) {
+	taskID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid render task ID",
+		})
+		return
+	}
+
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	task, err := action(uint(taskID), userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "render task not found":
+			statusCode = http.StatusNotFound
+		case "render task cannot be cancelled", "render task cannot be retried":
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if auditAction != "" {
+		middleware.Audit(ctx, auditAction, "render_task", task.ID, nil, task)
+	}
+
+	response := gin.H{
+		"render_task": c.renderTaskService.RenderTaskStatus(*task),
+	}
+	if message != "" {
+		response["message"] = message
+	}
+	ctx.JSON(successCode, response)
+}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type RoleController struct {
+	roleService *services.RoleService
+	userService *services.UserService
+}
+
+func NewRoleController() *RoleController {
+	return &RoleController{
+		roleService: services.NewRoleService(),
+		userService: services.NewUserService(),
+	}
+}
+
+// @Summary List roles
+// @Description List roles with their permissions, and every permission a role can grant (requires admin:roles)
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/roles [get]
+func (c *RoleController) ListRoles(ctx *gin.Context) {
+	roles, err := c.roleService.ListRoles()
+	if err != nil {
+		logger.Errorf("Failed to list roles: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get roles",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"roles":       roles,
+		"permissions": models.Permissions,
+	})
+}
+
+// @Summary Create role
+// @Description Create a role with a set of permissions (requires admin:roles)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RoleCreateRequest true "Role name, description and permissions"
+// @Success 201 {object} models.Role
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/roles [post]
+func (c *RoleController) CreateRole(ctx *gin.Context) {
+	var req models.RoleCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	role, err := c.roleService.CreateRole(&req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionRoleCreate, "role", role.Name, nil, role)
+
+	ctx.JSON(http.StatusCreated, role)
+}
+
+// @Summary Update role
+// @Description Change the description or permissions of a role; every instance applies them within a minute. The admin role always grants every permission (requires admin:roles)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Param request body models.RoleUpdateRequest true "Fields to change"
+// @Success 200 {object} models.Role
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/roles/{name} [patch]
+func (c *RoleController) UpdateRole(ctx *gin.Context) {
+	var req models.RoleUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	before, _ := c.roleService.GetRole(ctx.Param("name"))
+	role, err := c.roleService.UpdateRole(ctx.Param("name"), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionRoleUpdate, "role", role.Name, before, role)
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+// @Summary Delete role
+// @Description Delete a custom role that no user has (requires admin:roles)
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/roles/{name} [delete]
+func (c *RoleController) DeleteRole(ctx *gin.Context) {
+	before, _ := c.roleService.GetRole(ctx.Param("name"))
+	if err := c.roleService.DeleteRole(ctx.Param("name")); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionRoleDelete, "role", ctx.Param("name"), before, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Role deleted",
+	})
+}
+
+// @Summary Change user role
+// @Description Assign a role to a user. Their current access tokens are revoked so the new role applies on the next refresh (requires admin:users)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body models.UserRoleUpdateRequest true "New role"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/users/{id}/role [patch]
+func (c *RoleController) UpdateUserRole(ctx *gin.Context) {
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var req models.UserRoleUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	user, previousRole, err := c.roleService.AssignUserRole(uint(userID), req.Role)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionUserRoleChange, "user", user.ID, gin.H{"role": previousRole}, gin.H{"role": req.Role})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "User role updated",
+		"user_id": user.ID,
+		"role":    req.Role,
+	})
+}
+
+// @Summary Change user plan
+// @Description Assign a rate limit plan to a user; an empty plan puts them back on the default one. Applies within five minutes on every instance (requires admin:users)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body models.UserPlanUpdateRequest true "New plan"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/users/{id}/plan [patch]
+func (c *RoleController) UpdateUserPlan(ctx *gin.Context) {
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var req models.UserPlanUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	user, previousPlan, err := c.userService.AssignUserPlan(uint(userID), req.Plan)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionUserPlanChange, "user", user.ID, gin.H{"plan": previousPlan}, gin.H{"plan": req.Plan})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "User plan updated",
+		"user_id": user.ID,
+		"plan":    req.Plan,
+	})
+}
+
+func (c *RoleController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "role not found", "user not found":
+		statusCode = http.StatusNotFound
+	case "invalid permission", "the admin role's permissions cannot be changed", "plan not found":
+		statusCode = http.StatusBadRequest
+	case "role already exists", "system roles cannot be deleted", "role is assigned to users":
+		statusCode = http.StatusConflict
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
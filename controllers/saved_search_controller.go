@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type SavedSearchController struct {
+	savedSearchService *services.SavedSearchService
+}
+
+func NewSavedSearchController() *SavedSearchController {
+	return &SavedSearchController{
+		savedSearchService: services.NewSavedSearchService(),
+	}
+}
+
+// @Summary Save search
+// @Description Save a clip search of the current workspace as a smart collection, optionally notifying its creator of new matches
+// @Tags saved-searches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.SavedSearchCreateRequest true "Saved search"
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Success 201 {object} models.SavedSearch
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/saved-searches [post]
+func (c *SavedSearchController) CreateSavedSearch(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.SavedSearchCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	savedSearch, err := c.savedSearchService.CreateSavedSearch(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionSavedSearchCreate, "saved_search", savedSearch.ID, nil, savedSearch)
+
+	ctx.JSON(http.StatusCreated, savedSearch)
+}
+
+// @Summary List saved searches
+// @Description List the saved searches of the current workspace
+// @Tags saved-searches
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/saved-searches [get]
+func (c *SavedSearchController) ListSavedSearches(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.SavedSearchListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	savedSearches, total, err := c.savedSearchService.ListSavedSearches(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		logger.Errorf("Failed to list saved searches: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get saved searches",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"saved_searches": savedSearches,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Get saved search
+// @Description Retrieve a saved search with its criteria
+// @Tags saved-searches
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Saved search ID"
+// @Success 200 {object} models.SavedSearch
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/saved-searches/{id} [get]
+func (c *SavedSearchController) GetSavedSearch(ctx *gin.Context) {
+	savedSearchID, ok := parseSavedSearchID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	savedSearch, err := c.savedSearchService.GetSavedSearch(savedSearchID, userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, savedSearch)
+}
+
+// @Summary Update saved search
+// @Description Rename a saved search, replace its criteria or turn its new match notifications on or off
+// @Tags saved-searches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Saved search ID"
+// @Param request body models.SavedSearchUpdateRequest true "Fields to change"
+// @Success 200 {object} models.SavedSearch
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/saved-searches/{id} [patch]
+func (c *SavedSearchController) UpdateSavedSearch(ctx *gin.Context) {
+	savedSearchID, ok := parseSavedSearchID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.SavedSearchUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	before, _ := c.savedSearchService.GetSavedSearch(savedSearchID, userID)
+	savedSearch, err := c.savedSearchService.UpdateSavedSearch(savedSearchID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionSavedSearchUpdate, "saved_search", savedSearch.ID, before, savedSearch)
+
+	ctx.JSON(http.StatusOK, savedSearch)
+}
+
+// @Summary Delete saved search
+// @Description Delete a saved search; the clips it matched are unchanged
+// @Tags saved-searches
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Saved search ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/saved-searches/{id} [delete]
+func (c *SavedSearchController) DeleteSavedSearch(ctx *gin.Context) {
+	savedSearchID, ok := parseSavedSearchID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	before, _ := c.savedSearchService.GetSavedSearch(savedSearchID, userID)
+	if err := c.savedSearchService.DeleteSavedSearch(savedSearchID, userID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionSavedSearchDelete, "saved_search", savedSearchID, before, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Saved search deleted",
+	})
+}
+
+// @Summary Run saved search
+// @Description Search the clips a saved search matches now, in the workspace it was saved in
+// @Tags saved-searches
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Saved search ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Cursor of the next page from the previous response; replaces page and skips the total"
+// @Param facets query bool false "Count the clips of each category, mood, style, resolution and duration; skipped with a cursor"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/saved-searches/{id}/clips [get]
+func (c *SavedSearchController) RunSavedSearch(ctx *gin.Context) {
+	savedSearchID, ok := parseSavedSearchID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.SavedSearchRunRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	savedSearch, result, err := c.savedSearchService.RunSavedSearch(savedSearchID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	response := gin.H{
+		"saved_search": savedSearch,
+		"clips":        result.Clips,
+		"pagination":   cursorPagination(req.Page, req.Limit, result.Total, req.Cursor, result.NextCursor),
+	}
+	if result.Facets != nil {
+		response["facets"] = result.Facets
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func parseSavedSearchID(ctx *gin.Context) (uint, bool) {
+	savedSearchID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid saved search ID",
+		})
+		return 0, false
+	}
+	return uint(savedSearchID), true
+}
+
+func (c *SavedSearchController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "saved search not found":
+		statusCode = http.StatusNotFound
+	case "insufficient organization role":
+		statusCode = http.StatusForbidden
+	case "invalid cursor", "query is required for semantic search", "semantic search is not enabled":
+		statusCode = http.StatusBadRequest
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type ScheduleController struct {
+	scheduleService *services.ScheduleService
+}
+
+func NewScheduleController() *ScheduleController {
+	return &ScheduleController{
+		scheduleService: services.NewScheduleService(),
+	}
+}
+
+// @Summary List schedules
+// @Description List recurring jobs with their schedule and last run (requires admin:schedules)
+// @Tags schedules
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/schedules [get]
+func (c *ScheduleController) ListSchedules(ctx *gin.Context) {
+	schedules, err := c.scheduleService.ListSchedules()
+	if err != nil {
+		logger.Errorf("Failed to list schedules: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get schedules",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"schedules": schedules,
+	})
+}
+
+// @Summary Get schedule
+// @Description Retrieve a recurring job by name (requires admin:schedules)
+// @Tags schedules
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Job name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/schedules/{name} [get]
+func (c *ScheduleController) GetSchedule(ctx *gin.Context) {
+	schedule, err := c.scheduleService.GetSchedule(ctx.Param("name"))
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"schedule": schedule,
+	})
+}
+
+// @Summary Update schedule
+// @Description Change the cron spec of a recurring job or enable/disable it (requires admin:schedules)
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Job name"
+// @Param request body models.ScheduleUpdateRequest true "Schedule changes"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/schedules/{name} [patch]
+func (c *ScheduleController) UpdateSchedule(ctx *gin.Context) {
+	var req models.ScheduleUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	schedule, err := c.scheduleService.UpdateSchedule(ctx.Param("name"), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Schedule updated",
+		"schedule": schedule,
+	})
+}
+
+// @Summary Run schedule now
+// @Description Make a recurring job due immediately; it starts within the scheduler tick (requires admin:schedules)
+// @Tags schedules
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Job name"
+// @Success 202 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/schedules/{name}/run [post]
+func (c *ScheduleController) RunSchedule(ctx *gin.Context) {
+	schedule, err := c.scheduleService.TriggerSchedule(ctx.Param("name"))
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":  "Schedule triggered",
+		"schedule": schedule,
+	})
+}
+
+func (c *ScheduleController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "schedule not found":
+		statusCode = http.StatusNotFound
+	case "invalid schedule spec":
+		statusCode = http.StatusBadRequest
+	case "schedule is disabled":
+		statusCode = http.StatusConflict
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
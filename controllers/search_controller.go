@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/services"
+)
+
+type SearchController struct {
+	searchIndexService *services.SearchIndexService
+}
+
+func NewSearchController() *SearchController {
+	return &SearchController{
+		searchIndexService: services.NewSearchIndexService(),
+	}
+}
+
+// @Summary Reindex clips for search
+// @Description Queue the indexing of every clip into the search index, to fill a new index or repair one that missed updates (requires admin:tasks)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/search/reindex [post]
+func (c *SearchController) Reindex(ctx *gin.Context) {
+	if err := c.searchIndexService.Reindex(middleware.GetRequestID(ctx)); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "search index is not enabled" {
+			statusCode = http.StatusConflict
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "Search reindex queued",
+	})
+}
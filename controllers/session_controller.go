@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type SessionController struct {
+	tokenService *services.TokenService
+}
+
+func NewSessionController() *SessionController {
+	return &SessionController{
+		tokenService: services.NewTokenService(),
+	}
+}
+
+// @Summary List sessions
+// @Description List the devices signed in to the account, with where and when each was last seen. The session of the request is marked current.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/auth/sessions [get]
+func (c *SessionController) ListSessions(ctx *gin.Context) {
+	claims, _ := middleware.GetClaims(ctx)
+
+	sessions, err := c.tokenService.ListSessions(claims.UserID, claims.SessionID)
+	if err != nil {
+		logger.Errorf("Failed to list sessions: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get sessions",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// @Summary Revoke session
+// @Description Sign a device out; its refresh and access tokens stop working
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/auth/sessions/{id} [delete]
+func (c *SessionController) RevokeSession(ctx *gin.Context) {
+	claims, _ := middleware.GetClaims(ctx)
+
+	if err := c.tokenService.RevokeSession(claims.UserID, ctx.Param("id")); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "session not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionSessionRevoke, "session", ctx.Param("id"), nil, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Session revoked",
+	})
+}
+
+// @Summary Sign out everywhere
+// @Description Sign out every device, or every other device with keep_current=true
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param keep_current query bool false "Keep the session of this request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/auth/sessions [delete]
+func (c *SessionController) RevokeAllSessions(ctx *gin.Context) {
+	claims, _ := middleware.GetClaims(ctx)
+
+	keepCurrent := ctx.Query("keep_current") == "true" && claims.SessionID != ""
+	var err error
+	if keepCurrent {
+		err = c.tokenService.RevokeOtherSessions(claims.UserID, claims.SessionID)
+	} else {
+		err = c.tokenService.RevokeAllForUser(claims.UserID)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionSessionRevoke, "session", nil, nil, gin.H{"all_devices": true, "keep_current": keepCurrent})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Sessions revoked",
+	})
+}
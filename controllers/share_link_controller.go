@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/services"
+)
+
+// sharePasswordHeader carries the password of a protected share link;
+// players that cannot send headers use the password query parameter
+const sharePasswordHeader = "X-Share-Password"
+
+type ShareLinkController struct {
+	shareLinkService *services.ShareLinkService
+}
+
+func NewShareLinkController() *ShareLinkController {
+	return &ShareLinkController{
+		shareLinkService: services.NewShareLinkService(),
+	}
+}
+
+// @Summary Share atomic clip
+// @Description Create a link that lets anyone holding it view the clip without an account. The token is only returned in this response.
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Param request body models.ShareLinkCreateRequest true "Scope, password and lifetime"
+// @Success 201 {object} models.ShareLinkCreateResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/atomic-clips/{id}/share [post]
+func (c *ShareLinkController) ShareClip(ctx *gin.Context) {
+	c.share(ctx, models.ShareResourceClip)
+}
+
+// @Summary Share composition
+// @Description Create a link that lets anyone holding it view the composition without an account. The token is only returned in this response.
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Composition ID"
+// @Param request body models.ShareLinkCreateRequest true "Scope, password and lifetime"
+// @Success 201 {object} models.ShareLinkCreateResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/compositions/{id}/share [post]
+func (c *ShareLinkController) ShareComposition(ctx *gin.Context) {
+	c.share(ctx, models.ShareResourceComposition)
+}
+
+// @Summary Share render output
+// @Description Create a link that lets anyone holding it watch a completed render without an account. The token is only returned in this response.
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Render task ID"
+// @Param request body models.ShareLinkCreateRequest true "Scope, password and lifetime"
+// @Success 201 {object} models.ShareLinkCreateResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/render-tasks/{id}/share [post]
+func (c *ShareLinkController) ShareRender(ctx *gin.Context) {
+	c.share(ctx, models.ShareResourceRender)
+}
+
+func (c *ShareLinkController) share(ctx *gin.Context, resourceType string) {
+	resourceID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ShareLinkCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	link, token, err := c.shareLinkService.CreateShareLink(userID, resourceType, uint(resourceID), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionShareCreate, "share_link", link.ID, nil, link)
+
+	ctx.JSON(http.StatusCreated, models.ShareLinkCreateResponse{
+		ShareLink: link,
+		Token:     token,
+	})
+}
+
+// @Summary List share links
+// @Description List the share links the current user created, without their tokens
+// @Tags share-links
+// @Produce json
+// @Security BearerAuth
+// @Param resource_type query string false "Filter by resource (clip/composition/render)"
+// @Param resource_id query int false "Filter by resource ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/share-links [get]
+func (c *ShareLinkController) ListShareLinks(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.ShareLinkListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	links, total, err := c.shareLinkService.ListShareLinks(userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"share_links": links,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Revoke share link
+// @Description Stop a share link from working at once
+// @Tags share-links
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Share link ID"
+// @Success 200 {object} models.ShareLink
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/share-links/{id} [delete]
+func (c *ShareLinkController) RevokeShareLink(ctx *gin.Context) {
+	linkID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid share link ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	link, err := c.shareLinkService.RevokeShareLink(uint(linkID), userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionShareRevoke, "share_link", link.ID, nil, link)
+
+	ctx.JSON(http.StatusOK, link)
+}
+
+// @Summary View shared resource
+// @Description Show what a share link points at, without an account. Protected links take their password in the X-Share-Password header.
+// @Tags share-links
+// @Produce json
+// @Param token path string true "Share token"
+// @Param X-Share-Password header string false "Password of a protected link"
+// @Success 200 {object} models.SharedResource
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 410 {object} map[string]interface{}
+// @Router /api/v1/shared/{token} [get]
+func (c *ShareLinkController) ViewShared(ctx *gin.Context) {
+	shared, err := c.shareLinkService.ViewShareLink(ctx.Param("token"), sharePassword(ctx))
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, shared)
+}
+
+// @Summary Stream shared media
+// @Description Play the clip or render a share link points at, with Range support
+// @Tags share-links
+// @Produce octet-stream
+// @Param token path string true "Share token"
+// @Param password query string false "Password of a protected link"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/shared/{token}/stream [get]
+func (c *ShareLinkController) StreamShared(ctx *gin.Context) {
+	path, err := c.shareLinkService.SharedMediaPath(ctx.Param("token"), sharePassword(ctx), false)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	servePlayback(ctx, path)
+}
+
+// @Summary Download shared media
+// @Description Download the clip or render a share link points at; the link needs the download scope
+// @Tags share-links
+// @Produce octet-stream
+// @Param token path string true "Share token"
+// @Param password query string false "Password of a protected link"
+// @Success 200 {file} file
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/shared/{token}/download [get]
+func (c *ShareLinkController) DownloadShared(ctx *gin.Context) {
+	path, err := c.shareLinkService.SharedMediaPath(ctx.Param("token"), sharePassword(ctx), true)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "File not found",
+		})
+		return
+	}
+
+	ctx.FileAttachment(path, filepath.Base(path))
+}
+
+func sharePassword(ctx *gin.Context) string {
+	if password := ctx.GetHeader(sharePasswordHeader); password != "" {
+		return password
+	}
+	return ctx.Query("password")
+}
+
+func (c *ShareLinkController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "share link not found", "atomic clip not found", "composition not found", "render task not found", "shared resource has no media":
+		statusCode = http.StatusNotFound
+	case "share link has expired":
+		statusCode = http.StatusGone
+	case "password required":
+		statusCode = http.StatusUnauthorized
+	case "incorrect password", "share link does not allow downloads", "insufficient organization role":
+		statusCode = http.StatusForbidden
+	case "render is not completed", "share link is already revoked":
+		statusCode = http.StatusConflict
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
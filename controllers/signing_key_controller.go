@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/auth"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type SigningKeyController struct {
+	signingKeyService *services.SigningKeyService
+}
+
+func NewSigningKeyController() *SigningKeyController {
+	return &SigningKeyController{
+		signingKeyService: services.NewSigningKeyService(),
+	}
+}
+
+// @Summary JSON Web Key Set
+// @Description Public keys that verify access tokens, by the kid in the token header
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /.well-known/jwks.json [get]
+func (c *SigningKeyController) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, gin.H{
+		"keys": auth.JWKS(),
+	})
+}
+
+// @Summary List signing keys
+// @Description List the keys that sign access tokens: the active one, retired ones still verifying, and revoked ones (requires admin:keys)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/signing-keys [get]
+func (c *SigningKeyController) ListKeys(ctx *gin.Context) {
+	keys, err := c.signingKeyService.ListKeys()
+	if err != nil {
+		logger.Errorf("Failed to list signing keys: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get signing keys",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"keys": keys,
+	})
+}
+
+// @Summary Rotate signing key
+// @Description Sign new access tokens with a new key. Tokens signed by the previous key stay valid until they expire (requires admin:keys)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} models.SigningKey
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/signing-keys/rotate [post]
+func (c *SigningKeyController) RotateKey(ctx *gin.Context) {
+	key, err := c.signingKeyService.Rotate()
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionSigningKeyRotate, "signing_key", key.KeyID, nil, key)
+
+	ctx.JSON(http.StatusCreated, key)
+}
+
+// @Summary Revoke signing key
+// @Description Stop accepting tokens signed by a key at once, e.g. after it leaked. Revoking the active key rotates first (requires admin:keys)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param kid path string true "Key ID"
+// @Success 200 {object} models.SigningKey
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/signing-keys/{kid}/revoke [post]
+func (c *SigningKeyController) RevokeKey(ctx *gin.Context) {
+	key, err := c.signingKeyService.Revoke(ctx.Param("kid"))
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionSigningKeyRevoke, "signing_key", key.KeyID, nil, key)
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+func (c *SigningKeyController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "signing key not found":
+		statusCode = http.StatusNotFound
+	case "signing key is already revoked":
+		statusCode = http.StatusConflict
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
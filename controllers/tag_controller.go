@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type TagController struct {
+	tagService *services.TagService
+}
+
+func NewTagController() *TagController {
+	return &TagController{
+		tagService: services.NewTagService(),
+	}
+}
+
+// @Summary Autocomplete tags
+// @Description Suggest the most used tags starting with the given text
+// @Tags tags
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Start of the tag"
+// @Param limit query int false "Number of suggestions" default(10)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/tags/autocomplete [get]
+func (c *TagController) Autocomplete(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50 // Max limit
+	}
+
+	tags, err := c.tagService.Autocomplete(ctx.Query("q"), limit)
+	if err != nil {
+		logger.Errorf("Failed to autocomplete tags: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get tags",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"tags": tags,
+	})
+}
+
+// @Summary List tags
+// @Description List every tag with the number of clips carrying it, most used first
+// @Tags tags
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Start of the tag"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/tags [get]
+func (c *TagController) ListTags(ctx *gin.Context) {
+	var req models.TagListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	tags, total, err := c.tagService.ListTags(&req)
+	if err != nil {
+		logger.Errorf("Failed to list tags: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get tags",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"tags": tags,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Rename tag
+// @Description Rename a tag on every clip carrying it. Renaming to an existing tag is refused; merge instead.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tag ID"
+// @Param request body models.TagRenameRequest true "New name"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/tags/{id} [put]
+func (c *TagController) RenameTag(ctx *gin.Context) {
+	tagID, ok := parseTagID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.TagRenameRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	tag, err := c.tagService.RenameTag(tagID, req.Name)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionTagRename, "tag", tag.ID, nil, tag)
+
+	ctx.JSON(http.StatusOK, tag)
+}
+
+// @Summary Merge tag
+// @Description Replace a tag with another on every clip carrying it, then delete it
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tag ID to merge away"
+// @Param request body models.TagMergeRequest true "Tag to keep"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tags/{id}/merge [post]
+func (c *TagController) MergeTag(ctx *gin.Context) {
+	tagID, ok := parseTagID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.TagMergeRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	tag, err := c.tagService.MergeTag(tagID, req.IntoID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionTagMerge, "tag", tagID, nil, tag)
+
+	ctx.JSON(http.StatusOK, tag)
+}
+
+// @Summary Reindex tags
+// @Description Normalize the tags of every clip and rebuild the tag list from them
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/tags/reindex [post]
+func (c *TagController) Reindex(ctx *gin.Context) {
+	count, err := c.tagService.Reindex()
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Tags reindexed",
+		"tags":    count,
+	})
+}
+
+func parseTagID(ctx *gin.Context) (uint, bool) {
+	tagID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid tag ID",
+		})
+		return 0, false
+	}
+	return uint(tagID), true
+}
+
+func (c *TagController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "tag not found":
+		statusCode = http.StatusNotFound
+	case "tag already exists":
+		statusCode = http.StatusConflict
+	case "invalid tag name", "cannot merge a tag into itself":
+		statusCode = http.StatusBadRequest
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type TaskController struct {
+	taskService *services.TaskService
+}
+
+func NewTaskController() *TaskController {
+	return &TaskController{
+		taskService: services.NewTaskService(),
+	}
+}
+
+// @Summary List background tasks
+// @Description List queue tasks with their lifecycle state, attempts and worker (requires admin:tasks)
+// @Tags tasks
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status (queued, running, retrying, succeeded, failed)"
+// @Param type query string false "Filter by task type"
+// @Param queue query string false "Filter by queue"
+// @Param worker_id query string false "Filter by worker"
+// @Param trace_id query string false "Filter by correlation ID of the originating request"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/tasks [get]
+func (c *TaskController) ListTasks(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	tasks, total, err := c.taskService.ListTasks(services.TaskFilter{
+		Status:   ctx.Query("status"),
+		Type:     ctx.Query("type"),
+		Queue:    ctx.Query("queue"),
+		WorkerID: ctx.Query("worker_id"),
+		TraceID:  ctx.Query("trace_id"),
+	}, page, limit)
+	if err != nil {
+		logger.Errorf("Failed to list tasks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get tasks",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"tasks": tasks,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// @Summary Get background task
+// @Description Retrieve a queue task by its task ID, including the payload snapshot and last error (requires admin:tasks)
+// @Tags tasks
+// @Produce json
+// @Security BearerAuth
+// @Param task_id path string true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tasks/{task_id} [get]
+func (c *TaskController) GetTask(ctx *gin.Context) {
+	task, err := c.taskService.GetTask(ctx.Param("task_id"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "task not found" {
+			statusCode = http.StatusNotFound
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"task": task,
+	})
+}
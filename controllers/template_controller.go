@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type TemplateController struct {
+	templateService *services.TemplateService
+}
+
+func NewTemplateController() *TemplateController {
+	return &TemplateController{
+		templateService: services.NewTemplateService(),
+	}
+}
+
+// @Summary Template gallery
+// @Description Browse the published templates of every workspace; no sign-in needed
+// @Tags templates
+// @Produce json
+// @Param query query string false "Search in name and description"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param sort query string false "popular, newest or rating" default(popular)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/templates/gallery [get]
+func (c *TemplateController) Gallery(ctx *gin.Context) {
+	var req models.TemplateListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	templates, total, err := c.templateService.Gallery(&req)
+	c.respondList(ctx, &req, templates, total, err)
+}
+
+// @Summary List templates
+// @Description List the templates of the current workspace, published or not
+// @Tags templates
+// @Produce json
+// @Security BearerAuth
+// @Param query query string false "Search in name and description"
+// @Param category query string false "Filter by category"
+// @Param tag query string false "Filter by tag"
+// @Param sort query string false "popular, newest or rating" default(popular)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/templates [get]
+func (c *TemplateController) ListTemplates(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.TemplateListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	templates, total, err := c.templateService.ListTemplates(userID, middleware.GetOrganizationID(ctx), &req)
+	c.respondList(ctx, &req, templates, total, err)
+}
+
+func (c *TemplateController) respondList(ctx *gin.Context, req *models.TemplateListRequest, templates []models.Template, total int64, err error) {
+	if err != nil {
+		logger.Errorf("Failed to list templates: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get templates",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Create template
+// @Description Create a template in the current workspace; set is_public to publish it to the gallery
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TemplateCreateRequest true "Template"
+// @Success 201 {object} models.Template
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/templates [post]
+func (c *TemplateController) CreateTemplate(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.TemplateCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	template, err := c.templateService.CreateTemplate(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionTemplateCreate, "template", template.ID, nil, template)
+
+	ctx.JSON(http.StatusCreated, template)
+}
+
+// @Summary Get template
+// @Description Retrieve a published template or one of your workspaces
+// @Tags templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.Template
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id} [get]
+func (c *TemplateController) GetTemplate(ctx *gin.Context) {
+	templateID, ok := parseTemplateID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	template, err := c.templateService.GetTemplate(templateID, userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, template)
+}
+
+// @Summary Update template
+// @Description Change a template of your workspace
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body models.TemplateUpdateRequest true "Fields to change"
+// @Success 200 {object} models.Template
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id} [patch]
+func (c *TemplateController) UpdateTemplate(ctx *gin.Context) {
+	templateID, ok := parseTemplateID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.TemplateUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	before, _ := c.templateService.GetTemplate(templateID, userID)
+	template, err := c.templateService.UpdateTemplate(templateID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionTemplateUpdate, "template", template.ID, before, template)
+
+	ctx.JSON(http.StatusOK, template)
+}
+
+// @Summary Publish template
+// @Description List a template of your workspace in the public gallery
+// @Tags templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.Template
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id}/publish [post]
+func (c *TemplateController) PublishTemplate(ctx *gin.Context) {
+	c.setPublic(ctx, true)
+}
+
+// @Summary Unpublish template
+// @Description Withdraw a template from the public gallery. Projects already created from it keep their copy.
+// @Tags templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.Template
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id}/unpublish [post]
+func (c *TemplateController) UnpublishTemplate(ctx *gin.Context) {
+	c.setPublic(ctx, false)
+}
+
+func (c *TemplateController) setPublic(ctx *gin.Context, public bool) {
+	templateID, ok := parseTemplateID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	template, err := c.templateService.SetPublic(templateID, userID, public)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionTemplatePublish, "template", template.ID, nil, gin.H{"is_public": public})
+
+	ctx.JSON(http.StatusOK, template)
+}
+
+// @Summary Delete template
+// @Description Delete a template of your workspace. Projects created from it keep their copy.
+// @Tags templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id} [delete]
+func (c *TemplateController) DeleteTemplate(ctx *gin.Context) {
+	templateID, ok := parseTemplateID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	before, _ := c.templateService.GetTemplate(templateID, userID)
+	if err := c.templateService.DeleteTemplate(templateID, userID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionTemplateDelete, "template", templateID, before, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Template deleted",
+	})
+}
+
+// @Summary Use template
+// @Description Create a project in the current workspace from a template, copying its canvas, timeline and settings
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body models.TemplateUseRequest true "Project title"
+// @Success 201 {object} models.Project
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/templates/{id}/use [post]
+func (c *TemplateController) UseTemplate(ctx *gin.Context) {
+	templateID, ok := parseTemplateID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.TemplateUseRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	project, err := c.templateService.UseTemplate(templateID, userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionProjectCreate, "project", project.ID, nil, project)
+
+	ctx.JSON(http.StatusCreated, project)
+}
+
+func parseTemplateID(ctx *gin.Context) (uint, bool) {
+	templateID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid template ID",
+		})
+		return 0, false
+	}
+	return uint(templateID), true
+}
+
+func (c *TemplateController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case err.Error() == "template not found":
+		statusCode = http.StatusNotFound
+	case err.Error() == "insufficient organization role":
+		statusCode = http.StatusForbidden
+	case strings.HasPrefix(err.Error(), "invalid timeline"):
+		statusCode = http.StatusBadRequest
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
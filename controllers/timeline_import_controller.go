@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/services"
+)
+
+type TimelineImportController struct {
+	importService *services.TimelineImportService
+}
+
+func NewTimelineImportController() *TimelineImportController {
+	return &TimelineImportController{
+		importService: services.NewTimelineImportService(),
+	}
+}
+
+// @Summary Import project from an editor timeline
+// @Description Upload an FCPXML or OpenTimelineIO file to create a project with its timeline. Media is relinked to readable clips: the clip a file was exported from, then a clip of the same file name, then one titled like the media. Uses of media no clip matches, or cut past the end of the clip that does, are left out and listed in unresolved_media.
+// @Tags projects
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "FCPXML or OpenTimelineIO file"
+// @Param format formData string false "Format (fcpxml/otio); defaults to the one of the file extension"
+// @Param title formData string false "Project title; defaults to the timeline name"
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Success 201 {object} models.TimelineImportResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/projects/import [post]
+func (c *TimelineImportController) ImportProject(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.TimelineImportRequest
+	if !bindForm(ctx, &req) {
+		return
+	}
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		invalidField(ctx, "file", "required")
+		return
+	}
+
+	result, err := c.importService.ImportProject(userID, middleware.GetOrganizationID(ctx), &req, file)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case err.Error() == "insufficient organization role":
+			statusCode = http.StatusForbidden
+		case strings.HasPrefix(err.Error(), "invalid timeline file"):
+			statusCode = http.StatusBadRequest
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionProjectImport, "project", result.Project.ID, nil, gin.H{
+		"file_name":        file.Filename,
+		"imported_clips":   result.ImportedClips,
+		"unresolved_media": len(result.UnresolvedMedia),
+	})
+
+	ctx.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type TrashController struct {
+	trashService *services.TrashService
+}
+
+func NewTrashController() *TrashController {
+	return &TrashController{
+		trashService: services.NewTrashService(),
+	}
+}
+
+// @Summary List deleted clips
+// @Description List the deleted clips of the current workspace, most recently deleted first, with when each is purged
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/trash/clips [get]
+func (c *TrashController) ListClips(ctx *gin.Context) {
+	c.list(ctx, c.trashService.ListClips)
+}
+
+// @Summary List deleted projects
+// @Description List the deleted projects of the current workspace, most recently deleted first, with when each is purged
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/trash/projects [get]
+func (c *TrashController) ListProjects(ctx *gin.Context) {
+	c.list(ctx, c.trashService.ListProjects)
+}
+
+func (c *TrashController) list(ctx *gin.Context, list func(uint, *uint, *models.TrashListRequest) ([]models.TrashItem, int64, error)) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.TrashListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	items, total, err := list(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		logger.Errorf("Failed to list trash: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get trash",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Restore clip
+// @Description Bring a deleted clip back to its library; collections it was in do not get it back
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Clip ID"
+// @Success 200 {object} models.AtomicClip
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/trash/clips/{id}/restore [post]
+func (c *TrashController) RestoreClip(ctx *gin.Context) {
+	clipID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clip ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	clip, err := c.trashService.RestoreClip(uint(clipID), userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionClipUndelete, "clip", clip.ID, nil, clip)
+
+	ctx.JSON(http.StatusOK, clip)
+}
+
+// @Summary Restore project
+// @Description Bring a deleted project back with its timeline, renders and revisions
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.Project
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/trash/projects/{id}/restore [post]
+func (c *TrashController) RestoreProject(ctx *gin.Context) {
+	projectID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	project, err := c.trashService.RestoreProject(uint(projectID), userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionProjectUndelete, "project", project.ID, nil, project)
+
+	ctx.JSON(http.StatusOK, project)
+}
+
+func (c *TrashController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "deleted clip not found", "deleted project not found":
+		statusCode = http.StatusNotFound
+	case "insufficient organization role":
+		statusCode = http.StatusForbidden
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/services"
+)
+
+type TwoFactorController struct {
+	twoFactorService *services.TwoFactorService
+	tokenService     *services.TokenService
+}
+
+func NewTwoFactorController() *TwoFactorController {
+	return &TwoFactorController{
+		twoFactorService: services.NewTwoFactorService(),
+		tokenService:     services.NewTokenService(),
+	}
+}
+
+// @Summary Start two-factor setup
+// @Description Generate a TOTP secret for an authenticator app; show otpauth_url as a QR code. Confirm with /auth/2fa/enable.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TwoFactorSetupResponse
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/auth/2fa/setup [post]
+func (c *TwoFactorController) Setup(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	setup, err := c.twoFactorService.Setup(userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, setup)
+}
+
+// @Summary Enable two-factor authentication
+// @Description Confirm setup with a code from the authenticator app. Returns recovery codes, which are only shown once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/auth/2fa/enable [post]
+func (c *TwoFactorController) Enable(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.TwoFactorCodeRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	codes, err := c.twoFactorService.Enable(userID, req.Code)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionTwoFactorEnable, "user", userID, nil, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off with the password and a TOTP or recovery code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorDisableRequest true "Password and code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/auth/2fa/disable [post]
+func (c *TwoFactorController) Disable(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.TwoFactorDisableRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	if err := c.twoFactorService.Disable(userID, req.Password, req.Code); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionTwoFactorDisable, "user", userID, nil, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// @Summary Regenerate recovery codes
+// @Description Replace the recovery codes; the previous ones stop working
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/auth/2fa/recovery-codes [post]
+func (c *TwoFactorController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.TwoFactorCodeRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	codes, err := c.twoFactorService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// @Summary Complete two-factor login
+// @Description Exchange the challenge token from /auth/login and a TOTP or recovery code for access and refresh tokens. A challenge may be tried 5 times; after that the login must be started again.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorVerifyRequest true "Challenge token and code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/auth/2fa/verify [post]
+func (c *TwoFactorController) Verify(ctx *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	user, err := c.twoFactorService.VerifyChallenge(req.ChallengeToken, req.Code)
+	if err != nil {
+		middleware.AuditUser(ctx, 0, models.AuditActionLoginFailed, "user", nil, nil, gin.H{"reason": err.Error(), "two_factor": true})
+		statusCode := http.StatusUnauthorized
+		if err.Error() == "failed to verify two-factor code" {
+			statusCode = http.StatusInternalServerError
+		}
+		ctx.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tokens, err := c.tokenService.IssueTokens(user, ctx.Request.UserAgent(), ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate authentication token",
+		})
+		return
+	}
+	middleware.AuditUser(ctx, user.ID, models.AuditActionLogin, "user", user.ID, nil, gin.H{"two_factor": true})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":       "Login successful",
+		"user":          user.ToResponse(),
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+func (c *TwoFactorController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.Error() {
+	case "user not found":
+		statusCode = http.StatusNotFound
+	case "invalid two-factor code", "password is incorrect", "two-factor setup has not been started":
+		statusCode = http.StatusBadRequest
+	case "two-factor authentication is already enabled", "two-factor authentication is not enabled":
+		statusCode = http.StatusConflict
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/upload"
+)
+
+// readUpload reads a multipart upload, storing the file of each field in
+// dirs as it arrives. When the upload cannot be read it answers the request
+// and returns nil.
+func readUpload(ctx *gin.Context, dirs map[string]string) *upload.Form {
+	form, err := upload.Read(ctx.Request, dirs)
+	if err == nil {
+		return form
+	}
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Upload must be at most %d bytes", tooLarge.Limit),
+		})
+	case errors.Is(err, upload.ErrStorage):
+		logger.Errorf("Failed to store upload: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save file",
+		})
+	default:
+		validationFailed(ctx, "Failed to parse multipart form", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/services"
+)
+
+type UsageController struct {
+	quotaService *services.QuotaService
+}
+
+func NewUsageController() *UsageController {
+	return &UsageController{
+		quotaService: services.NewQuotaService(),
+	}
+}
+
+// @Summary Get usage
+// @Description Get the user's usage of each quota of their plan: uploads today and this month, render minutes this month and storage. A limit of 0 is unlimited; days and months are counted in UTC
+// @Tags usage
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.Usage
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/usage [get]
+func (c *UsageController) GetUsage(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	usage, err := c.quotaService.GetUsage(userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, usage)
+}
+
+// @Summary Get user usage
+// @Description Get a user's usage of each quota of their plan (requires admin:users)
+// @Tags usage
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.Usage
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/users/{id}/usage [get]
+func (c *UsageController) GetUserUsage(ctx *gin.Context) {
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	usage, err := c.quotaService.GetUsage(uint(userID))
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, usage)
+}
+
+func (c *UsageController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	if err.Error() == "user not found" {
+		statusCode = http.StatusNotFound
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/upload"
+	"creative-studio-server/pkg/validation"
+)
+
+// bindJSON binds the JSON body of the request to obj and validates it.
+// When the body is not valid it answers the request and returns false.
+func bindJSON(ctx *gin.Context, obj interface{}) bool {
+	if err := ctx.ShouldBindJSON(obj); err != nil {
+		validationFailed(ctx, "Invalid request data", err)
+		return false
+	}
+	return true
+}
+
+// bindQuery binds the query parameters of the request to obj and validates
+// them. When they are not valid it answers the request and returns false.
+func bindQuery(ctx *gin.Context, obj interface{}) bool {
+	if err := ctx.ShouldBindQuery(obj); err != nil {
+		validationFailed(ctx, "Invalid query parameters", err)
+		return false
+	}
+	return true
+}
+
+// bindForm binds the form fields of the request, multipart ones included,
+// to obj and validates them. When they are not valid it answers the
+// request and returns false.
+func bindForm(ctx *gin.Context, obj interface{}) bool {
+	if err := ctx.ShouldBind(obj); err != nil {
+		validationFailed(ctx, "Invalid request data", err)
+		return false
+	}
+	return true
+}
+
+// validateRequest validates a request built by hand, such as from a
+// multipart form. When it is not valid it answers the request and returns
+// false.
+func validateRequest(ctx *gin.Context, obj interface{}) bool {
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		validationFailed(ctx, "Invalid request data", err)
+		return false
+	}
+	return true
+}
+
+// formFile returns the file uploaded in a multipart field. When it was not
+// sent it answers the request and returns nil.
+func formFile(ctx *gin.Context, form *upload.Form, field string) *upload.File {
+	file, ok := form.Files[field]
+	if !ok {
+		invalidField(ctx, field, "required")
+	}
+	return file
+}
+
+// invalidField answers a request one of whose fields broke a rule the
+// controller checks itself.
+func invalidField(ctx *gin.Context, field, rule string) {
+	ctx.JSON(http.StatusBadRequest, models.ValidationErrorResponse{
+		Error:  "Invalid request data",
+		Fields: []models.FieldError{validation.Field(field, rule, locale(ctx))},
+	})
+}
+
+// validationFailed answers a request that could not be bound, with why for
+// each field in the language the client accepts.
+func validationFailed(ctx *gin.Context, message string, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Request body must be at most %d bytes", tooLarge.Limit),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusBadRequest, models.ValidationErrorResponse{
+		Error:  message,
+		Fields: validation.Errors(err, locale(ctx)),
+	})
+}
+
+func locale(ctx *gin.Context) string {
+	return validation.Locale(ctx.GetHeader("Accept-Language"))
+}
@@ -1,8 +1,8 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -10,76 +10,89 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"creative-studio-server/config"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/auth"
 	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/validation"
+	"creative-studio-server/services"
 	"creative-studio-server/pkg/video_engine"
 )
 
 type VideoController struct {
-	ffmpegProcessor *video_engine.FFmpegProcessor
+	ffmpegProcessor  *video_engine.FFmpegProcessor
+	videoFileService *services.VideoFileService
 }
 
 func NewVideoController() *VideoController {
 	cfg := config.AppConfig
 	return &VideoController{
-		ffmpegProcessor: video_engine.NewFFmpegProcessor(cfg),
+		ffmpegProcessor:  video_engine.NewFFmpegProcessor(cfg),
+		videoFileService: services.NewVideoFileService(),
 	}
 }
 
 // 上传视频文件
+// @Summary Upload video file
+// @Description Upload a video to the user's uploads directory for concatenation. Served in simple mode too, without authentication
+// @Tags videos
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param video formData file true "Video file"
+// @Param Idempotency-Key header string false "Replays the first response to retries sent with the same key"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/videos/upload [post]
 func (vc *VideoController) UploadVideo(c *gin.Context) {
-	// 解析表单数据
-	err := c.Request.ParseMultipartForm(500 << 20) // 500MB max
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to parse form data",
+	// 读取表单, 视频直接写入用户的上传目录
+	userID, _ := middleware.GetUserID(c)
+	uploadDir := vc.videoFileService.Dir(models.VideoFileUpload, userID)
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		logger.Errorf("Failed to create upload directory: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save file",
 		})
 		return
 	}
+	form := readUpload(c, map[string]string{"video": uploadDir})
+	if form == nil {
+		return
+	}
+	defer form.RemoveFiles()
 
 	// 获取上传的文件
-	file, header, err := c.Request.FormFile("video")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "No video file provided",
-		})
+	file := formFile(c, form, "video")
+	if file == nil {
 		return
 	}
-	defer file.Close()
 
 	// 验证文件类型
-	contentType := header.Header.Get("Content-Type")
-	if !isValidVideoType(contentType) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid file type. Only video files are allowed",
-		})
+	if !isValidVideoType(file.ContentType) {
+		invalidField(c, "video", validation.RuleVideoFile)
 		return
 	}
 
-	// 创建上传目录
-	uploadDir := "./uploads"
-	os.MkdirAll(uploadDir, 0755)
-
 	// 生成文件名
 	timestamp := time.Now().Unix()
-	filename := fmt.Sprintf("%d_%s", timestamp, header.Filename)
+	filename := fmt.Sprintf("%d_%s", timestamp, services.SafeFilename(file.Filename))
 	filePath := filepath.Join(uploadDir, filename)
 
 	// 保存文件
-	dst, err := os.Create(filePath)
-	if err != nil {
-		logger.Errorf("Failed to create file: %v", err)
+	if err := os.Rename(file.Path, filePath); err != nil {
+		logger.Errorf("Failed to save file: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to save file",
 		})
 		return
 	}
-	defer dst.Close()
-
-	_, err = io.Copy(dst, file)
-	if err != nil {
-		logger.Errorf("Failed to save file: %v", err)
+	if err := vc.videoFileService.Record(models.VideoFileUpload, userID, filename, file.Size); err != nil {
+		os.Remove(filePath)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to save file",
+			"error": err.Error(),
 		})
 		return
 	}
@@ -105,32 +118,42 @@ func (vc *VideoController) UploadVideo(c *gin.Context) {
 }
 
 // 拼接视频
+// @Summary Concatenate videos
+// @Description Concatenate the user's uploaded videos, in order, into one output file. The response carries signed URLs to download and play it
+// @Tags videos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body map[string]interface{} true "files (at least two uploaded file names), output_name and quality"
+// @Param Idempotency-Key header string false "Replays the first response to retries sent with the same key"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/videos/concatenate [post]
 func (vc *VideoController) ConcatenateVideos(c *gin.Context) {
 	var request struct {
-		Files []string `json:"files" binding:"required"`
+		Files []string `json:"files" binding:"required,min=2"`
 		OutputName string `json:"output_name"`
 		Quality string `json:"quality"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-		})
-		return
-	}
-
-	if len(request.Files) < 2 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "At least 2 files are required for concatenation",
-		})
+	if !bindJSON(c, &request) {
 		return
 	}
 
-	// 验证文件存在
+	// 验证文件存在且属于当前用户
+	userID, _ := middleware.GetUserID(c)
 	var inputPaths []string
 	for _, filename := range request.Files {
-		filePath := filepath.Join("./uploads", filename)
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		filePath, err := vc.videoFileService.Path(models.VideoFileUpload, userID, filename)
+		if err != nil {
+			if err.Error() == "failed to get file" {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": fmt.Sprintf("File not found: %s", filename),
 			})
@@ -147,9 +170,14 @@ func (vc *VideoController) ConcatenateVideos(c *gin.Context) {
 	if filepath.Ext(outputName) == "" {
 		outputName += ".mp4"
 	}
+	if !services.ValidFilename(outputName) {
+		invalidField(c, "output_name", validation.RuleFileName)
+		return
+	}
 
-	outputPath := filepath.Join("./output", outputName)
-	os.MkdirAll("./output", 0755)
+	outputDir := vc.videoFileService.Dir(models.VideoFileOutput, userID)
+	outputPath := filepath.Join(outputDir, outputName)
+	os.MkdirAll(outputDir, 0755)
 
 	// 设置渲染选项
 	options := &video_engine.RenderOptions{
@@ -175,38 +203,117 @@ func (vc *VideoController) ConcatenateVideos(c *gin.Context) {
 	fileInfo, err := os.Stat(outputPath)
 	if err != nil {
 		logger.Errorf("Failed to get output file info: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to concatenate videos",
+		})
+		return
 	}
 
 	logger.Infof("Video concatenation completed: %s", outputName)
 
+	if err := vc.videoFileService.Record(models.VideoFileOutput, userID, outputName, fileInfo.Size()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	urls, err := vc.videoFileService.CreateDownloadURLs(outputName, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":     "Videos concatenated successfully",
 		"output_file": outputName,
 		"output_path": outputPath,
 		"file_size":   fileInfo.Size(),
-		"download_url": fmt.Sprintf("/api/v1/video/download/%s", outputName),
+		"download_url": urls.DownloadURL,
+		"play_url":    urls.PlayURL,
+		"expires_at":  urls.ExpiresAt,
 	})
 }
 
-// 下载拼接后的视频
-func (vc *VideoController) DownloadVideo(c *gin.Context) {
-	filename := c.Param("filename")
-	if filename == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Filename is required",
-		})
+// 生成签名下载链接
+// @Summary Create download URLs
+// @Description Sign URLs that download and play an output video of the user without credentials until they expire
+// @Tags videos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.DownloadURLRequest true "Output file name"
+// @Success 200 {object} models.DownloadURLs
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/videos/download-url [post]
+func (vc *VideoController) CreateDownloadURL(c *gin.Context) {
+	var req models.DownloadURLRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	filePath := filepath.Join("./output", filename)
-	
-	// 验证文件存在
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "File not found",
-		})
+	userID, _ := middleware.GetUserID(c)
+	urls, err := vc.videoFileService.CreateDownloadURLs(req.Filename, userID)
+	if err != nil {
+		videoFileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, urls)
+}
+
+// verifyDownloadURL checks the signature of a download or play URL and
+// returns the path of the video. When it is not valid it answers the
+// request and returns "".
+func (vc *VideoController) verifyDownloadURL(c *gin.Context) string {
+	path, err := vc.videoFileService.VerifyDownloadURL(c.Param("filename"), c.Request.URL)
+	if err != nil {
+		videoFileError(c, err)
+		return ""
+	}
+	return path
+}
+
+// videoFileError answers a request whose video file could not be used.
+func videoFileError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, auth.ErrInvalidDownloadURL), errors.Is(err, auth.ErrDownloadURLExpired):
+		status = http.StatusForbidden
+	case err.Error() == "invalid file name":
+		status = http.StatusBadRequest
+	case err.Error() == "file not found":
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+	})
+}
+
+// 下载拼接后的视频
+// @Summary Download output video
+// @Description Download a concatenated video as an attachment, through a URL signed by POST /api/v1/videos/download-url
+// @Tags videos
+// @Produce octet-stream
+// @Param filename path string true "Output file name"
+// @Param owner query int true "User the URL was issued to"
+// @Param expires query int true "Expiry, in Unix seconds"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/videos/download/{filename} [get]
+func (vc *VideoController) DownloadVideo(c *gin.Context) {
+	// 验证签名, 文件名和所有权
+	filePath := vc.verifyDownloadURL(c)
+	if filePath == "" {
 		return
 	}
+	filename := filepath.Base(filePath)
 
 	// 设置响应头
 	c.Header("Content-Description", "File Transfer")
@@ -218,11 +325,44 @@ func (vc *VideoController) DownloadVideo(c *gin.Context) {
 	c.File(filePath)
 }
 
+// 在线播放拼接后的视频（支持 Range 请求）
+// @Summary Play output video
+// @Description Stream a concatenated video inline, with Range requests for seeking, through a URL signed by POST /api/v1/videos/download-url
+// @Tags videos
+// @Produce octet-stream
+// @Param filename path string true "Output file name"
+// @Param owner query int true "User the URL was issued to"
+// @Param expires query int true "Expiry, in Unix seconds"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/videos/play/{filename} [get]
+func (vc *VideoController) PlayVideo(c *gin.Context) {
+	filePath := vc.verifyDownloadURL(c)
+	if filePath == "" {
+		return
+	}
+
+	servePlayback(c, filePath)
+}
+
 // 列出已上传的文件
+// @Summary List uploaded files
+// @Tags videos
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/videos/files [get]
 func (vc *VideoController) ListFiles(c *gin.Context) {
-	uploadDir := "./uploads"
+	userID, _ := middleware.GetUserID(c)
+	uploadDir := vc.videoFileService.Dir(models.VideoFileUpload, userID)
 	files, err := os.ReadDir(uploadDir)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to read upload directory",
 		})
@@ -251,10 +391,20 @@ func (vc *VideoController) ListFiles(c *gin.Context) {
 }
 
 // 列出已生成的输出文件
+// @Summary List output files
+// @Description List output videos; download and play them through URLs signed by POST /api/v1/videos/download-url
+// @Tags videos
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/videos/output [get]
 func (vc *VideoController) ListOutputFiles(c *gin.Context) {
-	outputDir := "./output"
+	userID, _ := middleware.GetUserID(c)
+	outputDir := vc.videoFileService.Dir(models.VideoFileOutput, userID)
 	files, err := os.ReadDir(outputDir)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to read output directory",
 		})
@@ -267,10 +417,9 @@ func (vc *VideoController) ListOutputFiles(c *gin.Context) {
 			info, _ := file.Info()
 			
 			outputFiles = append(outputFiles, map[string]interface{}{
-				"name":         file.Name(),
-				"size":         info.Size(),
-				"modified":     info.ModTime(),
-				"download_url": fmt.Sprintf("/api/v1/video/download/%s", file.Name()),
+				"name":     file.Name(),
+				"size":     info.Size(),
+				"modified": info.ModTime(),
 			})
 		}
 	}
@@ -282,36 +431,35 @@ func (vc *VideoController) ListOutputFiles(c *gin.Context) {
 }
 
 // 删除文件
+// @Summary Delete file
+// @Description Delete an uploaded file, or an output file with type=output
+// @Tags videos
+// @Produce json
+// @Security BearerAuth
+// @Param filename path string true "File name"
+// @Param type query string false "upload or output" default(upload)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/videos/{filename} [delete]
 func (vc *VideoController) DeleteFile(c *gin.Context) {
 	filename := c.Param("filename")
-	fileType := c.Query("type") // "upload" or "output"
-	
-	if filename == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Filename is required",
-		})
+	var query struct {
+		Type string `form:"type" binding:"omitempty,oneof=upload output"`
+	}
+	if !bindQuery(c, &query) {
 		return
 	}
-
-	var filePath string
-	if fileType == "output" {
-		filePath = filepath.Join("./output", filename)
-	} else {
-		filePath = filepath.Join("./uploads", filename)
+	fileType := query.Type
+	if fileType == "" {
+		fileType = models.VideoFileUpload
 	}
 
-	// 删除文件
-	err := os.Remove(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "File not found",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to delete file",
-			})
-		}
+	// 删除文件, 仅限当前用户的文件
+	userID, _ := middleware.GetUserID(c)
+	if err := vc.videoFileService.Remove(fileType, userID, filename); err != nil {
+		videoFileError(c, err)
 		return
 	}
 
@@ -321,22 +469,25 @@ func (vc *VideoController) DeleteFile(c *gin.Context) {
 }
 
 // 获取视频信息
+// @Summary Get video info
+// @Description Probe an uploaded video for its duration, resolution and codecs
+// @Tags videos
+// @Produce json
+// @Security BearerAuth
+// @Param filename path string true "Uploaded file name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/videos/info/{filename} [get]
 func (vc *VideoController) GetVideoInfo(c *gin.Context) {
 	filename := c.Param("filename")
-	if filename == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Filename is required",
-		})
-		return
-	}
 
-	filePath := filepath.Join("./uploads", filename)
-	
-	// 验证文件存在
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "File not found",
-		})
+	// 验证文件名, 文件存在且属于当前用户
+	userID, _ := middleware.GetUserID(c)
+	filePath, err := vc.videoFileService.Path(models.VideoFileUpload, userID, filename)
+	if err != nil {
+		videoFileError(c, err)
 		return
 	}
 
@@ -0,0 +1,342 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+type WebhookController struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookController() *WebhookController {
+	return &WebhookController{
+		webhookService: services.NewWebhookService(),
+	}
+}
+
+// @Summary Create webhook
+// @Description Register an endpoint receiving the chosen events of the current workspace; organization endpoints take an organization admin. Deliveries carry X-Webhook-Event, X-Webhook-Delivery, X-Webhook-Timestamp and X-Webhook-Signature, the hex HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret returned here only
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.WebhookCreateRequest true "Webhook"
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Success 201 {object} models.WebhookSecretResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/webhooks [post]
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.WebhookCreateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	response, err := c.webhookService.CreateWebhook(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionWebhookCreate, "webhook", response.Webhook.ID, nil, response.Webhook)
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// @Summary List webhooks
+// @Description List the webhook endpoints of the current workspace
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param X-Organization-ID header int false "Organization workspace; omit for the personal one"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/webhooks [get]
+func (c *WebhookController) ListWebhooks(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.WebhookListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	webhooks, total, err := c.webhookService.ListWebhooks(userID, middleware.GetOrganizationID(ctx), &req)
+	if err != nil {
+		logger.Errorf("Failed to list webhooks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get webhooks",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"webhooks": webhooks,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Get webhook
+// @Description Retrieve a webhook endpoint; its secret is never shown again
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/webhooks/{id} [get]
+func (c *WebhookController) GetWebhook(ctx *gin.Context) {
+	webhookID, ok := parseWebhookID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	webhook, err := c.webhookService.GetWebhook(webhookID, userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+// @Summary Update webhook
+// @Description Change a webhook endpoint's URL, description or events, or turn it off and on; deliveries queued while it is off are dropped
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param request body models.WebhookUpdateRequest true "Fields to change"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/webhooks/{id} [patch]
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	webhookID, ok := parseWebhookID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.WebhookUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	before, _ := c.webhookService.GetWebhook(webhookID, userID)
+	webhook, err := c.webhookService.UpdateWebhook(webhookID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionWebhookUpdate, "webhook", webhook.ID, before, webhook)
+
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+// @Summary Delete webhook
+// @Description Delete a webhook endpoint; deliveries still queued for it are dropped
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/webhooks/{id} [delete]
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	webhookID, ok := parseWebhookID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	before, _ := c.webhookService.GetWebhook(webhookID, userID)
+	if err := c.webhookService.DeleteWebhook(webhookID, userID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionWebhookDelete, "webhook", webhookID, before, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted",
+	})
+}
+
+// @Summary Rotate webhook secret
+// @Description Replace a webhook endpoint's signing secret; every delivery sent from now on, retries included, is signed with the new one
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.WebhookSecretResponse
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/webhooks/{id}/rotate-secret [post]
+func (c *WebhookController) RotateSecret(ctx *gin.Context) {
+	webhookID, ok := parseWebhookID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	response, err := c.webhookService.RotateSecret(webhookID, userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	middleware.Audit(ctx, models.AuditActionWebhookRotate, "webhook", webhookID, nil, nil)
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Test webhook
+// @Description Send a ping event to a webhook endpoint, whatever events it subscribes to
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/webhooks/{id}/test [post]
+func (c *WebhookController) TestWebhook(ctx *gin.Context) {
+	webhookID, ok := parseWebhookID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	delivery, err := c.webhookService.TestWebhook(webhookID, userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, delivery)
+}
+
+// @Summary List webhook deliveries
+// @Description List the deliveries of a webhook endpoint, newest first, with the outcome of each one's last attempt
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param status query string false "Delivery status (pending, retrying, succeeded, failed)"
+// @Param event query string false "Event"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (c *WebhookController) ListDeliveries(ctx *gin.Context) {
+	webhookID, ok := parseWebhookID(ctx)
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	var req models.WebhookDeliveryListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	deliveries, total, err := c.webhookService.ListDeliveries(webhookID, userID, &req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"pagination": gin.H{
+			"page":  req.Page,
+			"limit": req.Limit,
+			"total": total,
+			"pages": (total + int64(req.Limit) - 1) / int64(req.Limit),
+		},
+	})
+}
+
+// @Summary Redeliver webhook delivery
+// @Description Send a logged delivery's payload to its endpoint again, as a new delivery
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (c *WebhookController) Redeliver(ctx *gin.Context) {
+	webhookID, ok := parseWebhookID(ctx)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseUint(ctx.Param("delivery_id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid delivery ID",
+		})
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	delivery, err := c.webhookService.Redeliver(webhookID, uint(deliveryID), userID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, delivery)
+}
+
+func parseWebhookID(ctx *gin.Context) (uint, bool) {
+	webhookID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook ID",
+		})
+		return 0, false
+	}
+	return uint(webhookID), true
+}
+
+func (c *WebhookController) respondError(ctx *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case err.Error() == "webhook not found", err.Error() == "webhook delivery not found":
+		statusCode = http.StatusNotFound
+	case err.Error() == "insufficient organization role":
+		statusCode = http.StatusForbidden
+	case err.Error() == "webhook is inactive":
+		statusCode = http.StatusConflict
+	case strings.HasPrefix(err.Error(), "invalid url"):
+		statusCode = http.StatusBadRequest
+	}
+	ctx.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/vikstrous/dataloadgen"
+	"creative-studio-server/models"
+)
+
+// loaderWait is how long a loader gathers keys before fetching them
+const loaderWait = 2 * time.Millisecond
+
+type loadersKey struct{}
+
+// loaders batch the lookups of nested fields: the clips of twenty projects
+// take one query rather than twenty. They are made for each request and
+// only return what the viewer may see.
+type loaders struct {
+	users       *dataloadgen.Loader[uint, *models.User]
+	clips       *dataloadgen.Loader[uint, *models.AtomicClip]
+	analyses    *dataloadgen.Loader[uint, *models.VideoAnalysis]
+	projects    *dataloadgen.Loader[uint, *models.Project]
+	renderTasks *dataloadgen.Loader[uint, []*models.RenderTask]
+}
+
+func (r *Resolver) newLoaders(userID uint) *loaders {
+	wait := dataloadgen.WithWait(loaderWait)
+	return &loaders{
+		users: dataloadgen.NewLoader(func(ctx context.Context, ids []uint) ([]*models.User, []error) {
+			users, err := r.userService.GetUsersByIDs(ids)
+			if err != nil {
+				return nil, []error{err}
+			}
+			return byKey(ids, users, func(u *models.User) uint { return u.ID }), nil
+		}, wait),
+		clips: dataloadgen.NewLoader(func(ctx context.Context, ids []uint) ([]*models.AtomicClip, []error) {
+			clips, err := r.clipService.GetAtomicClipsByIDs(ids, userID)
+			if err != nil {
+				return nil, []error{err}
+			}
+			return byKey(ids, clips, func(c *models.AtomicClip) uint { return c.ID }), nil
+		}, wait),
+		// Keyed by clip; only reached through clips the viewer can open
+		analyses: dataloadgen.NewLoader(func(ctx context.Context, clipIDs []uint) ([]*models.VideoAnalysis, []error) {
+			analyses, err := r.clipService.GetClipAnalyses(clipIDs)
+			if err != nil {
+				return nil, []error{err}
+			}
+			return byKey(clipIDs, analyses, func(a *models.VideoAnalysis) uint { return a.AtomicClipID }), nil
+		}, wait),
+		projects: dataloadgen.NewLoader(func(ctx context.Context, ids []uint) ([]*models.Project, []error) {
+			projects, err := r.projectService.GetProjectsByIDs(ids, userID)
+			if err != nil {
+				return nil, []error{err}
+			}
+			return byKey(ids, projects, func(p *models.Project) uint { return p.ID }), nil
+		}, wait),
+		// Keyed by project
+		renderTasks: dataloadgen.NewLoader(func(ctx context.Context, projectIDs []uint) ([][]*models.RenderTask, []error) {
+			tasks, err := r.renderTaskService.GetProjectsRenderTasks(projectIDs, userID)
+			if err != nil {
+				return nil, []error{err}
+			}
+			byProject := make(map[uint][]*models.RenderTask, len(projectIDs))
+			for i := range tasks {
+				byProject[tasks[i].ProjectID] = append(byProject[tasks[i].ProjectID], &tasks[i])
+			}
+			results := make([][]*models.RenderTask, len(projectIDs))
+			for i, projectID := range projectIDs {
+				results[i] = byProject[projectID]
+			}
+			return results, nil
+		}, wait),
+	}
+}
+
+func loadersFor(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// byKey puts fetched rows in the order of the keys asked for, with nil for
+// the keys that matched none.
+func byKey[T any](keys []uint, rows []T, key func(*T) uint) []*T {
+	index := make(map[uint]*T, len(rows))
+	for i := range rows {
+		index[key(&rows[i])] = &rows[i]
+	}
+	results := make([]*T, len(keys))
+	for i, k := range keys {
+		results[i] = index[k]
+	}
+	return results
+}
@@ -0,0 +1,46 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package model
+
+import (
+	"creative-studio-server/models"
+)
+
+type ClipFilter struct {
+	Query *string `json:"query,omitempty"`
+	// workspace (default), public or stock
+	Library  *string  `json:"library,omitempty"`
+	Category *string  `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Mood     *string  `json:"mood,omitempty"`
+	Style    *string  `json:"style,omitempty"`
+	Color    *string  `json:"color,omitempty"`
+	// short, medium or long
+	Duration   *string `json:"duration,omitempty"`
+	Resolution *string `json:"resolution,omitempty"`
+}
+
+type ClipPage struct {
+	Clips    []*models.AtomicClip `json:"clips"`
+	PageInfo *PageInfo            `json:"pageInfo"`
+}
+
+type PageInfo struct {
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+	Total int `json:"total"`
+	Pages int `json:"pages"`
+}
+
+type ProjectPage struct {
+	Projects []*models.Project `json:"projects"`
+	PageInfo *PageInfo         `json:"pageInfo"`
+}
+
+type Query struct {
+}
+
+type RenderTaskPage struct {
+	RenderTasks []*models.RenderTask `json:"renderTasks"`
+	PageInfo    *PageInfo            `json:"pageInfo"`
+}
@@ -0,0 +1,131 @@
+// Package graph serves the GraphQL API described by schema.graphqls.
+// generated.go and model/ are generated by gqlgen from the schema and
+// gqlgen.yml; run `make graphql` after changing either.
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin/binding"
+	"creative-studio-server/graph/model"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/validation"
+	"creative-studio-server/services"
+)
+
+// maxPageLimit bounds the items of a list field, as on the REST API
+const maxPageLimit = 100
+
+type Resolver struct {
+	userService       *services.UserService
+	clipService       *services.AtomicClipService
+	projectService    *services.ProjectService
+	renderTaskService *services.RenderTaskService
+	roleService       *services.RoleService
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		userService:       services.NewUserService(),
+		clipService:       services.NewAtomicClipService(),
+		projectService:    services.NewProjectService(),
+		renderTaskService: services.NewRenderTaskService(),
+		roleService:       services.NewRoleService(),
+	}
+}
+
+// Viewer is who a request is served for.
+type Viewer struct {
+	UserID uint
+	Role   string
+	// OrganizationID is the workspace lists are taken from; nil for the
+	// personal one
+	OrganizationID *uint
+	// APIKey is set when the request authenticated with one
+	APIKey *models.APIKey
+}
+
+type viewerKey struct{}
+
+// WithViewer returns the context a request for the viewer is resolved in,
+// with its own loaders so batches and caches never mix users.
+func (r *Resolver) WithViewer(ctx context.Context, viewer *Viewer) context.Context {
+	ctx = context.WithValue(ctx, viewerKey{}, viewer)
+	return context.WithValue(ctx, loadersKey{}, r.newLoaders(viewer.UserID))
+}
+
+func viewerFor(ctx context.Context) *Viewer {
+	return ctx.Value(viewerKey{}).(*Viewer)
+}
+
+// authorize checks the viewer's role, and API key, grant the permission
+// the REST routes to the same data need.
+func (r *Resolver) authorize(ctx context.Context, permission string) error {
+	viewer := viewerFor(ctx)
+	allowed, err := r.roleService.HasPermission(viewer.Role, permission)
+	if err != nil {
+		logger.Errorf("Failed to check permission %s: %v", permission, err)
+		return errors.New("failed to check permissions")
+	}
+	if !allowed {
+		return errors.New("insufficient permissions")
+	}
+	if viewer.APIKey != nil && !viewer.APIKey.HasScope(permission) {
+		return errors.New("API key lacks the required scope")
+	}
+	return nil
+}
+
+// pageArgs applies the defaults of page and limit and checks them.
+func pageArgs(page, limit *int) (int, int, error) {
+	p, l := 1, 20
+	if page != nil {
+		p = *page
+	}
+	if limit != nil {
+		l = *limit
+	}
+	if p < 1 || l < 1 || l > maxPageLimit {
+		return 0, 0, errors.New("invalid page or limit")
+	}
+	return p, l, nil
+}
+
+// validate applies the binding rules the REST API checks the same request
+// with.
+func validate(req interface{}) error {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return errors.New(validation.Message(err, validation.DefaultLocale))
+	}
+	return nil
+}
+
+func stringArg(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func pageInfo(page, limit int, total int64) *model.PageInfo {
+	return &model.PageInfo{
+		Page:  page,
+		Limit: limit,
+		Total: int(total),
+		Pages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+}
+
+// loadUser loads the author of a clip or project.
+func loadUser(ctx context.Context, userID uint) (*models.User, error) {
+	user, err := loadersFor(ctx).users.Load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
@@ -0,0 +1,290 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.55
+
+import (
+	"context"
+	"creative-studio-server/graph/model"
+	"creative-studio-server/models"
+	"errors"
+	"fmt"
+)
+
+// DominantColors is the resolver for the dominantColors field.
+func (r *analysisResolver) DominantColors(ctx context.Context, obj *models.VideoAnalysis) ([]string, error) {
+	return obj.DominantColors, nil
+}
+
+// AiTags is the resolver for the aiTags field.
+func (r *analysisResolver) AiTags(ctx context.Context, obj *models.VideoAnalysis) ([]string, error) {
+	return obj.AITags, nil
+}
+
+// Tags is the resolver for the tags field.
+func (r *clipResolver) Tags(ctx context.Context, obj *models.AtomicClip) ([]string, error) {
+	return obj.Tags, nil
+}
+
+// Objects is the resolver for the objects field.
+func (r *clipResolver) Objects(ctx context.Context, obj *models.AtomicClip) ([]string, error) {
+	return obj.Objects, nil
+}
+
+// Actions is the resolver for the actions field.
+func (r *clipResolver) Actions(ctx context.Context, obj *models.AtomicClip) ([]string, error) {
+	return obj.Actions, nil
+}
+
+// Emotions is the resolver for the emotions field.
+func (r *clipResolver) Emotions(ctx context.Context, obj *models.AtomicClip) ([]string, error) {
+	return obj.Emotions, nil
+}
+
+// Metadata is the resolver for the metadata field.
+func (r *clipResolver) Metadata(ctx context.Context, obj *models.AtomicClip) (map[string]interface{}, error) {
+	return obj.Metadata, nil
+}
+
+// User is the resolver for the user field.
+func (r *clipResolver) User(ctx context.Context, obj *models.AtomicClip) (*models.User, error) {
+	return loadUser(ctx, obj.UserID)
+}
+
+// Analysis is the resolver for the analysis field.
+func (r *clipResolver) Analysis(ctx context.Context, obj *models.AtomicClip) (*models.VideoAnalysis, error) {
+	return loadersFor(ctx).analyses.Load(ctx, obj.ID)
+}
+
+// Timeline is the resolver for the timeline field.
+func (r *projectResolver) Timeline(ctx context.Context, obj *models.Project) (map[string]interface{}, error) {
+	return obj.Timeline, nil
+}
+
+// Settings is the resolver for the settings field.
+func (r *projectResolver) Settings(ctx context.Context, obj *models.Project) (map[string]interface{}, error) {
+	return obj.Settings, nil
+}
+
+// User is the resolver for the user field.
+func (r *projectResolver) User(ctx context.Context, obj *models.Project) (*models.User, error) {
+	return loadUser(ctx, obj.UserID)
+}
+
+// Clips is the resolver for the clips field.
+func (r *projectResolver) Clips(ctx context.Context, obj *models.Project) ([]*models.AtomicClip, error) {
+	timeline, err := models.ParseProjectTimeline(obj.Timeline)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeline: %w", err)
+	}
+	loaded, err := loadersFor(ctx).clips.LoadAll(ctx, timeline.ClipIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	// Clips the viewer cannot open are left out
+	clips := make([]*models.AtomicClip, 0, len(loaded))
+	for _, clip := range loaded {
+		if clip != nil {
+			clips = append(clips, clip)
+		}
+	}
+	return clips, nil
+}
+
+// RenderTasks is the resolver for the renderTasks field.
+func (r *projectResolver) RenderTasks(ctx context.Context, obj *models.Project) ([]*models.RenderTask, error) {
+	if err := r.authorize(ctx, models.PermissionRendersRead); err != nil {
+		return nil, err
+	}
+	return loadersFor(ctx).renderTasks.Load(ctx, obj.ID)
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*models.User, error) {
+	return r.userService.GetUserByID(viewerFor(ctx).UserID)
+}
+
+// Clip is the resolver for the clip field.
+func (r *queryResolver) Clip(ctx context.Context, id uint) (*models.AtomicClip, error) {
+	clip, err := r.clipService.GetAtomicClipByID(id, viewerFor(ctx).UserID)
+	if err != nil {
+		if err.Error() == "atomic clip not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return clip, nil
+}
+
+// Clips is the resolver for the clips field.
+func (r *queryResolver) Clips(ctx context.Context, filter *model.ClipFilter, page *int, limit *int) (*model.ClipPage, error) {
+	p, l, err := pageArgs(page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.AtomicClipSearchRequest{Page: p, Limit: l}
+	if filter != nil {
+		req.Query = stringArg(filter.Query)
+		req.Library = stringArg(filter.Library)
+		req.Category = stringArg(filter.Category)
+		req.Tags = filter.Tags
+		req.Mood = stringArg(filter.Mood)
+		req.Style = stringArg(filter.Style)
+		req.Color = stringArg(filter.Color)
+		req.Duration = stringArg(filter.Duration)
+		req.Resolution = stringArg(filter.Resolution)
+	}
+	if err := validate(req); err != nil {
+		return nil, fmt.Errorf("invalid clip filter: %w", err)
+	}
+
+	viewer := viewerFor(ctx)
+	result, err := r.clipService.SearchAtomicClips(req, viewer.UserID, viewer.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	clips := make([]*models.AtomicClip, len(result.Clips))
+	for i := range result.Clips {
+		clips[i] = &result.Clips[i]
+	}
+	return &model.ClipPage{Clips: clips, PageInfo: pageInfo(p, l, result.Total)}, nil
+}
+
+// Project is the resolver for the project field.
+func (r *queryResolver) Project(ctx context.Context, id uint) (*models.Project, error) {
+	if err := r.authorize(ctx, models.PermissionCompositionsRead); err != nil {
+		return nil, err
+	}
+
+	project, err := r.projectService.GetProjectByID(id, viewerFor(ctx).UserID)
+	if err != nil {
+		if err.Error() == "project not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return project, nil
+}
+
+// Projects is the resolver for the projects field.
+func (r *queryResolver) Projects(ctx context.Context, status *string, page *int, limit *int) (*model.ProjectPage, error) {
+	if err := r.authorize(ctx, models.PermissionCompositionsRead); err != nil {
+		return nil, err
+	}
+	p, l, err := pageArgs(page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.ProjectListRequest{Status: stringArg(status), Page: p, Limit: l}
+	if err := validate(req); err != nil {
+		return nil, fmt.Errorf("invalid project filter: %w", err)
+	}
+
+	viewer := viewerFor(ctx)
+	projects, total, _, err := r.projectService.ListProjects(viewer.UserID, viewer.OrganizationID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ProjectPage{Projects: make([]*models.Project, len(projects)), PageInfo: pageInfo(p, l, total)}
+	for i := range projects {
+		result.Projects[i] = &projects[i]
+	}
+	return result, nil
+}
+
+// RenderTask is the resolver for the renderTask field.
+func (r *queryResolver) RenderTask(ctx context.Context, id uint) (*models.RenderTask, error) {
+	if err := r.authorize(ctx, models.PermissionRendersRead); err != nil {
+		return nil, err
+	}
+
+	task, err := r.renderTaskService.GetRenderTaskByID(id, viewerFor(ctx).UserID)
+	if err != nil {
+		if err.Error() == "render task not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+// RenderTasks is the resolver for the renderTasks field.
+func (r *queryResolver) RenderTasks(ctx context.Context, projectID *uint, status *string, page *int, limit *int) (*model.RenderTaskPage, error) {
+	if err := r.authorize(ctx, models.PermissionRendersRead); err != nil {
+		return nil, err
+	}
+	p, l, err := pageArgs(page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var project uint
+	if projectID != nil {
+		project = *projectID
+	}
+	tasks, total, _, err := r.renderTaskService.ListRenderTasks(viewerFor(ctx).UserID, project, "", stringArg(status), p, l, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.RenderTaskPage{RenderTasks: make([]*models.RenderTask, len(tasks)), PageInfo: pageInfo(p, l, total)}
+	for i := range tasks {
+		result.RenderTasks[i] = &tasks[i]
+	}
+	return result, nil
+}
+
+// Project is the resolver for the project field.
+func (r *renderTaskResolver) Project(ctx context.Context, obj *models.RenderTask) (*models.Project, error) {
+	if err := r.authorize(ctx, models.PermissionCompositionsRead); err != nil {
+		return nil, err
+	}
+
+	project, err := loadersFor(ctx).projects.Load(ctx, obj.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, errors.New("project not found")
+	}
+	return project, nil
+}
+
+// Email is the resolver for the email field.
+func (r *userResolver) Email(ctx context.Context, obj *models.User) (*string, error) {
+	if obj.ID != viewerFor(ctx).UserID {
+		return nil, nil
+	}
+	return &obj.Email, nil
+}
+
+// Analysis returns AnalysisResolver implementation.
+func (r *Resolver) Analysis() AnalysisResolver { return &analysisResolver{r} }
+
+// Clip returns ClipResolver implementation.
+func (r *Resolver) Clip() ClipResolver { return &clipResolver{r} }
+
+// Project returns ProjectResolver implementation.
+func (r *Resolver) Project() ProjectResolver { return &projectResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// RenderTask returns RenderTaskResolver implementation.
+func (r *Resolver) RenderTask() RenderTaskResolver { return &renderTaskResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type analysisResolver struct{ *Resolver }
+type clipResolver struct{ *Resolver }
+type projectResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type renderTaskResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"creative-studio-server/pkg/logger"
+)
+
+// maxComplexity bounds the fields a query may ask for, nested ones
+// included, so one request cannot walk the whole database
+const maxComplexity = 500
+
+// NewServer returns the HTTP handler of the GraphQL API. Requests must
+// carry a Viewer in their context.
+func NewServer(resolver *Resolver) *handler.Server {
+	server := handler.New(NewExecutableSchema(Config{Resolvers: resolver}))
+	server.AddTransport(transport.GET{})
+	server.AddTransport(transport.POST{})
+	server.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	server.Use(extension.Introspection{})
+	server.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](100)})
+	server.Use(extension.FixedComplexityLimit(maxComplexity))
+	server.SetRecoverFunc(func(ctx context.Context, recovered interface{}) error {
+		logger.Errorf("Panic resolving GraphQL field %s: %v", graphql.GetPath(ctx), recovered)
+		return errors.New("internal server error")
+	})
+	return server
+}
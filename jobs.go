@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/scheduler"
+	"creative-studio-server/services"
+)
+
+// taskHistoryRetention is how long finished task records are kept
+const taskHistoryRetention = 30 * 24 * time.Hour
+
+// webhookDeliveryRetention is how long finished webhook deliveries are kept
+const webhookDeliveryRetention = 30 * 24 * time.Hour
+
+// registerScheduledJobs declares the recurring jobs. Their schedules are
+// persisted on first start and can then be changed through the admin API.
+func registerScheduledJobs() error {
+	if err := scheduler.Register("prune-task-history", "@daily",
+		"Delete finished background task records older than 30 days",
+		func(ctx context.Context) error {
+			removed, err := services.NewTaskService().PruneFinishedTasks(time.Now().Add(-taskHistoryRetention))
+			if err != nil {
+				return err
+			}
+			logger.Infof("Pruned %d finished task records", removed)
+			return nil
+		}); err != nil {
+		return err
+	}
+
+	if err := scheduler.Register("prune-refresh-tokens", "@daily",
+		"Delete refresh tokens and sessions that expired more than a day ago",
+		func(ctx context.Context) error {
+			tokens := services.NewTokenService()
+			cutoff := time.Now().Add(-24 * time.Hour)
+			removed, err := tokens.PruneExpiredRefreshTokens(cutoff)
+			if err != nil {
+				return err
+			}
+			sessions, err := tokens.PruneExpiredSessions(cutoff)
+			if err != nil {
+				return err
+			}
+			logger.Infof("Pruned %d expired refresh tokens and %d sessions", removed, sessions)
+			return nil
+		}); err != nil {
+		return err
+	}
+
+	if err := scheduler.Register("prune-account-tokens", "@daily",
+		"Delete email verification and password reset tokens that expired more than a day ago",
+		func(ctx context.Context) error {
+			removed, err := services.NewAccountService().PruneExpiredAccountTokens(time.Now().Add(-24 * time.Hour))
+			if err != nil {
+				return err
+			}
+			logger.Infof("Pruned %d expired account tokens", removed)
+			return nil
+		}); err != nil {
+		return err
+	}
+
+	if err := scheduler.Register("notify-saved-searches", "@every 15m",
+		"Notify the creators of saved searches with notifications on of clips that newly match",
+		func(ctx context.Context) error {
+			notified, err := services.NewSavedSearchService().NotifyNewMatches()
+			if err != nil {
+				return err
+			}
+			logger.Infof("Notified %d saved searches of new matches", notified)
+			return nil
+		}); err != nil {
+		return err
+	}
+
+	if err := scheduler.Register("purge-trash", "@daily",
+		"Delete for good, with their files, clips and projects deleted longer ago than TRASH_RETENTION",
+		func(ctx context.Context) error {
+			retention := config.AppConfig.Storage.TrashRetention
+			if retention <= 0 {
+				return nil
+			}
+			clips, projects, err := services.NewTrashService().PurgeExpired(time.Now().Add(-retention))
+			if err != nil {
+				return err
+			}
+			logger.Infof("Purged %d clips and %d projects from the trash", clips, projects)
+			return nil
+		}); err != nil {
+		return err
+	}
+
+	if err := scheduler.Register("prune-webhook-deliveries", "@daily",
+		"Delete finished webhook deliveries older than 30 days",
+		func(ctx context.Context) error {
+			removed, err := services.NewWebhookService().PruneDeliveries(time.Now().Add(-webhookDeliveryRetention))
+			if err != nil {
+				return err
+			}
+			logger.Infof("Pruned %d webhook deliveries", removed)
+			return nil
+		}); err != nil {
+		return err
+	}
+
+	if err := scheduler.Register("prune-transcodes", "@daily",
+		"Delete transcoded clip downloads not downloaded for longer than TRANSCODE_RETENTION",
+		func(ctx context.Context) error {
+			retention := config.AppConfig.Storage.TranscodeRetention
+			if retention <= 0 {
+				return nil
+			}
+			removed, err := services.NewTranscodeService().PruneTranscodes(time.Now().Add(-retention))
+			if err != nil {
+				return err
+			}
+			logger.Infof("Pruned %d transcoded downloads", removed)
+			return nil
+		}); err != nil {
+		return err
+	}
+
+	if err := scheduler.Register("advance-analysis-backfills", "@every 1m",
+		"Queue the next batch of clips of running analysis backfills for re-analysis",
+		func(ctx context.Context) error {
+			queued, err := services.NewAnalysisBackfillService().AdvanceBackfills()
+			if queued > 0 {
+				logger.Infof("Queued %d clips for analysis backfill", queued)
+			}
+			return err
+		}); err != nil {
+		return err
+	}
+
+	return scheduler.Register("rotate-signing-keys", "@hourly",
+		"Rotate the access token signing key once it is older than JWT_KEY_ROTATION_INTERVAL, and delete keys no token can use",
+		func(ctx context.Context) error {
+			keys := services.NewSigningKeyService()
+			rotated, err := keys.RotateIfDue()
+			if err != nil {
+				return err
+			}
+			if rotated {
+				logger.Info("Rotated the signing key")
+			}
+
+			removed, err := keys.PruneRetiredKeys(time.Now().Add(-24 * time.Hour))
+			if err != nil {
+				return err
+			}
+			logger.Infof("Pruned %d retired signing keys", removed)
+			return nil
+		})
+}
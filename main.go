@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,14 +12,31 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 
 	"creative-studio-server/config"
 	"creative-studio-server/middleware"
-	// "creative-studio-server/pkg/cache" // disabled
-	// "creative-studio-server/pkg/database" // disabled
+	"creative-studio-server/pkg/ai"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/embedding"
+	"creative-studio-server/pkg/eventbus"
+	"creative-studio-server/pkg/faces"
 	"creative-studio-server/pkg/logger"
-	// "creative-studio-server/pkg/queue" // disabled
+	"creative-studio-server/pkg/mailer"
+	"creative-studio-server/pkg/metrics"
+	"creative-studio-server/pkg/moderation"
+	"creative-studio-server/pkg/objects"
+	"creative-studio-server/pkg/ocr"
+	"creative-studio-server/pkg/queue"
+	"creative-studio-server/pkg/scheduler"
+	"creative-studio-server/pkg/search"
+	"creative-studio-server/pkg/suggestions"
+	"creative-studio-server/pkg/transcription"
+	"creative-studio-server/pkg/video_engine"
 	"creative-studio-server/routes"
+	"creative-studio-server/rpc"
+	"creative-studio-server/services"
 )
 
 // @title Creative Studio Server API
@@ -32,8 +51,7 @@ import (
 // @license.name MIT
 // @license.url https://opensource.org/licenses/MIT
 
-// @host localhost:8080
-// @BasePath /api/v1
+// @BasePath /
 
 // @securityDefinitions.apikey BearerAuth
 // @in header
@@ -41,6 +59,15 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	mode := flag.String("mode", modeAll, "run mode: all (API and workers), api (API only) or worker (workers only)")
+	registerWorkerFlags(flag.CommandLine)
+	flag.Parse()
+
+	if err := validateMode(*mode); err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
 	// Load configuration
 	if err := config.LoadConfig(); err != nil {
 		fmt.Printf("Failed to load config: %v\n", err)
@@ -51,25 +78,133 @@ func main() {
 
 	// Initialize logger
 	logger.InitLogger(cfg)
-	logger.Info("Starting Creative Studio Server...")
+	logger.Infof("Starting Creative Studio Server (mode: %s)...", *mode)
+	if cfg.Profile != "" {
+		logger.Infof("Using config profile %s", cfg.Profile)
+	}
+
+	if err := registerScheduledJobs(); err != nil {
+		logger.Fatalf("Failed to register scheduled jobs: %v", err)
+	}
+
+	// Register external composition scorers
+	for name, endpoint := range cfg.Composition.ExternalScorers {
+		if err := video_engine.RegisterExternalScorer(name, endpoint, cfg.Composition.ExternalScorerTimeout); err != nil {
+			logger.Fatalf("Failed to register external scorer %s: %v", name, err)
+		}
+		logger.Infof("Registered external composition scorer %s -> %s", name, endpoint)
+	}
+
+	if cfg.Server.SimpleMode {
+		if *mode != modeAll {
+			logger.Fatalf("Mode %s requires SIMPLE_MODE=false", *mode)
+		}
+		logger.Info("Running in simple mode: database, Redis and RabbitMQ are disabled")
+	} else {
+		// In-process tasks never reach another process
+		if cfg.Queue.Backend == queue.BackendMemory && *mode != modeAll {
+			logger.Fatalf("Mode %s cannot be used with QUEUE_BACKEND=memory", *mode)
+		}
+
+		// Initialize database
+		if err := database.InitDatabase(cfg); err != nil {
+			logger.Fatalf("Failed to initialize database: %v", err)
+		}
+		if err := services.NewRoleService().SeedDefaultRoles(); err != nil {
+			logger.Warnf("Failed to seed default roles: %v", err)
+		}
+		// Until a signing key loads, tokens are signed with JWT_SECRET
+		if err := services.NewSigningKeyService().EnsureActiveKey(); err != nil {
+			logger.Warnf("Failed to load signing keys: %v", err)
+		}
+
+		// Initialize the sender of account emails
+		if err := mailer.InitMailer(cfg); err != nil {
+			logger.Fatalf("Failed to initialize mailer: %v", err)
+		}
+
+		// Initialize Redis cache
+		if err := cache.InitRedis(cfg); err != nil {
+			logger.Fatalf("Failed to initialize Redis: %v", err)
+		}
+
+		// Initialize the clip search index
+		if err := search.InitSearch(cfg); err != nil {
+			logger.Fatalf("Failed to initialize search: %v", err)
+		}
+
+		// Initialize the AI provider, before the analysis capabilities
+		// that use it
+		if err := ai.InitAI(cfg); err != nil {
+			logger.Fatalf("Failed to initialize AI provider: %v", err)
+		}
+
+		// Initialize embeddings for semantic search, after Redis as
+		// vectors can be kept there
+		if err := embedding.InitEmbedding(cfg); err != nil {
+			logger.Fatalf("Failed to initialize embeddings: %v", err)
+		}
+
+		// Initialize face detection for clip analysis
+		if err := faces.InitFaces(cfg); err != nil {
+			logger.Fatalf("Failed to initialize face detection: %v", err)
+		}
+
+		// Initialize object detection for auto-tagging clips
+		if err := objects.InitObjects(cfg); err != nil {
+			logger.Fatalf("Failed to initialize object detection: %v", err)
+		}
+
+		// Initialize OCR for on-screen text in clips
+		if err := ocr.InitOCR(cfg); err != nil {
+			logger.Fatalf("Failed to initialize OCR: %v", err)
+		}
+
+		// Initialize moderation of uploads
+		if err := moderation.InitModeration(cfg); err != nil {
+			logger.Fatalf("Failed to initialize moderation: %v", err)
+		}
+
+		// Initialize speech-to-text for clip transcripts
+		if err := transcription.InitTranscription(cfg); err != nil {
+			logger.Fatalf("Failed to initialize transcription: %v", err)
+		}
+
+		// Initialize metadata suggestions for new uploads
+		if err := suggestions.InitSuggestions(cfg); err != nil {
+			logger.Fatalf("Failed to initialize metadata suggestions: %v", err)
+		}
+
+		// Initialize the task queue (RabbitMQ, Kafka or in-memory)
+		if err := queue.InitQueue(cfg); err != nil {
+			logger.Fatalf("Failed to initialize task queue: %v", err)
+		}
+
+		var ctx context.Context
+		ctx, stopBackground = context.WithCancel(context.Background())
+
+		// Follow queue settings changed through the admin API
+		go queue.ListenForSettingsChanges(ctx)
+
+		// Receive events broadcast by the other instances
+		go eventbus.Run(ctx)
 
-	// Initialize database (disabled for simple mode)
-	// if err := database.InitDatabase(cfg); err != nil {
-	// 	logger.Fatalf("Failed to initialize database: %v", err)
-	// }
+		// Pick up rotated signing keys missed by events
+		go services.NewSigningKeyService().RunKeysetRefresh(ctx)
 
-	// Initialize Redis cache (disabled)
-	// if err := cache.InitRedis(cfg); err != nil {
-	//	logger.Fatalf("Failed to initialize Redis: %v", err)
-	// }
+		// Sample queue depths for /metrics
+		go queue.ReportQueueDepth(ctx)
 
-	// Initialize RabbitMQ (disabled)
-	// if err := queue.InitRabbitMQ(cfg); err != nil {
-	//	logger.Fatalf("Failed to initialize RabbitMQ: %v", err)
-	// }
+		// Start background workers
+		if *mode != modeAPI {
+			startBackgroundWorkers(ctx)
+		}
+	}
 
-	// Start background workers (disabled - no RabbitMQ)
-	// startBackgroundWorkers()
+	if *mode == modeWorker {
+		runWorkerOnly()
+		return
+	}
 
 	// Set Gin mode
 	gin.SetMode(cfg.Server.Mode)
@@ -78,9 +213,12 @@ func main() {
 	r := gin.New()
 
 	// Add global middleware
+	r.Use(middleware.RequestID())
 	r.Use(middleware.Logger())
 	r.Use(gin.Recovery())
-	r.Use(middleware.CORS())
+	r.Use(middleware.SecurityHeaders(cfg.Server.HSTSMaxAge, cfg.Server.ContentSecurityPolicy))
+	r.Use(middleware.CORS(cfg.Server.CORSOrigins))
+	r.Use(middleware.Compress())
 
 	// Setup routes
 	routes.SetupRoutes(r)
@@ -103,6 +241,22 @@ func main() {
 		}
 	}()
 
+	// Internal gRPC API; it needs the database, so not in simple mode
+	var grpcSrv *grpc.Server
+	if cfg.Server.GRPCPort != "" && !cfg.Server.SimpleMode {
+		listener, err := net.Listen("tcp", ":"+cfg.Server.GRPCPort)
+		if err != nil {
+			logger.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		grpcSrv = rpc.NewServer()
+		go func() {
+			logger.Infof("gRPC server starting on port %s", cfg.Server.GRPCPort)
+			if err := grpcSrv.Serve(listener); err != nil {
+				logger.Fatalf("Failed to start gRPC server: %v", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -119,66 +273,129 @@ func main() {
 		logger.Errorf("Server forced to shutdown: %v", err)
 	}
 
+	// Let running calls finish, within the same deadline
+	if grpcSrv != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcSrv.Stop()
+		}
+	}
+
 	// Close connections
 	cleanup()
 
 	logger.Info("Server stopped")
 }
 
-func startBackgroundWorkers() {
+// stopBackground ends the listeners and the scheduler once workers have
+// drained
+var (
+	stopBackground context.CancelFunc
+	schedulerDone  chan struct{}
+)
+
+func startBackgroundWorkers(ctx context.Context) {
 	logger.Info("Starting background workers...")
 
-	// Start video processing workers (disabled - no RabbitMQ)
-	// go func() {
-	//	if err := queue.Queue.ConsumeTask("video_processing", queue.VideoProcessingHandler, 2); err != nil {
-	//		logger.Errorf("Failed to start video processing workers: %v", err)
-	//	}
-	// }()
-
-	// Start smart composition workers (disabled - no RabbitMQ)
-	// go func() {
-	//	if err := queue.Queue.ConsumeTask("smart_composition", queue.SmartCompositionHandler, 1); err != nil {
-	//		logger.Errorf("Failed to start smart composition workers: %v", err)
-	//	}
-	// }()
-
-	// Start render task workers (disabled - no RabbitMQ)
-	// go func() {
-	//	if err := queue.Queue.ConsumeTask("render_tasks", queue.RenderTaskHandler, 3); err != nil {
-	//		logger.Errorf("Failed to start render task workers: %v", err)
-	//	}
-	// }()
-
-	// Start analysis task workers (disabled - no RabbitMQ)
-	// go func() {
-	//	if err := queue.Queue.ConsumeTask("analysis_tasks", queue.AnalysisTaskHandler, 2); err != nil {
-	//		logger.Errorf("Failed to start analysis task workers: %v", err)
-	//	}
-	// }()
-
-	// Start thumbnail generation workers (disabled - no RabbitMQ)
-	// go func() {
-	//	if err := queue.Queue.ConsumeTask("thumbnail_generation", queue.ThumbnailTaskHandler, 4); err != nil {
-	//		logger.Errorf("Failed to start thumbnail generation workers: %v", err)
-	//	}
-	// }()
+	for _, wq := range workerQueues {
+		concurrency := wq.concurrency
+		if concurrency < 0 {
+			concurrency = queue.SettingsFor(wq.queue).Concurrency
+		}
+		if concurrency <= 0 {
+			logger.Infof("Skipping %s workers (disabled)", wq.label)
+			continue
+		}
+
+		go func() {
+			if err := queue.Queue.ConsumeTask(wq.queue, wq.handler, concurrency); err != nil {
+				logger.Errorf("Failed to start %s workers: %v", wq.label, err)
+			}
+		}()
+	}
+
+	// Stop in-flight renders cancelled from any API instance
+	go queue.ListenForRenderCancellations(ctx)
+
+	// Fire recurring jobs; one worker instance leads at a time
+	schedulerDone = make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.Run(ctx)
+	}()
 
 	logger.Info("Background workers started")
 }
 
+// runWorkerOnly blocks until the process is asked to stop; workers consume
+// in the background and no HTTP listener is started.
+func runWorkerOnly() {
+	logger.Info("Worker mode: HTTP API disabled")
+
+	// Autoscaling and alerting still need the worker metrics
+	metricsSrv := &http.Server{
+		Addr:    ":" + config.AppConfig.Server.MetricsPort,
+		Handler: metrics.Handler(),
+	}
+	go func() {
+		logger.Infof("Metrics listening on port %s", config.AppConfig.Server.MetricsPort)
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("Metrics listener failed: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down workers...")
+	metricsSrv.Close()
+	cleanup()
+	logger.Info("Workers stopped")
+}
+
 func cleanup() {
 	logger.Info("Cleaning up resources...")
 
-	// Close RabbitMQ connection (disabled)
-	// if err := queue.Queue.Close(); err != nil {
-	//	logger.Errorf("Failed to close RabbitMQ connection: %v", err)
-	// }
+	// Let running tasks finish; the rest go back to the broker
+	if queue.Queue != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), config.AppConfig.Queue.DrainTimeout)
+		if err := queue.Queue.Drain(ctx); err != nil {
+			logger.Warnf("Stopped waiting for running tasks, they will be redelivered: %v", err)
+		}
+		cancel()
+	}
+	if stopBackground != nil {
+		stopBackground()
+	}
+	if schedulerDone != nil {
+		select {
+		case <-schedulerDone:
+		case <-time.After(config.AppConfig.Queue.DrainTimeout):
+			logger.Warn("Stopped waiting for scheduled jobs to finish")
+		}
+	}
+
+	// Close task queue connection
+	if queue.Queue != nil {
+		if err := queue.Queue.Close(); err != nil {
+			logger.Errorf("Failed to close task queue connection: %v", err)
+		}
+	}
 
-	// Close Redis connection (disabled)
-	// if err := cache.Cache.Close(); err != nil {
-	//	logger.Errorf("Failed to close Redis connection: %v", err)
-	// }
+	// Close Redis connection
+	if cache.Cache != nil {
+		if err := cache.Cache.Close(); err != nil {
+			logger.Errorf("Failed to close Redis connection: %v", err)
+		}
+	}
 
 	// Close database connections would be handled by GORM automatically
 	logger.Info("Cleanup completed")
-}
\ No newline at end of file
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/models"
+	"creative-studio-server/services"
+)
+
+// Audit records an action of the request's user in the audit log, with
+// snapshots of the resource before and after it; pass nil for a resource
+// that did not exist before or no longer exists after.
+func Audit(c *gin.Context, action, resourceType string, resourceID interface{}, before, after interface{}) {
+	var actorID *uint
+	if userID, ok := GetUserID(c); ok {
+		actorID = &userID
+	}
+	record(c, actorID, action, resourceType, resourceID, before, after)
+}
+
+// AuditUser is Audit for requests that identify the user themselves, such
+// as logins, rather than through AuthRequired. userID is 0 when unknown.
+func AuditUser(c *gin.Context, userID uint, action, resourceType string, resourceID interface{}, before, after interface{}) {
+	var actorID *uint
+	if userID != 0 {
+		actorID = &userID
+	}
+	record(c, actorID, action, resourceType, resourceID, before, after)
+}
+
+func record(c *gin.Context, actorID *uint, action, resourceType string, resourceID interface{}, before, after interface{}) {
+	entry := &models.AuditLog{
+		ActorID:        actorID,
+		OrganizationID: GetOrganizationID(c),
+		Action:         action,
+		ResourceType:   resourceType,
+		IPAddress:      c.ClientIP(),
+		UserAgent:      c.Request.UserAgent(),
+		RequestID:      GetRequestID(c),
+	}
+	if resourceID != nil {
+		entry.ResourceID = fmt.Sprint(resourceID)
+	}
+	if apiKey, ok := GetAPIKey(c); ok {
+		entry.APIKeyID = &apiKey.ID
+	}
+
+	services.NewAuditService().Record(entry, before, after)
+}
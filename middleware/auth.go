@@ -5,13 +5,28 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"creative-studio-server/models"
 	"creative-studio-server/pkg/auth"
 	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
 )
 
+// APIKeyHeader carries an API key, as an alternative to a Bearer JWT
+const APIKeyHeader = "X-API-Key"
+
+// AuthRequired accepts a Bearer JWT or an API key. Every route behind it
+// must also use PermissionRequired, which checks API key scopes too, or
+// SessionRequired, which turns API keys away.
 func AuthRequired() gin.HandlerFunc {
+	apiKeyService := services.NewAPIKeyService()
+	tokenService := services.NewTokenService()
+
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && c.GetHeader(APIKeyHeader) != "" {
+			authenticateAPIKey(c, apiKeyService)
+			return
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Authorization header is required",
@@ -40,8 +55,17 @@ func AuthRequired() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if auth.IsRevoked(claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Token has been revoked",
+			})
+			c.Abort()
+			return
+		}
+		tokenService.TouchSession(claims.SessionID, c.ClientIP())
 
 		// Set user information in context
+		c.Set("claims", claims)
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("email", claims.Email)
@@ -51,6 +75,107 @@ func AuthRequired() gin.HandlerFunc {
 	}
 }
 
+func authenticateAPIKey(c *gin.Context, apiKeyService *services.APIKeyService) {
+	apiKey, user, err := apiKeyService.Authenticate(c.GetHeader(APIKeyHeader))
+	if err != nil {
+		statusCode := http.StatusUnauthorized
+		if err.Error() == "failed to authenticate API key" {
+			statusCode = http.StatusInternalServerError
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	// Set user information in context
+	c.Set("api_key", apiKey)
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+	c.Set("email", user.Email)
+	c.Set("role", user.Role)
+
+	c.Next()
+}
+
+// PermissionRequired lets the request through when the user's role grants
+// the permission and, for API keys, when the key was granted it as well.
+func PermissionRequired(permission string) gin.HandlerFunc {
+	roleService := services.NewRoleService()
+
+	return func(c *gin.Context) {
+		role, _ := GetUserRole(c)
+		allowed, err := roleService.HasPermission(role, permission)
+		if err != nil {
+			logger.Errorf("Failed to check permission %s: %v", permission, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to check permissions",
+			})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Insufficient permissions",
+			})
+			c.Abort()
+			return
+		}
+
+		if apiKey, ok := GetAPIKey(c); ok && !apiKey.HasScope(permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "API key lacks the required scope",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SessionRequired rejects API keys, for routes that manage the account
+// itself.
+func SessionRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPIKey(c); ok {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "This endpoint cannot be used with an API key",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetAPIKey returns the API key the request was authenticated with.
+func GetAPIKey(c *gin.Context) (*models.APIKey, bool) {
+	apiKey, exists := c.Get("api_key")
+	if !exists {
+		return nil, false
+	}
+
+	key, ok := apiKey.(*models.APIKey)
+	return key, ok
+}
+
+// QueryTokenAuth lets clients that cannot set headers, such as browser
+// EventSource connections, pass the JWT as an access_token query parameter.
+// It must run before AuthRequired. The Logger leaves the token out of the
+// request logs.
+func QueryTokenAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
+
 func OptionalAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -67,12 +192,13 @@ func OptionalAuth() gin.HandlerFunc {
 
 		tokenString := tokenParts[1]
 		claims, err := auth.ParseToken(tokenString)
-		if err != nil {
+		if err != nil || auth.IsRevoked(claims) {
 			c.Next()
 			return
 		}
 
 		// Set user information in context
+		c.Set("claims", claims)
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("email", claims.Email)
@@ -82,8 +208,18 @@ func OptionalAuth() gin.HandlerFunc {
 	}
 }
 
+// RoleRequired also rejects API keys: role-restricted routes are for
+// people, not automation.
 func RoleRequired(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if _, ok := GetAPIKey(c); ok {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Insufficient permissions",
+			})
+			c.Abort()
+			return
+		}
+
 		userRole, exists := c.Get("role")
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -127,6 +263,17 @@ func GetUserID(c *gin.Context) (uint, bool) {
 	return id, ok
 }
 
+// GetClaims returns the claims of the access token of the request.
+func GetClaims(c *gin.Context) (*auth.Claims, bool) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return nil, false
+	}
+
+	authClaims, ok := claims.(*auth.Claims)
+	return authClaims, ok
+}
+
 func GetUserRole(c *gin.Context) (string, bool) {
 	role, exists := c.Get("role")
 	if !exists {
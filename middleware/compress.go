@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// minCompressedSize is the smallest response worth compressing; below it
+// the encoding's overhead outweighs the savings
+const minCompressedSize = 1024
+
+// compressibleTypes are the content types compressed; media is already
+var compressibleTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"application/xml",
+	"application/javascript",
+	"text/",
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}
+	flateWriters = sync.Pool{New: func() interface{} {
+		w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return w
+	}}
+)
+
+// compressWriter holds back the start of a response until it knows whether
+// the response is worth compressing, then compresses the rest as it is
+// written.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	buffer   []byte
+	decided  bool
+	encoder  io.WriteCloser
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buffer = append(w.buffer, data...)
+	if len(w.buffer) >= minCompressedSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends what was written so far, so streamed responses keep
+// streaming.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// decide compresses the response from here on when it is large enough and
+// of a compressible type, then writes out what was held back.
+func (w *compressWriter) decide() error {
+	w.decided = true
+
+	header := w.Header()
+	if len(w.buffer) >= minCompressedSize && header.Get("Content-Encoding") == "" &&
+		w.Status() != http.StatusNoContent && w.Status() != http.StatusNotModified &&
+		isCompressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		if w.encoding == "gzip" {
+			encoder := gzipWriters.Get().(*gzip.Writer)
+			encoder.Reset(w.ResponseWriter)
+			w.encoder = encoder
+		} else {
+			encoder := flateWriters.Get().(*flate.Writer)
+			encoder.Reset(w.ResponseWriter)
+			w.encoder = encoder
+		}
+	}
+
+	buffered := w.buffer
+	w.buffer = nil
+	if len(buffered) == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buffered)
+	} else {
+		_, err = w.ResponseWriter.Write(buffered)
+	}
+	return err
+}
+
+// close ends the compressed stream and returns the encoder to its pool.
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decide()
+	}
+	switch encoder := w.encoder.(type) {
+	case *gzip.Writer:
+		encoder.Close()
+		gzipWriters.Put(encoder)
+	case *flate.Writer:
+		encoder.Close()
+		flateWriters.Put(encoder)
+	}
+	w.encoder = nil
+}
+
+// Compress gzip- or deflate-encodes JSON and text responses of at least a
+// kilobyte for clients that accept it. Media, event streams and
+// connection upgrades are sent as they are.
+func Compress() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := acceptedEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		// Caches must keep the encodings apart
+		c.Header("Vary", "Accept-Encoding")
+		writer := &compressWriter{ResponseWriter: c.Writer, encoding: encoding}
+		c.Writer = writer
+		defer func() {
+			writer.close()
+			c.Writer = writer.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// acceptedEncoding picks gzip, or else deflate, when the Accept-Encoding
+// header allows it.
+func acceptedEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil && q == 0 {
+				continue
+			}
+		}
+		accepted[name] = true
+	}
+
+	switch {
+	case accepted["gzip"] || accepted["*"]:
+		return "gzip"
+	case accepted["deflate"]:
+		return "deflate"
+	}
+	return ""
+}
+
+func isCompressible(contentType string) bool {
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		return false
+	}
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
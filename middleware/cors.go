@@ -7,23 +7,38 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func CORS() gin.HandlerFunc {
+// CORS lets browsers on the allowed origins call the API. A * in an origin
+// matches any subdomain; a lone * allows every origin, but then browsers
+// send no credentials.
+func CORS(origins []string) gin.HandlerFunc {
+	allowAll := false
+	for _, origin := range origins {
+		if origin == "*" {
+			allowAll = true
+		}
+	}
+	if allowAll {
+		origins = nil
+	}
+
 	return cors.New(cors.Config{
-		AllowOriginFunc: func(origin string) bool {
-			// In production, you should specify exact origins
-			return true
-		},
+		AllowAllOrigins: allowAll,
+		AllowOrigins:    origins,
+		AllowWildcard:   true,
 		AllowMethods: []string{
 			"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS",
 		},
 		AllowHeaders: []string{
 			"Origin", "Content-Length", "Content-Type", "Authorization",
 			"X-Requested-With", "Accept", "Accept-Encoding", "Accept-Language",
+			"X-Request-ID", "X-API-Key", "X-Organization-ID", "Idempotency-Key",
+			"If-None-Match",
 		},
 		ExposeHeaders: []string{
-			"Content-Length", "Content-Type",
+			"Content-Length", "Content-Type", "X-Request-ID", "Idempotent-Replayed", "ETag",
+			"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After",
 		},
-		AllowCredentials: true,
+		AllowCredentials: !allowAll,
 		MaxAge:           12 * time.Hour,
 	})
 }
\ No newline at end of file
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagWriter holds back a response so its ETag can be computed from the
+// whole body.
+type etagWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *etagWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *etagWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// ETag tags successful GET responses with a weak ETag of their body and
+// answers requests whose If-None-Match carries it with 304 Not Modified, so
+// clients polling a clip or a listing only download it again once it
+// changed. The tag is weak because compression may encode the body
+// differently. Clients must revalidate before reusing a response, as it
+// depends on what the user may access.
+func ETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		writer := &etagWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.Status() != http.StatusOK {
+			writer.ResponseWriter.Write(writer.body.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(writer.body.Bytes())
+		etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", "private, no-cache")
+		if ETagMatches(c.GetHeader("If-None-Match"), etag) {
+			c.Writer.WriteHeader(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+		writer.ResponseWriter.Write(writer.body.Bytes())
+	}
+}
+
+// ETagMatches reports whether an If-None-Match header carries etag. The
+// comparison is weak, as If-None-Match's is.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// IdempotencyKeyHeader names a POST so retries of it are answered with
+	// the first response instead of being run again
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a replayed response
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxFingerprintedBody bounds the bodies hashed to tell a retry from
+	// another request reusing its key; larger ones, such as uploads, are
+	// told apart by their length
+	maxFingerprintedBody = 1 << 20
+	// maxReplayedResponse bounds the responses kept for replay; requests
+	// answering with more are run again when retried
+	maxReplayedResponse = 1 << 20
+	// idempotencyLockTTL bounds how long a request holds its key while it
+	// runs, so a request lost with its process does not hold it for good
+	idempotencyLockTTL = 15 * time.Minute
+)
+
+// idempotentResponse is the stored state of a keyed request: pending while
+// it runs, then its response.
+type idempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Pending     bool   `json:"pending,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// responseRecorder keeps a copy of the response as it is written.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseRecorder) record(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > maxReplayedResponse {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+// Idempotency honors the Idempotency-Key header on POSTs: the first
+// response to a key is kept in Redis for ttl and replayed to retries, so a
+// client retrying after a timeout does not upload a clip or start a render
+// twice. Keys are scoped to the user, or the client IP on routes without
+// authentication. A retry arriving while the first request still runs is
+// refused, and so is a key reused for a different request.
+//
+// Server errors, rate limiting and refused authentication are not kept, so
+// the retry runs again. Without Redis the header is ignored.
+func Idempotency(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" || ttl <= 0 || cache.Cache == nil {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength),
+			})
+			c.Abort()
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to read request body",
+			})
+			c.Abort()
+			return
+		}
+		storeKey := idempotencyStoreKey(c, key)
+
+		pending, _ := json.Marshal(idempotentResponse{Fingerprint: fingerprint, Pending: true})
+		acquired, err := cache.Cache.SetNX(storeKey, pending, idempotencyLockTTL)
+		if err != nil {
+			// Served unprotected rather than refused while Redis is down
+			if !errors.Is(err, cache.ErrCircuitOpen) {
+				logger.Warnf("Failed to claim idempotency key: %v", err)
+			}
+			c.Next()
+			return
+		}
+		if !acquired {
+			replayIdempotent(c, storeKey, fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if recorder.overflow || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests ||
+			status == http.StatusUnauthorized || status == http.StatusForbidden {
+			if _, err := cache.Cache.DeleteIfEquals(storeKey, string(pending)); err != nil && !errors.Is(err, cache.ErrCircuitOpen) {
+				logger.Warnf("Failed to release idempotency key: %v", err)
+			}
+			return
+		}
+
+		response := idempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := cache.Cache.Set(storeKey, response, ttl); err != nil && !errors.Is(err, cache.ErrCircuitOpen) {
+			logger.Warnf("Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// replayIdempotent answers a request whose key is already taken.
+func replayIdempotent(c *gin.Context, storeKey, fingerprint string) {
+	var stored idempotentResponse
+	err := cache.Cache.GetJSON(storeKey, &stored)
+	switch {
+	case err != nil && !errors.Is(err, cache.ErrKeyNotFound):
+		logger.Warnf("Failed to read idempotent response: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Failed to check the idempotency key, retry later",
+		})
+	case stored.Fingerprint != "" && stored.Fingerprint != fingerprint:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("%s was already used for a different request", IdempotencyKeyHeader),
+		})
+	case err != nil || stored.Pending:
+		// Released between the claim and the read when not found
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("A request with this %s is still being processed", IdempotencyKeyHeader),
+		})
+	default:
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(stored.Status, stored.ContentType, stored.Body)
+	}
+	c.Abort()
+}
+
+// requestFingerprint identifies what a request asks for, leaving its body
+// readable by the handler.
+func requestFingerprint(c *gin.Context) (string, error) {
+	hash := sha256.New()
+	io.WriteString(hash, c.Request.Method+" "+c.Request.URL.RequestURI()+"\n")
+	io.WriteString(hash, c.ContentType()+"\n")
+
+	length := c.Request.ContentLength
+	if length > 0 && length <= maxFingerprintedBody {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash.Write(body)
+	} else {
+		io.WriteString(hash, strconv.FormatInt(length, 10))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// idempotencyStoreKey scopes the client's key to the user, or the client IP
+// without authentication, and the route.
+func idempotencyStoreKey(c *gin.Context, key string) string {
+	scope := "ip:" + c.ClientIP()
+	if userID, ok := GetUserID(c); ok {
+		scope = "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	sum := sha256.Sum256([]byte(scope + "\n" + c.Request.URL.Path + "\n" + key))
+	return "idempotency:" + hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,10 +12,11 @@ import (
 func Logger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		logger.WithFields(logrus.Fields{
+			"request_id":  param.Keys["request_id"],
 			"client_ip":   param.ClientIP,
 			"timestamp":   param.TimeStamp.Format(time.RFC3339),
 			"method":      param.Method,
-			"path":        param.Path,
+			"path":        redactQuery(param.Request.URL),
 			"protocol":    param.Request.Proto,
 			"status_code": param.StatusCode,
 			"latency":     param.Latency,
@@ -25,6 +27,25 @@ func Logger() gin.HandlerFunc {
 	})
 }
 
+// redactedParams are query parameters that carry credentials, such as the
+// JWT QueryTokenAuth accepts and share link passwords, and are left out of
+// the logs
+var redactedParams = []string{"access_token", "password"}
+
+// redactQuery returns the path and query of a request URL as it is logged.
+func redactQuery(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	query := u.Query()
+	for _, param := range redactedParams {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+		}
+	}
+	return u.Path + "?" + query.Encode()
+}
+
 func Recovery() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
 		logger.WithFields(logrus.Fields{
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/models"
+	"creative-studio-server/services"
+)
+
+// Maintenance refuses requests with 503 Service Unavailable while the API
+// is in maintenance: writes in read-only mode, everything in full mode.
+// Routes in exempt, keyed by their full path, are always served, so health
+// checks keep passing and operators can turn maintenance off again.
+func Maintenance(exempt map[string]bool) gin.HandlerFunc {
+	maintenanceService := services.NewMaintenanceService()
+
+	return func(c *gin.Context) {
+		if exempt[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		maintenance := maintenanceService.GetMaintenance()
+		switch maintenance.Mode {
+		case models.MaintenanceFull:
+		case models.MaintenanceReadOnly:
+			switch c.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				c.Next()
+				return
+			}
+		default:
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", strconv.Itoa(maintenance.RetryAfter))
+		response := gin.H{
+			"error":       "Service is under maintenance",
+			"maintenance": maintenance.Mode,
+		}
+		if maintenance.Message != "" {
+			response["message"] = maintenance.Message
+		}
+		c.JSON(http.StatusServiceUnavailable, response)
+		c.Abort()
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/models"
+	"creative-studio-server/services"
+)
+
+// OrganizationHeader selects the organization workspace of a request.
+// Without it requests work in the user's personal workspace.
+const OrganizationHeader = "X-Organization-ID"
+
+// OrganizationContext switches the request to the organization named by
+// the X-Organization-ID header, once the user is found to be a member who
+// meets its two-factor policy. It must run after AuthRequired.
+func OrganizationContext() gin.HandlerFunc {
+	organizationService := services.NewOrganizationService()
+
+	return func(c *gin.Context) {
+		header := c.GetHeader(OrganizationHeader)
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		organizationID, err := strconv.ParseUint(header, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid organization ID",
+			})
+			c.Abort()
+			return
+		}
+
+		userID, _ := GetUserID(c)
+		membership, err := organizationService.EnterWorkspace(uint(organizationID), userID)
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			switch err.Error() {
+			case "organization not found":
+				statusCode = http.StatusNotFound
+			case "organization requires two-factor authentication":
+				statusCode = http.StatusForbidden
+			}
+			c.JSON(statusCode, gin.H{
+				"error": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("membership", membership)
+		c.Next()
+	}
+}
+
+// GetOrganizationID returns the organization the request works in, or nil
+// for the personal workspace.
+func GetOrganizationID(c *gin.Context) *uint {
+	membership, ok := GetMembership(c)
+	if !ok {
+		return nil
+	}
+	return &membership.OrganizationID
+}
+
+// GetMembership returns the user's membership of the request's
+// organization.
+func GetMembership(c *gin.Context) (*models.Membership, bool) {
+	membership, exists := c.Get("membership")
+	if !exists {
+		return nil, false
+	}
+
+	m, ok := membership.(*models.Membership)
+	return m, ok
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
+)
+
+// Quota refuses requests using a quota of the user's plan that is used up:
+// 429 Too Many Requests with a Retry-After header for the daily upload
+// quota, which starts over tomorrow, and 402 Payment Required for monthly
+// and storage quotas, which take a bigger plan or freeing storage. It must
+// run after AuthRequired.
+func Quota(quota string) gin.HandlerFunc {
+	quotaService := services.NewQuotaService()
+
+	return func(c *gin.Context) {
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		usage, err := quotaService.CheckQuota(userID, quota)
+		if err == nil {
+			c.Next()
+			return
+		}
+		if usage == nil {
+			// The handler is left to fail if the database does
+			logger.Warnf("Failed to check %s quota of user %d: %v", quota, userID, err)
+			c.Next()
+			return
+		}
+
+		statusCode := http.StatusPaymentRequired
+		if err.Error() == "daily upload quota exceeded" {
+			statusCode = http.StatusTooManyRequests
+			retryAfter := math.Ceil(time.Until(*usage.DailyUploads.ResetsAt).Seconds())
+			c.Header("Retry-After", strconv.Itoa(int(math.Max(1, retryAfter))))
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+			"usage": usage,
+		})
+		c.Abort()
+	}
+}
+
+func UploadQuota() gin.HandlerFunc {
+	return Quota(services.QuotaUploads)
+}
+
+func RenderQuota() gin.HandlerFunc {
+	return Quota(services.QuotaRenders)
+}
@@ -1,47 +1,117 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/services"
 )
 
-type RateLimiter struct {
-	limiter *rate.Limiter
+// localSweepInterval is how often idle buckets are dropped from the
+// in-process fallback
+const localSweepInterval = time.Minute
+
+// localLimiters are the buckets used while Redis is unavailable. They only
+// limit the requests this instance serves.
+var localLimiters = &localBuckets{buckets: make(map[string]*localBucket)}
+
+type localBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
 }
 
-func NewRateLimiter(r rate.Limit, b int) *RateLimiter {
-	return &RateLimiter{
-		limiter: rate.NewLimiter(r, b),
+type localBuckets struct {
+	mu        sync.Mutex
+	buckets   map[string]*localBucket
+	lastSweep time.Time
+}
+
+// take takes a token from the bucket at key, creating it full. Buckets idle
+// long enough to have refilled are dropped, since a new one is the same.
+func (b *localBuckets) take(key string, perSecond float64, burst int) *cache.TokenBucket {
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Sub(b.lastSweep) >= localSweepInterval {
+		for k, bucket := range b.buckets {
+			refill := time.Duration(float64(bucket.limiter.Burst()) / float64(bucket.limiter.Limit()) * float64(time.Second))
+			if now.Sub(bucket.lastSeen) > refill {
+				delete(b.buckets, k)
+			}
+		}
+		b.lastSweep = now
+	}
+
+	bucket, exists := b.buckets[key]
+	if !exists {
+		bucket = &localBucket{limiter: rate.NewLimiter(rate.Limit(perSecond), burst)}
+		b.buckets[key] = bucket
+	}
+	bucket.lastSeen = now
+
+	allowed := bucket.limiter.AllowN(now, 1)
+	tokens := bucket.limiter.TokensAt(now)
+	result := &cache.TokenBucket{
+		Allowed:   allowed,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     time.Duration((float64(burst) - tokens) / perSecond * float64(time.Second)),
+	}
+	if tokens < 1 {
+		result.RetryAfter = time.Duration((1 - tokens) / perSecond * float64(time.Second))
 	}
+	return result
 }
 
-func (rl *RateLimiter) Allow() bool {
-	return rl.limiter.Allow()
+// RateLimit limits each client IP separately on every route it guards, for
+// routes served without authentication.
+func RateLimit(requestsPerMinute int, burst int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ratelimit:ip:" + c.FullPath() + ":" + c.ClientIP()
+		if !limit(c, key, requestsPerMinute, burst) {
+			return
+		}
+
+		c.Next()
+	}
 }
 
-var limiters = make(map[string]*RateLimiter)
+// PlanRateLimit limits authenticated requests by the plan of the user, or of
+// the owner of the API key they were made with. Each API key has its own
+// budget, separate from its owner's interactive requests. It must run after
+// AuthRequired.
+func PlanRateLimit() gin.HandlerFunc {
+	userService := services.NewUserService()
 
-func RateLimit(requestsPerMinute int, burst int) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-		
-		limiter, exists := limiters[clientIP]
-		if !exists {
-			limiter = NewRateLimiter(rate.Every(time.Minute/time.Duration(requestsPerMinute)), burst)
-			limiters[clientIP] = limiter
-		}
-
-		if !limiter.Allow() {
-			c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", requestsPerMinute))
-			c.Header("X-RateLimit-Remaining", "0")
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded",
-			})
-			c.Abort()
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		key := "ratelimit:user:" + strconv.FormatUint(uint64(userID), 10)
+		if apiKey, ok := GetAPIKey(c); ok {
+			key = "ratelimit:key:" + strconv.FormatUint(uint64(apiKey.ID), 10)
+		}
+
+		planName, err := userService.GetUserPlan(userID)
+		if err != nil {
+			logger.Warnf("Failed to get rate limit plan, using the default: %v", err)
+			planName = config.AppConfig.RateLimit.DefaultPlan
+		}
+		plan := config.AppConfig.RateLimit.Plans[planName]
+		if !limit(c, key, plan.RequestsPerMinute, plan.Burst) {
 			return
 		}
 
@@ -49,10 +119,54 @@ func RateLimit(requestsPerMinute int, burst int) gin.HandlerFunc {
 	}
 }
 
+// limit takes a token from the bucket at key and sets the rate limit headers,
+// answering 429 when none is left. Buckets live in Redis so every instance
+// shares them; while Redis is unavailable each instance limits on its own.
+// A limit of zero requests per minute is unlimited.
+func limit(c *gin.Context, key string, requestsPerMinute int, burst int) bool {
+	if requestsPerMinute <= 0 {
+		return true
+	}
+	if burst <= 0 {
+		burst = requestsPerMinute
+	}
+	perSecond := float64(requestsPerMinute) / 60
+
+	var bucket *cache.TokenBucket
+	if cache.Cache != nil {
+		var err error
+		bucket, err = cache.Cache.TakeToken(key, perSecond, burst)
+		if err != nil && !errors.Is(err, cache.ErrCircuitOpen) {
+			logger.Warnf("Failed to check rate limit, limiting locally: %v", err)
+		}
+	}
+	if bucket == nil {
+		bucket = localLimiters.take(key, perSecond, burst)
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(burst))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(bucket.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(bucket.Reset.Seconds()))))
+
+	if !bucket.Allowed {
+		retryAfter := int(math.Ceil(bucket.RetryAfter.Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Rate limit exceeded",
+		})
+		c.Abort()
+		return false
+	}
+	return true
+}
+
 func AuthRateLimit() gin.HandlerFunc {
 	return RateLimit(5, 10) // 5 requests per minute with burst of 10
 }
 
 func APIRateLimit() gin.HandlerFunc {
 	return RateLimit(100, 200) // 100 requests per minute with burst of 200
-}
\ No newline at end of file
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"creative-studio-server/pkg/tracing"
+)
+
+// RequestID tags every request with a correlation ID, reusing the one the
+// caller sent in X-Request-ID when it is valid, and echoes it back. Tasks
+// queued by the request carry the same ID.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(tracing.Header)
+		if !tracing.ValidID(id) {
+			id = tracing.NewID()
+		}
+
+		c.Set("request_id", id)
+		c.Header(tracing.Header, id)
+		c.Next()
+	}
+}
+
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// AccountToken is a single-use token emailed to a user to verify their
+// address or reset their password. Only its SHA-256 hash is stored.
+type AccountToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Purpose   string     `json:"purpose" gorm:"not null;size:30"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (AccountToken) TableName() string {
+	return "account_tokens"
+}
+
+// Account token purposes
+const (
+	AccountTokenEmailVerification = "email_verification"
+	AccountTokenPasswordReset     = "password_reset"
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnalysisBackfill re-analyzes the clips whose analysis predates a version
+// of the analysis pipeline, a batch at a time, so what analysis learned
+// since applies to libraries uploaded before.
+type AnalysisBackfill struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	Version      string `json:"version" gorm:"not null;size:20"` // pipeline version clips are brought up to
+	AnalysisType string `json:"analysis_type" gorm:"not null;size:20"`
+	BatchSize    int    `json:"batch_size"`
+	Status       string `json:"status" gorm:"not null;size:20;index"` // running, completed, cancelled
+	CreatedBy    uint   `json:"created_by"`
+
+	// Progress; Total is counted when the backfill starts
+	Total      int64  `json:"total"`
+	Queued     int64  `json:"queued"`
+	LastClipID uint   `json:"last_clip_id"`                          // clips are queued in ID order
+	LastError  string `json:"last_error,omitempty" gorm:"type:text"` // of the last batch that failed to queue
+
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+func (AnalysisBackfill) TableName() string {
+	return "analysis_backfills"
+}
+
+const (
+	AnalysisBackfillRunning   = "running"
+	AnalysisBackfillCompleted = "completed"
+	AnalysisBackfillCancelled = "cancelled"
+)
+
+// AnalysisBackfillRequest starts a backfill to the current pipeline
+// version. Batches default to 100 clips.
+type AnalysisBackfillRequest struct {
+	AnalysisType string `json:"analysis_type" binding:"omitempty,oneof=full content motion color audio"`
+	BatchSize    int    `json:"batch_size" binding:"omitempty,min=1,max=1000"`
+}
+
+// CompareAnalysisVersions orders dotted analysis versions such as "1.10"
+// and "1.2" by their numbers, returning -1, 0 or 1. A missing version, from
+// analyses stored before versions were recorded, is older than any.
+func CompareAnalysisVersions(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return -1
+	case b == "":
+		return 1
+	}
+
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKey lets automation act as its owner through the X-API-Key header,
+// limited to its scopes. Only the SHA-256 hash of the key is stored; the
+// prefix identifies the key in listings.
+type APIKey struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	UserID     uint        `json:"user_id" gorm:"not null;index"`
+	Name       string      `json:"name" gorm:"not null;size:100"`
+	Prefix     string      `json:"prefix" gorm:"not null;size:16"`
+	KeyHash    string      `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	Scopes     StringArray `json:"scopes" gorm:"type:text"`
+	ExpiresAt  *time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time  `json:"revoked_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// HasScope reports whether the key was granted the permission.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyScopes lists the permissions a key can be granted. A key only
+// works within the permissions of its owner's role as well.
+var APIKeyScopes = []string{
+	PermissionClipsRead,
+	PermissionClipsWrite,
+	PermissionRendersRead,
+	PermissionRendersCreate,
+	PermissionCompositionsRead,
+	PermissionCompositionsWrite,
+	PermissionCompositionsReview,
+}
+
+type APIKeyCreateRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// ExpiresInDays leaves the key valid forever when omitted
+	ExpiresInDays *int `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// APIKeyCreateResponse carries the key itself, which is only ever shown
+// when it is created or rotated.
+type APIKeyCreateResponse struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
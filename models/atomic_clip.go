@@ -3,6 +3,8 @@ package models
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,14 +15,16 @@ type AtomicClip struct {
 	Title       string    `json:"title" gorm:"not null;size:200"`
 	Description string    `json:"description" gorm:"type:text"`
 	FilePath    string    `json:"file_path" gorm:"not null;size:500"`
-	FileSize    int64     `json:"file_size"`
-	Duration    float64   `json:"duration"`
+	FileSize    int64     `json:"file_size" gorm:"index"`
+	Duration    float64   `json:"duration" gorm:"index"`
 	Resolution  string    `json:"resolution" gorm:"size:20"`
 	FrameRate   float64   `json:"frame_rate"`
 	Codec       string    `json:"codec" gorm:"size:50"`
 	Bitrate     int       `json:"bitrate"`
 	Format      string    `json:"format" gorm:"size:20"`
 	Thumbnail   string    `json:"thumbnail" gorm:"size:500"`
+	// ThumbnailTime overrides the frame analysis picked for the thumbnail
+	ThumbnailTime *float64 `json:"thumbnail_time,omitempty"`
 	
 	// Classification fields
 	Category    string    `json:"category" gorm:"size:50"`
@@ -38,16 +42,61 @@ type AtomicClip struct {
 	// Metadata
 	Metadata    JSON      `json:"metadata" gorm:"type:jsonb"`
 	
+	// Engagement, kept in step with the favorites and ratings tables
+	FavoriteCount int     `json:"favorite_count" gorm:"default:0;index"`
+	RatingCount   int     `json:"rating_count" gorm:"default:0"`
+	RatingAverage float64 `json:"rating_average" gorm:"default:0;index"`
+	// UsageCount is the number of project timelines using the clip
+	UsageCount    int     `json:"usage_count" gorm:"default:0;index"`
+	// QualityScore rates the technical quality of the file from 0 to 1
+	QualityScore  float64 `json:"quality_score" gorm:"default:0;index"`
+	
 	// Status and relations
 	Status      string    `json:"status" gorm:"default:'active';size:20"`
 	UserID      uint      `json:"user_id" gorm:"not null"`
-	CreatedAt   time.Time `json:"created_at"`
+	// OrganizationID puts the clip in a team library; nil keeps it personal
+	OrganizationID *uint  `json:"organization_id" gorm:"index"`
+	// Visibility lets users outside the library open the clip
+	Visibility  string    `json:"visibility" gorm:"default:'private';size:20;index"`
+	// Stock puts the clip in the stock library compositions can draw from:
+	// its organization's, or everyone's for a personal clip
+	Stock           bool       `json:"stock" gorm:"default:false;index"`
+	StockApprovedBy *uint      `json:"stock_approved_by,omitempty"`
+	StockApprovedAt *time.Time `json:"stock_approved_at,omitempty"`
+	// Moderation holds the classifier's verdict until a reviewer decides
+	ModerationStatus string      `json:"moderation_status" gorm:"default:'pending';size:20;index"`
+	ModerationScore  float64     `json:"moderation_score"`
+	ModerationLabels StringArray `json:"moderation_labels" gorm:"type:text"`
+	ModeratedBy      *uint       `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time  `json:"moderated_at,omitempty"`
+	ModerationNote   string      `json:"moderation_note,omitempty" gorm:"size:500"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 	
 	// Relations
 	User         User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	VideoAnalysis *VideoAnalysis `json:"video_analysis,omitempty" gorm:"foreignKey:AtomicClipID"`
+	MusicAnalysis *MusicAnalysis `json:"music_analysis,omitempty" gorm:"foreignKey:AtomicClipID"`
+	// Transcript is loaded for indexing; the API serves it on its own
+	Transcript   *ClipTranscript `json:"-" gorm:"foreignKey:AtomicClipID"`
+}
+
+// TechnicalQuality scores the file from 0 to 1 on its resolution, bitrate
+// and frame rate, each counting in full from what a 1080p delivery needs.
+func (c *AtomicClip) TechnicalQuality() float64 {
+	var width, height int
+	fmt.Sscanf(c.Resolution, "%dx%d", &width, &height)
+	// Probed bitrates are in bit/s, older clips recorded kbit/s
+	kbps := float64(c.Bitrate)
+	if kbps > 100000 {
+		kbps /= 1000
+	}
+
+	pixels := math.Min(float64(width*height)/(1920*1080), 1)
+	bitrate := math.Min(kbps/8000, 1)
+	frameRate := math.Min(c.FrameRate/30, 1)
+	return math.Round((0.5*pixels+0.3*bitrate+0.2*frameRate)*1000) / 1000
 }
 
 type AtomicClipCreateRequest struct {
@@ -58,6 +107,7 @@ type AtomicClipCreateRequest struct {
 	Mood        string      `json:"mood" binding:"omitempty,max=50"`
 	Style       string      `json:"style" binding:"omitempty,max=50"`
 	Color       string      `json:"color" binding:"omitempty,max=50"`
+	Visibility  string      `json:"visibility" binding:"omitempty,oneof=private unlisted public"` // default private
 }
 
 type AtomicClipUpdateRequest struct {
@@ -68,10 +118,78 @@ type AtomicClipUpdateRequest struct {
 	Mood        string   `json:"mood" binding:"omitempty,max=50"`
 	Style       string   `json:"style" binding:"omitempty,max=50"`
 	Color       string   `json:"color" binding:"omitempty,max=50"`
+	Visibility  string   `json:"visibility" binding:"omitempty,oneof=private unlisted public"`
 }
 
+// Clip visibilities: private clips are seen only in their library,
+// unlisted ones by anyone with their ID, public ones are also searchable
+const (
+	ClipVisibilityPrivate  = "private"
+	ClipVisibilityUnlisted = "unlisted"
+	ClipVisibilityPublic   = "public"
+)
+
+// ClipStockRequest adds a clip to the stock library or takes it out.
+type ClipStockRequest struct {
+	Stock bool `json:"stock"`
+}
+
+// ClipDownloadRequest selects a transcoded variant of a clip to download.
+// Without a format, resolution or quality the original file is sent;
+// otherwise the clip is re-encoded to H.264, as mp4 unless another format
+// is asked for.
+type ClipDownloadRequest struct {
+	Format     string `form:"format" binding:"omitempty,oneof=mp4 mov mkv"`
+	Resolution string `form:"resolution" binding:"omitempty,oneof=360p 480p 720p 1080p 1440p 2160p"`
+	Quality    string `form:"quality" binding:"omitempty,oneof=low medium high"` // default medium
+}
+
+// ClipThumbnailRequest selects the frame of a clip thumbnail and the box
+// it is scaled to fit, keeping its aspect ratio. Without a time the clip's
+// thumbnail frame is used: the one chosen for it, else the one analysis
+// picked, else the frame one second in, or the middle one of shorter clips.
+type ClipThumbnailRequest struct {
+	Width  int      `form:"w" binding:"omitempty,min=16,max=1920"`
+	Height int      `form:"h" binding:"omitempty,min=16,max=1920"`
+	Time   *float64 `form:"t" binding:"omitempty,min=0"`
+}
+
+// ClipThumbnailUpdateRequest chooses the frame of a clip's thumbnail by
+// hand. A null time goes back to the frame analysis picked.
+type ClipThumbnailUpdateRequest struct {
+	Time *float64 `json:"time" binding:"omitempty,min=0"`
+}
+
+// StockScope limits a query to the stock clips the user can compose with:
+// those of the user's organizations and the global ones, approved from
+// personal libraries, that moderation does not block.
+func StockScope(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		organizations := db.Session(&gorm.Session{NewDB: true}).Model(&Membership{}).
+			Select("organization_id").Where("user_id = ?", userID)
+		return db.Where("stock = ? AND moderation_status NOT IN ? AND (organization_id IS NULL OR organization_id IN (?))", true, ModerationBlocked, organizations)
+	}
+}
+
+// Clip search libraries: the current workspace, every public clip or the
+// stock library
+const (
+	ClipLibraryWorkspace = "workspace"
+	ClipLibraryPublic    = "public"
+	ClipLibraryStock     = "stock"
+)
+
+// Clip search modes: keyword matches the words of the query, semantic
+// the meaning of a description
+const (
+	ClipSearchKeyword  = "keyword"
+	ClipSearchSemantic = "semantic"
+)
+
 type AtomicClipSearchRequest struct {
 	Query      string   `json:"query" form:"query"`
+	Mode       string   `json:"mode" form:"mode" binding:"omitempty,oneof=keyword semantic"` // default keyword
+	Library    string   `json:"library" form:"library" binding:"omitempty,oneof=workspace public stock"` // default workspace
 	Category   string   `json:"category" form:"category"`
 	Tags       []string `json:"tags" form:"tags"`
 	Mood       string   `json:"mood" form:"mood"`
@@ -79,8 +197,101 @@ type AtomicClipSearchRequest struct {
 	Color      string   `json:"color" form:"color"`
 	Duration   string   `json:"duration" form:"duration"` // "short", "medium", "long"
 	Resolution string   `json:"resolution" form:"resolution"`
+	Sort       string   `json:"sort" form:"sort" binding:"omitempty,oneof=newest rating favorites"` // sort_by takes precedence
+	SortBy     string   `json:"sort_by" form:"sort_by" binding:"omitempty,oneof=created_at duration file_size quality usage rating favorites relevance"`
+	SortOrder  string   `json:"sort_order" form:"sort_order" binding:"omitempty,oneof=asc desc"` // default desc
 	Page       int      `json:"page" form:"page,default=1"`
 	Limit      int      `json:"limit" form:"limit,default=20"`
+	// Cursor resumes a search after the page it was returned with; page is
+	// then ignored
+	Cursor     string   `json:"cursor" form:"cursor"`
+	// Facets adds the counts of each value of the ClipSearchFacets
+	Facets     bool     `json:"facets" form:"facets"`
+	// CreatedAfter limits the search to clips added since; saved searches
+	// use it to find new matches
+	CreatedAfter *time.Time `json:"created_after,omitempty" form:"-"`
+}
+
+// ClipSearchFacets are the fields a clip search counts values of. Each
+// facet is counted with every filter but its own, so the counts show what
+// choosing another value would return.
+var ClipSearchFacets = []string{"category", "mood", "style", "resolution", "duration"}
+
+// FacetCount is how many clips of a search have one value of a facet.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// ClipSearchResult is a page of a clip search.
+type ClipSearchResult struct {
+	Clips      []AtomicClip            `json:"clips"`
+	Total      int64                   `json:"total"`
+	NextCursor string                  `json:"next_cursor"`
+	Facets     map[string][]FacetCount `json:"facets,omitempty"`
+}
+
+// DurationBucket is the duration filter a clip of that many seconds
+// matches: short, medium or long.
+func DurationBucket(duration float64) string {
+	switch {
+	case duration < 30:
+		return "short"
+	case duration <= 180:
+		return "medium"
+	}
+	return "long"
+}
+
+// AtomicClipListRequest pages the clips of a workspace by page number or,
+// for large libraries, by the cursor of the previous page.
+type AtomicClipListRequest struct {
+	Page   int    `form:"page,default=1" binding:"min=1"`
+	Limit  int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Cursor string `form:"cursor"`
+}
+
+// Actions of a clip batch
+const (
+	ClipBatchDelete           = "delete"
+	ClipBatchAddTags          = "add_tags"
+	ClipBatchRemoveTags       = "remove_tags"
+	ClipBatchUpdate           = "update"
+	ClipBatchMoveToCollection = "move_to_collection"
+)
+
+// MaxClipBatch bounds how many clips one batch request changes
+const MaxClipBatch = 500
+
+// AtomicClipBatchRequest applies one action to many clips. Tags are used by
+// add_tags and remove_tags, Category and Mood by update. move_to_collection
+// adds the clips to CollectionID and drops them from FromCollectionID when
+// it is set. Force lets delete remove clips active projects still use.
+type AtomicClipBatchRequest struct {
+	Action           string   `json:"action" binding:"required,oneof=delete add_tags remove_tags update move_to_collection"`
+	ClipIDs          []uint   `json:"clip_ids" binding:"required,min=1,max=500"`
+	Tags             []string `json:"tags" binding:"omitempty"`
+	Category         *string  `json:"category" binding:"omitempty,max=50"`
+	Mood             *string  `json:"mood" binding:"omitempty,max=50"`
+	CollectionID     *uint    `json:"collection_id"`
+	FromCollectionID *uint    `json:"from_collection_id"`
+	Force            bool     `json:"force"`
+}
+
+// Statuses of a clip batch item
+const (
+	ClipBatchItemOK     = "ok"
+	ClipBatchItemFailed = "failed"
+	// ClipBatchItemSkipped marks valid items left unchanged because
+	// another item failed
+	ClipBatchItemSkipped = "skipped"
+)
+
+// ClipBatchResult reports what a batch did to one clip
+type ClipBatchResult struct {
+	ClipID uint   `json:"clip_id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
 }
 
 // Custom types for PostgreSQL arrays and JSON
@@ -108,6 +319,30 @@ func (s *StringArray) Scan(value interface{}) error {
 	return nil
 }
 
+type Float64Array []float64
+
+func (f Float64Array) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *Float64Array) Scan(value interface{}) error {
+	if value == nil {
+		*f = nil
+		return nil
+	}
+	
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	}
+	return nil
+}
+
 type JSON map[string]interface{}
 
 func (j JSON) Value() (driver.Value, error) {
@@ -130,4 +365,38 @@ func (j *JSON) Scan(value interface{}) error {
 		return json.Unmarshal([]byte(v), j)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// RawJSON stores an arbitrary JSON document (arrays included) in a text column.
+type RawJSON []byte
+
+func (r RawJSON) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	return string(r), nil
+}
+
+func (r *RawJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*r = nil
+	case []byte:
+		*r = append((*r)[:0], v...)
+	case string:
+		*r = RawJSON(v)
+	}
+	return nil
+}
+
+func (r RawJSON) MarshalJSON() ([]byte, error) {
+	if len(r) == 0 {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+func (r *RawJSON) UnmarshalJSON(data []byte) error {
+	*r = append((*r)[:0], data...)
+	return nil
+}
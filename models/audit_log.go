@@ -0,0 +1,122 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditLog records who did what to which resource, with snapshots of the
+// resource before and after. Entries are only ever appended.
+type AuditLog struct {
+	ID             uint    `json:"id" gorm:"primaryKey"`
+	ActorID        *uint   `json:"actor_id" gorm:"index"` // nil for anonymous requests, e.g. failed logins
+	APIKeyID       *uint   `json:"api_key_id,omitempty"`  // set when the actor used an API key
+	OrganizationID *uint   `json:"organization_id,omitempty" gorm:"index"`
+	Action         string  `json:"action" gorm:"not null;size:50;index"`
+	ResourceType   string  `json:"resource_type" gorm:"size:50;index:idx_audit_resource"`
+	ResourceID     string  `json:"resource_id" gorm:"size:64;index:idx_audit_resource"`
+	Before         RawJSON `json:"before,omitempty" gorm:"type:text"`
+	After          RawJSON `json:"after,omitempty" gorm:"type:text"`
+	IPAddress      string  `json:"ip_address" gorm:"size:45"`
+	UserAgent      string  `json:"user_agent" gorm:"size:255"`
+	RequestID      string  `json:"request_id" gorm:"size:64"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+var errAuditLogAppendOnly = errors.New("audit logs are append-only")
+
+func (AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return errAuditLogAppendOnly
+}
+
+func (AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return errAuditLogAppendOnly
+}
+
+// Audited actions
+const (
+	AuditActionLogin              = "auth.login"
+	AuditActionLoginFailed        = "auth.login_failed"
+	AuditActionLogout             = "auth.logout"
+	AuditActionPasswordChange     = "auth.password_change"
+	AuditActionPasswordReset      = "auth.password_reset"
+	AuditActionTwoFactorEnable    = "auth.2fa_enable"
+	AuditActionTwoFactorDisable   = "auth.2fa_disable"
+	AuditActionSessionRevoke      = "session.revoke"
+	AuditActionAPIKeyCreate       = "api_key.create"
+	AuditActionAPIKeyRotate       = "api_key.rotate"
+	AuditActionAPIKeyRevoke       = "api_key.revoke"
+	AuditActionRoleCreate         = "role.create"
+	AuditActionRoleUpdate         = "role.update"
+	AuditActionRoleDelete         = "role.delete"
+	AuditActionUserRoleChange     = "user.role_change"
+	AuditActionUserPlanChange     = "user.plan_change"
+	AuditActionClipCreate         = "clip.create"
+	AuditActionClipUpdate         = "clip.update"
+	AuditActionClipDelete         = "clip.delete"
+	AuditActionClipImport         = "clip.import"
+	AuditActionClipBatch          = "clip.batch"
+	AuditActionClipUndelete       = "clip.undelete"
+	AuditActionClipStock          = "clip.stock"
+	AuditActionClipModerate       = "clip.moderate"
+	AuditActionRenderCreate       = "render.create"
+	AuditActionRenderCancel       = "render.cancel"
+	AuditActionRenderRetry        = "render.retry"
+	AuditActionProjectCreate      = "project.create"
+	AuditActionProjectUpdate      = "project.update"
+	AuditActionProjectArchive     = "project.archive"
+	AuditActionProjectRestore     = "project.restore"
+	AuditActionProjectImport      = "project.import"
+	AuditActionProjectDelete      = "project.delete"
+	AuditActionProjectUndelete    = "project.undelete"
+	AuditActionTemplateCreate     = "template.create"
+	AuditActionTemplateUpdate     = "template.update"
+	AuditActionTemplatePublish    = "template.publish"
+	AuditActionTemplateDelete     = "template.delete"
+	AuditActionCollectionCreate   = "collection.create"
+	AuditActionCollectionUpdate   = "collection.update"
+	AuditActionCollectionDelete   = "collection.delete"
+	AuditActionSavedSearchCreate  = "saved_search.create"
+	AuditActionSavedSearchUpdate  = "saved_search.update"
+	AuditActionSavedSearchDelete  = "saved_search.delete"
+	AuditActionTagRename          = "tag.rename"
+	AuditActionTagMerge           = "tag.merge"
+	AuditActionOrganizationCreate = "organization.create"
+	AuditActionOrganizationUpdate = "organization.update"
+	AuditActionMemberAdd          = "organization.member_add"
+	AuditActionMemberUpdate       = "organization.member_update"
+	AuditActionMemberRemove       = "organization.member_remove"
+	AuditActionSigningKeyRotate   = "signing_key.rotate"
+	AuditActionSigningKeyRevoke   = "signing_key.revoke"
+	AuditActionAuditExport        = "audit.export"
+	AuditActionShareCreate        = "share_link.create"
+	AuditActionShareRevoke        = "share_link.revoke"
+	AuditActionWebhookCreate      = "webhook.create"
+	AuditActionWebhookUpdate      = "webhook.update"
+	AuditActionWebhookDelete      = "webhook.delete"
+	AuditActionWebhookRotate      = "webhook.rotate_secret"
+	AuditActionBackfillStart      = "analysis_backfill.start"
+	AuditActionBackfillCancel     = "analysis_backfill.cancel"
+	AuditActionMaintenanceUpdate  = "maintenance.update"
+)
+
+// AuditLogQuery filters audit log entries. Times are RFC 3339.
+type AuditLogQuery struct {
+	ActorID        uint      `json:"actor_id,omitempty" form:"actor_id"`
+	OrganizationID uint      `json:"organization_id,omitempty" form:"organization_id"`
+	Action         string    `json:"action,omitempty" form:"action"`
+	ResourceType   string    `json:"resource_type,omitempty" form:"resource_type"`
+	ResourceID     string    `json:"resource_id,omitempty" form:"resource_id"`
+	From           time.Time `json:"from" form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To             time.Time `json:"to" form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page           int       `json:"-" form:"page,default=1" binding:"min=1"`
+	Limit          int       `json:"-" form:"limit,default=50" binding:"min=1,max=200"`
+	Cursor         string    `json:"-" form:"cursor"`
+}
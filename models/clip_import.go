@@ -0,0 +1,100 @@
+package models
+
+import "time"
+
+// ClipImport is a batch of clips created from an uploaded ZIP archive, or
+// a single clip downloaded from a URL. The archive is kept until the
+// import has been processed.
+type ClipImport struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	UserID         uint   `json:"user_id" gorm:"not null;index"`
+	OrganizationID *uint  `json:"organization_id" gorm:"index"`
+	FileName       string `json:"file_name" gorm:"size:255"`
+	ArchivePath    string `json:"-" gorm:"size:500"`
+	Status         string `json:"status" gorm:"size:20;index"` // pending, processing, completed, failed
+	TotalFiles     int    `json:"total_files"`
+	CreatedClips   int    `json:"created_clips"`
+	FailedFiles    int    `json:"failed_files"`
+	ErrorMessage   string `json:"error_message,omitempty" gorm:"type:text"`
+	TraceID        string `json:"trace_id,omitempty" gorm:"size:64;index"`
+	// SourceURL is set on imports that download their video
+	SourceURL string `json:"source_url,omitempty" gorm:"size:2000"`
+	// Download progress; TotalBytes is 0 while unknown
+	DownloadedBytes int64 `json:"downloaded_bytes,omitempty"`
+	TotalBytes      int64 `json:"total_bytes,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+
+	// Relations
+	Items []ClipImportItem `json:"items,omitempty" gorm:"foreignKey:ImportID"`
+}
+
+func (ClipImport) TableName() string {
+	return "clip_imports"
+}
+
+// ClipImportItem is one video file of an import and what became of it.
+type ClipImportItem struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	ImportID     uint   `json:"import_id" gorm:"not null;index"`
+	FileName     string `json:"file_name" gorm:"size:500"` // path inside the archive
+	Status       string `json:"status" gorm:"size:20"`     // pending, created, failed
+	AtomicClipID *uint  `json:"atomic_clip_id"`
+	Error        string `json:"error,omitempty" gorm:"type:text"`
+	// DuplicateOfID warns that the clip's footage is already in the
+	// library, as the clip most like it; set once the clip is analyzed
+	DuplicateOfID *uint `json:"duplicate_of_id,omitempty"`
+	// Metadata is the clip's manifest entry, applied when it is created
+	Metadata RawJSON `json:"metadata,omitempty" gorm:"type:text"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (ClipImportItem) TableName() string {
+	return "clip_import_items"
+}
+
+const (
+	ImportStatusPending    = "pending"
+	ImportStatusProcessing = "processing"
+	ImportStatusCompleted  = "completed"
+	ImportStatusFailed     = "failed"
+
+	ImportItemPending = "pending"
+	ImportItemCreated = "created"
+	ImportItemFailed  = "failed"
+)
+
+// ClipManifestEntry describes one file of an import archive. In a CSV
+// manifest tags are comma-separated within their column.
+type ClipManifestEntry struct {
+	File        string   `json:"file"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Category    string   `json:"category"`
+	Tags        []string `json:"tags"`
+	Mood        string   `json:"mood"`
+	Style       string   `json:"style"`
+	Color       string   `json:"color"`
+}
+
+// ClipURLImportRequest downloads a video from a URL into a clip.
+type ClipURLImportRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=2000"`
+	Title       string   `json:"title" binding:"omitempty,max=200"`
+	Description string   `json:"description" binding:"omitempty,max=1000"`
+	Category    string   `json:"category" binding:"omitempty,max=50"`
+	Tags        []string `json:"tags" binding:"omitempty,max=50"`
+	Mood        string   `json:"mood" binding:"omitempty,max=50"`
+	Style       string   `json:"style" binding:"omitempty,max=50"`
+	Color       string   `json:"color" binding:"omitempty,max=50"`
+}
+
+// ClipImportListRequest pages the imports of a workspace.
+type ClipImportListRequest struct {
+	Page  int `form:"page,default=1" binding:"min=1"`
+	Limit int `form:"limit,default=20" binding:"min=1,max=100"`
+}
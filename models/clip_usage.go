@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ClipUsage records that a project's timeline, or a composition generated
+// for it, uses a clip. The rows are updated whenever the timeline or
+// composition is written, so the usages of a clip are found without reading
+// every timeline.
+type ClipUsage struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	ClipID    uint `json:"clip_id" gorm:"not null;index"`
+	ProjectID uint `json:"project_id" gorm:"not null;index"`
+	// CompositionID is nil for the project's timeline
+	CompositionID *uint     `json:"composition_id,omitempty" gorm:"index"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SetClipUsages records the clips used by the project's timeline, or by
+// one of its compositions when compositionID is set, in place of those
+// recorded before. Clips still used keep the time they were first used.
+func SetClipUsages(tx *gorm.DB, projectID uint, compositionID *uint, clipIDs []uint) error {
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("project_id = ?", projectID)
+		if compositionID != nil {
+			return db.Where("composition_id = ?", *compositionID)
+		}
+		return db.Where("composition_id IS NULL")
+	}
+
+	var recorded []uint
+	if err := tx.Model(&ClipUsage{}).Scopes(scope).Pluck("clip_id", &recorded).Error; err != nil {
+		return err
+	}
+	known := make(map[uint]bool, len(recorded))
+	for _, clipID := range recorded {
+		known[clipID] = true
+	}
+
+	// Timelines may use a clip more than once
+	used := make(map[uint]bool, len(clipIDs))
+	var usages []ClipUsage
+	for _, clipID := range clipIDs {
+		if !known[clipID] && !used[clipID] {
+			usages = append(usages, ClipUsage{ClipID: clipID, ProjectID: projectID, CompositionID: compositionID})
+		}
+		used[clipID] = true
+	}
+
+	var removed []uint
+	for _, clipID := range recorded {
+		if !used[clipID] {
+			removed = append(removed, clipID)
+		}
+	}
+	if len(removed) > 0 {
+		if err := tx.Scopes(scope).Where("clip_id IN ?", removed).Delete(&ClipUsage{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(usages) == 0 {
+		return nil
+	}
+	return tx.Create(&usages).Error
+}
+
+// ClipUsageItem is a project, or a composition of it, using a clip.
+type ClipUsageItem struct {
+	ProjectID     uint   `json:"project_id"`
+	ProjectTitle  string `json:"project_title"`
+	ProjectStatus string `json:"project_status"`
+	// CompositionID is nil when the project's timeline uses the clip
+	CompositionID *uint     `json:"composition_id,omitempty"`
+	UsedSince     time.Time `json:"used_since"`
+}
+
+// ClipUsageListRequest pages the usages of a clip.
+type ClipUsageListRequest struct {
+	Page  int `form:"page,default=1" binding:"min=1"`
+	Limit int `form:"limit,default=20" binding:"min=1,max=100"`
+}
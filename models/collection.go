@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxCollectionClips bounds how many clips one collection holds
+const MaxCollectionClips = 500
+
+// Collection is an ordered set of clips, used as a folder or playlist and
+// as the clip pool for a smart composition.
+type Collection struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"not null;size:200"`
+	Description string `json:"description" gorm:"type:text"`
+	UserID      uint   `json:"user_id" gorm:"not null;index"`
+	// OrganizationID shares the collection with a team; nil keeps it personal
+	OrganizationID *uint `json:"organization_id" gorm:"index"`
+	// ClipCount is filled in when listing
+	ClipCount int64 `json:"clip_count" gorm:"-"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Items []CollectionItem `json:"items,omitempty" gorm:"foreignKey:CollectionID"`
+}
+
+func (Collection) TableName() string {
+	return "collections"
+}
+
+// CollectionItem places a clip in a collection. Position orders the
+// collection from zero.
+type CollectionItem struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CollectionID uint      `json:"collection_id" gorm:"not null;uniqueIndex:idx_collection_clip"`
+	AtomicClipID uint      `json:"atomic_clip_id" gorm:"not null;uniqueIndex:idx_collection_clip;index"`
+	Position     int       `json:"position" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Relations
+	AtomicClip *AtomicClip `json:"atomic_clip,omitempty" gorm:"foreignKey:AtomicClipID"`
+}
+
+func (CollectionItem) TableName() string {
+	return "collection_items"
+}
+
+type CollectionCreateRequest struct {
+	Name        string `json:"name" binding:"required,max=200"`
+	Description string `json:"description" binding:"omitempty,max=1000"`
+	ClipIDs     []uint `json:"clip_ids" binding:"omitempty,max=500"`
+}
+
+type CollectionUpdateRequest struct {
+	Name        string  `json:"name" binding:"omitempty,max=200"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+}
+
+// CollectionClipsRequest lists clips to add to a collection, or its whole
+// membership in order when replacing it.
+type CollectionClipsRequest struct {
+	ClipIDs []uint `json:"clip_ids" binding:"required,max=500"`
+}
+
+// CollectionListRequest pages the collections of a workspace.
+type CollectionListRequest struct {
+	Page  int `form:"page,default=1" binding:"min=1"`
+	Limit int `form:"limit,default=20" binding:"min=1,max=100"`
+}
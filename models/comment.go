@@ -0,0 +1,85 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment is review feedback on a clip or a render, pinned to a moment of
+// the video and optionally to a region of its frame.
+type Comment struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	ResourceType string         `json:"resource_type" gorm:"not null;size:20;index:idx_comment_resource"`
+	ResourceID   uint           `json:"resource_id" gorm:"not null;index:idx_comment_resource"`
+	UserID       uint           `json:"user_id" gorm:"not null;index"`
+	Body         string         `json:"body" gorm:"type:text;not null"`
+	Timestamp    float64        `json:"timestamp"` // seconds into the video
+	Region       *CommentRegion `json:"region,omitempty" gorm:"type:text"`
+	// Mentions are the usernames of the users notified by the comment
+	Mentions   StringArray `json:"mentions" gorm:"type:text"`
+	ResolvedAt *time.Time  `json:"resolved_at"`
+	ResolvedBy *uint       `json:"resolved_by"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (Comment) TableName() string {
+	return "comments"
+}
+
+// Resources a comment can be left on
+const (
+	CommentResourceClip   = "clip"
+	CommentResourceRender = "render"
+)
+
+// CommentRegion is a rectangle of the frame, in fractions of its width and
+// height from the top left corner.
+type CommentRegion struct {
+	X      float64 `json:"x" binding:"min=0,max=1"`
+	Y      float64 `json:"y" binding:"min=0,max=1"`
+	Width  float64 `json:"width" binding:"gt=0,max=1"`
+	Height float64 `json:"height" binding:"gt=0,max=1"`
+}
+
+func (r CommentRegion) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+func (r *CommentRegion) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	}
+	return nil
+}
+
+type CommentCreateRequest struct {
+	Body      string         `json:"body" binding:"required,max=5000"`
+	Timestamp *float64       `json:"timestamp" binding:"required,min=0"`
+	Region    *CommentRegion `json:"region" binding:"omitempty"`
+}
+
+type CommentUpdateRequest struct {
+	Body      string         `json:"body" binding:"omitempty,max=5000"`
+	Timestamp *float64       `json:"timestamp" binding:"omitempty,min=0"`
+	Region    *CommentRegion `json:"region" binding:"omitempty"`
+}
+
+// CommentListRequest pages the comments of a resource in video order.
+// Resolved filters by state when set.
+type CommentListRequest struct {
+	Resolved *bool `form:"resolved"`
+	Page     int   `form:"page,default=1" binding:"min=1"`
+	Limit    int   `form:"limit,default=50" binding:"min=1,max=200"`
+}
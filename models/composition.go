@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Composition struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	ProjectID uint   `json:"project_id" gorm:"not null;index"`
+	UserID    uint   `json:"user_id" gorm:"not null;index"`
+	Status    string `json:"status" gorm:"default:'pending';size:20"` // pending, completed, failed
+
+	// Generation inputs
+	Algorithm    string  `json:"algorithm" gorm:"size:50"`
+	Variant      int     `json:"variant" gorm:"default:0"`
+	Seed         int64   `json:"seed"`
+	Requirements RawJSON `json:"requirements" gorm:"type:text"`
+	TraceID      string  `json:"trace_id,omitempty" gorm:"size:64;index"` // request that queued the generation
+	// CollectionID limits the clips drawn from; nil uses the whole library
+	CollectionID *uint `json:"collection_id,omitempty" gorm:"index"`
+	// IncludeStock adds the stock clips the user can use to those drawn from
+	IncludeStock bool `json:"include_stock" gorm:"default:false"`
+
+	// Generation results
+	SelectedClips RawJSON `json:"selected_clips" gorm:"type:text"`
+	Timeline      RawJSON `json:"timeline" gorm:"type:text"`
+	TotalDuration float64 `json:"total_duration"`
+	QualityScore  float64 `json:"quality_score"`
+	CohesionScore float64 `json:"cohesion_score"`
+	Metadata      JSON    `json:"metadata" gorm:"type:text"`
+
+	ErrorMessage string     `json:"error_message,omitempty" gorm:"type:text"`
+	PromotedAt   *time.Time `json:"promoted_at"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Project Project `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+}
+
+type CompositionCreateRequest struct {
+	Requirements JSON     `json:"requirements" binding:"required"`
+	Variants     int      `json:"variants" binding:"omitempty,min=1,max=10"`
+	Algorithms   []string `json:"algorithms" binding:"omitempty"`
+	CollectionID *uint    `json:"collection_id" binding:"omitempty"` // compose from this collection's clips only
+	IncludeStock bool     `json:"include_stock"`                     // also draw from the stock library
+}
+
+const (
+	CompositionStatusPending   = "pending"
+	CompositionStatusCompleted = "completed"
+	CompositionStatusFailed    = "failed"
+)
+
+// CompositionFeedback records what a user did with an auto-selected clip
+// after a composition was generated, used as a scoring signal.
+type CompositionFeedback struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	CompositionID    uint      `json:"composition_id" gorm:"not null;index"`
+	UserID           uint      `json:"user_id" gorm:"not null;index:idx_feedback_user_theme"`
+	Theme            string    `json:"theme" gorm:"size:50;index:idx_feedback_user_theme"`
+	ClipID           uint      `json:"clip_id" gorm:"not null;index"`
+	Category         string    `json:"category" gorm:"size:50"`
+	Action           string    `json:"action" gorm:"size:20"` // kept, reordered, removed, added
+	OriginalPosition int       `json:"original_position"`     // -1 when the clip was added by the user
+	FinalPosition    int       `json:"final_position"`        // -1 when the clip was removed
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type CompositionFeedbackRequest struct {
+	ClipIDs []uint `json:"clip_ids" binding:"required"` // final clip order after user edits
+}
+
+const (
+	FeedbackActionKept      = "kept"
+	FeedbackActionReordered = "reordered"
+	FeedbackActionRemoved   = "removed"
+	FeedbackActionAdded     = "added"
+)
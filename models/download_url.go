@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// DownloadURLRequest asks for signed URLs of an output video.
+type DownloadURLRequest struct {
+	Filename string `json:"filename" binding:"required,max=255"`
+}
+
+// DownloadURLs download and play an output video without credentials
+// until they expire.
+type DownloadURLs struct {
+	Filename    string    `json:"filename"`
+	DownloadURL string    `json:"download_url"`
+	PlayURL     string    `json:"play_url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// ClipDuplicate is a clip whose footage nearly duplicates another's, with
+// how alike their frames are from 0 to 1.
+type ClipDuplicate struct {
+	Clip       AtomicClip `json:"clip"`
+	Similarity float64    `json:"similarity"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Things users can favorite and rate
+const (
+	FavoriteTargetClip     = "clip"
+	FavoriteTargetTemplate = "template"
+)
+
+// Favorite bookmarks a clip or template for a user.
+type Favorite struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_favorite"`
+	TargetType string    `json:"target_type" gorm:"not null;size:20;uniqueIndex:idx_favorite;index:idx_favorite_target"`
+	TargetID   uint      `json:"target_id" gorm:"not null;uniqueIndex:idx_favorite;index:idx_favorite_target"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Favorite) TableName() string {
+	return "favorites"
+}
+
+// Rating is a user's one to five star score of a clip or template. Rating
+// again replaces the score.
+type Rating struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_rating"`
+	TargetType string    `json:"target_type" gorm:"not null;size:20;uniqueIndex:idx_rating;index:idx_rating_target"`
+	TargetID   uint      `json:"target_id" gorm:"not null;uniqueIndex:idx_rating;index:idx_rating_target"`
+	Score      int       `json:"score" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (Rating) TableName() string {
+	return "ratings"
+}
+
+type RatingRequest struct {
+	Score int `json:"score" binding:"required,min=1,max=5"`
+}
+
+// Engagement is the favorite and rating summary of a clip or template, with
+// the caller's own favorite and score.
+type Engagement struct {
+	FavoriteCount int     `json:"favorite_count"`
+	RatingCount   int     `json:"rating_count"`
+	RatingAverage float64 `json:"rating_average"`
+	Favorited     bool    `json:"favorited"`
+	MyRating      int     `json:"my_rating,omitempty"`
+}
+
+// FavoriteListRequest pages a user's favorites.
+type FavoriteListRequest struct {
+	Page  int `form:"page,default=1" binding:"min=1"`
+	Limit int `form:"limit,default=20" binding:"min=1,max=100"`
+}
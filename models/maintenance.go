@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Maintenance modes
+const (
+	MaintenanceOff      = "off"
+	MaintenanceReadOnly = "read_only" // reads are served, writes refused
+	MaintenanceFull     = "full"      // every request is refused
+)
+
+// Maintenance is the maintenance mode every API instance applies, kept in
+// Redis so one toggle covers them all.
+type Maintenance struct {
+	Mode    string `json:"mode"`
+	Message string `json:"message,omitempty"` // shown to clients refused meanwhile
+	// RetryAfter is the wait in seconds clients are told to retry after
+	RetryAfter int        `json:"retry_after,omitempty"`
+	StartedBy  uint       `json:"started_by,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	// ExpiresAt ends the mode by itself, so a forgotten one does not last
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// MaintenanceUpdateRequest turns maintenance on or off. Without expires_in
+// the mode lasts until it is turned off.
+type MaintenanceUpdateRequest struct {
+	Mode       string `json:"mode" binding:"required,oneof=off read_only full"`
+	Message    string `json:"message" binding:"max=500"`
+	RetryAfter int    `json:"retry_after" binding:"min=0,max=86400"` // seconds; defaults to five minutes
+	ExpiresIn  int    `json:"expires_in" binding:"min=0"`            // seconds
+}
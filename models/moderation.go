@@ -0,0 +1,42 @@
+package models
+
+import "gorm.io/gorm"
+
+// Clip moderation statuses: pending until the classifier has scored the
+// clip, approved when it found nothing or a reviewer cleared the clip,
+// flagged when it awaits review and rejected when a reviewer confirmed it.
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationFlagged  = "flagged"
+	ModerationRejected = "rejected"
+)
+
+// ModerationBlocked lists the statuses that keep a clip out of public view,
+// the stock library and compositions. The clip stays in its own library.
+var ModerationBlocked = []string{ModerationFlagged, ModerationRejected}
+
+// IsModerationBlocked reports whether moderation keeps the clip out of
+// public view and compositions.
+func (c *AtomicClip) IsModerationBlocked() bool {
+	return c.ModerationStatus == ModerationFlagged || c.ModerationStatus == ModerationRejected
+}
+
+// ModerationAllowed limits a query to the clips moderation does not block.
+func ModerationAllowed(db *gorm.DB) *gorm.DB {
+	return db.Where("moderation_status NOT IN ?", ModerationBlocked)
+}
+
+// ModerationQueueRequest pages the clips awaiting or past review, flagged
+// ones by default.
+type ModerationQueueRequest struct {
+	Status string `form:"status,default=flagged" binding:"oneof=pending approved flagged rejected"`
+	Page   int    `form:"page,default=1" binding:"min=1"`
+	Limit  int    `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+// ModerationReviewRequest records a reviewer's decision on a clip.
+type ModerationReviewRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved rejected"`
+	Note   string `json:"note" binding:"omitempty,max=500"`
+}
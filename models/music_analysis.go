@@ -0,0 +1,74 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ClipCategoryMusic marks a clip as a music asset, analyzed for its beat
+// once uploaded.
+const ClipCategoryMusic = "music"
+
+// Energy levels of music sections
+const (
+	MusicEnergyLow    = "low"
+	MusicEnergyMedium = "medium"
+	MusicEnergyHigh   = "high"
+)
+
+// MusicAnalysis is the rhythm and structure of a music clip, which
+// compositions cut and end on. Times are seconds from the start of the
+// track; the analysis assumes four beats to the bar.
+type MusicAnalysis struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	AtomicClipID  uint         `json:"atomic_clip_id" gorm:"uniqueIndex;not null"`
+	BPM           float64      `json:"bpm"`
+	BeatTimes     Float64Array `json:"beat_times" gorm:"type:text"`
+	DownbeatTimes Float64Array `json:"downbeat_times" gorm:"type:text"`
+	// EnergyCurve is the loudness of the track from 0 to 1, one point every
+	// EnergyInterval seconds
+	EnergyInterval float64       `json:"energy_interval"`
+	EnergyCurve    Float64Array  `json:"energy_curve" gorm:"type:text"`
+	Sections       MusicSections `json:"sections" gorm:"type:text"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// MusicSection is a stretch of a track at one energy level, such as a
+// quiet intro or a loud chorus. Boundaries fall on downbeats.
+type MusicSection struct {
+	Start  float64 `json:"start"`
+	End    float64 `json:"end"`
+	Energy string  `json:"energy"` // low, medium, high
+}
+
+// IsMusic reports whether the clip is a music asset.
+func (c *AtomicClip) IsMusic() bool {
+	return strings.EqualFold(c.Category, ClipCategoryMusic)
+}
+
+type MusicSections []MusicSection
+
+func (s MusicSections) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *MusicSections) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return nil
+}
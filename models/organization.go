@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization is a team workspace. Clips, projects and templates with its
+// ID belong to the team rather than to the user who created them.
+type Organization struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Name      string         `json:"name" gorm:"not null;size:100"`
+	Slug      string         `json:"slug" gorm:"uniqueIndex;not null;size:100"`
+	OwnerID   uint           `json:"owner_id" gorm:"not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// RequireTwoFactor keeps members without two-factor authentication out
+	// of the workspace
+	RequireTwoFactor bool `json:"require_two_factor" gorm:"default:false"`
+
+	// Relations
+	Members []Membership `json:"members,omitempty" gorm:"foreignKey:OrganizationID"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// Membership gives a user a role within an organization. The role only
+// applies to the organization's content; what a user may do at all is
+// still decided by their global role.
+type Membership struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;uniqueIndex:idx_membership"`
+	UserID         uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_membership;index"`
+	Role           string    `json:"role" gorm:"not null;size:20"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relations
+	Organization *Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	User         *User         `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (Membership) TableName() string {
+	return "memberships"
+}
+
+// Organization roles
+const (
+	OrgRoleOwner  = "owner"  // the creator; cannot leave or be removed
+	OrgRoleAdmin  = "admin"  // manages members
+	OrgRoleMember = "member" // adds and edits shared content
+	OrgRoleViewer = "viewer" // reads shared content
+)
+
+// CanWrite reports whether the member may add and change shared content.
+func (m *Membership) CanWrite() bool {
+	return m.Role != OrgRoleViewer
+}
+
+// CanManage reports whether the member may manage other members.
+func (m *Membership) CanManage() bool {
+	return m.Role == OrgRoleOwner || m.Role == OrgRoleAdmin
+}
+
+type OrganizationCreateRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	Slug string `json:"slug" binding:"required,min=2,max=100,alphanum"`
+}
+
+type OrganizationUpdateRequest struct {
+	Name             *string `json:"name" binding:"omitempty,max=100"`
+	RequireTwoFactor *bool   `json:"require_two_factor"`
+}
+
+type MembershipCreateRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=admin member viewer"`
+}
+
+type MembershipUpdateRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member viewer"`
+}
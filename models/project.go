@@ -29,6 +29,8 @@ type Project struct {
 	// Relations
 	UserID      uint      `json:"user_id" gorm:"not null"`
 	TemplateID  *uint     `json:"template_id"`
+	// OrganizationID shares the project with a team; nil keeps it personal
+	OrganizationID *uint  `json:"organization_id" gorm:"index"`
 	
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
@@ -60,6 +62,28 @@ type ProjectUpdateRequest struct {
 	Status      string  `json:"status" binding:"omitempty,oneof=draft active archived"`
 }
 
+// ProjectTimelineUpdateRequest replaces the timeline. Version, when set,
+// must match the project's, so concurrent edits are not silently lost.
+type ProjectTimelineUpdateRequest struct {
+	Timeline JSON `json:"timeline" binding:"required"`
+	Version  int  `json:"version" binding:"omitempty,min=1"`
+}
+
+// ProjectListRequest filters and pages the projects of a workspace.
+type ProjectListRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=draft active archived"`
+	Page   int    `form:"page,default=1" binding:"min=1"`
+	Limit  int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Cursor string `form:"cursor"`
+}
+
+// Project statuses
+const (
+	ProjectStatusDraft    = "draft"
+	ProjectStatusActive   = "active"
+	ProjectStatusArchived = "archived"
+)
+
 type Template struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
 	Name        string    `json:"name" gorm:"not null;size:200"`
@@ -82,7 +106,14 @@ type Template struct {
 	IsPublic    bool      `json:"is_public" gorm:"default:false"`
 	UsageCount  int       `json:"usage_count" gorm:"default:0"`
 	
+	// Engagement, kept in step with the favorites and ratings tables
+	FavoriteCount int     `json:"favorite_count" gorm:"default:0"`
+	RatingCount   int     `json:"rating_count" gorm:"default:0"`
+	RatingAverage float64 `json:"rating_average" gorm:"default:0"`
+	
 	UserID      uint      `json:"user_id" gorm:"not null"`
+	// OrganizationID shares the template with a team; nil keeps it personal
+	OrganizationID *uint  `json:"organization_id" gorm:"index"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -103,4 +134,33 @@ type TemplateCreateRequest struct {
 	Settings    JSON     `json:"settings" binding:"omitempty"`
 	Tags        []string `json:"tags" binding:"omitempty"`
 	IsPublic    bool     `json:"is_public" binding:"omitempty"`
+}
+
+type TemplateUpdateRequest struct {
+	Name        string   `json:"name" binding:"omitempty,max=200"`
+	Description string   `json:"description" binding:"omitempty,max=1000"`
+	Category    string   `json:"category" binding:"omitempty,max=50"`
+	Width       int      `json:"width" binding:"omitempty,min=320,max=7680"`
+	Height      int      `json:"height" binding:"omitempty,min=240,max=4320"`
+	FrameRate   float64  `json:"frame_rate" binding:"omitempty,min=1,max=120"`
+	Timeline    JSON     `json:"timeline" binding:"omitempty"`
+	Settings    JSON     `json:"settings" binding:"omitempty"`
+	Tags        []string `json:"tags" binding:"omitempty"`
+}
+
+// TemplateListRequest filters and pages templates. Sort is "popular"
+// (most used), "newest" or "rating".
+type TemplateListRequest struct {
+	Query    string `form:"query"`
+	Category string `form:"category"`
+	Tag      string `form:"tag"`
+	Sort     string `form:"sort,default=popular" binding:"omitempty,oneof=popular newest rating"`
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	Limit    int    `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+// TemplateUseRequest names the project created from a template.
+type TemplateUseRequest struct {
+	Title       string `json:"title" binding:"required,max=200"`
+	Description string `json:"description" binding:"omitempty,max=1000"`
 }
\ No newline at end of file
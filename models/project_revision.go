@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// ProjectRevision is a snapshot of a project's timeline, saved whenever the
+// timeline is saved. Version is the project version the save produced.
+type ProjectRevision struct {
+	ID        uint    `json:"id" gorm:"primaryKey"`
+	ProjectID uint    `json:"project_id" gorm:"not null;uniqueIndex:idx_project_revision"`
+	Version   int     `json:"version" gorm:"not null;uniqueIndex:idx_project_revision"`
+	Timeline  JSON    `json:"timeline,omitempty" gorm:"type:jsonb"`
+	Duration  float64 `json:"duration"`
+	UserID    uint    `json:"user_id" gorm:"not null"`
+	// RestoredFrom is the version this revision brought back, if any
+	RestoredFrom *int      `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (ProjectRevision) TableName() string {
+	return "project_revisions"
+}
+
+// ProjectRevisionListRequest pages the revisions of a project, newest first.
+type ProjectRevisionListRequest struct {
+	Page  int `form:"page,default=1" binding:"min=1"`
+	Limit int `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+// ProjectRevisionDiffRequest names the two revisions to compare.
+type ProjectRevisionDiffRequest struct {
+	From int `form:"from" binding:"required,min=1"`
+	To   int `form:"to" binding:"required,min=1"`
+}
+
+// ProjectRevisionDiff compares the timelines of two revisions.
+type ProjectRevisionDiff struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	TimelineDiff
+}
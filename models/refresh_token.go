@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken is a single-use credential exchanged for a new access
+// token. Only its SHA-256 hash is stored. Every rotation replaces the token
+// with a new one of the same family; presenting a replaced token again
+// revokes the whole family, since it means the token was stolen.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	FamilyID  string     `json:"family_id" gorm:"not null;index;size:32"` // shared by every rotation of one login
+	ExpiresAt time.Time  `json:"expires_at" gorm:"index"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	// ReplacedByID is the token issued when this one was rotated
+	ReplacedByID *uint  `json:"replaced_by_id"`
+	UserAgent    string `json:"user_agent" gorm:"size:255"`
+	IPAddress    string `json:"ip_address" gorm:"size:45"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	// AllDevices signs out every session of the user
+	AllDevices bool `json:"all_devices"`
+}
+
+// TokenPair is returned by login, registration and refresh.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // access token lifetime in seconds
+}
@@ -16,10 +16,19 @@ type RenderTask struct {
 	Priority     int       `json:"priority" gorm:"default:5"`
 	
 	// Render settings
+	Mode         string    `json:"mode" gorm:"default:'full';size:20"` // full, preview
 	OutputFormat string    `json:"output_format" gorm:"size:20"`
 	Quality      string    `json:"quality" gorm:"size:20"`
 	Resolution   string    `json:"resolution" gorm:"size:20"`
 	FrameRate    float64   `json:"frame_rate"`
+	AspectRatio  string    `json:"aspect_ratio,omitempty" gorm:"size:10"`
+	Captions     bool      `json:"captions" gorm:"default:false"` // burns in the clips' transcripts
+	
+	// Outputs requested together share a group and a single render pass
+	GroupID      string    `json:"group_id,omitempty" gorm:"size:50;index"`
+	
+	// Correlation ID of the request that created the task, carried by its queue tasks
+	TraceID      string    `json:"trace_id,omitempty" gorm:"size:64;index"`
 	
 	// File information
 	OutputPath   string    `json:"output_path" gorm:"size:500"`
@@ -30,6 +39,7 @@ type RenderTask struct {
 	StartedAt    *time.Time `json:"started_at"`
 	CompletedAt  *time.Time `json:"completed_at"`
 	EstimatedTime int       `json:"estimated_time"` // in seconds
+	Work          float64   `json:"work"`           // render effort in 1080p30 output seconds, used for throughput stats
 	
 	// Error information
 	ErrorMessage string    `json:"error_message" gorm:"type:text"`
@@ -50,13 +60,68 @@ type RenderTask struct {
 
 type RenderTaskCreateRequest struct {
 	ProjectID    uint    `json:"project_id" binding:"required"`
-	OutputFormat string  `json:"output_format" binding:"required,oneof=mp4 mov avi mkv"`
+	Mode         string  `json:"mode" binding:"omitempty,oneof=full preview"`
+	OutputFormat string  `json:"output_format" binding:"required,oneof=mp4 mov avi mkv hls"`
 	Quality      string  `json:"quality" binding:"required,oneof=low medium high ultra"`
 	Resolution   string  `json:"resolution" binding:"omitempty"`
 	FrameRate    float64 `json:"frame_rate" binding:"omitempty,min=1,max=120"`
+	AspectRatio  string  `json:"aspect_ratio" binding:"omitempty,oneof=16:9 9:16 1:1 4:5 4:3"`
+	Captions     bool    `json:"captions"`
 	Priority     int     `json:"priority" binding:"omitempty,min=1,max=10"`
 }
 
+// RenderGroupCreateRequest renders several outputs of a project in one job.
+// Captions apply to every output, as they share the decoded timeline.
+type RenderGroupCreateRequest struct {
+	ProjectID uint                  `json:"project_id" binding:"required"`
+	Mode      string                `json:"mode" binding:"omitempty,oneof=full preview"`
+	Priority  int                   `json:"priority" binding:"omitempty,min=1,max=10"`
+	Captions  bool                  `json:"captions"`
+	Outputs   []RenderOutputRequest `json:"outputs" binding:"required,min=1,max=8,dive"`
+}
+
+type RenderOutputRequest struct {
+	OutputFormat string  `json:"output_format" binding:"required,oneof=mp4 mov avi mkv hls"`
+	Quality      string  `json:"quality" binding:"required,oneof=low medium high ultra"`
+	Resolution   string  `json:"resolution" binding:"omitempty"`
+	FrameRate    float64 `json:"frame_rate" binding:"omitempty,min=1,max=120"`
+	AspectRatio  string  `json:"aspect_ratio" binding:"omitempty,oneof=16:9 9:16 1:1 4:5 4:3"`
+}
+
+// TaskRequest expands one output of the group into a single render request.
+func (r *RenderGroupCreateRequest) TaskRequest(output RenderOutputRequest) *RenderTaskCreateRequest {
+	return &RenderTaskCreateRequest{
+		ProjectID:    r.ProjectID,
+		Mode:         r.Mode,
+		OutputFormat: output.OutputFormat,
+		Quality:      output.Quality,
+		Resolution:   output.Resolution,
+		FrameRate:    output.FrameRate,
+		AspectRatio:  output.AspectRatio,
+		Captions:     r.Captions,
+		Priority:     r.Priority,
+	}
+}
+
+const (
+	RenderModeFull    = "full"
+	RenderModePreview = "preview"
+)
+
+const (
+	RenderStatusPending    = "pending"
+	RenderStatusProcessing = "processing"
+	RenderStatusCompleted  = "completed"
+	RenderStatusFailed     = "failed"
+	RenderStatusCancelled  = "cancelled"
+)
+
+// RenderTaskStatus is the live view of a render task returned by the API.
+type RenderTaskStatus struct {
+	RenderTask
+	ETA *int `json:"eta"` // seconds until completion, nil when unknown or finished
+}
+
 type RenderTaskUpdateRequest struct {
 	Status       string  `json:"status" binding:"omitempty,oneof=pending processing completed failed cancelled"`
 	Progress     int     `json:"progress" binding:"omitempty,min=0,max=100"`
@@ -73,6 +138,8 @@ type VideoAnalysis struct {
 	AvgContrast   float64   `json:"avg_contrast"`
 	AvgSaturation float64   `json:"avg_saturation"`
 	DominantColors StringArray `json:"dominant_colors" gorm:"type:text"`
+	Embedding     Float64Array `json:"embedding,omitempty" gorm:"type:text"` // visual feature vector
+	PerceptualHash string   `json:"perceptual_hash,omitempty" gorm:"size:128"` // per-frame pHashes in hex, for near-duplicates
 	
 	// Motion analysis
 	MotionIntensity string  `json:"motion_intensity" gorm:"size:20"` // low, medium, high
@@ -83,6 +150,9 @@ type VideoAnalysis struct {
 	FaceCount     int       `json:"face_count"`
 	HasText       bool      `json:"has_text"`
 	TextContent   string    `json:"text_content" gorm:"type:text"`
+	ShotType      string    `json:"shot_type" gorm:"size:20;index"` // close_up, medium_shot, wide_shot
+	// ThumbnailTime is the offset of the frame picked as the best thumbnail
+	ThumbnailTime *float64  `json:"thumbnail_time,omitempty"`
 	
 	// Audio analysis (if available)
 	HasAudio      bool      `json:"has_audio"`
@@ -92,6 +162,7 @@ type VideoAnalysis struct {
 	// AI-generated data
 	AITags        StringArray `json:"ai_tags" gorm:"type:text"`
 	AIDescription string    `json:"ai_description" gorm:"type:text"`
+	SuggestedTitles StringArray `json:"suggested_titles" gorm:"type:text"`
 	Confidence    float64   `json:"confidence"`
 	
 	// Analysis metadata
@@ -103,4 +174,31 @@ type VideoAnalysis struct {
 	
 	// Relations
 	AtomicClip    AtomicClip `json:"atomic_clip,omitempty" gorm:"foreignKey:AtomicClipID"`
-}
\ No newline at end of file
+}
+
+// Shot types, from how much of the frame the subject fills
+const (
+	ShotTypeCloseUp = "close_up"
+	ShotTypeMedium  = "medium_shot"
+	ShotTypeWide    = "wide_shot"
+)
+
+const (
+	AnalysisTypeFull    = "full"
+	AnalysisTypeContent = "content"
+	AnalysisTypeMotion  = "motion"
+	AnalysisTypeColor   = "color"
+	AnalysisTypeAudio   = "audio"
+
+	// DefaultAnalysisVersion is the version of the analysis pipeline,
+	// recorded unless a re-analysis names another. Bump it when analysis
+	// learns something existing clips should get, then backfill them.
+	DefaultAnalysisVersion = "1.1"
+)
+
+// ClipAnalysisRequest picks what a clip is (re)analyzed for and the analyzer
+// version recorded on the VideoAnalysis once it completes.
+type ClipAnalysisRequest struct {
+	AnalysisType string `json:"analysis_type" binding:"omitempty,oneof=full content motion color audio"`
+	Version      string `json:"version" binding:"omitempty,max=20"`
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"time"
+)
+
+// Role is a named set of permissions. User.Role holds the role name.
+// System roles are seeded at startup and cannot be deleted.
+type Role struct {
+	ID          uint        `json:"id" gorm:"primaryKey"`
+	Name        string      `json:"name" gorm:"uniqueIndex;not null;size:20"`
+	Description string      `json:"description" gorm:"size:255"`
+	Permissions StringArray `json:"permissions" gorm:"type:text"`
+	IsSystem    bool        `json:"is_system" gorm:"default:false"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+// HasPermission reports whether the role grants the permission.
+func (r *Role) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions
+const (
+	PermissionClipsRead          = "clips:read"
+	PermissionClipsWrite         = "clips:write"
+	PermissionRendersRead        = "renders:read"
+	PermissionRendersCreate      = "renders:create"
+	PermissionCompositionsRead   = "compositions:read"
+	PermissionCompositionsWrite  = "compositions:write"
+	PermissionCompositionsReview = "compositions:review" // promote and give feedback
+	PermissionAdminUsers         = "admin:users"
+	PermissionAdminRoles         = "admin:roles"
+	PermissionAdminTasks         = "admin:tasks"
+	PermissionAdminSchedules     = "admin:schedules"
+	PermissionAdminQueues        = "admin:queues"
+	PermissionAdminKeys          = "admin:keys" // rotate and revoke token signing keys
+	PermissionAdminAudit         = "admin:audit"
+	PermissionAdminTags          = "admin:tags"        // rename and merge tags across every library
+	PermissionAdminStock         = "admin:stock"       // approve personal clips for the global stock library
+	PermissionAdminModeration    = "admin:moderation"  // review clips flagged for unsafe content
+	PermissionAdminDocs          = "admin:docs"        // browse the API documentation in production
+	PermissionAdminMaintenance   = "admin:maintenance" // turn maintenance and read-only mode on and off
+)
+
+// Permissions lists every permission a role can grant
+var Permissions = []string{
+	PermissionClipsRead,
+	PermissionClipsWrite,
+	PermissionRendersRead,
+	PermissionRendersCreate,
+	PermissionCompositionsRead,
+	PermissionCompositionsWrite,
+	PermissionCompositionsReview,
+	PermissionAdminUsers,
+	PermissionAdminRoles,
+	PermissionAdminTasks,
+	PermissionAdminSchedules,
+	PermissionAdminQueues,
+	PermissionAdminKeys,
+	PermissionAdminAudit,
+	PermissionAdminTags,
+	PermissionAdminStock,
+	PermissionAdminModeration,
+	PermissionAdminDocs,
+	PermissionAdminMaintenance,
+}
+
+// Default roles
+const (
+	RoleAdmin    = "admin"
+	RoleUser     = "user" // given on registration
+	RoleEditor   = "editor"
+	RoleReviewer = "reviewer"
+)
+
+// DefaultRoles are seeded when missing. Admins may change their
+// permissions afterwards, except the admin role's, which always grants
+// every permission.
+var DefaultRoles = []Role{
+	{
+		Name:        RoleAdmin,
+		Description: "Full access, including user and system administration",
+		Permissions: Permissions,
+	},
+	{
+		Name:        RoleUser,
+		Description: "Creates and reviews their own content",
+		Permissions: StringArray{
+			PermissionClipsRead, PermissionClipsWrite,
+			PermissionRendersRead, PermissionRendersCreate,
+			PermissionCompositionsRead, PermissionCompositionsWrite, PermissionCompositionsReview,
+		},
+	},
+	{
+		Name:        RoleEditor,
+		Description: "Uploads clips, generates compositions and renders",
+		Permissions: StringArray{
+			PermissionClipsRead, PermissionClipsWrite,
+			PermissionRendersRead, PermissionRendersCreate,
+			PermissionCompositionsRead, PermissionCompositionsWrite,
+		},
+	},
+	{
+		Name:        RoleReviewer,
+		Description: "Reviews compositions and renders without editing",
+		Permissions: StringArray{
+			PermissionClipsRead,
+			PermissionRendersRead,
+			PermissionCompositionsRead, PermissionCompositionsReview,
+		},
+	},
+}
+
+type RoleCreateRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=20"`
+	Description string   `json:"description" binding:"omitempty,max=255"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+type RoleUpdateRequest struct {
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Permissions []string `json:"permissions" binding:"omitempty"`
+}
+
+type UserRoleUpdateRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// UserPlanUpdateRequest assigns a rate limit plan to a user; an empty plan
+// puts them back on the default one.
+type UserPlanUpdateRequest struct {
+	Plan string `json:"plan" binding:"omitempty,max=20"`
+}
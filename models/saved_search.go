@@ -0,0 +1,107 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SavedSearch is a named clip search, a smart collection holding whatever
+// clips the search matches when it is run.
+type SavedSearch struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Name   string `json:"name" gorm:"not null;size:200"`
+	UserID uint   `json:"user_id" gorm:"not null;index"`
+	// OrganizationID shares the search with a team, and searches its
+	// library; nil keeps it personal
+	OrganizationID *uint              `json:"organization_id" gorm:"index"`
+	Criteria       ClipSearchCriteria `json:"criteria" gorm:"type:text"`
+	// Notify sends its creator an event when new clips match
+	Notify bool `json:"notify" gorm:"index"`
+	// CheckedAt is when new matches were last looked for
+	CheckedAt *time.Time `json:"checked_at"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (SavedSearch) TableName() string {
+	return "saved_searches"
+}
+
+// ClipSearchCriteria is what a saved search matches and how it sorts the
+// matches, as in AtomicClipSearchRequest.
+type ClipSearchCriteria struct {
+	Query      string   `json:"query" binding:"max=500"`
+	Mode       string   `json:"mode" binding:"omitempty,oneof=keyword semantic"`
+	Category   string   `json:"category"`
+	Tags       []string `json:"tags"`
+	Mood       string   `json:"mood"`
+	Style      string   `json:"style"`
+	Color      string   `json:"color"`
+	Duration   string   `json:"duration" binding:"omitempty,oneof=short medium long"`
+	Resolution string   `json:"resolution"`
+	SortBy     string   `json:"sort_by" binding:"omitempty,oneof=created_at duration file_size quality usage rating favorites relevance"`
+	SortOrder  string   `json:"sort_order" binding:"omitempty,oneof=asc desc"`
+}
+
+func (c ClipSearchCriteria) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *ClipSearchCriteria) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return nil
+}
+
+// SearchRequest returns the clip search of the criteria, from its first
+// page.
+func (c *ClipSearchCriteria) SearchRequest() *AtomicClipSearchRequest {
+	return &AtomicClipSearchRequest{
+		Query:      c.Query,
+		Mode:       c.Mode,
+		Category:   c.Category,
+		Tags:       c.Tags,
+		Mood:       c.Mood,
+		Style:      c.Style,
+		Color:      c.Color,
+		Duration:   c.Duration,
+		Resolution: c.Resolution,
+		SortBy:     c.SortBy,
+		SortOrder:  c.SortOrder,
+	}
+}
+
+type SavedSearchCreateRequest struct {
+	Name     string             `json:"name" binding:"required,max=200"`
+	Criteria ClipSearchCriteria `json:"criteria"`
+	Notify   bool               `json:"notify"`
+}
+
+type SavedSearchUpdateRequest struct {
+	Name     string              `json:"name" binding:"omitempty,max=200"`
+	Criteria *ClipSearchCriteria `json:"criteria" binding:"omitempty"`
+	Notify   *bool               `json:"notify" binding:"omitempty"`
+}
+
+// SavedSearchListRequest pages the saved searches of a workspace.
+type SavedSearchListRequest struct {
+	Page  int `form:"page,default=1" binding:"min=1"`
+	Limit int `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+// SavedSearchRunRequest pages the clips a saved search matches.
+type SavedSearchRunRequest struct {
+	Page   int    `form:"page,default=1" binding:"min=1"`
+	Limit  int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Cursor string `form:"cursor"`
+	Facets bool   `form:"facets"`
+}
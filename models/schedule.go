@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// Schedule is the persisted state of a recurring job registered in code.
+// Admins can change Spec and Enabled; the run fields are kept up to date by
+// the scheduler.
+type Schedule struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"uniqueIndex;not null;size:100"`
+	Spec        string `json:"spec" gorm:"not null;size:100"` // cron expression or @every/@daily descriptor
+	Description string `json:"description" gorm:"size:255"`
+	Enabled     bool   `json:"enabled" gorm:"default:true"`
+
+	// Runs
+	NextRunAt      *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	LastStatus     string     `json:"last_status" gorm:"size:20"` // running, succeeded, failed
+	LastError      string     `json:"last_error,omitempty" gorm:"type:text"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastWorkerID   string     `json:"last_worker_id" gorm:"size:100"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Schedule) TableName() string {
+	return "schedules"
+}
+
+const (
+	ScheduleStatusRunning   = "running"
+	ScheduleStatusSucceeded = "succeeded"
+	ScheduleStatusFailed    = "failed"
+)
+
+type ScheduleUpdateRequest struct {
+	Spec    *string `json:"spec" binding:"omitempty"`
+	Enabled *bool   `json:"enabled" binding:"omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Session is a signed-in device: one login and the refresh tokens rotated
+// from it, which share its ID as their family. Access tokens carry the ID
+// too, so revoking a session rejects them at once.
+type Session struct {
+	ID         uint       `json:"-" gorm:"primaryKey"`
+	SessionID  string     `json:"id" gorm:"uniqueIndex;not null;size:32"`
+	UserID     uint       `json:"-" gorm:"not null;index"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255"`
+	IPAddress  string     `json:"ip_address" gorm:"size:45"` // last seen from
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"index"` // when its refresh token expires
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Current marks the session of the request listing sessions
+	Current bool `json:"current" gorm:"-"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+)
+
+// ShareLink lets anyone holding its token view a clip, composition or
+// rendered output without an account. Only the SHA-256 hash of the token
+// is stored, and the bcrypt hash of the password when it has one.
+type ShareLink struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	TokenHash      string     `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	ResourceType   string     `json:"resource_type" gorm:"not null;size:20;index:idx_share_resource"`
+	ResourceID     uint       `json:"resource_id" gorm:"not null;index:idx_share_resource"`
+	Scope          string     `json:"scope" gorm:"not null;size:20"`
+	PasswordHash   string     `json:"-" gorm:"size:100"`
+	UserID         uint       `json:"user_id" gorm:"not null;index"`
+	OrganizationID *uint      `json:"organization_id"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	ViewCount      int        `json:"view_count" gorm:"default:0"`
+	LastViewedAt   *time.Time `json:"last_viewed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (ShareLink) TableName() string {
+	return "share_links"
+}
+
+// Resources a share link can point at
+const (
+	ShareResourceClip        = "clip"
+	ShareResourceComposition = "composition"
+	ShareResourceRender      = "render"
+)
+
+// Share link scopes. Every link can be viewed and played; downloading the
+// file takes the download scope.
+const (
+	ShareScopeView     = "view"
+	ShareScopeDownload = "download"
+)
+
+// Protected reports whether the link asks for a password.
+func (l *ShareLink) Protected() bool {
+	return l.PasswordHash != ""
+}
+
+type ShareLinkCreateRequest struct {
+	Scope    string `json:"scope" binding:"omitempty,oneof=view download"` // default view
+	Password string `json:"password" binding:"omitempty,min=6,max=72"`
+	// ExpiresInHours leaves the link valid until revoked when omitted
+	ExpiresInHours *int `json:"expires_in_hours" binding:"omitempty,min=1,max=8760"`
+}
+
+// ShareLinkCreateResponse carries the token, which is only ever shown when
+// the link is created.
+type ShareLinkCreateResponse struct {
+	ShareLink *ShareLink `json:"share_link"`
+	Token     string     `json:"token"`
+}
+
+// ShareLinkListRequest filters and pages the user's share links.
+type ShareLinkListRequest struct {
+	ResourceType string `form:"resource_type" binding:"omitempty,oneof=clip composition render"`
+	ResourceID   uint   `form:"resource_id"`
+	Page         int    `form:"page,default=1" binding:"min=1"`
+	Limit        int    `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+// SharedResource is what the public viewer shows of a shared resource.
+type SharedResource struct {
+	ResourceType string     `json:"resource_type"`
+	Scope        string     `json:"scope"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description,omitempty"`
+	Duration     float64    `json:"duration"`
+	Resolution   string     `json:"resolution,omitempty"`
+	Format       string     `json:"format,omitempty"`
+	// Timeline of a shared composition
+	Timeline RawJSON `json:"timeline,omitempty"`
+	// HasMedia tells whether the link has a file to stream
+	HasMedia bool `json:"has_media"`
+}
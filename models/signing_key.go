@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// SigningKey is a key pair that signs access tokens. The active key signs;
+// retired keys keep verifying the tokens they signed until those expire.
+// Revoked keys verify nothing, for keys that leaked.
+type SigningKey struct {
+	ID         uint       `json:"-" gorm:"primaryKey"`
+	KeyID      string     `json:"kid" gorm:"uniqueIndex;not null;size:32"`
+	Algorithm  string     `json:"alg" gorm:"not null;size:10"`
+	PrivateKey string     `json:"-" gorm:"type:text;not null"` // PEM, encrypted
+	PublicKey  string     `json:"-" gorm:"type:text;not null"` // PEM
+	RetiredAt  *time.Time `json:"retired_at,omitempty" gorm:"index"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
+
+// Active reports whether the key signs new tokens.
+func (k *SigningKey) Active() bool {
+	return k.RetiredAt == nil && k.RevokedAt == nil
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// maxTagLength bounds a normalized tag, in characters
+const maxTagLength = 50
+
+// Tag is a normalized clip tag. UsageCount is the number of clips carrying
+// it and orders autocomplete suggestions.
+type Tag struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Name       string    `json:"name" gorm:"uniqueIndex;not null;size:50"`
+	UsageCount int       `json:"usage_count" gorm:"default:0;index"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// NormalizeTag lowercases a tag, drops a leading '#' and any character other
+// than letters, digits, '-', '_' and single spaces. It returns "" when
+// nothing is left.
+func NormalizeTag(tag string) string {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(tag) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			space = true
+		}
+	}
+
+	normalized := []rune(b.String())
+	if len(normalized) > maxTagLength {
+		normalized = []rune(strings.TrimSpace(string(normalized[:maxTagLength])))
+	}
+	return string(normalized)
+}
+
+// NormalizeTags splits comma-separated entries, normalizes every tag and
+// drops empty and repeated ones, keeping the first occurrence's place.
+func NormalizeTags(tags []string) StringArray {
+	seen := make(map[string]bool, len(tags))
+	normalized := make(StringArray, 0, len(tags))
+	for _, entry := range tags {
+		for _, tag := range strings.Split(entry, ",") {
+			tag = NormalizeTag(tag)
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				normalized = append(normalized, tag)
+			}
+		}
+	}
+	return normalized
+}
+
+// ParseTags reads the comma-separated tags of an upload form.
+func ParseTags(raw string) StringArray {
+	return NormalizeTags([]string{raw})
+}
+
+type TagRenameRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+// TagMergeRequest folds the tag into the one named IntoID.
+type TagMergeRequest struct {
+	IntoID uint `json:"into_id" binding:"required"`
+}
+
+// TagListRequest pages tags by usage; Query keeps those starting with it.
+type TagListRequest struct {
+	Query string `form:"q"`
+	Page  int    `form:"page,default=1" binding:"min=1"`
+	Limit int    `form:"limit,default=50" binding:"min=1,max=200"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// TaskRecord persists the lifecycle of a background queue task so operators
+// can see what ran, where, for how long and why it failed.
+type TaskRecord struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	TaskID   string `json:"task_id" gorm:"uniqueIndex;not null;size:64"`
+	Type     string `json:"type" gorm:"size:50;index"`
+	Queue    string `json:"queue" gorm:"size:100;index"`
+	Status   string `json:"status" gorm:"size:20;index"` // queued, running, retrying, succeeded, failed
+	Priority int    `json:"priority"`
+	TraceID  string `json:"trace_id,omitempty" gorm:"size:64;index"`
+
+	// Execution
+	Attempts   int        `json:"attempts"`
+	MaxRetry   int        `json:"max_retry"`
+	WorkerID   string     `json:"worker_id" gorm:"size:100"`
+	LastError  string     `json:"last_error,omitempty" gorm:"type:text"`
+	QueuedAt   time.Time  `json:"queued_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	DurationMs int64      `json:"duration_ms"` // of the latest attempt
+
+	Payload JSON `json:"payload" gorm:"type:text"` // snapshot at publish time
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (TaskRecord) TableName() string {
+	return "tasks"
+}
+
+const (
+	TaskStatusQueued    = "queued"
+	TaskStatusRunning   = "running"
+	TaskStatusRetrying  = "retrying"
+	TaskStatusSucceeded = "succeeded"
+	TaskStatusFailed    = "failed"
+)
+
+// QueueSettingsUpdateRequest changes the worker settings of a queue at
+// runtime; omitted fields keep their current value.
+type QueueSettingsUpdateRequest struct {
+	Concurrency *int `json:"concurrency" binding:"omitempty,min=0,max=64"`
+	Prefetch    *int `json:"prefetch" binding:"omitempty,min=0,max=1000"`
+	MaxRetries  *int `json:"max_retries" binding:"omitempty,min=-1,max=20"`
+}
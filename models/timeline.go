@@ -0,0 +1,260 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ProjectTimeline is the structure stored in Project.Timeline.
+type ProjectTimeline struct {
+	Clips         []TimelineClip       `json:"clips"`
+	Transitions   []TimelineTransition `json:"transitions,omitempty"`
+	Duration      float64              `json:"duration"`
+	CompositionID *uint                `json:"composition_id,omitempty"`
+}
+
+type TimelineClip struct {
+	ClipID    uint    `json:"clip_id"`
+	Position  float64 `json:"position"`   // start on the project timeline, in seconds
+	StartTime float64 `json:"start_time"` // in point inside the source clip
+	EndTime   float64 `json:"end_time"`   // out point inside the source clip
+	Duration  float64 `json:"duration"`
+}
+
+type TimelineTransition struct {
+	Type     string  `json:"type"`
+	Position float64 `json:"position"`
+	Duration float64 `json:"duration"`
+	Easing   string  `json:"easing,omitempty"`
+}
+
+func ParseProjectTimeline(data JSON) (*ProjectTimeline, error) {
+	timeline := &ProjectTimeline{}
+	if data == nil {
+		return timeline, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode timeline: %w", err)
+	}
+	if err := json.Unmarshal(raw, timeline); err != nil {
+		return nil, fmt.Errorf("invalid timeline structure: %w", err)
+	}
+
+	return timeline, nil
+}
+
+func (t *ProjectTimeline) ToJSON() (JSON, error) {
+	raw, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode timeline: %w", err)
+	}
+
+	var data JSON
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to encode timeline: %w", err)
+	}
+
+	return data, nil
+}
+
+const (
+	// maxTimelineClips bounds the size of a timeline saved through the API
+	maxTimelineClips = 1000
+	// timelineEpsilon absorbs rounding in times sent by editors
+	timelineEpsilon = 0.001
+)
+
+// Validate checks that the timeline is consistent: clips have a positive
+// span inside their source, nothing starts before zero, and the duration
+// covers every clip and transition. A zero clip duration is filled in.
+func (t *ProjectTimeline) Validate() error {
+	if len(t.Clips) > maxTimelineClips {
+		return fmt.Errorf("timeline has more than %d clips", maxTimelineClips)
+	}
+
+	end := 0.0
+	for i := range t.Clips {
+		clip := &t.Clips[i]
+		if clip.ClipID == 0 {
+			return fmt.Errorf("clip %d has no clip_id", i)
+		}
+		if clip.Position < 0 || clip.StartTime < 0 {
+			return fmt.Errorf("clip %d starts before zero", i)
+		}
+		if clip.EndTime <= clip.StartTime {
+			return fmt.Errorf("clip %d ends before it starts", i)
+		}
+
+		span := clip.EndTime - clip.StartTime
+		if clip.Duration == 0 {
+			clip.Duration = span
+		} else if clip.Duration < 0 || clip.Duration-span > timelineEpsilon || span-clip.Duration > timelineEpsilon {
+			return fmt.Errorf("clip %d duration does not match its in and out points", i)
+		}
+		if clip.Position+clip.Duration > end {
+			end = clip.Position + clip.Duration
+		}
+	}
+
+	for i, transition := range t.Transitions {
+		if transition.Type == "" {
+			return fmt.Errorf("transition %d has no type", i)
+		}
+		if transition.Position < 0 || transition.Duration <= 0 {
+			return fmt.Errorf("transition %d has an invalid position or duration", i)
+		}
+		if transition.Position+transition.Duration > end+timelineEpsilon {
+			return fmt.Errorf("transition %d runs past the last clip", i)
+		}
+	}
+
+	if t.Duration == 0 {
+		t.Duration = end
+	} else if t.Duration < end-timelineEpsilon {
+		return errors.New("timeline duration is shorter than its clips")
+	}
+
+	return nil
+}
+
+// ClipIDs returns the distinct clips the timeline uses.
+func (t *ProjectTimeline) ClipIDs() []uint {
+	seen := make(map[uint]bool, len(t.Clips))
+	ids := make([]uint, 0, len(t.Clips))
+	for _, clip := range t.Clips {
+		if !seen[clip.ClipID] {
+			seen[clip.ClipID] = true
+			ids = append(ids, clip.ClipID)
+		}
+	}
+	return ids
+}
+
+// CheckSources checks the timeline against the durations of the clips it
+// may use: every clip must be among them and cut within its source.
+// Unknown durations (zero) are not checked.
+func (t *ProjectTimeline) CheckSources(durations map[uint]float64) error {
+	for _, clip := range t.Clips {
+		duration, ok := durations[clip.ClipID]
+		if !ok {
+			return fmt.Errorf("clip %d not found", clip.ClipID)
+		}
+		if duration > 0 && clip.EndTime > duration+timelineEpsilon {
+			return fmt.Errorf("clip %d is cut past its end", clip.ClipID)
+		}
+	}
+	return nil
+}
+
+// TimelineDiff lists what changed between two timelines. The n-th use of a
+// clip in one is matched with its n-th use in the other; a matched use that
+// moved or was recut is changed rather than removed and added.
+type TimelineDiff struct {
+	DurationFrom       float64              `json:"duration_from"`
+	DurationTo         float64              `json:"duration_to"`
+	AddedClips         []TimelineClip       `json:"added_clips"`
+	RemovedClips       []TimelineClip       `json:"removed_clips"`
+	ChangedClips       []TimelineClipChange `json:"changed_clips"`
+	AddedTransitions   []TimelineTransition `json:"added_transitions"`
+	RemovedTransitions []TimelineTransition `json:"removed_transitions"`
+}
+
+type TimelineClipChange struct {
+	From TimelineClip `json:"from"`
+	To   TimelineClip `json:"to"`
+}
+
+// Diff compares the timeline with a later one.
+func (t *ProjectTimeline) Diff(to *ProjectTimeline) TimelineDiff {
+	diff := TimelineDiff{
+		DurationFrom:       t.Duration,
+		DurationTo:         to.Duration,
+		AddedClips:         []TimelineClip{},
+		RemovedClips:       []TimelineClip{},
+		ChangedClips:       []TimelineClipChange{},
+		AddedTransitions:   []TimelineTransition{},
+		RemovedTransitions: []TimelineTransition{},
+	}
+
+	uses := make(map[uint][]TimelineClip)
+	for _, clip := range t.Clips {
+		uses[clip.ClipID] = append(uses[clip.ClipID], clip)
+	}
+	matched := make(map[uint]int)
+	for _, clip := range to.Clips {
+		n := matched[clip.ClipID]
+		if n >= len(uses[clip.ClipID]) {
+			diff.AddedClips = append(diff.AddedClips, clip)
+			continue
+		}
+		matched[clip.ClipID]++
+		if previous := uses[clip.ClipID][n]; previous != clip {
+			diff.ChangedClips = append(diff.ChangedClips, TimelineClipChange{From: previous, To: clip})
+		}
+	}
+	seen := make(map[uint]int)
+	for _, clip := range t.Clips {
+		seen[clip.ClipID]++
+		if seen[clip.ClipID] > matched[clip.ClipID] {
+			diff.RemovedClips = append(diff.RemovedClips, clip)
+		}
+	}
+
+	transitions := make(map[TimelineTransition]int)
+	for _, transition := range t.Transitions {
+		transitions[transition]++
+	}
+	for _, transition := range to.Transitions {
+		if transitions[transition] > 0 {
+			transitions[transition]--
+			continue
+		}
+		diff.AddedTransitions = append(diff.AddedTransitions, transition)
+	}
+	for _, transition := range t.Transitions {
+		if transitions[transition] > 0 {
+			transitions[transition]--
+			diff.RemovedTransitions = append(diff.RemovedTransitions, transition)
+		}
+	}
+
+	return diff
+}
+
+// TimelineExportRequest picks the interchange format of a timeline export:
+// CMX 3600 EDL, FCPXML or OpenTimelineIO.
+type TimelineExportRequest struct {
+	Format string `form:"format" binding:"required,oneof=edl fcpxml otio"`
+}
+
+// TimelineImportRequest holds the form fields sent with an imported
+// timeline file. The format defaults to the one of the file's extension
+// and the title to the name of the timeline in it.
+type TimelineImportRequest struct {
+	Title  string `form:"title" binding:"omitempty,max=200"`
+	Format string `form:"format" binding:"omitempty,oneof=fcpxml otio"`
+}
+
+// Reasons media of an imported timeline is left out
+const (
+	UnresolvedMediaNotFound      = "not_found"
+	UnresolvedMediaOutsideSource = "outside_source"
+)
+
+// UnresolvedMedia is a media reference of an imported timeline that no
+// clip stands in for. Its uses are left out of the project timeline.
+type UnresolvedMedia struct {
+	Name     string `json:"name"`
+	MediaURL string `json:"media_url,omitempty"`
+	Uses     int    `json:"uses"`
+	Reason   string `json:"reason"`
+}
+
+type TimelineImportResult struct {
+	Project         *Project          `json:"project"`
+	ImportedClips   int               `json:"imported_clips"`
+	UnresolvedMedia []UnresolvedMedia `json:"unresolved_media"`
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClipTranscript is the speech in a clip's audio, transcribed into
+// time-aligned segments. Clips without speech get an empty transcript.
+type ClipTranscript struct {
+	ID           uint               `json:"id" gorm:"primaryKey"`
+	AtomicClipID uint               `json:"atomic_clip_id" gorm:"uniqueIndex;not null"`
+	Language     string             `json:"language" gorm:"size:16"`
+	Text         string             `json:"text" gorm:"type:text"`
+	Segments     TranscriptSegments `json:"segments" gorm:"type:text"`
+	Provider     string             `json:"provider" gorm:"size:20"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// TranscriptSegment is a stretch of speech; Start and End are seconds from
+// the start of the clip.
+type TranscriptSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Transcript export formats
+const (
+	TranscriptFormatJSON   = "json"
+	TranscriptFormatSRT    = "srt"
+	TranscriptFormatWebVTT = "vtt"
+)
+
+// ClipTranscriptRequest picks the export format of a transcript.
+type ClipTranscriptRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=json srt vtt"`
+}
+
+// ClipTranscriptionRequest (re)transcribes a clip, in the given language
+// or the configured one.
+type ClipTranscriptionRequest struct {
+	Language string `json:"language" binding:"omitempty,min=2,max=16"`
+}
+
+type TranscriptSegments []TranscriptSegment
+
+func (s TranscriptSegments) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *TranscriptSegments) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return nil
+}
+
+// Window returns the segments overlapping [from, from+duration), cut to it
+// and timed from its start, as when the stretch is placed on a timeline.
+func (s TranscriptSegments) Window(from, duration float64) TranscriptSegments {
+	var window TranscriptSegments
+	for _, segment := range s {
+		start := max(segment.Start, from) - from
+		end := min(segment.End, from+duration) - from
+		if end <= start {
+			continue
+		}
+		window = append(window, TranscriptSegment{Start: start, End: end, Text: segment.Text})
+	}
+	return window
+}
+
+// SRT writes the segments as SubRip subtitles.
+func (s TranscriptSegments) SRT() string {
+	var b strings.Builder
+	for i, segment := range s {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1,
+			subtitleTimestamp(segment.Start, ","), subtitleTimestamp(segment.End, ","), subtitleText(segment.Text))
+	}
+	return b.String()
+}
+
+// WebVTT writes the segments as WebVTT subtitles.
+func (s TranscriptSegments) WebVTT() string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, segment := range s {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+			subtitleTimestamp(segment.Start, "."), subtitleTimestamp(segment.End, "."), subtitleText(segment.Text))
+	}
+	return b.String()
+}
+
+// subtitleTimestamp formats seconds as HH:MM:SS followed by the separator
+// and milliseconds.
+func subtitleTimestamp(seconds float64, separator string) string {
+	ms := int64(max(seconds, 0)*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, separator, ms%1000)
+}
+
+// subtitleText keeps a cue's text from ending the cue early: blank lines
+// end cues in both formats, and "-->" starts a WebVTT timing line.
+func subtitleText(text string) string {
+	lines := strings.FieldsFunc(strings.TrimSpace(text), func(r rune) bool { return r == '\n' || r == '\r' })
+	return strings.ReplaceAll(strings.Join(lines, "\n"), "-->", "->")
+}
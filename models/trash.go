@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Kinds of deleted records the trash holds
+const (
+	TrashTypeClip    = "clip"
+	TrashTypeProject = "project"
+)
+
+// TrashItem is a deleted clip or project that can still be restored.
+// PurgeAt is when it is deleted for good, nil when the trash is kept.
+type TrashItem struct {
+	Type      string     `json:"type"`
+	ID        uint       `json:"id"`
+	Title     string     `json:"title"`
+	Thumbnail string     `json:"thumbnail"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at"`
+}
+
+// TrashListRequest pages the trash of a workspace, most recently deleted
+// first.
+type TrashListRequest struct {
+	Page  int `form:"page,default=1" binding:"min=1"`
+	Limit int `form:"limit,default=20" binding:"min=1,max=100"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// RecoveryCode signs a user in once in place of a TOTP code, for when
+// their authenticator is lost. Only its SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null;size:64"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// TwoFactorSetupResponse carries the secret to add to an authenticator
+// app, either typed in or scanned from a QR code of the URL.
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	// Code is a TOTP code or a recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// Code is a TOTP code or a recovery code
+	Code string `json:"code" binding:"required"`
+}
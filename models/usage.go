@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Usage metrics counted against the quotas of a plan
+const (
+	UsageUploads       = "uploads"        // clips created, uploaded or imported
+	UsageRenderSeconds = "render_seconds" // of full renders completed
+)
+
+// UsageCounter is how much of a metric a user used in a day or a month.
+// Each use is added to the counter of its day and of its month.
+type UsageCounter struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_usage_counter"`
+	Metric string `json:"metric" gorm:"not null;size:30;uniqueIndex:idx_usage_counter"`
+	// Period is the UTC day, 2006-01-02, or month, 2006-01, counted
+	Period    string    `json:"period" gorm:"not null;size:10;uniqueIndex:idx_usage_counter"`
+	Amount    int64     `json:"amount"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (UsageCounter) TableName() string {
+	return "usage_counters"
+}
+
+// UsageDay and UsageMonth name the periods of the counters covering t.
+func UsageDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+func UsageMonth(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// RecordUsage adds amount to the user's counters of the metric for the day
+// and the month of at.
+func RecordUsage(tx *gorm.DB, userID uint, metric string, amount int64, at time.Time) error {
+	counters := []UsageCounter{
+		{UserID: userID, Metric: metric, Period: UsageDay(at), Amount: amount},
+		{UserID: userID, Metric: metric, Period: UsageMonth(at), Amount: amount},
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "metric"}, {Name: "period"}},
+		DoUpdates: append(
+			clause.Assignments(map[string]interface{}{"amount": gorm.Expr("amount + VALUES(amount)")}),
+			clause.AssignmentColumns([]string{"updated_at"})...,
+		),
+	}).Create(&counters).Error
+}
+
+// UsageMeter is the use of one quota: its limit, 0 when unlimited, and
+// when it starts over, for quotas counted per period.
+type UsageMeter struct {
+	Used     int64      `json:"used"`
+	Limit    int64      `json:"limit"`
+	ResetsAt *time.Time `json:"resets_at,omitempty"`
+}
+
+// Exceeded reports whether the quota is used up.
+func (m UsageMeter) Exceeded() bool {
+	return m.Limit > 0 && m.Used >= m.Limit
+}
+
+// Usage is a user's use of the quotas of their plan.
+type Usage struct {
+	UserID         uint       `json:"user_id"`
+	Plan           string     `json:"plan"`
+	DailyUploads   UsageMeter `json:"daily_uploads"`
+	MonthlyUploads UsageMeter `json:"monthly_uploads"`
+	RenderMinutes  UsageMeter `json:"render_minutes"` // this month
+	StorageBytes   UsageMeter `json:"storage_bytes"`  // of the user's clips, trash included
+}
@@ -13,9 +13,17 @@ type User struct {
 	Email     string    `json:"email" gorm:"uniqueIndex;not null;size:100"`
 	Password  string    `json:"-" gorm:"not null"`
 	Role      string    `json:"role" gorm:"not null;default:'user';size:20"`
+	// Plan sets the user's API rate limit; empty is the default plan
+	Plan      string    `json:"plan" gorm:"size:20"`
 	Avatar    string    `json:"avatar" gorm:"size:255"`
 	IsActive  bool      `json:"is_active" gorm:"default:true"`
 	LastLogin *time.Time `json:"last_login"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// TwoFactorSecret is the encrypted TOTP secret; it is set during
+	// enrollment and only in use once TwoFactorEnabledAt is set
+	TwoFactorSecret    string     `json:"-" gorm:"size:255"`
+	TwoFactorEnabledAt *time.Time `json:"two_factor_enabled_at"`
+	TwoFactorLastStep  int64      `json:"-"` // last TOTP time step used, against replays
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -51,6 +59,8 @@ type UserResponse struct {
 	Avatar    string     `json:"avatar"`
 	IsActive  bool       `json:"is_active"`
 	LastLogin *time.Time `json:"last_login"`
+	EmailVerified bool   `json:"email_verified"`
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 }
@@ -77,6 +87,8 @@ func (u *User) ToResponse() *UserResponse {
 		Avatar:    u.Avatar,
 		IsActive:  u.IsActive,
 		LastLogin: u.LastLogin,
+		EmailVerified: u.EmailVerifiedAt != nil,
+		TwoFactorEnabled: u.TwoFactorEnabledAt != nil,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}
@@ -0,0 +1,16 @@
+package models
+
+// FieldError is why a field of a request was refused. Field is empty for
+// problems of the whole request, such as a malformed body.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Rule    string `json:"rule"`    // the rule broken, such as required or max
+	Message string `json:"message"` // in the language the client accepts
+}
+
+// ValidationErrorResponse is the response to a request that failed
+// validation.
+type ValidationErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Kinds of video file, each kept in its own directory
+const (
+	VideoFileUpload = "upload" // uploaded for concatenation
+	VideoFileOutput = "output" // concatenated
+)
+
+// VideoFile records who owns a file of the video endpoints. Each user's
+// files are kept under a directory of their own; only files recorded for
+// them are served or deleted.
+type VideoFile struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_video_file"`
+	Kind      string    `json:"kind" gorm:"not null;size:10;uniqueIndex:idx_video_file"`
+	Filename  string    `json:"filename" gorm:"not null;size:255;uniqueIndex:idx_video_file"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (VideoFile) TableName() string {
+	return "video_files"
+}
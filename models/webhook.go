@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook events
+const (
+	WebhookEventClipAnalyzed     = "clip.analyzed"
+	WebhookEventClipTranscribed  = "clip.transcribed"
+	WebhookEventClipFlagged      = "clip.flagged"
+	WebhookEventClipDuplicate    = "clip.duplicate"
+	WebhookEventRenderCompleted  = "render.completed"
+	WebhookEventRenderFailed     = "render.failed"
+	WebhookEventCompositionReady = "composition.ready"
+	// WebhookEventPing is only sent when an endpoint is tested
+	WebhookEventPing = "ping"
+)
+
+// WebhookEvents lists the events an endpoint can subscribe to
+var WebhookEvents = []string{
+	WebhookEventClipAnalyzed,
+	WebhookEventClipTranscribed,
+	WebhookEventClipFlagged,
+	WebhookEventClipDuplicate,
+	WebhookEventRenderCompleted,
+	WebhookEventRenderFailed,
+	WebhookEventCompositionReady,
+}
+
+// Webhook is an endpoint that receives the events of a workspace: the
+// user's personal one, or the organization's when OrganizationID is set.
+// Deliveries are signed with the secret, which is only shown when the
+// endpoint is created or its secret rotated.
+type Webhook struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	UserID         uint           `json:"user_id" gorm:"not null;index"`
+	OrganizationID *uint          `json:"organization_id" gorm:"index"`
+	URL            string         `json:"url" gorm:"not null;size:500"`
+	Description    string         `json:"description" gorm:"size:200"`
+	Events         StringArray    `json:"events" gorm:"type:text"`
+	Secret         string         `json:"-" gorm:"not null;size:64"`
+	Active         bool           `json:"active" gorm:"default:true"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// Subscribes reports whether the endpoint receives the event.
+func (w *Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses of a webhook delivery
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryRetrying  = "retrying"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event sent, or to be sent, to an endpoint, with
+// the outcome of its last attempt.
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	WebhookID      uint       `json:"webhook_id" gorm:"not null;index"`
+	Event          string     `json:"event" gorm:"not null;size:50"`
+	Payload        RawJSON    `json:"payload" gorm:"type:text"`
+	Status         string     `json:"status" gorm:"default:'pending';size:20;index"`
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty" gorm:"type:text"` // truncated
+	Error          string     `json:"error,omitempty" gorm:"type:text"`
+	DurationMs     int64      `json:"duration_ms"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type WebhookCreateRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=500"`
+	Description string   `json:"description" binding:"omitempty,max=200"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=clip.analyzed render.completed render.failed composition.ready"`
+}
+
+type WebhookUpdateRequest struct {
+	URL         string   `json:"url" binding:"omitempty,url,max=500"`
+	Description *string  `json:"description" binding:"omitempty,max=200"`
+	Events      []string `json:"events" binding:"omitempty,min=1,dive,oneof=clip.analyzed render.completed render.failed composition.ready"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookSecretResponse carries the signing secret, which is only ever
+// shown when the endpoint is created or its secret rotated.
+type WebhookSecretResponse struct {
+	Webhook *Webhook `json:"webhook"`
+	Secret  string   `json:"secret"`
+}
+
+type WebhookListRequest struct {
+	Page  int `form:"page,default=1" binding:"min=1"`
+	Limit int `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+type WebhookDeliveryListRequest struct {
+	Page   int    `form:"page,default=1" binding:"min=1"`
+	Limit  int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Status string `form:"status" binding:"omitempty,oneof=pending retrying succeeded failed"`
+	Event  string `form:"event"`
+}
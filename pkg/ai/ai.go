@@ -0,0 +1,84 @@
+// Package ai puts the AI capabilities of clip analysis behind one provider
+// chosen per deployment by AI_PROVIDER, so the pipeline is not tied to a
+// vendor: the OpenAI API or a compatible one, or a local program. The
+// object detection, embedding, transcription, moderation and suggestion
+// packages use it when their own provider is "ai".
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+)
+
+// ErrUnsupported is returned for capabilities a provider does not offer.
+var ErrUnsupported = errors.New("not supported by the AI provider")
+
+// Tag is something a frame shows, scored from 0 to 1.
+type Tag struct {
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+}
+
+// Transcript is the speech in an audio file, in time-aligned segments.
+type Transcript struct {
+	Language string                    `json:"language"`
+	Text     string                    `json:"text"`
+	Segments models.TranscriptSegments `json:"segments"`
+}
+
+// AnalysisProvider offers the AI capabilities of clip analysis. Frames are
+// JPEG images, audio is a 16 kHz mono WAV file.
+type AnalysisProvider interface {
+	Name() string
+	// Tag names the objects, people and animals a frame shows
+	Tag(ctx context.Context, frame []byte) ([]Tag, error)
+	// Embed returns a vector per text, in the same order
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+	// Transcribe transcribes the speech in the language, or detects it
+	// when empty
+	Transcribe(ctx context.Context, audioPath, language string) (*Transcript, error)
+	// Moderate scores a frame per unsafe category from 0 to 1
+	Moderate(ctx context.Context, frame []byte) (map[string]float64, error)
+	// Generate follows the instructions about the input with a language
+	// model, answering with a JSON object
+	Generate(ctx context.Context, instructions, input string) ([]byte, error)
+}
+
+var provider AnalysisProvider
+
+// InitAI sets up the provider configured by AI_PROVIDER. It must run
+// before the capabilities that use it are initialized.
+func InitAI(cfg *config.Config) error {
+	switch cfg.AI.Provider {
+	case "none", "":
+		provider = nil
+		return nil
+	case "openai":
+		provider = newOpenAIProvider(&cfg.AI)
+	case "command":
+		cmd, err := newCommandProvider(&cfg.AI)
+		if err != nil {
+			return fmt.Errorf("invalid AI_COMMAND: %w", err)
+		}
+		provider = cmd
+	default:
+		return fmt.Errorf("unknown AI provider %q", cfg.AI.Provider)
+	}
+
+	logger.Infof("AI initialized with the %s provider", cfg.AI.Provider)
+	return nil
+}
+
+// Provider returns the deployment's AI provider, or an error when there is
+// none.
+func Provider() (AnalysisProvider, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("AI provider is not configured")
+	}
+	return provider, nil
+}
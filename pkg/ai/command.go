@@ -0,0 +1,124 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/command"
+)
+
+// unsupportedExitCode is how the program says it lacks a capability
+const unsupportedExitCode = 3
+
+// commandProvider runs a local program for every capability, such as a
+// script serving open models, which keeps them out of the server's process
+// and build. The capability is the first argument:
+//
+//	tag          reads a JPEG on stdin, writes {"objects": [{"label": "dog", "score": 0.9}]}
+//	embed        reads {"input": ["..."]} on stdin, writes {"embeddings": [[0.1, ...]]}
+//	transcribe   is given the WAV file's path and the language if set, writes Whisper's verbose JSON
+//	moderate     reads a JPEG on stdin, writes {"categories": {"nudity": 0.93}}
+//	generate     reads {"instructions": "...", "input": "..."} on stdin, writes the JSON object answering them
+//
+// It exits with status 3 for capabilities it does not offer.
+type commandProvider struct {
+	command *command.Command
+}
+
+func newCommandProvider(cfg *config.AIConfig) (*commandProvider, error) {
+	cmd, err := command.Parse(cfg.Command)
+	if err != nil {
+		return nil, err
+	}
+	return &commandProvider{command: cmd}, nil
+}
+
+func (p *commandProvider) Name() string {
+	return "command"
+}
+
+func (p *commandProvider) Tag(ctx context.Context, frame []byte) ([]Tag, error) {
+	var response struct {
+		Objects []Tag `json:"objects"`
+	}
+	if err := p.run(ctx, frame, &response, "tag"); err != nil {
+		return nil, err
+	}
+	return response.Objects, nil
+}
+
+func (p *commandProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	input, err := json.Marshal(map[string]interface{}{"input": texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode AI request: %w", err)
+	}
+
+	var response struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := p.run(ctx, input, &response, "embed"); err != nil {
+		return nil, err
+	}
+	if len(response.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("AI command returned %d embeddings for %d texts", len(response.Embeddings), len(texts))
+	}
+	return response.Embeddings, nil
+}
+
+func (p *commandProvider) Transcribe(ctx context.Context, audioPath, language string) (*Transcript, error) {
+	args := []string{"transcribe", audioPath}
+	if language != "" {
+		args = append(args, language)
+	}
+
+	var transcript Transcript
+	if err := p.run(ctx, nil, &transcript, args...); err != nil {
+		return nil, err
+	}
+	return &transcript, nil
+}
+
+func (p *commandProvider) Moderate(ctx context.Context, frame []byte) (map[string]float64, error) {
+	var response struct {
+		Categories map[string]float64 `json:"categories"`
+	}
+	if err := p.run(ctx, frame, &response, "moderate"); err != nil {
+		return nil, err
+	}
+	return response.Categories, nil
+}
+
+func (p *commandProvider) Generate(ctx context.Context, instructions, input string) ([]byte, error) {
+	request, err := json.Marshal(map[string]string{"instructions": instructions, "input": input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode AI request: %w", err)
+	}
+
+	var answer json.RawMessage
+	if err := p.run(ctx, request, &answer, "generate"); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// run runs the program with the capability's arguments and decodes what it
+// writes to stdout.
+func (p *commandProvider) run(ctx context.Context, input []byte, response interface{}, args ...string) error {
+	output, stderr, err := p.command.Run(ctx, input, args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == unsupportedExitCode {
+			return fmt.Errorf("%s: %w", args[0], ErrUnsupported)
+		}
+		return fmt.Errorf("AI command %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr))
+	}
+	if err := json.Unmarshal(output, response); err != nil {
+		return fmt.Errorf("failed to decode AI command output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"creative-studio-server/config"
+)
+
+// maxOpenAIResponse bounds an answer; transcripts of hours of speech stay
+// well under it
+const maxOpenAIResponse = 16 << 20
+
+// tagPrompt asks the vision model for the labels object detectors give
+const tagPrompt = `List the objects, people and animals clearly visible in the image. ` +
+	`Answer with JSON of the form {"objects": [{"label": "dog", "score": 0.9}]}, ` +
+	`using short lowercase English labels and scoring from 0 to 1 how sure you are.`
+
+// openAIProvider calls the OpenAI API, or a compatible one such as a
+// self-hosted vLLM or LocalAI server at another base URL.
+type openAIProvider struct {
+	url                string
+	apiKey             string
+	visionModel        string
+	embeddingModel     string
+	transcriptionModel string
+	moderationModel    string
+	textModel          string
+	client             *http.Client
+}
+
+func newOpenAIProvider(cfg *config.AIConfig) *openAIProvider {
+	return &openAIProvider{
+		url:                strings.TrimSuffix(cfg.URL, "/"),
+		apiKey:             cfg.APIKey,
+		visionModel:        cfg.VisionModel,
+		embeddingModel:     cfg.EmbeddingModel,
+		transcriptionModel: cfg.TranscriptionModel,
+		moderationModel:    cfg.ModerationModel,
+		textModel:          cfg.TextModel,
+		client:             &http.Client{},
+	}
+}
+
+func (p *openAIProvider) Name() string {
+	return "openai"
+}
+
+func (p *openAIProvider) Tag(ctx context.Context, frame []byte) ([]Tag, error) {
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	err := p.postJSON(ctx, "/chat/completions", map[string]interface{}{
+		"model":           p.visionModel,
+		"response_format": map[string]string{"type": "json_object"},
+		"messages": []map[string]interface{}{
+			{"role": "system", "content": tagPrompt},
+			{"role": "user", "content": []map[string]interface{}{imageContent(frame)}},
+		},
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("AI provider returned no tags")
+	}
+
+	var tags struct {
+		Objects []Tag `json:"objects"`
+	}
+	if err := json.Unmarshal([]byte(response.Choices[0].Message.Content), &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %w", err)
+	}
+	return tags.Objects, nil
+}
+
+func (p *openAIProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := p.postJSON(ctx, "/embeddings", map[string]interface{}{
+		"model": p.embeddingModel,
+		"input": texts,
+	}, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Data) != len(texts) {
+		return nil, fmt.Errorf("AI provider returned %d embeddings for %d texts", len(response.Data), len(texts))
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, item := range response.Data {
+		if item.Index < 0 || item.Index >= len(texts) || len(item.Embedding) == 0 {
+			return nil, fmt.Errorf("AI provider returned an invalid embedding")
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
+
+func (p *openAIProvider) Transcribe(ctx context.Context, audioPath, language string) (*Transcript, error) {
+	audio, err := os.Open(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio: %w", err)
+	}
+	defer audio.Close()
+
+	// The audio is streamed rather than buffered, it runs to tens of MB
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		writer.CloseWithError(func() error {
+			fields := map[string]string{"model": p.transcriptionModel, "response_format": "verbose_json"}
+			if language != "" {
+				fields["language"] = language
+			}
+			for name, value := range fields {
+				if err := form.WriteField(name, value); err != nil {
+					return err
+				}
+			}
+			part, err := form.CreateFormFile("file", filepath.Base(audioPath))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(part, audio); err != nil {
+				return err
+			}
+			return form.Close()
+		}())
+	}()
+
+	var transcript Transcript
+	if err := p.post(ctx, "/audio/transcriptions", body, form.FormDataContentType(), &transcript); err != nil {
+		return nil, err
+	}
+	return &transcript, nil
+}
+
+func (p *openAIProvider) Moderate(ctx context.Context, frame []byte) (map[string]float64, error) {
+	var response struct {
+		Results []struct {
+			CategoryScores map[string]float64 `json:"category_scores"`
+		} `json:"results"`
+	}
+	if err := p.postJSON(ctx, "/moderations", map[string]interface{}{
+		"model": p.moderationModel,
+		"input": []map[string]interface{}{imageContent(frame)},
+	}, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Results) == 0 {
+		return nil, fmt.Errorf("AI provider returned no moderation result")
+	}
+	return response.Results[0].CategoryScores, nil
+}
+
+func (p *openAIProvider) Generate(ctx context.Context, instructions, input string) ([]byte, error) {
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	err := p.postJSON(ctx, "/chat/completions", map[string]interface{}{
+		"model":           p.textModel,
+		"response_format": map[string]string{"type": "json_object"},
+		"messages": []map[string]interface{}{
+			{"role": "system", "content": instructions},
+			{"role": "user", "content": input},
+		},
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("AI provider returned no answer")
+	}
+	return []byte(response.Choices[0].Message.Content), nil
+}
+
+// imageContent is a JPEG frame as message content, inlined as a data URL.
+func imageContent(frame []byte) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "image_url",
+		"image_url": map[string]string{
+			"url": "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(frame),
+		},
+	}
+}
+
+func (p *openAIProvider) postJSON(ctx context.Context, path string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode AI request: %w", err)
+	}
+	return p.post(ctx, path, bytes.NewReader(body), "application/json", response)
+}
+
+func (p *openAIProvider) post(ctx context.Context, path string, body io.Reader, contentType string, response interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+path, body)
+	if err != nil {
+		if closer, ok := body.(io.Closer); ok {
+			closer.Close()
+		}
+		return fmt.Errorf("failed to create AI request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach AI provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("AI provider returned %d for %s: %s", resp.StatusCode, path, detail)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOpenAIResponse))
+	if err != nil {
+		return fmt.Errorf("failed to read AI response: %w", err)
+	}
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("failed to decode AI response: %w", err)
+	}
+	return nil
+}
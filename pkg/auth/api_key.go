@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// apiKeyPrefix marks API keys, so they are recognisable in logs and by
+// secret scanners
+const apiKeyPrefix = "csk_"
+
+// APIKeyPrefixLength is how much of a key is kept in clear to identify it
+const APIKeyPrefixLength = 12
+
+// NewAPIKey returns a random API key and the hash to store.
+func NewAPIKey() (key, hash string, err error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + secret
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the stored form of an API key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
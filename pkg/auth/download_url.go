@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"creative-studio-server/config"
+)
+
+var (
+	ErrInvalidDownloadURL = errors.New("invalid download signature")
+	ErrDownloadURLExpired = errors.New("download URL has expired")
+)
+
+// SignDownloadURL returns the URL of path, unescaped, with the query that
+// lets it be fetched without credentials until expires: the user it was
+// issued to, the expiry and an HMAC of both with the path.
+func SignDownloadURL(path string, owner uint, expires time.Time) string {
+	values := url.Values{}
+	values.Set("owner", strconv.FormatUint(uint64(owner), 10))
+	values.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	values.Set("signature", downloadSignature(path, values.Get("owner"), values.Get("expires")))
+	return (&url.URL{Path: path, RawQuery: values.Encode()}).String()
+}
+
+// VerifyDownloadURL checks the signature and expiry SignDownloadURL put in
+// the query of path, unescaped, and returns the user the URL was issued to.
+func VerifyDownloadURL(path string, query url.Values) (uint, error) {
+	owner, expires := query.Get("owner"), query.Get("expires")
+	ownerID, err := strconv.ParseUint(owner, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidDownloadURL
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidDownloadURL
+	}
+
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil {
+		return 0, ErrInvalidDownloadURL
+	}
+	expected, _ := hex.DecodeString(downloadSignature(path, owner, expires))
+	if !hmac.Equal(signature, expected) {
+		return 0, ErrInvalidDownloadURL
+	}
+	if time.Now().Unix() > expiresAt {
+		return 0, ErrDownloadURLExpired
+	}
+	return uint(ownerID), nil
+}
+
+func downloadSignature(path, owner, expires string) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.Storage.DownloadURLSecret))
+	mac.Write([]byte(path + "\n" + owner + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,6 +1,11 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,26 +18,44 @@ type Claims struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Role     string `json:"role"`
+	// SessionID names the session the token was issued to
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, username, email, role string) (string, error) {
+func GenerateToken(userID uint, username, email, role, sessionID string) (string, error) {
 	cfg := config.AppConfig
-	
+
+	// The ID lets a single access token be revoked on logout
+	id, err := NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
-		Role:     role,
+		UserID:    userID,
+		Username:  username,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.JWT.ExpiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "creative-studio-server",
 			Subject:   fmt.Sprintf("user:%d", userID),
+			ID:        id,
 		},
 	}
 
+	// Tokens are signed with the current key of the keyset and name it, so
+	// keys can rotate while tokens signed by the previous ones stay valid
+	if key := currentSigningKey(); key != nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = key.ID
+		return token.SignedString(key.Private)
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(cfg.JWT.Secret))
 }
@@ -41,9 +64,25 @@ func ParseToken(tokenString string) (*Claims, error) {
 	cfg := config.AppConfig
 	
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if kid, ok := token.Header["kid"].(string); ok {
+			if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			key, ok := verifyingKey(kid)
+			if !ok {
+				return nil, fmt.Errorf("unknown signing key: %s", kid)
+			}
+			return key.Public, nil
+		}
+
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		// Tokens signed with the JWT secret before signing keys existed, or
+		// while none could be loaded
+		if !cfg.JWT.AcceptLegacyTokens && currentSigningKey() != nil {
+			return nil, errors.New("tokens signed with the JWT secret are no longer accepted")
+		}
 		return []byte(cfg.JWT.Secret), nil
 	})
 
@@ -58,17 +97,41 @@ func ParseToken(tokenString string) (*Claims, error) {
 	return nil, fmt.Errorf("invalid token")
 }
 
-func RefreshToken(tokenString string) (string, error) {
-	claims, err := ParseToken(tokenString)
+// NewRefreshToken returns a random refresh token and the hash to store.
+func NewRefreshToken() (token, hash string, err error) {
+	token, err = randomToken(32)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
+	return token, HashRefreshToken(token), nil
+}
 
-	// Check if token is close to expiry (within 1 hour)
-	if time.Until(claims.ExpiresAt.Time) > time.Hour {
-		return tokenString, nil // Token is still valid for a while
-	}
+// HashRefreshToken returns the stored form of a refresh token.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAccountToken returns a random token for an account email and the
+// hash to store.
+func NewAccountToken() (token, hash string, err error) {
+	return NewRefreshToken()
+}
 
-	// Generate new token
-	return GenerateToken(claims.UserID, claims.Username, claims.Email, claims.Role)
+// HashAccountToken returns the stored form of an account email token.
+func HashAccountToken(token string) string {
+	return HashRefreshToken(token)
+}
+
+// NewTokenID returns a random identifier for a token or token family.
+func NewTokenID() (string, error) {
+	return randomToken(16)
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
\ No newline at end of file
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// SigningKey is an ES256 key that access tokens name in their kid header.
+// Keys without a private half only verify tokens signed before a rotation.
+type SigningKey struct {
+	ID      string
+	Private *ecdsa.PrivateKey
+	Public  *ecdsa.PublicKey
+}
+
+// keyset holds the key that signs new access tokens and every key whose
+// tokens are still accepted. It is replaced as a whole by SetKeyset.
+var keyset struct {
+	sync.RWMutex
+	signing   *SigningKey
+	verifying map[string]*SigningKey
+}
+
+// SetKeyset installs the signing key and the keys that verify tokens. A nil
+// signing key falls back to signing with the JWT secret.
+func SetKeyset(signing *SigningKey, verifying []*SigningKey) {
+	keys := make(map[string]*SigningKey, len(verifying))
+	for _, key := range verifying {
+		keys[key.ID] = key
+	}
+	if signing != nil {
+		keys[signing.ID] = signing
+	}
+
+	keyset.Lock()
+	defer keyset.Unlock()
+	keyset.signing = signing
+	keyset.verifying = keys
+}
+
+func currentSigningKey() *SigningKey {
+	keyset.RLock()
+	defer keyset.RUnlock()
+	return keyset.signing
+}
+
+func verifyingKey(id string) (*SigningKey, bool) {
+	keyset.RLock()
+	defer keyset.RUnlock()
+	key, ok := keyset.verifying[id]
+	return key, ok
+}
+
+// NewSigningKey generates a P-256 key pair and returns it with its PEM
+// encoded private and public halves for storage.
+func NewSigningKey() (key *SigningKey, privatePEM, publicPEM string, err error) {
+	id, err := NewTokenID()
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	privateDER, err := x509.MarshalECPrivateKey(private)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	key = &SigningKey{ID: id, Private: private, Public: &private.PublicKey}
+	privatePEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateDER}))
+	publicPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	return key, privatePEM, publicPEM, nil
+}
+
+// ParseSigningKey restores a key from its stored PEM halves. An empty
+// privatePEM gives a key that only verifies.
+func ParseSigningKey(id, privatePEM, publicPEM string) (*SigningKey, error) {
+	key := &SigningKey{ID: id}
+
+	if privatePEM != "" {
+		block, _ := pem.Decode([]byte(privatePEM))
+		if block == nil {
+			return nil, errors.New("malformed private key")
+		}
+		private, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		key.Private = private
+		key.Public = &private.PublicKey
+		return key, nil
+	}
+
+	block, _ := pem.Decode([]byte(publicPEM))
+	if block == nil {
+		return nil, errors.New("malformed public key")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	public, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an ECDSA key")
+	}
+	key.Public = public
+	return key, nil
+}
+
+// JWK is a public key in JSON Web Key form (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JWKS returns the public keys that verify access tokens, for services
+// that validate tokens themselves.
+func JWKS() []JWK {
+	keyset.RLock()
+	defer keyset.RUnlock()
+
+	keys := make([]JWK, 0, len(keyset.verifying))
+	for _, key := range keyset.verifying {
+		size := (key.Public.Curve.Params().BitSize + 7) / 8
+		x := make([]byte, size)
+		y := make([]byte, size)
+		key.Public.X.FillBytes(x)
+		key.Public.Y.FillBytes(y)
+
+		keys = append(keys, JWK{
+			KeyType:   "EC",
+			Curve:     "P-256",
+			X:         base64.RawURLEncoding.EncodeToString(x),
+			Y:         base64.RawURLEncoding.EncodeToString(y),
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: "ES256",
+		})
+	}
+	return keys
+}
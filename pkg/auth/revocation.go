@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/logger"
+)
+
+// Access tokens are stateless, so revoking one before it expires takes a
+// denylist. Entries live in Redis until the revoked tokens would have
+// expired anyway.
+
+func revokedTokenKey(tokenID string) string {
+	return fmt.Sprintf("auth:revoked:%s", tokenID)
+}
+
+func userRevokedBeforeKey(userID uint) string {
+	return fmt.Sprintf("auth:revoked_before:%d", userID)
+}
+
+func revokedSessionKey(sessionID string) string {
+	return fmt.Sprintf("auth:revoked_session:%s", sessionID)
+}
+
+// RevokeToken rejects the access token from now on.
+func RevokeToken(claims *Claims) error {
+	if cache.Cache == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+	return cache.Cache.Set(revokedTokenKey(claims.ID), "1", ttl)
+}
+
+// RevokeUserTokens rejects every access token issued to the user so far,
+// e.g. when they sign out everywhere or change their password.
+func RevokeUserTokens(userID uint, maxAge time.Duration) error {
+	if cache.Cache == nil {
+		return nil
+	}
+	return cache.Cache.Set(userRevokedBeforeKey(userID), strconv.FormatInt(time.Now().Unix(), 10), maxAge)
+}
+
+// RevokeSession rejects every access token issued to the session so far,
+// when it is signed out.
+func RevokeSession(sessionID string, maxAge time.Duration) error {
+	if cache.Cache == nil || sessionID == "" {
+		return nil
+	}
+	return cache.Cache.Set(revokedSessionKey(sessionID), "1", maxAge)
+}
+
+// IsRevoked reports whether the access token was revoked. When Redis cannot
+// be reached the token is accepted: revocation is best effort, and
+// rejecting every request would take the API down with the cache.
+func IsRevoked(claims *Claims) bool {
+	if cache.Cache == nil {
+		return false
+	}
+
+	if claims.ID != "" {
+		revoked, err := cache.Cache.Exists(revokedTokenKey(claims.ID))
+		if err != nil {
+			logger.Warnf("Failed to check token revocation: %v", err)
+			return false
+		}
+		if revoked {
+			return true
+		}
+	}
+
+	if claims.SessionID != "" {
+		revoked, err := cache.Cache.Exists(revokedSessionKey(claims.SessionID))
+		if err != nil {
+			logger.Warnf("Failed to check token revocation: %v", err)
+			return false
+		}
+		if revoked {
+			return true
+		}
+	}
+
+	value, err := cache.Cache.Get(userRevokedBeforeKey(claims.UserID))
+	if err != nil {
+		if !errors.Is(err, cache.ErrKeyNotFound) {
+			logger.Warnf("Failed to check token revocation: %v", err)
+		}
+		return false
+	}
+	revokedBefore, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || claims.IssuedAt == nil {
+		return false
+	}
+	return claims.IssuedAt.Unix() < revokedBefore
+}
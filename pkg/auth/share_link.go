@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// shareTokenPrefix marks share link tokens, so they are recognisable in
+// logs and by secret scanners
+const shareTokenPrefix = "css_"
+
+// NewShareToken returns a random share link token and the hash to store.
+func NewShareToken() (token, hash string, err error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	token = shareTokenPrefix + secret
+	return token, HashShareToken(token), nil
+}
+
+// HashShareToken returns the stored form of a share link token.
+func HashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
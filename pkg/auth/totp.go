@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"creative-studio-server/config"
+)
+
+// TOTP parameters (RFC 6238), the defaults of authenticator apps
+const (
+	totpIssuer = "Creative Studio"
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+	// totpSkew accepts codes from the neighbouring periods, for clock drift
+	totpSkew = 1
+)
+
+// ChallengeTTL is how long a password login may wait for its second factor
+const ChallengeTTL = 5 * time.Minute
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret returns a random base32 secret for an authenticator app.
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return base32NoPadding.EncodeToString(b), nil
+}
+
+// TOTPURL returns the otpauth:// URL that authenticator apps import,
+// usually shown as a QR code.
+func TOTPURL(secret, account string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", totpIssuer)
+	values.Set("digits", fmt.Sprint(totpDigits))
+	values.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	label := url.PathEscape(totpIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
+
+// ValidateTOTP checks a code against the secret and returns the time step
+// it matched. Callers reject steps at or before the last one used, so a
+// code cannot be replayed.
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	step := now.Unix() / int64(totpPeriod.Seconds())
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		if hmac.Equal([]byte(totpCode(key, step+offset)), []byte(code)) {
+			return step + offset, true
+		}
+	}
+	return 0, false
+}
+
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// NewRecoveryCode returns a random single-use recovery code, formatted
+// xxxxx-xxxxx for reading aloud or typing.
+func NewRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	code := strings.ToLower(base32NoPadding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// HashRecoveryCode returns the stored form of a recovery code. Dashes and
+// case are ignored.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return HashAPIKey(normalized)
+}
+
+// EncryptSecret encrypts a secret for storage with a key derived from
+// ENCRYPTION_KEY.
+func EncryptSecret(secret string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret reverses EncryptSecret.
+func DecryptSecret(encrypted string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed secret")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plain), nil
+}
+
+func secretCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("totp:" + config.AppConfig.JWT.EncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// challengeAudience keeps challenge tokens from being accepted anywhere
+// else; they are also signed with a different key than access tokens.
+const challengeAudience = "2fa-challenge"
+
+// GenerateChallengeToken returns a short-lived token proving the user
+// passed the password check and still owes a second factor.
+func GenerateChallengeToken(userID uint) (string, error) {
+	now := time.Now()
+	claims := &jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(ChallengeTTL)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    "creative-studio-server",
+		Subject:   fmt.Sprint(userID),
+		Audience:  jwt.ClaimStrings{challengeAudience},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(challengeKey())
+}
+
+// ParseChallengeToken returns the user of a valid challenge token.
+func ParseChallengeToken(tokenString string) (uint, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return challengeKey(), nil
+	}, jwt.WithAudience(challengeAudience))
+	if err != nil {
+		return 0, err
+	}
+
+	var userID uint
+	if _, err := fmt.Sscan(claims.Subject, &userID); err != nil {
+		return 0, fmt.Errorf("invalid challenge subject: %w", err)
+	}
+	return userID, nil
+}
+
+func challengeKey() []byte {
+	key := sha256.Sum256([]byte("2fa-challenge:" + config.AppConfig.JWT.Secret))
+	return key[:]
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// breakerThreshold is how many consecutive failures open the circuit
+	breakerThreshold = 5
+	// breakerCooldown is how long an open circuit fails fast before a
+	// single command is let through to probe Redis
+	breakerCooldown = 10 * time.Second
+)
+
+// ErrCircuitOpen is returned instead of waiting on a Redis that keeps
+// failing, so callers fall back right away.
+var ErrCircuitOpen = errors.New("redis circuit breaker is open")
+
+// breaker is a go-redis hook that stops sending commands to an unreachable
+// Redis for a while. Replies such as redis.Nil or WRONGTYPE are not
+// failures: only errors reaching the server are.
+type breaker struct {
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // zero while closed
+	probing  bool
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return true
+	}
+	if b.probing || time.Since(b.openedAt) < breakerCooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !isConnectionFailure(err) {
+		if !b.openedAt.IsZero() {
+			logger.Info("Redis is reachable again, closing circuit breaker")
+		}
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+
+	b.failures++
+	if !b.openedAt.IsZero() {
+		// The probe failed: stay open for another cooldown
+		b.openedAt = time.Now()
+	} else if b.failures >= breakerThreshold {
+		logger.Warnf("Redis failed %d times in a row, opening circuit breaker: %v", b.failures, err)
+		b.openedAt = time.Now()
+	}
+}
+
+// trip opens the circuit, e.g. when Redis is down at startup.
+func (b *breaker) trip() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = breakerThreshold
+	b.openedAt = time.Now()
+}
+
+// Open reports whether commands currently fail fast.
+func (b *breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openedAt.IsZero()
+}
+
+func isConnectionFailure(err error) bool {
+	if err == nil || err == redis.Nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var replyErr redis.Error
+	return !errors.As(err, &replyErr)
+}
+
+func (b *breaker) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (b *breaker) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !b.allow() {
+			cmd.SetErr(ErrCircuitOpen)
+			return ErrCircuitOpen
+		}
+		err := next(ctx, cmd)
+		b.record(err)
+		return err
+	}
+}
+
+func (b *breaker) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !b.allow() {
+			for _, cmd := range cmds {
+				cmd.SetErr(ErrCircuitOpen)
+			}
+			return ErrCircuitOpen
+		}
+		err := next(ctx, cmds)
+		b.record(err)
+		return err
+	}
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLockNotAcquired is returned by Lock when another owner holds the key
+	ErrLockNotAcquired = errors.New("lock is held by another owner")
+	// ErrLockLost is returned when the lock expired and may have been taken
+	// over by another owner
+	ErrLockLost = errors.New("lock was lost")
+)
+
+// Lock is a lease on a Redis key, held until it is released or its TTL runs
+// out. Only the owner that acquired it can refresh or release it.
+type Lock struct {
+	client *RedisClient
+	key    string
+	token  string
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	stop     chan struct{}
+	released bool
+	renewing sync.WaitGroup
+}
+
+// Lock acquires key for ttl with SET NX PX. It returns ErrLockNotAcquired
+// when the key is already held.
+func (r *RedisClient) Lock(key string, ttl time.Duration) (*Lock, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := r.client.SetNX(r.ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if !ok {
+		return nil, ErrLockNotAcquired
+	}
+
+	return &Lock{client: r, key: key, token: token, ttl: ttl}, nil
+}
+
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Key returns the locked key.
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Refresh extends the lock by its TTL. It returns ErrLockLost when the lock
+// is no longer held.
+func (l *Lock) Refresh() error {
+	ok, err := l.client.ExpireIfEquals(l.key, l.token, l.ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// AutoRenew refreshes the lock every interval until it is released.
+// onLost, if set, is called once when a refresh finds the lock gone; errors
+// talking to Redis are passed to it as well but renewal keeps trying, since
+// the lock outlives a short outage.
+func (l *Lock) AutoRenew(interval time.Duration, onLost func(error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != nil || l.released {
+		return
+	}
+	l.stop = make(chan struct{})
+	stop := l.stop
+
+	l.renewing.Add(1)
+	go func() {
+		defer l.renewing.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				err := l.Refresh()
+				if err == nil {
+					continue
+				}
+				if onLost != nil {
+					onLost(err)
+				}
+				if errors.Is(err, ErrLockLost) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Unlock stops renewal and releases the lock if this owner still holds it.
+// It returns ErrLockLost when the lock had already expired.
+func (l *Lock) Unlock() error {
+	l.mu.Lock()
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+	l.released = true
+	l.mu.Unlock()
+	l.renewing.Wait()
+
+	ok, err := l.client.DeleteIfEquals(l.key, l.token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	return nil
+}
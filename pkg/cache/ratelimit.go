@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript takes a token from the bucket at KEYS[1], refilled at
+// ARGV[1] tokens per second up to ARGV[2]. It returns whether a token was
+// taken, the whole tokens left, and the milliseconds until the next token
+// and until the bucket is full. Time is the server's, so instances with
+// skewed clocks share buckets fairly, and buckets expire once full.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "at")
+local tokens = tonumber(bucket[1]) or burst
+local at = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - at) * rate / 1000)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+local full = math.ceil((burst - tokens) * 1000 / rate)
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "at", now)
+redis.call("PEXPIRE", KEYS[1], full + 1000)
+
+local wait = 0
+if tokens < 1 then
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+return {allowed, math.floor(tokens), wait, full}`)
+
+// TokenBucket is the state of a rate limit bucket after taking a token.
+type TokenBucket struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is the wait until a token is available again, when none is
+	// left
+	RetryAfter time.Duration
+	// Reset is the wait until the bucket is full again
+	Reset time.Duration
+}
+
+// TakeToken takes a token from the bucket at key, which holds up to burst
+// tokens and refills at perSecond tokens a second. A new bucket starts
+// full.
+func (r *RedisClient) TakeToken(key string, perSecond float64, burst int) (*TokenBucket, error) {
+	values, err := tokenBucketScript.Run(r.ctx, r.client, []string{key}, perSecond, burst).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to take token from %s: %w", key, err)
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("failed to take token from %s: unexpected reply %v", key, values)
+	}
+
+	return &TokenBucket{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		Reset:      time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"creative-studio-server/pkg/logger"
+)
+
+// loads coalesces concurrent loads of the same key, so an expired popular
+// key costs one database query instead of one per request
+var loads singleflight.Group
+
+// ttlJitter is the largest fraction added to a TTL, so keys cached
+// together do not all expire at the same moment
+const ttlJitter = 0.1
+
+// ReadThrough fills dest from the cached JSON at key, or calls load to fill
+// it and caches the result for ttl plus some jitter. Concurrent misses on
+// the same key in this process share a single load. Without Redis or with a
+// zero ttl it only calls load. While Redis fails, reads go to the in-memory
+// fallback when it is enabled and to load otherwise.
+func ReadThrough(key string, ttl time.Duration, dest interface{}, load func() error) error {
+	if Cache == nil || ttl <= 0 {
+		return load()
+	}
+
+	err := Cache.GetJSON(key, dest)
+	if err == nil {
+		return nil
+	}
+	redisDown := !errors.Is(err, ErrKeyNotFound)
+	if redisDown {
+		if !errors.Is(err, ErrCircuitOpen) {
+			logger.Warnf("Failed to read cache key %s: %v", key, err)
+		}
+		if localCache != nil {
+			if data, ok := localCache.get(key); ok && json.Unmarshal(data, dest) == nil {
+				return nil
+			}
+		}
+	}
+
+	data, err, shared := loads.Do(key, func() (interface{}, error) {
+		if err := load(); err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(dest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value: %w", err)
+		}
+		if redisDown && localCache != nil {
+			localCache.set(key, data, ttl)
+		} else if err := Cache.Set(key, data, jitter(ttl)); err != nil {
+			if !errors.Is(err, ErrCircuitOpen) {
+				logger.Warnf("Failed to cache key %s: %v", key, err)
+			}
+			if localCache != nil {
+				localCache.set(key, data, ttl)
+			}
+		}
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+	if !shared {
+		// load filled dest itself
+		return nil
+	}
+	// Callers that waited on another one's load get a copy of its result
+	if err := json.Unmarshal(data.([]byte), dest); err != nil {
+		return fmt.Errorf("failed to unmarshal cached value: %w", err)
+	}
+	return nil
+}
+
+func jitter(ttl time.Duration) time.Duration {
+	return ttl + time.Duration(rand.Int63n(int64(float64(ttl)*ttlJitter)+1))
+}
+
+// ClipSearchGeneration returns the current generation of clip searches,
+// to be included in their cache key.
+func ClipSearchGeneration() string {
+	if Cache == nil {
+		return "0"
+	}
+	generation, err := Cache.Get(ClipSearchGenerationKey)
+	if err != nil {
+		if !errors.Is(err, ErrKeyNotFound) && !errors.Is(err, ErrCircuitOpen) {
+			logger.Warnf("Failed to read clip search generation: %v", err)
+		}
+		return "0"
+	}
+	return generation
+}
+
+// InvalidateClip drops the cached clip and every cached clip search, which
+// may include it.
+func InvalidateClip(clipID uint) {
+	if Cache == nil {
+		return
+	}
+	if localCache != nil {
+		localCache.delete(AtomicClipCacheKey(clipID))
+	}
+	if err := Cache.Delete(AtomicClipCacheKey(clipID)); err != nil {
+		logger.Warnf("Failed to invalidate cached clip %d: %v", clipID, err)
+	}
+	InvalidateClipSearches()
+}
+
+// InvalidateClipSearches makes every cached clip search miss; the stale
+// entries expire on their own.
+func InvalidateClipSearches() {
+	if Cache == nil {
+		return
+	}
+	if localCache != nil {
+		localCache.deletePrefix("search:")
+	}
+	if _, err := Cache.Increment(ClipSearchGenerationKey); err != nil {
+		logger.Warnf("Failed to invalidate cached clip searches: %v", err)
+	}
+}
+
+// InvalidateProject drops the cached project.
+func InvalidateProject(projectID uint) {
+	if Cache == nil {
+		return
+	}
+	if localCache != nil {
+		localCache.delete(ProjectCacheKey(projectID))
+	}
+	if err := Cache.Delete(ProjectCacheKey(projectID)); err != nil {
+		logger.Warnf("Failed to invalidate cached project %d: %v", projectID, err)
+	}
+}
+
+// InvalidateUserPlan drops the cached rate limit plan of a user.
+func InvalidateUserPlan(userID uint) {
+	if Cache == nil {
+		return
+	}
+	if localCache != nil {
+		localCache.delete(UserPlanCacheKey(userID))
+	}
+	if err := Cache.Delete(UserPlanCacheKey(userID)); err != nil {
+		logger.Warnf("Failed to invalidate cached plan of user %d: %v", userID, err)
+	}
+}
@@ -2,8 +2,12 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,41 +16,91 @@ import (
 )
 
 type RedisClient struct {
-	client *redis.Client
-	ctx    context.Context
+	client  redis.UniversalClient
+	ctx     context.Context
+	breaker *breaker
 }
 
 var Cache *RedisClient
 
+// ErrKeyNotFound is returned by Get and GetJSON for a missing key
+var ErrKeyNotFound = errors.New("key not found")
+
 func InitRedis(cfg *config.Config) error {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:         cfg.GetRedisAddr(),
-		Password:     cfg.Redis.Password,
-		DB:           cfg.Redis.DB,
-		DialTimeout:  10 * time.Second,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		PoolSize:     10,
-		PoolTimeout:  30 * time.Second,
-	})
+	rdb := newRedisClient(cfg)
+	b := &breaker{}
+	rdb.AddHook(b)
+	if cfg.Redis.Fallback {
+		localCache = newLRUCache(cfg.Redis.FallbackSize)
+	}
 
 	ctx := context.Background()
 	
 	// Test connection
 	_, err := rdb.Ping(ctx).Result()
-	if err != nil {
+	if err != nil && !cfg.Redis.Fallback {
 		return fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
 	Cache = &RedisClient{
-		client: rdb,
-		ctx:    ctx,
+		client:  rdb,
+		ctx:     ctx,
+		breaker: b,
 	}
 
-	logger.Info("Redis connected successfully")
+	if err != nil {
+		// go-redis keeps reconnecting; the breaker lets a probe through
+		// every cooldown until Redis is back
+		logger.Warnf("Redis is unavailable, starting with the in-memory fallback: %v", err)
+		b.trip()
+		return nil
+	}
+
+	logger.Infof("Redis connected successfully (%s mode)", cfg.Redis.Mode)
 	return nil
 }
 
+// newRedisClient connects to a single node, to the master found through
+// Sentinel, or to a cluster, depending on the configured mode.
+func newRedisClient(cfg *config.Config) redis.UniversalClient {
+	switch cfg.Redis.Mode {
+	case "sentinel":
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.Redis.MasterName,
+			SentinelAddrs:    cfg.GetRedisAddrs(),
+			SentinelPassword: cfg.Redis.SentinelPassword,
+			Password:         cfg.Redis.Password,
+			DB:               cfg.Redis.DB,
+			DialTimeout:      10 * time.Second,
+			ReadTimeout:      30 * time.Second,
+			WriteTimeout:     30 * time.Second,
+			PoolSize:         10,
+			PoolTimeout:      30 * time.Second,
+		})
+	case "cluster":
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.GetRedisAddrs(),
+			Password:     cfg.Redis.Password,
+			DialTimeout:  10 * time.Second,
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			PoolSize:     10,
+			PoolTimeout:  30 * time.Second,
+		})
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:         cfg.GetRedisAddr(),
+			Password:     cfg.Redis.Password,
+			DB:           cfg.Redis.DB,
+			DialTimeout:  10 * time.Second,
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			PoolSize:     10,
+			PoolTimeout:  30 * time.Second,
+		})
+	}
+}
+
 func (r *RedisClient) Set(key string, value interface{}, expiration time.Duration) error {
 	var data []byte
 	var err error
@@ -74,7 +128,7 @@ func (r *RedisClient) Set(key string, value interface{}, expiration time.Duratio
 func (r *RedisClient) Get(key string) (string, error) {
 	val, err := r.client.Get(r.ctx, key).Result()
 	if err == redis.Nil {
-		return "", fmt.Errorf("key %s not found", key)
+		return "", fmt.Errorf("%w: %s", ErrKeyNotFound, key)
 	} else if err != nil {
 		return "", fmt.Errorf("failed to get cache key %s: %w", key, err)
 	}
@@ -114,6 +168,56 @@ func (r *RedisClient) Exists(key string) (bool, error) {
 	return exists > 0, nil
 }
 
+// SetNX sets key only if it does not exist yet and reports whether it did.
+func (r *RedisClient) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(r.ctx, key, value, expiration).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to set cache key %s: %w", key, err)
+	}
+
+	return ok, nil
+}
+
+// Scripts that only touch a key while it still holds the caller's value
+var (
+	expireIfEqualsScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	deleteIfEqualsScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+	incrementWithTTLScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n`)
+)
+
+// ExpireIfEquals extends the TTL of key if it still holds value.
+func (r *RedisClient) ExpireIfEquals(key, value string, ttl time.Duration) (bool, error) {
+	n, err := expireIfEqualsScript.Run(r.ctx, r.client, []string{key}, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to extend cache key %s: %w", key, err)
+	}
+
+	return n > 0, nil
+}
+
+// DeleteIfEquals deletes key if it still holds value.
+func (r *RedisClient) DeleteIfEquals(key, value string) (bool, error) {
+	n, err := deleteIfEqualsScript.Run(r.ctx, r.client, []string{key}, value).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete cache key %s: %w", key, err)
+	}
+
+	return n > 0, nil
+}
+
 func (r *RedisClient) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
 	return r.Set(key, value, ttl)
 }
@@ -127,6 +231,16 @@ func (r *RedisClient) Increment(key string) (int64, error) {
 	return val, nil
 }
 
+// IncrementWithTTL increments key, which expires ttl after it was created.
+func (r *RedisClient) IncrementWithTTL(key string, ttl time.Duration) (int64, error) {
+	n, err := incrementWithTTLScript.Run(r.ctx, r.client, []string{key}, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment key %s: %w", key, err)
+	}
+
+	return n, nil
+}
+
 func (r *RedisClient) SetHash(key string, field string, value interface{}) error {
 	var data string
 	var err error
@@ -208,17 +322,104 @@ func (r *RedisClient) PopList(key string) (string, error) {
 	return val, nil
 }
 
+// scanBatchSize is the COUNT hint of each SCAN call
+const scanBatchSize = 500
+
+// ScanKeys calls fn with batches of the keys matching pattern, walking the
+// keyspace with SCAN so Redis is never blocked the way KEYS blocks it. Keys
+// added or removed during the walk may or may not be seen, and a key can be
+// reported twice. Iteration stops at the first error fn returns.
+func (r *RedisClient) ScanKeys(pattern string, fn func(keys []string) error) error {
+	// A cluster spreads keys over its masters, which each only know their own
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		var mu sync.Mutex // fn is not expected to be safe for concurrent use
+		err := cluster.ForEachMaster(r.ctx, func(ctx context.Context, node *redis.Client) error {
+			return scanNode(ctx, node, pattern, func(keys []string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				return fn(keys)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan keys with pattern %s: %w", pattern, err)
+		}
+		return nil
+	}
+
+	if err := scanNode(r.ctx, r.client, pattern, fn); err != nil {
+		return fmt.Errorf("failed to scan keys with pattern %s: %w", pattern, err)
+	}
+	return nil
+}
+
+func scanNode(ctx context.Context, client redis.Cmdable, pattern string, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// GetKeys returns every key matching pattern. Prefer ScanKeys on large
+// keyspaces, which does not hold them all in memory.
 func (r *RedisClient) GetKeys(pattern string) ([]string, error) {
-	keys, err := r.client.Keys(r.ctx, pattern).Result()
+	var keys []string
+	err := r.ScanKeys(pattern, func(batch []string) error {
+		keys = append(keys, batch...)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get keys with pattern %s: %w", pattern, err)
+		return nil, err
 	}
 
 	return keys, nil
 }
 
+// DeleteByPattern deletes every key matching pattern, one SCAN batch at a
+// time, and returns how many were deleted. UNLINK frees the values in the
+// background.
+func (r *RedisClient) DeleteByPattern(pattern string) (int64, error) {
+	var deleted int64
+	err := r.ScanKeys(pattern, func(keys []string) error {
+		// One command per key: keys of a batch may live in different
+		// cluster slots
+		cmds, err := r.client.Pipelined(r.ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Unlink(r.ctx, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, cmd := range cmds {
+			deleted += cmd.(*redis.IntCmd).Val()
+		}
+		return nil
+	})
+	return deleted, err
+}
+
 func (r *RedisClient) FlushDB() error {
-	err := r.client.FlushDB(r.ctx).Err()
+	var err error
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(r.ctx, func(ctx context.Context, node *redis.Client) error {
+			return node.FlushDB(ctx).Err()
+		})
+	} else {
+		err = r.client.FlushDB(r.ctx).Err()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to flush database: %w", err)
 	}
@@ -226,15 +427,62 @@ func (r *RedisClient) FlushDB() error {
 	return nil
 }
 
+func (r *RedisClient) Publish(channel string, message interface{}) error {
+	if err := r.client.Publish(r.ctx, channel, message).Err(); err != nil {
+		return fmt.Errorf("failed to publish to channel %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Do runs a raw command, for modules such as RediSearch that have no typed
+// client.
+func (r *RedisClient) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	result, err := r.client.Do(ctx, args...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %v: %w", args[0], err)
+	}
+	return result, nil
+}
+
+// Subscribe listens on the given channels until ctx is cancelled.
+func (r *RedisClient) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return r.client.Subscribe(ctx, channels...)
+}
+
+// Available reports whether Redis is currently reachable, as far as the
+// circuit breaker knows.
+func (r *RedisClient) Available() bool {
+	return !r.breaker.Open()
+}
+
 func (r *RedisClient) Close() error {
 	return r.client.Close()
 }
 
+// Pub/sub channels
+const (
+	// RenderCancelChannel carries task IDs of renders that workers must stop
+	RenderCancelChannel = "render:cancel"
+	// QueueSettingsChannel carries names of queues whose settings changed
+	QueueSettingsChannel = "queue:settings"
+	// EventBusChannel carries the events of the cross-instance event bus
+	EventBusChannel = "events"
+)
+
+// QueueSettingsKey is a hash of queue name to runtime settings overrides
+const QueueSettingsKey = "queue:settings"
+
 // Cache key helpers
 func UserCacheKey(userID uint) string {
 	return fmt.Sprintf("user:%d", userID)
 }
 
+// UserPlanCacheKey holds the rate limit plan of a user, looked up on every
+// request
+func UserPlanCacheKey(userID uint) string {
+	return fmt.Sprintf("user_plan:%d", userID)
+}
+
 func AtomicClipCacheKey(clipID uint) string {
 	return fmt.Sprintf("clip:%d", clipID)
 }
@@ -243,12 +491,44 @@ func ProjectCacheKey(projectID uint) string {
 	return fmt.Sprintf("project:%d", projectID)
 }
 
+// SearchCacheKey hashes the search parameters, so every distinct search
+// (filters and page included) gets its own entry
 func SearchCacheKey(query string, filters map[string]interface{}) string {
-	// Create a cache key based on search parameters
-	// In practice, you'd hash the parameters for a cleaner key
-	return fmt.Sprintf("search:%s", query)
+	data, _ := json.Marshal(filters) // map keys are marshalled sorted
+	sum := sha256.Sum256(append([]byte(query+"\x00"), data...))
+	return fmt.Sprintf("search:%s", hex.EncodeToString(sum[:16]))
+}
+
+// ClipSearchGenerationKey is bumped on every clip write; it is part of the
+// search filters so a write makes all cached clip searches miss
+const ClipSearchGenerationKey = "search:clips:generation"
+
+// ClipVectorKeyPrefix prefixes the hashes holding clip embeddings, which
+// the clip vector index covers
+const ClipVectorKeyPrefix = "vector:clip:"
+
+// ClipVectorIndex is the RediSearch index of clip embeddings
+const ClipVectorIndex = "idx:vector:clip"
+
+func ClipVectorKey(clipID uint) string {
+	return fmt.Sprintf("%s%d", ClipVectorKeyPrefix, clipID)
 }
 
 func RenderTaskCacheKey(taskID string) string {
 	return fmt.Sprintf("render_task:%s", taskID)
+}
+
+// TaskLockKey is held by the worker currently running a queue task
+func TaskLockKey(taskID string) string {
+	return fmt.Sprintf("task:lock:%s", taskID)
+}
+
+// TaskDoneKey marks a queue task that completed successfully
+func TaskDoneKey(taskID string) string {
+	return fmt.Sprintf("task:done:%s", taskID)
+}
+
+// SchedulerLeaderKey is held by the instance that fires scheduled jobs
+func SchedulerLeaderKey() string {
+	return "scheduler:leader"
 }
\ No newline at end of file
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// ErrEmpty is returned for a command line with no program in it
+var ErrEmpty = errors.New("command is empty")
+
+// Command is a local program the server hands work to, such as a wrapper
+// around a model, which keeps it out of the server's process and build.
+type Command struct {
+	name string
+	args []string
+}
+
+// Parse splits a configured command line into the program and its
+// arguments. It is run directly rather than through a shell, so quotes
+// and variables are not expanded.
+func Parse(line string) (*Command, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, ErrEmpty
+	}
+	return &Command{name: fields[0], args: fields[1:]}, nil
+}
+
+// Run runs the program with its arguments followed by args, reading input
+// on stdin when it is not nil. It returns what the program wrote to stdout
+// and to stderr; err is an *exec.ExitError when the program ran but failed.
+func (c *Command) Run(ctx context.Context, input []byte, args ...string) ([]byte, string, error) {
+	cmd := exec.CommandContext(ctx, c.name, append(append([]string{}, c.args...), args...)...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	return output, stderr.String(), err
+}
@@ -46,10 +46,12 @@ func InitDatabase(cfg *config.Config) error {
 	sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifeTime)
 	sqlDB.SetConnMaxIdleTime(cfg.Database.MaxIdleTime)
 
-	// Auto-migrate models (disabled - manual table creation)
-	// if err := AutoMigrate(); err != nil {
-	// 	return fmt.Errorf("failed to auto-migrate models: %w", err)
-	// }
+	// Auto-migrate models (opt-in, tables are created manually by default)
+	if cfg.Database.AutoMigrate {
+		if err := AutoMigrate(); err != nil {
+			return fmt.Errorf("failed to auto-migrate models: %w", err)
+		}
+	}
 
 	pkgLogger.Info("Database connected successfully")
 	return nil
@@ -60,9 +62,42 @@ func AutoMigrate() error {
 		&models.User{},
 		&models.AtomicClip{},
 		&models.Project{},
+		&models.ProjectRevision{},
 		&models.Template{},
 		&models.RenderTask{},
 		&models.VideoAnalysis{},
+		&models.ClipTranscript{},
+		&models.MusicAnalysis{},
+		&models.Composition{},
+		&models.CompositionFeedback{},
+		&models.TaskRecord{},
+		&models.Schedule{},
+		&models.AnalysisBackfill{},
+		&models.UsageCounter{},
+		&models.VideoFile{},
+		&models.RefreshToken{},
+		&models.APIKey{},
+		&models.Role{},
+		&models.Organization{},
+		&models.Membership{},
+		&models.AccountToken{},
+		&models.RecoveryCode{},
+		&models.SigningKey{},
+		&models.Session{},
+		&models.AuditLog{},
+		&models.Collection{},
+		&models.CollectionItem{},
+		&models.Favorite{},
+		&models.Rating{},
+		&models.Tag{},
+		&models.ClipImport{},
+		&models.ClipImportItem{},
+		&models.ShareLink{},
+		&models.Comment{},
+		&models.SavedSearch{},
+		&models.ClipUsage{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
 	)
 }
 
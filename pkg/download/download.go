@@ -0,0 +1,200 @@
+// Package download fetches files from URLs users give the server, without
+// letting those URLs reach the server's own network.
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrBlockedAddress = errors.New("address is not publicly routable")
+	ErrTooLarge       = errors.New("file is too large")
+)
+
+// maxRedirects bounds the redirects followed to the file
+const maxRedirects = 5
+
+// progressInterval is the least time between two progress reports
+const progressInterval = time.Second
+
+// CheckURL parses a URL that can be downloaded: absolute HTTP(S) with a
+// host.
+func CheckURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.New("invalid URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("URL must use http or https")
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("URL has no host")
+	}
+	if u.User != nil {
+		return nil, errors.New("URL must not contain credentials")
+	}
+	return u, nil
+}
+
+// NewClient returns a client for downloads. Unless allowPrivate is set it
+// refuses to connect to loopback, private, link-local and other addresses
+// that are not publicly routable, checked on the resolved address of every
+// connection so redirects and DNS answers cannot get around it.
+func NewClient(allowPrivate bool, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !publicIP(ip) {
+				return ErrBlockedAddress
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy would make the connection, hiding where it goes
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("redirect to a URL that is not http or https")
+			}
+			return nil
+		},
+	}
+}
+
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	// Carrier-grade NAT, shared by many networks behind a provider
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64 {
+		return false
+	}
+	return true
+}
+
+// Response is a download that passed the checks before its body is read.
+type Response struct {
+	ContentType string
+	// Size is the announced size, or -1 when the server did not send one
+	Size int64
+	// FileName is from the Content-Disposition header, when there is one
+	FileName string
+}
+
+// ToFile downloads the URL to path, failing with ErrTooLarge past maxSize
+// bytes. accept can refuse the download once its headers are in; progress,
+// when set, is called about every second with the bytes written so far.
+// The file is removed when the download fails.
+func ToFile(ctx context.Context, client *http.Client, rawURL, path string, maxSize int64, accept func(*Response) error, progress func(written, size int64)) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrBlockedAddress) {
+			return nil, ErrBlockedAddress
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server responded with %s", resp.Status)
+	}
+
+	info := &Response{
+		ContentType: strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0]),
+		Size:        resp.ContentLength,
+	}
+	if disposition := resp.Header.Get("Content-Disposition"); disposition != "" {
+		if _, params, err := mime.ParseMediaType(disposition); err == nil {
+			info.FileName = params["filename"]
+		}
+	}
+	if info.Size > maxSize {
+		return nil, ErrTooLarge
+	}
+	if accept != nil {
+		if err := accept(info); err != nil {
+			return nil, err
+		}
+	}
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	written, err := copyWithProgress(dst, io.LimitReader(resp.Body, maxSize+1), info.Size, progress)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && written > maxSize {
+		err = ErrTooLarge
+	}
+	if err != nil {
+		os.Remove(path)
+		if errors.Is(err, ErrTooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	if progress != nil {
+		progress(written, info.Size)
+	}
+	return info, nil
+}
+
+func copyWithProgress(dst io.Writer, src io.Reader, size int64, progress func(written, size int64)) (int64, error) {
+	buf := make([]byte, 256<<10)
+	var written int64
+	reported := time.Now()
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return written, werr
+			}
+			written += int64(n)
+			if progress != nil && time.Since(reported) >= progressInterval {
+				progress(written, size)
+				reported = time.Now()
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Package duplicates finds clips showing the same footage as another by
+// the perceptual hashes analysis stores, so re-uploads can be pointed out
+// before libraries fill with them.
+package duplicates
+
+import (
+	"math/bits"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+)
+
+const (
+	// Clips whose aligned frames differ in at most this many of a frame
+	// hash's bits on average show the same footage
+	maxFrameDistance = 10
+	frameHashBits    = 64
+	// A frame's hash in hex
+	frameHashLength = 16
+
+	// Re-uploads keep their length; trimmed or extended cuts of the
+	// footage are different clips
+	durationTolerance = 0.1
+
+	batchSize = 1000
+)
+
+// Match is a clip near-duplicating another, with how alike their frames
+// are from 0 to 1.
+type Match struct {
+	ClipID     uint
+	Similarity float64
+}
+
+// Similarity compares two perceptual hashes frame by frame, from 0 to 1.
+// It reports false when they have no frame in common to compare or are not
+// hashes.
+func Similarity(a, b string) (float64, bool) {
+	frames := min(len(a), len(b)) / frameHashLength
+	if frames == 0 {
+		return 0, false
+	}
+
+	distance := 0
+	for i := 0; i < frames; i++ {
+		start, end := i*frameHashLength, (i+1)*frameHashLength
+		frameA, errA := strconv.ParseUint(a[start:end], 16, 64)
+		frameB, errB := strconv.ParseUint(b[start:end], 16, 64)
+		if errA != nil || errB != nil {
+			return 0, false
+		}
+		distance += bits.OnesCount64(frameA ^ frameB)
+	}
+	return 1 - float64(distance)/float64(frames*frameHashBits), true
+}
+
+// IsNearDuplicate reports whether a similarity means the same footage.
+func IsNearDuplicate(similarity float64) bool {
+	return similarity >= 1-float64(maxFrameDistance)/frameHashBits
+}
+
+// Find returns the clips of the clip's workspace whose hash is near its
+// hash, most alike first.
+func Find(db *gorm.DB, clip *models.AtomicClip, hash string) ([]Match, error) {
+	query := db.Model(&models.VideoAnalysis{}).
+		Select("video_analyses.id", "video_analyses.atomic_clip_id", "video_analyses.perceptual_hash").
+		Joins("JOIN atomic_clips ON atomic_clips.id = video_analyses.atomic_clip_id AND atomic_clips.deleted_at IS NULL").
+		Where("video_analyses.perceptual_hash <> ''").
+		Where("atomic_clips.id <> ?", clip.ID)
+	if clip.OrganizationID != nil {
+		query = query.Where("atomic_clips.organization_id = ?", *clip.OrganizationID)
+	} else {
+		query = query.Where("atomic_clips.user_id = ? AND atomic_clips.organization_id IS NULL", clip.UserID)
+	}
+	if clip.Duration > 0 {
+		query = query.Where("atomic_clips.duration BETWEEN ? AND ?", clip.Duration*(1-durationTolerance), clip.Duration*(1+durationTolerance))
+	}
+
+	var matches []Match
+	var analyses []models.VideoAnalysis
+	err := query.FindInBatches(&analyses, batchSize, func(tx *gorm.DB, _ int) error {
+		for _, analysis := range analyses {
+			if similarity, ok := Similarity(hash, analysis.PerceptualHash); ok && IsNearDuplicate(similarity) {
+				matches = append(matches, Match{ClipID: analysis.AtomicClipID, Similarity: similarity})
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Similarity > matches[j].Similarity
+	})
+	return matches, nil
+}
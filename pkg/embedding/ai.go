@@ -0,0 +1,16 @@
+package embedding
+
+import (
+	"context"
+
+	"creative-studio-server/pkg/ai"
+)
+
+// aiProvider embeds texts with the AI provider's embedding model.
+type aiProvider struct {
+	provider ai.AnalysisProvider
+}
+
+func (p *aiProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	return p.provider.Embed(ctx, texts)
+}
@@ -0,0 +1,76 @@
+package embedding
+
+import (
+	"context"
+	"sort"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+)
+
+// databaseStoreBatchSize is how many vectors are compared per query
+const databaseStoreBatchSize = 1000
+
+// databaseStore uses the vectors the analyses keep in their Embedding
+// column and compares the query with every vector of the workspace. It needs nothing
+// beyond the database and suits libraries of up to some ten thousand
+// clips; larger ones should use the Redis store.
+type databaseStore struct {
+	db *gorm.DB
+}
+
+func newDatabaseStore() *databaseStore {
+	return &databaseStore{db: database.GetDB()}
+}
+
+// Upsert does nothing: the analysis stores the vector.
+func (s *databaseStore) Upsert(ctx context.Context, vector *Vector) error {
+	return nil
+}
+
+// Delete does nothing: vectors of deleted clips are skipped by Nearest and
+// go with their analysis.
+func (s *databaseStore) Delete(ctx context.Context, clipIDs []uint) error {
+	return nil
+}
+
+func (s *databaseStore) Nearest(ctx context.Context, values []float64, scope Scope, k int) ([]Match, error) {
+	query := s.db.WithContext(ctx).Model(&models.VideoAnalysis{}).
+		Select("video_analyses.id", "video_analyses.atomic_clip_id", "video_analyses.embedding").
+		Joins("JOIN atomic_clips ON atomic_clips.id = video_analyses.atomic_clip_id AND atomic_clips.deleted_at IS NULL").
+		Where("video_analyses.embedding IS NOT NULL")
+	if scope.OrganizationID != nil {
+		query = query.Where("atomic_clips.organization_id = ?", *scope.OrganizationID)
+	} else {
+		query = query.Where("atomic_clips.user_id = ? AND atomic_clips.organization_id IS NULL", scope.UserID)
+	}
+
+	var matches []Match
+	var analyses []models.VideoAnalysis
+	err := query.FindInBatches(&analyses, databaseStoreBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, analysis := range analyses {
+			matches = append(matches, Match{ClipID: analysis.AtomicClipID, Score: cosine(values, analysis.Embedding)})
+		}
+		// Only the best k can make it, so the rest is dropped as it goes
+		if len(matches) > 2*k {
+			matches = topMatches(matches, k)
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return topMatches(matches, k), nil
+}
+
+// topMatches returns the k best matches, best first.
+func topMatches(matches []Match, k int) []Match {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
@@ -0,0 +1,220 @@
+// Package embedding turns clips and search queries into vectors so clips
+// can be found by meaning: a query describing a scene matches clips about
+// it even when no word is shared, and similar clips are the nearest ones
+// rather than those with the same category and mood.
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/ai"
+	"creative-studio-server/pkg/logger"
+)
+
+// Provider embeds texts into vectors of one model.
+type Provider interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// Vector is the embedding of a clip, with the workspace it belongs to.
+type Vector struct {
+	ClipID         uint
+	UserID         uint
+	OrganizationID *uint
+	Values         []float64
+}
+
+// Scope is the workspace nearest neighbors are looked for in: a user's
+// personal library or, when OrganizationID is set, an organization's.
+type Scope struct {
+	UserID         uint
+	OrganizationID *uint
+}
+
+// Match is a clip near the searched vector. Score is the cosine
+// similarity, 1 for the same direction.
+type Match struct {
+	ClipID uint
+	Score  float64
+}
+
+// Store keeps clip vectors and finds the nearest ones.
+type Store interface {
+	Upsert(ctx context.Context, vector *Vector) error
+	Delete(ctx context.Context, clipIDs []uint) error
+	// Nearest returns at most k clips of the scope, most similar first
+	Nearest(ctx context.Context, values []float64, scope Scope, k int) ([]Match, error)
+}
+
+var (
+	provider Provider
+	store    Store
+	timeout  time.Duration
+)
+
+// InitEmbedding sets up the provider and vector store configured by
+// EMBEDDING_PROVIDER and VECTOR_STORE. Semantic search stays off without a
+// provider.
+func InitEmbedding(cfg *config.Config) error {
+	timeout = cfg.Embedding.Timeout
+
+	model := cfg.Embedding.Model
+	switch cfg.Embedding.Provider {
+	case "none", "":
+		provider, store = nil, nil
+		return nil
+	case "http":
+		provider = newHTTPProvider(&cfg.Embedding)
+	case "ai":
+		analysisProvider, err := ai.Provider()
+		if err != nil {
+			return err
+		}
+		provider = &aiProvider{provider: analysisProvider}
+		model = cfg.AI.EmbeddingModel
+	default:
+		return fmt.Errorf("unknown embedding provider %q", cfg.Embedding.Provider)
+	}
+
+	switch cfg.Embedding.Store {
+	case "database", "":
+		store = newDatabaseStore()
+	case "redis":
+		redisStore, err := newRedisStore(cfg.Embedding.Dimensions)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := redisStore.createIndex(ctx); err != nil {
+			return err
+		}
+		store = redisStore
+	default:
+		return fmt.Errorf("unknown vector store %q", cfg.Embedding.Store)
+	}
+
+	logger.Infof("Embeddings initialized with model %s and %s vector store", model, cfg.Embedding.Store)
+	return nil
+}
+
+// Enabled reports whether semantic search is available.
+func Enabled() bool {
+	return provider != nil
+}
+
+// EmbedText returns the embedding of a search query.
+func EmbedText(text string) ([]float64, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("embeddings are not enabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	vectors, err := provider.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedClip embeds the clip's content, as described by its metadata and
+// analysis, and adds the vector to the store. The caller keeps it on the
+// analysis, where similar clips start from.
+func EmbedClip(clip *models.AtomicClip, analysis *models.VideoAnalysis) ([]float64, error) {
+	values, err := EmbedText(ClipText(clip, analysis))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	vector := &Vector{ClipID: clip.ID, UserID: clip.UserID, OrganizationID: clip.OrganizationID, Values: values}
+	if err := store.Upsert(ctx, vector); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Delete removes the vectors of deleted clips.
+func Delete(clipIDs []uint) error {
+	if store == nil || len(clipIDs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return store.Delete(ctx, clipIDs)
+}
+
+// Restore adds back the vector of a clip restored from the trash, as its
+// analysis kept it.
+func Restore(clip *models.AtomicClip, values []float64) error {
+	if store == nil || len(values) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return store.Upsert(ctx, &Vector{ClipID: clip.ID, UserID: clip.UserID, OrganizationID: clip.OrganizationID, Values: values})
+}
+
+// Nearest returns the clips of the scope nearest to the vector.
+func Nearest(values []float64, scope Scope, k int) ([]Match, error) {
+	if store == nil {
+		return nil, fmt.Errorf("embeddings are not enabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return store.Nearest(ctx, values, scope, k)
+}
+
+// ClipText describes a clip in words for the embedding model: its title,
+// description and tags, then what analysis found in it.
+func ClipText(clip *models.AtomicClip, analysis *models.VideoAnalysis) string {
+	var parts []string
+	add := func(label, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			parts = append(parts, label+": "+value)
+		}
+	}
+
+	add("Title", clip.Title)
+	add("Description", clip.Description)
+	add("Tags", strings.Join(clip.Tags, ", "))
+	add("Category", clip.Category)
+	add("Mood", clip.Mood)
+	add("Style", clip.Style)
+	add("Color", clip.Color)
+	if analysis != nil {
+		add("Scene", analysis.AIDescription)
+		add("Detected", strings.Join(analysis.AITags, ", "))
+		add("On-screen text", analysis.TextContent)
+		add("Motion", analysis.MotionIntensity)
+		add("Camera", analysis.CameraMovement)
+		add("Audio", analysis.AudioType)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// cosine returns the cosine similarity of two vectors, 0 when their sizes
+// differ or either is zero.
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
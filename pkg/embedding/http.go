@@ -0,0 +1,87 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"creative-studio-server/config"
+)
+
+// httpProvider calls an OpenAI-compatible embeddings endpoint, which most
+// hosted and self-hosted embedding servers offer.
+type httpProvider struct {
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func newHTTPProvider(cfg *config.EmbeddingConfig) *httpProvider {
+	return &httpProvider{
+		url:    cfg.URL,
+		apiKey: cfg.APIKey,
+		model:  cfg.Model,
+		client: &http.Client{},
+	}
+}
+
+func (p *httpProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model": p.model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embedding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach embedding endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("embedding endpoint returned %d: %s", resp.StatusCode, detail)
+	}
+
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+	if len(response.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding endpoint returned %d embeddings for %d texts", len(response.Data), len(texts))
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, item := range response.Data {
+		if item.Index < 0 || item.Index >= len(texts) || len(item.Embedding) == 0 {
+			return nil, fmt.Errorf("embedding endpoint returned an invalid embedding")
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	for _, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("embedding endpoint returned an invalid embedding")
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,141 @@
+package embedding
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"creative-studio-server/pkg/cache"
+)
+
+// redisStore keeps vectors in Redis hashes covered by a RediSearch HNSW
+// index, which finds nearest neighbors without comparing every vector. It
+// needs Redis Stack or the RediSearch module.
+type redisStore struct {
+	client     *cache.RedisClient
+	dimensions int
+}
+
+func newRedisStore(dimensions int) (*redisStore, error) {
+	if cache.Cache == nil {
+		return nil, fmt.Errorf("VECTOR_STORE=redis requires Redis")
+	}
+	return &redisStore{client: cache.Cache, dimensions: dimensions}, nil
+}
+
+// createIndex creates the vector index unless it exists.
+func (s *redisStore) createIndex(ctx context.Context) error {
+	_, err := s.client.Do(ctx, "FT.CREATE", cache.ClipVectorIndex,
+		"ON", "HASH", "PREFIX", 1, cache.ClipVectorKeyPrefix,
+		"SCHEMA",
+		"user_id", "NUMERIC",
+		"organization_id", "NUMERIC",
+		"vector", "VECTOR", "HNSW", 6, "TYPE", "FLOAT32", "DIM", s.dimensions, "DISTANCE_METRIC", "COSINE")
+	if err != nil && !strings.Contains(err.Error(), "Index already exists") {
+		return fmt.Errorf("failed to create vector index: %w", err)
+	}
+	return nil
+}
+
+func (s *redisStore) Upsert(ctx context.Context, vector *Vector) error {
+	if len(vector.Values) != s.dimensions {
+		return fmt.Errorf("embedding has %d dimensions, the vector index %d", len(vector.Values), s.dimensions)
+	}
+	var organizationID uint // personal clips
+	if vector.OrganizationID != nil {
+		organizationID = *vector.OrganizationID
+	}
+
+	_, err := s.client.Do(ctx, "HSET", cache.ClipVectorKey(vector.ClipID),
+		"user_id", vector.UserID,
+		"organization_id", organizationID,
+		"vector", float32Bytes(vector.Values))
+	return err
+}
+
+func (s *redisStore) Delete(ctx context.Context, clipIDs []uint) error {
+	// One key at a time, as keys of a cluster live on different slots
+	for _, clipID := range clipIDs {
+		if _, err := s.client.Do(ctx, "DEL", cache.ClipVectorKey(clipID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *redisStore) Nearest(ctx context.Context, values []float64, scope Scope, k int) ([]Match, error) {
+	filter := fmt.Sprintf("(@user_id:[%d %d] @organization_id:[0 0])", scope.UserID, scope.UserID)
+	if scope.OrganizationID != nil {
+		filter = fmt.Sprintf("(@organization_id:[%d %d])", *scope.OrganizationID, *scope.OrganizationID)
+	}
+
+	reply, err := s.client.Do(ctx, "FT.SEARCH", cache.ClipVectorIndex,
+		fmt.Sprintf("%s=>[KNN %d @vector $vector AS distance]", filter, k),
+		"PARAMS", 2, "vector", float32Bytes(values),
+		"SORTBY", "distance", "ASC",
+		"RETURN", 1, "distance",
+		"LIMIT", 0, k,
+		"DIALECT", 2)
+	if err != nil {
+		return nil, err
+	}
+	return parseSearchReply(reply)
+}
+
+// parseSearchReply reads the keys and distances of an FT.SEARCH reply, in
+// its RESP2 (array) or RESP3 (map) form. Cosine distances are turned back
+// into similarities.
+func parseSearchReply(reply interface{}) ([]Match, error) {
+	var matches []Match
+	add := func(key interface{}, distance interface{}) error {
+		id, err := strconv.ParseUint(strings.TrimPrefix(fmt.Sprint(key), cache.ClipVectorKeyPrefix), 10, 64)
+		if err != nil {
+			return fmt.Errorf("unexpected key %v in vector search reply", key)
+		}
+		d, err := strconv.ParseFloat(fmt.Sprint(distance), 64)
+		if err != nil {
+			return fmt.Errorf("unexpected distance %v in vector search reply", distance)
+		}
+		matches = append(matches, Match{ClipID: uint(id), Score: 1 - d})
+		return nil
+	}
+
+	switch r := reply.(type) {
+	case []interface{}:
+		// total, key, [field, value, ...], key, [...], ...
+		for i := 1; i+1 < len(r); i += 2 {
+			fields, _ := r[i+1].([]interface{})
+			for j := 0; j+1 < len(fields); j += 2 {
+				if fmt.Sprint(fields[j]) == "distance" {
+					if err := add(r[i], fields[j+1]); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	case map[interface{}]interface{}:
+		results, _ := r["results"].([]interface{})
+		for _, result := range results {
+			item, _ := result.(map[interface{}]interface{})
+			attributes, _ := item["extra_attributes"].(map[interface{}]interface{})
+			if err := add(item["id"], attributes["distance"]); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected vector search reply %T", reply)
+	}
+	return matches, nil
+}
+
+// float32Bytes encodes a vector the way RediSearch FLOAT32 fields store it.
+func float32Bytes(values []float64) []byte {
+	data := make([]byte, 4*len(values))
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(float32(value)))
+	}
+	return data
+}
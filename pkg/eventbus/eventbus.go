@@ -0,0 +1,128 @@
+// Package eventbus broadcasts events to every server instance through Redis
+// pub/sub, so state held in one process (open websocket and SSE
+// connections, in-process caches) can follow what happened in another.
+// Delivery is at most once: instances that are down or disconnected miss
+// the events published meanwhile.
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/logger"
+)
+
+// Event is a published event as handlers receive it.
+type Event struct {
+	Type   string          `json:"type"`
+	Origin string          `json:"origin"` // instance that published it
+	Data   json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals the event data into v.
+func (e *Event) Decode(v interface{}) error {
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Local reports whether the event was published by this instance.
+func (e *Event) Local() bool {
+	return e.Origin == instanceID
+}
+
+// Handler processes one event.
+type Handler func(event *Event) error
+
+var instanceID = func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}()
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = make(map[string][]Handler)
+)
+
+// Subscribe has handler called for every event of the given type, whichever
+// instance published it. It is meant to be called at startup.
+func Subscribe(eventType string, handler Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[eventType] = append(handlers[eventType], handler)
+}
+
+// Publish broadcasts an event to every instance, this one included. Without
+// Redis, or when publishing fails, the event is only handled locally.
+func Publish(eventType string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+	event := &Event{Type: eventType, Origin: instanceID, Data: body}
+
+	if cache.Cache == nil {
+		dispatch(event)
+		return nil
+	}
+
+	message, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+	if err := cache.Cache.Publish(cache.EventBusChannel, message); err != nil {
+		dispatch(event)
+		return err
+	}
+	return nil
+}
+
+// Run delivers the events published by any instance to the local handlers
+// until ctx is done. Every instance that subscribes to events runs it.
+func Run(ctx context.Context) {
+	if cache.Cache == nil {
+		return
+	}
+
+	pubsub := cache.Cache.Subscribe(ctx, cache.EventBusChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				logger.Warnf("Ignoring malformed event: %v", err)
+				continue
+			}
+			dispatch(&event)
+		}
+	}
+}
+
+func dispatch(event *Event) {
+	handlersMu.RLock()
+	eventHandlers := handlers[event.Type]
+	handlersMu.RUnlock()
+
+	for _, handler := range eventHandlers {
+		if err := handler(event); err != nil {
+			logger.Warnf("Failed to handle %s event from %s: %v", event.Type, event.Origin, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package faces
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/command"
+)
+
+// commandDetector pipes frames through a local program, which keeps models
+// such as an ONNX face detector out of the server's process and build.
+// The program reads the JPEG on stdin and writes the detections to stdout.
+type commandDetector struct {
+	command *command.Command
+}
+
+func newCommandDetector(cfg *config.FaceDetectionConfig) (*commandDetector, error) {
+	cmd, err := command.Parse(cfg.Command)
+	if err != nil {
+		return nil, err
+	}
+	return &commandDetector{command: cmd}, nil
+}
+
+func (d *commandDetector) Detect(ctx context.Context, frame []byte) ([]Box, error) {
+	output, stderr, err := d.command.Run(ctx, frame)
+	if err != nil {
+		return nil, fmt.Errorf("face detection command failed: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	return decodeDetections(output)
+}
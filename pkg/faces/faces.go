@@ -0,0 +1,104 @@
+// Package faces detects faces in frames sampled from clips and follows
+// them across frames, so compositions can favor moments with people and
+// crops can keep them in view.
+//
+// Detectors receive one JPEG frame and answer with JSON of the form
+// {"faces": [{"x": 0.4, "y": 0.2, "width": 0.1, "height": 0.15, "score": 0.98}]},
+// where the box's top-left corner and size are fractions of the frame's
+// width and height.
+package faces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/logger"
+)
+
+// Box is a detected face; coordinates are fractions of the frame's size.
+type Box struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Score  float64 `json:"score"`
+}
+
+// Detector finds the faces in a JPEG frame.
+type Detector interface {
+	Detect(ctx context.Context, frame []byte) ([]Box, error)
+}
+
+var (
+	detector Detector
+	minScore float64
+	timeout  time.Duration
+)
+
+// InitFaces sets up the detector configured by FACE_DETECTION_PROVIDER.
+// Clips are analyzed without faces when there is none.
+func InitFaces(cfg *config.Config) error {
+	minScore = cfg.Faces.MinScore
+	timeout = cfg.Faces.Timeout
+
+	switch cfg.Faces.Provider {
+	case "none", "":
+		detector = nil
+		return nil
+	case "http":
+		detector = newHTTPDetector(&cfg.Faces)
+	case "command":
+		cmd, err := newCommandDetector(&cfg.Faces)
+		if err != nil {
+			return fmt.Errorf("invalid FACE_DETECTION_COMMAND: %w", err)
+		}
+		detector = cmd
+	default:
+		return fmt.Errorf("unknown face detection provider %q", cfg.Faces.Provider)
+	}
+
+	logger.Infof("Face detection initialized with the %s detector", cfg.Faces.Provider)
+	return nil
+}
+
+// Enabled reports whether clips are analyzed for faces.
+func Enabled() bool {
+	return detector != nil
+}
+
+// Detect returns the faces in a JPEG frame scoring at least the configured
+// minimum.
+func Detect(frame []byte) ([]Box, error) {
+	if detector == nil {
+		return nil, fmt.Errorf("face detection is not enabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	boxes, err := detector.Detect(ctx, frame)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := boxes[:0]
+	for _, box := range boxes {
+		if box.Score >= minScore && box.Width > 0 && box.Height > 0 {
+			kept = append(kept, box)
+		}
+	}
+	return kept, nil
+}
+
+// decodeDetections reads a detector's answer.
+func decodeDetections(data []byte) ([]Box, error) {
+	var response struct {
+		Faces []Box `json:"faces"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode face detections: %w", err)
+	}
+	return response.Faces, nil
+}
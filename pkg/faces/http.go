@@ -0,0 +1,58 @@
+package faces
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"creative-studio-server/config"
+)
+
+// maxDetectionResponse bounds a detector's answer; a frame holds a few
+// dozen faces at most
+const maxDetectionResponse = 1 << 20
+
+// httpDetector posts frames to an external face detection API.
+type httpDetector struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newHTTPDetector(cfg *config.FaceDetectionConfig) *httpDetector {
+	return &httpDetector{
+		url:    cfg.URL,
+		apiKey: cfg.APIKey,
+		client: &http.Client{},
+	}
+}
+
+func (d *httpDetector) Detect(ctx context.Context, frame []byte) ([]Box, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(frame))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create face detection request: %w", err)
+	}
+	req.Header.Set("Content-Type", "image/jpeg")
+	if d.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+d.apiKey)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach face detection endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("face detection endpoint returned %d: %s", resp.StatusCode, detail)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDetectionResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read face detections: %w", err)
+	}
+	return decodeDetections(data)
+}
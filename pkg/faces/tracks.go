@@ -0,0 +1,115 @@
+package faces
+
+import (
+	"encoding/json"
+
+	"creative-studio-server/models"
+)
+
+const (
+	// Boxes in consecutive samples overlapping at least this much are the
+	// same face
+	trackMinIoU = 0.3
+	// A track ends once its face is missing from this many samples in a row
+	trackMaxGap = 2
+
+	tracksMetadataKey = "face_tracks"
+)
+
+// TrackedBox is a face's box in the frame sampled at Time seconds.
+type TrackedBox struct {
+	Time float64 `json:"t"`
+	Box
+}
+
+// Track follows one face across the sampled frames of a clip.
+type Track struct {
+	ID    int          `json:"id"`
+	Boxes []TrackedBox `json:"boxes"`
+}
+
+// Tracker links the faces detected in consecutive samples into tracks.
+type Tracker struct {
+	tracks []*Track
+	// lastSeen is the sample each track was last seen in
+	lastSeen []int
+	samples  int
+}
+
+// Add links the faces detected in the next sample, taken at t seconds, to
+// the tracks of the samples before.
+func (tr *Tracker) Add(t float64, boxes []Box) {
+	sample := tr.samples
+	tr.samples++
+
+	claimed := make(map[int]bool)
+	for _, box := range boxes {
+		best, bestIoU := -1, trackMinIoU
+		for i, track := range tr.tracks {
+			if claimed[i] || sample-tr.lastSeen[i] > trackMaxGap {
+				continue
+			}
+			if overlap := iou(track.Boxes[len(track.Boxes)-1].Box, box); overlap >= bestIoU {
+				best, bestIoU = i, overlap
+			}
+		}
+
+		if best < 0 {
+			tr.tracks = append(tr.tracks, &Track{ID: len(tr.tracks) + 1})
+			tr.lastSeen = append(tr.lastSeen, sample)
+			best = len(tr.tracks) - 1
+		}
+		claimed[best] = true
+		tr.tracks[best].Boxes = append(tr.tracks[best].Boxes, TrackedBox{Time: t, Box: box})
+		tr.lastSeen[best] = sample
+	}
+}
+
+// Tracks returns the tracks found so far, in order of appearance.
+func (tr *Tracker) Tracks() []Track {
+	tracks := make([]Track, len(tr.tracks))
+	for i, track := range tr.tracks {
+		tracks[i] = *track
+	}
+	return tracks
+}
+
+// iou is the intersection over union of two boxes.
+func iou(a, b Box) float64 {
+	width := min(a.X+a.Width, b.X+b.Width) - max(a.X, b.X)
+	height := min(a.Y+a.Height, b.Y+b.Height) - max(a.Y, b.Y)
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+	intersection := width * height
+	return intersection / (a.Width*a.Height + b.Width*b.Height - intersection)
+}
+
+// TracksFromMetadata loads the face tracks previously stored on a clip.
+func TracksFromMetadata(metadata models.JSON) []Track {
+	raw, ok := metadata[tracksMetadataKey]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+
+	var tracks []Track
+	if err := json.Unmarshal(data, &tracks); err != nil {
+		return nil
+	}
+	return tracks
+}
+
+// StoreTracks attaches face tracks to clip metadata for smart crops and
+// composition scoring.
+func StoreTracks(metadata models.JSON, tracks []Track) models.JSON {
+	if metadata == nil {
+		metadata = make(models.JSON)
+	}
+	metadata[tracksMetadataKey] = tracks
+	return metadata
+}
@@ -0,0 +1,76 @@
+package interchange
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// edlRecordStart is where the record timecode starts, one hour in as is
+// customary for programme masters
+const edlRecordStart = 3600
+
+// exportEDL writes a CMX 3600 EDL. An EDL holds one video track of cuts, so
+// clips overlapping others are listed as notes, and so are transitions.
+func exportEDL(timeline *Timeline) []byte {
+	// Timecode counts whole frames; 29.97 uses a 30 frame non-drop count
+	base := int64(math.Round(timeline.FrameRate))
+	if base < 1 {
+		base = 1
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "TITLE: %s\n", edlText(timeline.Name))
+	b.WriteString("FCM: NON-DROP FRAME\n\n")
+
+	tracks := lanes(timeline.Clips)
+	recordOffset := int64(edlRecordStart) * base
+	if len(tracks) > 0 {
+		for i, clip := range tracks[0] {
+			sourceIn := frames(clip.In, float64(base))
+			sourceOut := sourceIn + frames(clip.duration(), float64(base))
+			recordIn := recordOffset + frames(clip.Position, float64(base))
+			recordOut := recordIn + (sourceOut - sourceIn)
+
+			fmt.Fprintf(&b, "%03d  AX       V     C        %s %s %s %s\n", i+1,
+				timecode(sourceIn, base), timecode(sourceOut, base), timecode(recordIn, base), timecode(recordOut, base))
+			fmt.Fprintf(&b, "* FROM CLIP NAME: %s\n", edlText(clip.Name))
+			if clip.MediaURL != "" {
+				fmt.Fprintf(&b, "* SOURCE FILE: %s\n", edlText(clip.MediaURL))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	for lane, track := range tracks {
+		if lane == 0 {
+			continue
+		}
+		for _, clip := range track {
+			fmt.Fprintf(&b, "* NOT INCLUDED: %s ON V%d AT %s\n", edlText(clip.Name), lane+1,
+				timecode(recordOffset+frames(clip.Position, float64(base)), base))
+		}
+	}
+	for _, transition := range timeline.Transitions {
+		fmt.Fprintf(&b, "* TRANSITION: %s OF %d FRAMES AT %s\n", edlText(strings.ToUpper(transition.Type)),
+			frames(transition.Duration, float64(base)), timecode(recordOffset+frames(transition.Position, float64(base)), base))
+	}
+
+	return b.Bytes()
+}
+
+// timecode formats a frame count as HH:MM:SS:FF at the whole frame rate.
+func timecode(count, base int64) string {
+	if count < 0 {
+		count = 0
+	}
+	ff := count % base
+	seconds := count / base
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60, ff)
+}
+
+// edlText keeps a value on one line of the EDL.
+func edlText(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
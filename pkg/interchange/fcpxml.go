@@ -0,0 +1,268 @@
+package interchange
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+)
+
+const fcpxmlVersion = "1.9"
+
+type fcpDocument struct {
+	XMLName   xml.Name     `xml:"fcpxml"`
+	Version   string       `xml:"version,attr"`
+	Resources fcpResources `xml:"resources"`
+	Library   fcpLibrary   `xml:"library"`
+}
+
+type fcpResources struct {
+	Format fcpFormat  `xml:"format"`
+	Assets []fcpAsset `xml:"asset"`
+}
+
+type fcpFormat struct {
+	ID            string `xml:"id,attr"`
+	FrameDuration string `xml:"frameDuration,attr"`
+	Width         int    `xml:"width,attr,omitempty"`
+	Height        int    `xml:"height,attr,omitempty"`
+}
+
+type fcpAsset struct {
+	ID       string      `xml:"id,attr"`
+	Name     string      `xml:"name,attr"`
+	Start    string      `xml:"start,attr"`
+	Duration string      `xml:"duration,attr"`
+	HasVideo string      `xml:"hasVideo,attr"`
+	HasAudio string      `xml:"hasAudio,attr"`
+	Format   string      `xml:"format,attr"`
+	MediaRep fcpMediaRep `xml:"media-rep"`
+}
+
+type fcpMediaRep struct {
+	Kind string `xml:"kind,attr"`
+	Src  string `xml:"src,attr"`
+}
+
+type fcpLibrary struct {
+	Event fcpEvent `xml:"event"`
+}
+
+type fcpEvent struct {
+	Name    string     `xml:"name,attr"`
+	Project fcpProject `xml:"project"`
+}
+
+type fcpProject struct {
+	Name     string      `xml:"name,attr"`
+	Sequence fcpSequence `xml:"sequence"`
+}
+
+type fcpSequence struct {
+	Format   string   `xml:"format,attr"`
+	Duration string   `xml:"duration,attr"`
+	TCStart  string   `xml:"tcStart,attr"`
+	TCFormat string   `xml:"tcFormat,attr"`
+	Spine    fcpSpine `xml:"spine"`
+}
+
+type fcpSpine struct {
+	Items []interface{}
+}
+
+// fcpClip is a clip in the primary storyline, or connected to an item of
+// it on a lane above
+type fcpClip struct {
+	XMLName   xml.Name   `xml:"asset-clip"`
+	Ref       string     `xml:"ref,attr"`
+	Lane      int        `xml:"lane,attr,omitempty"`
+	Offset    string     `xml:"offset,attr"`
+	Name      string     `xml:"name,attr"`
+	Start     string     `xml:"start,attr"`
+	Duration  string     `xml:"duration,attr"`
+	Connected []*fcpClip `xml:"asset-clip"`
+}
+
+type fcpGap struct {
+	XMLName   xml.Name   `xml:"gap"`
+	Name      string     `xml:"name,attr"`
+	Offset    string     `xml:"offset,attr"`
+	Start     string     `xml:"start,attr"`
+	Duration  string     `xml:"duration,attr"`
+	Connected []*fcpClip `xml:"asset-clip"`
+}
+
+type fcpTransition struct {
+	XMLName  xml.Name `xml:"transition"`
+	Name     string   `xml:"name,attr"`
+	Offset   string   `xml:"offset,attr"`
+	Duration string   `xml:"duration,attr"`
+}
+
+// fcpClock writes times as whole frames, which FCPXML requires, in the
+// rational seconds of the frame duration.
+type fcpClock struct {
+	rate     float64
+	num, den int64
+}
+
+func newFCPClock(rate float64) fcpClock {
+	// Fractional NTSC rates such as 29.97 have frames of 1001/30000s
+	if whole := math.Round(rate); math.Abs(rate-whole) < 0.01 {
+		return fcpClock{rate: rate, num: 100, den: int64(whole) * 100}
+	}
+	return fcpClock{rate: rate, num: 1001, den: int64(math.Round(rate*1.001)) * 1000}
+}
+
+func (c fcpClock) frames(seconds float64) int64 {
+	return frames(seconds, c.rate)
+}
+
+func (c fcpClock) time(count int64) string {
+	if count == 0 {
+		return "0s"
+	}
+	return fmt.Sprintf("%d/%ds", count*c.num, c.den)
+}
+
+// spineItem is an item of the primary storyline with its span in frames,
+// so clips on upper lanes can be connected to it
+type spineItem struct {
+	offset, start, duration int64
+	connect                 func(*fcpClip)
+}
+
+// exportFCPXML writes an FCPXML project. Overlapping clips are connected
+// on lanes above the primary storyline; transitions go on its cuts.
+func exportFCPXML(timeline *Timeline) ([]byte, error) {
+	clock := newFCPClock(timeline.FrameRate)
+	doc := fcpDocument{
+		Version: fcpxmlVersion,
+		Resources: fcpResources{
+			Format: fcpFormat{
+				ID:            "r1",
+				FrameDuration: fmt.Sprintf("%d/%ds", clock.num, clock.den),
+				Width:         timeline.Width,
+				Height:        timeline.Height,
+			},
+		},
+	}
+
+	assets := make(map[uint]string)
+	for _, clip := range timeline.Clips {
+		if _, ok := assets[clip.ID]; ok {
+			continue
+		}
+		id := fmt.Sprintf("r%d", len(assets)+2)
+		assets[clip.ID] = id
+		duration := clip.SourceDuration
+		if duration < clip.Out {
+			duration = clip.Out
+		}
+		doc.Resources.Assets = append(doc.Resources.Assets, fcpAsset{
+			ID:       id,
+			Name:     clip.Name,
+			Start:    "0s",
+			Duration: clock.time(clock.frames(duration)),
+			HasVideo: "1",
+			HasAudio: "1",
+			Format:   "r1",
+			MediaRep: fcpMediaRep{Kind: "original-media", Src: clip.MediaURL},
+		})
+	}
+
+	newClip := func(clip Clip) *fcpClip {
+		return &fcpClip{
+			Ref:      assets[clip.ID],
+			Name:     clip.Name,
+			Start:    clock.time(clock.frames(clip.In)),
+			Duration: clock.time(clock.frames(clip.duration())),
+		}
+	}
+
+	var end int64
+	for _, clip := range timeline.Clips {
+		if e := clock.frames(clip.end()); e > end {
+			end = e
+		}
+	}
+
+	tracks := lanes(timeline.Clips)
+	var primary []Clip
+	if len(tracks) > 0 {
+		primary = tracks[0]
+	}
+	transitionsAt := make(map[int]Transition)
+	for _, transition := range timeline.Transitions {
+		if i := nearestCut(primary, transition); i >= 0 {
+			transitionsAt[i] = transition
+		}
+	}
+
+	spine := &doc.Library.Event.Project.Sequence.Spine
+	var items []spineItem
+	addGap := func(from, to int64) {
+		gap := &fcpGap{Name: "Gap", Offset: clock.time(from), Start: "0s", Duration: clock.time(to - from)}
+		spine.Items = append(spine.Items, gap)
+		items = append(items, spineItem{offset: from, duration: to - from, connect: func(c *fcpClip) {
+			gap.Connected = append(gap.Connected, c)
+		}})
+	}
+
+	var cursor int64
+	for i, clip := range primary {
+		offset := clock.frames(clip.Position)
+		if offset > cursor {
+			addGap(cursor, offset)
+		}
+		if transition, ok := transitionsAt[i]; ok {
+			duration := clock.frames(transition.Duration)
+			spine.Items = append(spine.Items, &fcpTransition{
+				Name:     "Cross Dissolve",
+				Offset:   clock.time(offset - duration/2),
+				Duration: clock.time(duration),
+			})
+		}
+
+		element := newClip(clip)
+		element.Offset = clock.time(offset)
+		spine.Items = append(spine.Items, element)
+		start := clock.frames(clip.In)
+		duration := clock.frames(clip.duration())
+		items = append(items, spineItem{offset: offset, start: start, duration: duration, connect: func(c *fcpClip) {
+			element.Connected = append(element.Connected, c)
+		}})
+		cursor = offset + duration
+	}
+	if cursor < end {
+		addGap(cursor, end)
+	}
+
+	for lane := 1; lane < len(tracks); lane++ {
+		for _, clip := range tracks[lane] {
+			position := clock.frames(clip.Position)
+			for _, item := range items {
+				if position >= item.offset && position < item.offset+item.duration {
+					connected := newClip(clip)
+					connected.Lane = lane
+					// Connected clips are placed in their parent's source time
+					connected.Offset = clock.time(item.start + position - item.offset)
+					item.connect(connected)
+					break
+				}
+			}
+		}
+	}
+
+	doc.Library.Event.Name = timeline.Name
+	doc.Library.Event.Project.Name = timeline.Name
+	doc.Library.Event.Project.Sequence.Format = "r1"
+	doc.Library.Event.Project.Sequence.Duration = clock.time(end)
+	doc.Library.Event.Project.Sequence.TCStart = "0s"
+	doc.Library.Event.Project.Sequence.TCFormat = "NDF"
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode FCPXML: %w", err)
+	}
+	return append([]byte(xml.Header+"<!DOCTYPE fcpxml>\n"), append(data, '\n')...), nil
+}
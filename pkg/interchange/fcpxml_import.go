@@ -0,0 +1,298 @@
+package interchange
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// xmlNode is an element of a document read without a fixed schema, since
+// FCPXML nests its clips in many ways across versions.
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xmlNode  `xml:",any"`
+}
+
+func (n *xmlNode) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func (n *xmlNode) child(name string) *xmlNode {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == name {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+// find returns the first element with the name, depth first.
+func (n *xmlNode) find(name string) *xmlNode {
+	if n.XMLName.Local == name {
+		return n
+	}
+	for i := range n.Children {
+		if found := n.Children[i].find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// fcpTime reads an FCPXML time attribute, such as "1001/30000s" or "5s".
+// A missing attribute is zero.
+func fcpTime(node *xmlNode, name string) (float64, error) {
+	value := node.attr(name)
+	if value == "" {
+		return 0, nil
+	}
+	seconds, err := parseRational(strings.TrimSuffix(value, "s"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q on %s", name, value, node.XMLName.Local)
+	}
+	return seconds, nil
+}
+
+type fcpSource struct {
+	name     string
+	src      string
+	start    float64
+	duration float64
+	hasVideo bool
+}
+
+type fcpImporter struct {
+	assets   map[string]fcpSource
+	timeline *Timeline
+}
+
+// importFCPXML reads the first project of an FCPXML document. Clips
+// connected on lanes and secondary storylines are placed where they play;
+// compound, multicam and synchronized clips are kept without media, so
+// they are reported rather than relinked.
+func importFCPXML(data []byte) (*Timeline, error) {
+	var root xmlNode
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid FCPXML: %w", err)
+	}
+	if root.XMLName.Local != "fcpxml" {
+		return nil, errors.New("invalid FCPXML: missing fcpxml element")
+	}
+
+	im := &fcpImporter{assets: make(map[string]fcpSource), timeline: &Timeline{}}
+	formats := make(map[string]*xmlNode)
+	if resources := root.child("resources"); resources != nil {
+		for i := range resources.Children {
+			resource := &resources.Children[i]
+			switch resource.XMLName.Local {
+			case "format":
+				formats[resource.attr("id")] = resource
+			case "asset":
+				source, err := fcpAssetSource(resource)
+				if err != nil {
+					return nil, err
+				}
+				im.assets[resource.attr("id")] = source
+			}
+		}
+	}
+
+	sequence := root.find("sequence")
+	if sequence == nil {
+		return nil, errors.New("invalid FCPXML: no sequence")
+	}
+	if project := root.find("project"); project != nil {
+		im.timeline.Name = project.attr("name")
+	}
+	if format, ok := formats[sequence.attr("format")]; ok {
+		if frameDuration, err := fcpTime(format, "frameDuration"); err == nil && frameDuration > 0 {
+			im.timeline.FrameRate = 1 / frameDuration
+		}
+		im.timeline.Width, _ = strconv.Atoi(format.attr("width"))
+		im.timeline.Height, _ = strconv.Atoi(format.attr("height"))
+	}
+
+	spine := sequence.child("spine")
+	if spine == nil {
+		return nil, errors.New("invalid FCPXML: sequence has no spine")
+	}
+	tcStart, err := fcpTime(sequence, "tcStart")
+	if err != nil {
+		return nil, err
+	}
+	// Items of the primary storyline are placed in sequence time
+	if err := im.storyline(spine, func(offset float64) float64 {
+		return offset - tcStart
+	}); err != nil {
+		return nil, err
+	}
+	return im.timeline, nil
+}
+
+func fcpAssetSource(asset *xmlNode) (fcpSource, error) {
+	source := fcpSource{
+		name:     asset.attr("name"),
+		src:      asset.attr("src"),
+		hasVideo: asset.attr("hasVideo") != "0",
+	}
+	// FCPXML 1.9 moved the file into a media-rep
+	if rep := asset.child("media-rep"); rep != nil && rep.attr("src") != "" {
+		source.src = rep.attr("src")
+	}
+	var err error
+	if source.start, err = fcpTime(asset, "start"); err != nil {
+		return source, err
+	}
+	if source.duration, err = fcpTime(asset, "duration"); err != nil {
+		return source, err
+	}
+	return source, nil
+}
+
+// storyline reads the items of a spine, placing them with toTimeline,
+// which maps an item offset to timeline seconds.
+func (im *fcpImporter) storyline(spine *xmlNode, toTimeline func(offset float64) float64) error {
+	for i := range spine.Children {
+		item := &spine.Children[i]
+		offset, err := fcpTime(item, "offset")
+		if err != nil {
+			return err
+		}
+		position := toTimeline(offset)
+		duration, err := fcpTime(item, "duration")
+		if err != nil {
+			return err
+		}
+
+		if item.XMLName.Local == "transition" {
+			im.timeline.Transitions = append(im.timeline.Transitions, Transition{
+				Type:     importedTransition,
+				Position: position,
+				Duration: duration,
+			})
+			continue
+		}
+		if err := im.item(item, position, duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// item reads a storyline item placed at the position, then the clips
+// connected to it.
+func (im *fcpImporter) item(item *xmlNode, position, duration float64) error {
+	start, err := fcpTime(item, "start")
+	if err != nil {
+		return err
+	}
+
+	switch item.XMLName.Local {
+	case "asset-clip", "video":
+		source, ok := im.assets[item.attr("ref")]
+		if !ok {
+			// Generators and titles reference effects, not media
+			break
+		}
+		if !source.hasVideo {
+			break
+		}
+		in := 0.0
+		if item.attr("start") != "" {
+			in = start - source.start
+		} else {
+			// Local time starts with the media
+			start = source.start
+		}
+		im.addClip(source, item.attr("name"), position, in, duration)
+	case "clip":
+		// A clip wraps its media in its own local time
+		for i := range item.Children {
+			media := &item.Children[i]
+			if media.XMLName.Local != "video" || media.attr("lane") != "" {
+				continue
+			}
+			source, ok := im.assets[media.attr("ref")]
+			if !ok {
+				continue
+			}
+			mediaOffset, err := fcpTime(media, "offset")
+			if err != nil {
+				return err
+			}
+			mediaStart, err := fcpTime(media, "start")
+			if err != nil {
+				return err
+			}
+			in := mediaStart + start - mediaOffset - source.start
+			im.addClip(source, item.attr("name"), position, in, duration)
+			break
+		}
+	case "ref-clip", "mc-clip", "sync-clip":
+		im.timeline.Clips = append(im.timeline.Clips, Clip{
+			Name:     item.attr("name"),
+			Position: position,
+			Out:      duration,
+		})
+	}
+
+	// Connected items are offset in the local time of the item they hang
+	// off, which starts at its start
+	for i := range item.Children {
+		connected := &item.Children[i]
+		if connected.attr("lane") == "" {
+			continue
+		}
+		offset, err := fcpTime(connected, "offset")
+		if err != nil {
+			return err
+		}
+		at := position + offset - start
+		if connected.XMLName.Local == "spine" {
+			spineStart, err := fcpTime(connected, "start")
+			if err != nil {
+				return err
+			}
+			if err := im.storyline(connected, func(o float64) float64 {
+				return at + o - spineStart
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		connectedDuration, err := fcpTime(connected, "duration")
+		if err != nil {
+			return err
+		}
+		if err := im.item(connected, at, connectedDuration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *fcpImporter) addClip(source fcpSource, name string, position, in, duration float64) {
+	if source.name != "" {
+		name = source.name
+	}
+	if in < 0 {
+		in = 0
+	}
+	im.timeline.Clips = append(im.timeline.Clips, Clip{
+		Name:           name,
+		MediaURL:       source.src,
+		SourceDuration: source.duration,
+		Position:       position,
+		In:             in,
+		Out:            in + duration,
+	})
+}
@@ -0,0 +1,75 @@
+package interchange
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ImportFormats are the formats Import reads. EDLs carry reel names rather
+// than media, so they cannot be relinked and are export only.
+var ImportFormats = []string{FormatFCPXML, FormatOTIO}
+
+// importedTransition is the transition type given to the dissolves editors
+// write, whatever they call them
+const importedTransition = "dissolve"
+
+// Import reads a timeline written by an editing application. Clips keep
+// the media reference and name they have in the file; ID is only set when
+// the file was exported from here. Clips on every video track are read,
+// so they may overlap.
+func Import(format string, data []byte) (*Timeline, error) {
+	var (
+		timeline *Timeline
+		err      error
+	)
+	switch format {
+	case FormatFCPXML:
+		timeline, err = importFCPXML(data)
+	case FormatOTIO:
+		timeline, err = importOTIO(data)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(timeline.Clips) == 0 {
+		return nil, errors.New("timeline has no clips")
+	}
+	return timeline, nil
+}
+
+// FormatOf returns the import format of a file name, or an empty string
+// when its extension is not one.
+func FormatOf(filename string) string {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".fcpxml"), strings.HasSuffix(name, ".xml"):
+		return FormatFCPXML
+	case strings.HasSuffix(name, ".otio"), strings.HasSuffix(name, ".json"):
+		return FormatOTIO
+	}
+	return ""
+}
+
+// parseRational reads a number that may be written as a fraction, such as
+// the 1001/30000 of FCPXML times.
+func parseRational(value string) (float64, error) {
+	if num, den, ok := strings.Cut(value, "/"); ok {
+		n, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return 0, err
+		}
+		d, err := strconv.ParseFloat(den, 64)
+		if err != nil {
+			return 0, err
+		}
+		if d == 0 {
+			return 0, errors.New("zero denominator")
+		}
+		return n / d, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
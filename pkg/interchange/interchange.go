@@ -0,0 +1,140 @@
+// Package interchange writes timelines in the formats editing applications
+// import, so a generated rough cut can be finished in Premiere, Resolve or
+// Final Cut: CMX 3600 EDL, FCPXML and OpenTimelineIO. FCPXML and
+// OpenTimelineIO edits can be read back.
+package interchange
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Export formats
+const (
+	FormatEDL    = "edl"
+	FormatFCPXML = "fcpxml"
+	FormatOTIO   = "otio"
+)
+
+// Timeline is a single-sequence edit. Times are in seconds.
+type Timeline struct {
+	Name        string
+	FrameRate   float64
+	Width       int
+	Height      int
+	Clips       []Clip
+	Transitions []Transition
+}
+
+// Clip is a cut of a source file placed on the timeline.
+type Clip struct {
+	ID   uint
+	Name string
+	// MediaURL locates the source file; editors relink it when it does not
+	// resolve on their machine
+	MediaURL       string
+	SourceDuration float64
+	Position       float64 // start on the timeline
+	In             float64 // in point inside the source
+	Out            float64 // out point inside the source
+}
+
+func (c Clip) duration() float64 {
+	return c.Out - c.In
+}
+
+func (c Clip) end() float64 {
+	return c.Position + c.duration()
+}
+
+// Transition blends the clips either side of the cut it spans.
+type Transition struct {
+	Type     string
+	Position float64
+	Duration float64
+}
+
+// timeEpsilon absorbs rounding when comparing timeline times
+const timeEpsilon = 0.001
+
+// Export writes the timeline in the format.
+func Export(format string, timeline *Timeline) ([]byte, error) {
+	if timeline.FrameRate <= 0 {
+		return nil, fmt.Errorf("invalid frame rate %v", timeline.FrameRate)
+	}
+
+	switch format {
+	case FormatEDL:
+		return exportEDL(timeline), nil
+	case FormatFCPXML:
+		return exportFCPXML(timeline)
+	case FormatOTIO:
+		return exportOTIO(timeline)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// ContentType returns the media type of an export format.
+func ContentType(format string) string {
+	switch format {
+	case FormatFCPXML:
+		return "application/xml"
+	case FormatOTIO:
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// Extension returns the file extension of an export format.
+func Extension(format string) string {
+	return "." + format
+}
+
+// lanes spreads the clips over as few tracks as possible so that clips on
+// one track never overlap, placing each clip on the lowest free track.
+// Every track is in timeline order.
+func lanes(clips []Clip) [][]Clip {
+	sorted := append([]Clip(nil), clips...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Position < sorted[j].Position
+	})
+
+	var tracks [][]Clip
+	for _, clip := range sorted {
+		placed := false
+		for i, track := range tracks {
+			if track[len(track)-1].end() <= clip.Position+timeEpsilon {
+				tracks[i] = append(track, clip)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			tracks = append(tracks, []Clip{clip})
+		}
+	}
+	return tracks
+}
+
+// nearestCut returns the index of the clip on the track that starts at the
+// cut closest to the middle of the transition, or -1 when no cut between
+// two adjacent clips falls within the transition.
+func nearestCut(track []Clip, transition Transition) int {
+	middle := transition.Position + transition.Duration/2
+	best, bestDistance := -1, transition.Duration/2+timeEpsilon
+	for i := 1; i < len(track); i++ {
+		if track[i].Position-track[i-1].end() > timeEpsilon {
+			continue
+		}
+		if distance := math.Abs(track[i].Position - middle); distance <= bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
+
+// frames converts seconds to whole frames at the rate.
+func frames(seconds, rate float64) int64 {
+	return int64(math.Round(seconds * rate))
+}
@@ -0,0 +1,125 @@
+package interchange
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// OpenTimelineIO documents are JSON objects tagged with their schema.
+type otioObject map[string]interface{}
+
+func otioTime(count int64, rate float64) otioObject {
+	return otioObject{
+		"OTIO_SCHEMA": "RationalTime.1",
+		"rate":        rate,
+		"value":       float64(count),
+	}
+}
+
+func otioRange(start, duration int64, rate float64) otioObject {
+	return otioObject{
+		"OTIO_SCHEMA": "TimeRange.1",
+		"start_time":  otioTime(start, rate),
+		"duration":    otioTime(duration, rate),
+	}
+}
+
+// exportOTIO writes an OpenTimelineIO timeline with one video track per
+// lane of overlapping clips. Transitions go on the cuts of the first track.
+func exportOTIO(timeline *Timeline) ([]byte, error) {
+	rate := timeline.FrameRate
+	tracks := lanes(timeline.Clips)
+
+	children := make([]interface{}, 0, len(tracks))
+	for lane, track := range tracks {
+		transitionsAt := make(map[int]Transition)
+		if lane == 0 {
+			for _, transition := range timeline.Transitions {
+				if i := nearestCut(track, transition); i >= 0 {
+					transitionsAt[i] = transition
+				}
+			}
+		}
+
+		items := make([]interface{}, 0, len(track))
+		var cursor int64
+		for i, clip := range track {
+			offset := frames(clip.Position, rate)
+			if offset > cursor {
+				items = append(items, otioObject{
+					"OTIO_SCHEMA":  "Gap.1",
+					"name":         "",
+					"source_range": otioRange(0, offset-cursor, rate),
+					"effects":      []interface{}{},
+					"markers":      []interface{}{},
+					"metadata":     otioObject{},
+				})
+			}
+			if transition, ok := transitionsAt[i]; ok {
+				half := frames(transition.Duration, rate) / 2
+				items = append(items, otioObject{
+					"OTIO_SCHEMA":     "Transition.1",
+					"name":            transition.Type,
+					"transition_type": "SMPTE_Dissolve",
+					"in_offset":       otioTime(half, rate),
+					"out_offset":      otioTime(half, rate),
+					"metadata":        otioObject{},
+				})
+			}
+
+			duration := frames(clip.duration(), rate)
+			reference := otioObject{
+				"OTIO_SCHEMA": "ExternalReference.1",
+				"name":        clip.Name,
+				"target_url":  clip.MediaURL,
+				"metadata":    otioObject{},
+			}
+			if clip.SourceDuration > 0 {
+				reference["available_range"] = otioRange(0, frames(clip.SourceDuration, rate), rate)
+			}
+			items = append(items, otioObject{
+				"OTIO_SCHEMA":     "Clip.1",
+				"name":            clip.Name,
+				"source_range":    otioRange(frames(clip.In, rate), duration, rate),
+				"media_reference": reference,
+				"effects":         []interface{}{},
+				"markers":         []interface{}{},
+				"metadata": otioObject{
+					"creative_studio": otioObject{"clip_id": clip.ID},
+				},
+			})
+			cursor = offset + duration
+		}
+
+		children = append(children, otioObject{
+			"OTIO_SCHEMA": "Track.1",
+			"name":        fmt.Sprintf("V%d", lane+1),
+			"kind":        "Video",
+			"children":    items,
+			"effects":     []interface{}{},
+			"markers":     []interface{}{},
+			"metadata":    otioObject{},
+		})
+	}
+
+	doc := otioObject{
+		"OTIO_SCHEMA":       "Timeline.1",
+		"name":              timeline.Name,
+		"global_start_time": otioTime(0, rate),
+		"metadata":          otioObject{},
+		"tracks": otioObject{
+			"OTIO_SCHEMA": "Stack.1",
+			"name":        "tracks",
+			"children":    children,
+			"effects":     []interface{}{},
+			"markers":     []interface{}{},
+			"metadata":    otioObject{},
+		},
+	}
+
+	data, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenTimelineIO: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,158 @@
+package interchange
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func (o otioObject) schema() string {
+	schema, _ := o["OTIO_SCHEMA"].(string)
+	return schema
+}
+
+// is reports whether the object has the schema, in any version.
+func (o otioObject) is(schema string) bool {
+	return strings.HasPrefix(o.schema(), schema+".")
+}
+
+func (o otioObject) object(key string) otioObject {
+	object, _ := o[key].(map[string]interface{})
+	return object
+}
+
+func (o otioObject) string(key string) string {
+	value, _ := o[key].(string)
+	return value
+}
+
+func (o otioObject) children() []otioObject {
+	items, _ := o["children"].([]interface{})
+	children := make([]otioObject, 0, len(items))
+	for _, item := range items {
+		if child, ok := item.(map[string]interface{}); ok {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// seconds reads a RationalTime, reporting false when it is missing.
+func (o otioObject) seconds() (float64, float64, bool) {
+	value, ok := o["value"].(float64)
+	rate, _ := o["rate"].(float64)
+	if !ok || rate <= 0 {
+		return 0, 0, false
+	}
+	return value / rate, rate, true
+}
+
+// timeRange reads a TimeRange as its start and duration in seconds.
+func (o otioObject) timeRange() (start, duration float64, ok bool) {
+	if o == nil {
+		return 0, 0, false
+	}
+	start, _, _ = o.object("start_time").seconds()
+	duration, _, ok = o.object("duration").seconds()
+	return start, duration, ok
+}
+
+// importOTIO reads the video tracks of an OpenTimelineIO timeline. Nested
+// stacks and tracks keep their place but their clips are not read.
+func importOTIO(data []byte) (*Timeline, error) {
+	var doc otioObject
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenTimelineIO: %w", err)
+	}
+	if !doc.is("Timeline") {
+		return nil, errors.New("invalid OpenTimelineIO: not a timeline")
+	}
+
+	timeline := &Timeline{Name: doc.string("name")}
+	if _, rate, ok := doc.object("global_start_time").seconds(); ok {
+		timeline.FrameRate = rate
+	}
+
+	for _, track := range doc.object("tracks").children() {
+		if !track.is("Track") {
+			continue
+		}
+		if kind := track.string("kind"); kind != "" && kind != "Video" {
+			continue
+		}
+
+		var cursor float64
+		for _, item := range track.children() {
+			switch {
+			case item.is("Transition"):
+				in, _, _ := item.object("in_offset").seconds()
+				out, _, _ := item.object("out_offset").seconds()
+				timeline.Transitions = append(timeline.Transitions, Transition{
+					Type:     transitionType(item.string("name")),
+					Position: cursor - in,
+					Duration: in + out,
+				})
+			case item.is("Clip"):
+				clip, err := otioClip(item)
+				if err != nil {
+					return nil, err
+				}
+				clip.Position = cursor
+				timeline.Clips = append(timeline.Clips, clip)
+				cursor += clip.duration()
+				if timeline.FrameRate == 0 {
+					_, timeline.FrameRate, _ = item.object("source_range").object("duration").seconds()
+				}
+			default:
+				// Gaps and nested compositions only take up time
+				if _, duration, ok := item.object("source_range").timeRange(); ok {
+					cursor += duration
+				}
+			}
+		}
+	}
+	return timeline, nil
+}
+
+func otioClip(item otioObject) (Clip, error) {
+	reference := item.object("media_reference")
+	clip := Clip{
+		Name:     item.string("name"),
+		MediaURL: reference.string("target_url"),
+	}
+	if id, ok := item.object("metadata").object("creative_studio")["clip_id"].(float64); ok && id > 0 {
+		clip.ID = uint(id)
+	}
+
+	available, availableDuration, hasAvailable := reference.object("available_range").timeRange()
+	if hasAvailable {
+		clip.SourceDuration = availableDuration
+	}
+	start, duration, ok := item.object("source_range").timeRange()
+	if !ok {
+		// A clip without a source range plays all of its media
+		if !hasAvailable {
+			return clip, fmt.Errorf("invalid OpenTimelineIO: clip %q has no range", clip.Name)
+		}
+		start, duration = available, availableDuration
+	}
+
+	// Ranges are in the media's time, which may not start at zero
+	clip.In = start - available
+	if clip.In < 0 {
+		clip.In = 0
+	}
+	clip.Out = clip.In + duration
+	return clip, nil
+}
+
+// transitionType keeps the transition names written on export and calls
+// everything else a dissolve.
+func transitionType(name string) string {
+	switch name {
+	case "fade", "dissolve", "slide", "wipe":
+		return name
+	}
+	return importedTransition
+}
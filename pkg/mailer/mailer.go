@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/logger"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers emails.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+var sender Sender = &logSender{}
+
+// InitMailer selects the sender configured by MAIL_PROVIDER.
+func InitMailer(cfg *config.Config) error {
+	switch cfg.Mail.Provider {
+	case "smtp":
+		sender = newSMTPSender(&cfg.Mail)
+	case "ses":
+		if cfg.Mail.SESAccessKeyID == "" || cfg.Mail.SESSecretAccessKey == "" {
+			return fmt.Errorf("MAIL_PROVIDER=ses requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		sender = newSESSender(&cfg.Mail)
+	default:
+		sender = &logSender{}
+	}
+
+	logger.Infof("Mailer initialized with provider %s", cfg.Mail.Provider)
+	return nil
+}
+
+// Send delivers the email with the configured sender.
+func Send(ctx context.Context, msg *Message) error {
+	return sender.Send(ctx, msg)
+}
+
+// logSender only logs emails, for development.
+type logSender struct{}
+
+func (s *logSender) Send(ctx context.Context, msg *Message) error {
+	logger.Infof("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
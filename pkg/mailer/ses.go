@@ -0,0 +1,119 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"creative-studio-server/config"
+)
+
+const sesPath = "/v2/email/outbound-emails"
+
+// sesSender sends through the Amazon SES v2 API, signing requests with
+// AWS Signature Version 4.
+type sesSender struct {
+	region          string
+	host            string
+	accessKeyID     string
+	secretAccessKey string
+	from            string
+	client          *http.Client
+}
+
+func newSESSender(cfg *config.MailConfig) *sesSender {
+	return &sesSender{
+		region:          cfg.SESRegion,
+		host:            fmt.Sprintf("email.%s.amazonaws.com", cfg.SESRegion),
+		accessKeyID:     cfg.SESAccessKeyID,
+		secretAccessKey: cfg.SESSecretAccessKey,
+		from:            cfg.From,
+		client:          &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+func (s *sesSender) Send(ctx context.Context, msg *Message) error {
+	payload := map[string]interface{}{
+		"FromEmailAddress": s.from,
+		"Destination": map[string]interface{}{
+			"ToAddresses": []string{msg.To},
+		},
+		"Content": map[string]interface{}{
+			"Simple": map[string]interface{}{
+				"Subject": sesContent{Data: msg.Subject, Charset: "UTF-8"},
+				"Body": map[string]interface{}{
+					"Text": sesContent{Data: msg.Body, Charset: "UTF-8"},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode SES request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+s.host+sesPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create SES request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email via SES: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("SES returned %d: %s", resp.StatusCode, detail)
+	}
+	return nil
+}
+
+// sign adds the Signature Version 4 headers to the request.
+func (s *sesSender) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "content-type;host;x-amz-date"
+	canonicalRequest := fmt.Sprintf("%s\n%s\n\ncontent-type:%s\nhost:%s\nx-amz-date:%s\n\n%s\n%s",
+		req.Method, sesPath, req.Header.Get("Content-Type"), s.host, amzDate, signedHeaders, sha256Hex(body))
+
+	scope := fmt.Sprintf("%s/%s/ses/aws4_request", date, s.region)
+	stringToSign := fmt.Sprintf("AWS4-HMAC-SHA256\n%s\n%s\n%s", amzDate, scope, sha256Hex([]byte(canonicalRequest)))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "ses")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
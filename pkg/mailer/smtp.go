@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"creative-studio-server/config"
+)
+
+// smtpSender sends through an SMTP server, upgrading to TLS when the
+// server offers STARTTLS.
+type smtpSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func newSMTPSender(cfg *config.MailConfig) *smtpSender {
+	s := &smtpSender{
+		addr: net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		from: cfg.From,
+	}
+	if cfg.SMTPUsername != "" {
+		s.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return s
+}
+
+func (s *smtpSender) Send(ctx context.Context, msg *Message) error {
+	from, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return fmt.Errorf("invalid MAIL_FROM: %w", err)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", from.String())
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	if err := smtp.SendMail(s.addr, s.auth, from.Address, []string{msg.To}, []byte(body.String())); err != nil {
+		return fmt.Errorf("failed to send email via SMTP: %w", err)
+	}
+	return nil
+}
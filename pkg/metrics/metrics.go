@@ -0,0 +1,64 @@
+// Package metrics holds the Prometheus collectors exposed on /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "creative_studio"
+
+// Task queue health, labelled by queue name
+var (
+	QueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "depth",
+		Help:      "Tasks waiting in the queue (consumer group lag on Kafka).",
+	}, []string{"queue"})
+
+	QueueConsumers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "consumers",
+		Help:      "Consumers attached to the queue, where the broker reports it.",
+	}, []string{"queue"})
+
+	TasksPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "published_total",
+		Help:      "Tasks published, by queue and result (ok or error).",
+	}, []string{"queue", "result"})
+
+	TaskDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "deliveries_total",
+		Help:      "Task deliveries handled by workers, by outcome: succeeded, retried, dead_lettered or skipped.",
+	}, []string{"queue", "outcome"})
+
+	TaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "handler_duration_seconds",
+		Help:      "Time spent in task handlers, by queue and status (succeeded or failed).",
+		// Tasks range from thumbnails (seconds) to full renders (tens of minutes)
+		Buckets: []float64{0.5, 1, 5, 15, 30, 60, 120, 300, 600, 1200, 3600},
+	}, []string{"queue", "status"})
+
+	TasksInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "inflight_tasks",
+		Help:      "Tasks currently being handled by this process.",
+	}, []string{"queue"})
+)
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,16 @@
+package moderation
+
+import (
+	"context"
+
+	"creative-studio-server/pkg/ai"
+)
+
+// aiClassifier scores frames with the AI provider's moderation model.
+type aiClassifier struct {
+	provider ai.AnalysisProvider
+}
+
+func (c *aiClassifier) Classify(ctx context.Context, frame []byte) (map[string]float64, error) {
+	return c.provider.Moderate(ctx, frame)
+}
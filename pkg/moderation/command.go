@@ -0,0 +1,33 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/command"
+)
+
+// commandClassifier pipes frames through a local program, such as a
+// wrapper around an open NSFW detection model.
+// The program reads the JPEG on stdin and writes the scores to stdout.
+type commandClassifier struct {
+	command *command.Command
+}
+
+func newCommandClassifier(cfg *config.ModerationConfig) (*commandClassifier, error) {
+	cmd, err := command.Parse(cfg.Command)
+	if err != nil {
+		return nil, err
+	}
+	return &commandClassifier{command: cmd}, nil
+}
+
+func (c *commandClassifier) Classify(ctx context.Context, frame []byte) (map[string]float64, error) {
+	output, stderr, err := c.command.Run(ctx, frame)
+	if err != nil {
+		return nil, fmt.Errorf("moderation command failed: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	return decodeScores(output)
+}
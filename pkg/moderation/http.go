@@ -0,0 +1,59 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"creative-studio-server/config"
+)
+
+// maxScoresResponse bounds a classifier's answer; it scores a handful of
+// categories
+const maxScoresResponse = 64 << 10
+
+// httpClassifier posts frames to an external moderation API, such as an
+// adapter in front of a cloud content safety service.
+type httpClassifier struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newHTTPClassifier(cfg *config.ModerationConfig) *httpClassifier {
+	return &httpClassifier{
+		url:    cfg.URL,
+		apiKey: cfg.APIKey,
+		client: &http.Client{},
+	}
+}
+
+func (c *httpClassifier) Classify(ctx context.Context, frame []byte) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(frame))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "image/jpeg")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach moderation endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("moderation endpoint returned %d: %s", resp.StatusCode, detail)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxScoresResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation scores: %w", err)
+	}
+	return decodeScores(data)
+}
@@ -0,0 +1,127 @@
+// Package moderation scores frames sampled from uploaded clips for unsafe
+// content, so flagged clips are kept out of public view and compositions
+// until a reviewer has seen them.
+//
+// Classifiers receive one JPEG frame and answer with JSON of the form
+// {"categories": {"nudity": 0.93, "violence": 0.02}}, scoring each unsafe
+// category from 0 to 1. Safe categories, such as NSFW models' "neutral",
+// are left out.
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/ai"
+	"creative-studio-server/pkg/logger"
+)
+
+// Classifier scores a JPEG frame per unsafe category.
+type Classifier interface {
+	Classify(ctx context.Context, frame []byte) (map[string]float64, error)
+}
+
+// Verdict is a clip's moderation result: its highest score in any
+// category and the categories at or above the threshold, highest first.
+type Verdict struct {
+	Score   float64
+	Labels  []string
+	Flagged bool
+}
+
+var (
+	classifier Classifier
+	threshold  float64
+	timeout    time.Duration
+)
+
+// InitModeration sets up the classifier configured by MODERATION_PROVIDER.
+// Clips are not moderated when there is none.
+func InitModeration(cfg *config.Config) error {
+	threshold = cfg.Moderation.Threshold
+	timeout = cfg.Moderation.Timeout
+
+	switch cfg.Moderation.Provider {
+	case "none", "":
+		classifier = nil
+		return nil
+	case "http":
+		classifier = newHTTPClassifier(&cfg.Moderation)
+	case "command":
+		cmd, err := newCommandClassifier(&cfg.Moderation)
+		if err != nil {
+			return fmt.Errorf("invalid MODERATION_COMMAND: %w", err)
+		}
+		classifier = cmd
+	case "ai":
+		provider, err := ai.Provider()
+		if err != nil {
+			return err
+		}
+		classifier = &aiClassifier{provider: provider}
+	default:
+		return fmt.Errorf("unknown moderation provider %q", cfg.Moderation.Provider)
+	}
+
+	logger.Infof("Moderation initialized with the %s classifier", cfg.Moderation.Provider)
+	return nil
+}
+
+// Enabled reports whether uploads are moderated.
+func Enabled() bool {
+	return classifier != nil
+}
+
+// Classify returns the scores of a JPEG frame per unsafe category.
+func Classify(frame []byte) (map[string]float64, error) {
+	if classifier == nil {
+		return nil, fmt.Errorf("moderation is not enabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return classifier.Classify(ctx, frame)
+}
+
+// Judge combines the scores of a clip's sampled frames. A category scores
+// its highest score in any frame, as one unsafe frame is enough to flag a
+// clip.
+func Judge(frames []map[string]float64) Verdict {
+	scores := make(map[string]float64)
+	for _, categories := range frames {
+		for category, score := range categories {
+			scores[category] = max(scores[category], score)
+		}
+	}
+
+	var verdict Verdict
+	for category, score := range scores {
+		verdict.Score = max(verdict.Score, score)
+		if score >= threshold {
+			verdict.Labels = append(verdict.Labels, category)
+		}
+	}
+	sort.Slice(verdict.Labels, func(i, j int) bool {
+		if scores[verdict.Labels[i]] != scores[verdict.Labels[j]] {
+			return scores[verdict.Labels[i]] > scores[verdict.Labels[j]]
+		}
+		return verdict.Labels[i] < verdict.Labels[j]
+	})
+	verdict.Flagged = len(verdict.Labels) > 0
+	return verdict
+}
+
+// decodeScores reads a classifier's answer.
+func decodeScores(data []byte) (map[string]float64, error) {
+	var response struct {
+		Categories map[string]float64 `json:"categories"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation scores: %w", err)
+	}
+	return response.Categories, nil
+}
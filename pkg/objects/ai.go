@@ -0,0 +1,27 @@
+package objects
+
+import (
+	"context"
+
+	"creative-studio-server/pkg/ai"
+)
+
+// aiDetector tags frames with the AI provider's vision model. Vision
+// models name what a frame shows without locating it, so detections have
+// no box.
+type aiDetector struct {
+	provider ai.AnalysisProvider
+}
+
+func (d *aiDetector) Detect(ctx context.Context, frame []byte) ([]Detection, error) {
+	tags, err := d.provider.Tag(ctx, frame)
+	if err != nil {
+		return nil, err
+	}
+
+	detections := make([]Detection, len(tags))
+	for i, tag := range tags {
+		detections[i] = Detection{Label: tag.Label, Score: tag.Score}
+	}
+	return detections, nil
+}
@@ -0,0 +1,164 @@
+// Package objects detects the objects in frames sampled from clips and
+// turns them into labels clips are tagged, searched and composed by.
+//
+// Detectors receive one JPEG frame and answer with JSON of the form
+// {"objects": [{"label": "dog", "score": 0.91, "x": 0.2, "y": 0.3, "width": 0.3, "height": 0.4}]},
+// where the box is in fractions of the frame's size, as YOLO-style models
+// report them.
+package objects
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/ai"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// Objects seen in less than this share of the sampled frames are
+	// passers-by rather than what the clip shows
+	minFrameShare = 0.1
+	maxLabels     = 10
+)
+
+// Detection is an object found in a frame.
+type Detection struct {
+	Label  string  `json:"label"`
+	Score  float64 `json:"score"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Detector finds the objects in a JPEG frame.
+type Detector interface {
+	Detect(ctx context.Context, frame []byte) ([]Detection, error)
+}
+
+var (
+	detector Detector
+	minScore float64
+	timeout  time.Duration
+)
+
+// InitObjects sets up the detector configured by OBJECT_DETECTION_PROVIDER.
+// Clips are not tagged with objects when there is none.
+func InitObjects(cfg *config.Config) error {
+	minScore = cfg.Objects.MinScore
+	timeout = cfg.Objects.Timeout
+
+	switch cfg.Objects.Provider {
+	case "none", "":
+		detector = nil
+		return nil
+	case "http":
+		detector = newHTTPDetector(&cfg.Objects)
+	case "command":
+		cmd, err := newCommandDetector(&cfg.Objects)
+		if err != nil {
+			return fmt.Errorf("invalid OBJECT_DETECTION_COMMAND: %w", err)
+		}
+		detector = cmd
+	case "ai":
+		provider, err := ai.Provider()
+		if err != nil {
+			return err
+		}
+		detector = &aiDetector{provider: provider}
+	default:
+		return fmt.Errorf("unknown object detection provider %q", cfg.Objects.Provider)
+	}
+
+	logger.Infof("Object detection initialized with the %s detector", cfg.Objects.Provider)
+	return nil
+}
+
+// Enabled reports whether clips are analyzed for objects.
+func Enabled() bool {
+	return detector != nil
+}
+
+// Detect returns the objects in a JPEG frame scoring at least the
+// configured minimum, with their labels normalized.
+func Detect(frame []byte) ([]Detection, error) {
+	if detector == nil {
+		return nil, fmt.Errorf("object detection is not enabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	detections, err := detector.Detect(ctx, frame)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := detections[:0]
+	for _, detection := range detections {
+		detection.Label = normalizeLabel(detection.Label)
+		if detection.Score >= minScore && detection.Label != "" {
+			kept = append(kept, detection)
+		}
+	}
+	return kept, nil
+}
+
+// Labels names the objects a clip shows from the detections in each of its
+// sampled frames, those in the most frames first.
+func Labels(frames [][]Detection) []string {
+	if len(frames) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, detections := range frames {
+		seen := make(map[string]bool)
+		for _, detection := range detections {
+			if !seen[detection.Label] {
+				seen[detection.Label] = true
+				counts[detection.Label]++
+			}
+		}
+	}
+
+	labels := make([]string, 0, len(counts))
+	for label, count := range counts {
+		if float64(count)/float64(len(frames)) >= minFrameShare {
+			labels = append(labels, label)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if counts[labels[i]] != counts[labels[j]] {
+			return counts[labels[i]] > counts[labels[j]]
+		}
+		return labels[i] < labels[j]
+	})
+	if len(labels) > maxLabels {
+		labels = labels[:maxLabels]
+	}
+	return labels
+}
+
+// normalizeLabel gives a label the form of a clip tag, so "Traffic Light"
+// and COCO's "traffic_light" match the tag "traffic light".
+func normalizeLabel(label string) string {
+	return models.NormalizeTag(strings.ReplaceAll(label, "_", " "))
+}
+
+// decodeDetections reads a detector's answer.
+func decodeDetections(data []byte) ([]Detection, error) {
+	var response struct {
+		Objects []Detection `json:"objects"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode object detections: %w", err)
+	}
+	return response.Objects, nil
+}
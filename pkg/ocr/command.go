@@ -0,0 +1,33 @@
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/command"
+)
+
+// commandRecognizer pipes frames through a local program, such as a
+// wrapper around Tesseract or PaddleOCR.
+// The program reads the JPEG on stdin and writes the lines to stdout.
+type commandRecognizer struct {
+	command *command.Command
+}
+
+func newCommandRecognizer(cfg *config.OCRConfig) (*commandRecognizer, error) {
+	cmd, err := command.Parse(cfg.Command)
+	if err != nil {
+		return nil, err
+	}
+	return &commandRecognizer{command: cmd}, nil
+}
+
+func (r *commandRecognizer) Recognize(ctx context.Context, frame []byte) ([]Line, error) {
+	output, stderr, err := r.command.Run(ctx, frame)
+	if err != nil {
+		return nil, fmt.Errorf("OCR command failed: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	return decodeLines(output)
+}
@@ -0,0 +1,59 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"creative-studio-server/config"
+)
+
+// maxOCRResponse bounds a recognizer's answer; a frame holds a few dozen
+// lines at most
+const maxOCRResponse = 1 << 20
+
+// httpRecognizer posts frames to an external OCR API, such as an adapter
+// in front of a cloud vision service.
+type httpRecognizer struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newHTTPRecognizer(cfg *config.OCRConfig) *httpRecognizer {
+	return &httpRecognizer{
+		url:    cfg.URL,
+		apiKey: cfg.APIKey,
+		client: &http.Client{},
+	}
+}
+
+func (r *httpRecognizer) Recognize(ctx context.Context, frame []byte) ([]Line, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(frame))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCR request: %w", err)
+	}
+	req.Header.Set("Content-Type", "image/jpeg")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach OCR endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("OCR endpoint returned %d: %s", resp.StatusCode, detail)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOCRResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCR lines: %w", err)
+	}
+	return decodeLines(data)
+}
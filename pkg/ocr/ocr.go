@@ -0,0 +1,110 @@
+// Package ocr reads the text burned into frames sampled from clips, such
+// as titles, lower thirds and subtitles, so such clips can be kept out of
+// compositions that add their own.
+//
+// Recognizers receive one JPEG frame and answer with JSON of the form
+// {"lines": [{"text": "BREAKING NEWS", "score": 0.94, "x": 0.1, "y": 0.8, "width": 0.5, "height": 0.08}]},
+// where the box is in fractions of the frame's size.
+package ocr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/logger"
+)
+
+// Shorter lines are mostly recognizers reading texture as letters
+const minLineLength = 2
+
+// Line is a line of text read in a frame.
+type Line struct {
+	Text   string  `json:"text"`
+	Score  float64 `json:"score"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Recognizer reads the text in a JPEG frame.
+type Recognizer interface {
+	Recognize(ctx context.Context, frame []byte) ([]Line, error)
+}
+
+var (
+	recognizer Recognizer
+	minScore   float64
+	timeout    time.Duration
+)
+
+// InitOCR sets up the recognizer configured by OCR_PROVIDER. Clips are not
+// read for on-screen text when there is none.
+func InitOCR(cfg *config.Config) error {
+	minScore = cfg.OCR.MinScore
+	timeout = cfg.OCR.Timeout
+
+	switch cfg.OCR.Provider {
+	case "none", "":
+		recognizer = nil
+		return nil
+	case "http":
+		recognizer = newHTTPRecognizer(&cfg.OCR)
+	case "command":
+		cmd, err := newCommandRecognizer(&cfg.OCR)
+		if err != nil {
+			return fmt.Errorf("invalid OCR_COMMAND: %w", err)
+		}
+		recognizer = cmd
+	default:
+		return fmt.Errorf("unknown OCR provider %q", cfg.OCR.Provider)
+	}
+
+	logger.Infof("OCR initialized with the %s recognizer", cfg.OCR.Provider)
+	return nil
+}
+
+// Enabled reports whether clips are analyzed for on-screen text.
+func Enabled() bool {
+	return recognizer != nil
+}
+
+// Recognize returns the lines of text in a JPEG frame scoring at least the
+// configured minimum, with their whitespace collapsed.
+func Recognize(frame []byte) ([]Line, error) {
+	if recognizer == nil {
+		return nil, fmt.Errorf("OCR is not enabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	lines, err := recognizer.Recognize(ctx, frame)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := lines[:0]
+	for _, line := range lines {
+		line.Text = strings.Join(strings.Fields(line.Text), " ")
+		if line.Score >= minScore && utf8.RuneCountInString(line.Text) >= minLineLength {
+			kept = append(kept, line)
+		}
+	}
+	return kept, nil
+}
+
+// decodeLines reads a recognizer's answer.
+func decodeLines(data []byte) ([]Line, error) {
+	var response struct {
+		Lines []Line `json:"lines"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode OCR lines: %w", err)
+	}
+	return response.Lines, nil
+}
@@ -0,0 +1,96 @@
+package ocr
+
+import (
+	"strings"
+
+	"creative-studio-server/models"
+)
+
+const (
+	// A text ends once it is missing from this many samples in a row, so a
+	// line misread in one frame is not split in two
+	timelineMaxGap = 2
+
+	textMetadataKey = "on_screen_text"
+)
+
+// Occurrence is a text shown on screen from Start to End seconds.
+type Occurrence struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Timeline collects the lines read in consecutive samples, taken every
+// interval seconds, into occurrences of each text.
+type Timeline struct {
+	interval    float64
+	occurrences []*Occurrence
+	// open maps a text's key to its occurrence and the sample it was last
+	// read in
+	open    map[string]openOccurrence
+	samples int
+}
+
+type openOccurrence struct {
+	occurrence *Occurrence
+	lastSeen   int
+}
+
+// NewTimeline starts a timeline of samples taken every interval seconds.
+func NewTimeline(interval float64) *Timeline {
+	return &Timeline{interval: interval, open: make(map[string]openOccurrence)}
+}
+
+// Add records the lines read in the next sample, taken at t seconds.
+func (tl *Timeline) Add(t float64, lines []Line) {
+	sample := tl.samples
+	tl.samples++
+
+	for _, line := range lines {
+		// Recognizers vary in case between frames of the same title
+		key := strings.ToLower(line.Text)
+		if open, ok := tl.open[key]; ok && sample-open.lastSeen <= timelineMaxGap {
+			open.occurrence.End = t + tl.interval
+			tl.open[key] = openOccurrence{occurrence: open.occurrence, lastSeen: sample}
+			continue
+		}
+
+		occurrence := &Occurrence{Start: t, End: t + tl.interval, Text: line.Text}
+		tl.occurrences = append(tl.occurrences, occurrence)
+		tl.open[key] = openOccurrence{occurrence: occurrence, lastSeen: sample}
+	}
+}
+
+// Occurrences returns the texts read so far, in order of appearance.
+func (tl *Timeline) Occurrences() []Occurrence {
+	occurrences := make([]Occurrence, len(tl.occurrences))
+	for i, occurrence := range tl.occurrences {
+		occurrences[i] = *occurrence
+	}
+	return occurrences
+}
+
+// Content joins the distinct texts of the occurrences, one per line, for
+// a clip's TextContent.
+func Content(occurrences []Occurrence) string {
+	seen := make(map[string]bool)
+	var texts []string
+	for _, occurrence := range occurrences {
+		key := strings.ToLower(occurrence.Text)
+		if !seen[key] {
+			seen[key] = true
+			texts = append(texts, occurrence.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// StoreOccurrences attaches the timed on-screen text to clip metadata.
+func StoreOccurrences(metadata models.JSON, occurrences []Occurrence) models.JSON {
+	if metadata == nil {
+		metadata = make(models.JSON)
+	}
+	metadata[textMetadataKey] = occurrences
+	return metadata
+}
@@ -0,0 +1,317 @@
+package queue
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/duplicates"
+	"creative-studio-server/pkg/faces"
+	"creative-studio-server/pkg/objects"
+	"creative-studio-server/pkg/ocr"
+	"creative-studio-server/pkg/realtime"
+	"creative-studio-server/pkg/suggestions"
+	"creative-studio-server/pkg/video_engine"
+)
+
+// Thresholds of the descriptive tags given to analyzed clips
+const (
+	brightTagLevel       = 0.65
+	darkTagLevel         = 0.3
+	vividTagLevel        = 0.45
+	mutedTagLevel        = 0.15
+	highContrastTagLevel = 0.6
+
+	// Each sampled frame is a call to every enabled detector, so long clips
+	// are sampled more sparsely than once a second
+	maxDetectionSamples = 60
+)
+
+// analyzeClip measures what the analysis type covers and returns the
+// VideoAnalysis columns to store. Content analysis runs the face and object
+// detectors and the text recognizer when they are configured, and labels
+// the shot type from the size of the detected subjects; it also hashes the
+// picture to find near-duplicates by and picks the best thumbnail frame,
+// favoring sharp, well-exposed frames with faces. Face tracks, the face
+// highlight signal and the timed on-screen text go to the clip's metadata,
+// detected objects to its Objects.
+func analyzeClip(processor *video_engine.FFmpegProcessor, clip *models.AtomicClip, analysisType string) (map[string]interface{}, error) {
+	full := analysisType == models.AnalysisTypeFull
+	updates := map[string]interface{}{}
+
+	var picture *video_engine.PictureStats
+	if full || analysisType == models.AnalysisTypeColor || analysisType == models.AnalysisTypeMotion {
+		stats, err := processor.AnalyzePicture(clip.FilePath, clip.Duration)
+		if err != nil {
+			return nil, err
+		}
+		picture = stats
+	}
+
+	var colors []string
+	if full || analysisType == models.AnalysisTypeColor {
+		updates["avg_brightness"] = picture.Brightness
+		updates["avg_contrast"] = picture.Contrast
+		updates["avg_saturation"] = picture.Saturation
+
+		var err error
+		if colors, err = processor.AnalyzeDominantColors(clip.FilePath, clip.Duration); err != nil {
+			return nil, err
+		}
+		updates["dominant_colors"] = models.StringArray(colors)
+	}
+
+	if full || analysisType == models.AnalysisTypeMotion {
+		updates["motion_intensity"] = picture.MotionIntensity
+	}
+
+	if full || analysisType == models.AnalysisTypeAudio {
+		info, err := processor.GetVideoInfo(clip.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to probe audio: %w", err)
+		}
+		updates["has_audio"] = info.HasAudio
+		updates["audio_level"] = 0.0
+		if info.HasAudio {
+			level, err := processor.AnalyzeAudioLevel(clip.FilePath)
+			if err != nil {
+				return nil, err
+			}
+			updates["audio_level"] = level
+		}
+	}
+
+	if full || analysisType == models.AnalysisTypeContent {
+		hash, err := processor.PerceptualHash(clip.FilePath, clip.Duration)
+		if err != nil {
+			return nil, err
+		}
+		updates["perceptual_hash"] = hash
+	}
+
+	var labels []string
+	var detected *frameDetections
+	detectFaces := faces.Enabled() && (full || analysisType == models.AnalysisTypeContent)
+	detectObjects := objects.Enabled() && (full || analysisType == models.AnalysisTypeContent)
+	readText := ocr.Enabled() && (full || analysisType == models.AnalysisTypeContent)
+	if detectFaces || detectObjects || readText {
+		var err error
+		if detected, err = detectInFrames(processor, clip, detectFaces, detectObjects, readText); err != nil {
+			return nil, err
+		}
+		if detectFaces || detectObjects {
+			updates["shot_type"] = video_engine.ClassifyShot(detected.subjects)
+		}
+
+		if detectFaces {
+			faceCount := 0
+			for _, count := range detected.faceCounts {
+				faceCount = max(faceCount, int(count))
+			}
+			updates["has_faces"] = faceCount > 0
+			// The most faces seen at once, not the people across the clip
+			updates["face_count"] = faceCount
+
+			clip.Metadata = faces.StoreTracks(clip.Metadata, detected.faceTracks)
+			clip.Metadata = video_engine.StoreFaceSignal(clip.Metadata, detected.faceCounts, detected.interval, clip.Duration)
+		}
+		if detectObjects {
+			labels = objects.Labels(detected.objects)
+			clip.Objects = models.StringArray(labels)
+			if !full {
+				updates["ai_tags"] = models.StringArray(labels)
+			}
+		}
+		if readText {
+			updates["has_text"] = len(detected.text) > 0
+			updates["text_content"] = ocr.Content(detected.text)
+			clip.Metadata = ocr.StoreOccurrences(clip.Metadata, detected.text)
+		}
+	}
+
+	if full || analysisType == models.AnalysisTypeContent {
+		candidates, err := processor.SampleThumbnailCandidates(clip.FilePath, clip.Duration)
+		if err != nil {
+			return nil, err
+		}
+		var faceCounts []float64
+		faceInterval := 0.0
+		if detectFaces {
+			faceCounts, faceInterval = detected.faceCounts, detected.interval
+		}
+		if offset, ok := video_engine.PickThumbnail(candidates, clip.Duration, faceCounts, faceInterval); ok {
+			updates["thumbnail_time"] = offset
+		}
+	}
+
+	if full {
+		updates["ai_tags"] = append(pictureTags(picture, colors), labels...)
+	}
+
+	return updates, nil
+}
+
+// frameDetections holds what the detectors and the text recognizer found in
+// frames sampled every interval seconds.
+type frameDetections struct {
+	interval   float64
+	faceTracks []faces.Track
+	faceCounts []float64
+	objects    [][]objects.Detection
+	text       []ocr.Occurrence
+	subjects   []video_engine.ShotSubject
+}
+
+// detectInFrames runs the enabled detectors over frames sampled across a
+// clip, sampling them once for all of them.
+func detectInFrames(processor *video_engine.FFmpegProcessor, clip *models.AtomicClip, detectFaces, detectObjects, readText bool) (*frameDetections, error) {
+	detected := &frameDetections{interval: math.Max(1, clip.Duration/maxDetectionSamples)}
+
+	dir, err := os.MkdirTemp("", "frames-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create frame directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	frames, err := processor.SampleFrames(clip.FilePath, dir, detected.interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracker faces.Tracker
+	timeline := ocr.NewTimeline(detected.interval)
+	for i, frame := range frames {
+		data, err := os.ReadFile(frame)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sampled frame: %w", err)
+		}
+
+		var subject video_engine.ShotSubject
+		unsized := false
+		if detectFaces {
+			boxes, err := faces.Detect(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to detect faces: %w", err)
+			}
+			detected.faceCounts = append(detected.faceCounts, float64(len(boxes)))
+			tracker.Add(float64(i)*detected.interval, boxes)
+			for _, box := range boxes {
+				subject.FaceHeight = max(subject.FaceHeight, box.Height)
+			}
+		}
+		if detectObjects {
+			found, err := objects.Detect(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to detect objects: %w", err)
+			}
+			detected.objects = append(detected.objects, found)
+			for _, detection := range found {
+				subject.ObjectHeight = max(subject.ObjectHeight, detection.Height)
+			}
+			// Vision models tag objects without locating them, which
+			// leaves the frame's shot unknown
+			unsized = subject.FaceHeight == 0 && subject.ObjectHeight == 0 && len(found) > 0
+		}
+		if !unsized {
+			detected.subjects = append(detected.subjects, subject)
+		}
+		if readText {
+			lines, err := ocr.Recognize(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read on-screen text: %w", err)
+			}
+			timeline.Add(float64(i)*detected.interval, lines)
+		}
+	}
+	detected.faceTracks = tracker.Tracks()
+	detected.text = timeline.Occurrences()
+
+	return detected, nil
+}
+
+// warnNearDuplicates tells the clip's owner, and the import it came from,
+// when its footage is already in the workspace. Failures are logged; the
+// analysis is done either way.
+func warnNearDuplicates(task *Task, clip *models.AtomicClip, hash string) {
+	db := database.GetDB()
+
+	matches, err := duplicates.Find(db, clip, hash)
+	if err != nil {
+		task.Logger().Warnf("Failed to look for duplicates of clip %d: %v", clip.ID, err)
+		return
+	}
+	if len(matches) == 0 {
+		return
+	}
+
+	duplicateIDs := make([]uint, len(matches))
+	for i, match := range matches {
+		duplicateIDs[i] = match.ClipID
+	}
+	if err := db.Model(&models.ClipImportItem{}).
+		Where("atomic_clip_id = ?", clip.ID).
+		Update("duplicate_of_id", duplicateIDs[0]).Error; err != nil {
+		task.Logger().Warnf("Failed to flag the import of clip %d as a duplicate: %v", clip.ID, err)
+	}
+
+	data := map[string]interface{}{
+		"clip_id":       clip.ID,
+		"duplicate_ids": duplicateIDs,
+	}
+	realtime.Publish(clip.UserID, realtime.EventClipDuplicates, data)
+	EmitWebhookEvent(clip.UserID, clip.OrganizationID, models.WebhookEventClipDuplicate, data)
+}
+
+// suggestMetadata writes a description and title ideas for a newly
+// analyzed clip, for its owner to pick from. Failures are logged; the
+// suggestions can still be asked for later.
+func suggestMetadata(task *Task, clip *models.AtomicClip, analysis *models.VideoAnalysis) {
+	db := database.GetDB()
+
+	// Transcription runs on its own queue and may not have finished yet
+	var transcript models.ClipTranscript
+	if err := db.Where("atomic_clip_id = ?", clip.ID).Limit(1).Find(&transcript).Error; err != nil {
+		task.Logger().Warnf("Failed to load the transcript of clip %d: %v", clip.ID, err)
+	}
+
+	clip.VideoAnalysis = analysis
+	suggestion, err := suggestions.Suggest(clip, transcript.Text)
+	if err != nil {
+		task.Logger().Warnf("Failed to suggest metadata for clip %d: %v", clip.ID, err)
+		return
+	}
+	if err := db.Model(analysis).UpdateColumns(map[string]interface{}{
+		"ai_description":   suggestion.Description,
+		"suggested_titles": models.StringArray(suggestion.Titles),
+	}).Error; err != nil {
+		task.Logger().Warnf("Failed to store metadata suggestions for clip %d: %v", clip.ID, err)
+		return
+	}
+	cache.InvalidateClip(clip.ID)
+}
+
+// pictureTags describes a clip's picture in words clips can be searched
+// and grouped by.
+func pictureTags(picture *video_engine.PictureStats, colors []string) models.StringArray {
+	tags := models.StringArray{}
+	switch {
+	case picture.Brightness >= brightTagLevel:
+		tags = append(tags, "bright")
+	case picture.Brightness <= darkTagLevel:
+		tags = append(tags, "dark")
+	}
+	switch {
+	case picture.Saturation >= vividTagLevel:
+		tags = append(tags, "vivid")
+	case picture.Saturation <= mutedTagLevel:
+		tags = append(tags, "muted")
+	}
+	if picture.Contrast >= highContrastTagLevel {
+		tags = append(tags, "high-contrast")
+	}
+	tags = append(tags, picture.MotionIntensity+"-motion")
+	return append(tags, colors...)
+}
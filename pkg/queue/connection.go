@@ -0,0 +1,192 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/streadway/amqp"
+	"creative-studio-server/pkg/logger"
+)
+
+// reconnectInitialDelay is the first wait before reconnecting; it doubles
+// after every failed attempt up to the configured maximum.
+const reconnectInitialDelay = time.Second
+
+var errQueueDisconnected = errors.New("task queue is disconnected")
+
+type consumerRegistration struct {
+	queueName   string
+	tag         string
+	handler     TaskHandler
+	concurrency int
+	prefetch    int
+}
+
+// consumerSeq keeps consumer tags unique when a queue's consumer is
+// replaced after a resize
+var consumerSeq atomic.Int64
+
+func consumerTag(queueName string) string {
+	return fmt.Sprintf("%s/%s/%d", workerID, queueName, consumerSeq.Add(1))
+}
+
+type pendingPublish struct {
+	queueName  string
+	taskID     string
+	publishing amqp.Publishing
+}
+
+// connect dials RabbitMQ, declares the queues and starts watching the new
+// connection for failures.
+func (r *RabbitMQClient) connect() error {
+	conn, err := amqp.Dial(r.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+
+	queues, err := declareQueues(ch)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to declare queues: %w", err)
+	}
+
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	channelClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
+	r.mu.Lock()
+	if r.closing {
+		r.mu.Unlock()
+		conn.Close()
+		return errors.New("task queue is closed")
+	}
+	r.connection = conn
+	r.channel = ch
+	r.queues = queues
+	r.connected = true
+	r.mu.Unlock()
+
+	go r.watch(connClosed, channelClosed)
+	return nil
+}
+
+// watch waits for the connection or its channel to close and, unless the
+// client is shutting down, reconnects.
+func (r *RabbitMQClient) watch(connClosed, channelClosed <-chan *amqp.Error) {
+	var reason *amqp.Error
+	select {
+	case reason = <-connClosed:
+	case reason = <-channelClosed:
+	}
+
+	r.mu.Lock()
+	if r.closing {
+		r.mu.Unlock()
+		return
+	}
+	r.connected = false
+	// A channel-level error leaves the connection open; start from scratch
+	r.connection.Close()
+	r.mu.Unlock()
+
+	logger.Warnf("RabbitMQ connection lost (%v), reconnecting", reason)
+	r.reconnect()
+}
+
+// reconnect retries with exponential backoff until connected, then
+// re-registers the consumers and sends the tasks buffered meanwhile.
+func (r *RabbitMQClient) reconnect() {
+	delay := reconnectInitialDelay
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-r.done:
+			return
+		case <-time.After(delay):
+		}
+
+		err := r.connect()
+		if err == nil {
+			logger.Infof("RabbitMQ reconnected after %d attempt(s)", attempt)
+			break
+		}
+
+		logger.Warnf("RabbitMQ reconnect attempt %d failed: %v", attempt, err)
+		delay *= 2
+		if r.reconnectMaxDelay > 0 && delay > r.reconnectMaxDelay {
+			delay = r.reconnectMaxDelay
+		}
+	}
+
+	r.mu.RLock()
+	consumers := append([]consumerRegistration(nil), r.consumers...)
+	r.mu.RUnlock()
+
+	// Workers that are shutting down don't take new tasks
+	if r.draining() {
+		consumers = nil
+	}
+
+	for _, consumer := range consumers {
+		if err := r.startConsumer(consumer); err != nil {
+			logger.Errorf("Failed to restart workers for queue %s: %v", consumer.queueName, err)
+		}
+	}
+
+	r.flushPending()
+}
+
+// bufferPublish holds a task until the connection is back, or fails fast
+// when the buffer is full.
+func (r *RabbitMQClient) bufferPublish(queueName, taskID string, publishing amqp.Publishing) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The connection may have come back while we were waiting for the lock
+	if r.connected {
+		if err := r.publish(queueName, publishing); err == nil {
+			logger.Infof("Task published to queue %s: %s", queueName, taskID)
+			return nil
+		}
+	}
+
+	if r.closing || len(r.pending) >= r.publishBufferSize {
+		return fmt.Errorf("failed to publish task to queue %s: %w", queueName, errQueueDisconnected)
+	}
+
+	r.pending = append(r.pending, pendingPublish{queueName: queueName, taskID: taskID, publishing: publishing})
+	logger.Warnf("RabbitMQ is disconnected, buffered task %s for queue %s (%d/%d)", taskID, queueName, len(r.pending), r.publishBufferSize)
+	return nil
+}
+
+// flushPending publishes the tasks buffered during an outage, keeping any
+// that fail for the next reconnect.
+func (r *RabbitMQClient) flushPending() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.pending) == 0 {
+		return
+	}
+
+	sent := 0
+	for _, pending := range r.pending {
+		if !r.connected {
+			break
+		}
+		if err := r.publish(pending.queueName, pending.publishing); err != nil {
+			logger.Errorf("Failed to publish buffered task %s: %v", pending.taskID, err)
+			break
+		}
+		sent++
+	}
+
+	r.pending = r.pending[sent:]
+	logger.Infof("Published %d buffered tasks, %d still pending", sent, len(r.pending))
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"errors"
+	"time"
+
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// taskLockTTL bounds how long a crashed worker keeps a task locked; the
+	// lock is refreshed every taskLockRefresh while the handler runs
+	taskLockTTL     = 10 * time.Minute
+	taskLockRefresh = 3 * time.Minute
+	// processedTaskTTL is how long a completed task ID is remembered
+	processedTaskTTL = 24 * time.Hour
+	// duplicateRecheckDelay is how long a delivery of a task another worker
+	// is running waits before checking again
+	duplicateRecheckDelay = time.Minute
+)
+
+type taskClaim int
+
+const (
+	taskClaimed taskClaim = iota
+	taskAlreadyProcessed
+	taskInProgress
+)
+
+// taskRun is a claim on a task in the processed-task registry.
+type taskRun struct {
+	task *Task
+	lock *cache.Lock
+}
+
+// claimTask checks the registry before a task runs. Without Redis, or when
+// Redis fails, the task runs anyway: a duplicate run is better than none.
+func claimTask(task *Task) (*taskRun, taskClaim) {
+	run := &taskRun{task: task}
+	if cache.Cache == nil {
+		return run, taskClaimed
+	}
+
+	if done, err := cache.Cache.Exists(cache.TaskDoneKey(task.ID)); err != nil {
+		logger.Warnf("Failed to check processed state of task %s: %v", task.ID, err)
+		return run, taskClaimed
+	} else if done {
+		return nil, taskAlreadyProcessed
+	}
+
+	lock, err := cache.Cache.Lock(cache.TaskLockKey(task.ID), taskLockTTL)
+	if errors.Is(err, cache.ErrLockNotAcquired) {
+		return nil, taskInProgress
+	}
+	if err != nil {
+		logger.Warnf("Failed to lock task %s: %v", task.ID, err)
+		return run, taskClaimed
+	}
+
+	// Refresh the lock while the handler runs, so long renders are not
+	// taken over by another worker
+	run.lock = lock
+	lock.AutoRenew(taskLockRefresh, func(err error) {
+		logger.Warnf("Lost lock on task %s while running: %v", task.ID, err)
+	})
+	return run, taskClaimed
+}
+
+// finish records a successful run so redeliveries are skipped, and releases
+// the lock so a failed task can be retried.
+func (run *taskRun) finish(succeeded bool) {
+	if run.lock == nil {
+		return
+	}
+
+	if succeeded {
+		if err := cache.Cache.Set(cache.TaskDoneKey(run.task.ID), time.Now().Unix(), processedTaskTTL); err != nil {
+			logger.Warnf("Failed to record task %s as processed: %v", run.task.ID, err)
+		}
+	}
+	if err := run.lock.Unlock(); err != nil && !errors.Is(err, cache.ErrLockLost) {
+		logger.Warnf("Failed to release lock on task %s: %v", run.task.ID, err)
+	}
+}
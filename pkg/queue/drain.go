@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"context"
+	"sync"
+)
+
+// drainer tracks the handlers a backend is running so a shutdown can stop
+// new work and wait for the current work to finish.
+type drainer struct {
+	mu       sync.Mutex
+	stopping chan struct{}
+	stopped  bool
+	inflight sync.WaitGroup
+}
+
+func newDrainer() *drainer {
+	return &drainer{stopping: make(chan struct{})}
+}
+
+// begin reserves a slot for a handler about to run. It returns false once
+// draining has started, in which case the task must be handed back.
+func (d *drainer) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return false
+	}
+	d.inflight.Add(1)
+	return true
+}
+
+func (d *drainer) end() {
+	d.inflight.Done()
+}
+
+// draining reports whether a drain has started.
+func (d *drainer) draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stopped
+}
+
+// stop refuses new handlers from now on.
+func (d *drainer) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.stopped {
+		d.stopped = true
+		close(d.stopping)
+	}
+}
+
+// wait blocks until the running handlers finish or ctx is done.
+func (d *drainer) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,443 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// retryAtHeader carries the time (unix milliseconds) a parked task is due
+	retryAtHeader = "retry-at"
+	// traceIDHeader carries the task's correlation ID for other consumers
+	traceIDHeader = "trace-id"
+)
+
+// KafkaClient carries tasks over Kafka topics named after the queues. Kafka
+// has no message priorities or delayed delivery: tasks are consumed in
+// partition order, and retries wait in per-attempt retry topics that are
+// relayed back once due. Failed tasks end up in dlx.<queue> topics, like
+// the RabbitMQ dead letter routing keys.
+type KafkaClient struct {
+	brokers []string
+	groupID string
+	writer  *kafka.Writer
+	admin   *kafka.Client // offset lookups for Depth
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	// fetchCtx stops reading new messages when draining
+	fetchCtx  context.Context
+	stopFetch context.CancelFunc
+	*drainer
+
+	mu        sync.Mutex
+	readers   []*kafka.Reader
+	relayed   map[string]bool
+	consumers map[string]*kafkaConsumer
+}
+
+// kafkaConsumer is the set of workers of one topic; each has its own reader
+// and is stopped through its cancel function.
+type kafkaConsumer struct {
+	handler TaskHandler
+	workers []context.CancelFunc
+}
+
+// NewKafkaClient connects to Kafka and creates the task topics.
+func NewKafkaClient(cfg *config.Config) (*KafkaClient, error) {
+	kafkaCfg := cfg.Queue.Kafka
+	if len(kafkaCfg.Brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+
+	if err := createKafkaTopics(kafkaCfg.Brokers[0], kafkaCfg.Partitions, kafkaCfg.ReplicationFactor); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fetchCtx, stopFetch := context.WithCancel(ctx)
+	client := &KafkaClient{
+		brokers: kafkaCfg.Brokers,
+		groupID: kafkaCfg.GroupID,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(kafkaCfg.Brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		admin:     &kafka.Client{Addr: kafka.TCP(kafkaCfg.Brokers...)},
+		ctx:       ctx,
+		cancel:    cancel,
+		fetchCtx:  fetchCtx,
+		stopFetch: stopFetch,
+		drainer:   newDrainer(),
+		relayed:   make(map[string]bool),
+		consumers: make(map[string]*kafkaConsumer),
+	}
+
+	logger.Info("Kafka connected successfully")
+	return client, nil
+}
+
+// createKafkaTopics provisions the task, retry and dead letter topics.
+// Creating a topic that already exists is a no-op.
+func createKafkaTopics(broker string, partitions, replicationFactor int) error {
+	conn, err := kafka.Dial("tcp", broker)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	defer conn.Close()
+
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to find Kafka controller: %w", err)
+	}
+	controllerConn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to Kafka controller: %w", err)
+	}
+	defer controllerConn.Close()
+
+	var topics []kafka.TopicConfig
+	addTopic := func(name string) {
+		topics = append(topics, kafka.TopicConfig{
+			Topic:             name,
+			NumPartitions:     partitions,
+			ReplicationFactor: replicationFactor,
+		})
+	}
+	for _, name := range taskQueueNames {
+		addTopic(name)
+		addTopic("dlx." + name)
+		for attempt := 1; attempt <= maxRetryLevels; attempt++ {
+			addTopic(retryQueueName(name, attempt))
+		}
+	}
+
+	if err := controllerConn.CreateTopics(topics...); err != nil {
+		return fmt.Errorf("failed to create Kafka topics: %w", err)
+	}
+	return nil
+}
+
+// PublishTask writes the task to the queue's topic, keyed by task ID.
+func (k *KafkaClient) PublishTask(queueName string, task *Task) error {
+	return k.publishTask(queueName, task, nil)
+}
+
+func (k *KafkaClient) publishTask(topic string, task *Task, headers []kafka.Header) error {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	if task.TraceID != "" {
+		headers = append(headers, kafka.Header{Key: traceIDHeader, Value: []byte(task.TraceID)})
+	}
+
+	if err := k.writer.WriteMessages(k.ctx, kafka.Message{
+		Topic:   topic,
+		Key:     []byte(task.ID),
+		Value:   body,
+		Headers: headers,
+	}); err != nil {
+		return fmt.Errorf("failed to publish task to topic %s: %w", topic, err)
+	}
+
+	logger.Infof("Task published to topic %s: %s", topic, task.ID)
+	return nil
+}
+
+// ConsumeTask joins the consumer group on the queue's topic with one reader
+// per worker; parallelism is further bounded by the topic's partitions.
+func (k *KafkaClient) ConsumeTask(queueName string, handler TaskHandler, concurrency int) error {
+	k.mu.Lock()
+	consumer, ok := k.consumers[queueName]
+	if !ok {
+		consumer = &kafkaConsumer{handler: handler}
+		k.consumers[queueName] = consumer
+	}
+	k.resize(queueName, consumer, concurrency)
+	k.mu.Unlock()
+
+	k.startRetryRelays(queueName)
+
+	logger.Infof("Started %d workers for topic %s", concurrency, queueName)
+	return nil
+}
+
+// SetConcurrency starts or stops workers of the topic. A stopped worker
+// finishes its current task and leaves the consumer group. Kafka has no
+// prefetch setting.
+func (k *KafkaClient) SetConcurrency(queueName string, concurrency, prefetch int) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	consumer, ok := k.consumers[queueName]
+	if !ok || k.draining() {
+		return nil
+	}
+	k.resize(queueName, consumer, concurrency)
+
+	logger.Infof("Running %d workers for topic %s", concurrency, queueName)
+	return nil
+}
+
+// resize must be called with k.mu held.
+func (k *KafkaClient) resize(queueName string, consumer *kafkaConsumer, concurrency int) {
+	for len(consumer.workers) < concurrency {
+		ctx, stop := context.WithCancel(k.fetchCtx)
+		consumer.workers = append(consumer.workers, stop)
+		go k.worker(ctx, k.newReaderLocked(queueName), consumer.handler, queueName)
+	}
+	for len(consumer.workers) > max(concurrency, 0) {
+		last := len(consumer.workers) - 1
+		consumer.workers[last]()
+		consumer.workers = consumer.workers[:last]
+	}
+}
+
+func (k *KafkaClient) newReader(topic string) *kafka.Reader {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.newReaderLocked(topic)
+}
+
+func (k *KafkaClient) newReaderLocked(topic string) *kafka.Reader {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: k.brokers,
+		GroupID: k.groupID,
+		Topic:   topic,
+	})
+	k.readers = append(k.readers, reader)
+	return reader
+}
+
+// dropReader closes a reader whose worker stopped, so the group hands its
+// partitions to the remaining workers.
+func (k *KafkaClient) dropReader(reader *kafka.Reader) {
+	k.mu.Lock()
+	for i, r := range k.readers {
+		if r == reader {
+			k.readers = append(k.readers[:i], k.readers[i+1:]...)
+			break
+		}
+	}
+	k.mu.Unlock()
+
+	if err := reader.Close(); err != nil {
+		logger.Warnf("Failed to close Kafka reader: %v", err)
+	}
+}
+
+func (k *KafkaClient) worker(ctx context.Context, reader *kafka.Reader, handler TaskHandler, queueName string) {
+	defer k.dropReader(reader)
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Errorf("Failed to read from topic %s: %v", queueName, err)
+			}
+			return
+		}
+
+		// Leave the offset uncommitted so the group reads it again
+		if !k.begin() {
+			return
+		}
+
+		var task Task
+		if err := json.Unmarshal(msg.Value, &task); err != nil {
+			logger.Errorf("Failed to unmarshal task from topic %s: %v", queueName, err)
+			k.deadLetter(queueName, msg)
+		} else if runTask(k, queueName, &task, handler) == taskDeadLetter {
+			k.deadLetter(queueName, msg)
+		}
+
+		if err := reader.CommitMessages(k.ctx, msg); err != nil && k.ctx.Err() == nil {
+			logger.Errorf("Failed to commit offset on topic %s: %v", queueName, err)
+		}
+		k.end()
+	}
+}
+
+// Drain stops reading new messages and waits for running handlers until ctx
+// is done. Uncommitted messages are read again by the consumer group.
+func (k *KafkaClient) Drain(ctx context.Context) error {
+	k.stop()
+	k.stopFetch()
+	return k.wait(ctx)
+}
+
+// Depth is the consumer group's lag on the topic: messages produced but not
+// yet committed, over all partitions. Kafka does not report consumers here.
+func (k *KafkaClient) Depth(topic string) (int, int, error) {
+	ctx, cancel := context.WithTimeout(k.ctx, 10*time.Second)
+	defer cancel()
+
+	meta, err := k.admin.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return 0, -1, fmt.Errorf("failed to describe topic %s: %w", topic, err)
+	}
+	var partitions []int
+	var requests []kafka.OffsetRequest
+	for _, t := range meta.Topics {
+		if t.Name != topic {
+			continue
+		}
+		for _, p := range t.Partitions {
+			partitions = append(partitions, p.ID)
+			requests = append(requests, kafka.LastOffsetOf(p.ID))
+		}
+	}
+	if len(partitions) == 0 {
+		return 0, -1, fmt.Errorf("topic %s has no partitions", topic)
+	}
+
+	offsets, err := k.admin.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return 0, -1, fmt.Errorf("failed to list offsets of topic %s: %w", topic, err)
+	}
+	committed, err := k.admin.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: k.groupID,
+		Topics:  map[string][]int{topic: partitions},
+	})
+	if err != nil {
+		return 0, -1, fmt.Errorf("failed to fetch committed offsets of topic %s: %w", topic, err)
+	}
+
+	committedOffsets := make(map[int]int64, len(partitions))
+	for _, p := range committed.Topics[topic] {
+		committedOffsets[p.Partition] = p.CommittedOffset
+	}
+
+	var lag int64
+	for _, p := range offsets.Topics[topic] {
+		offset, ok := committedOffsets[p.Partition]
+		if !ok || offset < 0 {
+			// Nothing committed yet: everything still on the partition is pending
+			offset = p.FirstOffset
+		}
+		if p.LastOffset > offset {
+			lag += p.LastOffset - offset
+		}
+	}
+	return int(lag), -1, nil
+}
+
+func (k *KafkaClient) deadLetter(queueName string, msg kafka.Message) {
+	if err := k.writer.WriteMessages(k.ctx, kafka.Message{
+		Topic:   "dlx." + queueName,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: msg.Headers,
+	}); err != nil {
+		logger.Errorf("Failed to dead letter message from topic %s: %v", queueName, err)
+	}
+}
+
+// parkTask writes the task to the retry topic for its attempt, stamped with
+// when it is due back on the queue.
+func (k *KafkaClient) parkTask(queueName string, task *Task, attempt int, delay time.Duration) error {
+	retryAt := strconv.FormatInt(time.Now().Add(delay).UnixMilli(), 10)
+	return k.publishTask(retryQueueName(queueName, max(attempt, 1)), task, []kafka.Header{
+		{Key: retryAtHeader, Value: []byte(retryAt)},
+	})
+}
+
+// startRetryRelays moves parked tasks of a queue back to it once due. Each
+// retry topic holds one attempt level, so messages fall due roughly in
+// order and waiting on the oldest one holds up little.
+func (k *KafkaClient) startRetryRelays(queueName string) {
+	k.mu.Lock()
+	if k.relayed[queueName] {
+		k.mu.Unlock()
+		return
+	}
+	k.relayed[queueName] = true
+	k.mu.Unlock()
+
+	for attempt := 1; attempt <= maxRetryLevels; attempt++ {
+		go k.relay(k.newReader(retryQueueName(queueName, attempt)), queueName)
+	}
+}
+
+func (k *KafkaClient) relay(reader *kafka.Reader, queueName string) {
+	for {
+		msg, err := reader.FetchMessage(k.fetchCtx)
+		if err != nil {
+			if k.fetchCtx.Err() == nil {
+				logger.Errorf("Failed to read retry topic of %s: %v", queueName, err)
+			}
+			return
+		}
+
+		if wait := time.Until(retryAt(msg)); wait > 0 {
+			select {
+			case <-k.fetchCtx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		// Keep the offset uncommitted until the task is back on its queue
+		for {
+			err := k.writer.WriteMessages(k.ctx, kafka.Message{Topic: queueName, Key: msg.Key, Value: msg.Value})
+			if err == nil {
+				break
+			}
+			if k.ctx.Err() != nil {
+				return
+			}
+			logger.Errorf("Failed to return parked task to topic %s: %v", queueName, err)
+			time.Sleep(reconnectInitialDelay)
+		}
+
+		if err := reader.CommitMessages(k.ctx, msg); err != nil && k.ctx.Err() == nil {
+			logger.Errorf("Failed to commit offset on retry topic of %s: %v", queueName, err)
+		}
+	}
+}
+
+func retryAt(msg kafka.Message) time.Time {
+	for _, header := range msg.Headers {
+		if header.Key != retryAtHeader {
+			continue
+		}
+		if ms, err := strconv.ParseInt(string(header.Value), 10, 64); err == nil {
+			return time.UnixMilli(ms)
+		}
+	}
+	return msg.Time
+}
+
+func (k *KafkaClient) Close() error {
+	k.cancel()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var errs []error
+	for _, reader := range k.readers {
+		if err := reader.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := k.writer.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,284 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"creative-studio-server/pkg/logger"
+)
+
+// MemoryQueue runs tasks inside the server process, for local development
+// and tests without a broker. Tasks are lost on restart and are only seen by
+// workers in the same process, so it requires --mode=all.
+type MemoryQueue struct {
+	mu        sync.Mutex
+	queues    map[string]*memoryTaskQueue
+	consumers map[string]*memoryConsumer
+	closed    bool
+	*drainer
+}
+
+type memoryConsumer struct {
+	handler TaskHandler
+	workers int
+}
+
+// NewMemoryQueue returns an empty in-process task queue.
+func NewMemoryQueue() *MemoryQueue {
+	logger.Warn("Using the in-memory task queue: tasks do not survive a restart")
+	return &MemoryQueue{
+		queues:    make(map[string]*memoryTaskQueue),
+		consumers: make(map[string]*memoryConsumer),
+		drainer:   newDrainer(),
+	}
+}
+
+func (m *MemoryQueue) queue(name string) *memoryTaskQueue {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.queueLocked(name)
+}
+
+func (m *MemoryQueue) queueLocked(name string) *memoryTaskQueue {
+	q, ok := m.queues[name]
+	if !ok {
+		q = newMemoryTaskQueue()
+		if m.closed {
+			q.close()
+		}
+		m.queues[name] = q
+	}
+	return q
+}
+
+// PublishTask adds a copy of the task to the queue. The task goes through
+// JSON like it would on a real broker, so handlers see the same payload
+// types (numbers as float64).
+func (m *MemoryQueue) PublishTask(queueName string, task *Task) error {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+	var copied Task
+	if err := json.Unmarshal(body, &copied); err != nil {
+		return fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+
+	if !m.queue(queueName).push(&copied) {
+		return fmt.Errorf("task queue is closed")
+	}
+
+	logger.Infof("Task published to in-memory queue %s: %s", queueName, task.ID)
+	return nil
+}
+
+func (m *MemoryQueue) ConsumeTask(queueName string, handler TaskHandler, concurrency int) error {
+	m.mu.Lock()
+	consumer, ok := m.consumers[queueName]
+	if !ok {
+		consumer = &memoryConsumer{handler: handler}
+		m.consumers[queueName] = consumer
+	}
+	m.resize(queueName, consumer, concurrency)
+	m.mu.Unlock()
+
+	logger.Infof("Started %d workers for in-memory queue %s", concurrency, queueName)
+	return nil
+}
+
+// SetConcurrency starts or retires workers of the queue; a retired worker
+// exits once it finishes its current task.
+func (m *MemoryQueue) SetConcurrency(queueName string, concurrency, prefetch int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	consumer, ok := m.consumers[queueName]
+	if !ok || m.closed {
+		return nil
+	}
+	m.resize(queueName, consumer, concurrency)
+
+	logger.Infof("Running %d workers for in-memory queue %s", concurrency, queueName)
+	return nil
+}
+
+// resize must be called with m.mu held.
+func (m *MemoryQueue) resize(queueName string, consumer *memoryConsumer, concurrency int) {
+	q := m.queueLocked(queueName)
+	for ; consumer.workers < concurrency; consumer.workers++ {
+		go m.worker(q, queueName, consumer.handler)
+	}
+	if extra := consumer.workers - max(concurrency, 0); extra > 0 {
+		q.retire(extra)
+		consumer.workers -= extra
+	}
+}
+
+func (m *MemoryQueue) worker(q *memoryTaskQueue, queueName string, handler TaskHandler) {
+	for {
+		task, ok := q.pop()
+		if !ok {
+			return
+		}
+		if !m.begin() {
+			q.push(task)
+			return
+		}
+		if runTask(m, queueName, task, handler) == taskDeadLetter {
+			logger.Errorf("Task %s from in-memory queue %s dropped after failing", task.ID, queueName)
+		}
+		m.end()
+	}
+}
+
+// parkTask puts the task back on its queue once delay has passed.
+func (m *MemoryQueue) parkTask(queueName string, task *Task, attempt int, delay time.Duration) error {
+	time.AfterFunc(delay, func() {
+		if err := m.PublishTask(queueName, task); err != nil {
+			logger.Warnf("Dropped parked task %s: %v", task.ID, err)
+		}
+	})
+	return nil
+}
+
+// Depth counts the tasks waiting in this process.
+func (m *MemoryQueue) Depth(queueName string) (int, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	consumers := 0
+	if consumer, ok := m.consumers[queueName]; ok {
+		consumers = consumer.workers
+	}
+	return m.queueLocked(queueName).len(), consumers, nil
+}
+
+// Drain stops the workers and waits for running handlers until ctx is done.
+// Tasks still queued are lost with the process.
+func (m *MemoryQueue) Drain(ctx context.Context) error {
+	m.stop()
+
+	m.mu.Lock()
+	remaining := 0
+	for _, q := range m.queues {
+		remaining += q.len()
+		q.close()
+	}
+	m.closed = true
+	m.mu.Unlock()
+
+	if remaining > 0 {
+		logger.Warnf("Dropping %d queued in-memory tasks on shutdown", remaining)
+	}
+	return m.wait(ctx)
+}
+
+func (m *MemoryQueue) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	for _, q := range m.queues {
+		q.close()
+	}
+	return nil
+}
+
+// memoryTaskQueue hands out tasks by priority, oldest first within a
+// priority, like a RabbitMQ priority queue.
+type memoryTaskQueue struct {
+	mu       sync.Mutex
+	ready    *sync.Cond
+	tasks    memoryTaskHeap
+	seq      uint64
+	retiring int // workers asked to exit
+	closed   bool
+}
+
+func newMemoryTaskQueue() *memoryTaskQueue {
+	q := &memoryTaskQueue{}
+	q.ready = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *memoryTaskQueue) push(task *Task) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return false
+	}
+	q.seq++
+	heap.Push(&q.tasks, memoryTask{task: task, seq: q.seq})
+	q.ready.Signal()
+	return true
+}
+
+// pop blocks until a task is available, the queue is closed or the calling
+// worker is retired.
+func (q *memoryTaskQueue) pop() (*Task, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.tasks) == 0 && !q.closed && q.retiring == 0 {
+		q.ready.Wait()
+	}
+	if q.closed {
+		return nil, false
+	}
+	if q.retiring > 0 {
+		q.retiring--
+		return nil, false
+	}
+	return heap.Pop(&q.tasks).(memoryTask).task, true
+}
+
+// retire makes the next n calls to pop return without a task.
+func (q *memoryTaskQueue) retire(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.retiring += n
+	q.ready.Broadcast()
+}
+
+func (q *memoryTaskQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.tasks)
+}
+
+func (q *memoryTaskQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.ready.Broadcast()
+}
+
+type memoryTask struct {
+	task *Task
+	seq  uint64
+}
+
+type memoryTaskHeap []memoryTask
+
+func (h memoryTaskHeap) Len() int { return len(h) }
+func (h memoryTaskHeap) Less(i, j int) bool {
+	if h[i].task.Priority != h[j].task.Priority {
+		return h[i].task.Priority > h[j].task.Priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h memoryTaskHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *memoryTaskHeap) Push(x interface{}) { *h = append(*h, x.(memoryTask)) }
+func (h *memoryTaskHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
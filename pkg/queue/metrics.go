@@ -0,0 +1,47 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/metrics"
+)
+
+// depthReportInterval is how often queue depths are sampled for metrics
+const depthReportInterval = 15 * time.Second
+
+// ReportQueueDepth samples the depth of every task queue into the metrics
+// until ctx is done.
+func ReportQueueDepth(ctx context.Context) {
+	ticker := time.NewTicker(depthReportInterval)
+	defer ticker.Stop()
+
+	for {
+		reportQueueDepth()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func reportQueueDepth() {
+	if Queue == nil {
+		return
+	}
+
+	for _, name := range taskQueueNames {
+		depth, consumers, err := Queue.Depth(name)
+		if err != nil {
+			logger.Debugf("Failed to sample depth of queue %s: %v", name, err)
+			continue
+		}
+		metrics.QueueDepth.WithLabelValues(name).Set(float64(depth))
+		if consumers >= 0 {
+			metrics.QueueConsumers.WithLabelValues(name).Set(float64(consumers))
+		}
+	}
+}
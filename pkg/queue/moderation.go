@@ -0,0 +1,107 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/moderation"
+	"creative-studio-server/pkg/realtime"
+	"creative-studio-server/pkg/video_engine"
+)
+
+// A single unsafe scene flags a clip, so clips are sampled more densely
+// than for analysis: once a second, long ones more sparsely to stay within
+// this many frames
+const maxModerationSamples = 120
+
+// ModerationTaskHandler scores a clip's sampled frames for unsafe content
+// and flags the clip for review when any category reaches the threshold.
+// Clips a reviewer has decided on keep the decision.
+func ModerationTaskHandler(task *Task) error {
+	clipID, ok := task.Payload["clip_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid clip_id in task payload")
+	}
+
+	db := database.GetDB()
+
+	var clip models.AtomicClip
+	if err := db.First(&clip, uint(clipID)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			task.Logger().Warnf("Clip %d no longer exists, skipping moderation", uint(clipID))
+			return nil
+		}
+		return fmt.Errorf("failed to load clip %d: %w", uint(clipID), err)
+	}
+	if clip.ModeratedBy != nil {
+		task.Logger().Infof("Clip %d was reviewed, skipping moderation", clip.ID)
+		return nil
+	}
+
+	task.Logger().Infof("Moderating clip %d", clip.ID)
+
+	dir, err := os.MkdirTemp("", "moderation-*")
+	if err != nil {
+		return fmt.Errorf("failed to create frame directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	processor := video_engine.NewFFmpegProcessor(config.AppConfig)
+	frames, err := processor.SampleFrames(clip.FilePath, dir, math.Max(1, clip.Duration/maxModerationSamples))
+	if err != nil {
+		return fmt.Errorf("failed to sample frames of clip %d: %w", clip.ID, err)
+	}
+
+	scores := make([]map[string]float64, 0, len(frames))
+	for _, frame := range frames {
+		data, err := os.ReadFile(frame)
+		if err != nil {
+			return fmt.Errorf("failed to read sampled frame: %w", err)
+		}
+		categories, err := moderation.Classify(data)
+		if err != nil {
+			return fmt.Errorf("failed to classify clip %d: %w", clip.ID, err)
+		}
+		scores = append(scores, categories)
+	}
+
+	verdict := moderation.Judge(scores)
+	status := models.ModerationApproved
+	if verdict.Flagged {
+		status = models.ModerationFlagged
+	}
+	// A reviewer may have decided while the frames were scored
+	if err := db.Model(&clip).Where("moderated_by IS NULL").Updates(map[string]interface{}{
+		"moderation_status": status,
+		"moderation_score":  verdict.Score,
+		"moderation_labels": models.StringArray(verdict.Labels),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to store moderation of clip %d: %w", clip.ID, err)
+	}
+
+	// Cached searches may show the clip publicly
+	cache.InvalidateClip(clip.ID)
+
+	realtime.Publish(clip.UserID, realtime.EventClipModerated, map[string]interface{}{
+		"clip_id": clip.ID,
+		"status":  status,
+		"labels":  verdict.Labels,
+	})
+	if verdict.Flagged {
+		EmitWebhookEvent(clip.UserID, clip.OrganizationID, models.WebhookEventClipFlagged, map[string]interface{}{
+			"clip_id": clip.ID,
+			"score":   verdict.Score,
+			"labels":  verdict.Labels,
+		})
+	}
+
+	task.Logger().Infof("Moderated clip %d: %s (score %.2f)", clip.ID, status, verdict.Score)
+	return nil
+}
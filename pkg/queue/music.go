@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/realtime"
+	"creative-studio-server/pkg/video_engine"
+)
+
+// MusicAnalysisTaskHandler finds the tempo, beats, bars, energy and
+// sections of a music clip and stores them in place of the previous
+// analysis.
+func MusicAnalysisTaskHandler(task *Task) error {
+	clipID, ok := task.Payload["clip_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid clip_id in task payload")
+	}
+
+	db := database.GetDB()
+
+	var clip models.AtomicClip
+	if err := db.First(&clip, uint(clipID)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			task.Logger().Warnf("Clip %d no longer exists, skipping music analysis", uint(clipID))
+			return nil
+		}
+		return fmt.Errorf("failed to load clip %d: %w", uint(clipID), err)
+	}
+
+	task.Logger().Infof("Analyzing music of clip %d", clip.ID)
+
+	features, err := video_engine.NewFFmpegProcessor(config.AppConfig).AnalyzeMusic(clip.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to analyze music of clip %d: %w", clip.ID, err)
+	}
+
+	analysis := models.MusicAnalysis{AtomicClipID: clip.ID}
+	if err := db.Where(models.MusicAnalysis{AtomicClipID: clip.ID}).
+		Assign(map[string]interface{}{
+			"bpm":             features.BPM,
+			"beat_times":      models.Float64Array(features.BeatTimes),
+			"downbeat_times":  models.Float64Array(features.DownbeatTimes),
+			"energy_interval": features.EnergyInterval,
+			"energy_curve":    models.Float64Array(features.EnergyCurve),
+			"sections":        models.MusicSections(features.Sections),
+		}).
+		FirstOrCreate(&analysis).Error; err != nil {
+		return fmt.Errorf("failed to store music analysis of clip %d: %w", clip.ID, err)
+	}
+	cache.InvalidateClip(clip.ID)
+
+	realtime.Publish(clip.UserID, realtime.EventMusicAnalyzed, map[string]interface{}{
+		"clip_id": clip.ID,
+		"bpm":     features.BPM,
+		"beats":   len(features.BeatTimes),
+	})
+
+	task.Logger().Infof("Analyzed music of clip %d: %.1f BPM, %d beats", clip.ID, features.BPM, len(features.BeatTimes))
+	return nil
+}
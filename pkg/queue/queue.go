@@ -0,0 +1,139 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/metrics"
+	"creative-studio-server/pkg/tracing"
+)
+
+// Supported task queue backends
+const (
+	BackendRabbitMQ = "rabbitmq"
+	BackendKafka    = "kafka"
+	BackendMemory   = "memory"
+)
+
+// TaskQueue is a message broker that carries tasks to workers. RabbitMQ is
+// the default; Kafka is available for deployments standardized on it, and
+// an in-process queue for local development and tests.
+type TaskQueue interface {
+	// PublishTask sends a task to the named queue (a topic on Kafka)
+	PublishTask(queueName string, task *Task) error
+	// ConsumeTask runs handler for tasks on the named queue with the given
+	// number of concurrent workers
+	ConsumeTask(queueName string, handler TaskHandler, concurrency int) error
+	// SetConcurrency resizes the workers of a queue this process consumes;
+	// 0 stops consuming it. prefetch only applies to RabbitMQ
+	SetConcurrency(queueName string, concurrency, prefetch int) error
+	// Depth reports how many tasks wait on the queue (consumer group lag on
+	// Kafka) and how many consumers are attached, -1 when unknown
+	Depth(queueName string) (depth, consumers int, err error)
+	// Drain stops taking new tasks and waits for running handlers until ctx
+	// is done; unfinished tasks are handed back to the broker
+	Drain(ctx context.Context) error
+	Close() error
+}
+
+type Task struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Payload   map[string]interface{} `json:"payload"`
+	Priority  int                    `json:"priority"`
+	Retry     int                    `json:"retry"`
+	MaxRetry  int                    `json:"max_retry"`
+	TraceID   string                 `json:"trace_id,omitempty"` // correlation ID of the request that queued it
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// Logger returns a log entry tagged with the task and its trace ID, so the
+// logs of one job can be followed from the API request to the worker.
+func (t *Task) Logger() *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		"task_id":   t.ID,
+		"task_type": t.Type,
+		"trace_id":  t.TraceID,
+	})
+}
+
+type TaskHandler func(task *Task) error
+
+var Queue TaskQueue
+
+// taskQueueNames are the queues every backend provisions
+var taskQueueNames = []string{
+	"video_processing",
+	"smart_composition",
+	"render_tasks",
+	"render_preview",
+	"analysis_tasks",
+	"thumbnail_generation",
+	"clip_imports",
+	"search_index",
+	"webhooks",
+	"transcriptions",
+	"moderation",
+	"music_analysis",
+}
+
+// InitQueue connects to the task queue backend selected in the config.
+func InitQueue(cfg *config.Config) error {
+	loadSettings(cfg)
+
+	switch cfg.Queue.Backend {
+	case BackendRabbitMQ, "":
+		client, err := NewRabbitMQClient(cfg)
+		if err != nil {
+			return err
+		}
+		Queue = client
+	case BackendKafka:
+		client, err := NewKafkaClient(cfg)
+		if err != nil {
+			return err
+		}
+		Queue = client
+	case BackendMemory:
+		Queue = NewMemoryQueue()
+	default:
+		return fmt.Errorf("unknown queue backend %q", cfg.Queue.Backend)
+	}
+
+	return nil
+}
+
+func CreateTask(taskType string, payload map[string]interface{}, priority int) *Task {
+	return &Task{
+		ID:        generateTaskID(),
+		Type:      taskType,
+		Payload:   payload,
+		Priority:  priority,
+		Retry:     0,
+		MaxRetry:  RetryPolicyFor(taskType).MaxRetries,
+		CreatedAt: time.Now(),
+	}
+}
+
+// enqueue publishes through the configured backend.
+func enqueue(queueName string, task *Task) error {
+	if Queue == nil {
+		return fmt.Errorf("task queue is not initialized")
+	}
+	applyRetryOverride(queueName, task)
+	if task.TraceID == "" {
+		task.TraceID = tracing.NewID()
+	}
+	if err := Queue.PublishTask(queueName, task); err != nil {
+		metrics.TasksPublished.WithLabelValues(queueName, "error").Inc()
+		return err
+	}
+	metrics.TasksPublished.WithLabelValues(queueName, "ok").Inc()
+
+	recordQueued(queueName, task)
+	return nil
+}
@@ -1,72 +1,74 @@
 package queue
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/streadway/amqp"
+	"gorm.io/gorm"
 	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/embedding"
 	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/moderation"
+	"creative-studio-server/pkg/objects"
+	"creative-studio-server/pkg/realtime"
+	"creative-studio-server/pkg/search"
+	"creative-studio-server/pkg/suggestions"
+	"creative-studio-server/pkg/transcription"
+	"creative-studio-server/pkg/video_engine"
 )
 
 type RabbitMQClient struct {
+	url        string
 	connection *amqp.Connection
 	channel    *amqp.Channel
 	queues     map[string]amqp.Queue
-}
-
-type Task struct {
-	ID        string                 `json:"id"`
-	Type      string                 `json:"type"`
-	Payload   map[string]interface{} `json:"payload"`
-	Priority  int                    `json:"priority"`
-	Retry     int                    `json:"retry"`
-	MaxRetry  int                    `json:"max_retry"`
-	CreatedAt time.Time              `json:"created_at"`
-}
 
-type TaskHandler func(task *Task) error
+	// mu guards the connection state; the channel is replaced on reconnect
+	mu        sync.RWMutex
+	connected bool
+	closing   bool
+	done      chan struct{}
 
-var Queue *RabbitMQClient
+	consumers []consumerRegistration
+	pending   []pendingPublish
+	*drainer
 
-func InitRabbitMQ(cfg *config.Config) error {
-	conn, err := amqp.Dial(cfg.RabbitMQ.URL)
-	if err != nil {
-		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
-	}
-
-	ch, err := conn.Channel()
-	if err != nil {
-		return fmt.Errorf("failed to open channel: %w", err)
-	}
+	publishBufferSize int
+	reconnectMaxDelay time.Duration
+}
 
-	Queue = &RabbitMQClient{
-		connection: conn,
-		channel:    ch,
-		queues:     make(map[string]amqp.Queue),
+// NewRabbitMQClient connects to RabbitMQ and declares the task queues.
+func NewRabbitMQClient(cfg *config.Config) (*RabbitMQClient, error) {
+	client := &RabbitMQClient{
+		url:               cfg.RabbitMQ.URL,
+		queues:            make(map[string]amqp.Queue),
+		done:              make(chan struct{}),
+		drainer:           newDrainer(),
+		publishBufferSize: cfg.RabbitMQ.PublishBufferSize,
+		reconnectMaxDelay: cfg.RabbitMQ.ReconnectMaxDelay,
 	}
 
-	// Declare default queues
-	if err := Queue.declareQueues(); err != nil {
-		return fmt.Errorf("failed to declare queues: %w", err)
+	if err := client.connect(); err != nil {
+		return nil, err
 	}
 
 	logger.Info("RabbitMQ connected successfully")
-	return nil
+	return client, nil
 }
 
-func (r *RabbitMQClient) declareQueues() error {
-	queueNames := []string{
-		"video_processing",
-		"smart_composition",
-		"render_tasks",
-		"analysis_tasks",
-		"thumbnail_generation",
-	}
-
-	for _, name := range queueNames {
-		queue, err := r.channel.QueueDeclare(
+func declareQueues(ch *amqp.Channel) (map[string]amqp.Queue, error) {
+	queues := make(map[string]amqp.Queue, len(taskQueueNames))
+	for _, name := range taskQueueNames {
+		queue, err := ch.QueueDeclare(
 			name,
 			true,  // durable
 			false, // delete when unused
@@ -80,14 +82,17 @@ func (r *RabbitMQClient) declareQueues() error {
 			},
 		)
 		if err != nil {
-			return fmt.Errorf("failed to declare queue %s: %w", name, err)
+			return nil, fmt.Errorf("failed to declare queue %s: %w", name, err)
+		}
+		if err := declareRetryQueues(ch, name); err != nil {
+			return nil, err
 		}
 
-		r.queues[name] = queue
+		queues[name] = queue
 	}
 
 	// Declare dead letter exchange
-	err := r.channel.ExchangeDeclare(
+	err := ch.ExchangeDeclare(
 		"dlx",
 		"direct",
 		true,
@@ -97,13 +102,26 @@ func (r *RabbitMQClient) declareQueues() error {
 		nil,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to declare dead letter exchange: %w", err)
+		return nil, fmt.Errorf("failed to declare dead letter exchange: %w", err)
 	}
 
-	return nil
+	return queues, nil
 }
 
+// PublishTask sends a task to a queue. While RabbitMQ is unreachable the
+// task is held in a bounded in-memory buffer and sent after reconnecting;
+// once the buffer is full publishing fails fast.
 func (r *RabbitMQClient) PublishTask(queueName string, task *Task) error {
+	return r.publishTask(queueName, task, "")
+}
+
+// publishTask publishes to queueName, optionally with a per-message
+// expiration in milliseconds.
+func (r *RabbitMQClient) publishTask(queueName string, task *Task, expiration string) error {
+	if r == nil {
+		return fmt.Errorf("task queue is not initialized")
+	}
+
 	body, err := json.Marshal(task)
 	if err != nil {
 		return fmt.Errorf("failed to marshal task: %w", err)
@@ -114,58 +132,119 @@ func (r *RabbitMQClient) PublishTask(queueName string, task *Task) error {
 		priority = 10
 	}
 
-	err = r.channel.Publish(
+	publishing := amqp.Publishing{
+		ContentType:   "application/json",
+		Body:          body,
+		Priority:      priority,
+		Timestamp:     time.Now(),
+		DeliveryMode:  amqp.Persistent,
+		Expiration:    expiration,
+		CorrelationId: task.TraceID,
+	}
+
+	r.mu.RLock()
+	if r.connected {
+		err = r.publish(queueName, publishing)
+		r.mu.RUnlock()
+		if err == nil {
+			logger.Infof("Task published to queue %s: %s", queueName, task.ID)
+			return nil
+		}
+		if !errors.Is(err, amqp.ErrClosed) {
+			return fmt.Errorf("failed to publish task to queue %s: %w", queueName, err)
+		}
+	} else {
+		r.mu.RUnlock()
+	}
+
+	return r.bufferPublish(queueName, task.ID, publishing)
+}
+
+// publish must be called with mu held.
+func (r *RabbitMQClient) publish(queueName string, publishing amqp.Publishing) error {
+	return r.channel.Publish(
 		"",        // exchange
 		queueName, // routing key
 		false,     // mandatory
 		false,     // immediate
-		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			Priority:     priority,
-			Timestamp:    time.Now(),
-			DeliveryMode: amqp.Persistent,
-		},
+		publishing,
 	)
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to publish task to queue %s: %w", queueName, err)
+// ConsumeTask starts concurrency workers on a queue. The consumer is
+// registered again whenever the connection is re-established.
+func (r *RabbitMQClient) ConsumeTask(queueName string, handler TaskHandler, concurrency int) error {
+	consumer := consumerRegistration{
+		queueName:   queueName,
+		tag:         consumerTag(queueName),
+		handler:     handler,
+		concurrency: concurrency,
+		prefetch:    SettingsFor(queueName).Prefetch,
 	}
 
-	logger.Infof("Task published to queue %s: %s", queueName, task.ID)
+	err := r.startConsumer(consumer)
+	if err != nil && !errors.Is(err, errQueueDisconnected) {
+		return err
+	}
+
+	r.mu.Lock()
+	r.consumers = append(r.consumers, consumer)
+	r.mu.Unlock()
+
+	if err != nil {
+		logger.Warnf("RabbitMQ is disconnected, workers for queue %s start after reconnecting", queueName)
+	}
 	return nil
 }
 
-func (r *RabbitMQClient) ConsumeTask(queueName string, handler TaskHandler, concurrency int) error {
-	// Set QoS for the channel
+func (r *RabbitMQClient) startConsumer(consumer consumerRegistration) error {
+	if consumer.concurrency <= 0 {
+		logger.Infof("Not consuming queue %s (no workers)", consumer.queueName)
+		return nil
+	}
+
+	prefetch := consumer.prefetch
+	if prefetch <= 0 {
+		prefetch = consumer.concurrency
+	}
+
+	r.mu.Lock()
+	if !r.connected {
+		r.mu.Unlock()
+		return errQueueDisconnected
+	}
+
+	// Set QoS for the channel; it applies to the consumer registered next
 	err := r.channel.Qos(
-		concurrency, // prefetch count
-		0,           // prefetch size
-		false,       // global
+		prefetch, // prefetch count
+		0,        // prefetch size
+		false,    // global
 	)
 	if err != nil {
+		r.mu.Unlock()
 		return fmt.Errorf("failed to set QoS: %w", err)
 	}
 
 	msgs, err := r.channel.Consume(
-		queueName, // queue
-		"",        // consumer
-		false,     // auto-ack
-		false,     // exclusive
-		false,     // no-local
-		false,     // no-wait
-		nil,       // args
+		consumer.queueName, // queue
+		consumer.tag,       // consumer
+		false,              // auto-ack
+		false,              // exclusive
+		false,              // no-local
+		false,              // no-wait
+		nil,                // args
 	)
+	r.mu.Unlock()
 	if err != nil {
 		return fmt.Errorf("failed to register consumer: %w", err)
 	}
 
-	// Start consumer goroutines
-	for i := 0; i < concurrency; i++ {
-		go r.worker(msgs, handler, queueName)
+	// Start consumer goroutines; they exit when the connection drops
+	for i := 0; i < consumer.concurrency; i++ {
+		go r.worker(msgs, consumer.handler, consumer.queueName)
 	}
 
-	logger.Infof("Started %d workers for queue %s", concurrency, queueName)
+	logger.Infof("Started %d workers for queue %s", consumer.concurrency, consumer.queueName)
 	return nil
 }
 
@@ -178,43 +257,118 @@ func (r *RabbitMQClient) worker(msgs <-chan amqp.Delivery, handler TaskHandler,
 			continue
 		}
 
-		logger.Infof("Processing task %s from queue %s", task.ID, queueName)
-
-		err := handler(&task)
-		if err != nil {
-			logger.Errorf("Task %s failed: %v", task.ID, err)
-
-			// Retry logic
-			if task.Retry < task.MaxRetry {
-				task.Retry++
-				if retryErr := r.PublishTask(queueName, &task); retryErr != nil {
-					logger.Errorf("Failed to retry task %s: %v", task.ID, retryErr)
-				} else {
-					logger.Infof("Task %s queued for retry (%d/%d)", task.ID, task.Retry, task.MaxRetry)
-				}
-			}
+		// Hand prefetched tasks back once draining has started
+		if !r.begin() {
+			msg.Nack(false, true)
+			continue
+		}
 
-			msg.Nack(false, false) // Dead letter after max retries
+		if runTask(r, queueName, &task, handler) == taskDeadLetter {
+			msg.Nack(false, false)
 		} else {
-			logger.Infof("Task %s completed successfully", task.ID)
 			msg.Ack(false)
 		}
+		r.end()
+	}
+}
+
+// Depth inspects the queue on the broker.
+func (r *RabbitMQClient) Depth(queueName string) (int, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.connected {
+		return 0, 0, errQueueDisconnected
+	}
+	q, err := r.channel.QueueInspect(queueName)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to inspect queue %s: %w", queueName, err)
+	}
+	return q.Messages, q.Consumers, nil
+}
+
+// SetConcurrency replaces the queue's consumer with one sized for the new
+// settings. Workers of the old consumer finish the tasks they were already
+// handed, then exit.
+func (r *RabbitMQClient) SetConcurrency(queueName string, concurrency, prefetch int) error {
+	r.mu.Lock()
+	index := -1
+	for i, consumer := range r.consumers {
+		if consumer.queueName == queueName {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		r.mu.Unlock()
+		return nil
+	}
+
+	old := r.consumers[index]
+	if old.concurrency == concurrency && old.prefetch == prefetch {
+		r.mu.Unlock()
+		return nil
 	}
+
+	consumer := old
+	consumer.tag = consumerTag(queueName)
+	consumer.concurrency = concurrency
+	consumer.prefetch = prefetch
+	r.consumers[index] = consumer
+
+	connected := r.connected
+	if connected && old.concurrency > 0 {
+		if err := r.channel.Cancel(old.tag, false); err != nil {
+			r.mu.Unlock()
+			return fmt.Errorf("failed to stop consumer: %w", err)
+		}
+	}
+	r.mu.Unlock()
+
+	// A reconnect starts the new consumer; a drain doesn't want one
+	if !connected || r.draining() {
+		return nil
+	}
+	return r.startConsumer(consumer)
 }
 
-func (r *RabbitMQClient) CreateTask(taskType string, payload map[string]interface{}, priority int) *Task {
-	return &Task{
-		ID:        generateTaskID(),
-		Type:      taskType,
-		Payload:   payload,
-		Priority:  priority,
-		Retry:     0,
-		MaxRetry:  3,
-		CreatedAt: time.Now(),
+// Drain stops the consumers and waits for running handlers until ctx is
+// done. Tasks still running after that are redelivered by RabbitMQ once the
+// connection is closed.
+func (r *RabbitMQClient) Drain(ctx context.Context) error {
+	r.stop()
+
+	r.mu.RLock()
+	if r.connected {
+		for _, consumer := range r.consumers {
+			if consumer.concurrency <= 0 {
+				continue
+			}
+			if err := r.channel.Cancel(consumer.tag, false); err != nil {
+				logger.Warnf("Failed to stop consumer for queue %s: %v", consumer.queueName, err)
+			}
+		}
 	}
+	r.mu.RUnlock()
+
+	return r.wait(ctx)
 }
 
 func (r *RabbitMQClient) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closing {
+		return nil
+	}
+	r.closing = true
+	r.connected = false
+	close(r.done)
+
+	if len(r.pending) > 0 {
+		logger.Warnf("Dropping %d buffered tasks that were never published", len(r.pending))
+	}
+
 	if r.channel != nil {
 		r.channel.Close()
 	}
@@ -224,65 +378,200 @@ func (r *RabbitMQClient) Close() error {
 	return nil
 }
 
+// feedbackHistoryLimit bounds how many recent feedback events shape a composition
+const feedbackHistoryLimit = 500
+
+
 // Task type constants
 const (
 	TaskTypeVideoProcessing      = "video_processing"
 	TaskTypeSmartComposition     = "smart_composition"
 	TaskTypeRenderVideo          = "render_video"
+	TaskTypeRenderPreview        = "render_preview"
 	TaskTypeAnalyzeVideo         = "analyze_video"
 	TaskTypeGenerateThumbnail    = "generate_thumbnail"
 	TaskTypeExtractAudio         = "extract_audio"
 	TaskTypeApplyEffects         = "apply_effects"
+	TaskTypeImportClips          = "import_clips"
+	TaskTypeIndexClips           = "index_clips"
+	TaskTypeDeliverWebhook       = "deliver_webhook"
+	TaskTypeTranscribeAudio      = "transcribe_audio"
+	TaskTypeModerateClip         = "moderate_clip"
+	TaskTypeAnalyzeMusic         = "analyze_music"
 )
 
 // Helper functions for different task types
-func PublishVideoProcessingTask(clipID uint, filePath string) error {
-	task := Queue.CreateTask(TaskTypeVideoProcessing, map[string]interface{}{
+func PublishVideoProcessingTask(clipID uint, filePath string, traceID string) error {
+	task := CreateTask(TaskTypeVideoProcessing, map[string]interface{}{
 		"clip_id":   clipID,
 		"file_path": filePath,
 	}, 5)
+	task.TraceID = traceID
 
-	return Queue.PublishTask("video_processing", task)
+	return enqueue("video_processing", task)
 }
 
-func PublishSmartCompositionTask(projectID uint, requirements map[string]interface{}) error {
-	task := Queue.CreateTask(TaskTypeSmartComposition, map[string]interface{}{
-		"project_id":    projectID,
-		"requirements":  requirements,
+// PublishSmartCompositionTask queues a composition job producing the requested
+// number of variants, cycling through algorithms (empty means smart_selection).
+// The first variant is written to the pending composition compositionID.
+// A collectionID limits the clips to that collection's.
+func PublishSmartCompositionTask(compositionID, projectID uint, collectionID *uint, includeStock bool, requirements map[string]interface{}, variants int, algorithms []string, traceID string) error {
+	task := CreateTask(TaskTypeSmartComposition, map[string]interface{}{
+		"composition_id": compositionID,
+		"project_id":     projectID,
+		"collection_id":  collectionID,
+		"include_stock":  includeStock,
+		"requirements":   requirements,
+		"variants":       variants,
+		"algorithms":     algorithms,
 	}, 7)
+	task.TraceID = traceID
 
-	return Queue.PublishTask("smart_composition", task)
+	return enqueue("smart_composition", task)
 }
 
-func PublishRenderTask(taskID string, renderOptions map[string]interface{}) error {
-	task := Queue.CreateTask(TaskTypeRenderVideo, map[string]interface{}{
+func PublishRenderTask(taskID string, renderOptions map[string]interface{}, traceID string) error {
+	task := CreateTask(TaskTypeRenderVideo, map[string]interface{}{
 		"task_id":        taskID,
 		"render_options": renderOptions,
 	}, 8)
+	task.TraceID = traceID
+
+	return enqueue("render_tasks", task)
+}
+
+// PublishPreviewRenderTask queues a low-resolution proxy render on the
+// high-priority preview queue so it is not stuck behind full renders.
+func PublishPreviewRenderTask(taskID string, renderOptions map[string]interface{}, traceID string) error {
+	task := CreateTask(TaskTypeRenderPreview, map[string]interface{}{
+		"task_id":        taskID,
+		"render_options": renderOptions,
+		"mode":           "preview",
+	}, 10)
+	task.TraceID = traceID
+
+	return enqueue("render_preview", task)
+}
+
+// PublishAnalysisTask queues a clip analysis; version is recorded on the
+// clip's VideoAnalysis when it completes.
+func PublishAnalysisTask(clipID uint, analysisType, version string, traceID string) error {
+	task := CreateTask(TaskTypeAnalyzeVideo, map[string]interface{}{
+		"clip_id":          clipID,
+		"analysis_type":    analysisType,
+		"analysis_version": version,
+	}, 3)
+	task.TraceID = traceID
+
+	return enqueue("analysis_tasks", task)
+}
+
+// PublishTranscriptionTask queues the transcription of a clip's audio, in
+// the given language or the configured one. It does nothing without a
+// transcription provider.
+func PublishTranscriptionTask(clipID uint, language string, traceID string) error {
+	if !transcription.Enabled() {
+		return nil
+	}
+	task := CreateTask(TaskTypeTranscribeAudio, map[string]interface{}{
+		"clip_id":  clipID,
+		"language": language,
+	}, 3)
+	task.TraceID = traceID
+
+	return enqueue("transcriptions", task)
+}
 
-	return Queue.PublishTask("render_tasks", task)
+// PublishModerationTask queues the scoring of a clip for unsafe content.
+// It does nothing without a moderation provider.
+func PublishModerationTask(clipID uint, traceID string) error {
+	if !moderation.Enabled() {
+		return nil
+	}
+	task := CreateTask(TaskTypeModerateClip, map[string]interface{}{
+		"clip_id": clipID,
+	}, 4)
+	task.TraceID = traceID
+
+	return enqueue("moderation", task)
 }
 
-func PublishAnalysisTask(clipID uint, analysisType string) error {
-	task := Queue.CreateTask(TaskTypeAnalyzeVideo, map[string]interface{}{
-		"clip_id":       clipID,
-		"analysis_type": analysisType,
+// PublishMusicAnalysisTask queues the beat and structure analysis of a
+// music clip.
+func PublishMusicAnalysisTask(clipID uint, traceID string) error {
+	task := CreateTask(TaskTypeAnalyzeMusic, map[string]interface{}{
+		"clip_id": clipID,
 	}, 3)
+	task.TraceID = traceID
+
+	return enqueue("music_analysis", task)
+}
+
+// PublishClipImportTask queues the extraction of an uploaded clip archive.
+func PublishClipImportTask(importID uint, traceID string) error {
+	task := CreateTask(TaskTypeImportClips, map[string]interface{}{
+		"import_id": importID,
+	}, 4)
+	task.TraceID = traceID
+
+	return enqueue("clip_imports", task)
+}
+
+// PublishSearchIndexTask queues the clips to be indexed again, or removed
+// from the index when deleted. It does nothing without a search index.
+func PublishSearchIndexTask(clipIDs []uint, traceID string) error {
+	if !search.Enabled() || len(clipIDs) == 0 {
+		return nil
+	}
+	task := CreateTask(TaskTypeIndexClips, map[string]interface{}{
+		"clip_ids": clipIDs,
+	}, 2)
+	task.TraceID = traceID
+
+	return enqueue("search_index", task)
+}
+
+// PublishSearchReindexTask queues the indexing of every clip after the given
+// ID, one batch per task.
+func PublishSearchReindexTask(afterID uint, traceID string) error {
+	task := CreateTask(TaskTypeIndexClips, map[string]interface{}{
+		"all":      true,
+		"after_id": afterID,
+	}, 1)
+	task.TraceID = traceID
 
-	return Queue.PublishTask("analysis_tasks", task)
+	return enqueue("search_index", task)
 }
 
-func PublishThumbnailTask(clipID uint, filePath string) error {
-	task := Queue.CreateTask(TaskTypeGenerateThumbnail, map[string]interface{}{
+func PublishThumbnailTask(clipID uint, filePath string, traceID string) error {
+	task := CreateTask(TaskTypeGenerateThumbnail, map[string]interface{}{
 		"clip_id":   clipID,
 		"file_path": filePath,
 	}, 2)
+	task.TraceID = traceID
 
-	return Queue.PublishTask("thumbnail_generation", task)
+	return enqueue("thumbnail_generation", task)
 }
 
+// decodePayloadField converts a loosely-typed payload value into dest by
+// round-tripping it through JSON. Missing values leave dest untouched.
+func decodePayloadField(value interface{}, dest interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, dest)
+}
+
+// generateTaskID must be unique across API instances, since completed task
+// IDs are remembered to skip redeliveries
 func generateTaskID() string {
-	return fmt.Sprintf("task_%d", time.Now().UnixNano())
+	return fmt.Sprintf("task_%d_%04x", time.Now().UnixNano(), rand.Intn(0x10000))
 }
 
 // Task Handlers
@@ -298,17 +587,38 @@ func VideoProcessingHandler(task *Task) error {
 		return fmt.Errorf("invalid file_path in task payload")
 	}
 
-	logger.Infof("Processing video for clip %d: %s", uint(clipID), filePath)
-	
-	// TODO: Implement actual video processing logic
-	// This would include:
+	task.Logger().Infof("Processing video for clip %d: %s", uint(clipID), filePath)
+
+	db := database.GetDB()
+
+	var clip models.AtomicClip
+	if err := db.First(&clip, uint(clipID)).Error; err != nil {
+		return fmt.Errorf("failed to load clip %d: %w", uint(clipID), err)
+	}
+
+	// Extract per-second highlight signals used for sub-clip selection
+	processor := video_engine.NewFFmpegProcessor(config.AppConfig)
+	signals, err := processor.AnalyzeHighlightSignals(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to analyze highlight signals: %w", err)
+	}
+
+	clip.Metadata = video_engine.StoreHighlightSignals(clip.Metadata, signals)
+	if err := db.Model(&clip).Update("metadata", clip.Metadata).Error; err != nil {
+		return fmt.Errorf("failed to store highlight signals for clip %d: %w", clip.ID, err)
+	}
+	cache.InvalidateClip(clip.ID)
+
+	realtime.Publish(clip.UserID, realtime.EventAnalysisCompleted, map[string]interface{}{
+		"clip_id":       clip.ID,
+		"analysis_type": "highlight_signals",
+	})
+
+	// TODO: Remaining processing steps:
 	// - Video analysis
 	// - Thumbnail generation
-	// - Metadata extraction
 	// - Quality assessment
 
-	time.Sleep(2 * time.Second) // Simulate processing time
-	
 	return nil
 }
 
@@ -318,38 +628,171 @@ func SmartCompositionHandler(task *Task) error {
 		return fmt.Errorf("invalid project_id in task payload")
 	}
 
-	logger.Infof("Generating smart composition for project %d", uint(projectID))
-	
-	// TODO: Implement smart composition logic
-	// This would include:
-	// - Fetching clips
-	// - Running composition algorithms
-	// - Generating timeline
-	// - Storing results
-
-	time.Sleep(5 * time.Second) // Simulate processing time
-	
+	compositionID, ok := task.Payload["composition_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid composition_id in task payload")
+	}
+
+	db := database.GetDB()
+
+	var pending models.Composition
+	if err := db.First(&pending, uint(compositionID)).Error; err != nil {
+		return fmt.Errorf("failed to load composition %d: %w", uint(compositionID), err)
+	}
+
+	// A second delivery of the same task must not add another set of variants
+	if pending.Status == models.CompositionStatusCompleted {
+		task.Logger().Infof("Composition %d is already generated, skipping", pending.ID)
+		return nil
+	}
+
+	results, err := generateCompositionVariants(task, uint(projectID))
+	if err != nil {
+		db.Model(&pending).Updates(map[string]interface{}{
+			"status":        models.CompositionStatusFailed,
+			"error_message": err.Error(),
+		})
+		realtime.Publish(pending.UserID, realtime.EventCompositionFailed, map[string]interface{}{
+			"composition_id": pending.ID,
+			"project_id":     pending.ProjectID,
+			"error":          err.Error(),
+		})
+		return err
+	}
+
+	compositionIDs := make([]uint, 0, len(results))
+
+	// Save every variant or none, so a rerun never finds half a set of siblings
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// The first variant fills the pending composition, the rest become siblings
+		for i, result := range results {
+			composition := pending
+			if i > 0 {
+				composition.ID = 0
+				composition.CreatedAt = time.Time{}
+				composition.UpdatedAt = time.Time{}
+			}
+
+			if err := result.ApplyTo(&composition); err != nil {
+				return fmt.Errorf("failed to convert composition variant %d: %w", i, err)
+			}
+			if err := tx.Save(&composition).Error; err != nil {
+				return fmt.Errorf("failed to save composition variant %d: %w", i, err)
+			}
+			clipIDs := make([]uint, len(result.SelectedClips))
+			for j, segment := range result.SelectedClips {
+				clipIDs[j] = segment.ClipID
+			}
+			if err := models.SetClipUsages(tx, composition.ProjectID, &composition.ID, clipIDs); err != nil {
+				return fmt.Errorf("failed to record clips of composition variant %d: %w", i, err)
+			}
+
+			task.Logger().Infof("Project %d composition %d (variant %d): %d clips, quality %.2f, cohesion %.2f",
+				composition.ProjectID, composition.ID, composition.Variant, len(result.SelectedClips), result.QualityScore, result.CohesionScore)
+			compositionIDs = append(compositionIDs, composition.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	realtime.Publish(pending.UserID, realtime.EventCompositionReady, map[string]interface{}{
+		"composition_id":  pending.ID,
+		"project_id":      pending.ProjectID,
+		"composition_ids": compositionIDs,
+	})
+	emitProjectWebhookEvent(db, pending.UserID, pending.ProjectID, models.WebhookEventCompositionReady, map[string]interface{}{
+		"composition_id":  pending.ID,
+		"project_id":      pending.ProjectID,
+		"composition_ids": compositionIDs,
+	})
+
 	return nil
 }
 
-func RenderTaskHandler(task *Task) error {
-	taskID, ok := task.Payload["task_id"].(string)
-	if !ok {
-		return fmt.Errorf("invalid task_id in task payload")
+func generateCompositionVariants(task *Task, projectID uint) ([]*video_engine.CompositionResult, error) {
+	var requirements video_engine.CompositionRequirements
+	if err := decodePayloadField(task.Payload["requirements"], &requirements); err != nil {
+		return nil, fmt.Errorf("invalid requirements in task payload: %w", err)
 	}
 
-	logger.Infof("Rendering video for task %s", taskID)
-	
-	// TODO: Implement video rendering logic
-	// This would include:
-	// - Fetching render parameters
-	// - Running FFmpeg commands
-	// - Progress tracking
-	// - Result storage
-
-	time.Sleep(10 * time.Second) // Simulate rendering time
-	
-	return nil
+	variants := 1
+	if v, ok := task.Payload["variants"].(float64); ok && v > 0 {
+		variants = int(v)
+	}
+
+	var algorithms []string
+	if err := decodePayloadField(task.Payload["algorithms"], &algorithms); err != nil {
+		return nil, fmt.Errorf("invalid algorithms in task payload: %w", err)
+	}
+
+	task.Logger().Infof("Generating %d smart composition variant(s) for project %d", variants, projectID)
+
+	db := database.GetDB()
+
+	var project models.Project
+	if err := db.First(&project, projectID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load project %d: %w", projectID, err)
+	}
+
+	// Size the composition from the attached soundtrack, if any
+	var track *video_engine.MusicTrack
+	if requirements.MusicClipID != nil {
+		var music models.AtomicClip
+		if err := db.Preload("MusicAnalysis").Where("user_id = ?", project.UserID).First(&music, *requirements.MusicClipID).Error; err != nil {
+			return nil, fmt.Errorf("failed to load music clip %d: %w", *requirements.MusicClipID, err)
+		}
+		track = video_engine.MusicTrackFromClip(music)
+	}
+	if err := video_engine.ResolveTargetDuration(&requirements, track); err != nil {
+		return nil, err
+	}
+
+	var pool *gorm.DB
+	if collectionID, ok := task.Payload["collection_id"].(float64); ok {
+		// Membership was checked against the collection's workspace when
+		// the clips were added
+		pool = db.Where("id IN (?)", db.Model(&models.CollectionItem{}).
+			Select("atomic_clip_id").Where("collection_id = ?", uint(collectionID)))
+	} else {
+		pool = db.Where("user_id = ?", project.UserID)
+	}
+	if includeStock, _ := task.Payload["include_stock"].(bool); includeStock {
+		pool = pool.Or(models.StockScope(project.UserID)(db))
+	}
+	query := db.Preload("VideoAnalysis").Where("status = ?", "active").Where(pool).Scopes(models.ModerationAllowed)
+	if requirements.MusicClipID != nil {
+		query = query.Where("id <> ?", *requirements.MusicClipID)
+	}
+
+	var clips []models.AtomicClip
+	if err := query.Find(&clips).Error; err != nil {
+		return nil, fmt.Errorf("failed to load clips for project %d: %w", project.ID, err)
+	}
+	if len(clips) == 0 {
+		return nil, fmt.Errorf("no clips available for project %d", project.ID)
+	}
+
+	// Apply learned preferences from the user's edits to earlier compositions
+	var feedback []models.CompositionFeedback
+	if err := db.Where("user_id = ? AND theme = ?", project.UserID, requirements.Theme).
+		Order("created_at DESC").Limit(feedbackHistoryLimit).
+		Find(&feedback).Error; err != nil {
+		task.Logger().Warnf("Failed to load composition feedback for user %d: %v", project.UserID, err)
+	}
+	requirements.Adjustments = video_engine.BuildScoreAdjustments(feedback)
+
+	compositor := video_engine.NewSmartCompositor(clips, requirements)
+	results, err := compositor.GenerateVariants(context.Background(), variants, algorithms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate composition variants: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no composition could be generated for project %d", project.ID)
+	}
+
+	return results, nil
 }
 
 func AnalysisTaskHandler(task *Task) error {
@@ -363,17 +806,102 @@ func AnalysisTaskHandler(task *Task) error {
 		return fmt.Errorf("invalid analysis_type in task payload")
 	}
 
-	logger.Infof("Analyzing clip %d with type %s", uint(clipID), analysisType)
-	
-	// TODO: Implement video analysis logic
-	// This would include:
-	// - Content analysis
-	// - AI-based tagging
-	// - Motion detection
-	// - Color analysis
-
-	time.Sleep(3 * time.Second) // Simulate analysis time
-	
+	// Tasks queued before versions were tracked carry none
+	version, _ := task.Payload["analysis_version"].(string)
+	if version == "" {
+		version = models.DefaultAnalysisVersion
+	}
+
+	task.Logger().Infof("Analyzing clip %d with type %s (version %s)", uint(clipID), analysisType, version)
+
+	db := database.GetDB()
+
+	var clip models.AtomicClip
+	if err := db.First(&clip, uint(clipID)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Deleted while queued, nothing to analyze
+			task.Logger().Warnf("Clip %d no longer exists, skipping analysis", uint(clipID))
+			return nil
+		}
+		return fmt.Errorf("failed to load clip %d: %w", uint(clipID), err)
+	}
+
+	updates, err := analyzeClip(video_engine.NewFFmpegProcessor(config.AppConfig), &clip, analysisType)
+	if err != nil {
+		return fmt.Errorf("failed to analyze clip %d: %w", clip.ID, err)
+	}
+	updates["analysis_version"] = version
+	updates["processed_at"] = time.Now()
+
+	var analysis models.VideoAnalysis
+	if err := db.Where(models.VideoAnalysis{AtomicClipID: clip.ID}).
+		Assign(updates).
+		FirstOrCreate(&analysis).Error; err != nil {
+		return fmt.Errorf("failed to store analysis for clip %d: %w", clip.ID, err)
+	}
+	// Detections are kept on the clip, for crops, compositions and search
+	_, facesDetected := updates["face_count"]
+	_, textRead := updates["has_text"]
+	if facesDetected || textRead {
+		if err := db.Model(&clip).Update("metadata", clip.Metadata).Error; err != nil {
+			return fmt.Errorf("failed to store detections for clip %d: %w", clip.ID, err)
+		}
+	}
+	reindex := false
+	if objects.Enabled() && (analysisType == models.AnalysisTypeFull || analysisType == models.AnalysisTypeContent) {
+		if err := db.Model(&clip).UpdateColumn("objects", clip.Objects).Error; err != nil {
+			return fmt.Errorf("failed to store objects of clip %d: %w", clip.ID, err)
+		}
+		reindex = true
+	}
+	// The uploader's color label wins over the measured one
+	if colors, _ := updates["dominant_colors"].(models.StringArray); clip.Color == "" && len(colors) > 0 {
+		if err := db.Model(&clip).UpdateColumn("color", colors[0]).Error; err != nil {
+			task.Logger().Warnf("Failed to label the color of clip %d: %v", clip.ID, err)
+		} else {
+			clip.Color = colors[0]
+			reindex = true
+		}
+	}
+	if reindex {
+		if err := PublishSearchIndexTask([]uint{clip.ID}, task.TraceID); err != nil {
+			task.Logger().Warnf("Failed to queue indexing of clip %d: %v", clip.ID, err)
+		}
+	}
+	// Scored again so clips created before the score sort by it too
+	if err := db.Model(&clip).UpdateColumn("quality_score", clip.TechnicalQuality()).Error; err != nil {
+		task.Logger().Warnf("Failed to score quality of clip %d: %v", clip.ID, err)
+	}
+	// The embedding service is retried like the other AI calls
+	if embedding.Enabled() {
+		vector, err := embedding.EmbedClip(&clip, &analysis)
+		if err != nil {
+			return fmt.Errorf("failed to embed clip %d: %w", clip.ID, err)
+		}
+		if err := db.Model(&analysis).UpdateColumn("embedding", models.Float64Array(vector)).Error; err != nil {
+			return fmt.Errorf("failed to store embedding for clip %d: %w", clip.ID, err)
+		}
+	}
+	cache.InvalidateClip(clip.ID)
+	cache.InvalidateClipSearches()
+	if hash, _ := updates["perceptual_hash"].(string); hash != "" {
+		warnNearDuplicates(task, &clip, hash)
+	}
+	if suggestions.Enabled() && analysisType == models.AnalysisTypeFull && analysis.AIDescription == "" {
+		suggestMetadata(task, &clip, &analysis)
+	}
+
+	realtime.Publish(clip.UserID, realtime.EventAnalysisCompleted, map[string]interface{}{
+		"clip_id":          clip.ID,
+		"analysis_type":    analysisType,
+		"analysis_version": version,
+	})
+	EmitWebhookEvent(clip.UserID, clip.OrganizationID, models.WebhookEventClipAnalyzed, map[string]interface{}{
+		"clip_id":          clip.ID,
+		"analysis_type":    analysisType,
+		"analysis_version": version,
+	})
+
 	return nil
 }
 
@@ -388,7 +916,7 @@ func ThumbnailTaskHandler(task *Task) error {
 		return fmt.Errorf("invalid file_path in task payload")
 	}
 
-	logger.Infof("Generating thumbnail for clip %d: %s", uint(clipID), filePath)
+	task.Logger().Infof("Generating thumbnail for clip %d: %s", uint(clipID), filePath)
 	
 	// TODO: Implement thumbnail generation logic
 	// This would use FFmpeg to extract frames
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"context"
+	"sync"
+
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/logger"
+)
+
+// activeRenders holds the cancel function of every render running in this
+// process, keyed by render task ID.
+var activeRenders = struct {
+	sync.Mutex
+	cancels map[string]context.CancelFunc
+}{cancels: make(map[string]context.CancelFunc)}
+
+// registerRender returns a context that is cancelled when any of the given
+// render tasks is cancelled, and a release function to call once the render
+// finishes. Outputs rendered together share one context.
+func registerRender(taskIDs ...string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	activeRenders.Lock()
+	for _, taskID := range taskIDs {
+		activeRenders.cancels[taskID] = cancel
+	}
+	activeRenders.Unlock()
+
+	release := func() {
+		activeRenders.Lock()
+		for _, taskID := range taskIDs {
+			delete(activeRenders.cancels, taskID)
+		}
+		activeRenders.Unlock()
+		cancel()
+	}
+	return ctx, release
+}
+
+// CancelRender stops the render running in this process for the task, which
+// kills its FFmpeg process. It reports whether a render was found.
+func CancelRender(taskID string) bool {
+	activeRenders.Lock()
+	cancel, ok := activeRenders.cancels[taskID]
+	activeRenders.Unlock()
+
+	if ok {
+		cancel()
+		logger.Infof("Render task %s cancelled on this worker", taskID)
+	}
+	return ok
+}
+
+// PublishRenderCancel asks whichever worker owns the render to stop it.
+func PublishRenderCancel(taskID string) error {
+	if cache.Cache == nil {
+		// Without Redis only renders in this process can be reached
+		CancelRender(taskID)
+		return nil
+	}
+	return cache.Cache.Publish(cache.RenderCancelChannel, taskID)
+}
+
+// ListenForRenderCancellations stops local renders as cancellation requests
+// arrive from any API instance, until ctx is done.
+func ListenForRenderCancellations(ctx context.Context) {
+	if cache.Cache == nil {
+		logger.Warn("Redis is not initialized, render cancellation only reaches local renders")
+		return
+	}
+
+	pubsub := cache.Cache.Subscribe(ctx, cache.RenderCancelChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	logger.Infof("Listening for render cancellations on %s", cache.RenderCancelChannel)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			CancelRender(msg.Payload)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"time"
+
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/realtime"
+	"creative-studio-server/pkg/video_engine"
+)
+
+const (
+	// renderProgressInterval throttles progress writes while a render is running
+	renderProgressInterval = 2 * time.Second
+	// renderCheckpointTTL is how long chunks of an unfinished render are kept
+	// for a retry
+	renderCheckpointTTL = 7 * 24 * time.Hour
+)
+
+// PublishRenderGroupTask queues every output of a render group as one job.
+func PublishRenderGroupTask(groupID string, priority int, traceID string) error {
+	task := CreateTask(TaskTypeRenderVideo, map[string]interface{}{
+		"group_id": groupID,
+	}, priority)
+	task.TraceID = traceID
+
+	return enqueue("render_tasks", task)
+}
+
+// RenderTaskHandler renders a single render task, or every output of a
+// render group in one FFmpeg pass when the payload carries a group_id.
+func RenderTaskHandler(task *Task) error {
+	db := database.GetDB()
+
+	var renderTasks []models.RenderTask
+	if groupID, _ := task.Payload["group_id"].(string); groupID != "" {
+		if err := db.Where("group_id = ?", groupID).Order("id").Find(&renderTasks).Error; err != nil {
+			return fmt.Errorf("failed to load render group %s: %w", groupID, err)
+		}
+		if len(renderTasks) == 0 {
+			return fmt.Errorf("render group %s has no tasks", groupID)
+		}
+	} else {
+		taskID, ok := task.Payload["task_id"].(string)
+		if !ok {
+			return fmt.Errorf("invalid task_id in task payload")
+		}
+
+		var renderTask models.RenderTask
+		if err := db.Where("task_id = ?", taskID).First(&renderTask).Error; err != nil {
+			return fmt.Errorf("failed to load render task %s: %w", taskID, err)
+		}
+		renderTasks = append(renderTasks, renderTask)
+	}
+
+	return runRenderTasks(task, renderTasks)
+}
+
+// runRenderTasks claims the given tasks of one project and renders them
+// together. Tasks cancelled before or during the render are left cancelled.
+func runRenderTasks(task *Task, renderTasks []models.RenderTask) error {
+	db := database.GetDB()
+
+	taskIDs := make([]string, 0, len(renderTasks))
+	for _, renderTask := range renderTasks {
+		taskIDs = append(taskIDs, renderTask.TaskID)
+	}
+
+	// Register before claiming the tasks so a cancel arriving in between is seen
+	ctx, release := registerRender(taskIDs...)
+	defer release()
+
+	startedAt := time.Now()
+	claimed := make([]*models.RenderTask, 0, len(renderTasks))
+	for i := range renderTasks {
+		renderTask := &renderTasks[i]
+		if renderTask.Status == models.RenderStatusCompleted {
+			continue
+		}
+
+		claim := db.Model(renderTask).Where("status <> ?", models.RenderStatusCancelled).Updates(map[string]interface{}{
+			"status":        models.RenderStatusProcessing,
+			"progress":      0,
+			"started_at":    startedAt,
+			"error_message": "",
+		})
+		if claim.Error != nil {
+			return fmt.Errorf("failed to mark render task %s as processing: %w", renderTask.TaskID, claim.Error)
+		}
+		if claim.RowsAffected == 0 {
+			task.Logger().Infof("Skipping cancelled render task %s", renderTask.TaskID)
+			continue
+		}
+		renderTask.StartedAt = &startedAt
+		claimed = append(claimed, renderTask)
+	}
+	if len(claimed) == 0 {
+		return nil
+	}
+
+	task.Logger().Infof("Rendering %d output(s) for project %d (mode: %s)", len(claimed), claimed[0].ProjectID, claimed[0].Mode)
+
+	outputs, err := renderProject(ctx, claimed)
+	if errors.Is(err, context.Canceled) {
+		task.Logger().Infof("Render of project %d stopped after cancellation", claimed[0].ProjectID)
+		return nil
+	}
+	if err != nil {
+		for _, renderTask := range claimed {
+			db.Model(renderTask).Where("status = ?", models.RenderStatusProcessing).Updates(map[string]interface{}{
+				"status":        models.RenderStatusFailed,
+				"error_message": err.Error(),
+				"completed_at":  time.Now(),
+			})
+			realtime.Publish(renderTask.UserID, realtime.EventRenderFailed, map[string]interface{}{
+				"render_task_id": renderTask.ID,
+				"task_id":        renderTask.TaskID,
+				"group_id":       renderTask.GroupID,
+				"error":          err.Error(),
+			})
+			// Webhooks only hear of the render once it is not retried
+			if task.Retry >= task.MaxRetry {
+				emitProjectWebhookEvent(db, renderTask.UserID, renderTask.ProjectID, models.WebhookEventRenderFailed, map[string]interface{}{
+					"render_task_id": renderTask.ID,
+					"task_id":        renderTask.TaskID,
+					"project_id":     renderTask.ProjectID,
+					"group_id":       renderTask.GroupID,
+					"error":          err.Error(),
+				})
+			}
+		}
+		return fmt.Errorf("render of project %d failed: %w", claimed[0].ProjectID, err)
+	}
+
+	processor := video_engine.NewFFmpegProcessor(config.AppConfig)
+	for i, renderTask := range claimed {
+		outputPath := outputs[i].Path
+
+		duration := 0.0
+		if info, err := processor.GetVideoInfo(outputPath); err == nil {
+			duration = info.Duration
+		}
+		size := video_engine.RenderOutputSize(outputPath)
+
+		result := db.Model(renderTask).Where("status = ?", models.RenderStatusProcessing).Updates(map[string]interface{}{
+			"status":       models.RenderStatusCompleted,
+			"progress":     100,
+			"output_path":  outputPath,
+			"file_size":    size,
+			"duration":     duration,
+			"completed_at": time.Now(),
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to store render result for task %s: %w", renderTask.TaskID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			// Cancelled while the rest of its group kept rendering
+			video_engine.RemoveRenderOutput(outputPath)
+			continue
+		}
+
+		// Previews are cheap and do not count against the render quota
+		if renderTask.Mode != models.RenderModePreview {
+			if err := models.RecordUsage(db, renderTask.UserID, models.UsageRenderSeconds, int64(math.Ceil(duration)), time.Now()); err != nil {
+				task.Logger().Warnf("Failed to record render usage of task %s: %v", renderTask.TaskID, err)
+			}
+		}
+
+		realtime.Publish(renderTask.UserID, realtime.EventRenderCompleted, map[string]interface{}{
+			"render_task_id": renderTask.ID,
+			"task_id":        renderTask.TaskID,
+			"group_id":       renderTask.GroupID,
+			"output_path":    outputPath,
+			"file_size":      size,
+			"duration":       duration,
+		})
+		emitProjectWebhookEvent(db, renderTask.UserID, renderTask.ProjectID, models.WebhookEventRenderCompleted, map[string]interface{}{
+			"render_task_id": renderTask.ID,
+			"task_id":        renderTask.TaskID,
+			"project_id":     renderTask.ProjectID,
+			"group_id":       renderTask.GroupID,
+			"file_size":      size,
+			"duration":       duration,
+		})
+		task.Logger().Infof("Render task %s completed in %s: %s", renderTask.TaskID, time.Since(startedAt).Round(time.Second), outputPath)
+	}
+
+	return nil
+}
+
+// renderProject renders the project timeline once into every task's output,
+// recording progress on the tasks as FFmpeg reports it.
+func renderProject(ctx context.Context, renderTasks []*models.RenderTask) ([]video_engine.RenderOutput, error) {
+	db := database.GetDB()
+	first := renderTasks[0]
+
+	var project models.Project
+	if err := db.First(&project, first.ProjectID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load project %d: %w", first.ProjectID, err)
+	}
+
+	timeline, err := models.ParseProjectTimeline(project.Timeline)
+	if err != nil {
+		return nil, err
+	}
+
+	clipIDs := make([]uint, 0, len(timeline.Clips))
+	for _, entry := range timeline.Clips {
+		clipIDs = append(clipIDs, entry.ClipID)
+	}
+
+	var clips []models.AtomicClip
+	if len(clipIDs) > 0 {
+		query := db.Preload("VideoAnalysis")
+		// Segments are captioned from the transcripts loaded with their clips
+		if first.Captions {
+			query = query.Preload("Transcript")
+		}
+		if err := query.Where("id IN ?", clipIDs).
+			Where(db.Where("user_id = ?", project.UserID).Or(models.StockScope(project.UserID)(db))).
+			Find(&clips).Error; err != nil {
+			return nil, fmt.Errorf("failed to load timeline clips: %w", err)
+		}
+	}
+	clipIndex := make(map[uint]models.AtomicClip, len(clips))
+	for _, clip := range clips {
+		clipIndex[clip.ID] = clip
+	}
+
+	segments, err := video_engine.BuildRenderSegments(timeline, clipIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode once at the project canvas (or the preview size) and the highest
+	// requested frame rate, then scale per output
+	canvas := video_engine.RenderOptionsForTask(models.RenderTask{Mode: first.Mode}, project)
+	outputDir := filepath.Join(config.AppConfig.Storage.OutputPath, "renders")
+
+	// Finished chunks are kept per group (or task) so a retry resumes from them
+	checkpointRoot := filepath.Join(outputDir, "checkpoints")
+	video_engine.PruneRenderCheckpoints(checkpointRoot, renderCheckpointTTL)
+	checkpointKey := first.TaskID
+	if first.GroupID != "" {
+		checkpointKey = first.GroupID
+	}
+	outputs := make([]video_engine.RenderOutput, 0, len(renderTasks))
+	for _, renderTask := range renderTasks {
+		options := video_engine.RenderOptionsForTask(*renderTask, project)
+		if options.FrameRate > canvas.FrameRate {
+			canvas.FrameRate = options.FrameRate
+		}
+		if work := video_engine.RenderWork(segments, clipIndex, options); work != renderTask.Work {
+			renderTask.Work = work
+			db.Model(renderTask).Update("work", work)
+		}
+		outputs = append(outputs, video_engine.RenderOutput{
+			Path:    video_engine.RenderOutputPath(outputDir, renderTask.TaskID, options.OutputFormat),
+			Options: options,
+		})
+	}
+
+	// Persist progress in whole percent, at most every few seconds
+	lastProgress := 0
+	lastUpdate := time.Now()
+	onProgress := func(progress float64) {
+		percent := int(progress * 100)
+		if percent <= lastProgress || (percent < 100 && time.Since(lastUpdate) < renderProgressInterval) {
+			return
+		}
+		lastProgress = percent
+		lastUpdate = time.Now()
+
+		for _, renderTask := range renderTasks {
+			// Refine the total estimate from the observed rate
+			updates := map[string]interface{}{"progress": percent}
+			if renderTask.StartedAt != nil && progress > 0 {
+				updates["estimated_time"] = int(time.Since(*renderTask.StartedAt).Seconds() / progress)
+			}
+			db.Model(renderTask).Where("status = ?", models.RenderStatusProcessing).Updates(updates)
+			realtime.Publish(renderTask.UserID, realtime.EventRenderProgress, map[string]interface{}{
+				"render_task_id": renderTask.ID,
+				"task_id":        renderTask.TaskID,
+				"group_id":       renderTask.GroupID,
+				"progress":       percent,
+			})
+		}
+	}
+
+	processor := video_engine.NewFFmpegProcessor(config.AppConfig)
+	checkpointDir := filepath.Join(checkpointRoot, checkpointKey)
+	if err := processor.RenderOutputsCheckpointed(ctx, segments, canvas, outputs, checkpointDir, onProgress); err != nil {
+		// Don't leave truncated files behind when FFmpeg was killed; finished
+		// chunks stay in the checkpoint for a retry
+		for _, output := range outputs {
+			video_engine.RemoveRenderOutput(output.Path)
+		}
+		return nil, err
+	}
+
+	return outputs, nil
+}
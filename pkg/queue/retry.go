@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// RetryPolicy controls how a failed task of one type is retried. The n-th
+// retry waits BaseDelay * 2^(n-1), capped at MaxDelay, spread by ±Jitter so
+// tasks that failed together do not all come back at once.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	Jitter     float64 // fraction of the delay, 0-1
+}
+
+var defaultRetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: 10 * time.Second, MaxDelay: 5 * time.Minute, Jitter: 0.2}
+
+// retryPolicies overrides the default policy per task type
+var retryPolicies = map[string]RetryPolicy{
+	// Renders are expensive and usually fail for reasons that take a while to clear
+	TaskTypeRenderVideo:   {MaxRetries: 2, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute, Jitter: 0.2},
+	TaskTypeRenderPreview: {MaxRetries: 2, BaseDelay: 15 * time.Second, MaxDelay: 2 * time.Minute, Jitter: 0.2},
+	// Analysis calls out to external AI services, back off harder
+	TaskTypeAnalyzeVideo:    {MaxRetries: 5, BaseDelay: 30 * time.Second, MaxDelay: 15 * time.Minute, Jitter: 0.3},
+	TaskTypeTranscribeAudio: {MaxRetries: 5, BaseDelay: 30 * time.Second, MaxDelay: 15 * time.Minute, Jitter: 0.3},
+	TaskTypeModerateClip:    {MaxRetries: 5, BaseDelay: 30 * time.Second, MaxDelay: 15 * time.Minute, Jitter: 0.3},
+	// Receiving endpoints can be down for a while; retries span about four hours
+	TaskTypeDeliverWebhook: {MaxRetries: 8, BaseDelay: time.Minute, MaxDelay: 6 * time.Hour, Jitter: 0.2},
+}
+
+// maxRetryLevels is how many retry queues are declared per work queue
+const maxRetryLevels = 5
+
+// RetryPolicyFor returns the retry policy of a task type.
+func RetryPolicyFor(taskType string) RetryPolicy {
+	if policy, ok := retryPolicies[taskType]; ok {
+		return policy
+	}
+	return defaultRetryPolicy
+}
+
+// Delay is the wait before the given retry attempt, starting at 1.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 - p.Jitter + rand.Float64()*2*p.Jitter))
+	}
+	return delay
+}
+
+// retryQueueName is the holding queue for a work queue's n-th retry. Each
+// attempt gets its own queue so per-message expirations within a queue stay
+// close together; expired messages dead-letter back to the work queue.
+func retryQueueName(queueName string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", queueName, min(attempt, maxRetryLevels))
+}
+
+func declareRetryQueues(ch *amqp.Channel, queueName string) error {
+	for attempt := 1; attempt <= maxRetryLevels; attempt++ {
+		_, err := ch.QueueDeclare(
+			retryQueueName(queueName, attempt),
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			amqp.Table{
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queueName,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare retry queue for %s: %w", queueName, err)
+		}
+	}
+	return nil
+}
+
+// parkTask holds the task in a retry queue for delay before it returns to
+// queueName.
+func (r *RabbitMQClient) parkTask(queueName string, task *Task, attempt int, delay time.Duration) error {
+	expiration := strconv.FormatInt(delay.Milliseconds(), 10)
+	return r.publishTask(retryQueueName(queueName, max(attempt, 1)), task, expiration)
+}
@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/logger"
+)
+
+// queueSettings holds the effective settings of every queue: the config,
+// overlaid with the runtime overrides saved in Redis.
+var queueSettings = struct {
+	sync.RWMutex
+	byQueue map[string]config.QueueSettings
+}{byQueue: make(map[string]config.QueueSettings)}
+
+// loadSettings starts from the configured settings and applies the runtime
+// overrides, so a restarted worker keeps what admins changed.
+func loadSettings(cfg *config.Config) {
+	queueSettings.Lock()
+	defer queueSettings.Unlock()
+
+	for name, settings := range cfg.Queue.Queues {
+		queueSettings.byQueue[name] = settings
+	}
+	if cache.Cache == nil {
+		return
+	}
+
+	overrides, err := cache.Cache.GetAllHash(cache.QueueSettingsKey)
+	if err != nil {
+		logger.Warnf("Failed to load queue settings overrides: %v", err)
+		return
+	}
+	for name, data := range overrides {
+		var settings config.QueueSettings
+		if err := json.Unmarshal([]byte(data), &settings); err != nil {
+			logger.Warnf("Ignoring bad settings override for queue %s: %v", name, err)
+			continue
+		}
+		if _, ok := queueSettings.byQueue[name]; ok {
+			queueSettings.byQueue[name] = settings
+		}
+	}
+}
+
+// SettingsFor returns the effective settings of a queue.
+func SettingsFor(queueName string) config.QueueSettings {
+	queueSettings.RLock()
+	defer queueSettings.RUnlock()
+
+	if settings, ok := queueSettings.byQueue[queueName]; ok {
+		return settings
+	}
+	return config.QueueSettings{Concurrency: 1, MaxRetries: -1}
+}
+
+// AllSettings returns the effective settings of every queue.
+func AllSettings() map[string]config.QueueSettings {
+	queueSettings.RLock()
+	defer queueSettings.RUnlock()
+
+	all := make(map[string]config.QueueSettings, len(queueSettings.byQueue))
+	for name, settings := range queueSettings.byQueue {
+		all[name] = settings
+	}
+	return all
+}
+
+// UpdateSettings saves new settings for a queue and has every worker
+// consuming it apply them.
+func UpdateSettings(queueName string, settings config.QueueSettings) error {
+	queueSettings.RLock()
+	_, ok := queueSettings.byQueue[queueName]
+	queueSettings.RUnlock()
+	if !ok {
+		return errors.New("queue not found")
+	}
+
+	if cache.Cache == nil {
+		// Without Redis only this process can be reached
+		applySettings(queueName, settings)
+		return nil
+	}
+
+	queueSettings.Lock()
+	queueSettings.byQueue[queueName] = settings
+	queueSettings.Unlock()
+
+	if err := cache.Cache.SetHash(cache.QueueSettingsKey, queueName, settings); err != nil {
+		logger.Errorf("Failed to save queue settings: %v", err)
+		return errors.New("failed to save queue settings")
+	}
+	if err := cache.Cache.Publish(cache.QueueSettingsChannel, queueName); err != nil {
+		logger.Errorf("Failed to announce queue settings: %v", err)
+		return errors.New("failed to save queue settings")
+	}
+	return nil
+}
+
+func applySettings(queueName string, settings config.QueueSettings) {
+	queueSettings.Lock()
+	queueSettings.byQueue[queueName] = settings
+	queueSettings.Unlock()
+
+	if Queue == nil {
+		return
+	}
+	if err := Queue.SetConcurrency(queueName, settings.Concurrency, settings.Prefetch); err != nil {
+		logger.Errorf("Failed to resize workers for queue %s: %v", queueName, err)
+	}
+}
+
+// ListenForSettingsChanges applies queue settings saved from any API
+// instance, until ctx is done. API-only processes listen too, to keep the
+// retry limits they publish with up to date.
+func ListenForSettingsChanges(ctx context.Context) {
+	if cache.Cache == nil {
+		return
+	}
+
+	pubsub := cache.Cache.Subscribe(ctx, cache.QueueSettingsChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			queueName := msg.Payload
+			data, err := cache.Cache.GetHash(cache.QueueSettingsKey, queueName)
+			if err != nil {
+				logger.Warnf("Failed to load new settings of queue %s: %v", queueName, err)
+				continue
+			}
+			var settings config.QueueSettings
+			if err := json.Unmarshal([]byte(data), &settings); err != nil {
+				logger.Warnf("Ignoring bad settings for queue %s: %v", queueName, err)
+				continue
+			}
+
+			logger.Infof("Applying new settings to queue %s: %d workers, prefetch %d, max retries %d",
+				queueName, settings.Concurrency, settings.Prefetch, settings.MaxRetries)
+			applySettings(queueName, settings)
+		}
+	}
+}
+
+// applyRetryOverride replaces the task type's retry limit when the queue
+// has its own.
+func applyRetryOverride(queueName string, task *Task) {
+	if maxRetries := SettingsFor(queueName).MaxRetries; maxRetries >= 0 {
+		task.MaxRetry = maxRetries
+	}
+}
@@ -0,0 +1,102 @@
+package queue
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+// workerID identifies this process in the task table
+var workerID = func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}()
+
+// Task state is recorded on a best-effort basis: a database hiccup must
+// never stop a task from being published or run.
+
+func recordQueued(queueName string, task *Task) {
+	db := database.GetDB()
+	if db == nil {
+		return
+	}
+
+	record := models.TaskRecord{
+		TaskID:   task.ID,
+		Type:     task.Type,
+		Queue:    queueName,
+		Status:   models.TaskStatusQueued,
+		Priority: task.Priority,
+		TraceID:  task.TraceID,
+		MaxRetry: task.MaxRetry,
+		QueuedAt: task.CreatedAt,
+		Payload:  models.JSON(task.Payload),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		logger.Warnf("Failed to record queued task %s: %v", task.ID, err)
+	}
+}
+
+func recordRunning(queueName string, task *Task, startedAt time.Time) {
+	db := database.GetDB()
+	if db == nil {
+		return
+	}
+
+	// Tasks published before tracking existed have no row yet
+	record := models.TaskRecord{
+		TaskID:    task.ID,
+		Type:      task.Type,
+		Queue:     queueName,
+		Status:    models.TaskStatusRunning,
+		Priority:  task.Priority,
+		TraceID:   task.TraceID,
+		Attempts:  task.Retry + 1,
+		MaxRetry:  task.MaxRetry,
+		WorkerID:  workerID,
+		QueuedAt:  task.CreatedAt,
+		StartedAt: &startedAt,
+		Payload:   models.JSON(task.Payload),
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "attempts", "max_retry", "worker_id", "started_at", "updated_at"}),
+	}).Create(&record).Error; err != nil {
+		logger.Warnf("Failed to record running task %s: %v", task.ID, err)
+	}
+}
+
+// recordFinished stores the outcome of an attempt; retrying marks a failed
+// attempt that will run again.
+func recordFinished(task *Task, startedAt time.Time, taskErr error, retrying bool) {
+	db := database.GetDB()
+	if db == nil {
+		return
+	}
+
+	finishedAt := time.Now()
+	updates := map[string]interface{}{
+		"status":      models.TaskStatusSucceeded,
+		"finished_at": finishedAt,
+		"duration_ms": finishedAt.Sub(startedAt).Milliseconds(),
+	}
+	if taskErr != nil {
+		updates["status"] = models.TaskStatusFailed
+		updates["last_error"] = taskErr.Error()
+		if retrying {
+			updates["status"] = models.TaskStatusRetrying
+		}
+	}
+
+	if err := db.Model(&models.TaskRecord{}).Where("task_id = ?", task.ID).Updates(updates).Error; err != nil {
+		logger.Warnf("Failed to record result of task %s: %v", task.ID, err)
+	}
+}
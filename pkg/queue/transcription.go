@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/realtime"
+	"creative-studio-server/pkg/transcription"
+	"creative-studio-server/pkg/video_engine"
+)
+
+// TranscriptionTaskHandler transcribes the speech in a clip's audio and
+// stores the transcript in place of the previous one. Clips without audio
+// get an empty transcript, so they are not queued again.
+func TranscriptionTaskHandler(task *Task) error {
+	clipID, ok := task.Payload["clip_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid clip_id in task payload")
+	}
+	language, _ := task.Payload["language"].(string)
+
+	db := database.GetDB()
+
+	var clip models.AtomicClip
+	if err := db.First(&clip, uint(clipID)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			task.Logger().Warnf("Clip %d no longer exists, skipping transcription", uint(clipID))
+			return nil
+		}
+		return fmt.Errorf("failed to load clip %d: %w", uint(clipID), err)
+	}
+
+	task.Logger().Infof("Transcribing clip %d", clip.ID)
+
+	processor := video_engine.NewFFmpegProcessor(config.AppConfig)
+	info, err := processor.GetVideoInfo(clip.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to probe audio of clip %d: %w", clip.ID, err)
+	}
+
+	result := &transcription.Result{}
+	if info.HasAudio {
+		dir, err := os.MkdirTemp("", "transcription-*")
+		if err != nil {
+			return fmt.Errorf("failed to create audio directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		audioPath := filepath.Join(dir, "audio.wav")
+		if err := processor.ExtractSpeechAudio(clip.FilePath, audioPath); err != nil {
+			return fmt.Errorf("failed to extract audio of clip %d: %w", clip.ID, err)
+		}
+		if result, err = transcription.Transcribe(audioPath, language); err != nil {
+			return fmt.Errorf("failed to transcribe clip %d: %w", clip.ID, err)
+		}
+	}
+
+	transcript := models.ClipTranscript{AtomicClipID: clip.ID}
+	if err := db.Where(models.ClipTranscript{AtomicClipID: clip.ID}).
+		Assign(map[string]interface{}{
+			"language": result.Language,
+			"text":     result.Text,
+			"segments": result.Segments,
+			"provider": transcription.ProviderName(),
+		}).
+		FirstOrCreate(&transcript).Error; err != nil {
+		return fmt.Errorf("failed to store transcript of clip %d: %w", clip.ID, err)
+	}
+
+	cache.InvalidateClipSearches()
+	if err := PublishSearchIndexTask([]uint{clip.ID}, task.TraceID); err != nil {
+		task.Logger().Warnf("Failed to queue indexing of clip %d: %v", clip.ID, err)
+	}
+
+	realtime.Publish(clip.UserID, realtime.EventTranscriptReady, map[string]interface{}{
+		"clip_id":  clip.ID,
+		"language": result.Language,
+		"segments": len(result.Segments),
+	})
+	EmitWebhookEvent(clip.UserID, clip.OrganizationID, models.WebhookEventClipTranscribed, map[string]interface{}{
+		"clip_id":  clip.ID,
+		"language": result.Language,
+	})
+
+	task.Logger().Infof("Transcribed clip %d: %d segments", clip.ID, len(result.Segments))
+	return nil
+}
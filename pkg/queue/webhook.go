@@ -0,0 +1,238 @@
+package queue
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/download"
+	"creative-studio-server/pkg/logger"
+)
+
+// maxWebhookResponseBody is how much of an endpoint's response is kept in
+// the delivery log
+const maxWebhookResponseBody = 2048
+
+var (
+	webhookClient     *http.Client
+	webhookClientOnce sync.Once
+)
+
+// Headers of a webhook delivery. The signature is the hex HMAC-SHA256, with
+// the endpoint's secret, of the timestamp, a dot and the body.
+const (
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// WebhookPayload is the body of a webhook delivery.
+type WebhookPayload struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// SignWebhook returns the signature header value of a delivery body sent
+// at the given Unix time.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// EmitWebhookEvent queues a delivery of the event to every active endpoint
+// of the workspace subscribed to it: the organization's when
+// organizationID is set, the user's personal one otherwise. Failures are
+// logged; the event itself has already happened.
+func EmitWebhookEvent(userID uint, organizationID *uint, event string, data interface{}) {
+	db := database.GetDB()
+
+	query := db.Where("active = ?", true)
+	if organizationID != nil {
+		query = query.Where("organization_id = ?", *organizationID)
+	} else {
+		query = query.Where("user_id = ? AND organization_id IS NULL", userID)
+	}
+	var webhooks []models.Webhook
+	if err := query.Find(&webhooks).Error; err != nil {
+		logger.Warnf("Failed to get webhooks for %s event: %v", event, err)
+		return
+	}
+
+	var payload []byte
+	for i := range webhooks {
+		if !webhooks[i].Subscribes(event) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(WebhookPayload{Event: event, CreatedAt: time.Now(), Data: data}); err != nil {
+				logger.Warnf("Failed to encode %s webhook payload: %v", event, err)
+				return
+			}
+		}
+		if _, err := QueueWebhookDelivery(db, webhooks[i].ID, event, payload); err != nil {
+			logger.Warnf("Failed to queue %s delivery to webhook %d: %v", event, webhooks[i].ID, err)
+		}
+	}
+}
+
+// QueueWebhookDelivery records a pending delivery of the payload and queues
+// it to be sent.
+func QueueWebhookDelivery(db *gorm.DB, webhookID uint, event string, payload []byte) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{
+		WebhookID: webhookID,
+		Event:     event,
+		Payload:   payload,
+		Status:    models.WebhookDeliveryPending,
+	}
+	if err := db.Create(delivery).Error; err != nil {
+		return nil, err
+	}
+	if err := PublishWebhookDeliveryTask(delivery.ID); err != nil {
+		db.Model(delivery).Updates(map[string]interface{}{
+			"status": models.WebhookDeliveryFailed,
+			"error":  "failed to queue delivery",
+		})
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// PublishWebhookDeliveryTask queues an attempt to send a delivery.
+func PublishWebhookDeliveryTask(deliveryID uint) error {
+	task := CreateTask(TaskTypeDeliverWebhook, map[string]interface{}{
+		"delivery_id": deliveryID,
+	}, 5)
+
+	return enqueue("webhooks", task)
+}
+
+// WebhookDeliveryHandler sends a delivery to its endpoint and records the
+// outcome. Failed attempts are retried with the queue's backoff; the last
+// one marks the delivery failed.
+func WebhookDeliveryHandler(task *Task) error {
+	deliveryID, ok := task.Payload["delivery_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid delivery_id in task payload")
+	}
+
+	db := database.GetDB()
+
+	var delivery models.WebhookDelivery
+	if err := db.First(&delivery, uint(deliveryID)).Error; err != nil {
+		return fmt.Errorf("failed to load webhook delivery %d: %w", uint(deliveryID), err)
+	}
+	if delivery.Status == models.WebhookDeliverySucceeded || delivery.Status == models.WebhookDeliveryFailed {
+		return nil
+	}
+
+	// Endpoints deleted or turned off meanwhile get nothing more
+	var webhook models.Webhook
+	if err := db.First(&webhook, delivery.WebhookID).Error; err != nil || !webhook.Active {
+		return db.Model(&delivery).Updates(map[string]interface{}{
+			"status": models.WebhookDeliveryFailed,
+			"error":  "webhook is deleted or inactive",
+		}).Error
+	}
+
+	startedAt := time.Now()
+	statusCode, body, sendErr := sendWebhook(&webhook, &delivery)
+	updates := map[string]interface{}{
+		"attempts":        delivery.Attempts + 1,
+		"response_status": statusCode,
+		"response_body":   body,
+		"duration_ms":     time.Since(startedAt).Milliseconds(),
+		"error":           "",
+	}
+	if sendErr == nil {
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = time.Now()
+	} else {
+		updates["error"] = sendErr.Error()
+		updates["status"] = models.WebhookDeliveryRetrying
+		if task.Retry >= task.MaxRetry {
+			updates["status"] = models.WebhookDeliveryFailed
+		}
+	}
+	if err := db.Model(&delivery).Updates(updates).Error; err != nil {
+		task.Logger().Warnf("Failed to record attempt of webhook delivery %d: %v", delivery.ID, err)
+	}
+
+	if sendErr != nil {
+		return fmt.Errorf("webhook delivery %d to %s failed: %w", delivery.ID, webhook.URL, sendErr)
+	}
+	task.Logger().Infof("Webhook delivery %d (%s) sent to webhook %d", delivery.ID, delivery.Event, webhook.ID)
+	return nil
+}
+
+// sendWebhook posts the signed delivery. Anything but a 2xx response is a
+// failure.
+func sendWebhook(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CreativeStudio-Webhooks/1.0")
+	req.Header.Set(WebhookHeaderEvent, delivery.Event)
+	req.Header.Set(WebhookHeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookHeaderSignature, SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := getWebhookClient().Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	// Cut short, the body may end within a character
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	body := strings.ToValidUTF8(string(raw), "")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, body, fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// getWebhookClient returns the client every delivery is sent with, built
+// on first use so its connections are reused.
+func getWebhookClient() *http.Client {
+	webhookClientOnce.Do(func() {
+		cfg := config.AppConfig.Webhook
+		webhookClient = download.NewClient(cfg.AllowPrivate, cfg.Timeout)
+		// Following a redirect would resend the event somewhere not registered
+		webhookClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	})
+	return webhookClient
+}
+
+// emitProjectWebhookEvent emits an event about a project to the workspace
+// the project belongs to.
+func emitProjectWebhookEvent(db *gorm.DB, userID, projectID uint, event string, data interface{}) {
+	var project models.Project
+	if err := db.Select("id", "organization_id").First(&project, projectID).Error; err != nil {
+		logger.Warnf("Failed to get project %d for %s webhooks: %v", projectID, event, err)
+		return
+	}
+	EmitWebhookEvent(userID, project.OrganizationID, event, data)
+}
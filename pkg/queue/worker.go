@@ -0,0 +1,92 @@
+package queue
+
+import (
+	"time"
+
+	"creative-studio-server/pkg/metrics"
+)
+
+// taskParker holds a task back for a while before it is delivered again;
+// each backend implements it with its own delayed-delivery mechanism.
+type taskParker interface {
+	parkTask(queueName string, task *Task, attempt int, delay time.Duration) error
+}
+
+type taskOutcome int
+
+const (
+	// taskDone means the delivery is finished with: the task ran, was
+	// skipped as a duplicate, or was parked for a retry
+	taskDone taskOutcome = iota
+	// taskDeadLetter means the task failed for good
+	taskDeadLetter
+)
+
+// runTask processes one delivered task: it skips duplicates, runs the
+// handler and schedules a delayed retry when the handler fails.
+func runTask(parker taskParker, queueName string, task *Task, handler TaskHandler) taskOutcome {
+	run, claim := claimTask(task)
+	switch claim {
+	case taskAlreadyProcessed:
+		task.Logger().Infof("Skipping task %s from queue %s: already processed", task.ID, queueName)
+		metrics.TaskDeliveries.WithLabelValues(queueName, "skipped").Inc()
+		return taskDone
+	case taskInProgress:
+		// Another worker is running it; look again later in case it dies
+		if err := parker.parkTask(queueName, task, task.Retry, duplicateRecheckDelay); err != nil {
+			task.Logger().Errorf("Failed to park duplicate delivery of task %s: %v", task.ID, err)
+			metrics.TaskDeliveries.WithLabelValues(queueName, "dead_lettered").Inc()
+			return taskDeadLetter
+		}
+		task.Logger().Infof("Task %s is already running on another worker, checking again in %s", task.ID, duplicateRecheckDelay)
+		metrics.TaskDeliveries.WithLabelValues(queueName, "skipped").Inc()
+		return taskDone
+	}
+
+	task.Logger().Infof("Processing task %s from queue %s", task.ID, queueName)
+
+	// The queue's retry limit may have changed since the task was published
+	applyRetryOverride(queueName, task)
+
+	startedAt := time.Now()
+	recordRunning(queueName, task, startedAt)
+
+	inflight := metrics.TasksInFlight.WithLabelValues(queueName)
+	inflight.Inc()
+	err := handler(task)
+	inflight.Dec()
+	run.finish(err == nil)
+
+	status := "succeeded"
+	if err != nil {
+		status = "failed"
+	}
+	metrics.TaskDuration.WithLabelValues(queueName, status).Observe(time.Since(startedAt).Seconds())
+
+	if err == nil {
+		recordFinished(task, startedAt, nil, false)
+		metrics.TaskDeliveries.WithLabelValues(queueName, "succeeded").Inc()
+		task.Logger().Infof("Task %s completed successfully", task.ID)
+		return taskDone
+	}
+
+	task.Logger().Errorf("Task %s failed: %v", task.ID, err)
+
+	// Retry after a backoff instead of requeueing immediately
+	if task.Retry < task.MaxRetry {
+		task.Retry++
+		delay := RetryPolicyFor(task.Type).Delay(task.Retry)
+		parkErr := parker.parkTask(queueName, task, task.Retry, delay)
+		if parkErr == nil {
+			recordFinished(task, startedAt, err, true)
+			metrics.TaskDeliveries.WithLabelValues(queueName, "retried").Inc()
+			task.Logger().Infof("Task %s retrying in %s (%d/%d)", task.ID, delay.Round(time.Second), task.Retry, task.MaxRetry)
+			return taskDone
+		}
+		task.Logger().Errorf("Failed to schedule retry of task %s: %v", task.ID, parkErr)
+	}
+
+	recordFinished(task, startedAt, err, false)
+	metrics.TaskDeliveries.WithLabelValues(queueName, "dead_lettered").Inc()
+	return taskDeadLetter
+}
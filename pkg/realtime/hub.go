@@ -0,0 +1,136 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+
+	"creative-studio-server/pkg/eventbus"
+	"creative-studio-server/pkg/logger"
+)
+
+// Event types pushed to clients
+const (
+	EventRenderProgress    = "render.progress"
+	EventRenderCompleted   = "render.completed"
+	EventRenderFailed      = "render.failed"
+	EventAnalysisCompleted = "analysis.completed"
+	EventTranscriptReady   = "transcript.ready"
+	EventMusicAnalyzed     = "music.analyzed"
+	EventBackfillProgress  = "analysis_backfill.progress"
+	EventClipModerated     = "clip.moderated"
+	EventClipDuplicates    = "clip.duplicates"
+	EventCompositionReady  = "composition.ready"
+	EventCompositionFailed = "composition.failed"
+	EventImportProgress    = "import.progress"
+	EventImportCompleted   = "import.completed"
+	EventCommentMention    = "comment.mention"
+	EventSavedSearchMatch  = "saved_search.match"
+)
+
+// subscriberBuffer is how many events a slow client may fall behind before
+// further events are dropped for it.
+const subscriberBuffer = 32
+
+type Event struct {
+	Type      string      `json:"type"`
+	UserID    uint        `json:"-"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// Hub fans events out to the connections each user has open.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan Event]struct{}
+}
+
+var Notifications = NewHub()
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[uint]map[chan Event]struct{}),
+	}
+}
+
+// Subscribe registers a connection for the user's events. The returned
+// function must be called when the connection closes.
+func (h *Hub) Subscribe(userID uint) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan Event]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish delivers an event to every connection of its user without
+// blocking; connections whose buffer is full miss the event.
+func (h *Hub) Publish(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+			logger.Warnf("Dropping %s event for user %d: subscriber is not keeping up", event.Type, event.UserID)
+		}
+	}
+}
+
+// busEventType carries user events over the event bus, since the user may
+// be connected to another instance than the one publishing
+const busEventType = "realtime.event"
+
+// busEvent is an Event as sent over the bus, where UserID is not hidden
+type busEvent struct {
+	Type      string      `json:"type"`
+	UserID    uint        `json:"user_id"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+func init() {
+	eventbus.Subscribe(busEventType, func(event *eventbus.Event) error {
+		var e busEvent
+		if err := event.Decode(&e); err != nil {
+			return err
+		}
+		Notifications.Publish(Event(e))
+		return nil
+	})
+}
+
+// Publish sends an event to the user's connections on every instance.
+func Publish(userID uint, eventType string, data interface{}) {
+	event := busEvent{
+		Type:      eventType,
+		UserID:    userID,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+	if err := eventbus.Publish(busEventType, event); err != nil {
+		logger.Warnf("Failed to broadcast %s event for user %d: %v", eventType, userID, err)
+	}
+}
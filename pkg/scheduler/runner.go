@@ -0,0 +1,250 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// tickInterval is how often the leader looks for due schedules, so
+	// jobs fire up to this late
+	tickInterval = 15 * time.Second
+	// leaderTTL bounds how long a crashed leader blocks the others
+	leaderTTL = 3 * tickInterval
+)
+
+// runner fires due schedules while this instance holds the leader lock.
+type runner struct {
+	workerID string
+	lock     *cache.Lock // held while leader, nil without Redis
+	leader   bool
+
+	mu      sync.Mutex
+	running map[string]bool
+	jobs    sync.WaitGroup
+}
+
+// Run persists the registered schedules and fires them until ctx is done.
+// Every worker instance runs it; a Redis lock elects the one that fires
+// jobs. Without Redis this instance assumes it is the only one.
+func Run(ctx context.Context) {
+	if database.GetDB() == nil {
+		logger.Warn("Scheduler disabled: no database")
+		return
+	}
+	if err := syncSchedules(); err != nil {
+		logger.Errorf("Failed to persist schedules: %v", err)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	r := &runner{
+		workerID: fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		running:  make(map[string]bool),
+	}
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		r.tick(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			r.jobs.Wait()
+			r.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncSchedules creates a row for every registered job that has none and
+// refreshes descriptions. Existing specs are left alone: they may have
+// been changed by an admin.
+func syncSchedules() error {
+	db := database.GetDB()
+	now := time.Now()
+
+	for _, job := range Jobs() {
+		schedule, _ := ParseSpec(job.Spec)
+		next := schedule.Next(now)
+
+		var row models.Schedule
+		if err := db.Where(models.Schedule{Name: job.Name}).
+			Attrs(models.Schedule{Spec: job.Spec, Enabled: true, NextRunAt: &next}).
+			FirstOrCreate(&row).Error; err != nil {
+			return fmt.Errorf("failed to persist schedule %s: %w", job.Name, err)
+		}
+		if row.Description != job.Description {
+			if err := db.Model(&row).Update("description", job.Description).Error; err != nil {
+				return fmt.Errorf("failed to update schedule %s: %w", job.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// elect takes or keeps the leader lock and reports whether this instance
+// is the leader.
+func (r *runner) elect() bool {
+	if cache.Cache == nil {
+		return true
+	}
+
+	if r.leader {
+		err := r.lock.Refresh()
+		if errors.Is(err, cache.ErrLockLost) {
+			logger.Warn("Lost scheduler leadership")
+			r.lock = nil
+			r.leader = false
+		} else if err != nil {
+			// Keep going: the lock outlives a short Redis hiccup
+			logger.Warnf("Failed to refresh scheduler leadership: %v", err)
+		}
+		return r.leader
+	}
+
+	lock, err := cache.Cache.Lock(cache.SchedulerLeaderKey(), leaderTTL)
+	if errors.Is(err, cache.ErrLockNotAcquired) {
+		return false
+	}
+	if err != nil {
+		logger.Warnf("Failed to acquire scheduler leadership: %v", err)
+		return false
+	}
+	logger.Info("Acquired scheduler leadership")
+	r.lock = lock
+	r.leader = true
+	return true
+}
+
+// resign hands leadership over right away instead of letting it expire.
+func (r *runner) resign() {
+	if r.lock == nil {
+		return
+	}
+	if err := r.lock.Unlock(); err != nil && !errors.Is(err, cache.ErrLockLost) {
+		logger.Warnf("Failed to release scheduler leadership: %v", err)
+	}
+	r.lock = nil
+	r.leader = false
+}
+
+func (r *runner) tick(ctx context.Context, now time.Time) {
+	if ctx.Err() != nil || !r.elect() {
+		return
+	}
+
+	db := database.GetDB()
+	var due []models.Schedule
+	if err := db.Where("enabled = ? AND (next_run_at IS NULL OR next_run_at <= ?)", true, now).
+		Find(&due).Error; err != nil {
+		logger.Errorf("Failed to load due schedules: %v", err)
+		return
+	}
+
+	for _, row := range due {
+		job, ok := Lookup(row.Name)
+		if !ok {
+			// Registered by a newer or older build
+			continue
+		}
+		schedule, err := ParseSpec(row.Spec)
+		if err != nil {
+			logger.Errorf("Skipping schedule %s: %v", row.Name, err)
+			continue
+		}
+		next := schedule.Next(now)
+
+		// Only schedule a row that has never been planned
+		if row.NextRunAt == nil {
+			if err := db.Model(&row).Update("next_run_at", next).Error; err != nil {
+				logger.Errorf("Failed to plan schedule %s: %v", row.Name, err)
+			}
+			continue
+		}
+		if r.isRunning(row.Name) {
+			continue
+		}
+
+		// Guard on next_run_at so a stale leader cannot fire the same run
+		result := db.Model(&models.Schedule{}).
+			Where("id = ? AND next_run_at = ?", row.ID, row.NextRunAt).
+			Updates(map[string]interface{}{
+				"next_run_at":    next,
+				"last_run_at":    now,
+				"last_status":    models.ScheduleStatusRunning,
+				"last_worker_id": r.workerID,
+			})
+		if result.Error != nil {
+			logger.Errorf("Failed to claim schedule %s: %v", row.Name, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		r.start(ctx, job, row.ID, now)
+	}
+}
+
+func (r *runner) isRunning(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running[name]
+}
+
+func (r *runner) start(ctx context.Context, job *Job, scheduleID uint, startedAt time.Time) {
+	r.mu.Lock()
+	r.running[job.Name] = true
+	r.mu.Unlock()
+	r.jobs.Add(1)
+
+	go func() {
+		defer r.jobs.Done()
+		defer func() {
+			r.mu.Lock()
+			delete(r.running, job.Name)
+			r.mu.Unlock()
+		}()
+
+		logger.Infof("Running scheduled job %s", job.Name)
+		err := runJob(ctx, job)
+
+		updates := map[string]interface{}{
+			"last_status":      models.ScheduleStatusSucceeded,
+			"last_error":       "",
+			"last_duration_ms": time.Since(startedAt).Milliseconds(),
+		}
+		if err != nil {
+			logger.Errorf("Scheduled job %s failed: %v", job.Name, err)
+			updates["last_status"] = models.ScheduleStatusFailed
+			updates["last_error"] = err.Error()
+		}
+		if err := database.GetDB().Model(&models.Schedule{}).Where("id = ?", scheduleID).Updates(updates).Error; err != nil {
+			logger.Warnf("Failed to record result of scheduled job %s: %v", job.Name, err)
+		}
+	}()
+}
+
+// runJob turns a panicking job into a failed run.
+func runJob(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/robfig/cron/v3"
+)
+
+// JobFunc runs one occurrence of a scheduled job. ctx is cancelled when the
+// process shuts down.
+type JobFunc func(ctx context.Context) error
+
+// Job is a recurring job registered in code. Spec is its default schedule;
+// once persisted, the schedule stored in the database wins.
+type Job struct {
+	Name        string
+	Spec        string
+	Description string
+	Run         JobFunc
+}
+
+var (
+	jobsMu sync.RWMutex
+	jobs   = make(map[string]*Job)
+)
+
+// Register adds a recurring job. It is meant to be called at startup, before
+// Run.
+func Register(name, spec, description string, run JobFunc) error {
+	if _, err := ParseSpec(spec); err != nil {
+		return err
+	}
+
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+
+	if _, exists := jobs[name]; exists {
+		return fmt.Errorf("scheduled job %s is already registered", name)
+	}
+	jobs[name] = &Job{Name: name, Spec: spec, Description: description, Run: run}
+	return nil
+}
+
+// Lookup returns the registered job with the given name.
+func Lookup(name string) (*Job, bool) {
+	jobsMu.RLock()
+	defer jobsMu.RUnlock()
+
+	job, ok := jobs[name]
+	return job, ok
+}
+
+// Jobs returns the registered jobs sorted by name.
+func Jobs() []*Job {
+	jobsMu.RLock()
+	defer jobsMu.RUnlock()
+
+	list := make([]*Job, 0, len(jobs))
+	for _, job := range jobs {
+		list = append(list, job)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ParseSpec parses a standard five-field cron expression, or a descriptor
+// such as @hourly or @every 10m.
+func ParseSpec(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return schedule, nil
+}
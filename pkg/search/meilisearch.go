@@ -0,0 +1,194 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"creative-studio-server/config"
+)
+
+// meilisearchSettings rank title matches above tag, detected object,
+// description and transcript matches. Typo tolerance is on by default.
+var meilisearchSettings = map[string]interface{}{
+	"searchableAttributes": []string{"title", "tags", "objects", "description", "transcript"},
+	"filterableAttributes": []string{"user_id", "organization_id", "category", "mood", "style", "color", "resolution", "tags", "duration", "duration_bucket", "created_at"},
+	"sortableAttributes":   []string{"created_at"},
+	"rankingRules":         []string{"words", "typo", "proximity", "attribute", "exactness", "created_at:desc"},
+}
+
+// meilisearchIndex talks to the Meilisearch REST API. Writes are queued by
+// Meilisearch and applied shortly after they return.
+type meilisearchIndex struct {
+	url    string
+	apiKey string
+	index  string
+	client *http.Client
+}
+
+func newMeilisearchIndex(cfg *config.SearchConfig) *meilisearchIndex {
+	return &meilisearchIndex{
+		url:    cfg.MeilisearchURL,
+		apiKey: cfg.MeilisearchAPIKey,
+		index:  cfg.Index,
+		client: &http.Client{},
+	}
+}
+
+// configure applies the index settings, creating the index when missing.
+func (m *meilisearchIndex) configure(ctx context.Context) error {
+	return m.do(ctx, http.MethodPatch, m.indexPath("/settings"), meilisearchSettings, nil)
+}
+
+func (m *meilisearchIndex) Upsert(ctx context.Context, docs []Document) error {
+	return m.do(ctx, http.MethodPut, m.indexPath("/documents?primaryKey=id"), docs, nil)
+}
+
+func (m *meilisearchIndex) Delete(ctx context.Context, ids []uint) error {
+	return m.do(ctx, http.MethodPost, m.indexPath("/documents/delete-batch"), ids, nil)
+}
+
+func (m *meilisearchIndex) Search(ctx context.Context, query *Query) (*Result, error) {
+	request := map[string]interface{}{
+		"q":                    query.Text,
+		"filter":               meilisearchFilter(query),
+		"offset":               query.Offset,
+		"limit":                query.Limit,
+		"attributesToRetrieve": []string{"id"},
+	}
+	var response struct {
+		Hits []struct {
+			ID uint `json:"id"`
+		} `json:"hits"`
+		EstimatedTotalHits int64 `json:"estimatedTotalHits"`
+	}
+	if err := m.do(ctx, http.MethodPost, m.indexPath("/search"), request, &response); err != nil {
+		return nil, err
+	}
+
+	result := &Result{IDs: make([]uint, len(response.Hits)), Total: response.EstimatedTotalHits}
+	for i, hit := range response.Hits {
+		result.IDs[i] = hit.ID
+	}
+	return result, nil
+}
+
+// meilisearchFacets maps facets to the attributes holding their values
+var meilisearchFacets = map[string]string{
+	"duration": "duration_bucket",
+}
+
+// Facets runs one search per facet, in a single multi-search request,
+// each without the filters on the facet's field.
+func (m *meilisearchIndex) Facets(ctx context.Context, query *Query, names []string) (map[string]map[string]int64, error) {
+	queries := make([]map[string]interface{}, len(names))
+	attributes := make([]string, len(names))
+	for i, name := range names {
+		attributes[i] = name
+		if attribute, ok := meilisearchFacets[name]; ok {
+			attributes[i] = attribute
+		}
+
+		facetQuery := *query
+		facetQuery.Filters = nil
+		for _, filter := range query.Filters {
+			if filter.Field != name {
+				facetQuery.Filters = append(facetQuery.Filters, filter)
+			}
+		}
+		queries[i] = map[string]interface{}{
+			"indexUid": m.index,
+			"q":        query.Text,
+			"filter":   meilisearchFilter(&facetQuery),
+			"limit":    0,
+			"facets":   []string{attributes[i]},
+		}
+	}
+
+	var response struct {
+		Results []struct {
+			FacetDistribution map[string]map[string]int64 `json:"facetDistribution"`
+		} `json:"results"`
+	}
+	if err := m.do(ctx, http.MethodPost, "/multi-search", map[string]interface{}{"queries": queries}, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Results) != len(names) {
+		return nil, fmt.Errorf("Meilisearch returned %d facet results for %d facets", len(response.Results), len(names))
+	}
+
+	facets := make(map[string]map[string]int64, len(names))
+	for i, name := range names {
+		facets[name] = response.Results[i].FacetDistribution[attributes[i]]
+	}
+	return facets, nil
+}
+
+// meilisearchFilter limits the query to its workspace and filters.
+func meilisearchFilter(query *Query) string {
+	var conditions []string
+	if query.OrganizationID != nil {
+		conditions = append(conditions, fmt.Sprintf("organization_id = %d", *query.OrganizationID))
+	} else {
+		conditions = append(conditions, fmt.Sprintf("user_id = %d AND organization_id IS NULL", query.UserID))
+	}
+	for _, filter := range query.Filters {
+		conditions = append(conditions, filter.Field+" "+filter.Op+" "+meilisearchValue(filter.Value))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func meilisearchValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func (m *meilisearchIndex) indexPath(path string) string {
+	return "/indexes/" + m.index + path
+}
+
+// do sends a request to Meilisearch and decodes the response into out,
+// when set.
+func (m *meilisearchIndex) do(ctx context.Context, method, path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode Meilisearch request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, m.url+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Meilisearch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Meilisearch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Meilisearch returned %d: %s", resp.StatusCode, detail)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Meilisearch response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Package search keeps a full-text index of clips so searches can rank
+// their results and tolerate typos, which LIKE queries in the database
+// cannot. The database stays the source of truth: the index only returns
+// clip IDs, and it is updated asynchronously after clips change.
+package search
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+)
+
+// Supported search backends
+const (
+	BackendDatabase    = "database"
+	BackendMeilisearch = "meilisearch"
+)
+
+// Document is the indexed form of a clip.
+type Document struct {
+	ID             uint     `json:"id"`
+	UserID         uint     `json:"user_id"`
+	OrganizationID *uint    `json:"organization_id"`
+	Title          string   `json:"title"`
+	Description    string   `json:"description"`
+	Tags           []string `json:"tags"`
+	Objects        []string `json:"objects"`    // detected in the clip
+	Transcript     string   `json:"transcript"` // speech in the clip's audio
+	Category       string   `json:"category"`
+	Mood           string   `json:"mood"`
+	Style          string   `json:"style"`
+	Color          string   `json:"color"`
+	Resolution     string   `json:"resolution"`
+	Duration       float64  `json:"duration"`
+	DurationBucket string   `json:"duration_bucket"`
+	CreatedAt      int64    `json:"created_at"` // Unix seconds
+}
+
+// NewDocument returns the document indexed for the clip, with its
+// transcript when loaded.
+func NewDocument(clip *models.AtomicClip) Document {
+	doc := Document{
+		ID:             clip.ID,
+		UserID:         clip.UserID,
+		OrganizationID: clip.OrganizationID,
+		Title:          clip.Title,
+		Description:    clip.Description,
+		Tags:           clip.Tags,
+		Objects:        clip.Objects,
+		Category:       clip.Category,
+		Mood:           clip.Mood,
+		Style:          clip.Style,
+		Color:          clip.Color,
+		Resolution:     clip.Resolution,
+		Duration:       clip.Duration,
+		DurationBucket: models.DurationBucket(clip.Duration),
+		CreatedAt:      clip.CreatedAt.Unix(),
+	}
+	if clip.Transcript != nil {
+		doc.Transcript = clip.Transcript.Text
+	}
+	return doc
+}
+
+// Filter compares a document field with a value; Op is one of =, <, <=, >
+// and >=.
+type Filter struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+// Query is a ranked search within one workspace: a user's personal library
+// or, when OrganizationID is set, an organization's.
+type Query struct {
+	Text           string
+	UserID         uint
+	OrganizationID *uint
+	Filters        []Filter
+	Offset         int
+	Limit          int
+}
+
+// Result holds the IDs of the matching clips, best match first, and how
+// many clips match in total. Totals can be estimates.
+type Result struct {
+	IDs   []uint
+	Total int64
+}
+
+// Index is a full-text index of clips.
+type Index interface {
+	// Upsert adds the documents or replaces those with the same ID
+	Upsert(ctx context.Context, docs []Document) error
+	Delete(ctx context.Context, ids []uint) error
+	Search(ctx context.Context, query *Query) (*Result, error)
+	// Facets counts the values of each named facet among the matches of
+	// the query, leaving out the facet's own filters
+	Facets(ctx context.Context, query *Query, names []string) (map[string]map[string]int64, error)
+}
+
+var (
+	index   Index
+	timeout time.Duration
+)
+
+// InitSearch connects to the index configured by SEARCH_BACKEND. The
+// database backend needs no index, and searches keep using LIKE.
+func InitSearch(cfg *config.Config) error {
+	timeout = cfg.Search.Timeout
+
+	switch cfg.Search.Backend {
+	case BackendDatabase, "":
+		index = nil
+		return nil
+	case BackendMeilisearch:
+		meili := newMeilisearchIndex(&cfg.Search)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		// The index stays usable when Meilisearch is down at startup;
+		// searches fall back to the database until it is back
+		if err := meili.configure(ctx); err != nil {
+			logger.Warnf("Failed to configure search index %s: %v", cfg.Search.Index, err)
+		}
+		index = meili
+	default:
+		return fmt.Errorf("unknown search backend %q", cfg.Search.Backend)
+	}
+
+	logger.Infof("Search initialized with backend %s", cfg.Search.Backend)
+	return nil
+}
+
+// Enabled reports whether clips are searched through an index.
+func Enabled() bool {
+	return index != nil
+}
+
+// Upsert indexes the documents.
+func Upsert(docs []Document) error {
+	if index == nil || len(docs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return index.Upsert(ctx, docs)
+}
+
+// Delete removes the documents of the clips from the index.
+func Delete(ids []uint) error {
+	if index == nil || len(ids) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return index.Delete(ctx, ids)
+}
+
+// Search runs a ranked query against the index.
+func Search(query *Query) (*Result, error) {
+	if index == nil {
+		return nil, fmt.Errorf("search index is not enabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return index.Search(ctx, query)
+}
+
+// Facets counts the values of the facets among the matches of the query.
+func Facets(query *Query, names []string) (map[string]map[string]int64, error) {
+	if index == nil {
+		return nil, fmt.Errorf("search index is not enabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return index.Facets(ctx, query, names)
+}
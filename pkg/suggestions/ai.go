@@ -0,0 +1,16 @@
+package suggestions
+
+import (
+	"context"
+
+	"creative-studio-server/pkg/ai"
+)
+
+// aiGenerator writes suggestions with the AI provider's text model.
+type aiGenerator struct {
+	provider ai.AnalysisProvider
+}
+
+func (g *aiGenerator) Generate(ctx context.Context, instructions, input string) ([]byte, error) {
+	return g.provider.Generate(ctx, instructions, input)
+}
@@ -0,0 +1,40 @@
+package suggestions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/command"
+)
+
+// commandGenerator runs a local program, such as a wrapper around a model
+// served by llama.cpp or Ollama. The program reads
+// {"instructions": "...", "input": "..."} on stdin and writes the answer
+// to stdout.
+type commandGenerator struct {
+	command *command.Command
+}
+
+func newCommandGenerator(cfg *config.SuggestionConfig) (*commandGenerator, error) {
+	cmd, err := command.Parse(cfg.Command)
+	if err != nil {
+		return nil, err
+	}
+	return &commandGenerator{command: cmd}, nil
+}
+
+func (g *commandGenerator) Generate(ctx context.Context, instructions, input string) ([]byte, error) {
+	request, err := json.Marshal(map[string]string{"instructions": instructions, "input": input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode suggestion request: %w", err)
+	}
+
+	output, stderr, err := g.command.Run(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("suggestion command failed: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	return output, nil
+}
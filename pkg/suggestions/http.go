@@ -0,0 +1,83 @@
+package suggestions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"creative-studio-server/config"
+)
+
+// maxCompletionResponse bounds a chat completion; a description and a few
+// titles take a small part of it
+const maxCompletionResponse = 1 << 20
+
+// httpGenerator asks an OpenAI-compatible chat completions endpoint, as
+// offered by OpenAI and self-hosted servers such as vLLM or Ollama.
+type httpGenerator struct {
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func newHTTPGenerator(cfg *config.SuggestionConfig) *httpGenerator {
+	return &httpGenerator{
+		url:    cfg.URL,
+		apiKey: cfg.APIKey,
+		model:  cfg.Model,
+		client: &http.Client{},
+	}
+}
+
+func (g *httpGenerator) Generate(ctx context.Context, instructions, input string) ([]byte, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model":           g.model,
+		"response_format": map[string]string{"type": "json_object"},
+		"messages": []map[string]string{
+			{"role": "system", "content": instructions},
+			{"role": "user", "content": input},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode suggestion request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create suggestion request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach suggestion endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("suggestion endpoint returned %d: %s", resp.StatusCode, detail)
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxCompletionResponse)).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("failed to decode suggestion response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("suggestion endpoint returned no answer")
+	}
+	return []byte(completion.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,177 @@
+// Package suggestions writes a description and title ideas for clips with
+// a language model, from what analysis found in them: visual tags,
+// detected objects, on-screen text and the transcript.
+//
+// Generators receive instructions and the clip's facts as text and answer
+// with JSON of the form {"description": "...", "titles": ["...", "..."]}.
+package suggestions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/ai"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// maxTitles bounds the suggested titles kept from an answer
+	maxTitles = 5
+	// maxTitleLength matches the length of clip titles
+	maxTitleLength = 200
+	// maxTranscriptExcerpt bounds the transcript given to the model; the
+	// opening of the speech is enough to tell what a clip is about
+	maxTranscriptExcerpt = 2000
+)
+
+// instructions tell the model what to write and how to answer.
+const instructions = `You write metadata for short video clips in a creative asset library.
+From the facts about a clip, write a one or two sentence description of what it shows,
+and up to five short, distinct titles for it. Describe only what the facts support.
+Answer with a JSON object: {"description": "...", "titles": ["...", "..."]}`
+
+// Generator follows the instructions about the input with a language
+// model, answering with a JSON object.
+type Generator interface {
+	Generate(ctx context.Context, instructions, input string) ([]byte, error)
+}
+
+// Suggestion is the metadata written for a clip.
+type Suggestion struct {
+	Description string   `json:"description"`
+	Titles      []string `json:"titles"`
+}
+
+var (
+	generator Generator
+	timeout   time.Duration
+)
+
+// InitSuggestions sets up the generator configured by SUGGESTION_PROVIDER.
+// No metadata is suggested when there is none.
+func InitSuggestions(cfg *config.Config) error {
+	timeout = cfg.Suggestions.Timeout
+
+	switch cfg.Suggestions.Provider {
+	case "none", "":
+		generator = nil
+		return nil
+	case "http":
+		generator = newHTTPGenerator(&cfg.Suggestions)
+	case "command":
+		cmd, err := newCommandGenerator(&cfg.Suggestions)
+		if err != nil {
+			return fmt.Errorf("invalid SUGGESTION_COMMAND: %w", err)
+		}
+		generator = cmd
+	case "ai":
+		provider, err := ai.Provider()
+		if err != nil {
+			return err
+		}
+		generator = &aiGenerator{provider: provider}
+	default:
+		return fmt.Errorf("unknown suggestion provider %q", cfg.Suggestions.Provider)
+	}
+
+	logger.Infof("Metadata suggestions initialized with the %s provider", cfg.Suggestions.Provider)
+	return nil
+}
+
+// Enabled reports whether metadata can be suggested.
+func Enabled() bool {
+	return generator != nil
+}
+
+// Suggest writes a description and titles for a clip from its analysis,
+// which should be loaded, and its transcript, empty when it has none.
+func Suggest(clip *models.AtomicClip, transcript string) (*Suggestion, error) {
+	if generator == nil {
+		return nil, fmt.Errorf("metadata suggestions are not enabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	answer, err := generator.Generate(ctx, instructions, describe(clip, transcript))
+	if err != nil {
+		return nil, err
+	}
+	return decodeSuggestion(answer)
+}
+
+// describe lists what is known about a clip, one fact per line, leaving
+// out what is unknown.
+func describe(clip *models.AtomicClip, transcript string) string {
+	var facts strings.Builder
+	fact := func(name, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			fmt.Fprintf(&facts, "%s: %s\n", name, value)
+		}
+	}
+
+	fact("Current title", clip.Title)
+	fact("Category", clip.Category)
+	fact("Tags", strings.Join(clip.Tags, ", "))
+	fact("Mood", clip.Mood)
+	fact("Scene", clip.SceneType)
+	fact("Objects", strings.Join(clip.Objects, ", "))
+	fact("Actions", strings.Join(clip.Actions, ", "))
+	if clip.Duration > 0 {
+		fact("Duration", fmt.Sprintf("%.0f seconds", clip.Duration))
+	}
+	if analysis := clip.VideoAnalysis; analysis != nil {
+		fact("Visual tags", strings.Join(analysis.AITags, ", "))
+		fact("Shot type", strings.ReplaceAll(analysis.ShotType, "_", " "))
+		fact("Camera movement", analysis.CameraMovement)
+		fact("Audio", analysis.AudioType)
+		if analysis.HasFaces {
+			fact("People on screen", fmt.Sprintf("%d", analysis.FaceCount))
+		}
+		fact("On-screen text", analysis.TextContent)
+	}
+	if runes := []rune(strings.TrimSpace(transcript)); len(runes) > maxTranscriptExcerpt {
+		transcript = string(runes[:maxTranscriptExcerpt]) + "…"
+	}
+	fact("Transcript", transcript)
+
+	return facts.String()
+}
+
+// decodeSuggestion reads a generator's answer, keeping the distinct
+// non-empty titles.
+func decodeSuggestion(data []byte) (*Suggestion, error) {
+	var suggestion Suggestion
+	if err := json.Unmarshal(data, &suggestion); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata suggestion: %w", err)
+	}
+	suggestion.Description = strings.TrimSpace(suggestion.Description)
+
+	titles := make([]string, 0, maxTitles)
+	seen := make(map[string]bool)
+	for _, title := range suggestion.Titles {
+		title = strings.Trim(strings.TrimSpace(title), `"`)
+		if runes := []rune(title); len(runes) > maxTitleLength {
+			title = strings.TrimSpace(string(runes[:maxTitleLength]))
+		}
+		key := strings.ToLower(title)
+		if title == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		titles = append(titles, title)
+		if len(titles) == maxTitles {
+			break
+		}
+	}
+	suggestion.Titles = titles
+
+	if suggestion.Description == "" && len(suggestion.Titles) == 0 {
+		return nil, fmt.Errorf("metadata suggestion is empty")
+	}
+	return &suggestion, nil
+}
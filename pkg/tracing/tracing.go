@@ -0,0 +1,42 @@
+// Package tracing carries the correlation IDs that tie an API request to
+// the queue tasks it starts and to the worker logs those tasks produce.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Header carries the correlation ID on HTTP requests and responses
+const Header = "X-Request-ID"
+
+// maxIDLength bounds IDs accepted from callers
+const maxIDLength = 64
+
+// NewID returns a random correlation ID.
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// ValidID reports whether an ID sent by a caller is safe to reuse: short,
+// and limited to characters that cannot break log lines or headers.
+func ValidID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,20 @@
+package transcription
+
+import (
+	"context"
+
+	"creative-studio-server/pkg/ai"
+)
+
+// aiProvider transcribes audio with the AI provider's speech model.
+type aiProvider struct {
+	provider ai.AnalysisProvider
+}
+
+func (p *aiProvider) Transcribe(ctx context.Context, audioPath, language string) (*Result, error) {
+	transcript, err := p.provider.Transcribe(ctx, audioPath, language)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Language: transcript.Language, Text: transcript.Text, Segments: transcript.Segments}, nil
+}
@@ -0,0 +1,47 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"creative-studio-server/config"
+	"creative-studio-server/pkg/command"
+)
+
+// commandProvider runs a local speech-to-text program, such as a wrapper
+// around whisper.cpp. The program is given the WAV file's path, followed by
+// the language when one is set, and writes the transcript to stdout.
+type commandProvider struct {
+	command *command.Command
+}
+
+func newCommandProvider(cfg *config.TranscriptionConfig) (*commandProvider, error) {
+	cmd, err := command.Parse(cfg.Command)
+	if err != nil {
+		return nil, err
+	}
+	return &commandProvider{command: cmd}, nil
+}
+
+func (p *commandProvider) Transcribe(ctx context.Context, audioPath, language string) (*Result, error) {
+	args := []string{audioPath}
+	if language != "" {
+		args = append(args, language)
+	}
+
+	output, stderr, err := p.command.Run(ctx, nil, args...)
+	if err != nil {
+		return nil, fmt.Errorf("transcription command failed: %w: %s", err, lastLine(stderr))
+	}
+	return decodeResult(output)
+}
+
+// lastLine keeps the end of a program's log, where the error usually is.
+func lastLine(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		return text[i+1:]
+	}
+	return text
+}
@@ -0,0 +1,95 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"creative-studio-server/config"
+)
+
+// maxTranscriptResponse bounds a transcript; hours of speech stay well
+// under it
+const maxTranscriptResponse = 16 << 20
+
+// httpProvider uploads audio to a Whisper-compatible transcription
+// endpoint, as offered by OpenAI and self-hosted Whisper servers.
+type httpProvider struct {
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func newHTTPProvider(cfg *config.TranscriptionConfig) *httpProvider {
+	return &httpProvider{
+		url:    cfg.URL,
+		apiKey: cfg.APIKey,
+		model:  cfg.Model,
+		client: &http.Client{},
+	}
+}
+
+func (p *httpProvider) Transcribe(ctx context.Context, audioPath, language string) (*Result, error) {
+	audio, err := os.Open(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio: %w", err)
+	}
+	defer audio.Close()
+
+	// The audio is streamed rather than buffered, it runs to tens of MB
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		writer.CloseWithError(func() error {
+			fields := map[string]string{"model": p.model, "response_format": "verbose_json"}
+			if language != "" {
+				fields["language"] = language
+			}
+			for name, value := range fields {
+				if err := form.WriteField(name, value); err != nil {
+					return err
+				}
+			}
+			part, err := form.CreateFormFile("file", filepath.Base(audioPath))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(part, audio); err != nil {
+				return err
+			}
+			return form.Close()
+		}())
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, body)
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("failed to create transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach transcription endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("transcription endpoint returned %d: %s", resp.StatusCode, detail)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTranscriptResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return decodeResult(data)
+}
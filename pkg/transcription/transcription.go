@@ -0,0 +1,123 @@
+// Package transcription turns the speech in clip audio into time-aligned
+// text, which clips are searched by and renders are captioned with.
+//
+// Providers answer in the verbose JSON of Whisper's transcription API:
+// {"language": "english", "text": "...", "segments": [{"start": 0.0, "end": 2.4, "text": "..."}]}.
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/ai"
+	"creative-studio-server/pkg/logger"
+)
+
+// Result is the transcript of an audio file.
+type Result struct {
+	Language string                    `json:"language"`
+	Text     string                    `json:"text"`
+	Segments models.TranscriptSegments `json:"segments"`
+}
+
+// Provider transcribes a 16 kHz mono WAV file. An empty language asks the
+// provider to detect it.
+type Provider interface {
+	Transcribe(ctx context.Context, audioPath, language string) (*Result, error)
+}
+
+var (
+	provider     Provider
+	providerName string
+	language     string
+	timeout      time.Duration
+)
+
+// InitTranscription sets up the provider configured by
+// TRANSCRIPTION_PROVIDER. Clips are not transcribed without one.
+func InitTranscription(cfg *config.Config) error {
+	language = cfg.Transcripts.Language
+	timeout = cfg.Transcripts.Timeout
+	providerName = cfg.Transcripts.Provider
+
+	switch cfg.Transcripts.Provider {
+	case "none", "":
+		provider = nil
+		return nil
+	case "http":
+		provider = newHTTPProvider(&cfg.Transcripts)
+	case "command":
+		cmd, err := newCommandProvider(&cfg.Transcripts)
+		if err != nil {
+			return fmt.Errorf("invalid TRANSCRIPTION_COMMAND: %w", err)
+		}
+		provider = cmd
+	case "ai":
+		analysisProvider, err := ai.Provider()
+		if err != nil {
+			return err
+		}
+		provider = &aiProvider{provider: analysisProvider}
+		// Transcripts record the vendor rather than "ai"
+		providerName = analysisProvider.Name()
+	default:
+		return fmt.Errorf("unknown transcription provider %q", cfg.Transcripts.Provider)
+	}
+
+	logger.Infof("Transcription initialized with the %s provider", cfg.Transcripts.Provider)
+	return nil
+}
+
+// Enabled reports whether clips are transcribed.
+func Enabled() bool {
+	return provider != nil
+}
+
+// ProviderName is the configured provider, recorded on transcripts.
+func ProviderName() string {
+	return providerName
+}
+
+// Transcribe returns the transcript of an audio file, in the given language
+// or else the configured one.
+func Transcribe(audioPath, lang string) (*Result, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("transcription is not enabled")
+	}
+	if lang == "" {
+		lang = language
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result, err := provider.Transcribe(ctx, audioPath, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whisper pads segments with spaces and emits empty ones for silence
+	segments := result.Segments[:0]
+	for _, segment := range result.Segments {
+		segment.Text = strings.TrimSpace(segment.Text)
+		if segment.Text != "" && segment.End > segment.Start {
+			segments = append(segments, segment)
+		}
+	}
+	result.Segments = segments
+	result.Text = strings.TrimSpace(result.Text)
+	return result, nil
+}
+
+// decodeResult reads a provider's answer.
+func decodeResult(data []byte) (*Result, error) {
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode transcript: %w", err)
+	}
+	return &result, nil
+}
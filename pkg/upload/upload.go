@@ -0,0 +1,148 @@
+// Package upload reads multipart uploads as they arrive, writing their
+// files straight to storage instead of buffering them in memory or in
+// temporary files first.
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// maxFieldSize bounds a form value; values are short metadata
+	maxFieldSize = 64 << 10
+	// maxFields bounds the form values of an upload
+	maxFields = 100
+)
+
+var (
+	ErrNotMultipart  = errors.New("request is not a multipart form")
+	ErrFieldTooLarge = errors.New("form value is too large")
+	ErrTooManyFields = errors.New("too many form values")
+	// ErrStorage is wrapped by failures to store a file, as opposed to
+	// failures to read the upload
+	ErrStorage = errors.New("failed to store file")
+)
+
+// File is an uploaded file stored on disk.
+type File struct {
+	Filename    string
+	ContentType string
+	Path        string
+	Size        int64
+}
+
+// Form is a read multipart upload: its values and stored files.
+type Form struct {
+	Values map[string]string
+	Files  map[string]*File
+}
+
+// Value returns the form value of a field, empty when it was not sent.
+func (f *Form) Value(name string) string {
+	return f.Values[name]
+}
+
+// RemoveFiles deletes the stored files the caller did not keep.
+func (f *Form) RemoveFiles() {
+	for _, file := range f.Files {
+		os.Remove(file.Path)
+	}
+}
+
+// Read reads a multipart request, storing the file of each field in dirs
+// in a new file in its directory. Files of other fields are skipped, and
+// only the first file of a field is kept. The request body should be
+// bounded with http.MaxBytesReader; on any error the files already stored
+// are deleted.
+func Read(r *http.Request, dirs map[string]string) (*Form, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, ErrNotMultipart
+	}
+
+	form := &Form{
+		Values: make(map[string]string),
+		Files:  make(map[string]*File),
+	}
+	fields := 0
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			form.RemoveFiles()
+			return nil, err
+		}
+
+		name := part.FormName()
+		if part.FileName() == "" {
+			fields++
+			if fields > maxFields {
+				part.Close()
+				form.RemoveFiles()
+				return nil, ErrTooManyFields
+			}
+			value, err := io.ReadAll(io.LimitReader(part, maxFieldSize+1))
+			part.Close()
+			if err != nil {
+				form.RemoveFiles()
+				return nil, err
+			}
+			if len(value) > maxFieldSize {
+				form.RemoveFiles()
+				return nil, ErrFieldTooLarge
+			}
+			if _, exists := form.Values[name]; !exists {
+				form.Values[name] = string(value)
+			}
+			continue
+		}
+
+		dir, wanted := dirs[name]
+		if _, exists := form.Files[name]; !wanted || exists {
+			// Drained by the next call to NextPart
+			part.Close()
+			continue
+		}
+		file, err := store(part, dir)
+		part.Close()
+		if err != nil {
+			form.RemoveFiles()
+			return nil, err
+		}
+		form.Files[name] = file
+	}
+}
+
+// store writes a file part to a new file in dir.
+func store(part *multipart.Part, dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	dst, err := os.CreateTemp(dir, "upload-*.part")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	defer dst.Close()
+
+	size, err := io.Copy(dst, part)
+	if err != nil {
+		os.Remove(dst.Name())
+		return nil, err
+	}
+
+	return &File{
+		// Only the base name is kept, so names cannot escape a directory
+		Filename:    filepath.Base(part.FileName()),
+		ContentType: part.Header.Get("Content-Type"),
+		Path:        dst.Name(),
+		Size:        size,
+	}, nil
+}
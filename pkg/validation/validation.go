@@ -0,0 +1,194 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	zhtranslations "github.com/go-playground/validator/v10/translations/zh"
+	"creative-studio-server/models"
+)
+
+// DefaultLocale is the language of messages when the client asks for none
+// of the supported ones
+const DefaultLocale = "en"
+
+var translators *ut.UniversalTranslator
+
+// The binding validator names fields as clients send them, by their JSON or
+// form key, and its messages are translated; both must be set up before the
+// first request is validated.
+func init() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(fieldName)
+
+	translators = ut.New(en.New(), en.New(), zh.New())
+	english, _ := translators.GetTranslator("en")
+	chinese, _ := translators.GetTranslator("zh")
+	if err := entranslations.RegisterDefaultTranslations(validate, english); err != nil {
+		panic(fmt.Sprintf("failed to register validation messages: %v", err))
+	}
+	if err := zhtranslations.RegisterDefaultTranslations(validate, chinese); err != nil {
+		panic(fmt.Sprintf("failed to register validation messages: %v", err))
+	}
+	for rule, messages := range customMessages {
+		english.Add(rule, messages[0], false)
+		chinese.Add(rule, messages[1], false)
+	}
+}
+
+// customMessages are the English and Chinese messages of the rules that
+// controllers check themselves, such as those on uploaded files
+var customMessages = map[string][2]string{
+	RuleVideoFile: {"{0} must be a video file", "{0}必须是视频文件"},
+	RuleFileName:  {"{0} may only contain letters, digits, dots, dashes and underscores", "{0}只能包含字母、数字、点、短横线和下划线"},
+}
+
+// Rules checked outside the binding validator
+const (
+	RuleVideoFile = "video_file"
+	RuleFileName  = "file_name"
+)
+
+// fieldName is the key a field is sent with: its JSON name, or else its
+// form name.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// Locale picks the language of messages from an Accept-Language header.
+func Locale(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		language, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		switch language {
+		case "en", "zh":
+			return language
+		}
+	}
+	return DefaultLocale
+}
+
+// Errors explains why a request could not be bound, field by field, in
+// the locale's language. Errors that concern no single field, such as a
+// malformed body, have no field.
+func Errors(err error, locale string) []models.FieldError {
+	var fieldErrors validator.ValidationErrors
+	if errors.As(err, &fieldErrors) {
+		translator, _ := translators.GetTranslator(locale)
+		result := make([]models.FieldError, 0, len(fieldErrors))
+		for _, fe := range fieldErrors {
+			result = append(result, models.FieldError{
+				Field:   fieldPath(fe),
+				Rule:    fe.Tag(),
+				Message: translate(fe, translator),
+			})
+		}
+		return result
+	}
+
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+	var numError *strconv.NumError
+	var timeError *time.ParseError
+	switch {
+	case errors.As(err, &typeError):
+		return []models.FieldError{{
+			Field:   typeError.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("%s must be a %s", typeError.Field, jsonType(typeError.Type)),
+		}}
+	case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF):
+		return []models.FieldError{{Rule: "json", Message: "request body is not valid JSON"}}
+	case errors.Is(err, io.EOF):
+		return []models.FieldError{{Rule: "required", Message: "request body is required"}}
+	case errors.As(err, &numError):
+		return []models.FieldError{{Rule: "type", Message: fmt.Sprintf("%q is not a valid number", numError.Num)}}
+	case errors.As(err, &timeError):
+		return []models.FieldError{{Rule: "type", Message: fmt.Sprintf("%q is not a valid time", timeError.Value)}}
+	}
+	return []models.FieldError{{Rule: "invalid", Message: err.Error()}}
+}
+
+// Message is Errors joined into one message, for APIs without room for
+// field errors.
+func Message(err error, locale string) string {
+	var messages []string
+	for _, fe := range Errors(err, locale) {
+		messages = append(messages, fe.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Field explains that a field broke a rule that takes no parameter, such
+// as required, in the locale's language.
+func Field(field, rule, locale string) models.FieldError {
+	message := fmt.Sprintf("%s failed the %s rule", field, rule)
+	if translator, found := translators.GetTranslator(locale); found {
+		if translated, err := translator.T(rule, field); err == nil {
+			message = translated
+		}
+	}
+	return models.FieldError{Field: field, Rule: rule, Message: message}
+}
+
+// fieldPath is where the field is in the request, such as clips[0].start,
+// without the request struct's name.
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func translate(fe validator.FieldError, translator ut.Translator) string {
+	if translator != nil {
+		if message := fe.Translate(translator); message != fe.Error() {
+			return message
+		}
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("%s failed the %s=%s rule", fe.Field(), fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("%s failed the %s rule", fe.Field(), fe.Tag())
+}
+
+// jsonType names a Go type as a JSON type.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Pointer:
+		return jsonType(t.Elem())
+	}
+	return "object"
+}
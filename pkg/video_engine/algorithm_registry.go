@@ -0,0 +1,77 @@
+package video_engine
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// AlgorithmFactory builds a fresh CompositionAlgorithm instance for a compositor.
+type AlgorithmFactory func() CompositionAlgorithm
+
+var (
+	algorithmRegistryMu sync.RWMutex
+	algorithmRegistry   = make(map[string]AlgorithmFactory)
+)
+
+func init() {
+	MustRegisterAlgorithm("smart_selection", func() CompositionAlgorithm { return &SmartSelectionAlgorithm{} })
+	MustRegisterAlgorithm("theme_based", func() CompositionAlgorithm { return &ThemeBasedAlgorithm{} })
+	MustRegisterAlgorithm("emotion_driven", func() CompositionAlgorithm { return &EmotionDrivenAlgorithm{} })
+	MustRegisterAlgorithm("optimizer", func() CompositionAlgorithm { return &OptimizerAlgorithm{} })
+}
+
+// RegisterAlgorithm makes a composition algorithm available to every
+// SmartCompositor created afterwards under the given name.
+func RegisterAlgorithm(name string, factory AlgorithmFactory) error {
+	if name == "" {
+		return fmt.Errorf("algorithm name is required")
+	}
+	if factory == nil {
+		return fmt.Errorf("algorithm factory for %s is nil", name)
+	}
+
+	algorithmRegistryMu.Lock()
+	defer algorithmRegistryMu.Unlock()
+
+	if _, exists := algorithmRegistry[name]; exists {
+		return fmt.Errorf("algorithm %s is already registered", name)
+	}
+	algorithmRegistry[name] = factory
+	return nil
+}
+
+func MustRegisterAlgorithm(name string, factory AlgorithmFactory) {
+	if err := RegisterAlgorithm(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+func UnregisterAlgorithm(name string) {
+	algorithmRegistryMu.Lock()
+	defer algorithmRegistryMu.Unlock()
+	delete(algorithmRegistry, name)
+}
+
+func RegisteredAlgorithms() []string {
+	algorithmRegistryMu.RLock()
+	defer algorithmRegistryMu.RUnlock()
+
+	names := make([]string, 0, len(algorithmRegistry))
+	for name := range algorithmRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newRegisteredAlgorithms() map[string]CompositionAlgorithm {
+	algorithmRegistryMu.RLock()
+	defer algorithmRegistryMu.RUnlock()
+
+	algorithms := make(map[string]CompositionAlgorithm, len(algorithmRegistry))
+	for name, factory := range algorithmRegistry {
+		algorithms[name] = factory()
+	}
+	return algorithms
+}
@@ -0,0 +1,405 @@
+package video_engine
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os/exec"
+
+	"creative-studio-server/models"
+)
+
+const (
+	// Audio is decoded to mono at this rate; beats need no more
+	beatSampleRate = 11025
+	// The onset envelope has a point every beatHop samples, about 23 ms,
+	// each measuring beatWindow samples
+	beatHop    = 256
+	beatWindow = 512
+
+	// Tempos outside this range are read as their double or half
+	minBPM = 60.0
+	maxBPM = 180.0
+	// Tempos are weighed towards preferredBPM, tempoPriorWidth octaves
+	// wide, so the beat is not mistaken for the bar or the hi-hat
+	preferredBPM    = 120.0
+	tempoPriorWidth = 1.0
+	// beatTightness penalizes beats straying from the tempo
+	beatTightness = 100.0
+	beatsPerBar   = 4
+
+	// The energy curve has a point every musicEnergyInterval seconds
+	musicEnergyInterval = 0.5
+	// Energy is smoothed over this many seconds either side before it is
+	// split into sections, so single hits do not start one
+	sectionSmoothing = 4.0
+	// Sections are at least this long; shorter ones join their neighbor
+	minSectionDuration = 8.0
+	// Smoothed energy below and above these shares of the track's mean
+	// makes low and high energy sections
+	lowEnergyRatio  = 0.75
+	highEnergyRatio = 1.25
+
+	// Tempo labels of composition requirements
+	slowTempoBPM = 90.0
+	fastTempoBPM = 120.0
+)
+
+// MusicFeatures is what a track's audio tells about its rhythm and
+// structure; see models.MusicAnalysis.
+type MusicFeatures struct {
+	BPM            float64
+	BeatTimes      []float64
+	DownbeatTimes  []float64
+	EnergyInterval float64
+	EnergyCurve    []float64
+	Sections       []models.MusicSection
+}
+
+// AnalyzeMusic decodes a track's audio and finds its tempo, beats, bars,
+// energy and sections.
+func (fp *FFmpegProcessor) AnalyzeMusic(filePath string) (*MusicFeatures, error) {
+	output, err := exec.Command(fp.ffmpegPath,
+		"-i", filePath,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprint(beatSampleRate),
+		"-f", "f32le",
+		"-",
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w", err)
+	}
+
+	samples := make([]float64, len(output)/4)
+	for i := range samples {
+		samples[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(output[i*4:])))
+	}
+	if len(samples) < beatSampleRate {
+		return nil, fmt.Errorf("no audio to analyze in %s", filePath)
+	}
+
+	return analyzeMusicSamples(samples, beatSampleRate), nil
+}
+
+// MusicTempo labels a tempo slow, medium or fast, as composition
+// requirements name them.
+func MusicTempo(bpm float64) string {
+	switch {
+	case bpm <= 0:
+		return ""
+	case bpm < slowTempoBPM:
+		return "slow"
+	case bpm < fastTempoBPM:
+		return "medium"
+	}
+	return "fast"
+}
+
+func analyzeMusicSamples(samples []float64, rate int) *MusicFeatures {
+	envelope := onsetEnvelope(samples)
+	frameRate := float64(rate) / beatHop
+
+	features := &MusicFeatures{EnergyInterval: musicEnergyInterval}
+	features.EnergyCurve = energyCurve(samples, rate)
+
+	if bpm := estimateTempo(envelope, frameRate); bpm > 0 {
+		features.BPM = math.Round(bpm*10) / 10
+		beats := trackBeats(envelope, frameRate*60/bpm)
+		features.BeatTimes = make([]float64, len(beats))
+		for i, frame := range beats {
+			// A hop's onset is timed at the middle of its window
+			features.BeatTimes[i] = roundMillis((float64(frame*beatHop) + beatWindow/2) / float64(rate))
+		}
+		features.DownbeatTimes = downbeats(features.BeatTimes, beats, envelope)
+	}
+
+	duration := float64(len(samples)) / float64(rate)
+	features.Sections = musicSections(features.EnergyCurve, features.DownbeatTimes, duration)
+	return features
+}
+
+// onsetEnvelope measures how sharply the sound gets louder at each hop,
+// which peaks on drum hits and notes starting. The signal is
+// pre-emphasized so percussive highs outweigh sustained bass.
+func onsetEnvelope(samples []float64) []float64 {
+	frames := (len(samples) - beatWindow) / beatHop
+	if frames < 2 {
+		return nil
+	}
+
+	logEnergy := make([]float64, frames)
+	for f := range logEnergy {
+		start := f * beatHop
+		sum := 0.0
+		for i := start + 1; i < start+beatWindow; i++ {
+			emphasized := samples[i] - 0.97*samples[i-1]
+			sum += emphasized * emphasized
+		}
+		logEnergy[f] = math.Log(sum/beatWindow + 1e-10)
+	}
+
+	envelope := make([]float64, frames)
+	for f := 1; f < frames; f++ {
+		envelope[f] = math.Max(0, logEnergy[f]-logEnergy[f-1])
+	}
+
+	// Rises are taken relative to the last second or so, so a loud passage
+	// does not make every hop an onset
+	const localFrames = 43
+	detrended := make([]float64, frames)
+	sum := 0.0
+	for f := range envelope {
+		sum += envelope[f]
+		if f >= localFrames {
+			sum -= envelope[f-localFrames]
+		}
+		mean := sum / float64(min(f+1, localFrames))
+		detrended[f] = math.Max(0, envelope[f]-mean)
+	}
+
+	if _, deviation := meanAndDeviation(detrended); deviation > 0 {
+		for f := range detrended {
+			detrended[f] /= deviation
+		}
+	}
+	return detrended
+}
+
+// estimateTempo picks the tempo whose period the onset envelope repeats at
+// most, by autocorrelation weighted towards preferredBPM. It returns 0 for
+// envelopes too short to tell.
+func estimateTempo(envelope []float64, frameRate float64) float64 {
+	minLag := int(math.Floor(frameRate * 60 / maxBPM))
+	maxLag := int(math.Ceil(frameRate * 60 / minBPM))
+	if len(envelope) <= 2*maxLag {
+		return 0
+	}
+
+	strength := make([]float64, maxLag+2)
+	for lag := minLag - 1; lag <= maxLag+1; lag++ {
+		sum := 0.0
+		for t := lag; t < len(envelope); t++ {
+			sum += envelope[t] * envelope[t-lag]
+		}
+		bpm := frameRate * 60 / float64(lag)
+		prior := math.Log2(bpm/preferredBPM) / tempoPriorWidth
+		strength[lag] = sum / float64(len(envelope)-lag) * math.Exp(-0.5*prior*prior)
+	}
+
+	best := minLag
+	for lag := minLag; lag <= maxLag; lag++ {
+		if strength[lag] > strength[best] {
+			best = lag
+		}
+	}
+	if strength[best] <= 0 {
+		return 0
+	}
+
+	// The peak lies between lags; a parabola through its neighbors finds it
+	lag := float64(best)
+	if below, above := strength[best-1], strength[best+1]; below+above != 2*strength[best] {
+		lag += 0.5 * (below - above) / (below - 2*strength[best] + above)
+	}
+	return frameRate * 60 / lag
+}
+
+// trackBeats places beats on onsets about a period apart by dynamic
+// programming: each frame scores its onset plus the best beat before it,
+// less how far their spacing strays from the period.
+func trackBeats(envelope []float64, period float64) []int {
+	if len(envelope) == 0 || period <= 0 {
+		return nil
+	}
+
+	score := make([]float64, len(envelope))
+	previous := make([]int, len(envelope))
+	for t := range envelope {
+		score[t] = envelope[t]
+		previous[t] = -1
+
+		best := math.Inf(-1)
+		for prev := t - int(math.Round(2*period)); prev <= t-int(math.Round(period/2)); prev++ {
+			if prev < 0 {
+				continue
+			}
+			stray := math.Log(float64(t-prev) / period)
+			if candidate := score[prev] - beatTightness*stray*stray; candidate > best {
+				best, previous[t] = candidate, prev
+			}
+		}
+		if previous[t] >= 0 {
+			score[t] += best
+		}
+	}
+
+	// The last beat is the best scoring frame of the last period
+	last := len(envelope) - 1
+	for t := max(0, len(envelope)-int(math.Round(period))); t < len(envelope); t++ {
+		if score[t] > score[last] {
+			last = t
+		}
+	}
+
+	var beats []int
+	for t := last; t >= 0; t = previous[t] {
+		beats = append(beats, t)
+	}
+	for i, j := 0, len(beats)-1; i < j; i, j = i+1, j-1 {
+		beats[i], beats[j] = beats[j], beats[i]
+	}
+	return beats
+}
+
+// downbeats returns every beatsPerBar-th beat, starting from the beat of
+// the bar whose onsets are strongest on average.
+func downbeats(times []float64, beats []int, envelope []float64) []float64 {
+	if len(beats) < beatsPerBar {
+		return nil
+	}
+
+	phase, best := 0, math.Inf(-1)
+	for p := 0; p < beatsPerBar; p++ {
+		sum, count := 0.0, 0
+		for i := p; i < len(beats); i += beatsPerBar {
+			sum += envelope[beats[i]]
+			count++
+		}
+		if average := sum / float64(count); average > best {
+			phase, best = p, average
+		}
+	}
+
+	var result []float64
+	for i := phase; i < len(times); i += beatsPerBar {
+		result = append(result, times[i])
+	}
+	return result
+}
+
+// energyCurve returns the RMS loudness of every musicEnergyInterval,
+// relative to the loudest.
+func energyCurve(samples []float64, rate int) []float64 {
+	step := int(musicEnergyInterval * float64(rate))
+	curve := make([]float64, 0, len(samples)/step+1)
+	loudest := 0.0
+	for start := 0; start < len(samples); start += step {
+		end := min(start+step, len(samples))
+		sum := 0.0
+		for _, sample := range samples[start:end] {
+			sum += sample * sample
+		}
+		rms := math.Sqrt(sum / float64(end-start))
+		curve = append(curve, rms)
+		loudest = math.Max(loudest, rms)
+	}
+	for i := range curve {
+		if loudest > 0 {
+			curve[i] = math.Round(curve[i]/loudest*1000) / 1000
+		}
+	}
+	return curve
+}
+
+// musicSections splits a track into stretches of low, medium and high
+// energy, at least minSectionDuration long, starting and ending on
+// downbeats when there are any.
+func musicSections(curve []float64, downbeatTimes []float64, duration float64) []models.MusicSection {
+	if len(curve) == 0 {
+		return nil
+	}
+
+	radius := int(sectionSmoothing / musicEnergyInterval)
+	smoothed := make([]float64, len(curve))
+	for i := range curve {
+		from, to := max(0, i-radius), min(len(curve), i+radius+1)
+		sum := 0.0
+		for _, energy := range curve[from:to] {
+			sum += energy
+		}
+		smoothed[i] = sum / float64(to-from)
+	}
+	mean, _ := meanAndDeviation(curve)
+
+	var sections []models.MusicSection
+	for i, energy := range smoothed {
+		level := models.MusicEnergyMedium
+		switch {
+		case energy < mean*lowEnergyRatio:
+			level = models.MusicEnergyLow
+		case energy > mean*highEnergyRatio:
+			level = models.MusicEnergyHigh
+		}
+
+		start := float64(i) * musicEnergyInterval
+		if len(sections) > 0 && sections[len(sections)-1].Energy == level {
+			continue
+		}
+		if len(sections) > 0 {
+			start = nearestTime(downbeatTimes, start)
+			sections[len(sections)-1].End = start
+		}
+		sections = append(sections, models.MusicSection{Start: start, Energy: level})
+	}
+	sections[len(sections)-1].End = duration
+
+	// Short sections join the one before them, or the next for the first
+	merged := []models.MusicSection{}
+	for _, section := range sections {
+		if len(merged) == 0 {
+			section.Start = 0
+			merged = append(merged, section)
+			continue
+		}
+		last := &merged[len(merged)-1]
+		// Snapping to downbeats can leave a section ending before it starts
+		section.Start = last.End
+		if section.End <= section.Start {
+			continue
+		}
+
+		switch {
+		case last.Energy == section.Energy || section.End-section.Start < minSectionDuration:
+			last.End = section.End
+		case last.End-last.Start < minSectionDuration:
+			last.End = section.End
+			last.Energy = section.Energy
+		default:
+			merged = append(merged, section)
+		}
+	}
+	merged[len(merged)-1].End = roundMillis(duration)
+	return merged
+}
+
+// nearestTime returns the time closest to t, or t when there is none.
+func nearestTime(times []float64, t float64) float64 {
+	if len(times) == 0 {
+		return t
+	}
+	nearest := times[0]
+	for _, candidate := range times[1:] {
+		if math.Abs(candidate-t) < math.Abs(nearest-t) {
+			nearest = candidate
+		}
+	}
+	return nearest
+}
+
+func meanAndDeviation(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum, squares := 0.0, 0.0
+	for _, value := range values {
+		sum += value
+		squares += value * value
+	}
+	mean := sum / float64(len(values))
+	return mean, math.Sqrt(math.Max(0, squares/float64(len(values))-mean*mean))
+}
+
+func roundMillis(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
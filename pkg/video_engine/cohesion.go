@@ -0,0 +1,122 @@
+package video_engine
+
+import (
+	"math"
+	"strings"
+
+	"creative-studio-server/models"
+)
+
+// Relative weights of each cohesion signal. Signals that are missing on
+// either clip are left out and the remaining weights are renormalized.
+const (
+	embeddingWeight  = 0.4
+	colorWeight      = 0.25
+	motionWeight     = 0.2
+	brightnessWeight = 0.15
+
+	baseSimilarity = 0.5
+)
+
+var motionLevels = map[string]float64{
+	"low":    0,
+	"medium": 1,
+	"high":   2,
+}
+
+// ClipSimilarity estimates in [0, 1] how smoothly clip b follows clip a using
+// visual embeddings, dominant colors, motion intensity and brightness from
+// their VideoAnalysis. Clips without analysis get a neutral base similarity.
+func ClipSimilarity(a, b models.AtomicClip) float64 {
+	total := 0.0
+	weights := 0.0
+
+	addSignal := func(value, weight float64) {
+		total += value * weight
+		weights += weight
+	}
+
+	analysisA, analysisB := a.VideoAnalysis, b.VideoAnalysis
+	comparedPalette := false
+
+	if analysisA != nil && analysisB != nil {
+		if sim, ok := cosineSimilarity(analysisA.Embedding, analysisB.Embedding); ok {
+			addSignal((sim+1)/2, embeddingWeight)
+		}
+
+		if len(analysisA.DominantColors) > 0 && len(analysisB.DominantColors) > 0 {
+			addSignal(jaccard(analysisA.DominantColors, analysisB.DominantColors), colorWeight)
+			comparedPalette = true
+		}
+
+		levelA, okA := motionLevels[strings.ToLower(analysisA.MotionIntensity)]
+		levelB, okB := motionLevels[strings.ToLower(analysisB.MotionIntensity)]
+		if okA && okB {
+			addSignal(1-math.Abs(levelA-levelB)/2, motionWeight)
+		}
+
+		if analysisA.AvgBrightness > 0 && analysisB.AvgBrightness > 0 {
+			addSignal(1-math.Min(math.Abs(analysisA.AvgBrightness-analysisB.AvgBrightness), 1), brightnessWeight)
+		}
+	}
+
+	// Fall back to the user-supplied color label when no analysed palette exists
+	if !comparedPalette && a.Color != "" && b.Color != "" {
+		if strings.EqualFold(a.Color, b.Color) {
+			addSignal(1, colorWeight)
+		} else {
+			addSignal(0, colorWeight)
+		}
+	}
+
+	if weights == 0 {
+		return baseSimilarity
+	}
+	return total / weights
+}
+
+func cosineSimilarity(a, b []float64) (float64, bool) {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0, false
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0, false
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), true
+}
+
+func jaccard(a, b []string) float64 {
+	set := make(map[string]bool, len(a))
+	for _, v := range a {
+		set[strings.ToLower(v)] = true
+	}
+
+	intersection := 0
+	union := len(set)
+	seen := make(map[string]bool, len(b))
+	for _, v := range b {
+		v = strings.ToLower(v)
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		if set[v] {
+			intersection++
+		} else {
+			union++
+		}
+	}
+
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
@@ -0,0 +1,120 @@
+package video_engine
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"creative-studio-server/models"
+)
+
+// ApplyTo copies a generated result into a persisted composition.
+func (r *CompositionResult) ApplyTo(composition *models.Composition) error {
+	selectedClips, err := json.Marshal(r.SelectedClips)
+	if err != nil {
+		return fmt.Errorf("failed to encode selected clips: %w", err)
+	}
+
+	timeline, err := json.Marshal(r.Timeline)
+	if err != nil {
+		return fmt.Errorf("failed to encode timeline: %w", err)
+	}
+
+	composition.SelectedClips = selectedClips
+	composition.Timeline = timeline
+	composition.TotalDuration = r.TotalDuration
+	composition.QualityScore = r.QualityScore
+	composition.CohesionScore = r.CohesionScore
+	composition.Metadata = models.JSON(r.Metadata)
+	composition.Status = models.CompositionStatusCompleted
+	composition.ErrorMessage = ""
+
+	if algorithm, ok := r.Metadata["algorithm"].(string); ok {
+		composition.Algorithm = algorithm
+	}
+	if variant, ok := r.Metadata["variant"].(int); ok {
+		composition.Variant = variant
+	}
+	if seed, ok := r.Metadata["seed"].(int64); ok {
+		composition.Seed = seed
+	}
+
+	return nil
+}
+
+// CompositionResultFromModel rebuilds a result from a persisted composition.
+func CompositionResultFromModel(composition *models.Composition) (*CompositionResult, error) {
+	result := &CompositionResult{
+		TotalDuration: composition.TotalDuration,
+		QualityScore:  composition.QualityScore,
+		CohesionScore: composition.CohesionScore,
+		Metadata:      composition.Metadata,
+	}
+
+	if len(composition.SelectedClips) > 0 {
+		if err := json.Unmarshal(composition.SelectedClips, &result.SelectedClips); err != nil {
+			return nil, fmt.Errorf("failed to decode selected clips: %w", err)
+		}
+	}
+	if len(composition.Timeline) > 0 {
+		if err := json.Unmarshal(composition.Timeline, &result.Timeline); err != nil {
+			return nil, fmt.Errorf("failed to decode timeline: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// ProjectTimeline converts the composition timeline into the structure
+// stored on a Project.
+func (r *CompositionResult) ProjectTimeline() (*models.ProjectTimeline, error) {
+	timeline := &models.ProjectTimeline{
+		Clips:    []models.TimelineClip{},
+		Duration: r.TotalDuration,
+	}
+
+	for _, event := range r.Timeline {
+		switch event.Type {
+		case "clip":
+			var props struct {
+				ClipID    uint    `json:"clip_id"`
+				StartTime float64 `json:"start_time"`
+				EndTime   float64 `json:"end_time"`
+			}
+			if err := decodeEventProperties(event.Properties, &props); err != nil {
+				return nil, err
+			}
+			timeline.Clips = append(timeline.Clips, models.TimelineClip{
+				ClipID:    props.ClipID,
+				Position:  event.StartTime,
+				StartTime: props.StartTime,
+				EndTime:   props.EndTime,
+				Duration:  event.Duration,
+			})
+
+		case "transition":
+			var transition Transition
+			if err := decodeEventProperties(event.Properties, &transition); err != nil {
+				return nil, err
+			}
+			timeline.Transitions = append(timeline.Transitions, models.TimelineTransition{
+				Type:     transition.Type,
+				Position: event.StartTime,
+				Duration: event.Duration,
+				Easing:   transition.Easing,
+			})
+		}
+	}
+
+	return timeline, nil
+}
+
+func decodeEventProperties(properties interface{}, dest interface{}) error {
+	data, err := json.Marshal(properties)
+	if err != nil {
+		return fmt.Errorf("failed to encode timeline event: %w", err)
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("failed to decode timeline event: %w", err)
+	}
+	return nil
+}
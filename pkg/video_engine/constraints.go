@@ -0,0 +1,275 @@
+package video_engine
+
+import (
+	"fmt"
+
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+)
+
+// CompositionConstraints are hard rules applied around whichever algorithm
+// builds the composition.
+type CompositionConstraints struct {
+	PinnedClips      []PinnedClip   `json:"pinned_clips"`
+	MustInclude      []uint         `json:"must_include"`
+	MustExclude      []uint         `json:"must_exclude"`
+	MaxPerCategory   map[string]int `json:"max_per_category"`
+	MinSourceSpacing int            `json:"min_source_spacing"` // clips required between two cuts of the same source video
+}
+
+// PinnedClip fixes a clip at a position in the composition. Position 0 opens
+// the composition; negative positions count from the end, so -1 closes it.
+type PinnedClip struct {
+	ClipID   uint `json:"clip_id"`
+	Position int  `json:"position"`
+}
+
+func (c *CompositionConstraints) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	excluded := make(map[uint]bool, len(c.MustExclude))
+	for _, id := range c.MustExclude {
+		excluded[id] = true
+	}
+
+	positions := make(map[int]uint, len(c.PinnedClips))
+	for _, pin := range c.PinnedClips {
+		if excluded[pin.ClipID] {
+			return fmt.Errorf("clip %d is both pinned and excluded", pin.ClipID)
+		}
+		if other, taken := positions[pin.Position]; taken {
+			return fmt.Errorf("clips %d and %d are pinned to the same position %d", other, pin.ClipID, pin.Position)
+		}
+		positions[pin.Position] = pin.ClipID
+	}
+	for _, id := range c.MustInclude {
+		if excluded[id] {
+			return fmt.Errorf("clip %d is both required and excluded", id)
+		}
+	}
+	for category, limit := range c.MaxPerCategory {
+		if limit < 0 {
+			return fmt.Errorf("max_per_category for %s must not be negative", category)
+		}
+	}
+	if c.MinSourceSpacing < 0 {
+		return fmt.Errorf("min_source_spacing must not be negative")
+	}
+
+	return nil
+}
+
+// selectWithConstraints runs the algorithm on the clips the constraints leave
+// available, then merges in required clips and enforces category caps,
+// pinned positions and source spacing on the result.
+func (sc *SmartCompositor) selectWithConstraints(algorithm CompositionAlgorithm, scoredClips []models.AtomicClip) ([]ClipSegment, error) {
+	constraints := sc.requirements.Constraints
+	if constraints == nil {
+		return algorithm.SelectClips(scoredClips, sc.requirements)
+	}
+	if err := constraints.Validate(); err != nil {
+		return nil, err
+	}
+
+	scoredIndex := make(map[uint]models.AtomicClip, len(scoredClips))
+	for _, clip := range scoredClips {
+		scoredIndex[clip.ID] = clip
+	}
+
+	// Required clips are placed by the constraints, not the algorithm
+	required := make(map[uint]bool)
+	categoryCounts := make(map[string]int)
+	pinned := make([]ClipSegment, 0, len(constraints.PinnedClips))
+	var included []ClipSegment
+	requiredDuration := 0.0
+
+	addRequired := func(id uint, reason string) (ClipSegment, error) {
+		clip, ok := scoredIndex[id]
+		if !ok {
+			return ClipSegment{}, fmt.Errorf("required clip %d is not available", id)
+		}
+		required[id] = true
+		categoryCounts[clip.Category]++
+		segment := sc.segmentForClip(clip, reason)
+		requiredDuration += segment.Duration
+		return segment, nil
+	}
+
+	for _, pin := range constraints.PinnedClips {
+		segment, err := addRequired(pin.ClipID, "Pinned clip")
+		if err != nil {
+			return nil, err
+		}
+		pinned = append(pinned, segment)
+	}
+	for _, id := range constraints.MustInclude {
+		if required[id] {
+			continue
+		}
+		segment, err := addRequired(id, "Required clip")
+		if err != nil {
+			return nil, err
+		}
+		included = append(included, segment)
+	}
+
+	for category, count := range categoryCounts {
+		if limit, capped := constraints.MaxPerCategory[category]; capped && count > limit {
+			return nil, fmt.Errorf("required clips exceed max_per_category for %s (%d > %d)", category, count, limit)
+		}
+	}
+
+	excluded := make(map[uint]bool, len(constraints.MustExclude))
+	for _, id := range constraints.MustExclude {
+		excluded[id] = true
+	}
+
+	pool := make([]models.AtomicClip, 0, len(scoredClips))
+	for _, clip := range scoredClips {
+		if !excluded[clip.ID] && !required[clip.ID] {
+			pool = append(pool, clip)
+		}
+	}
+
+	var selected []ClipSegment
+	requirements := sc.requirements
+	requirements.TargetDuration -= requiredDuration
+	if requirements.TargetDuration > requirements.MinClipDuration && len(pool) > 0 {
+		var err error
+		selected, err = algorithm.SelectClips(pool, requirements)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Drop the lowest-ranked extras once a category reaches its cap
+	free := make([]ClipSegment, 0, len(selected)+len(included))
+	for _, segment := range selected {
+		category := sc.clipIndex[segment.ClipID].Category
+		if limit, capped := constraints.MaxPerCategory[category]; capped && categoryCounts[category] >= limit {
+			continue
+		}
+		categoryCounts[category]++
+		free = append(free, segment)
+	}
+	free = append(free, included...)
+
+	arranged := arrangePinned(constraints.PinnedClips, pinned, free)
+	return sc.enforceSourceSpacing(arranged, required, constraints.MinSourceSpacing), nil
+}
+
+// arrangePinned places pinned segments at their positions and fills the
+// remaining slots with the free segments in order.
+func arrangePinned(pins []PinnedClip, pinned, free []ClipSegment) []ClipSegment {
+	total := len(pinned) + len(free)
+	slots := make([]*ClipSegment, total)
+
+	for i, pin := range pins {
+		position := pin.Position
+		if position < 0 {
+			position += total
+		}
+		if position < 0 {
+			position = 0
+		}
+		if position >= total {
+			position = total - 1
+		}
+		// Positions collapse together when there are few clips; take the next open slot
+		for slots[position] != nil {
+			position = (position + 1) % total
+		}
+		slots[position] = &pinned[i]
+	}
+
+	arranged := make([]ClipSegment, 0, total)
+	next := 0
+	for _, slot := range slots {
+		if slot != nil {
+			arranged = append(arranged, *slot)
+			continue
+		}
+		arranged = append(arranged, free[next])
+		next++
+	}
+	return arranged
+}
+
+// enforceSourceSpacing moves later segments forward to keep cuts of the same
+// source apart, dropping optional segments that cannot be placed. Required
+// segments are never dropped or moved past one another.
+func (sc *SmartCompositor) enforceSourceSpacing(segments []ClipSegment, required map[uint]bool, spacing int) []ClipSegment {
+	if spacing <= 0 {
+		return segments
+	}
+
+	conflicts := func(placed []ClipSegment, candidate ClipSegment) bool {
+		source := clipSource(sc.clipIndex[candidate.ClipID])
+		for i := len(placed) - 1; i >= 0 && i >= len(placed)-spacing; i-- {
+			if clipSource(sc.clipIndex[placed[i].ClipID]) == source {
+				return true
+			}
+		}
+		return false
+	}
+
+	result := make([]ClipSegment, 0, len(segments))
+	pending := append([]ClipSegment(nil), segments...)
+	for len(pending) > 0 {
+		chosen := -1
+		for i, candidate := range pending {
+			if !conflicts(result, candidate) {
+				chosen = i
+				break
+			}
+			// Pinned and required clips are never skipped over
+			if required[candidate.ClipID] {
+				break
+			}
+		}
+
+		if chosen == -1 {
+			if required[pending[0].ClipID] {
+				logger.Warnf("Clip %d violates source spacing but is required by the composition constraints", pending[0].ClipID)
+				chosen = 0
+			} else {
+				pending = pending[1:]
+				continue
+			}
+		}
+
+		result = append(result, pending[chosen])
+		pending = append(pending[:chosen], pending[chosen+1:]...)
+	}
+
+	return result
+}
+
+// segmentForClip cuts a required clip the same way smart selection would.
+func (sc *SmartCompositor) segmentForClip(clip models.AtomicClip, reason string) ClipSegment {
+	duration := clip.Duration
+	if sc.requirements.MaxClipDuration > 0 && duration > sc.requirements.MaxClipDuration {
+		duration = sc.requirements.MaxClipDuration
+	}
+	startTime, endTime := FindHighlightWindow(HighlightSignalsFromMetadata(clip.Metadata), clip.Duration, duration)
+	score, _ := clip.Metadata["composition_score"].(float64)
+
+	return ClipSegment{
+		ClipID:    clip.ID,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Duration:  duration,
+		Score:     score,
+		Reason:    reason,
+	}
+}
+
+// clipSource identifies the source video a clip was cut from.
+func clipSource(clip models.AtomicClip) string {
+	if source, ok := clip.Metadata["source_video"].(string); ok && source != "" {
+		return source
+	}
+	return clip.FilePath
+}
@@ -0,0 +1,112 @@
+package video_engine
+
+import (
+	"strings"
+
+	"creative-studio-server/models"
+)
+
+const (
+	sameSourcePenalty = 0.5
+	sameScenePenalty  = 0.3
+	sameColorPenalty  = 0.15
+	minBalanceFactor  = 0.1
+)
+
+// selectionState tracks what has been picked so far so candidates can be
+// weighed against ContentBalance ratios and repetition rules.
+type selectionState struct {
+	shotDurations    map[string]float64
+	selectedDuration float64
+	previous         *models.AtomicClip
+}
+
+func newSelectionState() *selectionState {
+	return &selectionState{
+		shotDurations: make(map[string]float64),
+	}
+}
+
+func (s *selectionState) add(clip models.AtomicClip, duration float64) {
+	if shotType := clipShotType(clip); shotType != "" {
+		s.shotDurations[shotType] += duration
+	}
+	s.selectedDuration += duration
+	s.previous = &clip
+}
+
+// adjustedScore applies shot-type balancing and repetition penalties to a
+// candidate's base composition score.
+func (s *selectionState) adjustedScore(clip models.AtomicClip, baseScore float64, requirements CompositionRequirements) float64 {
+	score := baseScore * s.balanceFactor(clip, requirements.ContentBalance)
+
+	if requirements.AvoidRepetition {
+		score -= s.repetitionPenalty(clip)
+	}
+
+	return score
+}
+
+// balanceFactor boosts shot types that are under their requested share of
+// the selected duration and damps those that are over it.
+func (s *selectionState) balanceFactor(clip models.AtomicClip, balance map[string]float64) float64 {
+	if len(balance) == 0 {
+		return 1.0
+	}
+
+	shotType := clipShotType(clip)
+	if shotType == "" {
+		return 1.0
+	}
+
+	target := balance[shotType] // unlisted shot types target a zero share
+	share := 0.0
+	if s.selectedDuration > 0 {
+		share = s.shotDurations[shotType] / s.selectedDuration
+	}
+
+	factor := 1.0 + (target - share)
+	if factor < minBalanceFactor {
+		factor = minBalanceFactor
+	}
+	return factor
+}
+
+func (s *selectionState) repetitionPenalty(clip models.AtomicClip) float64 {
+	if s.previous == nil {
+		return 0
+	}
+
+	penalty := 0.0
+	if clip.FilePath != "" && clip.FilePath == s.previous.FilePath {
+		penalty += sameSourcePenalty
+	}
+	if clip.SceneType != "" && strings.EqualFold(clip.SceneType, s.previous.SceneType) {
+		penalty += sameScenePenalty
+	}
+	if color := clipDominantColor(clip); color != "" && strings.EqualFold(color, clipDominantColor(*s.previous)) {
+		penalty += sameColorPenalty
+	}
+
+	return penalty
+}
+
+// clipShotType returns the clip's shot classification (close_up, medium_shot,
+// wide_shot, ...) or "" when it has not been classified. Analysis sets it;
+// clips may also carry one in their metadata from before.
+func clipShotType(clip models.AtomicClip) string {
+	if clip.VideoAnalysis != nil && clip.VideoAnalysis.ShotType != "" {
+		return clip.VideoAnalysis.ShotType
+	}
+	if shotType, ok := clip.Metadata["shot_type"].(string); ok {
+		return shotType
+	}
+	return ""
+}
+
+func clipDominantColor(clip models.AtomicClip) string {
+	if clip.VideoAnalysis != nil && len(clip.VideoAnalysis.DominantColors) > 0 {
+		return clip.VideoAnalysis.DominantColors[0]
+	}
+	return clip.Color
+}
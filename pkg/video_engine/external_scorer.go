@@ -0,0 +1,110 @@
+package video_engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+)
+
+// ExternalScorerAlgorithm delegates clip scoring to an HTTP service and reuses
+// the smart selection strategy to build the final clip list.
+type ExternalScorerAlgorithm struct {
+	name     string
+	endpoint string
+	client   *http.Client
+	fallback SmartSelectionAlgorithm
+}
+
+type externalScoreRequest struct {
+	Algorithm    string                  `json:"algorithm"`
+	Clip         models.AtomicClip       `json:"clip"`
+	Requirements CompositionRequirements `json:"requirements"`
+	Context      externalScoreContext    `json:"context"`
+}
+
+type externalScoreContext struct {
+	PreviousClipIDs []uint  `json:"previous_clip_ids"`
+	CurrentPosition float64 `json:"current_position"`
+	RemainingTime   float64 `json:"remaining_time"`
+}
+
+type externalScoreResponse struct {
+	Score float64 `json:"score"`
+}
+
+func NewExternalScorerAlgorithm(name, endpoint string, timeout time.Duration) *ExternalScorerAlgorithm {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &ExternalScorerAlgorithm{
+		name:     name,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// RegisterExternalScorer registers an HTTP scorer endpoint as a composition algorithm.
+func RegisterExternalScorer(name, endpoint string, timeout time.Duration) error {
+	if endpoint == "" {
+		return fmt.Errorf("external scorer %s has no endpoint", name)
+	}
+	return RegisterAlgorithm(name, func() CompositionAlgorithm {
+		return NewExternalScorerAlgorithm(name, endpoint, timeout)
+	})
+}
+
+func (a *ExternalScorerAlgorithm) Score(clip models.AtomicClip, requirements CompositionRequirements, context CompositionContext) float64 {
+	score, err := a.requestScore(clip, requirements, context)
+	if err != nil {
+		logger.Warnf("External scorer %s failed for clip %d, using smart selection score: %v", a.name, clip.ID, err)
+		return a.fallback.Score(clip, requirements, context)
+	}
+	return score
+}
+
+func (a *ExternalScorerAlgorithm) SelectClips(clips []models.AtomicClip, requirements CompositionRequirements) ([]ClipSegment, error) {
+	return a.fallback.SelectClips(clips, requirements)
+}
+
+func (a *ExternalScorerAlgorithm) requestScore(clip models.AtomicClip, requirements CompositionRequirements, context CompositionContext) (float64, error) {
+	previousIDs := make([]uint, 0, len(context.PreviousClips))
+	for _, prev := range context.PreviousClips {
+		previousIDs = append(previousIDs, prev.ID)
+	}
+
+	body, err := json.Marshal(externalScoreRequest{
+		Algorithm:    a.name,
+		Clip:         clip,
+		Requirements: requirements,
+		Context: externalScoreContext{
+			PreviousClipIDs: previousIDs,
+			CurrentPosition: context.CurrentPosition,
+			RemainingTime:   context.RemainingTime,
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal score request: %w", err)
+	}
+
+	resp, err := a.client.Post(a.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to call scorer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("scorer returned status %d", resp.StatusCode)
+	}
+
+	var result externalScoreResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode score response: %w", err)
+	}
+
+	return result.Score, nil
+}
@@ -0,0 +1,66 @@
+package video_engine
+
+import (
+	"creative-studio-server/models"
+)
+
+// Per-event score deltas learned from user edits to past compositions.
+var feedbackActionWeights = map[string]float64{
+	models.FeedbackActionKept:      0.05,
+	models.FeedbackActionReordered: 0.02,
+	models.FeedbackActionRemoved:   -0.1,
+	models.FeedbackActionAdded:     0.1,
+}
+
+const maxFeedbackAdjustment = 0.3
+
+// ScoreAdjustments nudges composition scores towards clips and categories
+// the user kept or added in earlier compositions, and away from removed ones.
+type ScoreAdjustments struct {
+	ByClip     map[uint]float64   `json:"by_clip"`
+	ByCategory map[string]float64 `json:"by_category"`
+}
+
+func BuildScoreAdjustments(feedback []models.CompositionFeedback) *ScoreAdjustments {
+	adjustments := &ScoreAdjustments{
+		ByClip:     make(map[uint]float64),
+		ByCategory: make(map[string]float64),
+	}
+
+	categoryEvents := make(map[string]int)
+	for _, event := range feedback {
+		weight := feedbackActionWeights[event.Action]
+		adjustments.ByClip[event.ClipID] += weight
+		if event.Category != "" {
+			adjustments.ByCategory[event.Category] += weight
+			categoryEvents[event.Category]++
+		}
+	}
+
+	for clipID, value := range adjustments.ByClip {
+		adjustments.ByClip[clipID] = clampAdjustment(value)
+	}
+	for category, value := range adjustments.ByCategory {
+		// Categories aggregate many clips, so use the mean per event
+		adjustments.ByCategory[category] = clampAdjustment(value / float64(categoryEvents[category]))
+	}
+
+	return adjustments
+}
+
+func (a *ScoreAdjustments) For(clip models.AtomicClip) float64 {
+	if a == nil {
+		return 0
+	}
+	return clampAdjustment(a.ByClip[clip.ID] + a.ByCategory[clip.Category])
+}
+
+func clampAdjustment(value float64) float64 {
+	if value > maxFeedbackAdjustment {
+		return maxFeedbackAdjustment
+	}
+	if value < -maxFeedbackAdjustment {
+		return -maxFeedbackAdjustment
+	}
+	return value
+}
@@ -1,10 +1,12 @@
 package video_engine
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -41,6 +43,22 @@ type RenderOptions struct {
 	AudioBitrate int     `json:"audio_bitrate"`
 	Preset       string  `json:"preset"`
 	CRF          int     `json:"crf"` // Constant Rate Factor for quality
+	AspectRatio  string  `json:"aspect_ratio"` // e.g. "9:16", crops the timeline before scaling
+}
+
+// PreviewRenderOptions returns low-resolution, fast-preset settings used for
+// quick proxy renders of a composition before a full-quality render.
+func PreviewRenderOptions() *RenderOptions {
+	return &RenderOptions{
+		OutputFormat: "mp4",
+		Quality:      "low",
+		Width:        854,
+		Height:       480,
+		FrameRate:    24,
+		AudioBitrate: 96,
+		Preset:       "veryfast",
+		CRF:          30,
+	}
 }
 
 func NewFFmpegProcessor(cfg *config.Config) *FFmpegProcessor {
@@ -172,6 +190,67 @@ func (fp *FFmpegProcessor) GenerateThumbnail(inputPath, outputPath string, timeO
 	return nil
 }
 
+// ExtractFrame writes the frame at timeOffset seconds as a JPEG, scaled to
+// fit within width x height with its aspect ratio kept. A zero dimension
+// follows from the other; both zero keep the source size.
+func (fp *FFmpegProcessor) ExtractFrame(inputPath, outputPath string, timeOffset float64, width, height int) error {
+	args := []string{
+		// Seeking before the input jumps to the nearest keyframe first
+		"-ss", fmt.Sprintf("%.2f", timeOffset),
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-q:v", "3",
+	}
+	switch {
+	case width > 0 && height > 0:
+		args = append(args, "-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", width, height))
+	case width > 0:
+		args = append(args, "-vf", fmt.Sprintf("scale=%d:-2", width))
+	case height > 0:
+		args = append(args, "-vf", fmt.Sprintf("scale=-2:%d", height))
+	}
+	args = append(args, "-f", "image2", "-y", outputPath)
+
+	output, err := exec.Command(fp.ffmpegPath, args...).CombinedOutput()
+	if err != nil {
+		logger.Errorf("FFmpeg frame extraction failed: %s", lastLines(string(output), 5))
+		return fmt.Errorf("failed to extract frame: %w", err)
+	}
+	if info, err := os.Stat(outputPath); err != nil || info.Size() == 0 {
+		// ffmpeg succeeds without output when the offset is past the end
+		return fmt.Errorf("no frame at %.2fs", timeOffset)
+	}
+
+	return nil
+}
+
+// Transcode re-encodes a video to H.264 and AAC in the container of
+// outputPath's extension, at the options' quality. A positive maxHeight
+// scales larger videos down to it; smaller ones keep their size.
+func (fp *FFmpegProcessor) Transcode(ctx context.Context, inputPath, outputPath string, maxHeight int, options *RenderOptions) error {
+	args := []string{"-i", inputPath}
+	if maxHeight > 0 {
+		args = append(args, "-vf", fmt.Sprintf("scale=-2:'min(ih,%d)'", maxHeight))
+	}
+	args = append(args, fp.buildRenderArgs(options)...)
+	if ext := filepath.Ext(outputPath); ext == ".mp4" || ext == ".mov" {
+		// Players can start before the whole file is downloaded
+		args = append(args, "-movflags", "+faststart")
+	}
+	args = append(args, "-y", outputPath)
+
+	output, err := exec.CommandContext(ctx, fp.ffmpegPath, args...).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logger.Errorf("FFmpeg transcode failed: %s", lastLines(string(output), 5))
+		return fmt.Errorf("failed to transcode video: %w", err)
+	}
+
+	return nil
+}
+
 func (fp *FFmpegProcessor) ConcatenateVideos(inputPaths []string, outputPath string, options *RenderOptions) error {
 	if len(inputPaths) == 0 {
 		return fmt.Errorf("no input files provided")
@@ -287,5 +366,25 @@ func (fp *FFmpegProcessor) ExtractAudio(inputPath, outputPath string) error {
 		return fmt.Errorf("failed to extract audio: %w", err)
 	}
 
+	return nil
+}
+
+// ExtractSpeechAudio writes the audio of a clip as the 16 kHz mono WAV
+// speech recognizers such as Whisper work on.
+func (fp *FFmpegProcessor) ExtractSpeechAudio(inputPath, outputPath string) error {
+	output, err := exec.Command(fp.ffmpegPath,
+		"-i", inputPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", "16000",
+		"-c:a", "pcm_s16le",
+		"-f", "wav",
+		"-y", outputPath,
+	).CombinedOutput()
+	if err != nil {
+		logger.Errorf("FFmpeg speech audio extraction failed: %s", lastLines(string(output), 5))
+		return fmt.Errorf("failed to extract speech audio: %w", err)
+	}
+
 	return nil
 }
\ No newline at end of file
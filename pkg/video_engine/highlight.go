@@ -0,0 +1,244 @@
+package video_engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	highlightSampleInterval = 1.0 // seconds per signal sample
+
+	highlightMotionWeight = 0.5
+	highlightAudioWeight  = 0.3
+	highlightFaceWeight   = 0.2
+
+	highlightSignalsMetadataKey = "highlight_signals"
+)
+
+// HighlightSignals holds per-sample interest signals for a clip. Each slice is
+// indexed by sample, where sample i covers [i*Interval, (i+1)*Interval).
+type HighlightSignals struct {
+	Interval    float64   `json:"interval"`
+	Motion      []float64 `json:"motion"`       // scene change score, 0-1
+	AudioEnergy []float64 `json:"audio_energy"` // linear RMS level, 0-1
+	Faces       []float64 `json:"faces"`        // detected face count
+}
+
+// AnalyzeHighlightSignals samples motion and audio energy once per second
+// using FFmpeg's scene detection and astats filters.
+func (fp *FFmpegProcessor) AnalyzeHighlightSignals(filePath string) (*HighlightSignals, error) {
+	signals := &HighlightSignals{Interval: highlightSampleInterval}
+
+	motionOutput, err := exec.Command(fp.ffmpegPath,
+		"-i", filePath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=1/%g,select='gte(scene\\,0)',metadata=print:file=-", highlightSampleInterval),
+		"-f", "null", "-",
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze motion: %w", err)
+	}
+	signals.Motion = parseFrameMetadataSeries(motionOutput, "lavfi.scene_score", highlightSampleInterval, nil)
+
+	audioOutput, err := exec.Command(fp.ffmpegPath,
+		"-i", filePath,
+		"-vn",
+		"-af", "aresample=16000,asetnsamples=n=16000:p=0,astats=metadata=1:reset=1,ametadata=print:key=lavfi.astats.Overall.RMS_level:file=-",
+		"-f", "null", "-",
+	).Output()
+	if err != nil {
+		// Clips without an audio stream are common, keep the motion signal
+		logger.Debugf("No audio energy for %s: %v", filePath, err)
+	} else {
+		signals.AudioEnergy = parseFrameMetadataSeries(audioOutput, "lavfi.astats.Overall.RMS_level", highlightSampleInterval, dbToLinear)
+	}
+
+	return signals, nil
+}
+
+// parseFrameMetadataSeries reads the output of FFmpeg's metadata=print filter
+// and buckets the values of key by pts_time.
+func parseFrameMetadataSeries(output []byte, key string, interval float64, transform func(float64) float64) []float64 {
+	var series []float64
+	currentIndex := -1
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if idx := strings.Index(line, "pts_time:"); idx >= 0 {
+			field := strings.Fields(line[idx+len("pts_time:"):])
+			if len(field) > 0 {
+				if t, err := strconv.ParseFloat(field[0], 64); err == nil {
+					currentIndex = int(t / interval)
+				}
+			}
+			continue
+		}
+
+		if currentIndex < 0 || !strings.HasPrefix(line, key+"=") {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimPrefix(line, key+"="), 64)
+		if err != nil {
+			continue
+		}
+		if transform != nil {
+			value = transform(value)
+		}
+
+		for len(series) <= currentIndex {
+			series = append(series, 0)
+		}
+		if value > series[currentIndex] {
+			series[currentIndex] = value
+		}
+	}
+
+	return series
+}
+
+func dbToLinear(db float64) float64 {
+	if math.IsInf(db, -1) || math.IsNaN(db) {
+		return 0
+	}
+	return math.Pow(10, db/20)
+}
+
+// HighlightSignalsFromMetadata loads signals previously stored on a clip.
+func HighlightSignalsFromMetadata(metadata models.JSON) *HighlightSignals {
+	raw, ok := metadata[highlightSignalsMetadataKey]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+
+	var signals HighlightSignals
+	if err := json.Unmarshal(data, &signals); err != nil || signals.Interval <= 0 {
+		return nil
+	}
+	return &signals
+}
+
+// StoreHighlightSignals attaches signals to clip metadata for later compositions.
+func StoreHighlightSignals(metadata models.JSON, signals *HighlightSignals) models.JSON {
+	if metadata == nil {
+		metadata = make(models.JSON)
+	}
+	metadata[highlightSignalsMetadataKey] = signals
+	return metadata
+}
+
+// StoreFaceSignal sets the face signal of the highlight signals stored on a
+// clip from face counts sampled every interval seconds.
+func StoreFaceSignal(metadata models.JSON, counts []float64, interval, clipDuration float64) models.JSON {
+	signals := HighlightSignalsFromMetadata(metadata)
+	if signals == nil {
+		signals = &HighlightSignals{Interval: highlightSampleInterval}
+	}
+
+	samples := int(math.Ceil(clipDuration / signals.Interval))
+	faces := make([]float64, 0, samples)
+	for i := 0; i < samples; i++ {
+		sample := int(float64(i) * signals.Interval / interval)
+		if sample >= len(counts) {
+			break
+		}
+		faces = append(faces, counts[sample])
+	}
+	signals.Faces = faces
+
+	return StoreHighlightSignals(metadata, signals)
+}
+
+// FindHighlightWindow returns the in/out points of the most interesting
+// window of the given length inside a clip. Without signals it falls back
+// to the head of the clip.
+func FindHighlightWindow(signals *HighlightSignals, clipDuration, windowDuration float64) (float64, float64) {
+	if windowDuration >= clipDuration {
+		return 0, clipDuration
+	}
+	if signals == nil || signals.Interval <= 0 {
+		return 0, windowDuration
+	}
+
+	samples := int(math.Ceil(clipDuration / signals.Interval))
+	if samples == 0 {
+		return 0, windowDuration
+	}
+
+	motion := normalizeSeries(signals.Motion, samples)
+	audio := normalizeSeries(signals.AudioEnergy, samples)
+	faces := normalizeSeries(signals.Faces, samples)
+
+	interest := make([]float64, samples)
+	for i := range interest {
+		interest[i] = motion[i]*highlightMotionWeight + audio[i]*highlightAudioWeight + faces[i]*highlightFaceWeight
+	}
+
+	windowSamples := int(math.Ceil(windowDuration / signals.Interval))
+	if windowSamples < 1 {
+		windowSamples = 1
+	}
+	lastStart := int((clipDuration - windowDuration) / signals.Interval)
+
+	bestStart := 0
+	bestScore := -1.0
+	windowScore := 0.0
+	for i := 0; i < windowSamples && i < samples; i++ {
+		windowScore += interest[i]
+	}
+
+	for start := 0; start <= lastStart; start++ {
+		if start > 0 {
+			windowScore -= interest[start-1]
+			if end := start + windowSamples - 1; end < samples {
+				windowScore += interest[end]
+			}
+		}
+		if windowScore > bestScore {
+			bestScore = windowScore
+			bestStart = start
+		}
+	}
+
+	startTime := float64(bestStart) * signals.Interval
+	if startTime+windowDuration > clipDuration {
+		startTime = clipDuration - windowDuration
+	}
+	return startTime, startTime + windowDuration
+}
+
+// normalizeSeries scales values into [0, 1] and pads/truncates to length.
+func normalizeSeries(values []float64, length int) []float64 {
+	normalized := make([]float64, length)
+
+	maxValue := 0.0
+	for _, v := range values {
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+	if maxValue == 0 {
+		return normalized
+	}
+
+	for i := 0; i < length && i < len(values); i++ {
+		normalized[i] = values[i] / maxValue
+	}
+	return normalized
+}
@@ -0,0 +1,104 @@
+package video_engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"creative-studio-server/models"
+)
+
+// minBeatAlignedRatio stops beat alignment from cutting the section too short
+// when beats are sparse near its end.
+const minBeatAlignedRatio = 0.5
+
+// MusicTrack is the timing information of a soundtrack used to size a composition.
+type MusicTrack struct {
+	ClipID        uint      `json:"clip_id"`
+	Duration      float64   `json:"duration"`
+	BPM           float64   `json:"bpm,omitempty"`
+	BeatTimes     []float64 `json:"beat_times"`               // seconds from the start of the track
+	DownbeatTimes []float64 `json:"downbeat_times,omitempty"` // the first beat of every bar
+}
+
+// MusicTrackFromClip reads track timing from a music clip: its music
+// analysis when it has one, else beat times stored in its metadata.
+func MusicTrackFromClip(clip models.AtomicClip) *MusicTrack {
+	track := &MusicTrack{
+		ClipID:   clip.ID,
+		Duration: clip.Duration,
+	}
+
+	if analysis := clip.MusicAnalysis; analysis != nil && len(analysis.BeatTimes) > 0 {
+		track.BPM = analysis.BPM
+		track.BeatTimes = append([]float64{}, analysis.BeatTimes...)
+		track.DownbeatTimes = append([]float64{}, analysis.DownbeatTimes...)
+	} else if raw, ok := clip.Metadata["beat_times"]; ok {
+		if data, err := json.Marshal(raw); err == nil {
+			json.Unmarshal(data, &track.BeatTimes)
+		}
+	}
+	sort.Float64s(track.BeatTimes)
+	sort.Float64s(track.DownbeatTimes)
+
+	return track
+}
+
+// ResolveTargetDuration fills TargetDuration from the music track when it was
+// omitted, limited to the requested section, and aligns the end of the
+// composition to the last bar, or else beat, inside that section. A missing
+// MusicTempo is taken from the track's tempo.
+func ResolveTargetDuration(requirements *CompositionRequirements, track *MusicTrack) error {
+	if track == nil {
+		if requirements.TargetDuration <= 0 {
+			return fmt.Errorf("target_duration is required when no music track is attached")
+		}
+		return nil
+	}
+
+	sectionStart := requirements.MusicSectionStart
+	if sectionStart < 0 || sectionStart >= track.Duration {
+		sectionStart = 0
+	}
+	sectionEnd := requirements.MusicSectionEnd
+	if sectionEnd <= sectionStart || sectionEnd > track.Duration {
+		sectionEnd = track.Duration
+	}
+
+	target := requirements.TargetDuration
+	if target <= 0 || sectionStart+target > sectionEnd {
+		target = sectionEnd - sectionStart
+	}
+
+	// Ending on a bar rather than mid-phrase lets the music, and any
+	// credits over it, finish naturally
+	for _, beats := range [][]float64{track.DownbeatTimes, track.BeatTimes} {
+		if beat, ok := lastBeatBefore(beats, sectionStart, sectionStart+target); ok {
+			if aligned := beat - sectionStart; aligned >= target*minBeatAlignedRatio {
+				target = aligned
+				break
+			}
+		}
+	}
+
+	if target <= 0 {
+		return fmt.Errorf("music track %d is too short to derive a target duration", track.ClipID)
+	}
+
+	requirements.MusicSectionStart = sectionStart
+	requirements.MusicSectionEnd = sectionStart + target
+	requirements.TargetDuration = target
+	if requirements.MusicTempo == "" {
+		requirements.MusicTempo = MusicTempo(track.BPM)
+	}
+	return nil
+}
+
+func lastBeatBefore(beats []float64, after, limit float64) (float64, bool) {
+	for i := len(beats) - 1; i >= 0; i-- {
+		if beats[i] <= limit && beats[i] > after {
+			return beats[i], true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,259 @@
+package video_engine
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"creative-studio-server/models"
+)
+
+const (
+	defaultOptimizerIterations = 200
+	maxOptimizerIterations     = 5000
+	optimizerPopulationSize    = 40
+	optimizerEliteCount        = 2
+	optimizerTournamentSize    = 3
+	optimizerMutationRate      = 0.2
+	optimizerMaxCandidates     = 60
+)
+
+// Default weights of each objective when the request does not override them.
+var defaultObjectiveWeights = map[string]float64{
+	"duration":  0.3,
+	"cohesion":  0.25,
+	"theme":     0.3,
+	"diversity": 0.15,
+}
+
+// OptimizerAlgorithm searches clip orderings with a genetic algorithm,
+// scoring each ordering against duration fit, cohesion, theme fitness and
+// diversity. Genomes are permutations of the candidate clips; a genome is
+// decoded by taking clips in order until the target duration is filled.
+type OptimizerAlgorithm struct {
+	scorer SmartSelectionAlgorithm
+}
+
+type optimizerGenome struct {
+	order   []int
+	fitness float64
+}
+
+func (a *OptimizerAlgorithm) Score(clip models.AtomicClip, requirements CompositionRequirements, context CompositionContext) float64 {
+	return a.scorer.Score(clip, requirements, context)
+}
+
+func (a *OptimizerAlgorithm) SelectClips(clips []models.AtomicClip, requirements CompositionRequirements) ([]ClipSegment, error) {
+	candidates := make([]models.AtomicClip, 0, len(clips))
+	for _, clip := range clips {
+		if clip.Duration >= requirements.MinClipDuration {
+			candidates = append(candidates, clip)
+		}
+		// Clips arrive sorted by score, keep the search space bounded
+		if len(candidates) == optimizerMaxCandidates {
+			break
+		}
+	}
+	if len(candidates) == 0 || requirements.TargetDuration <= 0 {
+		return []ClipSegment{}, nil
+	}
+
+	seed := requirements.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	iterations := requirements.OptimizerIterations
+	if iterations <= 0 {
+		iterations = defaultOptimizerIterations
+	}
+	if iterations > maxOptimizerIterations {
+		iterations = maxOptimizerIterations
+	}
+
+	weights := objectiveWeights(requirements.ObjectiveWeights)
+	evaluate := func(order []int) float64 {
+		return a.fitness(candidates, order, requirements, weights)
+	}
+
+	population := make([]optimizerGenome, optimizerPopulationSize)
+	for i := range population {
+		order := rng.Perm(len(candidates))
+		if i == 0 {
+			// Seed the population with the greedy score ordering
+			for j := range order {
+				order[j] = j
+			}
+		}
+		population[i] = optimizerGenome{order: order, fitness: evaluate(order)}
+	}
+
+	for generation := 0; generation < iterations; generation++ {
+		sort.Slice(population, func(i, j int) bool { return population[i].fitness > population[j].fitness })
+
+		next := make([]optimizerGenome, 0, optimizerPopulationSize)
+		next = append(next, population[:optimizerEliteCount]...)
+
+		for len(next) < optimizerPopulationSize {
+			parentA := tournamentSelect(population, rng)
+			parentB := tournamentSelect(population, rng)
+			child := orderCrossover(parentA.order, parentB.order, rng)
+			if rng.Float64() < optimizerMutationRate {
+				swapMutation(child, rng)
+			}
+			next = append(next, optimizerGenome{order: child, fitness: evaluate(child)})
+		}
+
+		population = next
+	}
+
+	sort.Slice(population, func(i, j int) bool { return population[i].fitness > population[j].fitness })
+	return a.decode(candidates, population[0].order, requirements), nil
+}
+
+func (a *OptimizerAlgorithm) decode(candidates []models.AtomicClip, order []int, requirements CompositionRequirements) []ClipSegment {
+	var segments []ClipSegment
+	remaining := requirements.TargetDuration
+
+	for _, index := range order {
+		if remaining <= requirements.MinClipDuration {
+			break
+		}
+
+		clip := candidates[index]
+		duration := clip.Duration
+		if requirements.MaxClipDuration > 0 && duration > requirements.MaxClipDuration {
+			duration = requirements.MaxClipDuration
+		}
+		if duration > remaining {
+			duration = remaining
+		}
+
+		start, end := FindHighlightWindow(HighlightSignalsFromMetadata(clip.Metadata), clip.Duration, duration)
+		score, _ := clip.Metadata["composition_score"].(float64)
+
+		segments = append(segments, ClipSegment{
+			ClipID:    clip.ID,
+			StartTime: start,
+			EndTime:   end,
+			Duration:  duration,
+			Score:     score,
+			Reason:    "Multi-objective optimizer",
+		})
+		remaining -= duration
+	}
+
+	return segments
+}
+
+func (a *OptimizerAlgorithm) fitness(candidates []models.AtomicClip, order []int, requirements CompositionRequirements, weights map[string]float64) float64 {
+	segments := a.decode(candidates, order, requirements)
+	if len(segments) == 0 {
+		return 0
+	}
+
+	byID := make(map[uint]models.AtomicClip, len(segments))
+	for _, index := range order[:len(segments)] {
+		byID[candidates[index].ID] = candidates[index]
+	}
+
+	totalDuration := 0.0
+	themeScore := 0.0
+	categories := make(map[string]bool)
+	scenes := make(map[string]bool)
+	for _, segment := range segments {
+		totalDuration += segment.Duration
+		themeScore += segment.Score
+		clip := byID[segment.ClipID]
+		categories[clip.Category] = true
+		scenes[clip.SceneType] = true
+	}
+	themeScore /= float64(len(segments))
+
+	durationScore := 1 - math.Min(math.Abs(requirements.TargetDuration-totalDuration)/requirements.TargetDuration, 1)
+
+	cohesionScore := 1.0
+	if len(segments) > 1 {
+		cohesionScore = 0
+		for i := 0; i < len(segments)-1; i++ {
+			cohesionScore += ClipSimilarity(byID[segments[i].ClipID], byID[segments[i+1].ClipID])
+		}
+		cohesionScore /= float64(len(segments) - 1)
+	}
+
+	diversityScore := float64(len(categories)+len(scenes)) / float64(2*len(segments))
+
+	return weights["duration"]*durationScore +
+		weights["cohesion"]*cohesionScore +
+		weights["theme"]*themeScore +
+		weights["diversity"]*diversityScore
+}
+
+func objectiveWeights(overrides map[string]float64) map[string]float64 {
+	weights := make(map[string]float64, len(defaultObjectiveWeights))
+	for name, weight := range defaultObjectiveWeights {
+		weights[name] = weight
+	}
+	for name, weight := range overrides {
+		if _, known := weights[name]; known && weight >= 0 {
+			weights[name] = weight
+		}
+	}
+	return weights
+}
+
+func tournamentSelect(population []optimizerGenome, rng *rand.Rand) optimizerGenome {
+	best := population[rng.Intn(len(population))]
+	for i := 1; i < optimizerTournamentSize; i++ {
+		candidate := population[rng.Intn(len(population))]
+		if candidate.fitness > best.fitness {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// orderCrossover (OX1) copies a slice of parent A and fills the remaining
+// positions with parent B's genes in order, keeping the child a permutation.
+func orderCrossover(parentA, parentB []int, rng *rand.Rand) []int {
+	size := len(parentA)
+	child := make([]int, size)
+	if size < 2 {
+		copy(child, parentA)
+		return child
+	}
+
+	start, end := rng.Intn(size), rng.Intn(size)
+	if start > end {
+		start, end = end, start
+	}
+
+	used := make(map[int]bool, size)
+	for i := start; i <= end; i++ {
+		child[i] = parentA[i]
+		used[parentA[i]] = true
+	}
+
+	position := (end + 1) % size
+	for i := 0; i < size; i++ {
+		gene := parentB[(end+1+i)%size]
+		if used[gene] {
+			continue
+		}
+		child[position] = gene
+		used[gene] = true
+		position = (position + 1) % size
+	}
+
+	return child
+}
+
+func swapMutation(order []int, rng *rand.Rand) {
+	if len(order) < 2 {
+		return
+	}
+	i, j := rng.Intn(len(order)), rng.Intn(len(order))
+	order[i], order[j] = order[j], order[i]
+}
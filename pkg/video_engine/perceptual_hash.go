@@ -0,0 +1,103 @@
+package video_engine
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+const (
+	// Frames hashed per clip, spread evenly across it so re-uploads of the
+	// same footage line up frame for frame
+	hashFrames = 8
+
+	// Frames are shrunk to hashFrameSize squared gray pixels, of which the
+	// lowest hashDCTSize squared frequencies make up a frame's hash
+	hashFrameSize = 32
+	hashDCTSize   = 8
+)
+
+// hashCosines holds the DCT-II basis for the frequencies kept in a hash.
+var hashCosines = func() [hashDCTSize][hashFrameSize]float64 {
+	var cosines [hashDCTSize][hashFrameSize]float64
+	for u := 0; u < hashDCTSize; u++ {
+		for x := 0; x < hashFrameSize; x++ {
+			cosines[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * hashFrameSize))
+		}
+	}
+	return cosines
+}()
+
+// PerceptualHash fingerprints a clip's picture with a 64-bit pHash of each
+// of hashFrames frames sampled evenly across it, as one hex string. Frames
+// that look alike hash to few differing bits however the clip was
+// re-encoded, scaled or lightly graded.
+func (fp *FFmpegProcessor) PerceptualHash(filePath string, duration float64) (string, error) {
+	rate := 1.0
+	if duration > 0 {
+		rate = hashFrames / duration
+	}
+	output, err := exec.Command(fp.ffmpegPath,
+		"-i", filePath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=%g,scale=%d:%d:flags=area,format=gray", rate, hashFrameSize, hashFrameSize),
+		"-frames:v", fmt.Sprint(hashFrames),
+		"-f", "rawvideo",
+		"-",
+	).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to sample frames for hashing: %w", err)
+	}
+
+	frameBytes := hashFrameSize * hashFrameSize
+	if len(output) < frameBytes {
+		return "", fmt.Errorf("no frames to hash in %s", filePath)
+	}
+
+	var hash strings.Builder
+	for offset := 0; offset+frameBytes <= len(output); offset += frameBytes {
+		fmt.Fprintf(&hash, "%016x", frameHash(output[offset:offset+frameBytes]))
+	}
+	return hash.String(), nil
+}
+
+// frameHash sets a bit for each low frequency of the frame above their
+// median, leaving out the DC term, which only carries the brightness.
+func frameHash(pixels []byte) uint64 {
+	// The DCT is separable: rows first, then the columns of the result
+	var rows [hashFrameSize][hashDCTSize]float64
+	for y := 0; y < hashFrameSize; y++ {
+		for u := 0; u < hashDCTSize; u++ {
+			sum := 0.0
+			for x := 0; x < hashFrameSize; x++ {
+				sum += float64(pixels[y*hashFrameSize+x]) * hashCosines[u][x]
+			}
+			rows[y][u] = sum
+		}
+	}
+
+	coefficients := make([]float64, 0, hashDCTSize*hashDCTSize)
+	for v := 0; v < hashDCTSize; v++ {
+		for u := 0; u < hashDCTSize; u++ {
+			sum := 0.0
+			for y := 0; y < hashFrameSize; y++ {
+				sum += rows[y][u] * hashCosines[v][y]
+			}
+			coefficients = append(coefficients, sum)
+		}
+	}
+
+	sorted := append([]float64{}, coefficients[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var hash uint64
+	for i, coefficient := range coefficients[1:] {
+		if coefficient > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
@@ -0,0 +1,257 @@
+package video_engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// renderChunkDuration is the output length rendered per checkpoint
+	renderChunkDuration = 60.0
+	// minRenderChunkDuration folds a short trailing chunk into the one before
+	minRenderChunkDuration = 10.0
+)
+
+// RenderOutputsCheckpointed renders long timelines chunk by chunk, keeping
+// each finished chunk in checkpointDir so a retried render only encodes the
+// chunks that are missing. The chunks of every output are then joined
+// without re-encoding. Short timelines are rendered in one pass.
+func (fp *FFmpegProcessor) RenderOutputsCheckpointed(ctx context.Context, segments []RenderSegment, canvas *RenderOptions, outputs []RenderOutput, checkpointDir string, onProgress RenderProgressFunc) error {
+	chunks := splitRenderChunks(segments, renderChunkDuration)
+	if len(chunks) < 2 || checkpointDir == "" {
+		return fp.RenderOutputs(ctx, segments, canvas, outputs, onProgress)
+	}
+	if len(outputs) == 0 {
+		return fmt.Errorf("no outputs to render")
+	}
+	if err := os.MkdirAll(checkpointDir, 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+
+	totalDuration := 0.0
+	for _, segment := range segments {
+		totalDuration += segment.Duration
+	}
+
+	chunkFiles := make([][]string, len(outputs))
+	rendered := 0.0
+	resumed := 0
+	for i, chunk := range chunks {
+		chunkDuration := 0.0
+		for _, segment := range chunk {
+			chunkDuration += segment.Duration
+		}
+
+		var pending []RenderOutput
+		for j, output := range outputs {
+			path := filepath.Join(checkpointDir, renderChunkName(i, chunk, canvas, output.Options))
+			chunkFiles[j] = append(chunkFiles[j], path)
+			if _, err := os.Stat(path); err == nil {
+				continue
+			}
+			pending = append(pending, RenderOutput{Path: path, Options: chunkRenderOptions(output.Options)})
+		}
+
+		if len(pending) == 0 {
+			resumed++
+		} else {
+			offset := rendered
+			var chunkProgress RenderProgressFunc
+			if onProgress != nil {
+				chunkProgress = func(progress float64) {
+					onProgress((offset + progress*chunkDuration) / totalDuration)
+				}
+			}
+			if err := fp.renderChunk(ctx, chunk, canvas, pending, chunkProgress); err != nil {
+				return err
+			}
+		}
+
+		rendered += chunkDuration
+		if onProgress != nil {
+			onProgress(min(rendered/totalDuration, 1))
+		}
+	}
+
+	if resumed > 0 {
+		logger.Infof("Resumed render from checkpoint: %d of %d chunks were already rendered", resumed, len(chunks))
+	}
+
+	for j, output := range outputs {
+		if err := fp.joinRenderChunks(ctx, chunkFiles[j], output, filepath.Join(checkpointDir, fmt.Sprintf("concat_%d.txt", j))); err != nil {
+			return err
+		}
+	}
+
+	// Only succeeds once no other output still has chunks here
+	os.Remove(checkpointDir)
+	return nil
+}
+
+// PruneRenderCheckpoints removes checkpoint directories under dir that have
+// not been touched for maxAge, left behind by renders that were never retried.
+func PruneRenderCheckpoints(dir string, maxAge time.Duration) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.IsDir() || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			logger.Warnf("Failed to remove render checkpoint %s: %v", entry.Name(), err)
+		}
+	}
+}
+
+// renderChunk encodes one chunk for every pending output, writing to
+// temporary files first so an interrupted chunk is never mistaken for a
+// finished one.
+func (fp *FFmpegProcessor) renderChunk(ctx context.Context, chunk []RenderSegment, canvas *RenderOptions, outputs []RenderOutput, onProgress RenderProgressFunc) error {
+	partial := make([]RenderOutput, len(outputs))
+	for i, output := range outputs {
+		partial[i] = RenderOutput{
+			Path:    strings.TrimSuffix(output.Path, ".ts") + ".partial.ts",
+			Options: output.Options,
+		}
+	}
+
+	if err := fp.RenderOutputs(ctx, chunk, canvas, partial, onProgress); err != nil {
+		for _, output := range partial {
+			os.Remove(output.Path)
+		}
+		return err
+	}
+
+	for i, output := range outputs {
+		if err := os.Rename(partial[i].Path, output.Path); err != nil {
+			return fmt.Errorf("failed to store render chunk: %w", err)
+		}
+	}
+	return nil
+}
+
+// joinRenderChunks concatenates the chunks of one output into its final
+// file, then drops the chunks.
+func (fp *FFmpegProcessor) joinRenderChunks(ctx context.Context, chunkFiles []string, output RenderOutput, listPath string) error {
+	var list strings.Builder
+	for _, path := range chunkFiles {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve render chunk path: %w", err)
+		}
+		fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(absPath, "'", `'\''`))
+	}
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write render chunk list: %w", err)
+	}
+	defer os.Remove(listPath)
+
+	if err := os.MkdirAll(filepath.Dir(output.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	args := []string{"-y", "-f", "concat", "-safe", "0", "-i", listPath, "-c", "copy"}
+	if output.Options != nil && output.Options.OutputFormat == "hls" {
+		args = append(args, hlsOutputArgs(output.Path)...)
+	}
+	args = append(args, output.Path)
+
+	cmd := exec.CommandContext(ctx, fp.ffmpegPath, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logger.Errorf("FFmpeg chunk join failed: %s", lastLines(stderr.String(), 5))
+		return fmt.Errorf("failed to join render chunks: %w", err)
+	}
+
+	for _, path := range chunkFiles {
+		os.Remove(path)
+	}
+	return nil
+}
+
+// splitRenderChunks cuts the timeline into chunks of about chunkDuration
+// seconds of output, splitting segments that cross a chunk boundary.
+func splitRenderChunks(segments []RenderSegment, chunkDuration float64) [][]RenderSegment {
+	var chunks [][]RenderSegment
+	var current []RenderSegment
+	currentDuration := 0.0
+
+	for _, segment := range segments {
+		for segment.Duration > 0.001 {
+			part := segment
+			part.Duration = min(segment.Duration, chunkDuration-currentDuration)
+			part.Captions = segment.Captions.Window(0, part.Duration)
+			current = append(current, part)
+			currentDuration += part.Duration
+			segment.Captions = segment.Captions.Window(part.Duration, segment.Duration-part.Duration)
+			segment.StartTime += part.Duration
+			segment.Duration -= part.Duration
+
+			if currentDuration >= chunkDuration-0.001 {
+				chunks = append(chunks, current)
+				current, currentDuration = nil, 0
+			}
+		}
+	}
+
+	if len(current) > 0 {
+		if len(chunks) > 0 && currentDuration < minRenderChunkDuration {
+			chunks[len(chunks)-1] = append(chunks[len(chunks)-1], current...)
+		} else {
+			chunks = append(chunks, current)
+		}
+	}
+	return chunks
+}
+
+// renderChunkName identifies a chunk by everything that affects its
+// content, so chunks of an edited timeline or changed settings are not
+// reused.
+func renderChunkName(index int, chunk []RenderSegment, canvas *RenderOptions, options *RenderOptions) string {
+	hash := sha256.New()
+	for _, segment := range chunk {
+		fmt.Fprintf(hash, "%s|%.3f|%.3f|%t", segment.FilePath, segment.StartTime, segment.Duration, segment.HasAudio)
+		if info, err := os.Stat(segment.FilePath); err == nil {
+			fmt.Fprintf(hash, "|%d|%d", info.Size(), info.ModTime().UnixNano())
+		}
+		for _, caption := range segment.Captions {
+			fmt.Fprintf(hash, "|%.3f|%.3f|%q", caption.Start, caption.End, caption.Text)
+		}
+		hash.Write([]byte{'\n'})
+	}
+	if canvas != nil {
+		fmt.Fprintf(hash, "canvas|%+v\n", *canvas)
+	}
+	if options != nil {
+		fmt.Fprintf(hash, "output|%+v\n", *options)
+	}
+
+	return fmt.Sprintf("chunk_%03d_%x.ts", index, hash.Sum(nil)[:8])
+}
+
+// chunkRenderOptions encodes a chunk with the output's settings into
+// MPEG-TS, which concatenates cleanly into any final container.
+func chunkRenderOptions(options *RenderOptions) *RenderOptions {
+	chunk := RenderOptions{}
+	if options != nil {
+		chunk = *options
+	}
+	chunk.OutputFormat = "ts"
+	return &chunk
+}
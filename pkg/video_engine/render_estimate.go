@@ -0,0 +1,128 @@
+package video_engine
+
+import (
+	"math"
+	"strings"
+
+	"creative-studio-server/models"
+)
+
+const (
+	// defaultRenderThroughput is the work rendered per wall-clock second when
+	// there is no history yet: roughly realtime for 1080p30 at the medium preset.
+	defaultRenderThroughput = 1.0
+	referencePixels         = 1920 * 1080
+	referenceFPS            = 30.0
+)
+
+// Source codecs that are more expensive to decode than H.264
+var decodeCostFactors = map[string]float64{
+	"hevc":   1.3,
+	"h265":   1.3,
+	"prores": 1.2,
+	"vp9":    1.4,
+	"av1":    1.6,
+}
+
+// Encoder presets relative to medium
+var presetCostFactors = map[string]float64{
+	"ultrafast": 0.3,
+	"superfast": 0.4,
+	"veryfast":  0.5,
+	"faster":    0.7,
+	"fast":      0.85,
+	"medium":    1.0,
+	"slow":      1.6,
+	"slower":    2.5,
+	"veryslow":  4.0,
+}
+
+// RenderEstimate predicts how long a render takes before it is submitted.
+type RenderEstimate struct {
+	OutputDuration   float64 `json:"output_duration"`
+	Work             float64 `json:"work"`       // 1080p30 medium-preset output seconds
+	Throughput       float64 `json:"throughput"` // work rendered per second
+	EstimatedSeconds int     `json:"estimated_seconds"`
+	Cost             float64 `json:"cost,omitempty"`
+	Currency         string  `json:"currency,omitempty"`
+	Basis            string  `json:"basis"` // history or default
+	SampleSize       int     `json:"sample_size"`
+}
+
+// RenderWork measures a render in reference units so renders of different
+// length, resolution and source codec can share one throughput figure.
+func RenderWork(segments []RenderSegment, clips map[uint]models.AtomicClip, options *RenderOptions) float64 {
+	decode := 0.0
+	for _, segment := range segments {
+		factor := 1.0
+		if clip, ok := clips[segment.ClipID]; ok {
+			if f, known := decodeCostFactors[strings.ToLower(clip.Codec)]; known {
+				factor = f
+			}
+		}
+		decode += segment.Duration * factor
+	}
+
+	return decode * outputCostFactor(options)
+}
+
+func outputCostFactor(options *RenderOptions) float64 {
+	if options == nil {
+		return 1.0
+	}
+
+	factor := 1.0
+	if options.Width > 0 && options.Height > 0 {
+		factor *= float64(options.Width*options.Height) / referencePixels
+	}
+	if options.FrameRate > 0 {
+		factor *= options.FrameRate / referenceFPS
+	}
+	if f, known := presetCostFactors[options.Preset]; known {
+		factor *= f
+	}
+	return factor
+}
+
+// ThroughputFromHistory derives throughput from finished renders given as
+// pairs of work and elapsed seconds.
+func ThroughputFromHistory(work, elapsed []float64) (float64, bool) {
+	totalWork, totalElapsed := 0.0, 0.0
+	for i := range work {
+		if i >= len(elapsed) || work[i] <= 0 || elapsed[i] <= 0 {
+			continue
+		}
+		totalWork += work[i]
+		totalElapsed += elapsed[i]
+	}
+	if totalWork == 0 || totalElapsed == 0 {
+		return 0, false
+	}
+	return totalWork / totalElapsed, true
+}
+
+// EstimateRender turns render work into an ETA, falling back to the default
+// throughput when no history is available, and prices it when costPerMinute
+// is set.
+func EstimateRender(outputDuration, work, throughput float64, samples int, costPerMinute float64, currency string) *RenderEstimate {
+	estimate := &RenderEstimate{
+		OutputDuration: outputDuration,
+		Work:           work,
+		Throughput:     throughput,
+		Basis:          "history",
+		SampleSize:     samples,
+	}
+	if throughput <= 0 {
+		estimate.Throughput = defaultRenderThroughput
+		estimate.Basis = "default"
+		estimate.SampleSize = 0
+	}
+
+	estimate.EstimatedSeconds = int(math.Ceil(work / estimate.Throughput))
+	if costPerMinute > 0 {
+		estimate.Cost = math.Round(float64(estimate.EstimatedSeconds)/60*costPerMinute*100) / 100
+		estimate.Currency = currency
+	}
+
+	return estimate
+}
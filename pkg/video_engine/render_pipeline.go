@@ -0,0 +1,445 @@
+package video_engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	renderAudioSampleRate = 48000
+	defaultRenderWidth    = 1920
+	defaultRenderHeight   = 1080
+	defaultRenderFPS      = 30
+)
+
+// RenderSegment is one cut of a source file placed on the output timeline.
+type RenderSegment struct {
+	ClipID    uint    `json:"clip_id"`
+	FilePath  string  `json:"file_path"`
+	StartTime float64 `json:"start_time"`
+	Duration  float64 `json:"duration"`
+	HasAudio  bool    `json:"has_audio"`
+	// Captions are timed from the start of the segment
+	Captions models.TranscriptSegments `json:"captions,omitempty"`
+}
+
+// RenderProgressFunc receives render progress as a fraction between 0 and 1.
+type RenderProgressFunc func(progress float64)
+
+// RenderOptionsForTask resolves the output settings of a render task,
+// falling back to the project's canvas for anything the task leaves unset.
+func RenderOptionsForTask(task models.RenderTask, project models.Project) *RenderOptions {
+	if task.Mode == models.RenderModePreview {
+		options := PreviewRenderOptions()
+		if task.AspectRatio != "" {
+			options.AspectRatio = task.AspectRatio
+			options.Width, options.Height = fitAspectRatio(options.Width, options.Height, task.AspectRatio)
+		}
+		return options
+	}
+
+	options := &RenderOptions{
+		OutputFormat: task.OutputFormat,
+		Quality:      task.Quality,
+		Width:        project.Width,
+		Height:       project.Height,
+		FrameRate:    project.FrameRate,
+		AspectRatio:  task.AspectRatio,
+	}
+
+	if options.Width <= 0 || options.Height <= 0 {
+		options.Width, options.Height = defaultRenderWidth, defaultRenderHeight
+	}
+	if width, height, ok := parseResolution(task.Resolution); ok {
+		options.Width, options.Height = width, height
+	} else if task.AspectRatio != "" {
+		options.Width, options.Height = fitAspectRatio(options.Width, options.Height, task.AspectRatio)
+	}
+	if task.FrameRate > 0 {
+		options.FrameRate = task.FrameRate
+	}
+	if options.FrameRate <= 0 {
+		options.FrameRate = defaultRenderFPS
+	}
+	if options.OutputFormat == "" {
+		options.OutputFormat = "mp4"
+	}
+
+	return options
+}
+
+// RenderOutputPath is where a render task's output is written. HLS renders
+// get a directory holding the playlist and its segments.
+func RenderOutputPath(dir, taskID, format string) string {
+	if format == "hls" {
+		return filepath.Join(dir, taskID, "index.m3u8")
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.%s", taskID, format))
+}
+
+// RemoveRenderOutput deletes a render output, including HLS segments.
+func RemoveRenderOutput(path string) error {
+	if filepath.Ext(path) == ".m3u8" {
+		return os.RemoveAll(filepath.Dir(path))
+	}
+	return os.Remove(path)
+}
+
+// RenderOutputSize is the size of a render output on disk, summing the
+// segments of an HLS output.
+func RenderOutputSize(path string) int64 {
+	if filepath.Ext(path) != ".m3u8" {
+		if stat, err := os.Stat(path); err == nil {
+			return stat.Size()
+		}
+		return 0
+	}
+
+	var size int64
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return 0
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && !entry.IsDir() {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// BuildRenderSegments turns a project timeline into render segments in
+// timeline order using the given clips for source files. Segments of clips
+// loaded with their transcript are captioned with it.
+func BuildRenderSegments(timeline *models.ProjectTimeline, clips map[uint]models.AtomicClip) ([]RenderSegment, error) {
+	segments := make([]RenderSegment, 0, len(timeline.Clips))
+	for _, entry := range timeline.Clips {
+		clip, ok := clips[entry.ClipID]
+		if !ok {
+			return nil, fmt.Errorf("clip %d on the timeline is not available", entry.ClipID)
+		}
+
+		duration := entry.Duration
+		if duration <= 0 {
+			duration = entry.EndTime - entry.StartTime
+		}
+		if duration <= 0 {
+			duration = clip.Duration - entry.StartTime
+		}
+		if duration <= 0 {
+			continue
+		}
+
+		hasAudio := true
+		if clip.VideoAnalysis != nil {
+			hasAudio = clip.VideoAnalysis.HasAudio
+		}
+
+		segment := RenderSegment{
+			ClipID:    clip.ID,
+			FilePath:  clip.FilePath,
+			StartTime: entry.StartTime,
+			Duration:  duration,
+			HasAudio:  hasAudio,
+		}
+		if clip.Transcript != nil {
+			segment.Captions = clip.Transcript.Segments.Window(entry.StartTime, duration)
+		}
+		segments = append(segments, segment)
+	}
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("timeline has no clips to render")
+	}
+	return segments, nil
+}
+
+// RenderOutput is one file produced by a render.
+type RenderOutput struct {
+	Path    string
+	Options *RenderOptions
+}
+
+// RenderSegments cuts each segment from its source, normalizes size, frame
+// rate and audio format, and concatenates the result into outputPath.
+func (fp *FFmpegProcessor) RenderSegments(ctx context.Context, segments []RenderSegment, outputPath string, options *RenderOptions, onProgress RenderProgressFunc) error {
+	if options == nil {
+		options = &RenderOptions{Width: defaultRenderWidth, Height: defaultRenderHeight, FrameRate: defaultRenderFPS}
+	}
+	return fp.RenderOutputs(ctx, segments, options, []RenderOutput{{Path: outputPath, Options: options}}, onProgress)
+}
+
+// RenderOutputs renders the segments once onto the canvas and encodes every
+// output from that shared decode in a single FFmpeg process.
+func (fp *FFmpegProcessor) RenderOutputs(ctx context.Context, segments []RenderSegment, canvas *RenderOptions, outputs []RenderOutput, onProgress RenderProgressFunc) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("no segments to render")
+	}
+	if len(outputs) == 0 {
+		return fmt.Errorf("no outputs to render")
+	}
+
+	args := []string{"-y", "-nostats", "-progress", "pipe:1"}
+	totalDuration := 0.0
+	for _, segment := range segments {
+		args = append(args,
+			"-ss", fmt.Sprintf("%.3f", segment.StartTime),
+			"-t", fmt.Sprintf("%.3f", segment.Duration),
+			"-i", segment.FilePath,
+		)
+		totalDuration += segment.Duration
+	}
+
+	captionsPath := ""
+	if captions := timelineCaptions(segments); len(captions) > 0 {
+		file, err := os.CreateTemp("", "captions-*.srt")
+		if err != nil {
+			return fmt.Errorf("failed to create captions file: %w", err)
+		}
+		defer os.Remove(file.Name())
+		_, err = file.WriteString(captions.SRT())
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write captions: %w", err)
+		}
+		captionsPath = file.Name()
+	}
+
+	args = append(args, "-filter_complex", buildRenderFilter(segments, canvas, outputs, captionsPath))
+
+	for i, output := range outputs {
+		if err := os.MkdirAll(filepath.Dir(output.Path), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		args = append(args, "-map", fmt.Sprintf("[ov%d]", i), "-map", fmt.Sprintf("[oa%d]", i))
+		args = append(args, fp.buildRenderArgs(output.Options)...)
+		if output.Options != nil && output.Options.OutputFormat == "hls" {
+			args = append(args, hlsOutputArgs(output.Path)...)
+		}
+		args = append(args, output.Path)
+	}
+
+	cmd := exec.CommandContext(ctx, fp.ffmpegPath, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to attach to ffmpeg output: %w", err)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if onProgress == nil || totalDuration <= 0 {
+			continue
+		}
+		// out_time_us is reported in microseconds
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found || key != "out_time_us" {
+			continue
+		}
+		if us, err := strconv.ParseFloat(value, 64); err == nil && us > 0 {
+			onProgress(min(us/1e6/totalDuration, 1))
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logger.Errorf("FFmpeg render failed: %s", lastLines(stderr.String(), 5))
+		return fmt.Errorf("failed to render video: %w", err)
+	}
+
+	if onProgress != nil {
+		onProgress(1)
+	}
+	return nil
+}
+
+// hlsOutputArgs writes a VOD playlist with its segments next to it
+func hlsOutputArgs(playlistPath string) []string {
+	return []string{
+		"-f", "hls",
+		"-hls_time", "6",
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(filepath.Dir(playlistPath), "segment_%03d.ts"),
+	}
+}
+
+// buildRenderFilter scales every input onto the canvas, concatenates them
+// (padding inputs without audio with silence), burns in the subtitles at
+// captionsPath if set, and splits the result into one cropped and scaled
+// stream pair per output.
+func buildRenderFilter(segments []RenderSegment, canvas *RenderOptions, outputs []RenderOutput, captionsPath string) string {
+	width, height := canvas.Width, canvas.Height
+	if width <= 0 || height <= 0 {
+		width, height = defaultRenderWidth, defaultRenderHeight
+	}
+	fps := canvas.FrameRate
+	if fps <= 0 {
+		fps = defaultRenderFPS
+	}
+
+	var filter strings.Builder
+	for i, segment := range segments {
+		fmt.Fprintf(&filter,
+			"[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%.3f,format=yuv420p[v%d];",
+			i, width, height, width, height, fps, i)
+		if segment.HasAudio {
+			fmt.Fprintf(&filter, "[%d:a]aresample=%d,aformat=channel_layouts=stereo[a%d];", i, renderAudioSampleRate, i)
+		} else {
+			fmt.Fprintf(&filter, "anullsrc=r=%d:cl=stereo,atrim=duration=%.3f[a%d];", renderAudioSampleRate, segment.Duration, i)
+		}
+	}
+	for i := range segments {
+		fmt.Fprintf(&filter, "[v%d][a%d]", i, i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=1:a=1[cv][ca];", len(segments))
+	video := "cv"
+	if captionsPath != "" {
+		fmt.Fprintf(&filter, "[cv]subtitles=filename=%s[ccv];", escapeFilterPath(captionsPath))
+		video = "ccv"
+	}
+
+	fmt.Fprintf(&filter, "[%s]split=%d", video, len(outputs))
+	for i := range outputs {
+		fmt.Fprintf(&filter, "[sv%d]", i)
+	}
+	fmt.Fprintf(&filter, ";[ca]asplit=%d", len(outputs))
+	for i := range outputs {
+		fmt.Fprintf(&filter, "[oa%d]", i)
+	}
+
+	for i, output := range outputs {
+		outWidth, outHeight := width, height
+		aspect := ""
+		if output.Options != nil {
+			if output.Options.Width > 0 && output.Options.Height > 0 {
+				outWidth, outHeight = output.Options.Width, output.Options.Height
+			}
+			aspect = output.Options.AspectRatio
+		}
+
+		fmt.Fprintf(&filter, ";[sv%d]", i)
+		if num, den, ok := parseAspectRatio(aspect); ok {
+			fmt.Fprintf(&filter, "crop='min(iw,ih*%d/%d)':'min(ih,iw*%d/%d)',", num, den, den, num)
+		}
+		fmt.Fprintf(&filter,
+			"scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1[ov%d]",
+			outWidth, outHeight, outWidth, outHeight, i)
+	}
+
+	return filter.String()
+}
+
+// timelineCaptions places the captions of every segment on the output
+// timeline.
+func timelineCaptions(segments []RenderSegment) models.TranscriptSegments {
+	var captions models.TranscriptSegments
+	offset := 0.0
+	for _, segment := range segments {
+		for _, caption := range segment.Captions {
+			captions = append(captions, models.TranscriptSegment{
+				Start: offset + caption.Start,
+				End:   offset + caption.End,
+				Text:  caption.Text,
+			})
+		}
+		offset += segment.Duration
+	}
+	return captions
+}
+
+// escapeFilterPath escapes a path given as a filter option, once for the
+// option and once for the filtergraph.
+func escapeFilterPath(path string) string {
+	return strings.NewReplacer(
+		`\`, `\\\\`,
+		`'`, `\\\'`,
+		`:`, `\\:`,
+		`,`, `\,`,
+		`;`, `\;`,
+		`[`, `\[`,
+		`]`, `\]`,
+	).Replace(path)
+}
+
+// fitAspectRatio sizes an output with the given aspect ratio so its shorter
+// edge matches the shorter edge of the canvas (1920x1080 becomes 1080x1920
+// for 9:16 and 1080x1080 for 1:1).
+func fitAspectRatio(width, height int, aspect string) (int, int) {
+	num, den, ok := parseAspectRatio(aspect)
+	if !ok {
+		return width, height
+	}
+
+	short := width
+	if height < short {
+		short = height
+	}
+	if num >= den {
+		return evenDimension(short * num / den), evenDimension(short)
+	}
+	return evenDimension(short), evenDimension(short * den / num)
+}
+
+func parseAspectRatio(aspect string) (int, int, bool) {
+	parts := strings.Split(aspect, ":")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	num, err := strconv.Atoi(parts[0])
+	if err != nil || num <= 0 {
+		return 0, 0, false
+	}
+	den, err := strconv.Atoi(parts[1])
+	if err != nil || den <= 0 {
+		return 0, 0, false
+	}
+	return num, den, true
+}
+
+// evenDimension rounds down to an even size, which H.264 requires
+func evenDimension(size int) int {
+	return size - size%2
+}
+
+func parseResolution(resolution string) (int, int, bool) {
+	parts := strings.Split(strings.ToLower(resolution), "x")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	width, err := strconv.Atoi(parts[0])
+	if err != nil || width <= 0 {
+		return 0, 0, false
+	}
+	height, err := strconv.Atoi(parts[1])
+	if err != nil || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,57 @@
+package video_engine
+
+import "creative-studio-server/models"
+
+// Shot-type thresholds on the subject's height as a share of the frame. A
+// face is a fraction of its person, so it needs far less of the frame for
+// the same shot than other subjects.
+const (
+	closeUpFaceHeight   = 0.25
+	mediumFaceHeight    = 0.08
+	closeUpObjectHeight = 0.7
+	mediumObjectHeight  = 0.3
+)
+
+// ShotSubject is what a sampled frame shows: the height of its tallest
+// face and of its tallest other detected object, as shares of the frame's
+// height, 0 when there is none.
+type ShotSubject struct {
+	FaceHeight   float64
+	ObjectHeight float64
+}
+
+// ClassifyShot labels a clip's shot type by the shot most of its sampled
+// frames show. Faces decide a frame's shot when there are any; frames
+// without a subject are wide shots, such as landscapes. It returns "" when
+// no frame was sampled.
+func ClassifyShot(subjects []ShotSubject) string {
+	votes := make(map[string]int)
+	for _, subject := range subjects {
+		votes[frameShot(subject)]++
+	}
+
+	shotType, best := "", 0
+	// Ties go to the tighter shot, as listed first
+	for _, candidate := range []string{models.ShotTypeCloseUp, models.ShotTypeMedium, models.ShotTypeWide} {
+		if votes[candidate] > best {
+			shotType, best = candidate, votes[candidate]
+		}
+	}
+	return shotType
+}
+
+func frameShot(subject ShotSubject) string {
+	switch {
+	case subject.FaceHeight >= closeUpFaceHeight:
+		return models.ShotTypeCloseUp
+	case subject.FaceHeight >= mediumFaceHeight:
+		return models.ShotTypeMedium
+	case subject.FaceHeight > 0:
+		return models.ShotTypeWide
+	case subject.ObjectHeight >= closeUpObjectHeight:
+		return models.ShotTypeCloseUp
+	case subject.ObjectHeight >= mediumObjectHeight:
+		return models.ShotTypeMedium
+	}
+	return models.ShotTypeWide
+}
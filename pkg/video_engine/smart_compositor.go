@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"sort"
+	"strings"
 	"time"
 
 	"creative-studio-server/models"
@@ -13,10 +14,19 @@ import (
 
 type SmartCompositor struct {
 	clips         []models.AtomicClip
+	clipIndex     map[uint]models.AtomicClip
 	requirements  CompositionRequirements
 	algorithms    map[string]CompositionAlgorithm
+	rng           *rand.Rand
 }
 
+const (
+	// MaxCompositionVariants caps how many variants a single request may ask for.
+	MaxCompositionVariants = 10
+	variantScoreJitter     = 0.2
+	variantAttemptsPerSlot = 4
+)
+
 type CompositionRequirements struct {
 	TargetDuration    float64   `json:"target_duration"`
 	Theme             string    `json:"theme"`
@@ -31,6 +41,25 @@ type CompositionRequirements struct {
 	ContentBalance    map[string]float64 `json:"content_balance"` // e.g., {"close_up": 0.3, "wide_shot": 0.4, "medium_shot": 0.3}
 	AvoidRepetition   bool      `json:"avoid_repetition"`
 	PreferHighQuality bool      `json:"prefer_high_quality"`
+	Seed              int64     `json:"seed"` // 0 picks a random seed
+
+	// Soundtrack: when MusicClipID is set TargetDuration may be omitted and is
+	// derived from the track (or the chosen section of it)
+	MusicClipID       *uint   `json:"music_clip_id"`
+	MusicSectionStart float64 `json:"music_section_start"`
+	MusicSectionEnd   float64 `json:"music_section_end"`
+
+	// Hard rules: pinned, required and excluded clips, category caps, source spacing
+	Constraints *CompositionConstraints `json:"constraints,omitempty"`
+	// Leaves out clips with burned-in titles or subtitles, unless pinned or required
+	ExcludeOnScreenText bool `json:"exclude_on_screen_text"`
+
+	// Optimizer algorithm settings
+	OptimizerIterations int                `json:"optimizer_iterations"`
+	ObjectiveWeights    map[string]float64 `json:"objective_weights"` // duration, cohesion, theme, diversity
+
+	// Learned from user feedback on earlier compositions, loaded by the worker
+	Adjustments       *ScoreAdjustments `json:"-"`
 }
 
 type CompositionResult struct {
@@ -77,21 +106,92 @@ type CompositionContext struct {
 }
 
 func NewSmartCompositor(clips []models.AtomicClip, requirements CompositionRequirements) *SmartCompositor {
+	seed := requirements.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	clipIndex := make(map[uint]models.AtomicClip, len(clips))
+	for _, clip := range clips {
+		clipIndex[clip.ID] = clip
+	}
+	if requirements.ExcludeOnScreenText {
+		clips = withoutOnScreenText(clips, requirements.Constraints)
+	}
+
 	compositor := &SmartCompositor{
 		clips:        clips,
+		clipIndex:    clipIndex,
 		requirements: requirements,
-		algorithms:   make(map[string]CompositionAlgorithm),
+		algorithms:   newRegisteredAlgorithms(),
+		rng:          rand.New(rand.NewSource(seed)),
 	}
 
-	// Register composition algorithms
-	compositor.algorithms["smart_selection"] = &SmartSelectionAlgorithm{}
-	compositor.algorithms["theme_based"] = &ThemeBasedAlgorithm{}
-	compositor.algorithms["emotion_driven"] = &EmotionDrivenAlgorithm{}
-
 	return compositor
 }
 
 func (sc *SmartCompositor) GenerateComposition(ctx context.Context, algorithmName string) (*CompositionResult, error) {
+	return sc.generate(ctx, algorithmName, 0)
+}
+
+// GenerateVariants produces up to count distinct compositions by cycling
+// through the given algorithms and perturbing clip scores with a per-variant
+// seed. Variants that select the same clips in the same order are dropped.
+func (sc *SmartCompositor) GenerateVariants(ctx context.Context, count int, algorithmNames []string) ([]*CompositionResult, error) {
+	if count <= 0 {
+		count = 1
+	}
+	if count > MaxCompositionVariants {
+		count = MaxCompositionVariants
+	}
+	if len(algorithmNames) == 0 {
+		algorithmNames = []string{"smart_selection"}
+	}
+
+	baseSeed := sc.requirements.Seed
+	if baseSeed == 0 {
+		baseSeed = time.Now().UnixNano()
+	}
+
+	seen := make(map[string]bool)
+	var variants []*CompositionResult
+
+	for attempt := 0; len(variants) < count && attempt < count*variantAttemptsPerSlot; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return variants, err
+		}
+
+		algorithmName := algorithmNames[len(variants)%len(algorithmNames)]
+		seed := baseSeed + int64(attempt)
+		sc.rng = rand.New(rand.NewSource(seed))
+
+		// The first attempt per algorithm uses the unperturbed scores
+		jitter := 0.0
+		if attempt >= len(algorithmNames) {
+			jitter = variantScoreJitter * float64(1+attempt/count)
+		}
+
+		result, err := sc.generate(ctx, algorithmName, jitter)
+		if err != nil {
+			return variants, err
+		}
+
+		signature := selectionSignature(result.SelectedClips)
+		if seen[signature] {
+			continue
+		}
+		seen[signature] = true
+
+		result.Metadata["variant"] = len(variants)
+		result.Metadata["seed"] = seed
+		variants = append(variants, result)
+	}
+
+	logger.Infof("Generated %d/%d composition variants", len(variants), count)
+	return variants, nil
+}
+
+func (sc *SmartCompositor) generate(ctx context.Context, algorithmName string, scoreJitter float64) (*CompositionResult, error) {
 	logger.Infof("Starting smart composition generation with algorithm: %s", algorithmName)
 
 	algorithm, exists := sc.algorithms[algorithmName]
@@ -100,10 +200,10 @@ func (sc *SmartCompositor) GenerateComposition(ctx context.Context, algorithmNam
 	}
 
 	// Score and filter clips
-	scoredClips := sc.scoreClips(algorithm)
+	scoredClips := sc.scoreClips(algorithm, scoreJitter)
 	
-	// Select clips based on algorithm
-	selectedClips, err := algorithm.SelectClips(scoredClips, sc.requirements)
+	// Select clips based on algorithm, within the composition constraints
+	selectedClips, err := sc.selectWithConstraints(algorithm, scoredClips)
 	if err != nil {
 		return nil, fmt.Errorf("failed to select clips: %w", err)
 	}
@@ -134,7 +234,7 @@ func (sc *SmartCompositor) GenerateComposition(ctx context.Context, algorithmNam
 	return result, nil
 }
 
-func (sc *SmartCompositor) scoreClips(algorithm CompositionAlgorithm) []models.AtomicClip {
+func (sc *SmartCompositor) scoreClips(algorithm CompositionAlgorithm, jitter float64) []models.AtomicClip {
 	scored := make([]models.AtomicClip, len(sc.clips))
 	copy(scored, sc.clips)
 
@@ -147,6 +247,10 @@ func (sc *SmartCompositor) scoreClips(algorithm CompositionAlgorithm) []models.A
 		}
 		
 		score := algorithm.Score(scored[i], sc.requirements, context)
+		score += sc.requirements.Adjustments.For(scored[i])
+		if jitter > 0 {
+			score *= 1 + (sc.rng.Float64()*2-1)*jitter
+		}
 		// Store score in metadata (you might want to add a Score field to AtomicClip)
 		if scored[i].Metadata == nil {
 			scored[i].Metadata = make(models.JSON)
@@ -164,6 +268,28 @@ func (sc *SmartCompositor) scoreClips(algorithm CompositionAlgorithm) []models.A
 	return scored
 }
 
+// withoutOnScreenText drops the clips OCR found text in, keeping those the
+// constraints pin or require.
+func withoutOnScreenText(clips []models.AtomicClip, constraints *CompositionConstraints) []models.AtomicClip {
+	kept := make(map[uint]bool)
+	if constraints != nil {
+		for _, pin := range constraints.PinnedClips {
+			kept[pin.ClipID] = true
+		}
+		for _, id := range constraints.MustInclude {
+			kept[id] = true
+		}
+	}
+
+	filtered := make([]models.AtomicClip, 0, len(clips))
+	for _, clip := range clips {
+		if kept[clip.ID] || clip.VideoAnalysis == nil || !clip.VideoAnalysis.HasText {
+			filtered = append(filtered, clip)
+		}
+	}
+	return filtered
+}
+
 func (sc *SmartCompositor) generateTimeline(clips []ClipSegment) []TimelineEvent {
 	var timeline []TimelineEvent
 	currentTime := 0.0
@@ -215,7 +341,7 @@ func (sc *SmartCompositor) selectTransition(fromClip, toClip ClipSegment) Transi
 		selectedType = "dissolve"
 		duration = 1.0
 	case "dynamic":
-		selectedType = transitionTypes[rand.Intn(len(transitionTypes))]
+		selectedType = transitionTypes[sc.rng.Intn(len(transitionTypes))]
 		duration = 0.3
 	}
 
@@ -244,15 +370,11 @@ func (sc *SmartCompositor) calculateCohesionScore(clips []ClipSegment) float64 {
 		return 1.0
 	}
 
-	// Calculate how well clips flow together
-	// This is a simplified implementation
+	// Calculate how well adjacent clips flow together
 	cohesionScore := 0.0
 	comparisons := 0
 
 	for i := 0; i < len(clips)-1; i++ {
-		// Compare adjacent clips for coherence
-		// In a real implementation, you'd analyze visual similarity,
-		// color continuity, motion consistency, etc.
 		similarity := sc.calculateClipSimilarity(clips[i], clips[i+1])
 		cohesionScore += similarity
 		comparisons++
@@ -266,12 +388,13 @@ func (sc *SmartCompositor) calculateCohesionScore(clips []ClipSegment) float64 {
 }
 
 func (sc *SmartCompositor) calculateClipSimilarity(clip1, clip2 ClipSegment) float64 {
-	// Simplified similarity calculation
-	// In practice, this would involve deep analysis of visual features
-	similarity := 0.5 // Base similarity
+	first, ok1 := sc.clipIndex[clip1.ClipID]
+	second, ok2 := sc.clipIndex[clip2.ClipID]
+	if !ok1 || !ok2 {
+		return baseSimilarity
+	}
 
-	// This would be enhanced with actual video analysis
-	return similarity
+	return ClipSimilarity(first, second)
 }
 
 func (sc *SmartCompositor) calculateTotalDuration(timeline []TimelineEvent) float64 {
@@ -290,6 +413,14 @@ func (sc *SmartCompositor) calculateTotalDuration(timeline []TimelineEvent) floa
 	return maxEndTime
 }
 
+func selectionSignature(clips []ClipSegment) string {
+	parts := make([]string, len(clips))
+	for i, clip := range clips {
+		parts[i] = fmt.Sprintf("%d@%.2f", clip.ClipID, clip.StartTime)
+	}
+	return strings.Join(parts, ",")
+}
+
 // Smart Selection Algorithm Implementation
 type SmartSelectionAlgorithm struct{}
 
@@ -315,45 +446,60 @@ func (a *SmartSelectionAlgorithm) SelectClips(clips []models.AtomicClip, require
 	var selectedClips []ClipSegment
 	remainingDuration := requirements.TargetDuration
 	usedClips := make(map[uint]bool)
+	state := newSelectionState()
 
 	for remainingDuration > requirements.MinClipDuration && len(selectedClips) < len(clips) {
-		bestClip := a.findBestClip(clips, usedClips, remainingDuration, requirements)
+		bestClip := a.findBestClip(clips, usedClips, remainingDuration, requirements, state)
 		if bestClip == nil {
 			break
 		}
 
 		clipDuration := bestClip.Duration
+		if requirements.MaxClipDuration > 0 && clipDuration > requirements.MaxClipDuration {
+			clipDuration = requirements.MaxClipDuration
+		}
 		if clipDuration > remainingDuration {
 			clipDuration = remainingDuration
 		}
 
+		// Pick the most interesting window when only part of the clip is used
+		startTime, endTime := FindHighlightWindow(HighlightSignalsFromMetadata(bestClip.Metadata), bestClip.Duration, clipDuration)
+
 		selectedClips = append(selectedClips, ClipSegment{
 			ClipID:    bestClip.ID,
-			StartTime: 0,
-			EndTime:   clipDuration,
+			StartTime: startTime,
+			EndTime:   endTime,
 			Duration:  clipDuration,
 			Score:     bestClip.Metadata["composition_score"].(float64),
 			Reason:    "Smart selection algorithm",
 		})
 
 		usedClips[bestClip.ID] = true
+		state.add(*bestClip, clipDuration)
 		remainingDuration -= clipDuration
 	}
 
 	return selectedClips, nil
 }
 
-func (a *SmartSelectionAlgorithm) findBestClip(clips []models.AtomicClip, usedClips map[uint]bool, remainingDuration float64, requirements CompositionRequirements) *models.AtomicClip {
-	for _, clip := range clips {
-		if usedClips[clip.ID] {
+func (a *SmartSelectionAlgorithm) findBestClip(clips []models.AtomicClip, usedClips map[uint]bool, remainingDuration float64, requirements CompositionRequirements, state *selectionState) *models.AtomicClip {
+	var best *models.AtomicClip
+	bestScore := 0.0
+
+	for i := range clips {
+		clip := clips[i]
+		if usedClips[clip.ID] || clip.Duration < requirements.MinClipDuration {
 			continue
 		}
-		
-		if clip.Duration >= requirements.MinClipDuration {
-			return &clip
+
+		baseScore, _ := clip.Metadata["composition_score"].(float64)
+		score := state.adjustedScore(clip, baseScore, requirements)
+		if best == nil || score > bestScore {
+			best = &clips[i]
+			bestScore = score
 		}
 	}
-	return nil
+	return best
 }
 
 func (a *SmartSelectionAlgorithm) calculateDurationFitness(duration float64, requirements CompositionRequirements) float64 {
@@ -373,6 +519,10 @@ func (a *SmartSelectionAlgorithm) calculateThemeFitness(clip models.AtomicClip,
 	
 	if requirements.Theme != "" && clip.Category == requirements.Theme {
 		fitness += 0.5
+	} else if requirements.Theme != "" && showsObject(clip, requirements.Theme) {
+		// A clip of the theme's subject fits nearly as well as one filed
+		// under it
+		fitness += 0.4
 	}
 	
 	if requirements.Mood != "" && clip.Mood == requirements.Mood {
@@ -386,6 +536,17 @@ func (a *SmartSelectionAlgorithm) calculateThemeFitness(clip models.AtomicClip,
 	return fitness
 }
 
+// showsObject reports whether the object was detected in the clip.
+func showsObject(clip models.AtomicClip, object string) bool {
+	object = models.NormalizeTag(object)
+	for _, detected := range clip.Objects {
+		if strings.EqualFold(detected, object) {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *SmartSelectionAlgorithm) calculateQualityFitness(clip models.AtomicClip, requirements CompositionRequirements) float64 {
 	fitness := 0.0
 	
@@ -0,0 +1,174 @@
+package video_engine
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+)
+
+const (
+	// Candidate frames sampled per clip, at most thumbnailMaxFPS
+	thumbnailCandidates = 48
+	thumbnailMaxFPS     = 2.0
+
+	// Candidates are shrunk to this size before they are measured
+	thumbnailSampleWidth  = 160
+	thumbnailSampleHeight = 90
+
+	// Fades in and out make poor thumbnails, so this share of either end
+	// of a clip is skipped, up to thumbnailMaxMargin seconds
+	thumbnailMargin    = 0.05
+	thumbnailMaxMargin = 1.0
+
+	// Mean pixel change from a neighboring candidate, as a share of the
+	// range, at which a frame counts as mid-transition
+	transitionFrameDiff = 0.2
+
+	// Weights of a candidate's measures in its score
+	thumbnailSharpnessWeight = 0.35
+	thumbnailExposureWeight  = 0.25
+	thumbnailStabilityWeight = 0.25
+	thumbnailFaceWeight      = 0.15
+)
+
+// ThumbnailCandidate is a frame that could be a clip's thumbnail. Measures
+// are between 0 and 1, higher being better.
+type ThumbnailCandidate struct {
+	Time float64
+	// Sharpness is relative to the sharpest candidate of the clip
+	Sharpness float64
+	// Exposure is how close the frame's brightness is to mid-gray
+	Exposure float64
+	// Stability is low for frames mid-cut or mid-fade
+	Stability float64
+}
+
+// SampleThumbnailCandidates measures frames sampled across a clip, in
+// order, in one pass over the clip.
+func (fp *FFmpegProcessor) SampleThumbnailCandidates(filePath string, duration float64) ([]ThumbnailCandidate, error) {
+	rate := thumbnailMaxFPS
+	if duration > 0 {
+		rate = math.Min(thumbnailMaxFPS, thumbnailCandidates/duration)
+	}
+	output, err := exec.Command(fp.ffmpegPath,
+		"-i", filePath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=%g,scale=%d:%d:flags=area,format=gray", rate, thumbnailSampleWidth, thumbnailSampleHeight),
+		"-f", "rawvideo",
+		"-",
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample thumbnail candidates: %w", err)
+	}
+
+	frameBytes := thumbnailSampleWidth * thumbnailSampleHeight
+	frames := make([][]byte, 0, len(output)/frameBytes)
+	for offset := 0; offset+frameBytes <= len(output); offset += frameBytes {
+		frames = append(frames, output[offset:offset+frameBytes])
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames to analyze in %s", filePath)
+	}
+
+	candidates := make([]ThumbnailCandidate, len(frames))
+	maxSharpness := 0.0
+	for i, frame := range frames {
+		change := 0.0
+		if i > 0 {
+			change = frameDifference(frames[i-1], frame)
+		}
+		if i+1 < len(frames) {
+			change = math.Max(change, frameDifference(frame, frames[i+1]))
+		}
+
+		candidates[i] = ThumbnailCandidate{
+			Time:      float64(i) / rate,
+			Sharpness: laplacianVariance(frame),
+			Exposure:  clamp01(1 - math.Abs(meanLevel(frame)-0.5)*2),
+			Stability: clamp01(1 - change/transitionFrameDiff),
+		}
+		maxSharpness = math.Max(maxSharpness, candidates[i].Sharpness)
+	}
+	for i := range candidates {
+		if maxSharpness > 0 {
+			candidates[i].Sharpness /= maxSharpness
+		}
+	}
+
+	return candidates, nil
+}
+
+// PickThumbnail returns the offset of the best candidate, rounded to a
+// tenth of a second. Frames showing faces are preferred when faceCounts,
+// sampled every faceInterval seconds, is given. It reports false when no
+// candidate is left once the clip's ends are skipped.
+func PickThumbnail(candidates []ThumbnailCandidate, duration float64, faceCounts []float64, faceInterval float64) (float64, bool) {
+	margin := 0.0
+	if duration > 0 {
+		margin = math.Min(duration*thumbnailMargin, thumbnailMaxMargin)
+	}
+
+	best, bestScore, found := 0.0, math.Inf(-1), false
+	for _, candidate := range candidates {
+		if candidate.Time < margin || (duration > 0 && candidate.Time > duration-margin) {
+			continue
+		}
+
+		score := thumbnailSharpnessWeight*candidate.Sharpness +
+			thumbnailExposureWeight*candidate.Exposure +
+			thumbnailStabilityWeight*candidate.Stability
+		if faceInterval > 0 {
+			if i := int(math.Round(candidate.Time / faceInterval)); i < len(faceCounts) && faceCounts[i] > 0 {
+				score += thumbnailFaceWeight
+			}
+		}
+
+		if score > bestScore {
+			best, bestScore, found = candidate.Time, score, true
+		}
+	}
+	return math.Floor(best*10) / 10, found
+}
+
+// laplacianVariance measures a gray frame's sharpness as the variance of
+// its Laplacian: blurred frames have few edges and a low variance.
+func laplacianVariance(frame []byte) float64 {
+	var sum, sumSquares float64
+	count := 0
+	for y := 1; y < thumbnailSampleHeight-1; y++ {
+		for x := 1; x < thumbnailSampleWidth-1; x++ {
+			i := y*thumbnailSampleWidth + x
+			laplacian := float64(frame[i-1]) + float64(frame[i+1]) +
+				float64(frame[i-thumbnailSampleWidth]) + float64(frame[i+thumbnailSampleWidth]) -
+				4*float64(frame[i])
+			sum += laplacian
+			sumSquares += laplacian * laplacian
+			count++
+		}
+	}
+	mean := sum / float64(count)
+	return sumSquares/float64(count) - mean*mean
+}
+
+// meanLevel is a gray frame's mean brightness between 0 and 1.
+func meanLevel(frame []byte) float64 {
+	sum := 0
+	for _, level := range frame {
+		sum += int(level)
+	}
+	return float64(sum) / float64(len(frame)) / 255
+}
+
+// frameDifference is the mean change of pixels between two gray frames,
+// between 0 and 1.
+func frameDifference(a, b []byte) float64 {
+	sum := 0
+	for i := range a {
+		diff := int(a[i]) - int(b[i])
+		if diff < 0 {
+			diff = -diff
+		}
+		sum += diff
+	}
+	return float64(sum) / float64(len(a)) / 255
+}
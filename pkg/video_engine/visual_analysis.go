@@ -0,0 +1,303 @@
+package video_engine
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// analysisMaxFrames caps the frames sampled from a clip; shorter clips
+	// are sampled at analysisMaxFPS
+	analysisMaxFrames = 120
+	analysisMaxFPS    = 2.0
+
+	// Mean scene change scores between sampled frames above which motion
+	// counts as medium and high
+	mediumMotionScore = 0.03
+	highMotionScore   = 0.1
+
+	// Colors covering less of the sampled pixels are not dominant
+	dominantColorMinShare = 0.1
+	maxDominantColors     = 3
+
+	// Sampled frames are shrunk to this size before their colors are counted
+	colorSampleWidth  = 32
+	colorSampleHeight = 18
+
+	// Frames sampled for detection are at most this wide, enough for
+	// faces filling a twentieth of the picture
+	detectionFrameWidth = 640
+)
+
+var meanVolumePattern = regexp.MustCompile(`mean_volume:\s*(-?[\d.]+|-inf) dB`)
+
+// PictureStats describes the picture of a clip, averaged over frames
+// sampled across it. Levels are between 0 and 1.
+type PictureStats struct {
+	Brightness float64
+	Contrast   float64
+	Saturation float64
+	// Motion is the mean scene change score between sampled frames
+	Motion          float64
+	MotionIntensity string // low, medium or high
+}
+
+// analysisSampleRate picks the frames per second sampled from a clip, so
+// long clips are not sampled more than analysisMaxFrames times.
+func analysisSampleRate(duration float64) float64 {
+	if duration <= 0 {
+		return analysisMaxFPS
+	}
+	return math.Min(analysisMaxFPS, analysisMaxFrames/duration)
+}
+
+// AnalyzePicture measures brightness, contrast and saturation with FFmpeg's
+// signalstats filter and motion with its scene detection, in one pass over
+// frames sampled across the clip.
+func (fp *FFmpegProcessor) AnalyzePicture(filePath string, duration float64) (*PictureStats, error) {
+	output, err := exec.Command(fp.ffmpegPath,
+		"-i", filePath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=%g,scale=320:-2,format=yuv420p,signalstats,select='gte(scene\\,0)',metadata=print:file=-", analysisSampleRate(duration)),
+		"-f", "null", "-",
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze picture: %w", err)
+	}
+
+	means, frames := parseFrameMetadataMeans(output)
+	if frames == 0 {
+		return nil, fmt.Errorf("no frames to analyze in %s", filePath)
+	}
+
+	stats := &PictureStats{
+		Brightness: clamp01(means["lavfi.signalstats.YAVG"] / 255),
+		// The spread between the darkest and brightest tenth of the pixels
+		Contrast: clamp01((means["lavfi.signalstats.YHIGH"] - means["lavfi.signalstats.YLOW"]) / 255),
+		// Chroma distance from gray tops out at 128 for real colors
+		Saturation: clamp01(means["lavfi.signalstats.SATAVG"] / 128),
+		Motion:     means["lavfi.scene_score"],
+	}
+	switch {
+	case stats.Motion >= highMotionScore:
+		stats.MotionIntensity = "high"
+	case stats.Motion >= mediumMotionScore:
+		stats.MotionIntensity = "medium"
+	default:
+		stats.MotionIntensity = "low"
+	}
+
+	return stats, nil
+}
+
+// parseFrameMetadataMeans averages every key printed by FFmpeg's
+// metadata=print filter over the frames it was printed for, and counts the
+// frames.
+func parseFrameMetadataMeans(output []byte) (map[string]float64, int) {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	frames := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "frame:") {
+			frames++
+			continue
+		}
+
+		key, raw, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		sums[key] += value
+		counts[key]++
+	}
+
+	means := make(map[string]float64, len(sums))
+	for key, sum := range sums {
+		means[key] = sum / float64(counts[key])
+	}
+	return means, frames
+}
+
+// AnalyzeDominantColors names the colors covering the most of frames
+// sampled across the clip, most common first. Pixels are shrunk and mapped
+// to a small palette of names, which is what clips are compared and
+// searched by.
+func (fp *FFmpegProcessor) AnalyzeDominantColors(filePath string, duration float64) ([]string, error) {
+	output, err := exec.Command(fp.ffmpegPath,
+		"-i", filePath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=%g,scale=%d:%d:flags=area", analysisSampleRate(duration), colorSampleWidth, colorSampleHeight),
+		"-f", "rawvideo",
+		"-pix_fmt", "rgb24",
+		"-",
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample colors: %w", err)
+	}
+
+	pixels := len(output) / 3
+	if pixels == 0 {
+		return nil, fmt.Errorf("no frames to analyze in %s", filePath)
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i+2 < len(output); i += 3 {
+		counts[colorName(output[i], output[i+1], output[i+2])]++
+	}
+
+	names := make([]string, 0, len(counts))
+	for name, count := range counts {
+		if float64(count)/float64(pixels) >= dominantColorMinShare {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > maxDominantColors {
+		names = names[:maxDominantColors]
+	}
+
+	return names, nil
+}
+
+// colorName maps a pixel to the name of its color: black, gray or white
+// for unsaturated ones, else by hue.
+func colorName(r, g, b byte) string {
+	red, green, blue := float64(r)/255, float64(g)/255, float64(b)/255
+	maxC := math.Max(red, math.Max(green, blue))
+	minC := math.Min(red, math.Min(green, blue))
+	value := maxC
+	saturation := 0.0
+	if maxC > 0 {
+		saturation = (maxC - minC) / maxC
+	}
+
+	switch {
+	case value < 0.2:
+		return "black"
+	case saturation < 0.15 && value > 0.85:
+		return "white"
+	case saturation < 0.15:
+		return "gray"
+	}
+
+	var hue float64
+	switch maxC {
+	case red:
+		hue = math.Mod((green-blue)/(maxC-minC), 6)
+	case green:
+		hue = (blue-red)/(maxC-minC) + 2
+	default:
+		hue = (red-green)/(maxC-minC) + 4
+	}
+	hue *= 60
+	if hue < 0 {
+		hue += 360
+	}
+
+	switch {
+	case hue < 15 || hue >= 345:
+		if value < 0.6 {
+			return "brown"
+		}
+		return "red"
+	case hue < 45:
+		if value < 0.6 {
+			return "brown"
+		}
+		return "orange"
+	case hue < 70:
+		return "yellow"
+	case hue < 165:
+		return "green"
+	case hue < 195:
+		return "cyan"
+	case hue < 255:
+		return "blue"
+	case hue < 290:
+		return "purple"
+	default:
+		return "pink"
+	}
+}
+
+// SampleFrames writes JPEG frames sampled across a clip to dir, one every
+// interval seconds starting at 0, and returns their paths in order. Frames
+// are shrunk for detection models.
+func (fp *FFmpegProcessor) SampleFrames(filePath, dir string, interval float64) ([]string, error) {
+	output, err := exec.Command(fp.ffmpegPath,
+		"-i", filePath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=1/%g,scale='min(iw,%d)':-2", interval, detectionFrameWidth),
+		"-q:v", "3",
+		"-f", "image2",
+		filepath.Join(dir, "frame_%05d.jpg"),
+	).CombinedOutput()
+	if err != nil {
+		logger.Errorf("FFmpeg frame sampling failed: %s", lastLines(string(output), 5))
+		return nil, fmt.Errorf("failed to sample frames: %w", err)
+	}
+
+	// The pattern's zero padding keeps them in order
+	frames, err := filepath.Glob(filepath.Join(dir, "frame_*.jpg"))
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames to analyze in %s", filePath)
+	}
+	return frames, nil
+}
+
+// AnalyzeAudioLevel returns the mean loudness of a clip's audio between 0
+// and 1, measured with FFmpeg's volumedetect filter.
+func (fp *FFmpegProcessor) AnalyzeAudioLevel(filePath string) (float64, error) {
+	// volumedetect reports on stderr
+	output, err := exec.Command(fp.ffmpegPath,
+		"-i", filePath,
+		"-vn",
+		"-af", "volumedetect",
+		"-f", "null", "-",
+	).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure audio level: %w", err)
+	}
+
+	match := meanVolumePattern.FindSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("no audio level reported for %s", filePath)
+	}
+	if string(match[1]) == "-inf" {
+		return 0, nil
+	}
+	db, err := strconv.ParseFloat(string(match[1]), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid audio level %q: %w", match[1], err)
+	}
+	return clamp01(dbToLinear(db)), nil
+}
+
+func clamp01(value float64) float64 {
+	return math.Max(0, math.Min(value, 1))
+}
@@ -0,0 +1,1274 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: studio/v1/studio.proto
+
+// Internal API for other services of the platform. Calls authenticate with
+// an API key in the x-api-key metadata and need the same permissions, and
+// key scopes, as the matching HTTP routes.
+
+package studiov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetClipRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetClipRequest) Reset() {
+	*x = GetClipRequest{}
+	mi := &file_studio_v1_studio_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetClipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetClipRequest) ProtoMessage() {}
+
+func (x *GetClipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_studio_v1_studio_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetClipRequest.ProtoReflect.Descriptor instead.
+func (*GetClipRequest) Descriptor() ([]byte, []int) {
+	return file_studio_v1_studio_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetClipRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type BatchGetClipsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// At most 100
+	Ids           []uint64 `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetClipsRequest) Reset() {
+	*x = BatchGetClipsRequest{}
+	mi := &file_studio_v1_studio_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetClipsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetClipsRequest) ProtoMessage() {}
+
+func (x *BatchGetClipsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_studio_v1_studio_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetClipsRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetClipsRequest) Descriptor() ([]byte, []int) {
+	return file_studio_v1_studio_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BatchGetClipsRequest) GetIds() []uint64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type BatchGetClipsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Clips         []*Clip                `protobuf:"bytes,1,rep,name=clips,proto3" json:"clips,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetClipsResponse) Reset() {
+	*x = BatchGetClipsResponse{}
+	mi := &file_studio_v1_studio_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetClipsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetClipsResponse) ProtoMessage() {}
+
+func (x *BatchGetClipsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_studio_v1_studio_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetClipsResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetClipsResponse) Descriptor() ([]byte, []int) {
+	return file_studio_v1_studio_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BatchGetClipsResponse) GetClips() []*Clip {
+	if x != nil {
+		return x.Clips
+	}
+	return nil
+}
+
+type Clip struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Duration      float64                `protobuf:"fixed64,4,opt,name=duration,proto3" json:"duration,omitempty"`
+	Resolution    string                 `protobuf:"bytes,5,opt,name=resolution,proto3" json:"resolution,omitempty"`
+	FrameRate     float64                `protobuf:"fixed64,6,opt,name=frame_rate,json=frameRate,proto3" json:"frame_rate,omitempty"`
+	Format        string                 `protobuf:"bytes,7,opt,name=format,proto3" json:"format,omitempty"`
+	Thumbnail     string                 `protobuf:"bytes,8,opt,name=thumbnail,proto3" json:"thumbnail,omitempty"`
+	Category      string                 `protobuf:"bytes,9,opt,name=category,proto3" json:"category,omitempty"`
+	Tags          []string               `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	Mood          string                 `protobuf:"bytes,11,opt,name=mood,proto3" json:"mood,omitempty"`
+	Style         string                 `protobuf:"bytes,12,opt,name=style,proto3" json:"style,omitempty"`
+	Color         string                 `protobuf:"bytes,13,opt,name=color,proto3" json:"color,omitempty"`
+	SceneType     string                 `protobuf:"bytes,14,opt,name=scene_type,json=sceneType,proto3" json:"scene_type,omitempty"`
+	Objects       []string               `protobuf:"bytes,15,rep,name=objects,proto3" json:"objects,omitempty"`
+	Actions       []string               `protobuf:"bytes,16,rep,name=actions,proto3" json:"actions,omitempty"`
+	Emotions      []string               `protobuf:"bytes,17,rep,name=emotions,proto3" json:"emotions,omitempty"`
+	QualityScore  float64                `protobuf:"fixed64,18,opt,name=quality_score,json=qualityScore,proto3" json:"quality_score,omitempty"`
+	UsageCount    int32                  `protobuf:"varint,19,opt,name=usage_count,json=usageCount,proto3" json:"usage_count,omitempty"`
+	FavoriteCount int32                  `protobuf:"varint,20,opt,name=favorite_count,json=favoriteCount,proto3" json:"favorite_count,omitempty"`
+	RatingAverage float64                `protobuf:"fixed64,21,opt,name=rating_average,json=ratingAverage,proto3" json:"rating_average,omitempty"`
+	Status        string                 `protobuf:"bytes,22,opt,name=status,proto3" json:"status,omitempty"`
+	UserId        uint64                 `protobuf:"varint,23,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Unset for personal clips
+	OrganizationId *uint64                `protobuf:"varint,24,opt,name=organization_id,json=organizationId,proto3,oneof" json:"organization_id,omitempty"`
+	Visibility     string                 `protobuf:"bytes,25,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	Stock          bool                   `protobuf:"varint,26,opt,name=stock,proto3" json:"stock,omitempty"`
+	Metadata       *structpb.Struct       `protobuf:"bytes,27,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,28,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt      *timestamppb.Timestamp `protobuf:"bytes,29,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Clip) Reset() {
+	*x = Clip{}
+	mi := &file_studio_v1_studio_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Clip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Clip) ProtoMessage() {}
+
+func (x *Clip) ProtoReflect() protoreflect.Message {
+	mi := &file_studio_v1_studio_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Clip.ProtoReflect.Descriptor instead.
+func (*Clip) Descriptor() ([]byte, []int) {
+	return file_studio_v1_studio_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Clip) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Clip) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Clip) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Clip) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *Clip) GetResolution() string {
+	if x != nil {
+		return x.Resolution
+	}
+	return ""
+}
+
+func (x *Clip) GetFrameRate() float64 {
+	if x != nil {
+		return x.FrameRate
+	}
+	return 0
+}
+
+func (x *Clip) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *Clip) GetThumbnail() string {
+	if x != nil {
+		return x.Thumbnail
+	}
+	return ""
+}
+
+func (x *Clip) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Clip) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Clip) GetMood() string {
+	if x != nil {
+		return x.Mood
+	}
+	return ""
+}
+
+func (x *Clip) GetStyle() string {
+	if x != nil {
+		return x.Style
+	}
+	return ""
+}
+
+func (x *Clip) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *Clip) GetSceneType() string {
+	if x != nil {
+		return x.SceneType
+	}
+	return ""
+}
+
+func (x *Clip) GetObjects() []string {
+	if x != nil {
+		return x.Objects
+	}
+	return nil
+}
+
+func (x *Clip) GetActions() []string {
+	if x != nil {
+		return x.Actions
+	}
+	return nil
+}
+
+func (x *Clip) GetEmotions() []string {
+	if x != nil {
+		return x.Emotions
+	}
+	return nil
+}
+
+func (x *Clip) GetQualityScore() float64 {
+	if x != nil {
+		return x.QualityScore
+	}
+	return 0
+}
+
+func (x *Clip) GetUsageCount() int32 {
+	if x != nil {
+		return x.UsageCount
+	}
+	return 0
+}
+
+func (x *Clip) GetFavoriteCount() int32 {
+	if x != nil {
+		return x.FavoriteCount
+	}
+	return 0
+}
+
+func (x *Clip) GetRatingAverage() float64 {
+	if x != nil {
+		return x.RatingAverage
+	}
+	return 0
+}
+
+func (x *Clip) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Clip) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Clip) GetOrganizationId() uint64 {
+	if x != nil && x.OrganizationId != nil {
+		return *x.OrganizationId
+	}
+	return 0
+}
+
+func (x *Clip) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *Clip) GetStock() bool {
+	if x != nil {
+		return x.Stock
+	}
+	return false
+}
+
+func (x *Clip) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Clip) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Clip) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GenerateCompositionRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId uint64                 `protobuf:"varint,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	// Same as the requirements of the HTTP API
+	Requirements *structpb.Struct `protobuf:"bytes,2,opt,name=requirements,proto3" json:"requirements,omitempty"`
+	// 1 to 10; 0 generates one
+	Variants   int32    `protobuf:"varint,3,opt,name=variants,proto3" json:"variants,omitempty"`
+	Algorithms []string `protobuf:"bytes,4,rep,name=algorithms,proto3" json:"algorithms,omitempty"`
+	// Compose from this collection's clips only
+	CollectionId *uint64 `protobuf:"varint,5,opt,name=collection_id,json=collectionId,proto3,oneof" json:"collection_id,omitempty"`
+	// Also draw from the stock library
+	IncludeStock  bool `protobuf:"varint,6,opt,name=include_stock,json=includeStock,proto3" json:"include_stock,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateCompositionRequest) Reset() {
+	*x = GenerateCompositionRequest{}
+	mi := &file_studio_v1_studio_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateCompositionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateCompositionRequest) ProtoMessage() {}
+
+func (x *GenerateCompositionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_studio_v1_studio_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateCompositionRequest.ProtoReflect.Descriptor instead.
+func (*GenerateCompositionRequest) Descriptor() ([]byte, []int) {
+	return file_studio_v1_studio_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GenerateCompositionRequest) GetProjectId() uint64 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *GenerateCompositionRequest) GetRequirements() *structpb.Struct {
+	if x != nil {
+		return x.Requirements
+	}
+	return nil
+}
+
+func (x *GenerateCompositionRequest) GetVariants() int32 {
+	if x != nil {
+		return x.Variants
+	}
+	return 0
+}
+
+func (x *GenerateCompositionRequest) GetAlgorithms() []string {
+	if x != nil {
+		return x.Algorithms
+	}
+	return nil
+}
+
+func (x *GenerateCompositionRequest) GetCollectionId() uint64 {
+	if x != nil && x.CollectionId != nil {
+		return *x.CollectionId
+	}
+	return 0
+}
+
+func (x *GenerateCompositionRequest) GetIncludeStock() bool {
+	if x != nil {
+		return x.IncludeStock
+	}
+	return false
+}
+
+type GetCompositionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCompositionRequest) Reset() {
+	*x = GetCompositionRequest{}
+	mi := &file_studio_v1_studio_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCompositionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCompositionRequest) ProtoMessage() {}
+
+func (x *GetCompositionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_studio_v1_studio_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCompositionRequest.ProtoReflect.Descriptor instead.
+func (*GetCompositionRequest) Descriptor() ([]byte, []int) {
+	return file_studio_v1_studio_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetCompositionRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type Composition struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ProjectId uint64                 `protobuf:"varint,2,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	// pending, completed or failed
+	Status        string  `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Algorithm     string  `protobuf:"bytes,4,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	Variant       int32   `protobuf:"varint,5,opt,name=variant,proto3" json:"variant,omitempty"`
+	TotalDuration float64 `protobuf:"fixed64,6,opt,name=total_duration,json=totalDuration,proto3" json:"total_duration,omitempty"`
+	QualityScore  float64 `protobuf:"fixed64,7,opt,name=quality_score,json=qualityScore,proto3" json:"quality_score,omitempty"`
+	CohesionScore float64 `protobuf:"fixed64,8,opt,name=cohesion_score,json=cohesionScore,proto3" json:"cohesion_score,omitempty"`
+	// The generated timeline as JSON, once completed
+	Timeline      string                 `protobuf:"bytes,9,opt,name=timeline,proto3" json:"timeline,omitempty"`
+	ErrorMessage  string                 `protobuf:"bytes,10,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	TraceId       string                 `protobuf:"bytes,11,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Composition) Reset() {
+	*x = Composition{}
+	mi := &file_studio_v1_studio_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Composition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Composition) ProtoMessage() {}
+
+func (x *Composition) ProtoReflect() protoreflect.Message {
+	mi := &file_studio_v1_studio_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Composition.ProtoReflect.Descriptor instead.
+func (*Composition) Descriptor() ([]byte, []int) {
+	return file_studio_v1_studio_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Composition) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Composition) GetProjectId() uint64 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *Composition) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Composition) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *Composition) GetVariant() int32 {
+	if x != nil {
+		return x.Variant
+	}
+	return 0
+}
+
+func (x *Composition) GetTotalDuration() float64 {
+	if x != nil {
+		return x.TotalDuration
+	}
+	return 0
+}
+
+func (x *Composition) GetQualityScore() float64 {
+	if x != nil {
+		return x.QualityScore
+	}
+	return 0
+}
+
+func (x *Composition) GetCohesionScore() float64 {
+	if x != nil {
+		return x.CohesionScore
+	}
+	return 0
+}
+
+func (x *Composition) GetTimeline() string {
+	if x != nil {
+		return x.Timeline
+	}
+	return ""
+}
+
+func (x *Composition) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *Composition) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *Composition) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Composition) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type SubmitRenderRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId uint64                 `protobuf:"varint,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	// full or preview; empty renders in full
+	Mode string `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`
+	// mp4, mov, avi, mkv or hls
+	OutputFormat string `protobuf:"bytes,3,opt,name=output_format,json=outputFormat,proto3" json:"output_format,omitempty"`
+	// low, medium, high or ultra
+	Quality    string  `protobuf:"bytes,4,opt,name=quality,proto3" json:"quality,omitempty"`
+	Resolution string  `protobuf:"bytes,5,opt,name=resolution,proto3" json:"resolution,omitempty"`
+	FrameRate  float64 `protobuf:"fixed64,6,opt,name=frame_rate,json=frameRate,proto3" json:"frame_rate,omitempty"`
+	// 16:9, 9:16, 1:1, 4:5 or 4:3
+	AspectRatio string `protobuf:"bytes,7,opt,name=aspect_ratio,json=aspectRatio,proto3" json:"aspect_ratio,omitempty"`
+	// 1 to 10; 0 keeps the default
+	Priority      int32 `protobuf:"varint,8,opt,name=priority,proto3" json:"priority,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitRenderRequest) Reset() {
+	*x = SubmitRenderRequest{}
+	mi := &file_studio_v1_studio_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitRenderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitRenderRequest) ProtoMessage() {}
+
+func (x *SubmitRenderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_studio_v1_studio_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitRenderRequest.ProtoReflect.Descriptor instead.
+func (*SubmitRenderRequest) Descriptor() ([]byte, []int) {
+	return file_studio_v1_studio_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SubmitRenderRequest) GetProjectId() uint64 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *SubmitRenderRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *SubmitRenderRequest) GetOutputFormat() string {
+	if x != nil {
+		return x.OutputFormat
+	}
+	return ""
+}
+
+func (x *SubmitRenderRequest) GetQuality() string {
+	if x != nil {
+		return x.Quality
+	}
+	return ""
+}
+
+func (x *SubmitRenderRequest) GetResolution() string {
+	if x != nil {
+		return x.Resolution
+	}
+	return ""
+}
+
+func (x *SubmitRenderRequest) GetFrameRate() float64 {
+	if x != nil {
+		return x.FrameRate
+	}
+	return 0
+}
+
+func (x *SubmitRenderRequest) GetAspectRatio() string {
+	if x != nil {
+		return x.AspectRatio
+	}
+	return ""
+}
+
+func (x *SubmitRenderRequest) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+type GetRenderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRenderRequest) Reset() {
+	*x = GetRenderRequest{}
+	mi := &file_studio_v1_studio_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRenderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRenderRequest) ProtoMessage() {}
+
+func (x *GetRenderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_studio_v1_studio_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRenderRequest.ProtoReflect.Descriptor instead.
+func (*GetRenderRequest) Descriptor() ([]byte, []int) {
+	return file_studio_v1_studio_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetRenderRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type RenderTask struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	TaskId    string                 `protobuf:"bytes,2,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	ProjectId uint64                 `protobuf:"varint,3,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	GroupId   string                 `protobuf:"bytes,4,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	// pending, processing, completed, failed or cancelled
+	Status       string  `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	Progress     int32   `protobuf:"varint,6,opt,name=progress,proto3" json:"progress,omitempty"`
+	Mode         string  `protobuf:"bytes,7,opt,name=mode,proto3" json:"mode,omitempty"`
+	OutputFormat string  `protobuf:"bytes,8,opt,name=output_format,json=outputFormat,proto3" json:"output_format,omitempty"`
+	Quality      string  `protobuf:"bytes,9,opt,name=quality,proto3" json:"quality,omitempty"`
+	Resolution   string  `protobuf:"bytes,10,opt,name=resolution,proto3" json:"resolution,omitempty"`
+	FrameRate    float64 `protobuf:"fixed64,11,opt,name=frame_rate,json=frameRate,proto3" json:"frame_rate,omitempty"`
+	AspectRatio  string  `protobuf:"bytes,12,opt,name=aspect_ratio,json=aspectRatio,proto3" json:"aspect_ratio,omitempty"`
+	FileSize     int64   `protobuf:"varint,13,opt,name=file_size,json=fileSize,proto3" json:"file_size,omitempty"`
+	Duration     float64 `protobuf:"fixed64,14,opt,name=duration,proto3" json:"duration,omitempty"`
+	ErrorMessage string  `protobuf:"bytes,15,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	// Seconds left, while pending or processing and known
+	EtaSeconds    *int32                 `protobuf:"varint,16,opt,name=eta_seconds,json=etaSeconds,proto3,oneof" json:"eta_seconds,omitempty"`
+	TraceId       string                 `protobuf:"bytes,17,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,19,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	CompletedAt   *timestamppb.Timestamp `protobuf:"bytes,20,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenderTask) Reset() {
+	*x = RenderTask{}
+	mi := &file_studio_v1_studio_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenderTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderTask) ProtoMessage() {}
+
+func (x *RenderTask) ProtoReflect() protoreflect.Message {
+	mi := &file_studio_v1_studio_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderTask.ProtoReflect.Descriptor instead.
+func (*RenderTask) Descriptor() ([]byte, []int) {
+	return file_studio_v1_studio_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RenderTask) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RenderTask) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *RenderTask) GetProjectId() uint64 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *RenderTask) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *RenderTask) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *RenderTask) GetProgress() int32 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *RenderTask) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *RenderTask) GetOutputFormat() string {
+	if x != nil {
+		return x.OutputFormat
+	}
+	return ""
+}
+
+func (x *RenderTask) GetQuality() string {
+	if x != nil {
+		return x.Quality
+	}
+	return ""
+}
+
+func (x *RenderTask) GetResolution() string {
+	if x != nil {
+		return x.Resolution
+	}
+	return ""
+}
+
+func (x *RenderTask) GetFrameRate() float64 {
+	if x != nil {
+		return x.FrameRate
+	}
+	return 0
+}
+
+func (x *RenderTask) GetAspectRatio() string {
+	if x != nil {
+		return x.AspectRatio
+	}
+	return ""
+}
+
+func (x *RenderTask) GetFileSize() int64 {
+	if x != nil {
+		return x.FileSize
+	}
+	return 0
+}
+
+func (x *RenderTask) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *RenderTask) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *RenderTask) GetEtaSeconds() int32 {
+	if x != nil && x.EtaSeconds != nil {
+		return *x.EtaSeconds
+	}
+	return 0
+}
+
+func (x *RenderTask) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *RenderTask) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *RenderTask) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *RenderTask) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+var File_studio_v1_studio_proto protoreflect.FileDescriptor
+
+const file_studio_v1_studio_proto_rawDesc = "" +
+	"\n" +
+	"\x16studio/v1/studio.proto\x12\tstudio.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\" \n" +
+	"\x0eGetClipRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"(\n" +
+	"\x14BatchGetClipsRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x04R\x03ids\">\n" +
+	"\x15BatchGetClipsResponse\x12%\n" +
+	"\x05clips\x18\x01 \x03(\v2\x0f.studio.v1.ClipR\x05clips\"\xa6\a\n" +
+	"\x04Clip\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1a\n" +
+	"\bduration\x18\x04 \x01(\x01R\bduration\x12\x1e\n" +
+	"\n" +
+	"resolution\x18\x05 \x01(\tR\n" +
+	"resolution\x12\x1d\n" +
+	"\n" +
+	"frame_rate\x18\x06 \x01(\x01R\tframeRate\x12\x16\n" +
+	"\x06format\x18\a \x01(\tR\x06format\x12\x1c\n" +
+	"\tthumbnail\x18\b \x01(\tR\tthumbnail\x12\x1a\n" +
+	"\bcategory\x18\t \x01(\tR\bcategory\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\x12\x12\n" +
+	"\x04mood\x18\v \x01(\tR\x04mood\x12\x14\n" +
+	"\x05style\x18\f \x01(\tR\x05style\x12\x14\n" +
+	"\x05color\x18\r \x01(\tR\x05color\x12\x1d\n" +
+	"\n" +
+	"scene_type\x18\x0e \x01(\tR\tsceneType\x12\x18\n" +
+	"\aobjects\x18\x0f \x03(\tR\aobjects\x12\x18\n" +
+	"\aactions\x18\x10 \x03(\tR\aactions\x12\x1a\n" +
+	"\bemotions\x18\x11 \x03(\tR\bemotions\x12#\n" +
+	"\rquality_score\x18\x12 \x01(\x01R\fqualityScore\x12\x1f\n" +
+	"\vusage_count\x18\x13 \x01(\x05R\n" +
+	"usageCount\x12%\n" +
+	"\x0efavorite_count\x18\x14 \x01(\x05R\rfavoriteCount\x12%\n" +
+	"\x0erating_average\x18\x15 \x01(\x01R\rratingAverage\x12\x16\n" +
+	"\x06status\x18\x16 \x01(\tR\x06status\x12\x17\n" +
+	"\auser_id\x18\x17 \x01(\x04R\x06userId\x12,\n" +
+	"\x0forganization_id\x18\x18 \x01(\x04H\x00R\x0eorganizationId\x88\x01\x01\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\x19 \x01(\tR\n" +
+	"visibility\x12\x14\n" +
+	"\x05stock\x18\x1a \x01(\bR\x05stock\x123\n" +
+	"\bmetadata\x18\x1b \x01(\v2\x17.google.protobuf.StructR\bmetadata\x129\n" +
+	"\n" +
+	"created_at\x18\x1c \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x1d \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\x12\n" +
+	"\x10_organization_id\"\x95\x02\n" +
+	"\x1aGenerateCompositionRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\x04R\tprojectId\x12;\n" +
+	"\frequirements\x18\x02 \x01(\v2\x17.google.protobuf.StructR\frequirements\x12\x1a\n" +
+	"\bvariants\x18\x03 \x01(\x05R\bvariants\x12\x1e\n" +
+	"\n" +
+	"algorithms\x18\x04 \x03(\tR\n" +
+	"algorithms\x12(\n" +
+	"\rcollection_id\x18\x05 \x01(\x04H\x00R\fcollectionId\x88\x01\x01\x12#\n" +
+	"\rinclude_stock\x18\x06 \x01(\bR\fincludeStockB\x10\n" +
+	"\x0e_collection_id\"'\n" +
+	"\x15GetCompositionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"\xd1\x03\n" +
+	"\vComposition\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x02 \x01(\x04R\tprojectId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1c\n" +
+	"\talgorithm\x18\x04 \x01(\tR\talgorithm\x12\x18\n" +
+	"\avariant\x18\x05 \x01(\x05R\avariant\x12%\n" +
+	"\x0etotal_duration\x18\x06 \x01(\x01R\rtotalDuration\x12#\n" +
+	"\rquality_score\x18\a \x01(\x01R\fqualityScore\x12%\n" +
+	"\x0ecohesion_score\x18\b \x01(\x01R\rcohesionScore\x12\x1a\n" +
+	"\btimeline\x18\t \x01(\tR\btimeline\x12#\n" +
+	"\rerror_message\x18\n" +
+	" \x01(\tR\ferrorMessage\x12\x19\n" +
+	"\btrace_id\x18\v \x01(\tR\atraceId\x129\n" +
+	"\n" +
+	"created_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x85\x02\n" +
+	"\x13SubmitRenderRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\x04R\tprojectId\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\tR\x04mode\x12#\n" +
+	"\routput_format\x18\x03 \x01(\tR\foutputFormat\x12\x18\n" +
+	"\aquality\x18\x04 \x01(\tR\aquality\x12\x1e\n" +
+	"\n" +
+	"resolution\x18\x05 \x01(\tR\n" +
+	"resolution\x12\x1d\n" +
+	"\n" +
+	"frame_rate\x18\x06 \x01(\x01R\tframeRate\x12!\n" +
+	"\faspect_ratio\x18\a \x01(\tR\vaspectRatio\x12\x1a\n" +
+	"\bpriority\x18\b \x01(\x05R\bpriority\"\"\n" +
+	"\x10GetRenderRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"\xbc\x05\n" +
+	"\n" +
+	"RenderTask\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x17\n" +
+	"\atask_id\x18\x02 \x01(\tR\x06taskId\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x03 \x01(\x04R\tprojectId\x12\x19\n" +
+	"\bgroup_id\x18\x04 \x01(\tR\agroupId\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1a\n" +
+	"\bprogress\x18\x06 \x01(\x05R\bprogress\x12\x12\n" +
+	"\x04mode\x18\a \x01(\tR\x04mode\x12#\n" +
+	"\routput_format\x18\b \x01(\tR\foutputFormat\x12\x18\n" +
+	"\aquality\x18\t \x01(\tR\aquality\x12\x1e\n" +
+	"\n" +
+	"resolution\x18\n" +
+	" \x01(\tR\n" +
+	"resolution\x12\x1d\n" +
+	"\n" +
+	"frame_rate\x18\v \x01(\x01R\tframeRate\x12!\n" +
+	"\faspect_ratio\x18\f \x01(\tR\vaspectRatio\x12\x1b\n" +
+	"\tfile_size\x18\r \x01(\x03R\bfileSize\x12\x1a\n" +
+	"\bduration\x18\x0e \x01(\x01R\bduration\x12#\n" +
+	"\rerror_message\x18\x0f \x01(\tR\ferrorMessage\x12$\n" +
+	"\veta_seconds\x18\x10 \x01(\x05H\x00R\n" +
+	"etaSeconds\x88\x01\x01\x12\x19\n" +
+	"\btrace_id\x18\x11 \x01(\tR\atraceId\x129\n" +
+	"\n" +
+	"created_at\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"started_at\x18\x13 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12=\n" +
+	"\fcompleted_at\x18\x14 \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAtB\x0e\n" +
+	"\f_eta_seconds2\x98\x01\n" +
+	"\vClipService\x125\n" +
+	"\aGetClip\x12\x19.studio.v1.GetClipRequest\x1a\x0f.studio.v1.Clip\x12R\n" +
+	"\rBatchGetClips\x12\x1f.studio.v1.BatchGetClipsRequest\x1a .studio.v1.BatchGetClipsResponse2\xb6\x01\n" +
+	"\x12CompositionService\x12T\n" +
+	"\x13GenerateComposition\x12%.studio.v1.GenerateCompositionRequest\x1a\x16.studio.v1.Composition\x12J\n" +
+	"\x0eGetComposition\x12 .studio.v1.GetCompositionRequest\x1a\x16.studio.v1.Composition2\x97\x01\n" +
+	"\rRenderService\x12E\n" +
+	"\fSubmitRender\x12\x1e.studio.v1.SubmitRenderRequest\x1a\x15.studio.v1.RenderTask\x12?\n" +
+	"\tGetRender\x12\x1b.studio.v1.GetRenderRequest\x1a\x15.studio.v1.RenderTaskB1Z/creative-studio-server/proto/studio/v1;studiov1b\x06proto3"
+
+var (
+	file_studio_v1_studio_proto_rawDescOnce sync.Once
+	file_studio_v1_studio_proto_rawDescData []byte
+)
+
+func file_studio_v1_studio_proto_rawDescGZIP() []byte {
+	file_studio_v1_studio_proto_rawDescOnce.Do(func() {
+		file_studio_v1_studio_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_studio_v1_studio_proto_rawDesc), len(file_studio_v1_studio_proto_rawDesc)))
+	})
+	return file_studio_v1_studio_proto_rawDescData
+}
+
+var file_studio_v1_studio_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_studio_v1_studio_proto_goTypes = []any{
+	(*GetClipRequest)(nil),             // 0: studio.v1.GetClipRequest
+	(*BatchGetClipsRequest)(nil),       // 1: studio.v1.BatchGetClipsRequest
+	(*BatchGetClipsResponse)(nil),      // 2: studio.v1.BatchGetClipsResponse
+	(*Clip)(nil),                       // 3: studio.v1.Clip
+	(*GenerateCompositionRequest)(nil), // 4: studio.v1.GenerateCompositionRequest
+	(*GetCompositionRequest)(nil),      // 5: studio.v1.GetCompositionRequest
+	(*Composition)(nil),                // 6: studio.v1.Composition
+	(*SubmitRenderRequest)(nil),        // 7: studio.v1.SubmitRenderRequest
+	(*GetRenderRequest)(nil),           // 8: studio.v1.GetRenderRequest
+	(*RenderTask)(nil),                 // 9: studio.v1.RenderTask
+	(*structpb.Struct)(nil),            // 10: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),      // 11: google.protobuf.Timestamp
+}
+var file_studio_v1_studio_proto_depIdxs = []int32{
+	3,  // 0: studio.v1.BatchGetClipsResponse.clips:type_name -> studio.v1.Clip
+	10, // 1: studio.v1.Clip.metadata:type_name -> google.protobuf.Struct
+	11, // 2: studio.v1.Clip.created_at:type_name -> google.protobuf.Timestamp
+	11, // 3: studio.v1.Clip.updated_at:type_name -> google.protobuf.Timestamp
+	10, // 4: studio.v1.GenerateCompositionRequest.requirements:type_name -> google.protobuf.Struct
+	11, // 5: studio.v1.Composition.created_at:type_name -> google.protobuf.Timestamp
+	11, // 6: studio.v1.Composition.updated_at:type_name -> google.protobuf.Timestamp
+	11, // 7: studio.v1.RenderTask.created_at:type_name -> google.protobuf.Timestamp
+	11, // 8: studio.v1.RenderTask.started_at:type_name -> google.protobuf.Timestamp
+	11, // 9: studio.v1.RenderTask.completed_at:type_name -> google.protobuf.Timestamp
+	0,  // 10: studio.v1.ClipService.GetClip:input_type -> studio.v1.GetClipRequest
+	1,  // 11: studio.v1.ClipService.BatchGetClips:input_type -> studio.v1.BatchGetClipsRequest
+	4,  // 12: studio.v1.CompositionService.GenerateComposition:input_type -> studio.v1.GenerateCompositionRequest
+	5,  // 13: studio.v1.CompositionService.GetComposition:input_type -> studio.v1.GetCompositionRequest
+	7,  // 14: studio.v1.RenderService.SubmitRender:input_type -> studio.v1.SubmitRenderRequest
+	8,  // 15: studio.v1.RenderService.GetRender:input_type -> studio.v1.GetRenderRequest
+	3,  // 16: studio.v1.ClipService.GetClip:output_type -> studio.v1.Clip
+	2,  // 17: studio.v1.ClipService.BatchGetClips:output_type -> studio.v1.BatchGetClipsResponse
+	6,  // 18: studio.v1.CompositionService.GenerateComposition:output_type -> studio.v1.Composition
+	6,  // 19: studio.v1.CompositionService.GetComposition:output_type -> studio.v1.Composition
+	9,  // 20: studio.v1.RenderService.SubmitRender:output_type -> studio.v1.RenderTask
+	9,  // 21: studio.v1.RenderService.GetRender:output_type -> studio.v1.RenderTask
+	16, // [16:22] is the sub-list for method output_type
+	10, // [10:16] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_studio_v1_studio_proto_init() }
+func file_studio_v1_studio_proto_init() {
+	if File_studio_v1_studio_proto != nil {
+		return
+	}
+	file_studio_v1_studio_proto_msgTypes[3].OneofWrappers = []any{}
+	file_studio_v1_studio_proto_msgTypes[4].OneofWrappers = []any{}
+	file_studio_v1_studio_proto_msgTypes[9].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_studio_v1_studio_proto_rawDesc), len(file_studio_v1_studio_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   3,
+		},
+		GoTypes:           file_studio_v1_studio_proto_goTypes,
+		DependencyIndexes: file_studio_v1_studio_proto_depIdxs,
+		MessageInfos:      file_studio_v1_studio_proto_msgTypes,
+	}.Build()
+	File_studio_v1_studio_proto = out.File
+	file_studio_v1_studio_proto_goTypes = nil
+	file_studio_v1_studio_proto_depIdxs = nil
+}
@@ -0,0 +1,473 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: studio/v1/studio.proto
+
+// Internal API for other services of the platform. Calls authenticate with
+// an API key in the x-api-key metadata and need the same permissions, and
+// key scopes, as the matching HTTP routes.
+
+package studiov1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ClipService_GetClip_FullMethodName       = "/studio.v1.ClipService/GetClip"
+	ClipService_BatchGetClips_FullMethodName = "/studio.v1.ClipService/BatchGetClips"
+)
+
+// ClipServiceClient is the client API for ClipService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ClipService looks up clip metadata.
+type ClipServiceClient interface {
+	// GetClip returns a clip the caller can read. Needs clips:read.
+	GetClip(ctx context.Context, in *GetClipRequest, opts ...grpc.CallOption) (*Clip, error)
+	// BatchGetClips returns the clips the caller can read among those asked
+	// for, in the order asked; the others are left out. Needs clips:read.
+	BatchGetClips(ctx context.Context, in *BatchGetClipsRequest, opts ...grpc.CallOption) (*BatchGetClipsResponse, error)
+}
+
+type clipServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClipServiceClient(cc grpc.ClientConnInterface) ClipServiceClient {
+	return &clipServiceClient{cc}
+}
+
+func (c *clipServiceClient) GetClip(ctx context.Context, in *GetClipRequest, opts ...grpc.CallOption) (*Clip, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Clip)
+	err := c.cc.Invoke(ctx, ClipService_GetClip_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clipServiceClient) BatchGetClips(ctx context.Context, in *BatchGetClipsRequest, opts ...grpc.CallOption) (*BatchGetClipsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetClipsResponse)
+	err := c.cc.Invoke(ctx, ClipService_BatchGetClips_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClipServiceServer is the server API for ClipService service.
+// All implementations must embed UnimplementedClipServiceServer
+// for forward compatibility.
+//
+// ClipService looks up clip metadata.
+type ClipServiceServer interface {
+	// GetClip returns a clip the caller can read. Needs clips:read.
+	GetClip(context.Context, *GetClipRequest) (*Clip, error)
+	// BatchGetClips returns the clips the caller can read among those asked
+	// for, in the order asked; the others are left out. Needs clips:read.
+	BatchGetClips(context.Context, *BatchGetClipsRequest) (*BatchGetClipsResponse, error)
+	mustEmbedUnimplementedClipServiceServer()
+}
+
+// UnimplementedClipServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedClipServiceServer struct{}
+
+func (UnimplementedClipServiceServer) GetClip(context.Context, *GetClipRequest) (*Clip, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetClip not implemented")
+}
+func (UnimplementedClipServiceServer) BatchGetClips(context.Context, *BatchGetClipsRequest) (*BatchGetClipsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetClips not implemented")
+}
+func (UnimplementedClipServiceServer) mustEmbedUnimplementedClipServiceServer() {}
+func (UnimplementedClipServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeClipServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClipServiceServer will
+// result in compilation errors.
+type UnsafeClipServiceServer interface {
+	mustEmbedUnimplementedClipServiceServer()
+}
+
+func RegisterClipServiceServer(s grpc.ServiceRegistrar, srv ClipServiceServer) {
+	// If the following call pancis, it indicates UnimplementedClipServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ClipService_ServiceDesc, srv)
+}
+
+func _ClipService_GetClip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetClipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClipServiceServer).GetClip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClipService_GetClip_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClipServiceServer).GetClip(ctx, req.(*GetClipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClipService_BatchGetClips_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetClipsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClipServiceServer).BatchGetClips(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClipService_BatchGetClips_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClipServiceServer).BatchGetClips(ctx, req.(*BatchGetClipsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ClipService_ServiceDesc is the grpc.ServiceDesc for ClipService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClipService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "studio.v1.ClipService",
+	HandlerType: (*ClipServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetClip",
+			Handler:    _ClipService_GetClip_Handler,
+		},
+		{
+			MethodName: "BatchGetClips",
+			Handler:    _ClipService_BatchGetClips_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "studio/v1/studio.proto",
+}
+
+const (
+	CompositionService_GenerateComposition_FullMethodName = "/studio.v1.CompositionService/GenerateComposition"
+	CompositionService_GetComposition_FullMethodName      = "/studio.v1.CompositionService/GetComposition"
+)
+
+// CompositionServiceClient is the client API for CompositionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CompositionService generates compositions for projects.
+type CompositionServiceClient interface {
+	// GenerateComposition queues the generation of a composition, and of
+	// any extra variants, for a project. Needs compositions:write.
+	GenerateComposition(ctx context.Context, in *GenerateCompositionRequest, opts ...grpc.CallOption) (*Composition, error)
+	// GetComposition returns a composition of the caller's. Needs
+	// compositions:read.
+	GetComposition(ctx context.Context, in *GetCompositionRequest, opts ...grpc.CallOption) (*Composition, error)
+}
+
+type compositionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCompositionServiceClient(cc grpc.ClientConnInterface) CompositionServiceClient {
+	return &compositionServiceClient{cc}
+}
+
+func (c *compositionServiceClient) GenerateComposition(ctx context.Context, in *GenerateCompositionRequest, opts ...grpc.CallOption) (*Composition, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Composition)
+	err := c.cc.Invoke(ctx, CompositionService_GenerateComposition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *compositionServiceClient) GetComposition(ctx context.Context, in *GetCompositionRequest, opts ...grpc.CallOption) (*Composition, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Composition)
+	err := c.cc.Invoke(ctx, CompositionService_GetComposition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CompositionServiceServer is the server API for CompositionService service.
+// All implementations must embed UnimplementedCompositionServiceServer
+// for forward compatibility.
+//
+// CompositionService generates compositions for projects.
+type CompositionServiceServer interface {
+	// GenerateComposition queues the generation of a composition, and of
+	// any extra variants, for a project. Needs compositions:write.
+	GenerateComposition(context.Context, *GenerateCompositionRequest) (*Composition, error)
+	// GetComposition returns a composition of the caller's. Needs
+	// compositions:read.
+	GetComposition(context.Context, *GetCompositionRequest) (*Composition, error)
+	mustEmbedUnimplementedCompositionServiceServer()
+}
+
+// UnimplementedCompositionServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCompositionServiceServer struct{}
+
+func (UnimplementedCompositionServiceServer) GenerateComposition(context.Context, *GenerateCompositionRequest) (*Composition, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenerateComposition not implemented")
+}
+func (UnimplementedCompositionServiceServer) GetComposition(context.Context, *GetCompositionRequest) (*Composition, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetComposition not implemented")
+}
+func (UnimplementedCompositionServiceServer) mustEmbedUnimplementedCompositionServiceServer() {}
+func (UnimplementedCompositionServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeCompositionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CompositionServiceServer will
+// result in compilation errors.
+type UnsafeCompositionServiceServer interface {
+	mustEmbedUnimplementedCompositionServiceServer()
+}
+
+func RegisterCompositionServiceServer(s grpc.ServiceRegistrar, srv CompositionServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCompositionServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CompositionService_ServiceDesc, srv)
+}
+
+func _CompositionService_GenerateComposition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateCompositionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompositionServiceServer).GenerateComposition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompositionService_GenerateComposition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompositionServiceServer).GenerateComposition(ctx, req.(*GenerateCompositionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompositionService_GetComposition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCompositionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompositionServiceServer).GetComposition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompositionService_GetComposition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompositionServiceServer).GetComposition(ctx, req.(*GetCompositionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CompositionService_ServiceDesc is the grpc.ServiceDesc for CompositionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CompositionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "studio.v1.CompositionService",
+	HandlerType: (*CompositionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GenerateComposition",
+			Handler:    _CompositionService_GenerateComposition_Handler,
+		},
+		{
+			MethodName: "GetComposition",
+			Handler:    _CompositionService_GetComposition_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "studio/v1/studio.proto",
+}
+
+const (
+	RenderService_SubmitRender_FullMethodName = "/studio.v1.RenderService/SubmitRender"
+	RenderService_GetRender_FullMethodName    = "/studio.v1.RenderService/GetRender"
+)
+
+// RenderServiceClient is the client API for RenderService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RenderService renders projects.
+type RenderServiceClient interface {
+	// SubmitRender queues a render of a project. Needs renders:create.
+	SubmitRender(ctx context.Context, in *SubmitRenderRequest, opts ...grpc.CallOption) (*RenderTask, error)
+	// GetRender returns a render of the caller's. Needs renders:read.
+	GetRender(ctx context.Context, in *GetRenderRequest, opts ...grpc.CallOption) (*RenderTask, error)
+}
+
+type renderServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRenderServiceClient(cc grpc.ClientConnInterface) RenderServiceClient {
+	return &renderServiceClient{cc}
+}
+
+func (c *renderServiceClient) SubmitRender(ctx context.Context, in *SubmitRenderRequest, opts ...grpc.CallOption) (*RenderTask, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenderTask)
+	err := c.cc.Invoke(ctx, RenderService_SubmitRender_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *renderServiceClient) GetRender(ctx context.Context, in *GetRenderRequest, opts ...grpc.CallOption) (*RenderTask, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenderTask)
+	err := c.cc.Invoke(ctx, RenderService_GetRender_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RenderServiceServer is the server API for RenderService service.
+// All implementations must embed UnimplementedRenderServiceServer
+// for forward compatibility.
+//
+// RenderService renders projects.
+type RenderServiceServer interface {
+	// SubmitRender queues a render of a project. Needs renders:create.
+	SubmitRender(context.Context, *SubmitRenderRequest) (*RenderTask, error)
+	// GetRender returns a render of the caller's. Needs renders:read.
+	GetRender(context.Context, *GetRenderRequest) (*RenderTask, error)
+	mustEmbedUnimplementedRenderServiceServer()
+}
+
+// UnimplementedRenderServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRenderServiceServer struct{}
+
+func (UnimplementedRenderServiceServer) SubmitRender(context.Context, *SubmitRenderRequest) (*RenderTask, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitRender not implemented")
+}
+func (UnimplementedRenderServiceServer) GetRender(context.Context, *GetRenderRequest) (*RenderTask, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRender not implemented")
+}
+func (UnimplementedRenderServiceServer) mustEmbedUnimplementedRenderServiceServer() {}
+func (UnimplementedRenderServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeRenderServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RenderServiceServer will
+// result in compilation errors.
+type UnsafeRenderServiceServer interface {
+	mustEmbedUnimplementedRenderServiceServer()
+}
+
+func RegisterRenderServiceServer(s grpc.ServiceRegistrar, srv RenderServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRenderServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RenderService_ServiceDesc, srv)
+}
+
+func _RenderService_SubmitRender_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitRenderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RenderServiceServer).SubmitRender(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RenderService_SubmitRender_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RenderServiceServer).SubmitRender(ctx, req.(*SubmitRenderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RenderService_GetRender_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRenderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RenderServiceServer).GetRender(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RenderService_GetRender_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RenderServiceServer).GetRender(ctx, req.(*GetRenderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RenderService_ServiceDesc is the grpc.ServiceDesc for RenderService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RenderService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "studio.v1.RenderService",
+	HandlerType: (*RenderServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitRender",
+			Handler:    _RenderService_SubmitRender_Handler,
+		},
+		{
+			MethodName: "GetRender",
+			Handler:    _RenderService_GetRender_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "studio/v1/studio.proto",
+}
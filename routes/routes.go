@@ -2,15 +2,43 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"creative-studio-server/config"
 	"creative-studio-server/controllers"
+	_ "creative-studio-server/docs"
+	"creative-studio-server/middleware"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/metrics"
 )
 
 func SetupRoutes(r *gin.Engine) {
+	// Upload routes take larger bodies than the rest
+	storage := config.AppConfig.Storage
+	r.Use(middleware.BodyLimit(config.AppConfig.Server.MaxRequestSize, map[string]int64{
+		"/api/v1/videos/upload":       storage.VideoUploadMaxSize,
+		"/api/v1/atomic-clips":        storage.MaxUploadSize,
+		"/api/v1/atomic-clips/import": storage.ImportMaxSize,
+		"/api/v1/projects/import":     storage.TimelineImportMaxSize,
+	}))
+	// Health checks, sign-in and the toggle itself stay up in maintenance,
+	// so operators can always turn it off again
+	r.Use(middleware.Maintenance(map[string]bool{
+		"/health":                 true,
+		"/metrics":                true,
+		"/api/v1/auth/login":      true,
+		"/api/v1/auth/refresh":    true,
+		"/api/v1/auth/2fa/verify": true,
+		"/api/v1/maintenance":     true,
+	}))
+
 	// Initialize video controller
 	videoController := controllers.NewVideoController()
 
 	// Health check and system endpoints
 	r.GET("/health", healthCheck)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "Creative Studio Video Server API",
@@ -22,20 +50,484 @@ func SetupRoutes(r *gin.Engine) {
 	// API v1 routes - simplified for video processing only
 	v1 := r.Group("/api/v1")
 	{
-		// Video processing routes (no authentication required)
+		// Video processing routes. Without the database, in simple mode,
+		// they need no authentication and files are shared; otherwise each
+		// user only sees their own files
+		videoAuth := middleware.AuthRequired()
+		if config.AppConfig.Server.SimpleMode {
+			videoAuth = middleware.OptionalAuth()
+		}
 		videos := v1.Group("/videos")
 		{
-			videos.POST("/upload", videoController.UploadVideo)
-			videos.POST("/concatenate", videoController.ConcatenateVideos)
-			videos.GET("/files", videoController.ListFiles)
-			videos.GET("/output", videoController.ListOutputFiles)
-			videos.GET("/info/:filename", videoController.GetVideoInfo)
+			// Output videos are downloaded and played through signed URLs
 			videos.GET("/download/:filename", videoController.DownloadVideo)
-			videos.DELETE("/:filename", videoController.DeleteFile)
+			videos.GET("/play/:filename", videoController.PlayVideo)
+		}
+		videoFiles := videos.Group("", videoAuth, middleware.Idempotency(config.AppConfig.Server.IdempotencyTTL))
+		{
+			videoFiles.POST("/upload", videoController.UploadVideo)
+			videoFiles.POST("/concatenate", videoController.ConcatenateVideos)
+			videoFiles.GET("/files", videoController.ListFiles)
+			videoFiles.GET("/output", videoController.ListOutputFiles)
+			videoFiles.GET("/info/:filename", videoController.GetVideoInfo)
+			videoFiles.POST("/download-url", videoController.CreateDownloadURL)
+			videoFiles.DELETE("/:filename", videoController.DeleteFile)
+		}
+	}
+
+	setupSwaggerRoutes(r)
+
+	// The remaining routes need the database, Redis and RabbitMQ
+	if !config.AppConfig.Server.SimpleMode {
+		if config.AppConfig.JWT.PublishJWKS {
+			r.GET("/.well-known/jwks.json", controllers.NewSigningKeyController().JWKS)
+		}
+		setupAPIRoutes(v1)
+	}
+}
+
+// setupSwaggerRoutes serves the Swagger UI and the OpenAPI spec generated
+// into docs by make swagger. Outside debug and test mode they are left to
+// admins, which takes the database, so simple mode serves them only there.
+func setupSwaggerRoutes(r *gin.Engine) {
+	handler := ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.PersistAuthorization(true))
+
+	switch {
+	case config.AppConfig.Server.Mode != gin.ReleaseMode:
+		r.GET("/swagger/*any", handler)
+	case !config.AppConfig.Server.SimpleMode:
+		r.GET("/swagger/*any", middleware.QueryTokenAuth(), middleware.AuthRequired(), middleware.PermissionRequired(models.PermissionAdminDocs), handler)
+	}
+}
+
+func setupAPIRoutes(v1 *gin.RouterGroup) {
+	authController := controllers.NewAuthController()
+	atomicClipController := controllers.NewAtomicClipController()
+	compositionController := controllers.NewCompositionController()
+	projectController := controllers.NewProjectController()
+	templateController := controllers.NewTemplateController()
+	collectionController := controllers.NewCollectionController()
+	savedSearchController := controllers.NewSavedSearchController()
+	favoriteController := controllers.NewFavoriteController()
+	tagController := controllers.NewTagController()
+	clipImportController := controllers.NewClipImportController()
+	shareLinkController := controllers.NewShareLinkController()
+	commentController := controllers.NewCommentController()
+	exportController := controllers.NewExportController()
+	timelineImportController := controllers.NewTimelineImportController()
+	renderTaskController := controllers.NewRenderTaskController()
+	notificationController := controllers.NewNotificationController()
+	taskController := controllers.NewTaskController()
+	scheduleController := controllers.NewScheduleController()
+	queueController := controllers.NewQueueController()
+	apiKeyController := controllers.NewAPIKeyController()
+	roleController := controllers.NewRoleController()
+	organizationController := controllers.NewOrganizationController()
+	twoFactorController := controllers.NewTwoFactorController()
+	signingKeyController := controllers.NewSigningKeyController()
+	sessionController := controllers.NewSessionController()
+	auditController := controllers.NewAuditController()
+	searchController := controllers.NewSearchController()
+	analysisBackfillController := controllers.NewAnalysisBackfillController()
+	trashController := controllers.NewTrashController()
+	webhookController := controllers.NewWebhookController()
+	moderationController := controllers.NewModerationController()
+	graphQLController := controllers.NewGraphQLController()
+	maintenanceController := controllers.NewMaintenanceController()
+	usageController := controllers.NewUsageController()
+
+	// Whether the API is in maintenance, for clients to warn their users
+	v1.GET("/maintenance", maintenanceController.GetMaintenance)
+
+	// Authentication routes
+	authRoutes := v1.Group("/auth")
+	{
+		authRoutes.POST("/register", middleware.AuthRateLimit(), authController.Register)
+		authRoutes.POST("/login", middleware.AuthRateLimit(), authController.Login)
+		authRoutes.POST("/refresh", middleware.AuthRateLimit(), authController.RefreshToken)
+		authRoutes.POST("/logout", middleware.AuthRequired(), middleware.SessionRequired(), authController.Logout)
+		authRoutes.GET("/profile", middleware.AuthRequired(), middleware.SessionRequired(), authController.Profile)
+		authRoutes.POST("/change-password", middleware.AuthRateLimit(), middleware.AuthRequired(), middleware.SessionRequired(), authController.ChangePassword)
+		authRoutes.POST("/verify-email", middleware.AuthRateLimit(), authController.VerifyEmail)
+		authRoutes.POST("/resend-verification", middleware.AuthRateLimit(), middleware.AuthRequired(), middleware.SessionRequired(), authController.ResendVerification)
+		authRoutes.POST("/forgot-password", middleware.AuthRateLimit(), authController.ForgotPassword)
+		authRoutes.POST("/reset-password", middleware.AuthRateLimit(), authController.ResetPassword)
+
+		// Signed-in devices
+		sessions := authRoutes.Group("/sessions")
+		sessions.Use(middleware.AuthRequired(), middleware.SessionRequired())
+		{
+			sessions.GET("", sessionController.ListSessions)
+			sessions.DELETE("", sessionController.RevokeAllSessions)
+			sessions.DELETE("/:id", sessionController.RevokeSession)
+		}
+
+		// Two-factor authentication
+		authRoutes.POST("/2fa/verify", middleware.AuthRateLimit(), twoFactorController.Verify)
+		twoFactor := authRoutes.Group("/2fa")
+		twoFactor.Use(middleware.AuthRateLimit(), middleware.AuthRequired(), middleware.SessionRequired())
+		{
+			twoFactor.POST("/setup", twoFactorController.Setup)
+			twoFactor.POST("/enable", twoFactorController.Enable)
+			twoFactor.POST("/disable", twoFactorController.Disable)
+			twoFactor.POST("/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
+		}
+	}
+
+	// Permissions each route needs; API keys need them as scopes too
+	clipsRead := middleware.PermissionRequired(models.PermissionClipsRead)
+	clipsWrite := middleware.PermissionRequired(models.PermissionClipsWrite)
+	rendersRead := middleware.PermissionRequired(models.PermissionRendersRead)
+	rendersCreate := middleware.PermissionRequired(models.PermissionRendersCreate)
+	compositionsRead := middleware.PermissionRequired(models.PermissionCompositionsRead)
+	compositionsWrite := middleware.PermissionRequired(models.PermissionCompositionsWrite)
+	compositionsReview := middleware.PermissionRequired(models.PermissionCompositionsReview)
+	// Usage quotas of the user's plan
+	uploadQuota := middleware.UploadQuota()
+	renderQuota := middleware.RenderQuota()
+
+	// Realtime notifications (server-sent events)
+	v1.GET("/events", middleware.QueryTokenAuth(), middleware.AuthRequired(), rendersRead, notificationController.StreamEvents)
+
+	// Clip playback, thumbnails, downloads and captions; <video>, <img> and
+	// <track> elements and download links cannot send headers either
+	v1.GET("/atomic-clips/:id/stream", middleware.QueryTokenAuth(), middleware.AuthRequired(), clipsRead, atomicClipController.StreamAtomicClip)
+	v1.GET("/atomic-clips/:id/thumbnail", middleware.QueryTokenAuth(), middleware.AuthRequired(), clipsRead, atomicClipController.GetClipThumbnail)
+	v1.GET("/atomic-clips/:id/download", middleware.QueryTokenAuth(), middleware.AuthRequired(), clipsRead, atomicClipController.DownloadAtomicClip)
+	v1.GET("/atomic-clips/:id/transcript", middleware.QueryTokenAuth(), middleware.AuthRequired(), clipsRead, atomicClipController.GetClipTranscript)
+
+	// Published templates, open to everyone
+	v1.GET("/templates/gallery", templateController.Gallery)
+
+	// Share links, open to anyone holding a token; limited as tokens and
+	// passwords can be guessed at
+	shared := v1.Group("/shared/:token", middleware.RateLimit(60, 120))
+	{
+		shared.GET("", shareLinkController.ViewShared)
+		shared.GET("/stream", shareLinkController.StreamShared)
+		shared.GET("/download", shareLinkController.DownloadShared)
+	}
+
+	// Authenticated routes
+	api := v1.Group("")
+	// Retried POSTs sent with an Idempotency-Key are replayed, not run again
+	api.Use(middleware.AuthRequired(), middleware.OrganizationContext(), middleware.PlanRateLimit(), middleware.Idempotency(config.AppConfig.Server.IdempotencyTTL))
+	{
+		atomicClips := api.Group("/atomic-clips")
+		{
+			atomicClips.POST("", clipsWrite, uploadQuota, atomicClipController.CreateAtomicClip)
+			atomicClips.GET("/search", clipsRead, middleware.ETag(), atomicClipController.SearchAtomicClips)
+			atomicClips.GET("/my-clips", clipsRead, middleware.ETag(), atomicClipController.GetUserAtomicClips)
+			atomicClips.GET("/favorites", clipsRead, favoriteController.ListFavoriteClips)
+			atomicClips.POST("/batch", clipsWrite, atomicClipController.BatchClips)
+			atomicClips.POST("/import", clipsWrite, uploadQuota, clipImportController.CreateImport)
+			atomicClips.POST("/import-url", clipsWrite, uploadQuota, clipImportController.CreateURLImport)
+			atomicClips.GET("/imports", clipsRead, clipImportController.ListImports)
+			atomicClips.GET("/imports/:id", clipsRead, clipImportController.GetImport)
+			atomicClips.GET("/:id", clipsRead, middleware.ETag(), atomicClipController.GetAtomicClip)
+			atomicClips.PUT("/:id", clipsWrite, atomicClipController.UpdateAtomicClip)
+			atomicClips.DELETE("/:id", clipsWrite, atomicClipController.DeleteAtomicClip)
+			atomicClips.PUT("/:id/stock", clipsWrite, atomicClipController.SetStock)
+			atomicClips.PUT("/:id/thumbnail", clipsWrite, atomicClipController.SetClipThumbnail)
+			atomicClips.GET("/:id/similar", clipsRead, atomicClipController.GetSimilarClips)
+			atomicClips.GET("/:id/duplicates", clipsRead, atomicClipController.GetDuplicateClips)
+			atomicClips.GET("/:id/usages", clipsRead, atomicClipController.GetClipUsages)
+			atomicClips.GET("/:id/analysis", clipsRead, middleware.ETag(), atomicClipController.GetClipAnalysis)
+			atomicClips.POST("/:id/analyze", clipsWrite, atomicClipController.AnalyzeClip)
+			atomicClips.POST("/:id/transcribe", clipsWrite, atomicClipController.TranscribeClip)
+			atomicClips.POST("/:id/suggest-metadata", clipsWrite, atomicClipController.SuggestClipMetadata)
+			atomicClips.GET("/:id/music-analysis", clipsRead, atomicClipController.GetMusicAnalysis)
+			atomicClips.POST("/:id/analyze-music", clipsWrite, atomicClipController.AnalyzeMusic)
+			atomicClips.POST("/:id/share", clipsWrite, shareLinkController.ShareClip)
+			atomicClips.GET("/:id/comments", clipsRead, commentController.ListClipComments)
+			atomicClips.POST("/:id/comments", clipsWrite, commentController.CreateClipComment)
+			// Favoriting and rating leave the clip itself unchanged
+			atomicClips.GET("/:id/engagement", clipsRead, favoriteController.GetClipEngagement)
+			atomicClips.POST("/:id/favorite", clipsRead, favoriteController.FavoriteClip)
+			atomicClips.DELETE("/:id/favorite", clipsRead, favoriteController.UnfavoriteClip)
+			atomicClips.PUT("/:id/rating", clipsRead, favoriteController.RateClip)
+			atomicClips.DELETE("/:id/rating", clipsRead, favoriteController.RemoveClipRating)
+		}
+
+		// Collections only arrange clips, so they share the clip permissions
+		collections := api.Group("/collections")
+		{
+			collections.POST("", clipsWrite, collectionController.CreateCollection)
+			collections.GET("", clipsRead, collectionController.ListCollections)
+			collections.GET("/:id", clipsRead, collectionController.GetCollection)
+			collections.PATCH("/:id", clipsWrite, collectionController.UpdateCollection)
+			collections.DELETE("/:id", clipsWrite, collectionController.DeleteCollection)
+			collections.POST("/:id/clips", clipsWrite, collectionController.AddClips)
+			collections.PUT("/:id/clips", clipsWrite, collectionController.SetClips)
+			collections.DELETE("/:id/clips/:clip_id", clipsWrite, collectionController.RemoveClip)
+		}
+
+		// Saved searches only find clips, so they share the clip permissions
+		savedSearches := api.Group("/saved-searches")
+		{
+			savedSearches.POST("", clipsWrite, savedSearchController.CreateSavedSearch)
+			savedSearches.GET("", clipsRead, savedSearchController.ListSavedSearches)
+			savedSearches.GET("/:id", clipsRead, savedSearchController.GetSavedSearch)
+			savedSearches.PATCH("/:id", clipsWrite, savedSearchController.UpdateSavedSearch)
+			savedSearches.DELETE("/:id", clipsWrite, savedSearchController.DeleteSavedSearch)
+			savedSearches.GET("/:id/clips", clipsRead, savedSearchController.RunSavedSearch)
+		}
+
+		// Projects hold the timeline compositions are promoted to, so they
+		// share their permissions
+		projects := api.Group("/projects")
+		{
+			projects.POST("", compositionsWrite, projectController.CreateProject)
+			projects.POST("/import", compositionsWrite, timelineImportController.ImportProject)
+			projects.GET("", compositionsRead, projectController.ListProjects)
+			projects.GET("/:id", compositionsRead, projectController.GetProject)
+			projects.PATCH("/:id", compositionsWrite, projectController.UpdateProject)
+			projects.PUT("/:id/timeline", compositionsWrite, projectController.UpdateTimeline)
+			projects.POST("/:id/archive", compositionsWrite, projectController.ArchiveProject)
+			projects.GET("/:id/export", compositionsRead, exportController.ExportProject)
+			projects.GET("/:id/revisions", compositionsRead, projectController.ListRevisions)
+			projects.GET("/:id/revisions/diff", compositionsRead, projectController.DiffRevisions)
+			projects.GET("/:id/revisions/:version", compositionsRead, projectController.GetRevision)
+			projects.POST("/:id/revisions/:version/restore", compositionsWrite, projectController.RestoreRevision)
+			projects.DELETE("/:id", compositionsWrite, projectController.DeleteProject)
+			projects.GET("/:id/compositions", compositionsRead, compositionController.ListProjectCompositions)
+			projects.POST("/:id/compositions", compositionsWrite, compositionController.GenerateComposition)
+		}
+
+		// Deleted clips and projects, restorable until purged
+		trash := api.Group("/trash")
+		{
+			trash.GET("/clips", clipsRead, trashController.ListClips)
+			trash.POST("/clips/:id/restore", clipsWrite, trashController.RestoreClip)
+			trash.GET("/projects", compositionsRead, trashController.ListProjects)
+			trash.POST("/projects/:id/restore", compositionsWrite, trashController.RestoreProject)
+		}
+
+		// GraphQL over clips, projects and renders; resolvers check the
+		// permissions beyond clips:read
+		api.GET("/graphql", clipsRead, graphQLController.Query)
+		api.POST("/graphql", clipsRead, graphQLController.Query)
+
+		// Outbound webhooks for clip, render and composition events
+		webhooks := api.Group("/webhooks")
+		{
+			webhooks.POST("", clipsWrite, webhookController.CreateWebhook)
+			webhooks.GET("", clipsRead, webhookController.ListWebhooks)
+			webhooks.GET("/:id", clipsRead, webhookController.GetWebhook)
+			webhooks.PATCH("/:id", clipsWrite, webhookController.UpdateWebhook)
+			webhooks.DELETE("/:id", clipsWrite, webhookController.DeleteWebhook)
+			webhooks.POST("/:id/rotate-secret", clipsWrite, webhookController.RotateSecret)
+			webhooks.POST("/:id/test", clipsWrite, webhookController.TestWebhook)
+			webhooks.GET("/:id/deliveries", clipsRead, webhookController.ListDeliveries)
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", clipsWrite, webhookController.Redeliver)
+		}
+
+		templates := api.Group("/templates")
+		{
+			templates.POST("", compositionsWrite, templateController.CreateTemplate)
+			templates.GET("", compositionsRead, templateController.ListTemplates)
+			templates.GET("/favorites", compositionsRead, favoriteController.ListFavoriteTemplates)
+			templates.GET("/:id", compositionsRead, templateController.GetTemplate)
+			templates.PATCH("/:id", compositionsWrite, templateController.UpdateTemplate)
+			templates.POST("/:id/publish", compositionsWrite, templateController.PublishTemplate)
+			templates.POST("/:id/unpublish", compositionsWrite, templateController.UnpublishTemplate)
+			templates.DELETE("/:id", compositionsWrite, templateController.DeleteTemplate)
+			templates.POST("/:id/use", compositionsWrite, templateController.UseTemplate)
+			templates.GET("/:id/engagement", compositionsRead, favoriteController.GetTemplateEngagement)
+			templates.POST("/:id/favorite", compositionsRead, favoriteController.FavoriteTemplate)
+			templates.DELETE("/:id/favorite", compositionsRead, favoriteController.UnfavoriteTemplate)
+			templates.PUT("/:id/rating", compositionsRead, favoriteController.RateTemplate)
+			templates.DELETE("/:id/rating", compositionsRead, favoriteController.RemoveTemplateRating)
+		}
+
+		compositions := api.Group("/compositions")
+		{
+			compositions.GET("/:id", compositionsRead, compositionController.GetComposition)
+			compositions.POST("/:id/regenerate", compositionsWrite, compositionController.RegenerateComposition)
+			compositions.POST("/:id/promote", compositionsReview, compositionController.PromoteComposition)
+			compositions.POST("/:id/feedback", compositionsReview, compositionController.SubmitFeedback)
+			compositions.POST("/:id/share", compositionsWrite, shareLinkController.ShareComposition)
+			compositions.GET("/:id/export", compositionsRead, exportController.ExportComposition)
+		}
+
+		tags := api.Group("/tags")
+		{
+			tags.GET("", clipsRead, tagController.ListTags)
+			tags.GET("/autocomplete", clipsRead, tagController.Autocomplete)
+		}
+		// Tags are shared by every library, so changing them is for operators
+		adminTags := tags.Group("", middleware.PermissionRequired(models.PermissionAdminTags))
+		{
+			adminTags.PUT("/:id", tagController.RenameTag)
+			adminTags.POST("/:id/merge", tagController.MergeTag)
+			adminTags.POST("/reindex", tagController.Reindex)
+		}
+
+		renderTasks := api.Group("/render-tasks")
+		{
+			renderTasks.POST("", rendersCreate, renderQuota, renderTaskController.CreateRenderTask)
+			renderTasks.GET("", rendersRead, renderTaskController.ListRenderTasks)
+			renderTasks.POST("/estimate", rendersRead, renderTaskController.EstimateRender)
+			renderTasks.POST("/batch", rendersCreate, renderQuota, renderTaskController.CreateRenderGroup)
+			renderTasks.GET("/:id", rendersRead, renderTaskController.GetRenderTask)
+			renderTasks.POST("/:id/cancel", rendersCreate, renderTaskController.CancelRenderTask)
+			renderTasks.POST("/:id/retry", rendersCreate, renderQuota, renderTaskController.RetryRenderTask)
+			renderTasks.POST("/:id/share", rendersCreate, shareLinkController.ShareRender)
+			renderTasks.GET("/:id/comments", rendersRead, commentController.ListRenderComments)
+			renderTasks.POST("/:id/comments", rendersCreate, commentController.CreateRenderComment)
+		}
+
+		// Comments are checked against what they are on, which may be a clip
+		// or a render, so no one permission fits; they take a signed-in user
+		comments := api.Group("/comments")
+		comments.Use(middleware.SessionRequired())
+		{
+			comments.PATCH("/:id", commentController.UpdateComment)
+			comments.DELETE("/:id", commentController.DeleteComment)
+			comments.POST("/:id/resolve", commentController.ResolveComment)
+			comments.POST("/:id/unresolve", commentController.UnresolveComment)
+		}
+
+		// Share links are checked against their creator, whatever they share,
+		// so no one permission fits; they take a signed-in user
+		shareLinks := api.Group("/share-links")
+		shareLinks.Use(middleware.SessionRequired())
+		{
+			shareLinks.GET("", shareLinkController.ListShareLinks)
+			shareLinks.DELETE("/:id", shareLinkController.RevokeShareLink)
+		}
+
+		// Team workspaces; managing them takes a signed-in user
+		organizations := api.Group("/organizations")
+		organizations.Use(middleware.SessionRequired())
+		{
+			organizations.POST("", organizationController.CreateOrganization)
+			organizations.GET("", organizationController.ListOrganizations)
+			organizations.GET("/:id", organizationController.GetOrganization)
+			organizations.PATCH("/:id", organizationController.UpdateOrganization)
+			organizations.POST("/:id/members", organizationController.AddMember)
+			organizations.PATCH("/:id/members/:user_id", organizationController.UpdateMember)
+			organizations.DELETE("/:id/members/:user_id", organizationController.RemoveMember)
+		}
+
+		// API keys for automation; managing them takes a signed-in user
+		apiKeys := api.Group("/api-keys")
+		apiKeys.Use(middleware.SessionRequired())
+		{
+			apiKeys.POST("", apiKeyController.CreateAPIKey)
+			apiKeys.GET("", apiKeyController.ListAPIKeys)
+			apiKeys.POST("/:id/rotate", apiKeyController.RotateAPIKey)
+			apiKeys.DELETE("/:id", apiKeyController.RevokeAPIKey)
+		}
+
+		// Roles and their permissions
+		roles := api.Group("/roles")
+		roles.Use(middleware.PermissionRequired(models.PermissionAdminRoles))
+		{
+			roles.GET("", roleController.ListRoles)
+			roles.POST("", roleController.CreateRole)
+			roles.PATCH("/:name", roleController.UpdateRole)
+			roles.DELETE("/:name", roleController.DeleteRole)
+		}
+
+		api.PATCH("/users/:id/role", middleware.PermissionRequired(models.PermissionAdminUsers), roleController.UpdateUserRole)
+		api.PATCH("/users/:id/plan", middleware.PermissionRequired(models.PermissionAdminUsers), roleController.UpdateUserPlan)
+		api.GET("/users/:id/usage", middleware.PermissionRequired(models.PermissionAdminUsers), usageController.GetUserUsage)
+
+		// Quota usage of the user's plan
+		api.GET("/usage", middleware.SessionRequired(), usageController.GetUsage)
+
+		// Keys that sign access tokens, for operators
+		signingKeys := api.Group("/signing-keys")
+		signingKeys.Use(middleware.PermissionRequired(models.PermissionAdminKeys))
+		{
+			signingKeys.GET("", signingKeyController.ListKeys)
+			signingKeys.POST("/rotate", signingKeyController.RotateKey)
+			signingKeys.POST("/:kid/revoke", signingKeyController.RevokeKey)
+		}
+
+		// Who did what, for operators
+		auditLogs := api.Group("/audit-logs")
+		auditLogs.Use(middleware.PermissionRequired(models.PermissionAdminAudit))
+		{
+			auditLogs.GET("", auditController.ListAuditLogs)
+			auditLogs.GET("/export", auditController.ExportAuditLogs)
+		}
+
+		// Background task state, for operators
+		tasks := api.Group("/tasks")
+		tasks.Use(middleware.PermissionRequired(models.PermissionAdminTasks))
+		{
+			tasks.GET("", taskController.ListTasks)
+			tasks.GET("/:task_id", taskController.GetTask)
+		}
+
+		// Search index maintenance, for operators
+		search := api.Group("/search")
+		search.Use(middleware.PermissionRequired(models.PermissionAdminTasks))
+		{
+			search.POST("/reindex", searchController.Reindex)
+		}
+
+		// Re-analysis of clips analyzed by an older pipeline, for operators
+		analysisBackfills := api.Group("/analysis-backfills")
+		analysisBackfills.Use(middleware.PermissionRequired(models.PermissionAdminTasks))
+		{
+			analysisBackfills.POST("", analysisBackfillController.StartBackfill)
+			analysisBackfills.GET("", analysisBackfillController.ListBackfills)
+			analysisBackfills.GET("/:id", analysisBackfillController.GetBackfill)
+			analysisBackfills.POST("/:id/cancel", analysisBackfillController.CancelBackfill)
 		}
+
+		// Recurring jobs, for operators
+		schedules := api.Group("/schedules")
+		schedules.Use(middleware.PermissionRequired(models.PermissionAdminSchedules))
+		{
+			schedules.GET("", scheduleController.ListSchedules)
+			schedules.GET("/:name", scheduleController.GetSchedule)
+			schedules.PATCH("/:name", scheduleController.UpdateSchedule)
+			schedules.POST("/:name/run", scheduleController.RunSchedule)
+		}
+
+		// Review of clips flagged for unsafe content
+		moderation := api.Group("/moderation")
+		moderation.Use(middleware.PermissionRequired(models.PermissionAdminModeration))
+		{
+			moderation.GET("/clips", moderationController.ListQueue)
+			moderation.POST("/clips/:id/review", moderationController.ReviewClip)
+		}
+
+		// Worker settings per queue, for operators
+		queues := api.Group("/queues")
+		queues.Use(middleware.PermissionRequired(models.PermissionAdminQueues))
+		{
+			queues.GET("", queueController.ListQueueSettings)
+			queues.PATCH("/:name", queueController.UpdateQueueSettings)
+		}
+
+		// Maintenance and read-only mode, for operators
+		api.PUT("/maintenance", middleware.PermissionRequired(models.PermissionAdminMaintenance), maintenanceController.UpdateMaintenance)
 	}
 }
 
+// redisStatus reports "degraded" while the cache runs without Redis.
+func redisStatus() string {
+	switch {
+	case cache.Cache == nil:
+		return "disabled"
+	case cache.Cache.Available():
+		return "available"
+	default:
+		return "degraded"
+	}
+}
+
+// @Summary Health check
+// @Description Report the server and its dependencies as available or degraded
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /health [get]
 func healthCheck(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"status":    "healthy",
@@ -45,6 +537,7 @@ func healthCheck(c *gin.Context) {
 		},
 		"services": gin.H{
 			"ffmpeg": "available",
+			"redis":  redisStatus(),
 		},
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	studiov1 "creative-studio-server/proto/studio/v1"
+	"creative-studio-server/services"
+)
+
+// maxBatchClips bounds the clips looked up in one call
+const maxBatchClips = 100
+
+type clipServer struct {
+	studiov1.UnimplementedClipServiceServer
+	clipService *services.AtomicClipService
+}
+
+func newClipServer() *clipServer {
+	return &clipServer{
+		clipService: services.NewAtomicClipService(),
+	}
+}
+
+func (s *clipServer) GetClip(ctx context.Context, req *studiov1.GetClipRequest) (*studiov1.Clip, error) {
+	clipID, err := parseID(req.GetId(), "clip")
+	if err != nil {
+		return nil, err
+	}
+
+	clip, err := s.clipService.GetAtomicClipByID(clipID, userID(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return clipMessage(clip), nil
+}
+
+func (s *clipServer) BatchGetClips(ctx context.Context, req *studiov1.BatchGetClipsRequest) (*studiov1.BatchGetClipsResponse, error) {
+	if len(req.GetIds()) > maxBatchClips {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d clips can be looked up at once", maxBatchClips)
+	}
+
+	response := &studiov1.BatchGetClipsResponse{}
+	for _, id := range req.GetIds() {
+		clipID, err := parseID(id, "clip")
+		if err != nil {
+			return nil, err
+		}
+		// Clips are cached one by one, so most lookups skip the database
+		clip, err := s.clipService.GetAtomicClipByID(clipID, userID(ctx))
+		if err != nil {
+			if err.Error() == "atomic clip not found" {
+				continue
+			}
+			return nil, toStatus(err)
+		}
+		response.Clips = append(response.Clips, clipMessage(clip))
+	}
+
+	return response, nil
+}
+
+func clipMessage(clip *models.AtomicClip) *studiov1.Clip {
+	message := &studiov1.Clip{
+		Id:            uint64(clip.ID),
+		Title:         clip.Title,
+		Description:   clip.Description,
+		Duration:      clip.Duration,
+		Resolution:    clip.Resolution,
+		FrameRate:     clip.FrameRate,
+		Format:        clip.Format,
+		Thumbnail:     clip.Thumbnail,
+		Category:      clip.Category,
+		Tags:          clip.Tags,
+		Mood:          clip.Mood,
+		Style:         clip.Style,
+		Color:         clip.Color,
+		SceneType:     clip.SceneType,
+		Objects:       clip.Objects,
+		Actions:       clip.Actions,
+		Emotions:      clip.Emotions,
+		QualityScore:  clip.QualityScore,
+		UsageCount:    int32(clip.UsageCount),
+		FavoriteCount: int32(clip.FavoriteCount),
+		RatingAverage: clip.RatingAverage,
+		Status:        clip.Status,
+		UserId:        uint64(clip.UserID),
+		Visibility:    clip.Visibility,
+		Stock:         clip.Stock,
+		CreatedAt:     timestamppb.New(clip.CreatedAt),
+		UpdatedAt:     timestamppb.New(clip.UpdatedAt),
+	}
+	if clip.OrganizationID != nil {
+		organizationID := uint64(*clip.OrganizationID)
+		message.OrganizationId = &organizationID
+	}
+	if len(clip.Metadata) > 0 {
+		// Metadata comes from JSON, so it only fails on values JSON cannot hold
+		metadata, err := structpb.NewStruct(clip.Metadata)
+		if err != nil {
+			logger.Warnf("Failed to convert metadata of clip %d: %v", clip.ID, err)
+		} else {
+			message.Metadata = metadata
+		}
+	}
+	return message
+}
@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"creative-studio-server/models"
+	studiov1 "creative-studio-server/proto/studio/v1"
+	"creative-studio-server/services"
+)
+
+type compositionServer struct {
+	studiov1.UnimplementedCompositionServiceServer
+	compositionService *services.CompositionService
+}
+
+func newCompositionServer() *compositionServer {
+	return &compositionServer{
+		compositionService: services.NewCompositionService(),
+	}
+}
+
+func (s *compositionServer) GenerateComposition(ctx context.Context, req *studiov1.GenerateCompositionRequest) (*studiov1.Composition, error) {
+	projectID, err := parseID(req.GetProjectId(), "project")
+	if err != nil {
+		return nil, err
+	}
+
+	createReq := models.CompositionCreateRequest{
+		Requirements: req.GetRequirements().AsMap(),
+		Variants:     int(req.GetVariants()),
+		Algorithms:   req.GetAlgorithms(),
+		IncludeStock: req.GetIncludeStock(),
+	}
+	if req.CollectionId != nil {
+		collectionID, err := parseID(req.GetCollectionId(), "collection")
+		if err != nil {
+			return nil, err
+		}
+		createReq.CollectionID = &collectionID
+	}
+	if len(createReq.Requirements) == 0 {
+		createReq.Requirements = nil
+	}
+	if err := validate(&createReq); err != nil {
+		return nil, err
+	}
+
+	composition, err := s.compositionService.GenerateComposition(projectID, userID(ctx), requestID(ctx), &createReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return compositionMessage(composition), nil
+}
+
+func (s *compositionServer) GetComposition(ctx context.Context, req *studiov1.GetCompositionRequest) (*studiov1.Composition, error) {
+	compositionID, err := parseID(req.GetId(), "composition")
+	if err != nil {
+		return nil, err
+	}
+
+	composition, err := s.compositionService.GetCompositionByID(compositionID, userID(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return compositionMessage(composition), nil
+}
+
+func compositionMessage(composition *models.Composition) *studiov1.Composition {
+	return &studiov1.Composition{
+		Id:            uint64(composition.ID),
+		ProjectId:     uint64(composition.ProjectID),
+		Status:        composition.Status,
+		Algorithm:     composition.Algorithm,
+		Variant:       int32(composition.Variant),
+		TotalDuration: composition.TotalDuration,
+		QualityScore:  composition.QualityScore,
+		CohesionScore: composition.CohesionScore,
+		Timeline:      string(composition.Timeline),
+		ErrorMessage:  composition.ErrorMessage,
+		TraceId:       composition.TraceID,
+		CreatedAt:     timestamppb.New(composition.CreatedAt),
+		UpdatedAt:     timestamppb.New(composition.UpdatedAt),
+	}
+}
@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"creative-studio-server/models"
+	studiov1 "creative-studio-server/proto/studio/v1"
+	"creative-studio-server/services"
+)
+
+type renderServer struct {
+	studiov1.UnimplementedRenderServiceServer
+	renderTaskService *services.RenderTaskService
+}
+
+func newRenderServer() *renderServer {
+	return &renderServer{
+		renderTaskService: services.NewRenderTaskService(),
+	}
+}
+
+func (s *renderServer) SubmitRender(ctx context.Context, req *studiov1.SubmitRenderRequest) (*studiov1.RenderTask, error) {
+	projectID, err := parseID(req.GetProjectId(), "project")
+	if err != nil {
+		return nil, err
+	}
+
+	createReq := models.RenderTaskCreateRequest{
+		ProjectID:    projectID,
+		Mode:         req.GetMode(),
+		OutputFormat: req.GetOutputFormat(),
+		Quality:      req.GetQuality(),
+		Resolution:   req.GetResolution(),
+		FrameRate:    req.GetFrameRate(),
+		AspectRatio:  req.GetAspectRatio(),
+		Priority:     int(req.GetPriority()),
+	}
+	if err := validate(&createReq); err != nil {
+		return nil, err
+	}
+
+	task, err := s.renderTaskService.CreateRenderTask(userID(ctx), requestID(ctx), &createReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return s.renderTaskMessage(task), nil
+}
+
+func (s *renderServer) GetRender(ctx context.Context, req *studiov1.GetRenderRequest) (*studiov1.RenderTask, error) {
+	taskID, err := parseID(req.GetId(), "render task")
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := s.renderTaskService.GetRenderTaskByID(taskID, userID(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return s.renderTaskMessage(task), nil
+}
+
+func (s *renderServer) renderTaskMessage(task *models.RenderTask) *studiov1.RenderTask {
+	message := &studiov1.RenderTask{
+		Id:           uint64(task.ID),
+		TaskId:       task.TaskID,
+		ProjectId:    uint64(task.ProjectID),
+		GroupId:      task.GroupID,
+		Status:       task.Status,
+		Progress:     int32(task.Progress),
+		Mode:         task.Mode,
+		OutputFormat: task.OutputFormat,
+		Quality:      task.Quality,
+		Resolution:   task.Resolution,
+		FrameRate:    task.FrameRate,
+		AspectRatio:  task.AspectRatio,
+		FileSize:     task.FileSize,
+		Duration:     task.Duration,
+		ErrorMessage: task.ErrorMessage,
+		TraceId:      task.TraceID,
+		CreatedAt:    timestamppb.New(task.CreatedAt),
+	}
+	if eta := s.renderTaskService.RenderTaskStatus(*task).ETA; eta != nil {
+		seconds := int32(*eta)
+		message.EtaSeconds = &seconds
+	}
+	if task.StartedAt != nil {
+		message.StartedAt = timestamppb.New(*task.StartedAt)
+	}
+	if task.CompletedAt != nil {
+		message.CompletedAt = timestamppb.New(*task.CompletedAt)
+	}
+	return message
+}
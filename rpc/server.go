@@ -0,0 +1,195 @@
+// Package rpc serves the internal gRPC API defined in proto/studio/v1 to
+// other services of the platform. It calls the same services as the HTTP
+// controllers, behind the same API keys, permissions and key scopes.
+package rpc
+
+import (
+	"context"
+	"math"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/tracing"
+	"creative-studio-server/pkg/validation"
+	studiov1 "creative-studio-server/proto/studio/v1"
+	"creative-studio-server/services"
+)
+
+// Metadata keys of a call; gRPC lowercases them
+const (
+	APIKeyMetadata    = "x-api-key"
+	RequestIDMetadata = "x-request-id"
+)
+
+// methodPermissions maps every method to the permission it needs, like the
+// routes of the HTTP API. Methods missing here are refused.
+var methodPermissions = map[string]string{
+	studiov1.ClipService_GetClip_FullMethodName:                    models.PermissionClipsRead,
+	studiov1.ClipService_BatchGetClips_FullMethodName:              models.PermissionClipsRead,
+	studiov1.CompositionService_GenerateComposition_FullMethodName: models.PermissionCompositionsWrite,
+	studiov1.CompositionService_GetComposition_FullMethodName:      models.PermissionCompositionsRead,
+	studiov1.RenderService_SubmitRender_FullMethodName:             models.PermissionRendersCreate,
+	studiov1.RenderService_GetRender_FullMethodName:                models.PermissionRendersRead,
+}
+
+type contextKey int
+
+const (
+	userIDKey contextKey = iota
+	requestIDKey
+)
+
+// NewServer returns a gRPC server with every service registered.
+func NewServer() *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		recoverer,
+		requestLogger,
+		authenticator(services.NewAPIKeyService(), services.NewRoleService()),
+	))
+
+	studiov1.RegisterClipServiceServer(server, newClipServer())
+	studiov1.RegisterCompositionServiceServer(server, newCompositionServer())
+	studiov1.RegisterRenderServiceServer(server, newRenderServer())
+	return server
+}
+
+func recoverer(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logger.WithFields(logrus.Fields{
+				"error":  recovered,
+				"method": info.FullMethod,
+				"stack":  string(debug.Stack()),
+			}).Error("Panic recovered")
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// requestLogger tags the call with a correlation ID, reusing the caller's
+// when valid, and logs its outcome.
+func requestLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	startedAt := time.Now()
+
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(RequestIDMetadata); len(ids) > 0 {
+			requestID = ids[0]
+		}
+	}
+	if !tracing.ValidID(requestID) {
+		requestID = tracing.NewID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, requestID))
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
+
+	resp, err := handler(ctx, req)
+
+	fields := logrus.Fields{
+		"request_id": requestID,
+		"method":     info.FullMethod,
+		"code":       status.Code(err).String(),
+		"latency":    time.Since(startedAt),
+	}
+	if err != nil {
+		fields["error"] = status.Convert(err).Message()
+	}
+	logger.WithFields(fields).Info("gRPC Request")
+	return resp, err
+}
+
+// authenticator lets calls through with an API key whose owner's role, and
+// the key itself, grant the method's permission.
+func authenticator(apiKeyService *services.APIKeyService, roleService *services.RoleService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		permission, ok := methodPermissions[info.FullMethod]
+		if !ok {
+			return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+		}
+
+		var key string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if keys := md.Get(APIKeyMetadata); len(keys) > 0 {
+				key = keys[0]
+			}
+		}
+		if key == "" {
+			return nil, status.Error(codes.Unauthenticated, "API key is required")
+		}
+
+		apiKey, user, err := apiKeyService.Authenticate(key)
+		if err != nil {
+			if err.Error() == "failed to authenticate API key" {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		allowed, err := roleService.HasPermission(user.Role, permission)
+		if err != nil {
+			logger.Errorf("Failed to check permission %s: %v", permission, err)
+			return nil, status.Error(codes.Internal, "failed to check permissions")
+		}
+		if !allowed {
+			return nil, status.Error(codes.PermissionDenied, "insufficient permissions")
+		}
+		if !apiKey.HasScope(permission) {
+			return nil, status.Error(codes.PermissionDenied, "API key lacks the required scope")
+		}
+
+		return handler(context.WithValue(ctx, userIDKey, user.ID), req)
+	}
+}
+
+func userID(ctx context.Context) uint {
+	id, _ := ctx.Value(userIDKey).(uint)
+	return id
+}
+
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// parseID checks an ID of the request fits the database's.
+func parseID(id uint64, what string) (uint, error) {
+	if id == 0 || id > math.MaxUint32 {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid %s ID", what)
+	}
+	return uint(id), nil
+}
+
+// validate applies the binding rules the HTTP API checks requests with.
+func validate(req interface{}) error {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid request data: %s", validation.Message(err, validation.DefaultLocale))
+	}
+	return nil
+}
+
+// toStatus maps a service error to the status of the call, as the HTTP
+// controllers map them to response codes.
+func toStatus(err error) error {
+	message := err.Error()
+	switch {
+	case strings.HasSuffix(message, "not found"):
+		return status.Error(codes.NotFound, message)
+	case strings.HasPrefix(message, "invalid"):
+		return status.Error(codes.InvalidArgument, message)
+	case strings.HasPrefix(message, "insufficient"):
+		return status.Error(codes.PermissionDenied, message)
+	case message == "collection is empty":
+		return status.Error(codes.FailedPrecondition, message)
+	}
+	return status.Error(codes.Internal, message)
+}
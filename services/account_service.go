@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/auth"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/mailer"
+)
+
+const (
+	// accountEmailInterval limits how often an account email of one kind
+	// is sent to the same user
+	accountEmailInterval = time.Minute
+	// accountEmailTimeout bounds the delivery of one account email
+	accountEmailTimeout = 30 * time.Second
+)
+
+// AccountService handles email verification and password resets.
+type AccountService struct {
+	db           *gorm.DB
+	tokenService *TokenService
+}
+
+func NewAccountService() *AccountService {
+	return &AccountService{
+		db:           database.GetDB(),
+		tokenService: NewTokenService(),
+	}
+}
+
+// SendVerificationEmail emails the user a link to verify their address.
+func (s *AccountService) SendVerificationEmail(user *models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return errors.New("email is already verified")
+	}
+
+	token, err := s.issueToken(user.ID, models.AccountTokenEmailVerification, config.AppConfig.Account.VerificationTTL)
+	if err != nil || token == "" {
+		return err
+	}
+
+	s.sendEmail(&mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening this link:\n\n%s\n\nThe link expires in %s.\n",
+			user.Username, accountLink("/verify-email", token), config.AppConfig.Account.VerificationTTL),
+	})
+	return nil
+}
+
+// VerifyEmail marks the address of the token's user as verified.
+func (s *AccountService) VerifyEmail(token string) (*models.User, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		accountToken, err := s.consumeToken(tx, token, models.AccountTokenEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, accountToken.UserID).Error; err != nil {
+			return err
+		}
+		if user.EmailVerifiedAt == nil {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+			return tx.Model(&user).Update("email_verified_at", now).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, accountTokenError(err, "failed to verify email")
+	}
+
+	logger.Infof("User %d verified their email address", user.ID)
+	return &user, nil
+}
+
+// RequestPasswordReset emails a reset link when an active account has the
+// address. Unknown addresses succeed silently, so the endpoint does not
+// reveal which addresses have accounts.
+func (s *AccountService) RequestPasswordReset(email string) error {
+	var user models.User
+	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		logger.Errorf("Failed to find user: %v", err)
+		return errors.New("failed to request password reset")
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	token, err := s.issueToken(user.ID, models.AccountTokenPasswordReset, config.AppConfig.Account.PasswordResetTTL)
+	if err != nil || token == "" {
+		return err
+	}
+
+	s.sendEmail(&mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nChoose a new password by opening this link:\n\n%s\n\nThe link expires in %s. If you did not ask to reset your password, ignore this email.\n",
+			user.Username, accountLink("/reset-password", token), config.AppConfig.Account.PasswordResetTTL),
+	})
+	return nil
+}
+
+// ResetPassword sets a new password and signs the user out everywhere.
+// Receiving the email also proves the address, so it is marked verified.
+func (s *AccountService) ResetPassword(token, newPassword string) (*models.User, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		accountToken, err := s.consumeToken(tx, token, models.AccountTokenPasswordReset)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, accountToken.UserID).Error; err != nil {
+			return err
+		}
+		user.Password = newPassword
+		if err := user.HashPassword(); err != nil {
+			return err
+		}
+		if user.EmailVerifiedAt == nil {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+		}
+		return tx.Save(&user).Error
+	})
+	if err != nil {
+		return nil, accountTokenError(err, "failed to reset password")
+	}
+
+	if err := s.tokenService.RevokeAllForUser(user.ID); err != nil {
+		logger.Warnf("Failed to revoke sessions after password reset: %v", err)
+	}
+
+	logger.Infof("User %d reset their password", user.ID)
+	return &user, nil
+}
+
+// PruneExpiredAccountTokens deletes account tokens that expired before the
+// given time.
+func (s *AccountService) PruneExpiredAccountTokens(before time.Time) (int64, error) {
+	result := s.db.Where("expires_at < ?", before).Delete(&models.AccountToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune account tokens: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// issueToken replaces the user's unused tokens of the purpose with a new
+// one. It returns an empty token, without error, when one was issued too
+// recently to send another email.
+func (s *AccountService) issueToken(userID uint, purpose string, ttl time.Duration) (string, error) {
+	var recent int64
+	if err := s.db.Model(&models.AccountToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userID, purpose, time.Now().Add(-accountEmailInterval)).
+		Count(&recent).Error; err != nil {
+		logger.Errorf("Failed to check recent account tokens: %v", err)
+		return "", errors.New("failed to send email")
+	}
+	if recent > 0 {
+		logger.Infof("Skipping %s email to user %d: one was sent less than %s ago", purpose, userID, accountEmailInterval)
+		return "", nil
+	}
+
+	token, hash, err := auth.NewAccountToken()
+	if err != nil {
+		logger.Errorf("Failed to generate account token: %v", err)
+		return "", errors.New("failed to send email")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Delete(&models.AccountToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.AccountToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to store account token: %v", err)
+		return "", errors.New("failed to send email")
+	}
+
+	return token, nil
+}
+
+// consumeToken marks a valid token used. The update is conditional so
+// concurrent requests cannot both use one token.
+func (s *AccountService) consumeToken(tx *gorm.DB, token, purpose string) (*models.AccountToken, error) {
+	var accountToken models.AccountToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", auth.HashAccountToken(token), purpose).
+		First(&accountToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errInvalidAccountToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if accountToken.UsedAt != nil || now.After(accountToken.ExpiresAt) {
+		return nil, errInvalidAccountToken
+	}
+
+	result := tx.Model(&models.AccountToken{}).
+		Where("id = ? AND used_at IS NULL", accountToken.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errInvalidAccountToken
+	}
+
+	return &accountToken, nil
+}
+
+var errInvalidAccountToken = errors.New("invalid or expired token")
+
+func accountTokenError(err error, message string) error {
+	if errors.Is(err, errInvalidAccountToken) {
+		return err
+	}
+	logger.Errorf("%s: %v", message, err)
+	return errors.New(message)
+}
+
+// sendEmail delivers in the background, so a slow mail server neither
+// delays responses nor hints at whether an email was sent.
+func (s *AccountService) sendEmail(msg *mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), accountEmailTimeout)
+		defer cancel()
+		if err := mailer.Send(ctx, msg); err != nil {
+			logger.Errorf("Failed to send email %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
+
+func accountLink(path, token string) string {
+	return config.AppConfig.Account.AppURL + path + "?token=" + url.QueryEscape(token)
+}
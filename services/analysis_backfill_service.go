@@ -0,0 +1,293 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/queue"
+	"creative-studio-server/pkg/realtime"
+)
+
+const (
+	// defaultBackfillBatchSize is how many clips a backfill queues per
+	// scheduler run when the request names no batch size
+	defaultBackfillBatchSize = 100
+	// maxListedBackfills bounds the backfill history returned
+	maxListedBackfills = 50
+)
+
+// AnalysisBackfillService re-queues clips analyzed by an older analysis
+// pipeline. Backfills advance one batch per run of the
+// advance-analysis-backfills job, so the analysis queue is never flooded;
+// its schedule and the batch size set the pace.
+type AnalysisBackfillService struct {
+	db *gorm.DB
+}
+
+func NewAnalysisBackfillService() *AnalysisBackfillService {
+	return &AnalysisBackfillService{
+		db: database.GetDB(),
+	}
+}
+
+// clipVersion is an analyzed clip with the pipeline version of its analysis
+type clipVersion struct {
+	ID              uint
+	AnalysisVersion string
+}
+
+// StartBackfill starts re-analyzing the clips whose analysis is older than
+// the current pipeline version and queues the first batch. Clips never
+// analyzed are left out; their import queues their analysis.
+func (s *AnalysisBackfillService) StartBackfill(userID uint, traceID string, req *models.AnalysisBackfillRequest) (*models.AnalysisBackfill, error) {
+	var running int64
+	if err := s.db.Model(&models.AnalysisBackfill{}).Where("status = ?", models.AnalysisBackfillRunning).Count(&running).Error; err != nil {
+		logger.Errorf("Failed to check running analysis backfills: %v", err)
+		return nil, errors.New("failed to start analysis backfill")
+	}
+	if running > 0 {
+		return nil, errors.New("an analysis backfill is already running")
+	}
+
+	backfill := models.AnalysisBackfill{
+		Version:      models.DefaultAnalysisVersion,
+		AnalysisType: req.AnalysisType,
+		BatchSize:    req.BatchSize,
+		Status:       models.AnalysisBackfillRunning,
+		CreatedBy:    userID,
+	}
+	if backfill.AnalysisType == "" {
+		backfill.AnalysisType = models.AnalysisTypeFull
+	}
+	if backfill.BatchSize == 0 {
+		backfill.BatchSize = defaultBackfillBatchSize
+	}
+
+	total, err := s.countOutdated(backfill.Version)
+	if err != nil {
+		logger.Errorf("Failed to count clips to backfill: %v", err)
+		return nil, errors.New("failed to start analysis backfill")
+	}
+	backfill.Total = total
+	if total == 0 {
+		now := time.Now()
+		backfill.Status = models.AnalysisBackfillCompleted
+		backfill.CompletedAt = &now
+	}
+
+	if err := s.db.Create(&backfill).Error; err != nil {
+		logger.Errorf("Failed to create analysis backfill: %v", err)
+		return nil, errors.New("failed to start analysis backfill")
+	}
+	logger.Infof("Analysis backfill %d started: %d clips older than version %s", backfill.ID, total, backfill.Version)
+
+	// The first batch goes right away rather than at the next run
+	if backfill.Status == models.AnalysisBackfillRunning {
+		if _, err := s.advance(&backfill, traceID); err != nil {
+			logger.Warnf("Failed to queue the first batch of analysis backfill %d: %v", backfill.ID, err)
+		}
+	}
+
+	return &backfill, nil
+}
+
+// ListBackfills returns the most recent backfills, newest first.
+func (s *AnalysisBackfillService) ListBackfills() ([]models.AnalysisBackfill, error) {
+	var backfills []models.AnalysisBackfill
+	if err := s.db.Order("id DESC").Limit(maxListedBackfills).Find(&backfills).Error; err != nil {
+		return nil, fmt.Errorf("failed to get analysis backfills: %w", err)
+	}
+
+	return backfills, nil
+}
+
+func (s *AnalysisBackfillService) GetBackfill(id uint) (*models.AnalysisBackfill, error) {
+	var backfill models.AnalysisBackfill
+	if err := s.db.First(&backfill, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("analysis backfill not found")
+		}
+		logger.Errorf("Failed to get analysis backfill: %v", err)
+		return nil, errors.New("failed to get analysis backfill")
+	}
+
+	return &backfill, nil
+}
+
+// CancelBackfill stops queuing clips. Clips already queued are still
+// analyzed.
+func (s *AnalysisBackfillService) CancelBackfill(id uint) (*models.AnalysisBackfill, error) {
+	backfill, err := s.GetBackfill(id)
+	if err != nil {
+		return nil, err
+	}
+	if backfill.Status != models.AnalysisBackfillRunning {
+		return nil, errors.New("analysis backfill is not running")
+	}
+
+	now := time.Now()
+	if err := s.db.Model(backfill).Updates(map[string]interface{}{
+		"status":       models.AnalysisBackfillCancelled,
+		"completed_at": &now,
+	}).Error; err != nil {
+		logger.Errorf("Failed to cancel analysis backfill: %v", err)
+		return nil, errors.New("failed to cancel analysis backfill")
+	}
+	backfill.Status = models.AnalysisBackfillCancelled
+	backfill.CompletedAt = &now
+
+	logger.Infof("Analysis backfill %d cancelled after queuing %d of %d clips", backfill.ID, backfill.Queued, backfill.Total)
+	return backfill, nil
+}
+
+// AdvanceBackfills queues the next batch of every running backfill and
+// returns how many clips were queued.
+func (s *AnalysisBackfillService) AdvanceBackfills() (int, error) {
+	var backfills []models.AnalysisBackfill
+	if err := s.db.Where("status = ?", models.AnalysisBackfillRunning).Order("id").Find(&backfills).Error; err != nil {
+		return 0, fmt.Errorf("failed to get running analysis backfills: %w", err)
+	}
+
+	queued := 0
+	var errs []error
+	for i := range backfills {
+		n, err := s.advance(&backfills[i], "")
+		queued += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("analysis backfill %d: %w", backfills[i].ID, err))
+		}
+	}
+	return queued, errors.Join(errs...)
+}
+
+// advance queues the next batch of outdated clips after the backfill's
+// cursor, and completes the backfill once none are left. A batch that
+// fails partway keeps what was queued; the rest is retried next run.
+func (s *AnalysisBackfillService) advance(backfill *models.AnalysisBackfill, traceID string) (int, error) {
+	clipIDs, cursor, done, err := s.nextBatch(backfill)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	var publishErr error
+	for _, clipID := range clipIDs {
+		if publishErr = queue.PublishAnalysisTask(clipID, backfill.AnalysisType, backfill.Version, traceID); publishErr != nil {
+			cursor = clipID - 1
+			done = false
+			break
+		}
+		queued++
+	}
+
+	progress := *backfill
+	progress.Queued += int64(queued)
+	progress.LastClipID = cursor
+	progress.LastError = ""
+	if publishErr != nil {
+		progress.LastError = publishErr.Error()
+	}
+	if done {
+		now := time.Now()
+		progress.Status = models.AnalysisBackfillCompleted
+		progress.CompletedAt = &now
+	}
+	// A backfill cancelled meanwhile stays cancelled
+	result := s.db.Model(&models.AnalysisBackfill{}).
+		Where("id = ? AND status = ?", backfill.ID, models.AnalysisBackfillRunning).
+		Updates(map[string]interface{}{
+			"queued":       progress.Queued,
+			"last_clip_id": progress.LastClipID,
+			"last_error":   progress.LastError,
+			"status":       progress.Status,
+			"completed_at": progress.CompletedAt,
+		})
+	if result.Error != nil {
+		return queued, fmt.Errorf("failed to record progress: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return queued, publishErr
+	}
+	*backfill = progress
+
+	logger.Infof("Analysis backfill %d queued %d of %d clips", backfill.ID, backfill.Queued, backfill.Total)
+	realtime.Publish(backfill.CreatedBy, realtime.EventBackfillProgress, map[string]interface{}{
+		"backfill_id": backfill.ID,
+		"status":      backfill.Status,
+		"queued":      backfill.Queued,
+		"total":       backfill.Total,
+	})
+	if publishErr != nil {
+		return queued, fmt.Errorf("failed to queue analysis of clips: %w", publishErr)
+	}
+	return queued, nil
+}
+
+// nextBatch finds up to a batch of clips after the backfill's cursor whose
+// analysis is older than its version. It returns the cursor to resume from
+// and whether no outdated clips are left after it.
+func (s *AnalysisBackfillService) nextBatch(backfill *models.AnalysisBackfill) ([]uint, uint, bool, error) {
+	clipIDs := make([]uint, 0, backfill.BatchSize)
+	cursor := backfill.LastClipID
+	for {
+		// Analyses newer than the backfill's version are filtered out
+		// here rather than in SQL, which cannot order dotted versions
+		var page []clipVersion
+		if err := s.analyzedClips().
+			Where("atomic_clips.id > ?", cursor).
+			Where("(video_analyses.analysis_version IS NULL OR video_analyses.analysis_version <> ?)", backfill.Version).
+			Order("atomic_clips.id").
+			Limit(backfill.BatchSize).
+			Scan(&page).Error; err != nil {
+			return nil, cursor, false, fmt.Errorf("failed to find clips to backfill: %w", err)
+		}
+
+		for _, clip := range page {
+			cursor = clip.ID
+			if models.CompareAnalysisVersions(clip.AnalysisVersion, backfill.Version) >= 0 {
+				continue
+			}
+			clipIDs = append(clipIDs, clip.ID)
+			if len(clipIDs) == backfill.BatchSize {
+				return clipIDs, cursor, false, nil
+			}
+		}
+		if len(page) < backfill.BatchSize {
+			return clipIDs, cursor, true, nil
+		}
+	}
+}
+
+// countOutdated counts the clips whose analysis is older than version.
+func (s *AnalysisBackfillService) countOutdated(version string) (int64, error) {
+	var counts []struct {
+		AnalysisVersion string
+		Count           int64
+	}
+	if err := s.analyzedClips().
+		Select("COALESCE(video_analyses.analysis_version, '') AS analysis_version, COUNT(*) AS count").
+		Group("video_analyses.analysis_version").
+		Scan(&counts).Error; err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, count := range counts {
+		if models.CompareAnalysisVersions(count.AnalysisVersion, version) < 0 {
+			total += count.Count
+		}
+	}
+	return total, nil
+}
+
+// analyzedClips selects the clips that have an analysis with its version.
+func (s *AnalysisBackfillService) analyzedClips() *gorm.DB {
+	return s.db.Model(&models.AtomicClip{}).
+		Select("atomic_clips.id, COALESCE(video_analyses.analysis_version, '') AS analysis_version").
+		Joins("JOIN video_analyses ON video_analyses.atomic_clip_id = atomic_clips.id")
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/auth"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+// apiKeyUsageInterval limits how often last_used_at is written for a key
+const apiKeyUsageInterval = time.Minute
+
+type APIKeyService struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyService() *APIKeyService {
+	return &APIKeyService{
+		db: database.GetDB(),
+	}
+}
+
+// CreateAPIKey creates a key for the user and returns it with the key
+// itself, which cannot be retrieved later. Keys cannot be granted more
+// than the user's role allows.
+func (s *APIKeyService) CreateAPIKey(userID uint, role string, req *models.APIKeyCreateRequest) (*models.APIKey, string, error) {
+	if err := s.validateScopes(role, req.Scopes); err != nil {
+		return nil, "", err
+	}
+
+	key, hash, err := auth.NewAPIKey()
+	if err != nil {
+		logger.Errorf("Failed to generate API key: %v", err)
+		return nil, "", errors.New("failed to create API key")
+	}
+
+	apiKey := &models.APIKey{
+		UserID:  userID,
+		Name:    req.Name,
+		Prefix:  key[:auth.APIKeyPrefixLength],
+		KeyHash: hash,
+		Scopes:  req.Scopes,
+	}
+	if req.ExpiresInDays != nil {
+		expiresAt := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err := s.db.Create(apiKey).Error; err != nil {
+		logger.Errorf("Failed to create API key: %v", err)
+		return nil, "", errors.New("failed to create API key")
+	}
+
+	logger.Infof("API key %d created for user %d", apiKey.ID, userID)
+	return apiKey, key, nil
+}
+
+func (s *APIKeyService) validateScopes(role string, scopes []string) error {
+	roleService := NewRoleService()
+	for _, scope := range scopes {
+		if !containsString(models.APIKeyScopes, scope) {
+			return errors.New("invalid API key scope")
+		}
+		allowed, err := roleService.HasPermission(role, scope)
+		if err != nil {
+			logger.Errorf("Failed to check permission: %v", err)
+			return errors.New("failed to create API key")
+		}
+		if !allowed {
+			return errors.New("API key scope exceeds your role")
+		}
+	}
+	return nil
+}
+
+func (s *APIKeyService) ListAPIKeys(userID uint) ([]models.APIKey, error) {
+	var apiKeys []models.APIKey
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&apiKeys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+
+	return apiKeys, nil
+}
+
+func (s *APIKeyService) getAPIKey(keyID, userID uint) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	if err := s.db.Where("id = ? AND user_id = ?", keyID, userID).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("API key not found")
+		}
+		logger.Errorf("Failed to get API key: %v", err)
+		return nil, errors.New("failed to get API key")
+	}
+
+	return &apiKey, nil
+}
+
+// RotateAPIKey replaces the key of an API key, keeping its name and scopes.
+// The previous key stops working immediately.
+func (s *APIKeyService) RotateAPIKey(keyID, userID uint) (*models.APIKey, string, error) {
+	apiKey, err := s.getAPIKey(keyID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, "", errors.New("API key is revoked")
+	}
+
+	key, hash, err := auth.NewAPIKey()
+	if err != nil {
+		logger.Errorf("Failed to generate API key: %v", err)
+		return nil, "", errors.New("failed to rotate API key")
+	}
+
+	apiKey.Prefix = key[:auth.APIKeyPrefixLength]
+	apiKey.KeyHash = hash
+	if err := s.db.Save(apiKey).Error; err != nil {
+		logger.Errorf("Failed to rotate API key: %v", err)
+		return nil, "", errors.New("failed to rotate API key")
+	}
+
+	logger.Infof("API key %d rotated", apiKey.ID)
+	return apiKey, key, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(keyID, userID uint) (*models.APIKey, error) {
+	apiKey, err := s.getAPIKey(keyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		return apiKey, nil
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	if err := s.db.Model(apiKey).Update("revoked_at", now).Error; err != nil {
+		logger.Errorf("Failed to revoke API key: %v", err)
+		return nil, errors.New("failed to revoke API key")
+	}
+
+	logger.Infof("API key %d revoked", apiKey.ID)
+	return apiKey, nil
+}
+
+// Authenticate returns the key and its owner for a key presented in a
+// request.
+func (s *APIKeyService) Authenticate(key string) (*models.APIKey, *models.User, error) {
+	var apiKey models.APIKey
+	if err := s.db.Where("key_hash = ?", auth.HashAPIKey(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("invalid API key")
+		}
+		logger.Errorf("Failed to get API key: %v", err)
+		return nil, nil, errors.New("failed to authenticate API key")
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt)) {
+		return nil, nil, errors.New("invalid API key")
+	}
+
+	var user models.User
+	if err := s.db.First(&user, apiKey.UserID).Error; err != nil || !user.IsActive {
+		return nil, nil, errors.New("invalid API key")
+	}
+
+	// Record usage at most once per interval rather than on every request
+	if err := s.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", apiKey.ID, now.Add(-apiKeyUsageInterval)).
+		Update("last_used_at", now).Error; err != nil {
+		logger.Warnf("Failed to record use of API key %d: %v", apiKey.ID, err)
+	}
+
+	return &apiKey, &user, nil
+}
@@ -3,12 +3,22 @@ package services
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"creative-studio-server/config"
 	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
 	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/duplicates"
+	"creative-studio-server/pkg/embedding"
 	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/queue"
+	"creative-studio-server/pkg/search"
+	"creative-studio-server/pkg/suggestions"
+	"creative-studio-server/pkg/transcription"
 )
 
 type AtomicClipService struct {
@@ -21,18 +31,32 @@ func NewAtomicClipService() *AtomicClipService {
 	}
 }
 
-func (s *AtomicClipService) CreateAtomicClip(userID uint, req *models.AtomicClipCreateRequest, filePath string, fileInfo map[string]interface{}) (*models.AtomicClip, error) {
+// CreateAtomicClip adds a clip to the user's personal library, or to the
+// organization's shared library when organizationID is set.
+func (s *AtomicClipService) CreateAtomicClip(userID uint, organizationID *uint, req *models.AtomicClipCreateRequest, filePath string, fileInfo map[string]interface{}) (*models.AtomicClip, error) {
+	if allowed, err := canAccess(s.db, userID, userID, organizationID, true); err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to create atomic clip")
+	} else if !allowed {
+		return nil, errors.New("insufficient organization role")
+	}
+
 	clip := &models.AtomicClip{
-		Title:       req.Title,
-		Description: req.Description,
-		FilePath:    filePath,
-		Category:    req.Category,
-		Tags:        req.Tags,
-		Mood:        req.Mood,
-		Style:       req.Style,
-		Color:       req.Color,
-		UserID:      userID,
-		Status:      "active",
+		Title:          req.Title,
+		Description:    req.Description,
+		FilePath:       filePath,
+		Category:       req.Category,
+		Tags:           models.NormalizeTags(req.Tags),
+		Mood:           req.Mood,
+		Style:          req.Style,
+		Color:          req.Color,
+		UserID:         userID,
+		Status:         "active",
+		OrganizationID: organizationID,
+		Visibility:     req.Visibility,
+	}
+	if clip.Visibility == "" {
+		clip.Visibility = models.ClipVisibilityPrivate
 	}
 
 	// Set file information from analysis
@@ -60,11 +84,19 @@ func (s *AtomicClipService) CreateAtomicClip(userID uint, req *models.AtomicClip
 	if thumbnail, ok := fileInfo["thumbnail"].(string); ok {
 		clip.Thumbnail = thumbnail
 	}
+	clip.QualityScore = clip.TechnicalQuality()
 
 	if err := s.db.Create(clip).Error; err != nil {
 		logger.Errorf("Failed to create atomic clip: %v", err)
 		return nil, errors.New("failed to create atomic clip")
 	}
+	recordUsage(s.db, userID, models.UsageUploads, 1)
+
+	cache.InvalidateClipSearches()
+	indexClips(clip.ID)
+	if err := syncTags(s.db, clip.Tags...); err != nil {
+		logger.Warnf("Failed to update tags of clip %d: %v", clip.ID, err)
+	}
 
 	logger.Infof("Atomic clip created successfully: %d", clip.ID)
 	return clip, nil
@@ -72,13 +104,11 @@ func (s *AtomicClipService) CreateAtomicClip(userID uint, req *models.AtomicClip
 
 func (s *AtomicClipService) GetAtomicClipByID(clipID, userID uint) (*models.AtomicClip, error) {
 	var clip models.AtomicClip
-	query := s.db.Preload("User").Preload("VideoAnalysis")
-	
-	if userID > 0 {
-		query = query.Where("user_id = ?", userID)
-	}
-	
-	if err := query.First(&clip, clipID).Error; err != nil {
+	// The clip is cached once for every user; access is checked below
+	err := cache.ReadThrough(cache.AtomicClipCacheKey(clipID), config.AppConfig.Cache.ClipTTL, &clip, func() error {
+		return s.db.Preload("User").Preload("VideoAnalysis").First(&clip, clipID).Error
+	})
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("atomic clip not found")
 		}
@@ -86,17 +116,81 @@ func (s *AtomicClipService) GetAtomicClipByID(clipID, userID uint) (*models.Atom
 		return nil, errors.New("failed to get atomic clip")
 	}
 
+	// Public and unlisted clips can be opened from outside their library,
+	// unless moderation blocks them
+	shared := clip.Visibility == models.ClipVisibilityPublic || clip.Visibility == models.ClipVisibilityUnlisted
+	if userID > 0 && (!shared || clip.IsModerationBlocked()) {
+		if err := s.checkAccess(&clip, userID, false); err != nil {
+			return nil, err
+		}
+	}
+
 	return &clip, nil
 }
 
+// GetAtomicClipsByIDs returns the clips among the IDs the user can open:
+// those of their libraries, public and unlisted ones, and the stock they
+// can use. They come in no particular order.
+func (s *AtomicClipService) GetAtomicClipsByIDs(clipIDs []uint, userID uint) ([]models.AtomicClip, error) {
+	newDB := s.db.Session(&gorm.Session{NewDB: true})
+	openable := readableScope(userID)(newDB).
+		Or("visibility IN ? AND moderation_status NOT IN ?", []string{models.ClipVisibilityPublic, models.ClipVisibilityUnlisted}, models.ModerationBlocked).
+		Or(models.StockScope(userID)(newDB))
+
+	var clips []models.AtomicClip
+	if err := s.db.Where("id IN ?", clipIDs).Where(openable).Find(&clips).Error; err != nil {
+		return nil, fmt.Errorf("failed to get atomic clips: %w", err)
+	}
+	return clips, nil
+}
+
+// GetClipAnalyses returns the analyses of the clips among the IDs that have
+// one. Access to the clips is not checked.
+func (s *AtomicClipService) GetClipAnalyses(clipIDs []uint) ([]models.VideoAnalysis, error) {
+	var analyses []models.VideoAnalysis
+	if err := s.db.Where("atomic_clip_id IN ?", clipIDs).Find(&analyses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get clip analyses: %w", err)
+	}
+	return analyses, nil
+}
+
+// checkAccess hides clips the user cannot read, and refuses changes to
+// team clips from members who may only view them.
+func (s *AtomicClipService) checkAccess(clip *models.AtomicClip, userID uint, write bool) error {
+	readable, err := canAccess(s.db, userID, clip.UserID, clip.OrganizationID, false)
+	if err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return errors.New("failed to get atomic clip")
+	}
+	if !readable {
+		return errors.New("atomic clip not found")
+	}
+
+	if write {
+		writable, err := canAccess(s.db, userID, clip.UserID, clip.OrganizationID, true)
+		if err != nil {
+			logger.Errorf("Failed to check organization access: %v", err)
+			return errors.New("failed to get atomic clip")
+		}
+		if !writable {
+			return errors.New("insufficient organization role")
+		}
+	}
+
+	return nil
+}
+
 func (s *AtomicClipService) UpdateAtomicClip(clipID, userID uint, req *models.AtomicClipUpdateRequest) (*models.AtomicClip, error) {
 	var clip models.AtomicClip
-	if err := s.db.Where("id = ? AND user_id = ?", clipID, userID).First(&clip).Error; err != nil {
+	if err := s.db.First(&clip, clipID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("atomic clip not found")
 		}
 		return nil, errors.New("failed to get atomic clip")
 	}
+	if err := s.checkAccess(&clip, userID, true); err != nil {
+		return nil, err
+	}
 
 	// Update fields
 	if req.Title != "" {
@@ -108,8 +202,9 @@ func (s *AtomicClipService) UpdateAtomicClip(clipID, userID uint, req *models.At
 	if req.Category != "" {
 		clip.Category = req.Category
 	}
+	previousTags := clip.Tags
 	if len(req.Tags) > 0 {
-		clip.Tags = req.Tags
+		clip.Tags = models.NormalizeTags(req.Tags)
 	}
 	if req.Mood != "" {
 		clip.Mood = req.Mood
@@ -120,46 +215,718 @@ func (s *AtomicClipService) UpdateAtomicClip(clipID, userID uint, req *models.At
 	if req.Color != "" {
 		clip.Color = req.Color
 	}
+	if req.Visibility != "" {
+		clip.Visibility = req.Visibility
+		// Personal clips are only global stock while everyone can see them
+		if clip.Stock && clip.OrganizationID == nil && clip.Visibility != models.ClipVisibilityPublic {
+			clip.Stock = false
+			clip.StockApprovedBy = nil
+			clip.StockApprovedAt = nil
+		}
+	}
 
 	if err := s.db.Save(&clip).Error; err != nil {
 		logger.Errorf("Failed to update atomic clip: %v", err)
 		return nil, errors.New("failed to update atomic clip")
 	}
+	cache.InvalidateClip(clip.ID)
+	indexClips(clip.ID)
+	if err := syncTags(s.db, append(previousTags, clip.Tags...)...); err != nil {
+		logger.Warnf("Failed to update tags of clip %d: %v", clip.ID, err)
+	}
 
 	return &clip, nil
 }
 
-func (s *AtomicClipService) DeleteAtomicClip(clipID, userID uint) error {
-	result := s.db.Where("id = ? AND user_id = ?", clipID, userID).Delete(&models.AtomicClip{})
-	if result.Error != nil {
-		logger.Errorf("Failed to delete atomic clip: %v", result.Error)
-		return errors.New("failed to delete atomic clip")
+// DeleteAtomicClip moves the clip to the trash. A clip on the timeline of
+// an active project is only deleted when forced; the number of those
+// projects is returned either way.
+func (s *AtomicClipService) DeleteAtomicClip(clipID, userID uint, force bool) (int64, error) {
+	var clip models.AtomicClip
+	if err := s.db.First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("atomic clip not found")
+		}
+		return 0, errors.New("failed to get atomic clip")
 	}
-	
-	if result.RowsAffected == 0 {
-		return errors.New("atomic clip not found")
+	if err := s.checkAccess(&clip, userID, true); err != nil {
+		return 0, err
 	}
 
-	return nil
+	activeProjects, err := s.countActiveUsages([]uint{clip.ID})
+	if err != nil {
+		logger.Errorf("Failed to count projects using atomic clip: %v", err)
+		return 0, errors.New("failed to delete atomic clip")
+	}
+	if activeProjects[clip.ID] > 0 && !force {
+		return activeProjects[clip.ID], errors.New("atomic clip is used by active projects")
+	}
+
+	// Collections drop the clip; gaps left in their order are harmless
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("atomic_clip_id = ?", clip.ID).Delete(&models.CollectionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&clip).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to delete atomic clip: %v", err)
+		return 0, errors.New("failed to delete atomic clip")
+	}
+	cache.InvalidateClip(clipID)
+	indexClips(clipID)
+	if err := embedding.Delete([]uint{clipID}); err != nil {
+		logger.Warnf("Failed to delete embedding of clip %d: %v", clipID, err)
+	}
+	if err := syncTags(s.db, clip.Tags...); err != nil {
+		logger.Warnf("Failed to update tags of clip %d: %v", clip.ID, err)
+	}
+
+	return activeProjects[clip.ID], nil
+}
+
+// countActiveUsages returns, for each clip used by any, the number of
+// projects neither archived nor deleted whose timeline uses it.
+func (s *AtomicClipService) countActiveUsages(clipIDs []uint) (map[uint]int64, error) {
+	var rows []struct {
+		ClipID   uint
+		Projects int64
+	}
+	if err := s.db.Model(&models.ClipUsage{}).
+		Select("clip_usages.clip_id, COUNT(DISTINCT clip_usages.project_id) AS projects").
+		Joins("JOIN projects ON projects.id = clip_usages.project_id AND projects.deleted_at IS NULL").
+		Where("clip_usages.clip_id IN ? AND clip_usages.composition_id IS NULL AND projects.status <> ?", clipIDs, models.ProjectStatusArchived).
+		Group("clip_usages.clip_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ClipID] = row.Projects
+	}
+	return counts, nil
+}
+
+// GetClipUsages returns a page of the projects, and compositions of them,
+// using a clip, most recent first. Only the projects the user can read are
+// listed.
+func (s *AtomicClipService) GetClipUsages(clipID, userID uint, req *models.ClipUsageListRequest) ([]models.ClipUsageItem, int64, error) {
+	var clip models.AtomicClip
+	if err := s.db.First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, errors.New("atomic clip not found")
+		}
+		return nil, 0, errors.New("failed to get atomic clip")
+	}
+	if err := s.checkAccess(&clip, userID, false); err != nil {
+		return nil, 0, err
+	}
+
+	readable := s.db.Model(&models.Project{}).Select("id").Scopes(readableScope(userID))
+	query := s.db.Model(&models.ClipUsage{}).
+		Joins("JOIN projects ON projects.id = clip_usages.project_id AND projects.deleted_at IS NULL").
+		Where("clip_usages.clip_id = ? AND clip_usages.project_id IN (?)", clip.ID, readable)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("Failed to count clip usages: %v", err)
+		return nil, 0, errors.New("failed to get clip usages")
+	}
+
+	items := []models.ClipUsageItem{}
+	if err := query.Select("clip_usages.project_id, projects.title AS project_title, projects.status AS project_status, clip_usages.composition_id, clip_usages.created_at AS used_since").
+		Order("clip_usages.created_at DESC, clip_usages.id DESC").
+		Offset((req.Page - 1) * req.Limit).Limit(req.Limit).
+		Scan(&items).Error; err != nil {
+		logger.Errorf("Failed to get clip usages: %v", err)
+		return nil, 0, errors.New("failed to get clip usages")
+	}
+
+	return items, total, nil
+}
+
+// SetStock adds a clip to the stock library or takes it out. Team clips go
+// to their organization's stock library on the word of its admins; personal
+// clips must be public and go to the global one, which takes a user allowed
+// to approve stock.
+func (s *AtomicClipService) SetStock(clipID, userID uint, canApproveGlobal bool, req *models.ClipStockRequest) (*models.AtomicClip, error) {
+	var clip models.AtomicClip
+	if err := s.db.First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("atomic clip not found")
+		}
+		return nil, errors.New("failed to get atomic clip")
+	}
+
+	if clip.OrganizationID != nil {
+		if err := s.checkAccess(&clip, userID, false); err != nil {
+			return nil, err
+		}
+		membership, err := NewOrganizationService().GetMembership(*clip.OrganizationID, userID)
+		if err != nil {
+			return nil, errors.New("failed to get atomic clip")
+		}
+		if !membership.CanManage() {
+			return nil, errors.New("insufficient organization role")
+		}
+	} else {
+		if clip.Visibility != models.ClipVisibilityPublic && clip.UserID != userID {
+			return nil, errors.New("atomic clip not found")
+		}
+		if !canApproveGlobal {
+			return nil, errors.New("insufficient permissions")
+		}
+		if req.Stock && clip.Visibility != models.ClipVisibilityPublic {
+			return nil, errors.New("only public clips can be global stock")
+		}
+	}
+	if req.Stock && clip.IsModerationBlocked() {
+		return nil, errors.New("clip is blocked by moderation")
+	}
+
+	updates := map[string]interface{}{
+		"stock":             req.Stock,
+		"stock_approved_by": nil,
+		"stock_approved_at": nil,
+	}
+	if req.Stock {
+		updates["stock_approved_by"] = userID
+		updates["stock_approved_at"] = time.Now()
+	}
+	if err := s.db.Model(&clip).Updates(updates).Error; err != nil {
+		logger.Errorf("Failed to update stock of atomic clip: %v", err)
+		return nil, errors.New("failed to update atomic clip")
+	}
+	cache.InvalidateClip(clip.ID)
+
+	logger.Infof("Atomic clip %d stock set to %t by user %d", clip.ID, req.Stock, userID)
+	return &clip, nil
+}
+
+// SetThumbnailTime chooses the frame of a clip's thumbnail in place of the
+// one analysis picked, or goes back to it when the time is nil.
+func (s *AtomicClipService) SetThumbnailTime(clipID, userID uint, req *models.ClipThumbnailUpdateRequest) (*models.AtomicClip, error) {
+	var clip models.AtomicClip
+	if err := s.db.Preload("VideoAnalysis").First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("atomic clip not found")
+		}
+		return nil, errors.New("failed to get atomic clip")
+	}
+	if err := s.checkAccess(&clip, userID, true); err != nil {
+		return nil, err
+	}
+	if req.Time != nil && clip.Duration > 0 && *req.Time >= clip.Duration {
+		return nil, errors.New("invalid time: past the end of the clip")
+	}
+
+	if err := s.db.Model(&clip).Update("thumbnail_time", req.Time).Error; err != nil {
+		logger.Errorf("Failed to update thumbnail of atomic clip: %v", err)
+		return nil, errors.New("failed to update atomic clip")
+	}
+	clip.ThumbnailTime = req.Time
+	cache.InvalidateClip(clip.ID)
+
+	return &clip, nil
 }
 
-func (s *AtomicClipService) SearchAtomicClips(req *models.AtomicClipSearchRequest, userID uint) ([]models.AtomicClip, int64, error) {
+// BatchClips applies one action to many clips in a single transaction. Every
+// clip is checked first; when any fails nothing changes and the results say
+// which clips failed and why.
+func (s *AtomicClipService) BatchClips(userID uint, req *models.AtomicClipBatchRequest) ([]models.ClipBatchResult, error) {
+	tags := models.NormalizeTags(req.Tags)
+	switch req.Action {
+	case models.ClipBatchAddTags, models.ClipBatchRemoveTags:
+		if len(tags) == 0 {
+			return nil, errors.New("tags are required")
+		}
+	case models.ClipBatchUpdate:
+		if req.Category == nil && req.Mood == nil {
+			return nil, errors.New("nothing to update")
+		}
+	case models.ClipBatchMoveToCollection:
+		if req.CollectionID == nil {
+			return nil, errors.New("collection_id is required")
+		}
+	}
+
+	var target, source *models.Collection
+	if req.Action == models.ClipBatchMoveToCollection {
+		collections := NewCollectionService()
+		var err error
+		if target, err = collections.getWritableCollection(*req.CollectionID, userID); err != nil {
+			return nil, err
+		}
+		if req.FromCollectionID != nil && *req.FromCollectionID != target.ID {
+			if source, err = collections.getWritableCollection(*req.FromCollectionID, userID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	clipIDs := uniqueClipIDs(req.ClipIDs)
+	var clips []models.AtomicClip
+	if err := s.db.Where("id IN ?", clipIDs).Find(&clips).Error; err != nil {
+		logger.Errorf("Failed to get atomic clips: %v", err)
+		return nil, errors.New("failed to get atomic clips")
+	}
+	byID := make(map[uint]*models.AtomicClip, len(clips))
+	for i := range clips {
+		byID[clips[i].ID] = &clips[i]
+	}
+
+	var activeProjects map[uint]int64
+	if req.Action == models.ClipBatchDelete && !req.Force {
+		var err error
+		if activeProjects, err = s.countActiveUsages(clipIDs); err != nil {
+			logger.Errorf("Failed to count projects using atomic clips: %v", err)
+			return nil, errors.New("failed to get atomic clips")
+		}
+	}
+
+	results := make([]models.ClipBatchResult, len(clipIDs))
+	failed := false
+	for i, id := range clipIDs {
+		results[i] = models.ClipBatchResult{ClipID: id, Status: models.ClipBatchItemOK}
+		clip, ok := byID[id]
+		var err error
+		if !ok {
+			err = errors.New("atomic clip not found")
+		} else if err = s.checkAccess(clip, userID, true); err == nil && target != nil && !inWorkspace(clip, target) {
+			err = errors.New("clip is not in the collection's workspace")
+		} else if err == nil && activeProjects[id] > 0 {
+			err = errors.New("atomic clip is used by active projects")
+		}
+		if err != nil {
+			results[i].Status = models.ClipBatchItemFailed
+			results[i].Error = err.Error()
+			failed = true
+		}
+	}
+	if failed {
+		for i := range results {
+			if results[i].Status == models.ClipBatchItemOK {
+				results[i].Status = models.ClipBatchItemSkipped
+			}
+		}
+		return results, errors.New("one or more clips cannot be changed")
+	}
+
+	// Tags the batch touches, before and after, so their usage is recounted
+	var touchedTags []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		switch req.Action {
+		case models.ClipBatchDelete:
+			if err := tx.Where("atomic_clip_id IN ?", clipIDs).Delete(&models.CollectionItem{}).Error; err != nil {
+				return err
+			}
+			for _, clip := range clips {
+				touchedTags = append(touchedTags, clip.Tags...)
+			}
+			return tx.Where("id IN ?", clipIDs).Delete(&models.AtomicClip{}).Error
+
+		case models.ClipBatchAddTags, models.ClipBatchRemoveTags:
+			for i := range clips {
+				clip := &clips[i]
+				updated := batchTags(clip.Tags, tags, req.Action == models.ClipBatchAddTags)
+				if equalTags(clip.Tags, updated) {
+					continue
+				}
+				touchedTags = append(touchedTags, clip.Tags...)
+				touchedTags = append(touchedTags, updated...)
+				if err := tx.Model(clip).Update("tags", updated).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+
+		case models.ClipBatchUpdate:
+			updates := map[string]interface{}{}
+			if req.Category != nil {
+				updates["category"] = *req.Category
+			}
+			if req.Mood != nil {
+				updates["mood"] = *req.Mood
+			}
+			return tx.Model(&models.AtomicClip{}).Where("id IN ?", clipIDs).Updates(updates).Error
+
+		case models.ClipBatchMoveToCollection:
+			if err := appendCollectionItems(tx, target, clipIDs); err != nil {
+				return err
+			}
+			if source == nil {
+				return nil
+			}
+			if err := tx.Where("collection_id = ? AND atomic_clip_id IN ?", source.ID, clipIDs).
+				Delete(&models.CollectionItem{}).Error; err != nil {
+				return err
+			}
+			return tx.Model(source).Update("updated_at", time.Now()).Error
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errCollectionFull) {
+			return nil, err
+		}
+		logger.Errorf("Failed to apply clip batch: %v", err)
+		return nil, errors.New("failed to apply clip batch")
+	}
+
+	invalidateClips(clipIDs)
+	if req.Action != models.ClipBatchMoveToCollection {
+		indexClips(clipIDs...)
+	}
+	if req.Action == models.ClipBatchDelete {
+		if err := embedding.Delete(clipIDs); err != nil {
+			logger.Warnf("Failed to delete embeddings of clip batch: %v", err)
+		}
+	}
+	if err := syncTags(s.db, touchedTags...); err != nil {
+		logger.Warnf("Failed to update tags of clip batch: %v", err)
+	}
+
+	logger.Infof("Clip batch %s applied to %d clips", req.Action, len(clipIDs))
+	return results, nil
+}
+
+// batchTags adds tags to or removes them from a clip's tags.
+func batchTags(current, tags models.StringArray, add bool) models.StringArray {
+	if add {
+		return models.NormalizeTags(append(append([]string{}, current...), tags...))
+	}
+
+	removed := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		removed[tag] = true
+	}
+	kept := make(models.StringArray, 0, len(current))
+	for _, tag := range current {
+		if !removed[tag] {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// inWorkspace reports whether the clip belongs to the collection's
+// workspace, the one a collection may hold clips from.
+func inWorkspace(clip *models.AtomicClip, collection *models.Collection) bool {
+	if collection.OrganizationID != nil {
+		return clip.OrganizationID != nil && *clip.OrganizationID == *collection.OrganizationID
+	}
+	return clip.OrganizationID == nil && clip.UserID == collection.UserID
+}
+
+// clipSortByLegacy maps the sort parameter that predates sort_by
+var clipSortByLegacy = map[string]string{
+	"newest":    "created_at",
+	"rating":    "rating",
+	"favorites": "favorites",
+}
+
+// clipRelevance ranks how well a clip's title matches the query: exactly,
+// by its start, anywhere, or only in its description. clipRelevanceSQL
+// ranks the same way in the database.
+func clipRelevance(clip *models.AtomicClip, query string) int {
+	query = strings.ToLower(query)
+	title := strings.ToLower(clip.Title)
+	switch {
+	case title == query:
+		return 3
+	case strings.HasPrefix(title, query):
+		return 2
+	case strings.Contains(title, query):
+		return 1
+	}
+	return 0
+}
+
+const clipRelevanceSQL = "(CASE WHEN LOWER(title) = ? THEN 3 WHEN LOWER(title) LIKE ? THEN 2 WHEN LOWER(title) LIKE ? THEN 1 ELSE 0 END)"
+
+// clipSort returns the order of a clip search. Ties fall back to the
+// newest clips, then the id.
+func clipSort(req *models.AtomicClipSearchRequest) keyset {
+	var sort keyset
+	switch req.SortBy {
+	case "duration":
+		sort = keyset{{name: "duration"}, {name: "id"}}
+	case "file_size":
+		sort = keyset{{name: "file_size"}, {name: "id"}}
+	case "quality":
+		sort = keyset{{name: "quality_score"}, {name: "id"}}
+	case "usage":
+		sort = keyset{{name: "usage_count"}, {name: "id"}}
+	case "rating":
+		sort = keyset{{name: "rating_average"}, {name: "rating_count"}, {name: "id"}}
+	case "favorites":
+		sort = keyset{{name: "favorite_count"}, {name: "id"}}
+	case "relevance":
+		query := strings.ToLower(req.Query)
+		escaped := likeEscaper.Replace(query)
+		sort = keyset{
+			{name: clipRelevanceSQL, args: []interface{}{query, escaped + "%", "%" + escaped + "%"}},
+			{name: "created_at", isTime: true},
+			{name: "id"},
+		}
+	default:
+		sort = keysetByCreated
+	}
+	if req.SortOrder == "asc" {
+		return sort.ascending()
+	}
+	return sort
+}
+
+// clipCursor returns the cursor resuming a clip search after the clip.
+func clipCursor(clip *models.AtomicClip, req *models.AtomicClipSearchRequest) string {
+	sort := clipSort(req)
+	switch req.SortBy {
+	case "duration":
+		return sort.cursor(clip.Duration, clip.ID)
+	case "file_size":
+		return sort.cursor(clip.FileSize, clip.ID)
+	case "quality":
+		return sort.cursor(clip.QualityScore, clip.ID)
+	case "usage":
+		return sort.cursor(clip.UsageCount, clip.ID)
+	case "rating":
+		return sort.cursor(clip.RatingAverage, clip.RatingCount, clip.ID)
+	case "favorites":
+		return sort.cursor(clip.FavoriteCount, clip.ID)
+	case "relevance":
+		return sort.cursor(clipRelevance(clip, req.Query), clip.CreatedAt, clip.ID)
+	}
+	return sort.cursor(clip.CreatedAt, clip.ID)
+}
+
+// SearchAtomicClips searches the user's personal library, or the
+// organization's when organizationID is set. Repeated searches are served
+// from the cache until a clip is created, updated or deleted. The returned
+// cursor resumes the search after the page; searches resumed from a cursor
+// are neither counted nor faceted.
+func (s *AtomicClipService) SearchAtomicClips(req *models.AtomicClipSearchRequest, userID uint, organizationID *uint) (*models.ClipSearchResult, error) {
+	// Normalise pagination first so equivalent searches share an entry
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100 // Max limit
+	}
+	if req.SortBy == "" && req.Sort == "" && req.Query != "" {
+		req.SortBy = "relevance"
+	} else if req.SortBy == "" {
+		req.SortBy = clipSortByLegacy[req.Sort]
+	}
+	req.Sort = ""
+	// Without a query every clip is as relevant
+	if req.SortBy == "" || (req.SortBy == "relevance" && req.Query == "") {
+		req.SortBy = "created_at"
+	}
+	if req.SortOrder == "" {
+		req.SortOrder = "desc"
+	}
+	if req.Mode == "" {
+		req.Mode = models.ClipSearchKeyword
+	}
+	if req.Library == "" {
+		req.Library = models.ClipLibraryWorkspace
+	}
+	if req.Mode == models.ClipSearchSemantic {
+		if req.Query == "" {
+			return nil, errors.New("query is required for semantic search")
+		}
+		if req.Library != models.ClipLibraryWorkspace {
+			return nil, errors.New("semantic search only covers the workspace")
+		}
+		if !embedding.Enabled() || userID == 0 {
+			return nil, errors.New("semantic search is not enabled")
+		}
+	}
+
+	key := cache.SearchCacheKey(req.Query, map[string]interface{}{
+		"generation":      cache.ClipSearchGeneration(),
+		"user_id":         userID,
+		"organization_id": organizationID,
+		"request":         req,
+	})
+	var result models.ClipSearchResult
+	err := cache.ReadThrough(key, config.AppConfig.Cache.SearchTTL, &result, func() error {
+		page, err := s.searchAtomicClips(req, userID, organizationID)
+		if err != nil {
+			return err
+		}
+		result = *page
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (s *AtomicClipService) searchAtomicClips(req *models.AtomicClipSearchRequest, userID uint, organizationID *uint) (*models.ClipSearchResult, error) {
+	if req.Mode == models.ClipSearchSemantic {
+		return s.searchSemantic(req, userID, organizationID)
+	}
+
+	// The index ranks better than the database, which stays the fallback
+	if search.Enabled() && req.SortBy == "relevance" && userID > 0 && req.Library == models.ClipLibraryWorkspace {
+		result, err := s.searchIndex(req, userID, organizationID)
+		if err == nil || errors.Is(err, errInvalidCursor) {
+			return result, err
+		}
+		logger.Warnf("Search index unavailable, searching the database: %v", err)
+	}
+
+	// matches selects the clips matching a search, before sorting
+	matches := func(req *models.AtomicClipSearchRequest) *gorm.DB {
+		query := s.db.Model(&models.AtomicClip{})
+
+		// Filter by library; the workspace only when a user is given
+		if userID > 0 || req.Library != models.ClipLibraryWorkspace {
+			query = query.Scopes(libraryScope(req.Library, userID, organizationID))
+		}
+
+		// Apply search filters
+		if req.Query != "" {
+			searchTerm := "%" + strings.ToLower(req.Query) + "%"
+			query = query.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ? OR LOWER(objects) LIKE ? OR id IN (?)", searchTerm, searchTerm, searchTerm,
+				s.db.Model(&models.ClipTranscript{}).Select("atomic_clip_id").Where("LOWER(text) LIKE ?", searchTerm))
+		}
+		return filterClips(query, req)
+	}
+
 	var clips []models.AtomicClip
 	var total int64
+	query := matches(req).Preload("User").Preload("VideoAnalysis")
+
+	sort := clipSort(req)
+	if req.Cursor != "" {
+		var err error
+		if query, err = sort.after(query, req.Cursor); err != nil {
+			return nil, err
+		}
+	} else {
+		// Count total
+		if err := query.Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("failed to count atomic clips: %w", err)
+		}
+		query = query.Offset((req.Page - 1) * req.Limit)
+	}
+
+	// One more row than asked for tells whether there is a next page
+	if err := query.Limit(req.Limit + 1).Order(sort.order()).Find(&clips).Error; err != nil {
+		return nil, fmt.Errorf("failed to get atomic clips: %w", err)
+	}
+
+	result := &models.ClipSearchResult{Clips: clips, Total: total}
+	if len(clips) > req.Limit {
+		result.Clips = clips[:req.Limit]
+		result.NextCursor = clipCursor(&result.Clips[req.Limit-1], req)
+	}
+	if req.Facets && req.Cursor == "" {
+		var err error
+		if result.Facets, err = countFacets(req, matches); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// libraryScope limits a search to the clips of the library: the workspace,
+// every public clip or the stock clips the user can use.
+func libraryScope(library string, userID uint, organizationID *uint) func(*gorm.DB) *gorm.DB {
+	switch library {
+	case models.ClipLibraryPublic:
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where("visibility = ?", models.ClipVisibilityPublic).Scopes(models.ModerationAllowed)
+		}
+	case models.ClipLibraryStock:
+		return models.StockScope(userID)
+	}
+	return workspaceScope(userID, organizationID)
+}
+
+// maxFacetValues bounds how many values of a facet are counted, the most
+// frequent first
+const maxFacetValues = 50
 
-	query := s.db.Model(&models.AtomicClip{}).Preload("User").Preload("VideoAnalysis")
-	
-	// Filter by user if specified
-	if userID > 0 {
-		query = query.Where("user_id = ?", userID)
+// durationBucketSQL computes models.DurationBucket in the database
+const durationBucketSQL = "CASE WHEN duration < 30 THEN 'short' WHEN duration <= 180 THEN 'medium' ELSE 'long' END"
+
+// withoutFacetFilter returns a copy of the search without the filter on
+// the facet.
+func withoutFacetFilter(req *models.AtomicClipSearchRequest, facet string) *models.AtomicClipSearchRequest {
+	facetReq := *req
+	switch facet {
+	case "category":
+		facetReq.Category = ""
+	case "mood":
+		facetReq.Mood = ""
+	case "style":
+		facetReq.Style = ""
+	case "resolution":
+		facetReq.Resolution = ""
+	case "duration":
+		facetReq.Duration = ""
 	}
+	return &facetReq
+}
+
+// countFacets counts the values of each facet among the clips matches
+// selects for the search without the facet's own filter.
+func countFacets(req *models.AtomicClipSearchRequest, matches func(*models.AtomicClipSearchRequest) *gorm.DB) (map[string][]models.FacetCount, error) {
+	facets := make(map[string][]models.FacetCount, len(models.ClipSearchFacets))
+	for _, facet := range models.ClipSearchFacets {
+		query := matches(withoutFacetFilter(req, facet))
+		column := facet
+		if facet == "duration" {
+			column = durationBucketSQL
+		} else {
+			query = query.Where(column + " <> ''") // Unset values are not counted
+		}
 
-	// Apply search filters
-	if req.Query != "" {
-		searchTerm := "%" + strings.ToLower(req.Query) + "%"
-		query = query.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
+		counts := []models.FacetCount{}
+		if err := query.
+			Select(column + " AS value, COUNT(*) AS count").
+			Group("value").
+			Order("count DESC, value").
+			Limit(maxFacetValues).
+			Scan(&counts).Error; err != nil {
+			return nil, fmt.Errorf("failed to count %s facet: %w", facet, err)
+		}
+		facets[facet] = counts
 	}
+	return facets, nil
+}
 
+// facetCounts orders the counts of a facet by frequency, then value.
+func facetCounts(values map[string]int64) []models.FacetCount {
+	counts := make([]models.FacetCount, 0, len(values))
+	for value, count := range values {
+		if value != "" {
+			counts = append(counts, models.FacetCount{Value: value, Count: count})
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+	if len(counts) > maxFacetValues {
+		counts = counts[:maxFacetValues]
+	}
+	return counts
+}
+
+// filterClips applies the filters of a clip search other than its query.
+func filterClips(query *gorm.DB, req *models.AtomicClipSearchRequest) *gorm.DB {
 	if req.Category != "" {
 		query = query.Where("category = ?", req.Category)
 	}
@@ -180,10 +947,8 @@ func (s *AtomicClipService) SearchAtomicClips(req *models.AtomicClipSearchReques
 		query = query.Where("resolution = ?", req.Resolution)
 	}
 
-	if len(req.Tags) > 0 {
-		for _, tag := range req.Tags {
-			query = query.Where("tags::text ILIKE ?", "%"+tag+"%")
-		}
+	for _, tag := range models.NormalizeTags(req.Tags) {
+		query = query.Where("tags LIKE ?", tagLikePattern(tag))
 	}
 
 	// Duration filter
@@ -196,56 +961,441 @@ func (s *AtomicClipService) SearchAtomicClips(req *models.AtomicClipSearchReques
 		query = query.Where("duration > ?", 180) // More than 3 minutes
 	}
 
-	// Count total
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to count atomic clips: %w", err)
+	if req.CreatedAfter != nil {
+		query = query.Where("created_at > ?", *req.CreatedAfter)
 	}
+	return query
+}
 
-	// Apply pagination
-	if req.Page <= 0 {
-		req.Page = 1
+// offsetCursor pages the searches ranked outside the database, by the
+// search index or by similarity, which can only skip to an offset
+var offsetCursor = keyset{{name: "offset"}}
+
+func decodeOffsetCursor(cursor string) (int, error) {
+	values, err := offsetCursor.decode(cursor)
+	if err != nil {
+		return 0, err
 	}
-	if req.Limit <= 0 {
-		req.Limit = 20
+	offset, ok := values[0].(int64)
+	if !ok || offset < 0 {
+		return 0, errInvalidCursor
 	}
-	if req.Limit > 100 {
-		req.Limit = 100 // Max limit
+	return int(offset), nil
+}
+
+// orderClips puts the clips in the order of ids, leaving out the IDs of
+// clips that were not found.
+func orderClips(clips []models.AtomicClip, ids []uint) []models.AtomicClip {
+	byID := make(map[uint]*models.AtomicClip, len(clips))
+	for i := range clips {
+		byID[clips[i].ID] = &clips[i]
+	}
+	ordered := make([]models.AtomicClip, 0, len(clips))
+	for _, id := range ids {
+		if clip, ok := byID[id]; ok {
+			ordered = append(ordered, *clip)
+		}
 	}
+	return ordered
+}
 
+// maxSemanticMatches bounds how many of the nearest clips a semantic search
+// ranks; filters apply to them, so a narrow filter can leave fewer
+const maxSemanticMatches = 500
+
+// searchSemantic ranks the clips of the workspace by how close their
+// content is to the meaning of the query.
+func (s *AtomicClipService) searchSemantic(req *models.AtomicClipSearchRequest, userID uint, organizationID *uint) (*models.ClipSearchResult, error) {
 	offset := (req.Page - 1) * req.Limit
-	if err := query.Offset(offset).Limit(req.Limit).Order("created_at DESC").Find(&clips).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to get atomic clips: %w", err)
+	if req.Cursor != "" {
+		var err error
+		if offset, err = decodeOffsetCursor(req.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	vector, err := embedding.EmbedText(req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed search query: %w", err)
+	}
+	nearest, err := embedding.Nearest(vector, embedding.Scope{UserID: userID, OrganizationID: organizationID}, maxSemanticMatches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nearest clips: %w", err)
+	}
+	result := &models.ClipSearchResult{Clips: []models.AtomicClip{}}
+	if len(nearest) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uint, len(nearest))
+	for i, match := range nearest {
+		ids[i] = match.ClipID
+	}
+	// matches selects the nearest clips that pass the filters
+	matches := func(req *models.AtomicClipSearchRequest) *gorm.DB {
+		query := s.db.Model(&models.AtomicClip{}).
+			Scopes(workspaceScope(userID, organizationID)).
+			Where("id IN ?", ids)
+		return filterClips(query, req)
+	}
+
+	var found []models.AtomicClip
+	if err := matches(req).Preload("User").Preload("VideoAnalysis").Find(&found).Error; err != nil {
+		return nil, fmt.Errorf("failed to get atomic clips: %w", err)
 	}
+	ranked := orderClips(found, ids)
 
-	return clips, total, nil
+	if req.Cursor == "" {
+		result.Total = int64(len(ranked))
+		if req.Facets {
+			if result.Facets, err = countFacets(req, matches); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if offset >= len(ranked) {
+		return result, nil
+	}
+	end := offset + req.Limit
+	if end < len(ranked) {
+		result.NextCursor = offsetCursor.cursor(end)
+	} else {
+		end = len(ranked)
+	}
+	result.Clips = ranked[offset:end]
+	return result, nil
+}
+
+// searchIndex runs a relevance-ranked search through the search index and
+// loads the matching clips, best match first.
+func (s *AtomicClipService) searchIndex(req *models.AtomicClipSearchRequest, userID uint, organizationID *uint) (*models.ClipSearchResult, error) {
+	query := indexQuery(req, userID, organizationID)
+	query.Offset = (req.Page - 1) * req.Limit
+	query.Limit = req.Limit + 1
+	if req.Cursor != "" {
+		var err error
+		if query.Offset, err = decodeOffsetCursor(req.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := search.Search(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := result.IDs
+	page := &models.ClipSearchResult{}
+	if len(ids) > req.Limit {
+		ids = ids[:req.Limit]
+		page.NextCursor = offsetCursor.cursor(query.Offset + req.Limit)
+	}
+
+	// Clips changed since they were indexed are checked against the
+	// workspace again, and those deleted meanwhile are left out
+	var found []models.AtomicClip
+	if len(ids) > 0 {
+		if err := s.db.Preload("User").Preload("VideoAnalysis").
+			Scopes(workspaceScope(userID, organizationID)).
+			Where("id IN ?", ids).Find(&found).Error; err != nil {
+			return nil, fmt.Errorf("failed to get atomic clips: %w", err)
+		}
+	}
+	page.Clips = orderClips(found, ids)
+
+	if req.Cursor == "" {
+		page.Total = result.Total
+		if req.Facets {
+			values, err := search.Facets(query, models.ClipSearchFacets)
+			if err != nil {
+				return nil, err
+			}
+			page.Facets = make(map[string][]models.FacetCount, len(values))
+			for facet, counts := range values {
+				page.Facets[facet] = facetCounts(counts)
+			}
+		}
+	}
+	return page, nil
 }
 
-func (s *AtomicClipService) GetUserAtomicClips(userID uint, page, limit int) ([]models.AtomicClip, int64, error) {
+// indexQuery returns the search index query matching the search.
+func indexQuery(req *models.AtomicClipSearchRequest, userID uint, organizationID *uint) *search.Query {
+	query := &search.Query{
+		Text:           req.Query,
+		UserID:         userID,
+		OrganizationID: organizationID,
+	}
+
+	for field, value := range map[string]string{
+		"category":   req.Category,
+		"mood":       req.Mood,
+		"style":      req.Style,
+		"color":      req.Color,
+		"resolution": req.Resolution,
+	} {
+		if value != "" {
+			query.Filters = append(query.Filters, search.Filter{Field: field, Op: "=", Value: value})
+		}
+	}
+	for _, tag := range models.NormalizeTags(req.Tags) {
+		query.Filters = append(query.Filters, search.Filter{Field: "tags", Op: "=", Value: tag})
+	}
+	switch req.Duration {
+	case "short":
+		query.Filters = append(query.Filters, search.Filter{Field: "duration", Op: "<", Value: 30.0})
+	case "medium":
+		query.Filters = append(query.Filters,
+			search.Filter{Field: "duration", Op: ">=", Value: 30.0},
+			search.Filter{Field: "duration", Op: "<=", Value: 180.0})
+	case "long":
+		query.Filters = append(query.Filters, search.Filter{Field: "duration", Op: ">", Value: 180.0})
+	}
+	if req.CreatedAfter != nil {
+		query.Filters = append(query.Filters, search.Filter{Field: "created_at", Op: ">", Value: float64(req.CreatedAfter.Unix())})
+	}
+	return query
+}
+
+// GetUserAtomicClips lists the clips of the user's current workspace,
+// newest first, from the page or after the cursor when one is given. It
+// returns the cursor of the next page, if any.
+func (s *AtomicClipService) GetUserAtomicClips(userID uint, organizationID *uint, req *models.AtomicClipListRequest) ([]models.AtomicClip, int64, string, error) {
 	var clips []models.AtomicClip
 	var total int64
 
-	query := s.db.Model(&models.AtomicClip{}).Where("user_id = ?", userID).Preload("VideoAnalysis")
+	query := s.db.Model(&models.AtomicClip{}).Scopes(workspaceScope(userID, organizationID)).Preload("VideoAnalysis")
 
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to count atomic clips: %w", err)
+	if req.Cursor != "" {
+		var err error
+		if query, err = keysetByCreated.after(query, req.Cursor); err != nil {
+			return nil, 0, "", err
+		}
+	} else {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, "", fmt.Errorf("failed to count atomic clips: %w", err)
+		}
+		query = query.Offset((req.Page - 1) * req.Limit)
+	}
+
+	if err := query.Limit(req.Limit + 1).Order(keysetByCreated.order()).Find(&clips).Error; err != nil {
+		return nil, 0, "", fmt.Errorf("failed to get atomic clips: %w", err)
+	}
+
+	var nextCursor string
+	if len(clips) > req.Limit {
+		clips = clips[:req.Limit]
+		last := clips[len(clips)-1]
+		nextCursor = keysetByCreated.cursor(last.CreatedAt, last.ID)
+	}
+	return clips, total, nextCursor, nil
+}
+
+// GetClipAnalysis returns the latest analysis of a clip the user can read.
+func (s *AtomicClipService) GetClipAnalysis(clipID, userID uint) (*models.VideoAnalysis, error) {
+	if _, err := s.GetAtomicClipByID(clipID, userID); err != nil {
+		return nil, err
+	}
+
+	var analysis models.VideoAnalysis
+	if err := s.db.Where("atomic_clip_id = ?", clipID).First(&analysis).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("analysis not found")
+		}
+		logger.Errorf("Failed to get clip analysis: %v", err)
+		return nil, errors.New("failed to get analysis")
+	}
+
+	return &analysis, nil
+}
+
+// RequestAnalysis queues the clip for (re)analysis. The existing analysis
+// stays readable until the new one replaces it.
+func (s *AtomicClipService) RequestAnalysis(clipID, userID uint, traceID string, req *models.ClipAnalysisRequest) error {
+	var clip models.AtomicClip
+	if err := s.db.First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("atomic clip not found")
+		}
+		return errors.New("failed to get atomic clip")
+	}
+	if err := s.checkAccess(&clip, userID, true); err != nil {
+		return err
+	}
+
+	if req.AnalysisType == "" {
+		req.AnalysisType = models.AnalysisTypeFull
+	}
+	if req.Version == "" {
+		req.Version = models.DefaultAnalysisVersion
+	}
+
+	if err := queue.PublishAnalysisTask(clip.ID, req.AnalysisType, req.Version, traceID); err != nil {
+		logger.Errorf("Failed to queue analysis for clip %d: %v", clip.ID, err)
+		return errors.New("failed to queue analysis")
+	}
+
+	return nil
+}
+
+// GetClipTranscript returns the transcript of a clip the user can open.
+func (s *AtomicClipService) GetClipTranscript(clipID, userID uint) (*models.ClipTranscript, error) {
+	if _, err := s.GetAtomicClipByID(clipID, userID); err != nil {
+		return nil, err
+	}
+
+	var transcript models.ClipTranscript
+	if err := s.db.Where("atomic_clip_id = ?", clipID).First(&transcript).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("transcript not found")
+		}
+		logger.Errorf("Failed to get clip transcript: %v", err)
+		return nil, errors.New("failed to get transcript")
+	}
+
+	return &transcript, nil
+}
+
+// RequestTranscription queues the clip's audio to be transcribed again.
+// The existing transcript stays readable until the new one replaces it.
+func (s *AtomicClipService) RequestTranscription(clipID, userID uint, traceID string, req *models.ClipTranscriptionRequest) error {
+	if !transcription.Enabled() {
+		return errors.New("transcription is not enabled")
+	}
+
+	var clip models.AtomicClip
+	if err := s.db.First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("atomic clip not found")
+		}
+		return errors.New("failed to get atomic clip")
+	}
+	if err := s.checkAccess(&clip, userID, true); err != nil {
+		return err
 	}
 
-	offset := (page - 1) * limit
-	if err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&clips).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to get atomic clips: %w", err)
+	if err := queue.PublishTranscriptionTask(clip.ID, req.Language, traceID); err != nil {
+		logger.Errorf("Failed to queue transcription for clip %d: %v", clip.ID, err)
+		return errors.New("failed to queue transcription")
 	}
 
-	return clips, total, nil
+	return nil
+}
+
+// GetMusicAnalysis returns the beat and structure analysis of a music clip
+// the user can open.
+func (s *AtomicClipService) GetMusicAnalysis(clipID, userID uint) (*models.MusicAnalysis, error) {
+	if _, err := s.GetAtomicClipByID(clipID, userID); err != nil {
+		return nil, err
+	}
+
+	var analysis models.MusicAnalysis
+	if err := s.db.Where("atomic_clip_id = ?", clipID).First(&analysis).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("music analysis not found")
+		}
+		logger.Errorf("Failed to get music analysis: %v", err)
+		return nil, errors.New("failed to get music analysis")
+	}
+
+	return &analysis, nil
+}
+
+// RequestMusicAnalysis queues a clip's audio to be analyzed as music. The
+// existing analysis stays readable until the new one replaces it.
+func (s *AtomicClipService) RequestMusicAnalysis(clipID, userID uint, traceID string) error {
+	var clip models.AtomicClip
+	if err := s.db.First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("atomic clip not found")
+		}
+		return errors.New("failed to get atomic clip")
+	}
+	if err := s.checkAccess(&clip, userID, true); err != nil {
+		return err
+	}
+
+	if err := queue.PublishMusicAnalysisTask(clip.ID, traceID); err != nil {
+		logger.Errorf("Failed to queue music analysis for clip %d: %v", clip.ID, err)
+		return errors.New("failed to queue music analysis")
+	}
+
+	return nil
 }
 
-func (s *AtomicClipService) GetSimilarClips(clipID uint, limit int) ([]models.AtomicClip, error) {
+// SuggestClipMetadata writes a description and title ideas for a clip from
+// its analysis and transcript, and keeps them on its analysis. Nothing is
+// applied to the clip; its owner picks what to use.
+func (s *AtomicClipService) SuggestClipMetadata(clipID, userID uint) (*suggestions.Suggestion, error) {
+	if !suggestions.Enabled() {
+		return nil, errors.New("metadata suggestions are not enabled")
+	}
+
+	var clip models.AtomicClip
+	if err := s.db.Preload("VideoAnalysis").First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("atomic clip not found")
+		}
+		return nil, errors.New("failed to get atomic clip")
+	}
+	if err := s.checkAccess(&clip, userID, true); err != nil {
+		return nil, err
+	}
+
+	var transcript models.ClipTranscript
+	if err := s.db.Where("atomic_clip_id = ?", clip.ID).Limit(1).Find(&transcript).Error; err != nil {
+		logger.Warnf("Failed to load the transcript of clip %d: %v", clip.ID, err)
+	}
+
+	suggestion, err := suggestions.Suggest(&clip, transcript.Text)
+	if err != nil {
+		logger.Errorf("Failed to suggest metadata for clip %d: %v", clip.ID, err)
+		return nil, errors.New("failed to suggest metadata")
+	}
+
+	analysis := models.VideoAnalysis{AtomicClipID: clip.ID}
+	if err := s.db.Where(models.VideoAnalysis{AtomicClipID: clip.ID}).
+		Assign(map[string]interface{}{
+			"ai_description":   suggestion.Description,
+			"suggested_titles": models.StringArray(suggestion.Titles),
+		}).
+		FirstOrCreate(&analysis).Error; err != nil {
+		logger.Errorf("Failed to store metadata suggestions for clip %d: %v", clip.ID, err)
+	}
+	cache.InvalidateClip(clip.ID)
+
+	return suggestion, nil
+}
+
+// GetSimilarClips returns the clips of the clip's library whose content is
+// nearest to it. Until the clip has an embedding, or when the vector store
+// fails, clips sharing its category, mood and style are returned instead.
+func (s *AtomicClipService) GetSimilarClips(clipID, userID uint, limit int) ([]models.AtomicClip, error) {
 	var baseClip models.AtomicClip
-	if err := s.db.First(&baseClip, clipID).Error; err != nil {
-		return nil, errors.New("clip not found")
+	if err := s.db.Preload("VideoAnalysis").First(&baseClip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("atomic clip not found")
+		}
+		return nil, errors.New("failed to get atomic clip")
+	}
+	if err := s.checkAccess(&baseClip, userID, false); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	if embedding.Enabled() && baseClip.VideoAnalysis != nil && len(baseClip.VideoAnalysis.Embedding) > 0 {
+		clips, err := s.nearestClips(&baseClip, limit)
+		if err == nil {
+			return clips, nil
+		}
+		logger.Warnf("Failed to find clips near clip %d, matching its metadata: %v", clipID, err)
 	}
 
 	var clips []models.AtomicClip
 	query := s.db.Model(&models.AtomicClip{}).
+		Scopes(workspaceScope(baseClip.UserID, baseClip.OrganizationID)).
 		Where("id != ?", clipID).
 		Preload("VideoAnalysis")
 
@@ -265,4 +1415,84 @@ func (s *AtomicClipService) GetSimilarClips(clipID uint, limit int) ([]models.At
 	}
 
 	return clips, nil
-}
\ No newline at end of file
+}
+
+// GetDuplicateClips returns the clips of the clip's workspace showing the
+// same footage, by the perceptual hash its analysis computed, most alike
+// first.
+func (s *AtomicClipService) GetDuplicateClips(clipID, userID uint) ([]models.ClipDuplicate, error) {
+	var clip models.AtomicClip
+	if err := s.db.Preload("VideoAnalysis").First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("atomic clip not found")
+		}
+		return nil, errors.New("failed to get atomic clip")
+	}
+	if err := s.checkAccess(&clip, userID, false); err != nil {
+		return nil, err
+	}
+	if clip.VideoAnalysis == nil || clip.VideoAnalysis.PerceptualHash == "" {
+		return nil, errors.New("clip has not been hashed")
+	}
+
+	matches, err := duplicates.Find(s.db, &clip, clip.VideoAnalysis.PerceptualHash)
+	if err != nil {
+		logger.Errorf("Failed to find duplicates of clip %d: %v", clipID, err)
+		return nil, errors.New("failed to get duplicate clips")
+	}
+	if len(matches) == 0 {
+		return []models.ClipDuplicate{}, nil
+	}
+
+	ids := make([]uint, len(matches))
+	similarities := make(map[uint]float64, len(matches))
+	for i, match := range matches {
+		ids[i] = match.ClipID
+		similarities[match.ClipID] = match.Similarity
+	}
+	var found []models.AtomicClip
+	if err := s.db.Preload("VideoAnalysis").Where("id IN ?", ids).Find(&found).Error; err != nil {
+		logger.Errorf("Failed to get duplicates of clip %d: %v", clipID, err)
+		return nil, errors.New("failed to get duplicate clips")
+	}
+
+	clips := orderClips(found, ids)
+	result := make([]models.ClipDuplicate, len(clips))
+	for i, duplicate := range clips {
+		result[i] = models.ClipDuplicate{Clip: duplicate, Similarity: similarities[duplicate.ID]}
+	}
+	return result, nil
+}
+
+// nearestClips returns the clips of the clip's library nearest to its
+// embedding, most similar first.
+func (s *AtomicClipService) nearestClips(baseClip *models.AtomicClip, limit int) ([]models.AtomicClip, error) {
+	scope := embedding.Scope{UserID: baseClip.UserID, OrganizationID: baseClip.OrganizationID}
+	// One more, as the clip itself is nearest
+	matches, err := embedding.Nearest(baseClip.VideoAnalysis.Embedding, scope, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(matches))
+	for _, match := range matches {
+		if match.ClipID != baseClip.ID {
+			ids = append(ids, match.ClipID)
+		}
+	}
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	if len(ids) == 0 {
+		return []models.AtomicClip{}, nil
+	}
+
+	var found []models.AtomicClip
+	if err := s.db.Preload("VideoAnalysis").
+		Scopes(workspaceScope(baseClip.UserID, baseClip.OrganizationID)).
+		Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	clips := orderClips(found, ids)
+	return clips, nil
+}
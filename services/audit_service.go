@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+// auditExportBatchSize is how many entries an export reads at a time
+const auditExportBatchSize = 500
+
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService() *AuditService {
+	return &AuditService{
+		db: database.GetDB(),
+	}
+}
+
+// Record appends an entry with snapshots of the resource before and after
+// the action; nil snapshots are left out. Failing to record is logged
+// rather than failing the action, which has already happened.
+func (s *AuditService) Record(entry *models.AuditLog, before, after interface{}) {
+	entry.Before = auditSnapshot(before)
+	entry.After = auditSnapshot(after)
+	entry.UserAgent = truncate(entry.UserAgent, 255)
+
+	if err := s.db.Create(entry).Error; err != nil {
+		logger.Errorf("Failed to record audit log %s on %s %s: %v", entry.Action, entry.ResourceType, entry.ResourceID, err)
+	}
+}
+
+func auditSnapshot(v interface{}) models.RawJSON {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Warnf("Failed to snapshot audited resource: %v", err)
+		return nil
+	}
+	return data
+}
+
+// QueryAuditLogs returns the entries matching the query, newest first,
+// and the cursor of the next page, if any.
+func (s *AuditService) QueryAuditLogs(query *models.AuditLogQuery) ([]models.AuditLog, int64, string, error) {
+	db := s.db.Model(&models.AuditLog{}).Scopes(auditFilter(query))
+
+	var total int64
+	if query.Cursor != "" {
+		var err error
+		if db, err = keysetByID.after(db, query.Cursor); err != nil {
+			return nil, 0, "", err
+		}
+	} else {
+		if err := db.Count(&total).Error; err != nil {
+			return nil, 0, "", fmt.Errorf("failed to count audit logs: %w", err)
+		}
+		db = db.Offset((query.Page - 1) * query.Limit)
+	}
+
+	var entries []models.AuditLog
+	if err := db.Order(keysetByID.order()).Limit(query.Limit + 1).Find(&entries).Error; err != nil {
+		return nil, 0, "", fmt.Errorf("failed to get audit logs: %w", err)
+	}
+
+	var nextCursor string
+	if len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+		nextCursor = keysetByID.cursor(entries[len(entries)-1].ID)
+	}
+	return entries, total, nextCursor, nil
+}
+
+// ExportAuditLogs passes every entry matching the query to fn, oldest
+// first, a batch at a time. Paging is ignored.
+func (s *AuditService) ExportAuditLogs(query *models.AuditLogQuery, fn func(entries []models.AuditLog) error) error {
+	var batch []models.AuditLog
+	result := s.db.Scopes(auditFilter(query)).Order("id ASC").
+		FindInBatches(&batch, auditExportBatchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to export audit logs: %w", result.Error)
+	}
+	return nil
+}
+
+func auditFilter(query *models.AuditLogQuery) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if query.ActorID != 0 {
+			db = db.Where("actor_id = ?", query.ActorID)
+		}
+		if query.OrganizationID != 0 {
+			db = db.Where("organization_id = ?", query.OrganizationID)
+		}
+		if query.Action != "" {
+			db = db.Where("action = ?", query.Action)
+		}
+		if query.ResourceType != "" {
+			db = db.Where("resource_type = ?", query.ResourceType)
+		}
+		if query.ResourceID != "" {
+			db = db.Where("resource_id = ?", query.ResourceID)
+		}
+		if !query.From.IsZero() {
+			db = db.Where("created_at >= ?", query.From)
+		}
+		if !query.To.IsZero() {
+			db = db.Where("created_at < ?", query.To)
+		}
+		return db
+	}
+}
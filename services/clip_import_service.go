@@ -0,0 +1,759 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/download"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/queue"
+	"creative-studio-server/pkg/realtime"
+	"creative-studio-server/pkg/upload"
+	"creative-studio-server/pkg/video_engine"
+)
+
+const (
+	// maxImportFiles bounds the videos of one archive
+	maxImportFiles = 500
+	// maxImportFileSize bounds one extracted video, whatever the archive
+	// claims its size is
+	maxImportFileSize = 2 << 30
+)
+
+// importVideoExtensions are the files of an archive that become clips; the
+// same formats single uploads accept
+var importVideoExtensions = map[string]bool{
+	".mp4": true,
+	".m4v": true,
+	".mov": true,
+	".avi": true,
+	".mkv": true,
+}
+
+// importManifestNames are looked for at the root of an archive uploaded
+// without a separate manifest
+var importManifestNames = []string{"manifest.json", "manifest.csv"}
+
+type ClipImportService struct {
+	db          *gorm.DB
+	clipService *AtomicClipService
+}
+
+func NewClipImportService() *ClipImportService {
+	return &ClipImportService{
+		db:          database.GetDB(),
+		clipService: NewAtomicClipService(),
+	}
+}
+
+// ImportArchiveDir is the directory uploaded import archives are stored in
+// until their import finishes.
+func ImportArchiveDir() string {
+	return filepath.Join(config.AppConfig.Storage.UploadPath, "imports")
+}
+
+// CreateImport records a pending item per video in an uploaded archive,
+// stored in ImportArchiveDir, with its manifest entry, and queues the
+// import. The import takes over the archive, deleting it when it is not
+// queued. The manifest may come as its own file or as
+// manifest.json/manifest.csv inside the archive.
+func (s *ClipImportService) CreateImport(userID uint, organizationID *uint, traceID string, archive, manifest *upload.File) (*models.ClipImport, error) {
+	archivePath := archive.Path
+	// Until the import is queued the archive is ours to clean up
+	queued := false
+	defer func() {
+		if !queued {
+			os.Remove(archivePath)
+		}
+	}()
+
+	if allowed, err := canAccess(s.db, userID, userID, organizationID, true); err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to create import")
+	} else if !allowed {
+		return nil, errors.New("insufficient organization role")
+	}
+
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, errors.New("invalid archive: not a ZIP file")
+	}
+	defer reader.Close()
+
+	var videos []string
+	for _, file := range reader.File {
+		if isImportVideo(file) {
+			videos = append(videos, file.Name)
+		}
+	}
+	if len(videos) == 0 {
+		return nil, errors.New("invalid archive: no video files found")
+	}
+	if len(videos) > maxImportFiles {
+		return nil, fmt.Errorf("invalid archive: at most %d video files can be imported at once", maxImportFiles)
+	}
+
+	entries, err := readImportManifest(&reader.Reader, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	clipImport := &models.ClipImport{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		FileName:       archive.Filename,
+		ArchivePath:    archivePath,
+		Status:         models.ImportStatusPending,
+		TotalFiles:     len(videos),
+		TraceID:        traceID,
+	}
+	for _, name := range videos {
+		entry, ok := entries[name]
+		if !ok {
+			entry, ok = entries[path.Base(name)]
+		}
+		if !ok {
+			entry = models.ClipManifestEntry{}
+		}
+		if entry.Title == "" {
+			entry.Title = strings.TrimSuffix(path.Base(name), path.Ext(name))
+		}
+		metadata, err := json.Marshal(entry)
+		if err != nil {
+			return nil, errors.New("failed to create import")
+		}
+
+		clipImport.Items = append(clipImport.Items, models.ClipImportItem{
+			FileName: name,
+			Status:   models.ImportItemPending,
+			Metadata: metadata,
+		})
+	}
+
+	if err := s.db.Create(clipImport).Error; err != nil {
+		logger.Errorf("Failed to create import: %v", err)
+		return nil, errors.New("failed to create import")
+	}
+
+	if err := queue.PublishClipImportTask(clipImport.ID, traceID); err != nil {
+		logger.Errorf("Failed to queue import %d: %v", clipImport.ID, err)
+		s.db.Model(clipImport).Updates(map[string]interface{}{
+			"status":        models.ImportStatusFailed,
+			"error_message": "failed to queue import task",
+		})
+		return nil, errors.New("failed to queue import")
+	}
+	queued = true
+
+	logger.Infof("Import %d queued with %d videos for user %d", clipImport.ID, len(videos), userID)
+	return clipImport, nil
+}
+
+// GetImport returns an import the user can read with the status of every
+// file.
+func (s *ClipImportService) GetImport(importID, userID uint) (*models.ClipImport, error) {
+	var clipImport models.ClipImport
+	if err := s.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&clipImport, importID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("import not found")
+		}
+		logger.Errorf("Failed to get import: %v", err)
+		return nil, errors.New("failed to get import")
+	}
+
+	allowed, err := canAccess(s.db, userID, clipImport.UserID, clipImport.OrganizationID, false)
+	if err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to get import")
+	}
+	if !allowed {
+		return nil, errors.New("import not found")
+	}
+
+	return &clipImport, nil
+}
+
+// ListImports returns the imports of the workspace, newest first, without
+// their items.
+func (s *ClipImportService) ListImports(userID uint, organizationID *uint, req *models.ClipImportListRequest) ([]models.ClipImport, int64, error) {
+	query := s.db.Model(&models.ClipImport{}).Scopes(workspaceScope(userID, organizationID))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count imports: %w", err)
+	}
+
+	var imports []models.ClipImport
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(req.Limit).Find(&imports).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get imports: %w", err)
+	}
+
+	return imports, total, nil
+}
+
+// ClipImportTaskHandler processes a queued import. Files already handled by
+// an earlier attempt are skipped, so a retry picks up where it stopped.
+func ClipImportTaskHandler(task *queue.Task) error {
+	importID, ok := task.Payload["import_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid import_id in task payload")
+	}
+
+	return NewClipImportService().process(task, uint(importID))
+}
+
+func (s *ClipImportService) process(task *queue.Task, importID uint) error {
+	var clipImport models.ClipImport
+	if err := s.db.Preload("Items", "status = ?", models.ImportItemPending).First(&clipImport, importID).Error; err != nil {
+		return fmt.Errorf("failed to load import %d: %w", importID, err)
+	}
+	if clipImport.Status == models.ImportStatusCompleted || clipImport.Status == models.ImportStatusFailed {
+		task.Logger().Infof("Import %d is already %s, skipping", importID, clipImport.Status)
+		return nil
+	}
+
+	s.db.Model(&clipImport).Update("status", models.ImportStatusProcessing)
+
+	if clipImport.SourceURL != "" {
+		return s.processURL(task, &clipImport)
+	}
+
+	reader, err := zip.OpenReader(clipImport.ArchivePath)
+	if err != nil {
+		// The archive was checked on upload, so it is gone rather than broken
+		s.finish(&clipImport, fmt.Sprintf("archive unavailable: %v", err))
+		return nil
+	}
+	defer reader.Close()
+
+	files := make(map[string]*zip.File, len(reader.File))
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	processor := video_engine.NewFFmpegProcessor(config.AppConfig)
+	for i := range clipImport.Items {
+		item := &clipImport.Items[i]
+
+		clip, err := s.importFile(processor, &clipImport, item, files[item.FileName])
+		if err := s.recordItem(task, item, clip, err); err != nil {
+			return err
+		}
+	}
+
+	s.finish(&clipImport, "")
+	return nil
+}
+
+// recordItem records what became of an imported file and queues the
+// processing and analysis of the clip it was made into.
+func (s *ClipImportService) recordItem(task *queue.Task, item *models.ClipImportItem, clip *models.AtomicClip, importErr error) error {
+	updates := map[string]interface{}{"status": models.ImportItemCreated}
+	if importErr != nil {
+		task.Logger().Warnf("Import %d: %s failed: %v", item.ImportID, item.FileName, importErr)
+		updates = map[string]interface{}{"status": models.ImportItemFailed, "error": importErr.Error()}
+	} else {
+		updates["atomic_clip_id"] = clip.ID
+
+		if err := queue.PublishVideoProcessingTask(clip.ID, clip.FilePath, task.TraceID); err != nil {
+			task.Logger().Warnf("Failed to queue processing of clip %d: %v", clip.ID, err)
+		}
+		if err := queue.PublishAnalysisTask(clip.ID, models.AnalysisTypeFull, models.DefaultAnalysisVersion, task.TraceID); err != nil {
+			task.Logger().Warnf("Failed to queue analysis of clip %d: %v", clip.ID, err)
+		}
+		if err := queue.PublishTranscriptionTask(clip.ID, "", task.TraceID); err != nil {
+			task.Logger().Warnf("Failed to queue transcription of clip %d: %v", clip.ID, err)
+		}
+		if err := queue.PublishModerationTask(clip.ID, task.TraceID); err != nil {
+			task.Logger().Warnf("Failed to queue moderation of clip %d: %v", clip.ID, err)
+		}
+		if clip.IsMusic() {
+			if err := queue.PublishMusicAnalysisTask(clip.ID, task.TraceID); err != nil {
+				task.Logger().Warnf("Failed to queue music analysis of clip %d: %v", clip.ID, err)
+			}
+		}
+	}
+	if err := s.db.Model(item).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update import item %d: %w", item.ID, err)
+	}
+	return nil
+}
+
+// importFile extracts one video next to the other uploads and creates its
+// clip from the manifest entry.
+func (s *ClipImportService) importFile(processor *video_engine.FFmpegProcessor, clipImport *models.ClipImport, item *models.ClipImportItem, file *zip.File) (*models.AtomicClip, error) {
+	if file == nil {
+		return nil, errors.New("file missing from archive")
+	}
+
+	var entry models.ClipManifestEntry
+	if err := json.Unmarshal(item.Metadata, &entry); err != nil {
+		return nil, errors.New("invalid manifest entry")
+	}
+
+	dir, err := clipImportDir(clipImport.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// Only the base name is kept, so entries cannot escape the directory
+	filePath := filepath.Join(dir, fmt.Sprintf("import%d_%d_%s", clipImport.ID, item.ID, path.Base(file.Name)))
+	if err := extractImportFile(file, filePath); err != nil {
+		os.Remove(filePath)
+		return nil, err
+	}
+
+	clip, err := s.createClip(processor, clipImport, &entry, filePath, int64(file.UncompressedSize64))
+	if err != nil {
+		os.Remove(filePath)
+		return nil, err
+	}
+	return clip, nil
+}
+
+// createClip probes a stored video and creates its clip from the manifest
+// entry. size stands in when the probe cannot tell the file size.
+func (s *ClipImportService) createClip(processor *video_engine.FFmpegProcessor, clipImport *models.ClipImport, entry *models.ClipManifestEntry, filePath string, size int64) (*models.AtomicClip, error) {
+	info, err := processor.GetVideoInfo(filePath)
+	if err != nil {
+		return nil, errors.New("not a readable video file")
+	}
+
+	req := &models.AtomicClipCreateRequest{
+		Title:       entry.Title,
+		Description: entry.Description,
+		Category:    entry.Category,
+		Tags:        entry.Tags,
+		Mood:        entry.Mood,
+		Style:       entry.Style,
+		Color:       entry.Color,
+	}
+	fileInfo := map[string]interface{}{
+		"file_size":  info.Size,
+		"duration":   info.Duration,
+		"resolution": fmt.Sprintf("%dx%d", info.Width, info.Height),
+		"frame_rate": info.FrameRate,
+		"codec":      info.Codec,
+		"bitrate":    info.Bitrate,
+		"format":     info.Format,
+	}
+	if info.Size == 0 {
+		fileInfo["file_size"] = size
+	}
+
+	return s.clipService.CreateAtomicClip(clipImport.UserID, clipImport.OrganizationID, req, filePath, fileInfo)
+}
+
+// clipImportDir returns the directory imported videos are stored in, next
+// to the user's other uploads.
+func clipImportDir(userID uint) (string, error) {
+	dir := filepath.Join(config.AppConfig.Storage.UploadPath, "clips", fmt.Sprint(userID))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	return dir, nil
+}
+
+// CreateURLImport queues the download of a video from a URL into a clip.
+// It is an import of one file: poll it for the download progress and
+// the clip it became.
+func (s *ClipImportService) CreateURLImport(userID uint, organizationID *uint, traceID string, req *models.ClipURLImportRequest) (*models.ClipImport, error) {
+	if allowed, err := canAccess(s.db, userID, userID, organizationID, true); err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to create import")
+	} else if !allowed {
+		return nil, errors.New("insufficient organization role")
+	}
+
+	sourceURL, err := download.CheckURL(req.URL)
+	if err != nil {
+		return nil, errors.New("invalid url: " + err.Error())
+	}
+
+	name := remoteFileName(sourceURL.Path, "")
+	metadata, err := json.Marshal(models.ClipManifestEntry{
+		File:        name,
+		Title:       req.Title,
+		Description: req.Description,
+		Category:    req.Category,
+		Tags:        req.Tags,
+		Mood:        req.Mood,
+		Style:       req.Style,
+		Color:       req.Color,
+	})
+	if err != nil {
+		return nil, errors.New("failed to create import")
+	}
+
+	clipImport := &models.ClipImport{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		FileName:       name,
+		Status:         models.ImportStatusPending,
+		TotalFiles:     1,
+		TraceID:        traceID,
+		SourceURL:      sourceURL.String(),
+		Items: []models.ClipImportItem{{
+			FileName: name,
+			Status:   models.ImportItemPending,
+			Metadata: metadata,
+		}},
+	}
+	if err := s.db.Create(clipImport).Error; err != nil {
+		logger.Errorf("Failed to create import: %v", err)
+		return nil, errors.New("failed to create import")
+	}
+
+	if err := queue.PublishClipImportTask(clipImport.ID, traceID); err != nil {
+		logger.Errorf("Failed to queue import %d: %v", clipImport.ID, err)
+		s.db.Model(clipImport).Updates(map[string]interface{}{
+			"status":        models.ImportStatusFailed,
+			"error_message": "failed to queue import task",
+		})
+		return nil, errors.New("failed to queue import")
+	}
+
+	logger.Infof("Import %d queued from %s for user %d", clipImport.ID, sourceURL.Host, userID)
+	return clipImport, nil
+}
+
+func (s *ClipImportService) processURL(task *queue.Task, clipImport *models.ClipImport) error {
+	processor := video_engine.NewFFmpegProcessor(config.AppConfig)
+	for i := range clipImport.Items {
+		item := &clipImport.Items[i]
+
+		clip, err := s.importURL(processor, clipImport, item)
+		if err := s.recordItem(task, item, clip, err); err != nil {
+			return err
+		}
+	}
+
+	s.finish(clipImport, "")
+	return nil
+}
+
+// importURL downloads the video of a URL import, reporting its progress,
+// and creates its clip.
+func (s *ClipImportService) importURL(processor *video_engine.FFmpegProcessor, clipImport *models.ClipImport, item *models.ClipImportItem) (*models.AtomicClip, error) {
+	var entry models.ClipManifestEntry
+	if err := json.Unmarshal(item.Metadata, &entry); err != nil {
+		return nil, errors.New("invalid import entry")
+	}
+
+	dir, err := clipImportDir(clipImport.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// The file is named once the server has said what it sends
+	part, err := os.CreateTemp(dir, fmt.Sprintf("import%d_*.part", clipImport.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	part.Close()
+	defer os.Remove(part.Name())
+
+	storage := config.AppConfig.Storage
+	client := download.NewClient(storage.RemoteImportAllowPrivate, storage.RemoteImportTimeout)
+	var extension string
+	accept := func(resp *download.Response) error {
+		extension = remoteVideoExtension(resp, clipImport.SourceURL)
+		if extension == "" {
+			return fmt.Errorf("not a video file (%s)", resp.ContentType)
+		}
+		return nil
+	}
+	progress := func(written, size int64) {
+		if size < 0 {
+			size = 0
+		}
+		s.db.Model(clipImport).Updates(map[string]interface{}{
+			"downloaded_bytes": written,
+			"total_bytes":      size,
+		})
+		realtime.Publish(clipImport.UserID, realtime.EventImportProgress, map[string]interface{}{
+			"import_id":        clipImport.ID,
+			"downloaded_bytes": written,
+			"total_bytes":      size,
+		})
+	}
+
+	resp, err := download.ToFile(context.Background(), client, clipImport.SourceURL, part.Name(), storage.RemoteImportMaxSize, accept, progress)
+	if err != nil {
+		switch {
+		case errors.Is(err, download.ErrTooLarge):
+			return nil, fmt.Errorf("file is larger than %d bytes", storage.RemoteImportMaxSize)
+		case errors.Is(err, download.ErrBlockedAddress):
+			return nil, errors.New("URL does not point to a public address")
+		}
+		return nil, err
+	}
+
+	name := remoteFileName(entry.File, resp.FileName)
+	if path.Ext(name) == "" {
+		name += extension
+	}
+	filePath := filepath.Join(dir, fmt.Sprintf("import%d_%d_%s", clipImport.ID, item.ID, name))
+	if err := os.Rename(part.Name(), filePath); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	if entry.Title == "" {
+		entry.Title = strings.TrimSuffix(name, path.Ext(name))
+	}
+
+	var size int64
+	if stat, err := os.Stat(filePath); err == nil {
+		size = stat.Size()
+	}
+	clip, err := s.createClip(processor, clipImport, &entry, filePath, size)
+	if err != nil {
+		os.Remove(filePath)
+		return nil, err
+	}
+	return clip, nil
+}
+
+// remoteVideoExtensions are the extensions of the video types a URL import
+// downloads
+var remoteVideoExtensions = map[string]string{
+	"video/mp4":        ".mp4",
+	"video/x-m4v":      ".m4v",
+	"video/quicktime":  ".mov",
+	"video/x-msvideo":  ".avi",
+	"video/x-matroska": ".mkv",
+}
+
+// remoteVideoExtension returns the extension of a downloaded video, or an
+// empty string when it is not one. Storage services often send videos as
+// plain binary, which the extension of the URL or file name then tells.
+func remoteVideoExtension(resp *download.Response, sourceURL string) string {
+	if extension, ok := remoteVideoExtensions[resp.ContentType]; ok {
+		return extension
+	}
+	if resp.ContentType != "application/octet-stream" && resp.ContentType != "binary/octet-stream" && resp.ContentType != "" {
+		return ""
+	}
+	for _, name := range []string{resp.FileName, sourceURL} {
+		if u, err := url.Parse(name); err == nil {
+			name = u.Path
+		}
+		if extension := strings.ToLower(path.Ext(name)); importVideoExtensions[extension] {
+			return extension
+		}
+	}
+	return ""
+}
+
+// remoteFileName returns a safe file name for a download: the server's
+// name when it sent one, else the last element of the URL path.
+func remoteFileName(urlPath, serverName string) string {
+	name := path.Base(urlPath)
+	if serverName != "" {
+		name = path.Base(strings.ReplaceAll(serverName, `\`, "/"))
+	}
+	name = strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "_"), "_.")
+	if name == "" {
+		return "video"
+	}
+	if len(name) > 200 {
+		name = name[len(name)-200:]
+	}
+	return name
+}
+
+// finish records the outcome of the import and deletes its archive. An
+// errorMessage fails the whole import.
+func (s *ClipImportService) finish(clipImport *models.ClipImport, errorMessage string) {
+	var counts []struct {
+		Status string
+		Count  int
+	}
+	s.db.Model(&models.ClipImportItem{}).Select("status, COUNT(*) AS count").
+		Where("import_id = ?", clipImport.ID).Group("status").Scan(&counts)
+	for _, count := range counts {
+		switch count.Status {
+		case models.ImportItemCreated:
+			clipImport.CreatedClips = count.Count
+		case models.ImportItemFailed:
+			clipImport.FailedFiles = count.Count
+		}
+	}
+
+	now := time.Now()
+	clipImport.Status = models.ImportStatusCompleted
+	clipImport.ErrorMessage = errorMessage
+	if errorMessage != "" {
+		clipImport.Status = models.ImportStatusFailed
+	}
+	clipImport.FinishedAt = &now
+	if err := s.db.Model(clipImport).Updates(map[string]interface{}{
+		"status":        clipImport.Status,
+		"created_clips": clipImport.CreatedClips,
+		"failed_files":  clipImport.FailedFiles,
+		"error_message": clipImport.ErrorMessage,
+		"finished_at":   now,
+	}).Error; err != nil {
+		logger.Errorf("Failed to update import %d: %v", clipImport.ID, err)
+	}
+
+	if clipImport.ArchivePath != "" {
+		if err := os.Remove(clipImport.ArchivePath); err != nil && !os.IsNotExist(err) {
+			logger.Warnf("Failed to delete archive of import %d: %v", clipImport.ID, err)
+		}
+	}
+
+	realtime.Publish(clipImport.UserID, realtime.EventImportCompleted, map[string]interface{}{
+		"import_id":     clipImport.ID,
+		"status":        clipImport.Status,
+		"created_clips": clipImport.CreatedClips,
+		"failed_files":  clipImport.FailedFiles,
+	})
+	logger.Infof("Import %d %s: %d clips created, %d files failed", clipImport.ID, clipImport.Status, clipImport.CreatedClips, clipImport.FailedFiles)
+}
+
+func extractImportFile(file *zip.File, filePath string) error {
+	if file.UncompressedSize64 > maxImportFileSize {
+		return fmt.Errorf("file is larger than %d bytes", int64(maxImportFileSize))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read file from archive: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	defer dst.Close()
+
+	// The declared size can lie; stop at the limit regardless
+	written, err := io.Copy(dst, io.LimitReader(src, maxImportFileSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to extract file: %w", err)
+	}
+	if written > maxImportFileSize {
+		return fmt.Errorf("file is larger than %d bytes", int64(maxImportFileSize))
+	}
+	return nil
+}
+
+// isImportVideo skips directories, hidden files and the resource forks
+// macOS adds to archives.
+func isImportVideo(file *zip.File) bool {
+	if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") {
+		return false
+	}
+	base := path.Base(file.Name)
+	if strings.HasPrefix(base, ".") {
+		return false
+	}
+	return importVideoExtensions[strings.ToLower(path.Ext(base))]
+}
+
+// readImportManifest parses the uploaded manifest, or the one at the root
+// of the archive, into entries keyed by file name. No manifest gives no
+// entries.
+func readImportManifest(archive *zip.Reader, manifest *upload.File) (map[string]models.ClipManifestEntry, error) {
+	if manifest != nil {
+		src, err := os.Open(manifest.Path)
+		if err != nil {
+			return nil, errors.New("invalid manifest: failed to read file")
+		}
+		defer src.Close()
+		return parseImportManifest(manifest.Filename, src)
+	}
+
+	for _, name := range importManifestNames {
+		for _, file := range archive.File {
+			if file.Name != name {
+				continue
+			}
+			src, err := file.Open()
+			if err != nil {
+				return nil, errors.New("invalid manifest: failed to read file")
+			}
+			defer src.Close()
+			return parseImportManifest(name, src)
+		}
+	}
+
+	return map[string]models.ClipManifestEntry{}, nil
+}
+
+// parseImportManifest reads a JSON array of entries, or a CSV file with a
+// header row naming the entry fields; only the file column is required.
+func parseImportManifest(name string, r io.Reader) (map[string]models.ClipManifestEntry, error) {
+	var list []models.ClipManifestEntry
+
+	if strings.EqualFold(path.Ext(name), ".csv") {
+		rows, err := csv.NewReader(r).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest: %v", err)
+		}
+		if len(rows) == 0 {
+			return nil, errors.New("invalid manifest: missing header row")
+		}
+
+		columns := make(map[string]int, len(rows[0]))
+		for i, column := range rows[0] {
+			columns[strings.ToLower(strings.TrimSpace(column))] = i
+		}
+		if _, ok := columns["file"]; !ok {
+			return nil, errors.New("invalid manifest: missing file column")
+		}
+		field := func(row []string, column string) string {
+			if i, ok := columns[column]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		for _, row := range rows[1:] {
+			entry := models.ClipManifestEntry{
+				File:        field(row, "file"),
+				Title:       field(row, "title"),
+				Description: field(row, "description"),
+				Category:    field(row, "category"),
+				Mood:        field(row, "mood"),
+				Style:       field(row, "style"),
+				Color:       field(row, "color"),
+			}
+			if tags := field(row, "tags"); tags != "" {
+				entry.Tags = []string{tags}
+			}
+			list = append(list, entry)
+		}
+	} else if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+
+	entries := make(map[string]models.ClipManifestEntry, len(list))
+	for _, entry := range list {
+		if entry.File == "" {
+			return nil, errors.New("invalid manifest: every entry needs a file")
+		}
+		if len(entry.Title) > 200 {
+			return nil, fmt.Errorf("invalid manifest: title of %s is longer than 200 characters", entry.File)
+		}
+		entries[entry.File] = entry
+	}
+	return entries, nil
+}
@@ -0,0 +1,389 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+type CollectionService struct {
+	db *gorm.DB
+}
+
+func NewCollectionService() *CollectionService {
+	return &CollectionService{
+		db: database.GetDB(),
+	}
+}
+
+// CreateCollection adds a collection to the user's personal workspace, or
+// to the organization's when organizationID is set, holding the given clips
+// in order.
+func (s *CollectionService) CreateCollection(userID uint, organizationID *uint, req *models.CollectionCreateRequest) (*models.Collection, error) {
+	if allowed, err := canAccess(s.db, userID, userID, organizationID, true); err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to create collection")
+	} else if !allowed {
+		return nil, errors.New("insufficient organization role")
+	}
+
+	collection := &models.Collection{
+		Name:           req.Name,
+		Description:    req.Description,
+		UserID:         userID,
+		OrganizationID: organizationID,
+	}
+
+	clipIDs := uniqueClipIDs(req.ClipIDs)
+	if err := s.checkClips(collection, clipIDs); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(collection).Error; err != nil {
+			return err
+		}
+		return insertCollectionItems(tx, collection.ID, clipIDs, 0)
+	})
+	if err != nil {
+		logger.Errorf("Failed to create collection: %v", err)
+		return nil, errors.New("failed to create collection")
+	}
+	collection.ClipCount = int64(len(clipIDs))
+
+	return collection, nil
+}
+
+// ListCollections returns a page of the workspace's collections, most
+// recently changed first, with their clip counts.
+func (s *CollectionService) ListCollections(userID uint, organizationID *uint, req *models.CollectionListRequest) ([]models.Collection, int64, error) {
+	query := s.db.Model(&models.Collection{}).Scopes(workspaceScope(userID, organizationID))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count collections: %w", err)
+	}
+
+	var collections []models.Collection
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order("updated_at DESC").Offset(offset).Limit(req.Limit).Find(&collections).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get collections: %w", err)
+	}
+	if len(collections) == 0 {
+		return collections, total, nil
+	}
+
+	ids := make([]uint, len(collections))
+	for i := range collections {
+		ids[i] = collections[i].ID
+	}
+	var counts []struct {
+		CollectionID uint
+		Count        int64
+	}
+	if err := s.db.Model(&models.CollectionItem{}).Select("collection_id, COUNT(*) AS count").
+		Where("collection_id IN ?", ids).Group("collection_id").Scan(&counts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count collection clips: %w", err)
+	}
+	byID := make(map[uint]int64, len(counts))
+	for _, count := range counts {
+		byID[count.CollectionID] = count.Count
+	}
+	for i := range collections {
+		collections[i].ClipCount = byID[collections[i].ID]
+	}
+
+	return collections, total, nil
+}
+
+// GetCollection returns a collection the user can read with its clips in
+// order.
+func (s *CollectionService) GetCollection(collectionID, userID uint) (*models.Collection, error) {
+	var collection models.Collection
+	err := s.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Preload("Items.AtomicClip").First(&collection, collectionID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("collection not found")
+		}
+		logger.Errorf("Failed to get collection: %v", err)
+		return nil, errors.New("failed to get collection")
+	}
+
+	if err := s.checkAccess(&collection, userID, false); err != nil {
+		return nil, err
+	}
+	collection.ClipCount = int64(len(collection.Items))
+
+	return &collection, nil
+}
+
+// getWritableCollection returns the collection without its clips when the
+// user may change it.
+func (s *CollectionService) getWritableCollection(collectionID, userID uint) (*models.Collection, error) {
+	var collection models.Collection
+	if err := s.db.First(&collection, collectionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("collection not found")
+		}
+		logger.Errorf("Failed to get collection: %v", err)
+		return nil, errors.New("failed to get collection")
+	}
+
+	if err := s.checkAccess(&collection, userID, true); err != nil {
+		return nil, err
+	}
+
+	return &collection, nil
+}
+
+// checkAccess hides collections the user cannot read, and refuses changes
+// to team collections from members who may only view them.
+func (s *CollectionService) checkAccess(collection *models.Collection, userID uint, write bool) error {
+	readable, err := canAccess(s.db, userID, collection.UserID, collection.OrganizationID, false)
+	if err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return errors.New("failed to get collection")
+	}
+	if !readable {
+		return errors.New("collection not found")
+	}
+
+	if write {
+		writable, err := canAccess(s.db, userID, collection.UserID, collection.OrganizationID, true)
+		if err != nil {
+			logger.Errorf("Failed to check organization access: %v", err)
+			return errors.New("failed to get collection")
+		}
+		if !writable {
+			return errors.New("insufficient organization role")
+		}
+	}
+
+	return nil
+}
+
+func (s *CollectionService) UpdateCollection(collectionID, userID uint, req *models.CollectionUpdateRequest) (*models.Collection, error) {
+	collection, err := s.getWritableCollection(collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) > 0 {
+		if err := s.db.Model(collection).Updates(updates).Error; err != nil {
+			logger.Errorf("Failed to update collection: %v", err)
+			return nil, errors.New("failed to update collection")
+		}
+	}
+
+	return s.GetCollection(collection.ID, userID)
+}
+
+// DeleteCollection deletes the collection; its clips stay in the library.
+func (s *CollectionService) DeleteCollection(collectionID, userID uint) error {
+	collection, err := s.getWritableCollection(collectionID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collection.ID).Delete(&models.CollectionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(collection).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to delete collection: %v", err)
+		return errors.New("failed to delete collection")
+	}
+
+	return nil
+}
+
+// AddClips appends clips to the end of the collection, skipping those
+// already in it.
+func (s *CollectionService) AddClips(collectionID, userID uint, clipIDs []uint) (*models.Collection, error) {
+	collection, err := s.getWritableCollection(collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	clipIDs = uniqueClipIDs(clipIDs)
+	if err := s.checkClips(collection, clipIDs); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return appendCollectionItems(tx, collection, clipIDs)
+	}); err != nil {
+		if errors.Is(err, errCollectionFull) {
+			return nil, err
+		}
+		logger.Errorf("Failed to add clips to collection: %v", err)
+		return nil, errors.New("failed to update collection")
+	}
+
+	return s.GetCollection(collection.ID, userID)
+}
+
+var errCollectionFull = fmt.Errorf("a collection holds at most %d clips", models.MaxCollectionClips)
+
+// appendCollectionItems adds the clips missing from the collection after its
+// last clip. The clips must already be checked against its workspace.
+func appendCollectionItems(tx *gorm.DB, collection *models.Collection, clipIDs []uint) error {
+	var existing []models.CollectionItem
+	if err := tx.Where("collection_id = ?", collection.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	present := make(map[uint]bool, len(existing))
+	next := 0
+	for _, item := range existing {
+		present[item.AtomicClipID] = true
+		if item.Position >= next {
+			next = item.Position + 1
+		}
+	}
+
+	added := make([]uint, 0, len(clipIDs))
+	for _, id := range clipIDs {
+		if !present[id] {
+			added = append(added, id)
+		}
+	}
+	if len(existing)+len(added) > models.MaxCollectionClips {
+		return errCollectionFull
+	}
+
+	if err := insertCollectionItems(tx, collection.ID, added, next); err != nil {
+		return err
+	}
+	return tx.Model(collection).Update("updated_at", time.Now()).Error
+}
+
+// SetClips replaces the collection's clips with the given ones, in the
+// given order; used to reorder it.
+func (s *CollectionService) SetClips(collectionID, userID uint, clipIDs []uint) (*models.Collection, error) {
+	collection, err := s.getWritableCollection(collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	clipIDs = uniqueClipIDs(clipIDs)
+	if err := s.checkClips(collection, clipIDs); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collection.ID).Delete(&models.CollectionItem{}).Error; err != nil {
+			return err
+		}
+		if err := insertCollectionItems(tx, collection.ID, clipIDs, 0); err != nil {
+			return err
+		}
+		return tx.Model(collection).Update("updated_at", time.Now()).Error
+	}); err != nil {
+		logger.Errorf("Failed to set collection clips: %v", err)
+		return nil, errors.New("failed to update collection")
+	}
+
+	return s.GetCollection(collection.ID, userID)
+}
+
+// RemoveClip takes a clip out of the collection, closing the gap it leaves.
+func (s *CollectionService) RemoveClip(collectionID, userID, clipID uint) (*models.Collection, error) {
+	collection, err := s.getWritableCollection(collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var item models.CollectionItem
+	if err := s.db.Where("collection_id = ? AND atomic_clip_id = ?", collection.ID, clipID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("clip is not in the collection")
+		}
+		logger.Errorf("Failed to get collection clip: %v", err)
+		return nil, errors.New("failed to update collection")
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.CollectionItem{}).
+			Where("collection_id = ? AND position > ?", collection.ID, item.Position).
+			Update("position", gorm.Expr("position - 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(collection).Update("updated_at", time.Now()).Error
+	}); err != nil {
+		logger.Errorf("Failed to remove clip from collection: %v", err)
+		return nil, errors.New("failed to update collection")
+	}
+
+	return s.GetCollection(collection.ID, userID)
+}
+
+// checkClips makes sure every clip belongs to the collection's workspace,
+// so a collection never exposes clips its readers could not see.
+func (s *CollectionService) checkClips(collection *models.Collection, clipIDs []uint) error {
+	if len(clipIDs) > models.MaxCollectionClips {
+		return errCollectionFull
+	}
+	if len(clipIDs) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.AtomicClip{}).Scopes(workspaceScope(collection.UserID, collection.OrganizationID)).
+		Where("id IN ?", clipIDs).Count(&count).Error; err != nil {
+		logger.Errorf("Failed to check collection clips: %v", err)
+		return errors.New("failed to check clips")
+	}
+	if count != int64(len(clipIDs)) {
+		return errors.New("one or more clips not found")
+	}
+
+	return nil
+}
+
+func insertCollectionItems(tx *gorm.DB, collectionID uint, clipIDs []uint, position int) error {
+	if len(clipIDs) == 0 {
+		return nil
+	}
+
+	items := make([]models.CollectionItem, len(clipIDs))
+	for i, clipID := range clipIDs {
+		items[i] = models.CollectionItem{
+			CollectionID: collectionID,
+			AtomicClipID: clipID,
+			Position:     position + i,
+		}
+	}
+	return tx.Create(&items).Error
+}
+
+// uniqueClipIDs drops repeated IDs, keeping the first occurrence's place.
+func uniqueClipIDs(clipIDs []uint) []uint {
+	seen := make(map[uint]bool, len(clipIDs))
+	unique := make([]uint, 0, len(clipIDs))
+	for _, id := range clipIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/realtime"
+)
+
+// mentionPattern finds @username mentions in a comment
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w+(?:[.-]\w+)*)`)
+
+// timestampTolerance absorbs rounding in timestamps sent by players
+const timestampTolerance = 0.5
+
+type CommentService struct {
+	db *gorm.DB
+}
+
+func NewCommentService() *CommentService {
+	return &CommentService{
+		db: database.GetDB(),
+	}
+}
+
+// commentTarget is the clip or render comments are left on.
+type commentTarget struct {
+	resourceType string
+	resourceID   uint
+	title        string
+	duration     float64
+	ownerID      uint
+	orgID        *uint
+}
+
+// getTarget returns the clip or render when the user can read it. Renders
+// are only visible to the user who started them.
+func (s *CommentService) getTarget(resourceType string, resourceID, userID uint) (*commentTarget, error) {
+	switch resourceType {
+	case models.CommentResourceClip:
+		clip, err := NewAtomicClipService().GetAtomicClipByID(resourceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &commentTarget{
+			resourceType: resourceType,
+			resourceID:   clip.ID,
+			title:        clip.Title,
+			duration:     clip.Duration,
+			ownerID:      clip.UserID,
+			orgID:        clip.OrganizationID,
+		}, nil
+	case models.CommentResourceRender:
+		task, err := NewRenderTaskService().GetRenderTaskByID(resourceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &commentTarget{
+			resourceType: resourceType,
+			resourceID:   task.ID,
+			title:        task.TaskID,
+			duration:     task.Duration,
+			ownerID:      task.UserID,
+		}, nil
+	}
+	return nil, errors.New("invalid comment resource")
+}
+
+// readable reports whether the user can read the target, so they may be
+// told about comments on it.
+func (t *commentTarget) readable(db *gorm.DB, userID uint) (bool, error) {
+	if t.resourceType == models.CommentResourceRender {
+		return userID == t.ownerID, nil
+	}
+	return canAccess(db, userID, t.ownerID, t.orgID, false)
+}
+
+func (t *commentTarget) checkTimestamp(timestamp float64) error {
+	if t.duration > 0 && timestamp > t.duration+timestampTolerance {
+		return errors.New("timestamp is past the end of the video")
+	}
+	return nil
+}
+
+// ListComments returns the comments on a clip or render in video order.
+func (s *CommentService) ListComments(resourceType string, resourceID, userID uint, req *models.CommentListRequest) ([]models.Comment, int64, error) {
+	if _, err := s.getTarget(resourceType, resourceID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.Model(&models.Comment{}).Where("resource_type = ? AND resource_id = ?", resourceType, resourceID)
+	if req.Resolved != nil {
+		if *req.Resolved {
+			query = query.Where("resolved_at IS NOT NULL")
+		} else {
+			query = query.Where("resolved_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("Failed to count comments: %v", err)
+		return nil, 0, errors.New("failed to get comments")
+	}
+
+	var comments []models.Comment
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Preload("User").Order("timestamp ASC, created_at ASC").
+		Offset(offset).Limit(req.Limit).Find(&comments).Error; err != nil {
+		logger.Errorf("Failed to get comments: %v", err)
+		return nil, 0, errors.New("failed to get comments")
+	}
+
+	return comments, total, nil
+}
+
+// CreateComment leaves a comment on a clip or render the user can read and
+// notifies the users it mentions.
+func (s *CommentService) CreateComment(resourceType string, resourceID, userID uint, req *models.CommentCreateRequest) (*models.Comment, error) {
+	target, err := s.getTarget(resourceType, resourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := target.checkTimestamp(*req.Timestamp); err != nil {
+		return nil, err
+	}
+
+	mentioned, err := s.mentionedUsers(target, req.Body, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &models.Comment{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		UserID:       userID,
+		Body:         req.Body,
+		Timestamp:    *req.Timestamp,
+		Region:       req.Region,
+		Mentions:     usernames(mentioned),
+	}
+	if err := s.db.Create(comment).Error; err != nil {
+		logger.Errorf("Failed to create comment: %v", err)
+		return nil, errors.New("failed to create comment")
+	}
+
+	s.notifyMentions(target, comment, mentioned)
+	return s.getComment(comment.ID)
+}
+
+// UpdateComment changes the author's comment. Users it newly mentions are
+// notified.
+func (s *CommentService) UpdateComment(commentID, userID uint, req *models.CommentUpdateRequest) (*models.Comment, error) {
+	comment, target, err := s.getOwnComment(commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var mentioned []models.User
+	if req.Body != "" {
+		users, err := s.mentionedUsers(target, req.Body, userID)
+		if err != nil {
+			return nil, err
+		}
+		previous := make(map[string]bool, len(comment.Mentions))
+		for _, username := range comment.Mentions {
+			previous[username] = true
+		}
+		for _, user := range users {
+			if !previous[user.Username] {
+				mentioned = append(mentioned, user)
+			}
+		}
+		comment.Body = req.Body
+		comment.Mentions = usernames(users)
+	}
+	if req.Timestamp != nil {
+		if err := target.checkTimestamp(*req.Timestamp); err != nil {
+			return nil, err
+		}
+		comment.Timestamp = *req.Timestamp
+	}
+	if req.Region != nil {
+		comment.Region = req.Region
+	}
+
+	if err := s.db.Omit("User").Save(comment).Error; err != nil {
+		logger.Errorf("Failed to update comment: %v", err)
+		return nil, errors.New("failed to update comment")
+	}
+
+	s.notifyMentions(target, comment, mentioned)
+	return s.getComment(comment.ID)
+}
+
+// DeleteComment deletes the author's comment.
+func (s *CommentService) DeleteComment(commentID, userID uint) error {
+	comment, _, err := s.getOwnComment(commentID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(comment).Error; err != nil {
+		logger.Errorf("Failed to delete comment: %v", err)
+		return errors.New("failed to delete comment")
+	}
+	return nil
+}
+
+// SetResolved resolves or reopens a comment. Anyone who can read what it
+// is on may, as reviewers resolve each other's comments.
+func (s *CommentService) SetResolved(commentID, userID uint, resolved bool) (*models.Comment, error) {
+	comment, err := s.getComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getTarget(comment.ResourceType, comment.ResourceID, userID); err != nil {
+		return nil, errors.New("comment not found")
+	}
+
+	if resolved == (comment.ResolvedAt != nil) {
+		if resolved {
+			return nil, errors.New("comment is already resolved")
+		}
+		return nil, errors.New("comment is not resolved")
+	}
+
+	updates := map[string]interface{}{"resolved_at": nil, "resolved_by": nil}
+	if resolved {
+		updates["resolved_at"] = time.Now()
+		updates["resolved_by"] = userID
+	}
+	if err := s.db.Model(comment).Updates(updates).Error; err != nil {
+		logger.Errorf("Failed to update comment: %v", err)
+		return nil, errors.New("failed to update comment")
+	}
+
+	return s.getComment(comment.ID)
+}
+
+func (s *CommentService) getComment(commentID uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := s.db.Preload("User").First(&comment, commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("comment not found")
+		}
+		logger.Errorf("Failed to get comment: %v", err)
+		return nil, errors.New("failed to get comment")
+	}
+	return &comment, nil
+}
+
+// getOwnComment returns a comment the user wrote on something they can
+// still read.
+func (s *CommentService) getOwnComment(commentID, userID uint) (*models.Comment, *commentTarget, error) {
+	comment, err := s.getComment(commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	target, err := s.getTarget(comment.ResourceType, comment.ResourceID, userID)
+	if err != nil {
+		return nil, nil, errors.New("comment not found")
+	}
+	if comment.UserID != userID {
+		return nil, nil, errors.New("only the author can change a comment")
+	}
+	return comment, target, nil
+}
+
+// mentionedUsers returns the users mentioned in the body who can read the
+// target, leaving out the author.
+func (s *CommentService) mentionedUsers(target *commentTarget, body string, authorID uint) ([]models.User, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var users []models.User
+	if err := s.db.Where("username IN ? AND id <> ? AND is_active = ?", names, authorID, true).Find(&users).Error; err != nil {
+		logger.Errorf("Failed to get mentioned users: %v", err)
+		return nil, errors.New("failed to create comment")
+	}
+
+	mentioned := make([]models.User, 0, len(users))
+	for _, user := range users {
+		readable, err := target.readable(s.db, user.ID)
+		if err != nil {
+			logger.Errorf("Failed to check organization access: %v", err)
+			return nil, errors.New("failed to create comment")
+		}
+		if readable {
+			mentioned = append(mentioned, user)
+		}
+	}
+	return mentioned, nil
+}
+
+func (s *CommentService) notifyMentions(target *commentTarget, comment *models.Comment, users []models.User) {
+	for _, user := range users {
+		realtime.Publish(user.ID, realtime.EventCommentMention, map[string]interface{}{
+			"comment_id":    comment.ID,
+			"resource_type": comment.ResourceType,
+			"resource_id":   comment.ResourceID,
+			"title":         target.title,
+			"timestamp":     comment.Timestamp,
+			"author_id":     comment.UserID,
+			"body":          comment.Body,
+		})
+	}
+}
+
+func usernames(users []models.User) models.StringArray {
+	names := make(models.StringArray, len(users))
+	for i, user := range users {
+		names[i] = user.Username
+	}
+	return names
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/queue"
+	"creative-studio-server/pkg/video_engine"
+)
+
+type CompositionService struct {
+	db                *gorm.DB
+	projectService    *ProjectService
+	collectionService *CollectionService
+}
+
+func NewCompositionService() *CompositionService {
+	return &CompositionService{
+		db:                database.GetDB(),
+		projectService:    NewProjectService(),
+		collectionService: NewCollectionService(),
+	}
+}
+
+// GenerateComposition creates a pending composition for the project and queues
+// a smart composition task that fills it (plus any extra variants). With a
+// collection only its clips are drawn from; stock clips are added to either
+// when asked for.
+func (s *CompositionService) GenerateComposition(projectID, userID uint, traceID string, req *models.CompositionCreateRequest) (*models.Composition, error) {
+	project, err := s.projectService.GetProjectByID(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CollectionID != nil {
+		collection, err := s.collectionService.GetCollection(*req.CollectionID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(collection.Items) == 0 {
+			return nil, errors.New("collection is empty")
+		}
+	}
+
+	requirements, err := json.Marshal(req.Requirements)
+	if err != nil {
+		return nil, errors.New("invalid composition requirements")
+	}
+
+	var parsed video_engine.CompositionRequirements
+	if err := json.Unmarshal(requirements, &parsed); err != nil {
+		return nil, errors.New("invalid composition requirements")
+	}
+	if err := parsed.Constraints.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid composition constraints: %w", err)
+	}
+
+	algorithm := "smart_selection"
+	if len(req.Algorithms) > 0 {
+		algorithm = req.Algorithms[0]
+	}
+
+	composition := &models.Composition{
+		ProjectID:    project.ID,
+		UserID:       userID,
+		Status:       models.CompositionStatusPending,
+		Algorithm:    algorithm,
+		Requirements: requirements,
+		TraceID:      traceID,
+		CollectionID: req.CollectionID,
+		IncludeStock: req.IncludeStock,
+	}
+
+	if err := s.db.Create(composition).Error; err != nil {
+		logger.Errorf("Failed to create composition: %v", err)
+		return nil, errors.New("failed to create composition")
+	}
+
+	if err := s.enqueue(composition, req.Requirements, req.Variants, req.Algorithms); err != nil {
+		return nil, err
+	}
+
+	return composition, nil
+}
+
+// RegenerateComposition queues a fresh composition using the same
+// requirements and algorithm as an existing one.
+func (s *CompositionService) RegenerateComposition(compositionID, userID uint, traceID string) (*models.Composition, error) {
+	source, err := s.GetCompositionByID(compositionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var requirements map[string]interface{}
+	if len(source.Requirements) > 0 {
+		if err := json.Unmarshal(source.Requirements, &requirements); err != nil {
+			return nil, errors.New("invalid composition requirements")
+		}
+	}
+	// Drop the stored seed so the regenerated composition differs, and
+	// record the requirements it is actually composed from
+	delete(requirements, "seed")
+	stored, err := json.Marshal(requirements)
+	if err != nil {
+		return nil, errors.New("invalid composition requirements")
+	}
+
+	composition := &models.Composition{
+		ProjectID:    source.ProjectID,
+		UserID:       source.UserID,
+		Status:       models.CompositionStatusPending,
+		Algorithm:    source.Algorithm,
+		Requirements: stored,
+		TraceID:      traceID,
+		CollectionID: source.CollectionID,
+		IncludeStock: source.IncludeStock,
+	}
+
+	if err := s.db.Create(composition).Error; err != nil {
+		logger.Errorf("Failed to create composition: %v", err)
+		return nil, errors.New("failed to create composition")
+	}
+
+	if err := s.enqueue(composition, requirements, 1, []string{source.Algorithm}); err != nil {
+		return nil, err
+	}
+
+	return composition, nil
+}
+
+func (s *CompositionService) enqueue(composition *models.Composition, requirements map[string]interface{}, variants int, algorithms []string) error {
+	if variants <= 0 {
+		variants = 1
+	}
+
+	if err := queue.PublishSmartCompositionTask(composition.ID, composition.ProjectID, composition.CollectionID, composition.IncludeStock, requirements, variants, algorithms, composition.TraceID); err != nil {
+		logger.Errorf("Failed to queue composition %d: %v", composition.ID, err)
+		s.db.Model(composition).Updates(map[string]interface{}{
+			"status":        models.CompositionStatusFailed,
+			"error_message": "failed to queue composition task",
+		})
+		return errors.New("failed to queue composition task")
+	}
+
+	return nil
+}
+
+func (s *CompositionService) GetCompositionByID(compositionID, userID uint) (*models.Composition, error) {
+	var composition models.Composition
+	query := s.db
+
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if err := query.First(&composition, compositionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("composition not found")
+		}
+		logger.Errorf("Failed to get composition: %v", err)
+		return nil, errors.New("failed to get composition")
+	}
+
+	return &composition, nil
+}
+
+func (s *CompositionService) ListProjectCompositions(projectID, userID uint, page, limit int) ([]models.Composition, int64, error) {
+	if _, err := s.projectService.GetProjectByID(projectID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	var compositions []models.Composition
+	var total int64
+
+	query := s.db.Model(&models.Composition{}).Where("project_id = ?", projectID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count compositions: %w", err)
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&compositions).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get compositions: %w", err)
+	}
+
+	return compositions, total, nil
+}
+
+// PromoteComposition replaces the project timeline with the composition.
+func (s *CompositionService) PromoteComposition(compositionID, userID uint) (*models.Project, error) {
+	composition, err := s.GetCompositionByID(compositionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if composition.Status != models.CompositionStatusCompleted {
+		return nil, errors.New("composition is not ready")
+	}
+
+	project, err := s.projectService.GetProjectByID(composition.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := video_engine.CompositionResultFromModel(composition)
+	if err != nil {
+		logger.Errorf("Failed to decode composition %d: %v", composition.ID, err)
+		return nil, errors.New("failed to read composition")
+	}
+
+	timeline, err := result.ProjectTimeline()
+	if err != nil {
+		logger.Errorf("Failed to build timeline from composition %d: %v", composition.ID, err)
+		return nil, errors.New("failed to read composition")
+	}
+	timeline.CompositionID = &composition.ID
+
+	timelineJSON, err := timeline.ToJSON()
+	if err != nil {
+		return nil, errors.New("failed to read composition")
+	}
+
+	now := time.Now()
+	var usedClips []uint
+	err = s.db.Transaction(func(tx *gorm.DB) (err error) {
+		if usedClips, err = updateClipUsage(tx, project.ID, timelineJSON); err != nil {
+			return err
+		}
+		project.Timeline = timelineJSON
+		project.Duration = timeline.Duration
+		project.Version++
+		if err := tx.Omit("User", "Template", "RenderTasks").Save(project).Error; err != nil {
+			return err
+		}
+		if err := recordClipUsages(tx, project.ID, timelineJSON); err != nil {
+			return err
+		}
+		revision := newRevision(project, userID)
+		revision.Version = project.Version
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		return tx.Model(composition).Update("promoted_at", now).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to promote composition %d: %v", composition.ID, err)
+		return nil, errors.New("failed to promote composition")
+	}
+	cache.InvalidateProject(project.ID)
+	invalidateClips(usedClips)
+
+	logger.Infof("Composition %d promoted to project %d timeline", composition.ID, project.ID)
+	return project, nil
+}
+
+// RecordFeedback compares the user's final clip order with the clips the
+// composition selected and stores one feedback event per affected clip.
+func (s *CompositionService) RecordFeedback(compositionID, userID uint, finalClipIDs []uint) ([]models.CompositionFeedback, error) {
+	composition, err := s.GetCompositionByID(compositionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if composition.Status != models.CompositionStatusCompleted {
+		return nil, errors.New("composition is not ready")
+	}
+
+	result, err := video_engine.CompositionResultFromModel(composition)
+	if err != nil {
+		logger.Errorf("Failed to decode composition %d: %v", composition.ID, err)
+		return nil, errors.New("failed to read composition")
+	}
+
+	var requirements struct {
+		Theme string `json:"theme"`
+	}
+	if len(composition.Requirements) > 0 {
+		json.Unmarshal(composition.Requirements, &requirements)
+	}
+
+	originalPositions := make(map[uint]int, len(result.SelectedClips))
+	clipIDs := make([]uint, 0, len(result.SelectedClips)+len(finalClipIDs))
+	for i, segment := range result.SelectedClips {
+		originalPositions[segment.ClipID] = i
+		clipIDs = append(clipIDs, segment.ClipID)
+	}
+
+	finalPositions := make(map[uint]int, len(finalClipIDs))
+	for i, clipID := range finalClipIDs {
+		finalPositions[clipID] = i
+		clipIDs = append(clipIDs, clipID)
+	}
+
+	var clips []models.AtomicClip
+	if err := s.db.Select("id", "category").Where("id IN ?", clipIDs).Find(&clips).Error; err != nil {
+		return nil, fmt.Errorf("failed to load clips: %w", err)
+	}
+	categories := make(map[uint]string, len(clips))
+	for _, clip := range clips {
+		categories[clip.ID] = clip.Category
+	}
+
+	newFeedback := func(clipID uint, action string, original, final int) models.CompositionFeedback {
+		return models.CompositionFeedback{
+			CompositionID:    composition.ID,
+			UserID:           userID,
+			Theme:            requirements.Theme,
+			ClipID:           clipID,
+			Category:         categories[clipID],
+			Action:           action,
+			OriginalPosition: original,
+			FinalPosition:    final,
+		}
+	}
+
+	var feedback []models.CompositionFeedback
+	for _, segment := range result.SelectedClips {
+		original := originalPositions[segment.ClipID]
+		final, kept := finalPositions[segment.ClipID]
+		switch {
+		case !kept:
+			feedback = append(feedback, newFeedback(segment.ClipID, models.FeedbackActionRemoved, original, -1))
+		case final == original:
+			feedback = append(feedback, newFeedback(segment.ClipID, models.FeedbackActionKept, original, final))
+		default:
+			feedback = append(feedback, newFeedback(segment.ClipID, models.FeedbackActionReordered, original, final))
+		}
+	}
+	for _, clipID := range finalClipIDs {
+		if _, selected := originalPositions[clipID]; !selected {
+			feedback = append(feedback, newFeedback(clipID, models.FeedbackActionAdded, -1, finalPositions[clipID]))
+		}
+	}
+
+	if len(feedback) == 0 {
+		return feedback, nil
+	}
+
+	if err := s.db.Create(&feedback).Error; err != nil {
+		logger.Errorf("Failed to store composition feedback: %v", err)
+		return nil, errors.New("failed to store composition feedback")
+	}
+
+	logger.Infof("Recorded %d feedback events for composition %d", len(feedback), composition.ID)
+	return feedback, nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/interchange"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/video_engine"
+)
+
+// unsafeFilenameChars are replaced in the names of exported and downloaded
+// files
+var unsafeFilenameChars = regexp.MustCompile(`[^\w.-]+`)
+
+type ExportService struct {
+	db                 *gorm.DB
+	projectService     *ProjectService
+	compositionService *CompositionService
+}
+
+func NewExportService() *ExportService {
+	return &ExportService{
+		db:                 database.GetDB(),
+		projectService:     NewProjectService(),
+		compositionService: NewCompositionService(),
+	}
+}
+
+// ExportProject writes the project timeline in an interchange format and
+// returns it with a file name for it.
+func (s *ExportService) ExportProject(projectID, userID uint, format string) ([]byte, string, error) {
+	project, err := s.projectService.GetProjectByID(projectID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	timeline, err := models.ParseProjectTimeline(project.Timeline)
+	if err != nil {
+		logger.Errorf("Failed to parse timeline of project %d: %v", project.ID, err)
+		return nil, "", errors.New("failed to export timeline")
+	}
+
+	return s.export(project, project.Title, timeline, format)
+}
+
+// ExportComposition writes a completed composition, on its project's
+// canvas, in an interchange format.
+func (s *ExportService) ExportComposition(compositionID, userID uint, format string) ([]byte, string, error) {
+	composition, err := s.compositionService.GetCompositionByID(compositionID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if composition.Status != models.CompositionStatusCompleted {
+		return nil, "", errors.New("composition is not ready")
+	}
+
+	project, err := s.projectService.GetProjectByID(composition.ProjectID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result, err := video_engine.CompositionResultFromModel(composition)
+	if err != nil {
+		logger.Errorf("Failed to decode composition %d: %v", composition.ID, err)
+		return nil, "", errors.New("failed to read composition")
+	}
+	timeline, err := result.ProjectTimeline()
+	if err != nil {
+		logger.Errorf("Failed to build timeline from composition %d: %v", composition.ID, err)
+		return nil, "", errors.New("failed to read composition")
+	}
+
+	name := fmt.Sprintf("%s - composition %d", project.Title, composition.ID)
+	return s.export(project, name, timeline, format)
+}
+
+// export converts the timeline. Media is referenced by file name, which
+// editors relink to their copy of the clips.
+func (s *ExportService) export(project *models.Project, name string, timeline *models.ProjectTimeline, format string) ([]byte, string, error) {
+	var clips []models.AtomicClip
+	if ids := timeline.ClipIDs(); len(ids) > 0 {
+		// Deleted clips are still named, so the cut can be rebuilt
+		if err := s.db.Unscoped().Where("id IN ?", ids).Find(&clips).Error; err != nil {
+			logger.Errorf("Failed to get timeline clips: %v", err)
+			return nil, "", errors.New("failed to export timeline")
+		}
+	}
+	byID := make(map[uint]models.AtomicClip, len(clips))
+	for _, clip := range clips {
+		byID[clip.ID] = clip
+	}
+
+	frameRate := project.FrameRate
+	if frameRate <= 0 {
+		frameRate = 30
+	}
+	edit := &interchange.Timeline{
+		Name:      name,
+		FrameRate: frameRate,
+		Width:     project.Width,
+		Height:    project.Height,
+	}
+	for _, entry := range timeline.Clips {
+		clip := interchange.Clip{
+			ID:       entry.ClipID,
+			Name:     fmt.Sprintf("Clip %d", entry.ClipID),
+			Position: entry.Position,
+			In:       entry.StartTime,
+			Out:      entry.EndTime,
+		}
+		if source, ok := byID[entry.ClipID]; ok {
+			clip.Name = source.Title
+			clip.MediaURL = filepath.Base(source.FilePath)
+			clip.SourceDuration = source.Duration
+		}
+		edit.Clips = append(edit.Clips, clip)
+	}
+	for _, transition := range timeline.Transitions {
+		edit.Transitions = append(edit.Transitions, interchange.Transition{
+			Type:     transition.Type,
+			Position: transition.Position,
+			Duration: transition.Duration,
+		})
+	}
+
+	data, err := interchange.Export(format, edit)
+	if err != nil {
+		logger.Errorf("Failed to export timeline as %s: %v", format, err)
+		return nil, "", errors.New("failed to export timeline")
+	}
+
+	filename := strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "_"), "_")
+	if filename == "" {
+		filename = "timeline"
+	}
+	return data, filename + interchange.Extension(format), nil
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+type FavoriteService struct {
+	db          *gorm.DB
+	clipService *AtomicClipService
+}
+
+func NewFavoriteService() *FavoriteService {
+	return &FavoriteService{
+		db:          database.GetDB(),
+		clipService: NewAtomicClipService(),
+	}
+}
+
+// checkTarget makes sure the user can see the clip or template.
+func (s *FavoriteService) checkTarget(targetType string, targetID, userID uint) error {
+	switch targetType {
+	case models.FavoriteTargetClip:
+		_, err := s.clipService.GetAtomicClipByID(targetID, userID)
+		return err
+	case models.FavoriteTargetTemplate:
+		_, err := getAccessibleTemplate(s.db, targetID, userID)
+		return err
+	}
+	return fmt.Errorf("unknown favorite target %q", targetType)
+}
+
+// Favorite bookmarks the clip or template; favoriting it again is a no-op.
+func (s *FavoriteService) Favorite(targetType string, targetID, userID uint) (*models.Engagement, error) {
+	if err := s.checkTarget(targetType, targetID, userID); err != nil {
+		return nil, err
+	}
+
+	favorite := models.Favorite{UserID: userID, TargetType: targetType, TargetID: targetID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(favorite).FirstOrCreate(&favorite).Error; err != nil {
+			return err
+		}
+		return refreshEngagement(tx, targetType, targetID)
+	})
+	if err != nil {
+		logger.Errorf("Failed to favorite %s %d: %v", targetType, targetID, err)
+		return nil, errors.New("failed to update favorites")
+	}
+	if targetType == models.FavoriteTargetClip {
+		cache.InvalidateClip(targetID)
+	}
+
+	return s.engagement(targetType, targetID, userID)
+}
+
+func (s *FavoriteService) Unfavorite(targetType string, targetID, userID uint) (*models.Engagement, error) {
+	if err := s.checkTarget(targetType, targetID, userID); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+			Delete(&models.Favorite{}).Error; err != nil {
+			return err
+		}
+		return refreshEngagement(tx, targetType, targetID)
+	})
+	if err != nil {
+		logger.Errorf("Failed to unfavorite %s %d: %v", targetType, targetID, err)
+		return nil, errors.New("failed to update favorites")
+	}
+	if targetType == models.FavoriteTargetClip {
+		cache.InvalidateClip(targetID)
+	}
+
+	return s.engagement(targetType, targetID, userID)
+}
+
+// Rate records the user's score, replacing an earlier one.
+func (s *FavoriteService) Rate(targetType string, targetID, userID uint, score int) (*models.Engagement, error) {
+	if err := s.checkTarget(targetType, targetID, userID); err != nil {
+		return nil, err
+	}
+
+	rating := models.Rating{UserID: userID, TargetType: targetType, TargetID: targetID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(rating).Assign(models.Rating{Score: score}).FirstOrCreate(&rating).Error; err != nil {
+			return err
+		}
+		return refreshEngagement(tx, targetType, targetID)
+	})
+	if err != nil {
+		logger.Errorf("Failed to rate %s %d: %v", targetType, targetID, err)
+		return nil, errors.New("failed to update rating")
+	}
+	if targetType == models.FavoriteTargetClip {
+		cache.InvalidateClip(targetID)
+	}
+
+	return s.engagement(targetType, targetID, userID)
+}
+
+func (s *FavoriteService) RemoveRating(targetType string, targetID, userID uint) (*models.Engagement, error) {
+	if err := s.checkTarget(targetType, targetID, userID); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+			Delete(&models.Rating{}).Error; err != nil {
+			return err
+		}
+		return refreshEngagement(tx, targetType, targetID)
+	})
+	if err != nil {
+		logger.Errorf("Failed to remove rating of %s %d: %v", targetType, targetID, err)
+		return nil, errors.New("failed to update rating")
+	}
+	if targetType == models.FavoriteTargetClip {
+		cache.InvalidateClip(targetID)
+	}
+
+	return s.engagement(targetType, targetID, userID)
+}
+
+// GetEngagement returns the favorite and rating summary of a clip or
+// template the user can see.
+func (s *FavoriteService) GetEngagement(targetType string, targetID, userID uint) (*models.Engagement, error) {
+	if err := s.checkTarget(targetType, targetID, userID); err != nil {
+		return nil, err
+	}
+	return s.engagement(targetType, targetID, userID)
+}
+
+func (s *FavoriteService) engagement(targetType string, targetID, userID uint) (*models.Engagement, error) {
+	var engagement models.Engagement
+	table := "atomic_clips"
+	if targetType == models.FavoriteTargetTemplate {
+		table = "templates"
+	}
+	if err := s.db.Table(table).Select("favorite_count, rating_count, rating_average").
+		Where("id = ?", targetID).Scan(&engagement).Error; err != nil {
+		return nil, fmt.Errorf("failed to get %s engagement: %w", targetType, err)
+	}
+
+	var favorites int64
+	if err := s.db.Model(&models.Favorite{}).
+		Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+		Count(&favorites).Error; err != nil {
+		return nil, fmt.Errorf("failed to get favorite: %w", err)
+	}
+	engagement.Favorited = favorites > 0
+
+	var rating models.Rating
+	err := s.db.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).First(&rating).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get rating: %w", err)
+	}
+	engagement.MyRating = rating.Score
+
+	return &engagement, nil
+}
+
+// refreshEngagement recounts the favorites and ratings stored on the clip or
+// template, so concurrent changes never leave the totals drifting.
+func refreshEngagement(tx *gorm.DB, targetType string, targetID uint) error {
+	var favorites int64
+	if err := tx.Model(&models.Favorite{}).Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Count(&favorites).Error; err != nil {
+		return err
+	}
+
+	var ratings struct {
+		Count   int
+		Average float64
+	}
+	if err := tx.Model(&models.Rating{}).Select("COUNT(*) AS count, COALESCE(AVG(score), 0) AS average").
+		Where("target_type = ? AND target_id = ?", targetType, targetID).Scan(&ratings).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"favorite_count": favorites,
+		"rating_count":   ratings.Count,
+		"rating_average": ratings.Average,
+	}
+	// Engagement is not an edit, so leave updated_at alone
+	switch targetType {
+	case models.FavoriteTargetClip:
+		return tx.Model(&models.AtomicClip{}).Where("id = ?", targetID).UpdateColumns(updates).Error
+	case models.FavoriteTargetTemplate:
+		return tx.Model(&models.Template{}).Where("id = ?", targetID).UpdateColumns(updates).Error
+	}
+	return nil
+}
+
+// ListFavoriteClips returns the clips the user favorited and can still
+// read, most recently favorited first.
+func (s *FavoriteService) ListFavoriteClips(userID uint, req *models.FavoriteListRequest) ([]models.AtomicClip, int64, error) {
+	readable := s.db.Model(&models.AtomicClip{}).Select("id").Scopes(readableScope(userID))
+	query := s.db.Model(&models.AtomicClip{}).
+		Joins("JOIN favorites ON favorites.target_id = atomic_clips.id AND favorites.target_type = ? AND favorites.user_id = ?", models.FavoriteTargetClip, userID).
+		Where("atomic_clips.id IN (?)", readable)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count favorite clips: %w", err)
+	}
+
+	var clips []models.AtomicClip
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Preload("VideoAnalysis").Order("favorites.created_at DESC").
+		Offset(offset).Limit(req.Limit).Find(&clips).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get favorite clips: %w", err)
+	}
+
+	return clips, total, nil
+}
+
+// ListFavoriteTemplates returns the templates the user favorited and can
+// still see, most recently favorited first.
+func (s *FavoriteService) ListFavoriteTemplates(userID uint, req *models.FavoriteListRequest) ([]models.Template, int64, error) {
+	readable := s.db.Model(&models.Template{}).Select("id").Scopes(readableScope(userID))
+	query := s.db.Model(&models.Template{}).
+		Joins("JOIN favorites ON favorites.target_id = templates.id AND favorites.target_type = ? AND favorites.user_id = ?", models.FavoriteTargetTemplate, userID).
+		Where("templates.is_public = ? OR templates.id IN (?)", true, readable)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count favorite templates: %w", err)
+	}
+
+	var templates []models.Template
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order("favorites.created_at DESC").Offset(offset).Limit(req.Limit).Find(&templates).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get favorite templates: %w", err)
+	}
+
+	return templates, total, nil
+}
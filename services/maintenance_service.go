@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// maintenanceKey holds the maintenance mode in Redis
+	maintenanceKey = "maintenance"
+	// maintenanceRefresh is how often each instance rereads the mode, so a
+	// toggle applies everywhere within it without a Redis read per request
+	maintenanceRefresh = 5 * time.Second
+	// defaultMaintenanceRetryAfter is the wait clients are told when the
+	// toggle names none
+	defaultMaintenanceRetryAfter = 5 * time.Minute
+)
+
+// maintenanceState is this instance's copy of the maintenance mode. It is
+// also the only copy without Redis.
+var maintenanceState struct {
+	sync.Mutex
+	maintenance models.Maintenance
+	fetchedAt   time.Time
+}
+
+// MaintenanceService turns maintenance and read-only mode on and off for
+// every API instance.
+type MaintenanceService struct{}
+
+func NewMaintenanceService() *MaintenanceService {
+	return &MaintenanceService{}
+}
+
+// GetMaintenance returns the current maintenance mode. It is read from
+// Redis at most every few seconds; while Redis fails the last mode read
+// stays in force.
+func (s *MaintenanceService) GetMaintenance() models.Maintenance {
+	maintenanceState.Lock()
+	defer maintenanceState.Unlock()
+
+	if cache.Cache != nil && time.Since(maintenanceState.fetchedAt) >= maintenanceRefresh {
+		var maintenance models.Maintenance
+		err := cache.Cache.GetJSON(maintenanceKey, &maintenance)
+		switch {
+		case err == nil:
+			maintenanceState.maintenance = maintenance
+		case errors.Is(err, cache.ErrKeyNotFound):
+			maintenanceState.maintenance = models.Maintenance{}
+		case !errors.Is(err, cache.ErrCircuitOpen):
+			logger.Warnf("Failed to read maintenance mode: %v", err)
+		}
+		maintenanceState.fetchedAt = time.Now()
+	}
+
+	maintenance := maintenanceState.maintenance
+	if maintenance.ExpiresAt != nil && time.Now().After(*maintenance.ExpiresAt) {
+		maintenance = models.Maintenance{}
+	}
+	if maintenance.Mode == "" {
+		maintenance.Mode = models.MaintenanceOff
+	}
+	return maintenance
+}
+
+// SetMaintenance turns a maintenance mode on, or off with
+// models.MaintenanceOff.
+func (s *MaintenanceService) SetMaintenance(userID uint, req *models.MaintenanceUpdateRequest) (models.Maintenance, error) {
+	maintenance := models.Maintenance{Mode: models.MaintenanceOff}
+	var ttl time.Duration
+	if req.Mode != models.MaintenanceOff {
+		now := time.Now()
+		maintenance = models.Maintenance{
+			Mode:       req.Mode,
+			Message:    req.Message,
+			RetryAfter: req.RetryAfter,
+			StartedBy:  userID,
+			StartedAt:  &now,
+		}
+		if maintenance.RetryAfter == 0 {
+			maintenance.RetryAfter = int(defaultMaintenanceRetryAfter.Seconds())
+		}
+		if req.ExpiresIn > 0 {
+			ttl = time.Duration(req.ExpiresIn) * time.Second
+			expiresAt := now.Add(ttl)
+			maintenance.ExpiresAt = &expiresAt
+		}
+	}
+
+	if cache.Cache != nil {
+		var err error
+		if maintenance.Mode == models.MaintenanceOff {
+			err = cache.Cache.Delete(maintenanceKey)
+		} else {
+			err = cache.Cache.Set(maintenanceKey, maintenance, ttl)
+		}
+		if err != nil {
+			logger.Errorf("Failed to store maintenance mode: %v", err)
+			return models.Maintenance{}, errors.New("failed to update maintenance mode")
+		}
+	}
+
+	maintenanceState.Lock()
+	maintenanceState.maintenance = maintenance
+	maintenanceState.fetchedAt = time.Now()
+	maintenanceState.Unlock()
+
+	logger.Infof("Maintenance mode set to %s by user %d", maintenance.Mode, userID)
+	return maintenance, nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/realtime"
+)
+
+type ModerationService struct {
+	db *gorm.DB
+}
+
+func NewModerationService() *ModerationService {
+	return &ModerationService{
+		db: database.GetDB(),
+	}
+}
+
+// ListQueue returns the clips with the requested moderation status across
+// every library, oldest first so flagged clips are reviewed in the order
+// they were uploaded.
+func (s *ModerationService) ListQueue(req *models.ModerationQueueRequest) ([]models.AtomicClip, int64, error) {
+	var clips []models.AtomicClip
+	var total int64
+
+	query := s.db.Model(&models.AtomicClip{}).Where("moderation_status = ?", req.Status)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count clips: %w", err)
+	}
+
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Preload("User").Order("created_at ASC, id ASC").
+		Offset(offset).Limit(req.Limit).Find(&clips).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get clips: %w", err)
+	}
+	return clips, total, nil
+}
+
+// Review records a reviewer's decision on a clip. Approved clips are
+// visible and composable again, rejected ones stay blocked. The decision
+// stands when the clip is moderated again.
+func (s *ModerationService) Review(clipID, reviewerID uint, req *models.ModerationReviewRequest) (*models.AtomicClip, error) {
+	var clip models.AtomicClip
+	if err := s.db.First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("atomic clip not found")
+		}
+		logger.Errorf("Failed to get atomic clip: %v", err)
+		return nil, errors.New("failed to get atomic clip")
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&clip).Updates(map[string]interface{}{
+		"moderation_status": req.Status,
+		"moderated_by":      reviewerID,
+		"moderated_at":      now,
+		"moderation_note":   req.Note,
+	}).Error; err != nil {
+		logger.Errorf("Failed to review atomic clip: %v", err)
+		return nil, errors.New("failed to review atomic clip")
+	}
+	cache.InvalidateClip(clip.ID)
+
+	realtime.Publish(clip.UserID, realtime.EventClipModerated, map[string]interface{}{
+		"clip_id": clip.ID,
+		"status":  req.Status,
+	})
+
+	logger.Infof("Atomic clip %d %s by reviewer %d", clip.ID, req.Status, reviewerID)
+	return &clip, nil
+}
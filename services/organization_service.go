@@ -0,0 +1,288 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+type OrganizationService struct {
+	db *gorm.DB
+}
+
+func NewOrganizationService() *OrganizationService {
+	return &OrganizationService{
+		db: database.GetDB(),
+	}
+}
+
+// CreateOrganization creates an organization owned by the user.
+func (s *OrganizationService) CreateOrganization(userID uint, req *models.OrganizationCreateRequest) (*models.Organization, error) {
+	var existing models.Organization
+	if err := s.db.Unscoped().Where("slug = ?", req.Slug).First(&existing).Error; err == nil {
+		return nil, errors.New("organization already exists")
+	}
+
+	organization := &models.Organization{
+		Name:    req.Name,
+		Slug:    req.Slug,
+		OwnerID: userID,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Membership{
+			OrganizationID: organization.ID,
+			UserID:         userID,
+			Role:           models.OrgRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to create organization: %v", err)
+		return nil, errors.New("failed to create organization")
+	}
+
+	logger.Infof("Organization %d created by user %d", organization.ID, userID)
+	return organization, nil
+}
+
+// ListMemberships returns the organizations the user belongs to, with their
+// role in each.
+func (s *OrganizationService) ListMemberships(userID uint) ([]models.Membership, error) {
+	var memberships []models.Membership
+	if err := s.db.Preload("Organization").Where("user_id = ?", userID).Order("created_at ASC").Find(&memberships).Error; err != nil {
+		return nil, fmt.Errorf("failed to get memberships: %w", err)
+	}
+
+	return memberships, nil
+}
+
+// GetMembership returns the user's membership of the organization. Users
+// outside an organization are told it does not exist.
+func (s *OrganizationService) GetMembership(organizationID, userID uint) (*models.Membership, error) {
+	var membership models.Membership
+	if err := s.db.Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("organization not found")
+		}
+		logger.Errorf("Failed to get membership: %v", err)
+		return nil, errors.New("failed to get organization")
+	}
+
+	return &membership, nil
+}
+
+// EnterWorkspace returns the user's membership for working in the
+// organization, enforcing its two-factor policy.
+func (s *OrganizationService) EnterWorkspace(organizationID, userID uint) (*models.Membership, error) {
+	var membership models.Membership
+	if err := s.db.Preload("Organization").Preload("User").
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("organization not found")
+		}
+		logger.Errorf("Failed to get membership: %v", err)
+		return nil, errors.New("failed to get organization")
+	}
+
+	if membership.Organization != nil && membership.Organization.RequireTwoFactor &&
+		(membership.User == nil || membership.User.TwoFactorEnabledAt == nil) {
+		return nil, errors.New("organization requires two-factor authentication")
+	}
+
+	return &membership, nil
+}
+
+// GetOrganization returns the organization with its members.
+func (s *OrganizationService) GetOrganization(organizationID, userID uint) (*models.Organization, error) {
+	if _, err := s.GetMembership(organizationID, userID); err != nil {
+		return nil, err
+	}
+
+	var organization models.Organization
+	if err := s.db.Preload("Members.User").First(&organization, organizationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("organization not found")
+		}
+		logger.Errorf("Failed to get organization: %v", err)
+		return nil, errors.New("failed to get organization")
+	}
+
+	return &organization, nil
+}
+
+// UpdateOrganization renames the organization or changes its two-factor
+// policy. Requiring two-factor authentication takes having it enabled, so
+// managers cannot lock themselves out.
+func (s *OrganizationService) UpdateOrganization(organizationID, actorID uint, req *models.OrganizationUpdateRequest) (*models.Organization, error) {
+	if _, err := s.managerMembership(organizationID, actorID); err != nil {
+		return nil, err
+	}
+
+	var organization models.Organization
+	if err := s.db.First(&organization, organizationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("organization not found")
+		}
+		return nil, errors.New("failed to get organization")
+	}
+
+	if req.RequireTwoFactor != nil && *req.RequireTwoFactor && !organization.RequireTwoFactor {
+		var actor models.User
+		if err := s.db.First(&actor, actorID).Error; err != nil {
+			return nil, errors.New("failed to get user")
+		}
+		if actor.TwoFactorEnabledAt == nil {
+			return nil, errors.New("enable two-factor authentication before requiring it")
+		}
+	}
+
+	if req.Name != nil {
+		organization.Name = *req.Name
+	}
+	if req.RequireTwoFactor != nil {
+		organization.RequireTwoFactor = *req.RequireTwoFactor
+	}
+
+	if err := s.db.Save(&organization).Error; err != nil {
+		logger.Errorf("Failed to update organization: %v", err)
+		return nil, errors.New("failed to update organization")
+	}
+
+	return &organization, nil
+}
+
+// managerMembership returns the membership of a user who manages members.
+func (s *OrganizationService) managerMembership(organizationID, userID uint) (*models.Membership, error) {
+	membership, err := s.GetMembership(organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !membership.CanManage() {
+		return nil, errors.New("insufficient organization role")
+	}
+	return membership, nil
+}
+
+func (s *OrganizationService) AddMember(organizationID, actorID uint, req *models.MembershipCreateRequest) (*models.Membership, error) {
+	if _, err := s.managerMembership(organizationID, actorID); err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, errors.New("failed to get user")
+	}
+
+	if _, err := s.GetMembership(organizationID, user.ID); err == nil {
+		return nil, errors.New("user is already a member")
+	}
+
+	membership := &models.Membership{
+		OrganizationID: organizationID,
+		UserID:         user.ID,
+		Role:           req.Role,
+	}
+	if err := s.db.Create(membership).Error; err != nil {
+		logger.Errorf("Failed to add member: %v", err)
+		return nil, errors.New("failed to add member")
+	}
+
+	logger.Infof("User %d added to organization %d as %s", user.ID, organizationID, req.Role)
+	return membership, nil
+}
+
+func (s *OrganizationService) UpdateMember(organizationID, actorID, userID uint, req *models.MembershipUpdateRequest) (*models.Membership, error) {
+	if _, err := s.managerMembership(organizationID, actorID); err != nil {
+		return nil, err
+	}
+
+	membership, err := s.GetMembership(organizationID, userID)
+	if err != nil {
+		return nil, errors.New("member not found")
+	}
+	if membership.Role == models.OrgRoleOwner {
+		return nil, errors.New("the owner's role cannot be changed")
+	}
+
+	if err := s.db.Model(membership).Update("role", req.Role).Error; err != nil {
+		logger.Errorf("Failed to update member: %v", err)
+		return nil, errors.New("failed to update member")
+	}
+
+	return membership, nil
+}
+
+// RemoveMember removes a member; members may also remove themselves to
+// leave the organization.
+func (s *OrganizationService) RemoveMember(organizationID, actorID, userID uint) error {
+	if actorID != userID {
+		if _, err := s.managerMembership(organizationID, actorID); err != nil {
+			return err
+		}
+	}
+
+	membership, err := s.GetMembership(organizationID, userID)
+	if err != nil {
+		return errors.New("member not found")
+	}
+	if membership.Role == models.OrgRoleOwner {
+		return errors.New("the owner cannot leave the organization")
+	}
+
+	if err := s.db.Delete(membership).Error; err != nil {
+		logger.Errorf("Failed to remove member: %v", err)
+		return errors.New("failed to remove member")
+	}
+
+	logger.Infof("User %d removed from organization %d", userID, organizationID)
+	return nil
+}
+
+// canAccess reports whether the user may read, or with write also change,
+// content of the organization, or of the owner's personal workspace when
+// organizationID is nil.
+func canAccess(db *gorm.DB, userID, ownerID uint, organizationID *uint, write bool) (bool, error) {
+	if organizationID == nil {
+		return userID == ownerID, nil
+	}
+
+	var membership models.Membership
+	if err := db.Where("organization_id = ? AND user_id = ?", *organizationID, userID).First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return !write || membership.CanWrite(), nil
+}
+
+// workspaceScope limits a query to the organization's content, or to the
+// user's personal content when organizationID is nil.
+func workspaceScope(userID uint, organizationID *uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if organizationID != nil {
+			return db.Where("organization_id = ?", *organizationID)
+		}
+		return db.Where("user_id = ? AND organization_id IS NULL", userID)
+	}
+}
+
+// readableScope limits a query to the content the user can read in any
+// workspace: their personal content and that of their organizations.
+func readableScope(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		organizations := db.Session(&gorm.Session{NewDB: true}).Model(&models.Membership{}).
+			Select("organization_id").Where("user_id = ?", userID)
+		return db.Where("(organization_id IS NULL AND user_id = ?) OR organization_id IN (?)", userID, organizations)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errInvalidCursor is returned for cursors that were not issued for the
+// listing they are sent to
+var errInvalidCursor = errors.New("invalid cursor")
+
+// keysetColumn is a column of a keyset sort order, or an expression with
+// its args. Times are kept apart as they cannot be told from strings once
+// encoded.
+type keysetColumn struct {
+	name   string
+	args   []interface{}
+	isTime bool
+	asc    bool
+}
+
+// keyset is a sort order ending with a unique column, so a listing can
+// resume right after the last row it returned. Unlike an offset, rows
+// inserted in the meantime neither shift the next page nor show up twice,
+// and the database seeks to the page instead of skipping every row before
+// it. Columns sort descending unless asc is set.
+type keyset []keysetColumn
+
+// ascending returns the keyset with every column sorted ascending.
+func (k keyset) ascending() keyset {
+	columns := make(keyset, len(k))
+	for i, column := range k {
+		column.asc = true
+		columns[i] = column
+	}
+	return columns
+}
+
+// order returns the ORDER BY clause of the sort.
+func (k keyset) order() clause.OrderBy {
+	columns := make([]string, len(k))
+	var args []interface{}
+	for i, column := range k {
+		direction := " DESC"
+		if column.asc {
+			direction = " ASC"
+		}
+		columns[i] = column.name + direction
+		args = append(args, column.args...)
+	}
+	return clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(columns, ", "), Vars: args, WithoutParentheses: true}}
+}
+
+// after limits the query to the rows sorted after the cursor. An empty
+// cursor starts at the first row.
+func (k keyset) after(query *gorm.DB, cursor string) (*gorm.DB, error) {
+	if cursor == "" {
+		return query, nil
+	}
+	values, err := k.decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// (a < ?) OR (a = ? AND b < ?) OR ...
+	var clauses []string
+	var args []interface{}
+	for i := range k {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, k[j].name+" = ?")
+			args = append(append(args, k[j].args...), values[j])
+		}
+		comparison := " < ?"
+		if k[i].asc {
+			comparison = " > ?"
+		}
+		terms = append(terms, k[i].name+comparison)
+		args = append(append(args, k[i].args...), values[i])
+		clauses = append(clauses, "("+strings.Join(terms, " AND ")+")")
+	}
+	return query.Where("("+strings.Join(clauses, " OR ")+")", args...), nil
+}
+
+// cursor encodes the sort values of a row, in the order of the keyset.
+func (k keyset) cursor(values ...interface{}) string {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (k keyset) decode(cursor string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil || len(raw) != len(k) {
+		return nil, errInvalidCursor
+	}
+
+	values := make([]interface{}, len(k))
+	for i, column := range k {
+		if column.isTime {
+			var t time.Time
+			if err := json.Unmarshal(raw[i], &t); err != nil {
+				return nil, errInvalidCursor
+			}
+			values[i] = t
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(raw[i]))
+		decoder.UseNumber()
+		var number json.Number
+		if err := decoder.Decode(&number); err != nil {
+			return nil, errInvalidCursor
+		}
+		if n, err := number.Int64(); err == nil {
+			values[i] = n
+		} else if f, err := number.Float64(); err == nil {
+			values[i] = f
+		} else {
+			return nil, errInvalidCursor
+		}
+	}
+	return values, nil
+}
+
+// Sort orders of paged listings
+var (
+	keysetByCreated = keyset{{name: "created_at", isTime: true}, {name: "id"}}
+	keysetByUpdated = keyset{{name: "updated_at", isTime: true}, {name: "id"}}
+	keysetByID      = keyset{{name: "id"}}
+)
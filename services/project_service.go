@@ -0,0 +1,557 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+type ProjectService struct {
+	db *gorm.DB
+}
+
+func NewProjectService() *ProjectService {
+	return &ProjectService{
+		db: database.GetDB(),
+	}
+}
+
+func (s *ProjectService) GetProjectByID(projectID, userID uint) (*models.Project, error) {
+	var project models.Project
+	// The project is cached once for every user; access is checked below
+	err := cache.ReadThrough(cache.ProjectCacheKey(projectID), config.AppConfig.Cache.ProjectTTL, &project, func() error {
+		return s.db.First(&project, projectID).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("project not found")
+		}
+		logger.Errorf("Failed to get project: %v", err)
+		return nil, errors.New("failed to get project")
+	}
+
+	if userID > 0 {
+		allowed, err := canAccess(s.db, userID, project.UserID, project.OrganizationID, false)
+		if err != nil {
+			logger.Errorf("Failed to check organization access: %v", err)
+			return nil, errors.New("failed to get project")
+		}
+		if !allowed {
+			return nil, errors.New("project not found")
+		}
+	}
+
+	return &project, nil
+}
+
+// GetProjectsByIDs returns the projects among the IDs the user can read, in
+// no particular order.
+func (s *ProjectService) GetProjectsByIDs(projectIDs []uint, userID uint) ([]models.Project, error) {
+	var projects []models.Project
+	if err := s.db.Where("id IN ?", projectIDs).Scopes(readableScope(userID)).Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+	return projects, nil
+}
+
+// CreateProject creates a project in the workspace. Starting from a
+// template copies its canvas, timeline and settings.
+func (s *ProjectService) CreateProject(userID uint, organizationID *uint, req *models.ProjectCreateRequest) (*models.Project, error) {
+	return s.createProject(userID, organizationID, req, nil)
+}
+
+// createProject creates the project, starting it with the timeline when
+// one is given, which must already be checked.
+func (s *ProjectService) createProject(userID uint, organizationID *uint, req *models.ProjectCreateRequest, timeline *models.ProjectTimeline) (*models.Project, error) {
+	if organizationID != nil {
+		allowed, err := canAccess(s.db, userID, userID, organizationID, true)
+		if err != nil {
+			logger.Errorf("Failed to check organization access: %v", err)
+			return nil, errors.New("failed to create project")
+		}
+		if !allowed {
+			return nil, errors.New("insufficient organization role")
+		}
+	}
+
+	project := &models.Project{
+		Title:          req.Title,
+		Description:    req.Description,
+		Width:          1920,
+		Height:         1080,
+		FrameRate:      30,
+		Status:         models.ProjectStatusDraft,
+		Version:        1,
+		UserID:         userID,
+		TemplateID:     req.TemplateID,
+		OrganizationID: organizationID,
+	}
+
+	if req.TemplateID != nil {
+		template, err := getAccessibleTemplate(s.db, *req.TemplateID, userID)
+		if err != nil {
+			return nil, err
+		}
+		project.Width = template.Width
+		project.Height = template.Height
+		project.FrameRate = template.FrameRate
+		project.Duration = template.Duration
+		project.Timeline = template.Timeline
+		project.Settings = template.Settings
+	}
+
+	if req.Width > 0 {
+		project.Width = req.Width
+	}
+	if req.Height > 0 {
+		project.Height = req.Height
+	}
+	if req.FrameRate > 0 {
+		project.FrameRate = req.FrameRate
+	}
+	if timeline != nil {
+		timelineJSON, err := timeline.ToJSON()
+		if err != nil {
+			return nil, errors.New("failed to create project")
+		}
+		project.Timeline = timelineJSON
+		project.Duration = timeline.Duration
+	}
+
+	var usedClips []uint
+	err := s.db.Transaction(func(tx *gorm.DB) (err error) {
+		if usedClips, err = updateClipUsage(tx, 0, project.Timeline); err != nil {
+			return err
+		}
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		if err := recordClipUsages(tx, project.ID, project.Timeline); err != nil {
+			return err
+		}
+		if project.Timeline != nil {
+			revision := newRevision(project, userID)
+			revision.Version = project.Version
+			if err := tx.Create(revision).Error; err != nil {
+				return err
+			}
+		}
+		if req.TemplateID != nil {
+			return tx.Model(&models.Template{}).Where("id = ?", *req.TemplateID).
+				UpdateColumn("usage_count", gorm.Expr("usage_count + 1")).Error
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("Failed to create project: %v", err)
+		return nil, errors.New("failed to create project")
+	}
+	invalidateClips(usedClips)
+
+	logger.Infof("Project %d created by user %d", project.ID, userID)
+	return project, nil
+}
+
+// ListProjects returns the projects of the workspace, most recently
+// updated first. Archived projects are only listed when asked for. The
+// returned cursor resumes the listing after the page.
+func (s *ProjectService) ListProjects(userID uint, organizationID *uint, req *models.ProjectListRequest) ([]models.Project, int64, string, error) {
+	query := s.db.Model(&models.Project{}).Scopes(workspaceScope(userID, organizationID))
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	} else {
+		query = query.Where("status <> ?", models.ProjectStatusArchived)
+	}
+
+	var total int64
+	if req.Cursor != "" {
+		var err error
+		if query, err = keysetByUpdated.after(query, req.Cursor); err != nil {
+			return nil, 0, "", err
+		}
+	} else {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, "", fmt.Errorf("failed to count projects: %w", err)
+		}
+		query = query.Offset((req.Page - 1) * req.Limit)
+	}
+
+	var projects []models.Project
+	if err := query.Order(keysetByUpdated.order()).Limit(req.Limit + 1).Find(&projects).Error; err != nil {
+		return nil, 0, "", fmt.Errorf("failed to get projects: %w", err)
+	}
+
+	var nextCursor string
+	if len(projects) > req.Limit {
+		projects = projects[:req.Limit]
+		last := projects[len(projects)-1]
+		nextCursor = keysetByUpdated.cursor(last.UpdatedAt, last.ID)
+	}
+	return projects, total, nextCursor, nil
+}
+
+// getWritableProject returns the project when the user may change it.
+func (s *ProjectService) getWritableProject(projectID, userID uint) (*models.Project, error) {
+	project, err := s.GetProjectByID(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := canAccess(s.db, userID, project.UserID, project.OrganizationID, true)
+	if err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to get project")
+	}
+	if !allowed {
+		return nil, errors.New("insufficient organization role")
+	}
+
+	return project, nil
+}
+
+// UpdateProject changes the details, canvas, settings or status of a
+// project, and its timeline when one is given.
+func (s *ProjectService) UpdateProject(projectID, userID uint, req *models.ProjectUpdateRequest) (*models.Project, error) {
+	project, err := s.getWritableProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var revision *models.ProjectRevision
+	if req.Timeline != nil {
+		if err := s.applyTimeline(project, req.Timeline, userID); err != nil {
+			return nil, err
+		}
+		revision = newRevision(project, userID)
+	}
+	if req.Title != "" {
+		project.Title = req.Title
+	}
+	if req.Description != "" {
+		project.Description = req.Description
+	}
+	if req.Width > 0 {
+		project.Width = req.Width
+	}
+	if req.Height > 0 {
+		project.Height = req.Height
+	}
+	if req.FrameRate > 0 {
+		project.FrameRate = req.FrameRate
+	}
+	if req.Settings != nil {
+		project.Settings = req.Settings
+	}
+	if req.Status != "" {
+		project.Status = req.Status
+	}
+
+	return s.save(project, revision)
+}
+
+// UpdateTimeline replaces the project's timeline. A stale version means
+// someone else saved meanwhile.
+func (s *ProjectService) UpdateTimeline(projectID, userID uint, req *models.ProjectTimelineUpdateRequest) (*models.Project, error) {
+	project, err := s.getWritableProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Version != 0 && req.Version != project.Version {
+		return nil, errors.New("project was changed by someone else")
+	}
+
+	if err := s.applyTimeline(project, req.Timeline, userID); err != nil {
+		return nil, err
+	}
+
+	return s.save(project, newRevision(project, userID))
+}
+
+// applyTimeline validates the timeline, including that the user can read
+// every clip it uses and that clips are cut within their source, and sets
+// it on the project.
+func (s *ProjectService) applyTimeline(project *models.Project, data models.JSON, userID uint) error {
+	timeline, err := models.ParseProjectTimeline(data)
+	if err != nil {
+		return errors.New("invalid timeline: " + err.Error())
+	}
+	if err := timeline.Validate(); err != nil {
+		return errors.New("invalid timeline: " + err.Error())
+	}
+
+	ids := timeline.ClipIDs()
+	var clips []models.AtomicClip
+	if len(ids) > 0 {
+		if err := s.db.Where("id IN ?", ids).Find(&clips).Error; err != nil {
+			logger.Errorf("Failed to get timeline clips: %v", err)
+			return errors.New("failed to update timeline")
+		}
+	}
+	durations := make(map[uint]float64, len(clips))
+	for _, clip := range clips {
+		// Global stock clips may be used by anyone
+		allowed := clip.Stock && clip.OrganizationID == nil
+		if !allowed {
+			if allowed, err = canAccess(s.db, userID, clip.UserID, clip.OrganizationID, false); err != nil {
+				logger.Errorf("Failed to check organization access: %v", err)
+				return errors.New("failed to update timeline")
+			}
+		}
+		if allowed {
+			durations[clip.ID] = clip.Duration
+		}
+	}
+	if err := timeline.CheckSources(durations); err != nil {
+		return errors.New("invalid timeline: " + err.Error())
+	}
+
+	timelineJSON, err := timeline.ToJSON()
+	if err != nil {
+		return errors.New("failed to update timeline")
+	}
+	project.Timeline = timelineJSON
+	project.Duration = timeline.Duration
+	return nil
+}
+
+// save writes the project as its next version, recording the revision
+// when its timeline was saved.
+func (s *ProjectService) save(project *models.Project, revision *models.ProjectRevision) (*models.Project, error) {
+	project.Version++
+	var usedClips []uint
+	err := s.db.Transaction(func(tx *gorm.DB) (err error) {
+		if revision != nil {
+			if usedClips, err = updateClipUsage(tx, project.ID, project.Timeline); err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit("User", "Template", "RenderTasks").Save(project).Error; err != nil {
+			return err
+		}
+		if revision == nil {
+			return nil
+		}
+		if err := recordClipUsages(tx, project.ID, project.Timeline); err != nil {
+			return err
+		}
+		revision.Version = project.Version
+		return tx.Create(revision).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to update project: %v", err)
+		return nil, errors.New("failed to update project")
+	}
+
+	cache.InvalidateProject(project.ID)
+	invalidateClips(usedClips)
+	return project, nil
+}
+
+// ArchiveProject hides the project from listings without deleting it.
+func (s *ProjectService) ArchiveProject(projectID, userID uint) (*models.Project, error) {
+	project, err := s.getWritableProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if project.Status == models.ProjectStatusArchived {
+		return nil, errors.New("project is already archived")
+	}
+
+	project.Status = models.ProjectStatusArchived
+	return s.save(project, nil)
+}
+
+// DeleteProject soft-deletes the project.
+func (s *ProjectService) DeleteProject(projectID, userID uint) error {
+	project, err := s.getWritableProject(projectID, userID)
+	if err != nil {
+		return err
+	}
+
+	var usedClips []uint
+	err = s.db.Transaction(func(tx *gorm.DB) (err error) {
+		if usedClips, err = updateClipUsage(tx, project.ID, nil); err != nil {
+			return err
+		}
+		return tx.Delete(project).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to delete project: %v", err)
+		return errors.New("failed to delete project")
+	}
+
+	cache.InvalidateProject(project.ID)
+	invalidateClips(usedClips)
+	logger.Infof("Project %d deleted by user %d", project.ID, userID)
+	return nil
+}
+
+// updateClipUsage counts the clips a project's timeline starts or stops
+// using against their usage_count, comparing it with the timeline stored
+// for the project, and returns the clips whose count changed. It runs
+// before the project is written.
+func updateClipUsage(tx *gorm.DB, projectID uint, timeline models.JSON) ([]uint, error) {
+	var stored models.JSON
+	if projectID != 0 {
+		var project models.Project
+		if err := tx.Select("timeline").First(&project, projectID).Error; err != nil {
+			return nil, err
+		}
+		stored = project.Timeline
+	}
+
+	before, err := models.ParseProjectTimeline(stored)
+	if err != nil {
+		return nil, err
+	}
+	after, err := models.ParseProjectTimeline(timeline)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[uint]bool)
+	for _, id := range before.ClipIDs() {
+		used[id] = true
+	}
+	var added, removed []uint
+	for _, id := range after.ClipIDs() {
+		if used[id] {
+			delete(used, id)
+		} else {
+			added = append(added, id)
+		}
+	}
+	for id := range used {
+		removed = append(removed, id)
+	}
+
+	// Unscoped, as deleted clips can still be on a timeline
+	if len(added) > 0 {
+		if err := tx.Unscoped().Model(&models.AtomicClip{}).Where("id IN ?", added).
+			UpdateColumn("usage_count", gorm.Expr("usage_count + 1")).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(removed) > 0 {
+		if err := tx.Unscoped().Model(&models.AtomicClip{}).Where("id IN ? AND usage_count > 0", removed).
+			UpdateColumn("usage_count", gorm.Expr("usage_count - 1")).Error; err != nil {
+			return nil, err
+		}
+	}
+	return append(added, removed...), nil
+}
+
+// recordClipUsages records the clips of the project's timeline as used by
+// it. Deleted projects keep theirs, so restoring a project restores them.
+func recordClipUsages(tx *gorm.DB, projectID uint, timeline models.JSON) error {
+	parsed, err := models.ParseProjectTimeline(timeline)
+	if err != nil {
+		return err
+	}
+	return models.SetClipUsages(tx, projectID, nil, parsed.ClipIDs())
+}
+
+func newRevision(project *models.Project, userID uint) *models.ProjectRevision {
+	return &models.ProjectRevision{
+		ProjectID: project.ID,
+		Timeline:  project.Timeline,
+		Duration:  project.Duration,
+		UserID:    userID,
+	}
+}
+
+// ListRevisions returns the project's revisions, newest first, without
+// their timelines.
+func (s *ProjectService) ListRevisions(projectID, userID uint, req *models.ProjectRevisionListRequest) ([]models.ProjectRevision, int64, error) {
+	if _, err := s.GetProjectByID(projectID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.Model(&models.ProjectRevision{}).Where("project_id = ?", projectID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("Failed to count project revisions: %v", err)
+		return nil, 0, errors.New("failed to get revisions")
+	}
+
+	var revisions []models.ProjectRevision
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Omit("timeline").Order("version DESC").Offset(offset).Limit(req.Limit).Find(&revisions).Error; err != nil {
+		logger.Errorf("Failed to get project revisions: %v", err)
+		return nil, 0, errors.New("failed to get revisions")
+	}
+
+	return revisions, total, nil
+}
+
+// GetRevision returns one revision of the project with its timeline.
+func (s *ProjectService) GetRevision(projectID, userID uint, version int) (*models.ProjectRevision, error) {
+	if _, err := s.GetProjectByID(projectID, userID); err != nil {
+		return nil, err
+	}
+	return s.getRevision(projectID, version)
+}
+
+func (s *ProjectService) getRevision(projectID uint, version int) (*models.ProjectRevision, error) {
+	var revision models.ProjectRevision
+	if err := s.db.Where("project_id = ? AND version = ?", projectID, version).First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("revision not found")
+		}
+		logger.Errorf("Failed to get project revision: %v", err)
+		return nil, errors.New("failed to get revision")
+	}
+	return &revision, nil
+}
+
+// DiffRevisions compares the timelines of two revisions of the project.
+func (s *ProjectService) DiffRevisions(projectID, userID uint, from, to int) (*models.ProjectRevisionDiff, error) {
+	if _, err := s.GetProjectByID(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	timelines := make([]*models.ProjectTimeline, 2)
+	for i, version := range []int{from, to} {
+		revision, err := s.getRevision(projectID, version)
+		if err != nil {
+			return nil, err
+		}
+		if timelines[i], err = models.ParseProjectTimeline(revision.Timeline); err != nil {
+			logger.Errorf("Failed to parse timeline of project %d revision %d: %v", projectID, version, err)
+			return nil, errors.New("failed to compare revisions")
+		}
+	}
+
+	return &models.ProjectRevisionDiff{
+		From:         from,
+		To:           to,
+		TimelineDiff: timelines[0].Diff(timelines[1]),
+	}, nil
+}
+
+// RestoreRevision makes a prior revision's timeline the current one, as a
+// new revision. The timeline is validated again, so it fails when a clip it
+// uses is gone.
+func (s *ProjectService) RestoreRevision(projectID, userID uint, version int) (*models.Project, error) {
+	project, err := s.getWritableProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.getRevision(projectID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyTimeline(project, revision.Timeline, userID); err != nil {
+		return nil, err
+	}
+	restored := newRevision(project, userID)
+	restored.RestoredFrom = &revision.Version
+
+	return s.save(project, restored)
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/queue"
+)
+
+// QueueService exposes the runtime worker settings of the task queues.
+type QueueService struct{}
+
+func NewQueueService() *QueueService {
+	return &QueueService{}
+}
+
+func (s *QueueService) ListQueueSettings() map[string]config.QueueSettings {
+	return queue.AllSettings()
+}
+
+// UpdateQueueSettings merges the request into the queue's current settings
+// and rolls them out to every worker.
+func (s *QueueService) UpdateQueueSettings(queueName string, req *models.QueueSettingsUpdateRequest) (config.QueueSettings, error) {
+	settings := queue.SettingsFor(queueName)
+	if req.Concurrency != nil {
+		settings.Concurrency = *req.Concurrency
+	}
+	if req.Prefetch != nil {
+		settings.Prefetch = *req.Prefetch
+	}
+	if req.MaxRetries != nil {
+		settings.MaxRetries = *req.MaxRetries
+	}
+
+	if err := queue.UpdateSettings(queueName, settings); err != nil {
+		return config.QueueSettings{}, err
+	}
+	return settings, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+// Quotas checked before a request uses them
+const (
+	QuotaUploads = "uploads"
+	QuotaRenders = "renders"
+)
+
+// QuotaService tracks users' usage against the quotas of their plan.
+type QuotaService struct {
+	db          *gorm.DB
+	userService *UserService
+}
+
+func NewQuotaService() *QuotaService {
+	return &QuotaService{
+		db:          database.GetDB(),
+		userService: NewUserService(),
+	}
+}
+
+// GetUsage returns the user's usage of each quota of their plan.
+func (s *QuotaService) GetUsage(userID uint) (*models.Usage, error) {
+	plan, err := s.userService.GetUserPlan(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("user not found")
+	}
+	if err != nil {
+		logger.Errorf("Failed to get user plan: %v", err)
+		return nil, errors.New("failed to get usage")
+	}
+	quotas := config.AppConfig.Quotas.Plans[plan]
+
+	now := time.Now().UTC()
+	day, month := models.UsageDay(now), models.UsageMonth(now)
+	var counters []models.UsageCounter
+	if err := s.db.Where("user_id = ? AND period IN ?", userID, []string{day, month}).Find(&counters).Error; err != nil {
+		logger.Errorf("Failed to get usage counters: %v", err)
+		return nil, errors.New("failed to get usage")
+	}
+
+	var storage int64
+	if err := s.db.Unscoped().Model(&models.AtomicClip{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(file_size), 0)").
+		Scan(&storage).Error; err != nil {
+		logger.Errorf("Failed to get storage usage: %v", err)
+		return nil, errors.New("failed to get usage")
+	}
+
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	usage := &models.Usage{
+		UserID:         userID,
+		Plan:           plan,
+		DailyUploads:   models.UsageMeter{Limit: quotas.DailyUploads, ResetsAt: &tomorrow},
+		MonthlyUploads: models.UsageMeter{Limit: quotas.MonthlyUploads, ResetsAt: &nextMonth},
+		RenderMinutes:  models.UsageMeter{Limit: quotas.MonthlyRenderMinutes, ResetsAt: &nextMonth},
+		StorageBytes:   models.UsageMeter{Used: storage, Limit: quotas.StorageBytes},
+	}
+	for _, counter := range counters {
+		switch {
+		case counter.Metric == models.UsageUploads && counter.Period == day:
+			usage.DailyUploads.Used = counter.Amount
+		case counter.Metric == models.UsageUploads && counter.Period == month:
+			usage.MonthlyUploads.Used = counter.Amount
+		case counter.Metric == models.UsageRenderSeconds && counter.Period == month:
+			// A started minute counts
+			usage.RenderMinutes.Used = (counter.Amount + 59) / 60
+		}
+	}
+
+	return usage, nil
+}
+
+// CheckQuota refuses a request that would use a quota already used up. It
+// returns the usage, so a refusal can tell when the quota starts over.
+func (s *QuotaService) CheckQuota(userID uint, quota string) (*models.Usage, error) {
+	usage, err := s.GetUsage(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch quota {
+	case QuotaUploads:
+		switch {
+		case usage.StorageBytes.Exceeded():
+			return usage, errors.New("storage quota exceeded")
+		case usage.MonthlyUploads.Exceeded():
+			return usage, errors.New("monthly upload quota exceeded")
+		case usage.DailyUploads.Exceeded():
+			return usage, errors.New("daily upload quota exceeded")
+		}
+	case QuotaRenders:
+		if usage.RenderMinutes.Exceeded() {
+			return usage, errors.New("monthly render quota exceeded")
+		}
+	}
+	return usage, nil
+}
+
+// recordUsage counts a use against the user's quotas. Failing to count it
+// does not fail what was used.
+func recordUsage(db *gorm.DB, userID uint, metric string, amount int64) {
+	if err := models.RecordUsage(db, userID, metric, amount, time.Now()); err != nil {
+		logger.Warnf("Failed to record %s usage of user %d: %v", metric, userID, err)
+	}
+}
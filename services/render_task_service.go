@@ -0,0 +1,432 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/queue"
+	"creative-studio-server/pkg/video_engine"
+)
+
+// throughputHistorySize is how many recent renders feed the throughput estimate
+const throughputHistorySize = 50
+
+type RenderTaskService struct {
+	db             *gorm.DB
+	projectService *ProjectService
+}
+
+func NewRenderTaskService() *RenderTaskService {
+	return &RenderTaskService{
+		db:             database.GetDB(),
+		projectService: NewProjectService(),
+	}
+}
+
+// CreateRenderTask queues a render. traceID is the correlation ID of the
+// request; the render task and its queue tasks keep it, retries included.
+func (s *RenderTaskService) CreateRenderTask(userID uint, traceID string, req *models.RenderTaskCreateRequest) (*models.RenderTask, error) {
+	project, err := s.projectService.GetProjectByID(req.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	task := newRenderTask(userID, req, project)
+	task.TraceID = traceID
+
+	// A missing estimate should not block the render itself
+	if estimate, err := s.estimate(task, project); err != nil {
+		logger.Warnf("Failed to estimate render for project %d: %v", project.ID, err)
+	} else {
+		task.EstimatedTime = estimate.EstimatedSeconds
+		task.Work = estimate.Work
+	}
+
+	if err := s.db.Create(task).Error; err != nil {
+		logger.Errorf("Failed to create render task: %v", err)
+		return nil, errors.New("failed to create render task")
+	}
+
+	if err := s.enqueue(task); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Render task %s queued for project %d", task.TaskID, project.ID)
+	return task, nil
+}
+
+// CreateRenderGroup queues several outputs of a project as one render job.
+// Each output gets its own task and status, but the timeline is decoded once
+// and shared by all of them.
+func (s *RenderTaskService) CreateRenderGroup(userID uint, traceID string, req *models.RenderGroupCreateRequest) ([]models.RenderTask, error) {
+	project, err := s.projectService.GetProjectByID(req.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groupID := fmt.Sprintf("group_%d", time.Now().UnixNano())
+	tasks := make([]models.RenderTask, 0, len(req.Outputs))
+	for i, output := range req.Outputs {
+		task := newRenderTask(userID, req.TaskRequest(output), project)
+		task.TaskID = fmt.Sprintf("%s_%d", task.TaskID, i)
+		task.GroupID = groupID
+		task.TraceID = traceID
+
+		if estimate, err := s.estimate(task, project); err != nil {
+			logger.Warnf("Failed to estimate render for project %d: %v", project.ID, err)
+		} else {
+			task.EstimatedTime = estimate.EstimatedSeconds
+			task.Work = estimate.Work
+		}
+		tasks = append(tasks, *task)
+	}
+
+	if err := s.db.Create(&tasks).Error; err != nil {
+		logger.Errorf("Failed to create render group: %v", err)
+		return nil, errors.New("failed to create render task")
+	}
+
+	if err := queue.PublishRenderGroupTask(groupID, tasks[0].Priority, traceID); err != nil {
+		logger.Errorf("Failed to queue render group %s: %v", groupID, err)
+		s.db.Model(&models.RenderTask{}).Where("group_id = ?", groupID).Updates(map[string]interface{}{
+			"status":        models.RenderStatusFailed,
+			"error_message": "failed to queue render task",
+		})
+		return nil, errors.New("failed to queue render task")
+	}
+
+	logger.Infof("Render group %s queued for project %d with %d outputs", groupID, project.ID, len(tasks))
+	return tasks, nil
+}
+
+// EstimateRender predicts the render time (and cost, when configured) of a
+// render request without submitting it.
+func (s *RenderTaskService) EstimateRender(userID uint, req *models.RenderTaskCreateRequest) (*video_engine.RenderEstimate, error) {
+	project, err := s.projectService.GetProjectByID(req.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.estimate(newRenderTask(userID, req, project), project)
+}
+
+func newRenderTask(userID uint, req *models.RenderTaskCreateRequest, project *models.Project) *models.RenderTask {
+	mode := req.Mode
+	if mode == "" {
+		mode = models.RenderModeFull
+	}
+	priority := req.Priority
+	if priority == 0 {
+		priority = 5
+	}
+
+	return &models.RenderTask{
+		TaskID:       fmt.Sprintf("render_%d", time.Now().UnixNano()),
+		Status:       models.RenderStatusPending,
+		Priority:     priority,
+		Mode:         mode,
+		OutputFormat: req.OutputFormat,
+		Quality:      req.Quality,
+		Resolution:   req.Resolution,
+		FrameRate:    req.FrameRate,
+		AspectRatio:  req.AspectRatio,
+		Captions:     req.Captions,
+		Duration:     project.Duration,
+		ProjectID:    project.ID,
+		UserID:       userID,
+	}
+}
+
+func (s *RenderTaskService) estimate(task *models.RenderTask, project *models.Project) (*video_engine.RenderEstimate, error) {
+	timeline, err := models.ParseProjectTimeline(project.Timeline)
+	if err != nil {
+		return nil, errors.New("invalid project timeline")
+	}
+
+	clipIDs := make([]uint, 0, len(timeline.Clips))
+	for _, entry := range timeline.Clips {
+		clipIDs = append(clipIDs, entry.ClipID)
+	}
+
+	var clips []models.AtomicClip
+	if len(clipIDs) > 0 {
+		if err := s.db.Preload("VideoAnalysis").Where("id IN ?", clipIDs).
+			Where(s.db.Where("user_id = ?", project.UserID).Or(models.StockScope(project.UserID)(s.db))).
+			Find(&clips).Error; err != nil {
+			logger.Errorf("Failed to load timeline clips: %v", err)
+			return nil, errors.New("failed to load timeline clips")
+		}
+	}
+	clipIndex := make(map[uint]models.AtomicClip, len(clips))
+	for _, clip := range clips {
+		clipIndex[clip.ID] = clip
+	}
+
+	segments, err := video_engine.BuildRenderSegments(timeline, clipIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	outputDuration := 0.0
+	for _, segment := range segments {
+		outputDuration += segment.Duration
+	}
+
+	options := video_engine.RenderOptionsForTask(*task, *project)
+	work := video_engine.RenderWork(segments, clipIndex, options)
+	throughput, samples := s.historicalThroughput()
+
+	renderCfg := config.AppConfig.Render
+	return video_engine.EstimateRender(outputDuration, work, throughput, samples, renderCfg.CostPerMinute, renderCfg.CostCurrency), nil
+}
+
+// historicalThroughput averages the throughput of recent completed renders.
+func (s *RenderTaskService) historicalThroughput() (float64, int) {
+	var tasks []models.RenderTask
+	if err := s.db.Select("work", "started_at", "completed_at").
+		Where("status = ? AND work > 0 AND started_at IS NOT NULL AND completed_at IS NOT NULL", models.RenderStatusCompleted).
+		Order("completed_at DESC").Limit(throughputHistorySize).
+		Find(&tasks).Error; err != nil {
+		logger.Warnf("Failed to load render history: %v", err)
+		return 0, 0
+	}
+
+	work := make([]float64, 0, len(tasks))
+	elapsed := make([]float64, 0, len(tasks))
+	for _, task := range tasks {
+		work = append(work, task.Work)
+		elapsed = append(elapsed, task.CompletedAt.Sub(*task.StartedAt).Seconds())
+	}
+
+	throughput, ok := video_engine.ThroughputFromHistory(work, elapsed)
+	if !ok {
+		return 0, 0
+	}
+	return throughput, len(tasks)
+}
+
+func (s *RenderTaskService) enqueue(task *models.RenderTask) error {
+	options := map[string]interface{}{
+		"project_id":    task.ProjectID,
+		"output_format": task.OutputFormat,
+		"quality":       task.Quality,
+		"resolution":    task.Resolution,
+		"frame_rate":    task.FrameRate,
+	}
+
+	var err error
+	if task.Mode == models.RenderModePreview {
+		err = queue.PublishPreviewRenderTask(task.TaskID, options, task.TraceID)
+	} else {
+		err = queue.PublishRenderTask(task.TaskID, options, task.TraceID)
+	}
+
+	if err != nil {
+		logger.Errorf("Failed to queue render task %s: %v", task.TaskID, err)
+		s.db.Model(task).Updates(map[string]interface{}{
+			"status":        models.RenderStatusFailed,
+			"error_message": "failed to queue render task",
+		})
+		return errors.New("failed to queue render task")
+	}
+
+	return nil
+}
+
+func (s *RenderTaskService) GetRenderTaskByID(id, userID uint) (*models.RenderTask, error) {
+	var task models.RenderTask
+	query := s.db
+
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if err := query.First(&task, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("render task not found")
+		}
+		logger.Errorf("Failed to get render task: %v", err)
+		return nil, errors.New("failed to get render task")
+	}
+
+	return &task, nil
+}
+
+// GetProjectsRenderTasks returns the user's render tasks of the projects,
+// newest first.
+func (s *RenderTaskService) GetProjectsRenderTasks(projectIDs []uint, userID uint) ([]models.RenderTask, error) {
+	var tasks []models.RenderTask
+	if err := s.db.Where("project_id IN ? AND user_id = ?", projectIDs, userID).
+		Order(keysetByCreated.order()).Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get render tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// ListRenderTasks lists the user's render tasks, newest first, from the
+// page or after the cursor when one is given. It returns the cursor of the
+// next page, if any.
+func (s *RenderTaskService) ListRenderTasks(userID uint, projectID uint, groupID, status string, page, limit int, cursor string) ([]models.RenderTask, int64, string, error) {
+	var tasks []models.RenderTask
+	var total int64
+
+	query := s.db.Model(&models.RenderTask{}).Where("user_id = ?", userID)
+	if projectID > 0 {
+		query = query.Where("project_id = ?", projectID)
+	}
+	if groupID != "" {
+		query = query.Where("group_id = ?", groupID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if cursor != "" {
+		var err error
+		if query, err = keysetByCreated.after(query, cursor); err != nil {
+			return nil, 0, "", err
+		}
+	} else {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, "", fmt.Errorf("failed to count render tasks: %w", err)
+		}
+		query = query.Offset((page - 1) * limit)
+	}
+
+	if err := query.Limit(limit + 1).Order(keysetByCreated.order()).Find(&tasks).Error; err != nil {
+		return nil, 0, "", fmt.Errorf("failed to get render tasks: %w", err)
+	}
+
+	var nextCursor string
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+		last := tasks[len(tasks)-1]
+		nextCursor = keysetByCreated.cursor(last.CreatedAt, last.ID)
+	}
+	return tasks, total, nextCursor, nil
+}
+
+// CancelRenderTask marks a pending or processing task as cancelled. Pending
+// tasks are skipped when a worker picks them up; running renders are killed.
+func (s *RenderTaskService) CancelRenderTask(id, userID uint) (*models.RenderTask, error) {
+	task, err := s.GetRenderTaskByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Status != models.RenderStatusPending && task.Status != models.RenderStatusProcessing {
+		return nil, errors.New("render task cannot be cancelled")
+	}
+
+	now := time.Now()
+	if err := s.db.Model(task).Updates(map[string]interface{}{
+		"status":       models.RenderStatusCancelled,
+		"completed_at": now,
+	}).Error; err != nil {
+		logger.Errorf("Failed to cancel render task %s: %v", task.TaskID, err)
+		return nil, errors.New("failed to cancel render task")
+	}
+
+	// Outputs of a group share one FFmpeg process, which keeps running for
+	// the outputs still wanted; this one is discarded when the render ends.
+	if task.GroupID != "" && s.groupStillRendering(task.GroupID) {
+		logger.Infof("Render task %s cancelled", task.TaskID)
+		return task, nil
+	}
+
+	// Stop the FFmpeg process on whichever worker is running the render. A
+	// pending task may be claimed concurrently, so signal in either case.
+	if err := queue.PublishRenderCancel(task.TaskID); err != nil {
+		logger.Errorf("Failed to signal cancellation of render task %s: %v", task.TaskID, err)
+	}
+
+	logger.Infof("Render task %s cancelled", task.TaskID)
+	return task, nil
+}
+
+// groupStillRendering reports whether any output of the group is still
+// pending or processing.
+func (s *RenderTaskService) groupStillRendering(groupID string) bool {
+	var active int64
+	if err := s.db.Model(&models.RenderTask{}).
+		Where("group_id = ? AND status IN ?", groupID, []string{models.RenderStatusPending, models.RenderStatusProcessing}).
+		Count(&active).Error; err != nil {
+		logger.Warnf("Failed to check render group %s: %v", groupID, err)
+		return false
+	}
+	return active > 0
+}
+
+// RetryRenderTask requeues a failed or cancelled task. Long renders resume
+// from the chunks the previous attempt finished.
+func (s *RenderTaskService) RetryRenderTask(id, userID uint) (*models.RenderTask, error) {
+	task, err := s.GetRenderTaskByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Status != models.RenderStatusFailed && task.Status != models.RenderStatusCancelled {
+		return nil, errors.New("render task cannot be retried")
+	}
+
+	if err := s.db.Model(task).Updates(map[string]interface{}{
+		"status":        models.RenderStatusPending,
+		"progress":      0,
+		"error_message": "",
+		"started_at":    nil,
+		"completed_at":  nil,
+		"retry_count":   gorm.Expr("retry_count + 1"),
+	}).Error; err != nil {
+		logger.Errorf("Failed to reset render task %s: %v", task.TaskID, err)
+		return nil, errors.New("failed to retry render task")
+	}
+
+	// Reload so the response carries the incremented retry count
+	if err := s.db.First(task, task.ID).Error; err != nil {
+		return nil, errors.New("failed to retry render task")
+	}
+
+	if err := s.enqueue(task); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Render task %s requeued (retry %d)", task.TaskID, task.RetryCount)
+	return task, nil
+}
+
+// RenderTaskStatus adds a live ETA to the task, extrapolated from progress
+// once rendering has started and from the initial estimate before that.
+func (s *RenderTaskService) RenderTaskStatus(task models.RenderTask) models.RenderTaskStatus {
+	status := models.RenderTaskStatus{RenderTask: task}
+
+	switch task.Status {
+	case models.RenderStatusPending:
+		if task.EstimatedTime > 0 {
+			eta := task.EstimatedTime
+			status.ETA = &eta
+		}
+	case models.RenderStatusProcessing:
+		if task.StartedAt == nil {
+			break
+		}
+		elapsed := time.Since(*task.StartedAt).Seconds()
+		if task.Progress > 0 {
+			eta := int(elapsed * float64(100-task.Progress) / float64(task.Progress))
+			status.ETA = &eta
+		} else if task.EstimatedTime > 0 {
+			eta := task.EstimatedTime - int(elapsed)
+			if eta < 0 {
+				eta = 0
+			}
+			status.ETA = &eta
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/auth"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/eventbus"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// rolesChangedEvent tells every instance to reload role permissions
+	rolesChangedEvent = "roles.changed"
+	// rolePermissionsTTL bounds how stale permissions get if an event is
+	// missed
+	rolePermissionsTTL = time.Minute
+)
+
+// rolePermissions caches the permissions of every role, since they are
+// checked on each request.
+var rolePermissions = struct {
+	sync.RWMutex
+	byRole   map[string]map[string]bool
+	loadedAt time.Time
+}{}
+
+func init() {
+	eventbus.Subscribe(rolesChangedEvent, func(event *eventbus.Event) error {
+		rolePermissions.Lock()
+		rolePermissions.byRole = nil
+		rolePermissions.Unlock()
+		return nil
+	})
+}
+
+type RoleService struct {
+	db *gorm.DB
+}
+
+func NewRoleService() *RoleService {
+	return &RoleService{
+		db: database.GetDB(),
+	}
+}
+
+// SeedDefaultRoles creates the default roles that do not exist yet.
+// Existing roles are left alone: admins may have changed them. The admin
+// role is granted permissions added since it was seeded.
+func (s *RoleService) SeedDefaultRoles() error {
+	for _, role := range models.DefaultRoles {
+		seeded := role
+		seeded.IsSystem = true
+		var existing models.Role
+		if err := s.db.Where(models.Role{Name: role.Name}).Attrs(seeded).FirstOrCreate(&existing).Error; err != nil {
+			return fmt.Errorf("failed to seed role %s: %w", role.Name, err)
+		}
+
+		if existing.Name == models.RoleAdmin && len(existing.Permissions) != len(models.Permissions) {
+			if err := s.db.Model(&existing).Update("permissions", models.StringArray(models.Permissions)).Error; err != nil {
+				return fmt.Errorf("failed to update role %s: %w", role.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// HasPermission reports whether the role grants the permission.
+func (s *RoleService) HasPermission(roleName, permission string) (bool, error) {
+	rolePermissions.RLock()
+	byRole, loadedAt := rolePermissions.byRole, rolePermissions.loadedAt
+	rolePermissions.RUnlock()
+
+	if byRole == nil || time.Since(loadedAt) > rolePermissionsTTL {
+		var err error
+		if byRole, err = s.loadPermissions(); err != nil {
+			return false, err
+		}
+	}
+
+	return byRole[roleName][permission], nil
+}
+
+func (s *RoleService) loadPermissions() (map[string]map[string]bool, error) {
+	var roles []models.Role
+	if err := s.db.Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to load roles: %w", err)
+	}
+
+	byRole := make(map[string]map[string]bool, len(roles))
+	for _, role := range roles {
+		byRole[role.Name] = make(map[string]bool, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			byRole[role.Name][permission] = true
+		}
+	}
+
+	rolePermissions.Lock()
+	rolePermissions.byRole = byRole
+	rolePermissions.loadedAt = time.Now()
+	rolePermissions.Unlock()
+	return byRole, nil
+}
+
+// rolesChanged has every instance reload permissions.
+func (s *RoleService) rolesChanged() {
+	if err := eventbus.Publish(rolesChangedEvent, nil); err != nil {
+		logger.Warnf("Failed to announce role change: %v", err)
+	}
+}
+
+func (s *RoleService) ListRoles() ([]models.Role, error) {
+	var roles []models.Role
+	if err := s.db.Order("name ASC").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (s *RoleService) GetRole(name string) (*models.Role, error) {
+	var role models.Role
+	if err := s.db.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("role not found")
+		}
+		logger.Errorf("Failed to get role: %v", err)
+		return nil, errors.New("failed to get role")
+	}
+
+	return &role, nil
+}
+
+func validatePermissions(permissions []string) error {
+	for _, permission := range permissions {
+		if !containsString(models.Permissions, permission) {
+			return errors.New("invalid permission")
+		}
+	}
+	return nil
+}
+
+func (s *RoleService) CreateRole(req *models.RoleCreateRequest) (*models.Role, error) {
+	if err := validatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
+	var existing models.Role
+	if err := s.db.Where("name = ?", req.Name).First(&existing).Error; err == nil {
+		return nil, errors.New("role already exists")
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	}
+	if err := s.db.Create(role).Error; err != nil {
+		logger.Errorf("Failed to create role: %v", err)
+		return nil, errors.New("failed to create role")
+	}
+
+	s.rolesChanged()
+	return role, nil
+}
+
+func (s *RoleService) UpdateRole(name string, req *models.RoleUpdateRequest) (*models.Role, error) {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Permissions != nil {
+		if err := validatePermissions(req.Permissions); err != nil {
+			return nil, err
+		}
+		// Keeps a way to administer roles, and new permissions reach it
+		if role.Name == models.RoleAdmin {
+			return nil, errors.New("the admin role's permissions cannot be changed")
+		}
+		role.Permissions = req.Permissions
+	}
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+
+	if err := s.db.Save(role).Error; err != nil {
+		logger.Errorf("Failed to update role: %v", err)
+		return nil, errors.New("failed to update role")
+	}
+
+	s.rolesChanged()
+	return role, nil
+}
+
+func (s *RoleService) DeleteRole(name string) error {
+	role, err := s.GetRole(name)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return errors.New("system roles cannot be deleted")
+	}
+
+	var users int64
+	if err := s.db.Model(&models.User{}).Where("role = ?", name).Count(&users).Error; err != nil {
+		logger.Errorf("Failed to count users of role: %v", err)
+		return errors.New("failed to delete role")
+	}
+	if users > 0 {
+		return errors.New("role is assigned to users")
+	}
+
+	if err := s.db.Delete(role).Error; err != nil {
+		logger.Errorf("Failed to delete role: %v", err)
+		return errors.New("failed to delete role")
+	}
+
+	s.rolesChanged()
+	return nil
+}
+
+// AssignUserRole changes the role of a user and returns the user with the
+// role they had. Access tokens issued before carry the old role, so they
+// are revoked; refreshing picks up the new one.
+func (s *RoleService) AssignUserRole(userID uint, roleName string) (*models.User, string, error) {
+	if _, err := s.GetRole(roleName); err != nil {
+		return nil, "", err
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", errors.New("user not found")
+		}
+		return nil, "", errors.New("failed to get user")
+	}
+
+	previousRole := user.Role
+	if err := s.db.Model(&user).Update("role", roleName).Error; err != nil {
+		logger.Errorf("Failed to update user role: %v", err)
+		return nil, "", errors.New("failed to update user role")
+	}
+
+	if err := auth.RevokeUserTokens(user.ID, config.AppConfig.JWT.ExpiresIn); err != nil {
+		logger.Warnf("Failed to revoke tokens of user %d after role change: %v", user.ID, err)
+	}
+
+	logger.Infof("User %d role changed to %s", user.ID, roleName)
+	return &user, previousRole, nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/realtime"
+)
+
+// newMatchPreview is how many of the new matches a notification lists
+const newMatchPreview = 5
+
+type SavedSearchService struct {
+	db    *gorm.DB
+	clips *AtomicClipService
+}
+
+func NewSavedSearchService() *SavedSearchService {
+	return &SavedSearchService{
+		db:    database.GetDB(),
+		clips: NewAtomicClipService(),
+	}
+}
+
+// CreateSavedSearch saves a clip search in the user's personal workspace,
+// or in the organization's when organizationID is set. New matches are
+// looked for from now on.
+func (s *SavedSearchService) CreateSavedSearch(userID uint, organizationID *uint, req *models.SavedSearchCreateRequest) (*models.SavedSearch, error) {
+	if allowed, err := canAccess(s.db, userID, userID, organizationID, true); err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to create saved search")
+	} else if !allowed {
+		return nil, errors.New("insufficient organization role")
+	}
+
+	now := time.Now()
+	savedSearch := &models.SavedSearch{
+		Name:           req.Name,
+		UserID:         userID,
+		OrganizationID: organizationID,
+		Criteria:       req.Criteria,
+		Notify:         req.Notify,
+		CheckedAt:      &now,
+	}
+	if err := s.db.Create(savedSearch).Error; err != nil {
+		logger.Errorf("Failed to create saved search: %v", err)
+		return nil, errors.New("failed to create saved search")
+	}
+
+	return savedSearch, nil
+}
+
+// ListSavedSearches returns a page of the workspace's saved searches, most
+// recently changed first.
+func (s *SavedSearchService) ListSavedSearches(userID uint, organizationID *uint, req *models.SavedSearchListRequest) ([]models.SavedSearch, int64, error) {
+	query := s.db.Model(&models.SavedSearch{}).Scopes(workspaceScope(userID, organizationID))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count saved searches: %w", err)
+	}
+
+	var savedSearches []models.SavedSearch
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order("updated_at DESC").Offset(offset).Limit(req.Limit).Find(&savedSearches).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get saved searches: %w", err)
+	}
+
+	return savedSearches, total, nil
+}
+
+// GetSavedSearch returns a saved search the user can read.
+func (s *SavedSearchService) GetSavedSearch(savedSearchID, userID uint) (*models.SavedSearch, error) {
+	return s.getSavedSearch(savedSearchID, userID, false)
+}
+
+func (s *SavedSearchService) getSavedSearch(savedSearchID, userID uint, write bool) (*models.SavedSearch, error) {
+	var savedSearch models.SavedSearch
+	if err := s.db.First(&savedSearch, savedSearchID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("saved search not found")
+		}
+		logger.Errorf("Failed to get saved search: %v", err)
+		return nil, errors.New("failed to get saved search")
+	}
+
+	readable, err := canAccess(s.db, userID, savedSearch.UserID, savedSearch.OrganizationID, false)
+	if err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to get saved search")
+	}
+	if !readable {
+		return nil, errors.New("saved search not found")
+	}
+
+	if write {
+		writable, err := canAccess(s.db, userID, savedSearch.UserID, savedSearch.OrganizationID, true)
+		if err != nil {
+			logger.Errorf("Failed to check organization access: %v", err)
+			return nil, errors.New("failed to get saved search")
+		}
+		if !writable {
+			return nil, errors.New("insufficient organization role")
+		}
+	}
+
+	return &savedSearch, nil
+}
+
+func (s *SavedSearchService) UpdateSavedSearch(savedSearchID, userID uint, req *models.SavedSearchUpdateRequest) (*models.SavedSearch, error) {
+	savedSearch, err := s.getSavedSearch(savedSearchID, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.Criteria != nil {
+		updates["criteria"] = *req.Criteria
+	}
+	if req.Notify != nil {
+		updates["notify"] = *req.Notify
+		// Clips added while notifications were off are not new matches
+		if *req.Notify && !savedSearch.Notify {
+			updates["checked_at"] = time.Now()
+		}
+	}
+	if len(updates) > 0 {
+		if err := s.db.Model(savedSearch).Updates(updates).Error; err != nil {
+			logger.Errorf("Failed to update saved search: %v", err)
+			return nil, errors.New("failed to update saved search")
+		}
+	}
+
+	return s.GetSavedSearch(savedSearch.ID, userID)
+}
+
+func (s *SavedSearchService) DeleteSavedSearch(savedSearchID, userID uint) error {
+	savedSearch, err := s.getSavedSearch(savedSearchID, userID, true)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(savedSearch).Error; err != nil {
+		logger.Errorf("Failed to delete saved search: %v", err)
+		return errors.New("failed to delete saved search")
+	}
+
+	return nil
+}
+
+// RunSavedSearch returns a page of the clips the saved search matches now,
+// searched in the workspace it was saved in.
+func (s *SavedSearchService) RunSavedSearch(savedSearchID, userID uint, req *models.SavedSearchRunRequest) (*models.SavedSearch, *models.ClipSearchResult, error) {
+	savedSearch, err := s.GetSavedSearch(savedSearchID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	searchReq := savedSearch.Criteria.SearchRequest()
+	searchReq.Page = req.Page
+	searchReq.Limit = req.Limit
+	searchReq.Cursor = req.Cursor
+	searchReq.Facets = req.Facets
+	result, err := s.clips.SearchAtomicClips(searchReq, userID, savedSearch.OrganizationID)
+	if err != nil {
+		switch err.Error() {
+		case "invalid cursor", "query is required for semantic search", "semantic search is not enabled":
+			return nil, nil, err
+		}
+		logger.Errorf("Failed to run saved search: %v", err)
+		return nil, nil, errors.New("failed to run saved search")
+	}
+
+	return savedSearch, result, nil
+}
+
+// NotifyNewMatches looks for clips added since each saved search with
+// notifications on was last checked, and tells the creators of those with
+// new matches. It returns how many notifications were sent.
+func (s *SavedSearchService) NotifyNewMatches() (int, error) {
+	var notified int
+	var savedSearches []models.SavedSearch
+	err := s.db.Where("notify = ?", true).FindInBatches(&savedSearches, 100, func(tx *gorm.DB, batch int) error {
+		for i := range savedSearches {
+			sent, err := s.notifyNewMatches(&savedSearches[i])
+			if err != nil {
+				// One failing search must not hold back the others
+				logger.Warnf("Failed to check saved search %d for new matches: %v", savedSearches[i].ID, err)
+				continue
+			}
+			if sent {
+				notified++
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return notified, fmt.Errorf("failed to get saved searches: %w", err)
+	}
+
+	return notified, nil
+}
+
+func (s *SavedSearchService) notifyNewMatches(savedSearch *models.SavedSearch) (bool, error) {
+	// Creators who left the organization are no longer told about its clips
+	readable, err := canAccess(s.db, savedSearch.UserID, savedSearch.UserID, savedSearch.OrganizationID, false)
+	if err != nil || !readable {
+		return false, err
+	}
+
+	now := time.Now()
+	since := savedSearch.CreatedAt
+	if savedSearch.CheckedAt != nil {
+		since = *savedSearch.CheckedAt
+	}
+
+	searchReq := savedSearch.Criteria.SearchRequest()
+	searchReq.Limit = newMatchPreview
+	searchReq.CreatedAfter = &since
+	result, err := s.clips.SearchAtomicClips(searchReq, savedSearch.UserID, savedSearch.OrganizationID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.db.Model(savedSearch).UpdateColumn("checked_at", now).Error; err != nil {
+		return false, err
+	}
+	if result.Total == 0 {
+		return false, nil
+	}
+
+	clipIDs := make([]uint, len(result.Clips))
+	for i, clip := range result.Clips {
+		clipIDs[i] = clip.ID
+	}
+	realtime.Publish(savedSearch.UserID, realtime.EventSavedSearchMatch, map[string]interface{}{
+		"saved_search_id": savedSearch.ID,
+		"name":            savedSearch.Name,
+		"new_matches":     result.Total,
+		"clip_ids":        clipIDs,
+	})
+	return true, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/scheduler"
+)
+
+type ScheduleService struct {
+	db *gorm.DB
+}
+
+func NewScheduleService() *ScheduleService {
+	return &ScheduleService{
+		db: database.GetDB(),
+	}
+}
+
+func (s *ScheduleService) ListSchedules() ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	if err := s.db.Order("name ASC").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func (s *ScheduleService) GetSchedule(name string) (*models.Schedule, error) {
+	var schedule models.Schedule
+	if err := s.db.Where("name = ?", name).First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("schedule not found")
+		}
+		logger.Errorf("Failed to get schedule: %v", err)
+		return nil, errors.New("failed to get schedule")
+	}
+
+	return &schedule, nil
+}
+
+// UpdateSchedule changes the spec or enables/disables a schedule. The next
+// run is planned from now whenever either changes.
+func (s *ScheduleService) UpdateSchedule(name string, req *models.ScheduleUpdateRequest) (*models.Schedule, error) {
+	schedule, err := s.GetSchedule(name)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := schedule.Spec
+	if req.Spec != nil {
+		spec = *req.Spec
+	}
+	parsed, err := scheduler.ParseSpec(spec)
+	if err != nil {
+		return nil, errors.New("invalid schedule spec")
+	}
+
+	updates := map[string]interface{}{
+		"spec":        spec,
+		"next_run_at": parsed.Next(time.Now()),
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+
+	if err := s.db.Model(schedule).Updates(updates).Error; err != nil {
+		logger.Errorf("Failed to update schedule: %v", err)
+		return nil, errors.New("failed to update schedule")
+	}
+
+	return s.GetSchedule(name)
+}
+
+// TriggerSchedule makes a schedule due now; the scheduler leader picks it
+// up on its next tick.
+func (s *ScheduleService) TriggerSchedule(name string) (*models.Schedule, error) {
+	schedule, err := s.GetSchedule(name)
+	if err != nil {
+		return nil, err
+	}
+	if !schedule.Enabled {
+		return nil, errors.New("schedule is disabled")
+	}
+
+	if err := s.db.Model(schedule).Update("next_run_at", time.Now()).Error; err != nil {
+		logger.Errorf("Failed to trigger schedule: %v", err)
+		return nil, errors.New("failed to trigger schedule")
+	}
+
+	return s.GetSchedule(name)
+}
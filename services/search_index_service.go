@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/queue"
+	"creative-studio-server/pkg/search"
+)
+
+// searchIndexBatchSize is how many clips one reindex task indexes
+const searchIndexBatchSize = 500
+
+// SearchIndexService keeps the search index in step with the clips.
+type SearchIndexService struct {
+	db *gorm.DB
+}
+
+func NewSearchIndexService() *SearchIndexService {
+	return &SearchIndexService{
+		db: database.GetDB(),
+	}
+}
+
+// indexClips queues the clips to be updated in the search index. Searches
+// see the change once the task has run.
+func indexClips(clipIDs ...uint) {
+	if err := queue.PublishSearchIndexTask(clipIDs, ""); err != nil {
+		logger.Warnf("Failed to queue search indexing of %d clips: %v", len(clipIDs), err)
+	}
+}
+
+// Reindex queues the indexing of every clip, to fill a new index or repair
+// one that missed updates.
+func (s *SearchIndexService) Reindex(traceID string) error {
+	if !search.Enabled() {
+		return errors.New("search index is not enabled")
+	}
+	if err := queue.PublishSearchReindexTask(0, traceID); err != nil {
+		logger.Errorf("Failed to queue search reindex: %v", err)
+		return errors.New("failed to queue search reindex")
+	}
+
+	logger.Info("Search reindex queued")
+	return nil
+}
+
+// SearchIndexTaskHandler indexes the clips of the task, or the next batch
+// of every clip for a reindex.
+func SearchIndexTaskHandler(task *queue.Task) error {
+	s := NewSearchIndexService()
+	if all, _ := task.Payload["all"].(bool); all {
+		afterID, _ := task.Payload["after_id"].(float64)
+		return s.reindexBatch(task, uint(afterID))
+	}
+
+	values, ok := task.Payload["clip_ids"].([]interface{})
+	if !ok {
+		return fmt.Errorf("invalid clip_ids in task payload")
+	}
+	clipIDs := make([]uint, 0, len(values))
+	for _, value := range values {
+		id, ok := value.(float64) // JSON numbers are float64
+		if !ok {
+			return fmt.Errorf("invalid clip_ids in task payload")
+		}
+		clipIDs = append(clipIDs, uint(id))
+	}
+	return s.index(clipIDs)
+}
+
+// index upserts the clips that exist and removes the deleted ones.
+func (s *SearchIndexService) index(clipIDs []uint) error {
+	var clips []models.AtomicClip
+	if err := s.db.Preload("Transcript").Where("id IN ?", clipIDs).Find(&clips).Error; err != nil {
+		return fmt.Errorf("failed to load clips to index: %w", err)
+	}
+
+	found := make(map[uint]bool, len(clips))
+	docs := make([]search.Document, len(clips))
+	for i := range clips {
+		found[clips[i].ID] = true
+		docs[i] = search.NewDocument(&clips[i])
+	}
+	var deleted []uint
+	for _, id := range clipIDs {
+		if !found[id] {
+			deleted = append(deleted, id)
+		}
+	}
+
+	if err := search.Upsert(docs); err != nil {
+		return fmt.Errorf("failed to index clips: %w", err)
+	}
+	if err := search.Delete(deleted); err != nil {
+		return fmt.Errorf("failed to remove clips from the index: %w", err)
+	}
+	return nil
+}
+
+// reindexBatch indexes the clips after afterID and queues the next batch.
+func (s *SearchIndexService) reindexBatch(task *queue.Task, afterID uint) error {
+	var clips []models.AtomicClip
+	if err := s.db.Preload("Transcript").Where("id > ?", afterID).Order("id").Limit(searchIndexBatchSize).Find(&clips).Error; err != nil {
+		return fmt.Errorf("failed to load clips to index: %w", err)
+	}
+	if len(clips) == 0 {
+		task.Logger().Info("Search reindex completed")
+		return nil
+	}
+
+	docs := make([]search.Document, len(clips))
+	for i := range clips {
+		docs[i] = search.NewDocument(&clips[i])
+	}
+	if err := search.Upsert(docs); err != nil {
+		return fmt.Errorf("failed to index clips: %w", err)
+	}
+
+	lastID := clips[len(clips)-1].ID
+	task.Logger().Infof("Search reindex indexed clips up to %d", lastID)
+	if len(clips) < searchIndexBatchSize {
+		task.Logger().Info("Search reindex completed")
+		return nil
+	}
+	return queue.PublishSearchReindexTask(lastID, task.TraceID)
+}
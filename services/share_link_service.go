@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/auth"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+type ShareLinkService struct {
+	db *gorm.DB
+}
+
+func NewShareLinkService() *ShareLinkService {
+	return &ShareLinkService{
+		db: database.GetDB(),
+	}
+}
+
+// CreateShareLink shares a resource the user may change and returns the
+// link with its token, which cannot be retrieved later. Renders can only
+// be shared once completed.
+func (s *ShareLinkService) CreateShareLink(userID uint, resourceType string, resourceID uint, req *models.ShareLinkCreateRequest) (*models.ShareLink, string, error) {
+	link := &models.ShareLink{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Scope:        req.Scope,
+		UserID:       userID,
+	}
+	if link.Scope == "" {
+		link.Scope = models.ShareScopeView
+	}
+
+	switch resourceType {
+	case models.ShareResourceClip:
+		var clip models.AtomicClip
+		if err := s.db.First(&clip, resourceID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, "", errors.New("atomic clip not found")
+			}
+			logger.Errorf("Failed to get atomic clip: %v", err)
+			return nil, "", errors.New("failed to create share link")
+		}
+		if err := NewAtomicClipService().checkAccess(&clip, userID, true); err != nil {
+			return nil, "", err
+		}
+		link.OrganizationID = clip.OrganizationID
+	case models.ShareResourceComposition:
+		if _, err := NewCompositionService().GetCompositionByID(resourceID, userID); err != nil {
+			return nil, "", err
+		}
+	case models.ShareResourceRender:
+		task, err := NewRenderTaskService().GetRenderTaskByID(resourceID, userID)
+		if err != nil {
+			return nil, "", err
+		}
+		if task.Status != models.RenderStatusCompleted {
+			return nil, "", errors.New("render is not completed")
+		}
+	default:
+		return nil, "", errors.New("invalid share resource")
+	}
+
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			logger.Errorf("Failed to hash share link password: %v", err)
+			return nil, "", errors.New("failed to create share link")
+		}
+		link.PasswordHash = string(hash)
+	}
+	if req.ExpiresInHours != nil {
+		expiresAt := time.Now().Add(time.Duration(*req.ExpiresInHours) * time.Hour)
+		link.ExpiresAt = &expiresAt
+	}
+
+	token, hash, err := auth.NewShareToken()
+	if err != nil {
+		logger.Errorf("Failed to generate share token: %v", err)
+		return nil, "", errors.New("failed to create share link")
+	}
+	link.TokenHash = hash
+
+	if err := s.db.Create(link).Error; err != nil {
+		logger.Errorf("Failed to create share link: %v", err)
+		return nil, "", errors.New("failed to create share link")
+	}
+
+	logger.Infof("Share link %d created for %s %d by user %d", link.ID, resourceType, resourceID, userID)
+	return link, token, nil
+}
+
+// ListShareLinks returns the links the user created, newest first.
+func (s *ShareLinkService) ListShareLinks(userID uint, req *models.ShareLinkListRequest) ([]models.ShareLink, int64, error) {
+	query := s.db.Model(&models.ShareLink{}).Where("user_id = ?", userID)
+	if req.ResourceType != "" {
+		query = query.Where("resource_type = ?", req.ResourceType)
+	}
+	if req.ResourceID != 0 {
+		query = query.Where("resource_id = ?", req.ResourceID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("Failed to count share links: %v", err)
+		return nil, 0, errors.New("failed to get share links")
+	}
+
+	var links []models.ShareLink
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(req.Limit).Find(&links).Error; err != nil {
+		logger.Errorf("Failed to get share links: %v", err)
+		return nil, 0, errors.New("failed to get share links")
+	}
+
+	return links, total, nil
+}
+
+// RevokeShareLink stops the link from working at once.
+func (s *ShareLinkService) RevokeShareLink(linkID, userID uint) (*models.ShareLink, error) {
+	var link models.ShareLink
+	if err := s.db.Where("id = ? AND user_id = ?", linkID, userID).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("share link not found")
+		}
+		logger.Errorf("Failed to get share link: %v", err)
+		return nil, errors.New("failed to get share link")
+	}
+	if link.RevokedAt != nil {
+		return nil, errors.New("share link is already revoked")
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&link).Update("revoked_at", now).Error; err != nil {
+		logger.Errorf("Failed to revoke share link: %v", err)
+		return nil, errors.New("failed to revoke share link")
+	}
+	link.RevokedAt = &now
+
+	return &link, nil
+}
+
+// openShareLink returns the link of a token that is still valid, once its
+// password, if it has one, is given.
+func (s *ShareLinkService) openShareLink(token, password string) (*models.ShareLink, error) {
+	var link models.ShareLink
+	if err := s.db.Where("token_hash = ?", auth.HashShareToken(token)).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("share link not found")
+		}
+		logger.Errorf("Failed to get share link: %v", err)
+		return nil, errors.New("failed to get share link")
+	}
+	if link.RevokedAt != nil {
+		return nil, errors.New("share link not found")
+	}
+	if link.ExpiresAt != nil && time.Now().After(*link.ExpiresAt) {
+		return nil, errors.New("share link has expired")
+	}
+
+	if link.Protected() {
+		if password == "" {
+			return nil, errors.New("password required")
+		}
+		if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+			return nil, errors.New("incorrect password")
+		}
+	}
+
+	return &link, nil
+}
+
+// ViewShareLink returns what the public viewer shows of the shared
+// resource, and counts the view.
+func (s *ShareLinkService) ViewShareLink(token, password string) (*models.SharedResource, error) {
+	link, err := s.openShareLink(token, password)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := &models.SharedResource{
+		ResourceType: link.ResourceType,
+		Scope:        link.Scope,
+		ExpiresAt:    link.ExpiresAt,
+	}
+	switch link.ResourceType {
+	case models.ShareResourceClip:
+		var clip models.AtomicClip
+		if err := s.db.First(&clip, link.ResourceID).Error; err != nil {
+			return nil, sharedResourceError(err)
+		}
+		shared.Title = clip.Title
+		shared.Description = clip.Description
+		shared.Duration = clip.Duration
+		shared.Resolution = clip.Resolution
+		shared.Format = clip.Format
+		shared.HasMedia = clip.FilePath != ""
+	case models.ShareResourceComposition:
+		var composition models.Composition
+		if err := s.db.Preload("Project").First(&composition, link.ResourceID).Error; err != nil {
+			return nil, sharedResourceError(err)
+		}
+		shared.Title = composition.Project.Title
+		shared.Duration = composition.TotalDuration
+		shared.Timeline = composition.Timeline
+	case models.ShareResourceRender:
+		var task models.RenderTask
+		if err := s.db.Preload("Project").First(&task, link.ResourceID).Error; err != nil {
+			return nil, sharedResourceError(err)
+		}
+		shared.Title = task.Project.Title
+		shared.Duration = task.Duration
+		shared.Resolution = task.Resolution
+		shared.Format = task.OutputFormat
+		shared.HasMedia = task.OutputPath != "" && task.OutputFormat != "hls"
+	}
+
+	if err := s.db.Model(link).Updates(map[string]interface{}{
+		"view_count":     gorm.Expr("view_count + 1"),
+		"last_viewed_at": time.Now(),
+	}).Error; err != nil {
+		logger.Warnf("Failed to count view of share link %d: %v", link.ID, err)
+	}
+
+	return shared, nil
+}
+
+// SharedMediaPath returns the file of the shared clip or render. Downloads
+// take a link with the download scope.
+func (s *ShareLinkService) SharedMediaPath(token, password string, download bool) (string, error) {
+	link, err := s.openShareLink(token, password)
+	if err != nil {
+		return "", err
+	}
+	if download && link.Scope != models.ShareScopeDownload {
+		return "", errors.New("share link does not allow downloads")
+	}
+
+	var path string
+	switch link.ResourceType {
+	case models.ShareResourceClip:
+		var clip models.AtomicClip
+		if err := s.db.First(&clip, link.ResourceID).Error; err != nil {
+			return "", sharedResourceError(err)
+		}
+		path = clip.FilePath
+	case models.ShareResourceRender:
+		var task models.RenderTask
+		if err := s.db.First(&task, link.ResourceID).Error; err != nil {
+			return "", sharedResourceError(err)
+		}
+		// An HLS playlist is useless without its segments
+		if task.OutputFormat != "hls" {
+			path = task.OutputPath
+		}
+	}
+	if path == "" {
+		return "", errors.New("shared resource has no media")
+	}
+
+	return path, nil
+}
+
+// sharedResourceError hides a shared resource that was deleted behind the
+// link.
+func sharedResourceError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("share link not found")
+	}
+	logger.Errorf("Failed to get shared resource: %v", err)
+	return errors.New("failed to get shared resource")
+}
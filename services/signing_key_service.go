@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/auth"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/eventbus"
+	"creative-studio-server/pkg/logger"
+)
+
+const (
+	// signingKeysChangedEvent tells every instance to reload the keyset
+	signingKeysChangedEvent = "signing_keys.changed"
+	// keysetRefreshInterval bounds how stale the keyset gets if an event
+	// is missed
+	keysetRefreshInterval = time.Minute
+)
+
+func init() {
+	eventbus.Subscribe(signingKeysChangedEvent, func(event *eventbus.Event) error {
+		return NewSigningKeyService().LoadKeyset()
+	})
+}
+
+type SigningKeyService struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyService() *SigningKeyService {
+	return &SigningKeyService{
+		db: database.GetDB(),
+	}
+}
+
+// verifyWindow is how long a retired key keeps verifying: the longest an
+// access token it signed can live.
+func verifyWindow() time.Duration {
+	return config.AppConfig.JWT.ExpiresIn
+}
+
+// LoadKeyset installs the active key and the retired keys whose tokens may
+// still be valid. Without an active key tokens are signed with the JWT
+// secret.
+func (s *SigningKeyService) LoadKeyset() error {
+	var keys []models.SigningKey
+	if err := s.db.Where("revoked_at IS NULL AND (retired_at IS NULL OR retired_at > ?)", time.Now().Add(-verifyWindow())).
+		Order("created_at ASC").Find(&keys).Error; err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	var signing *auth.SigningKey
+	verifying := make([]*auth.SigningKey, 0, len(keys))
+	for i := range keys {
+		key := &keys[i]
+		if !key.Active() {
+			parsed, err := auth.ParseSigningKey(key.KeyID, "", key.PublicKey)
+			if err != nil {
+				logger.Errorf("Skipping signing key %s: %v", key.KeyID, err)
+				continue
+			}
+			verifying = append(verifying, parsed)
+			continue
+		}
+
+		privatePEM, err := auth.DecryptSecret(key.PrivateKey)
+		if err != nil {
+			logger.Errorf("Skipping signing key %s: %v", key.KeyID, err)
+			continue
+		}
+		parsed, err := auth.ParseSigningKey(key.KeyID, privatePEM, key.PublicKey)
+		if err != nil {
+			logger.Errorf("Skipping signing key %s: %v", key.KeyID, err)
+			continue
+		}
+		// The newest active key signs; an older one left by a race between
+		// instances rotating still verifies
+		if signing != nil {
+			verifying = append(verifying, signing)
+		}
+		signing = parsed
+	}
+
+	auth.SetKeyset(signing, verifying)
+	return nil
+}
+
+// EnsureActiveKey creates a signing key when there is none, so tokens stop
+// being signed with the JWT secret, then loads the keyset.
+func (s *SigningKeyService) EnsureActiveKey() error {
+	var count int64
+	if err := s.db.Model(&models.SigningKey{}).Where("retired_at IS NULL AND revoked_at IS NULL").Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count signing keys: %w", err)
+	}
+	if count == 0 {
+		if _, err := s.createKey(); err != nil {
+			return err
+		}
+	}
+
+	return s.LoadKeyset()
+}
+
+// RunKeysetRefresh reloads the keyset periodically, dropping retired keys
+// whose tokens have expired, until ctx is cancelled.
+func (s *SigningKeyService) RunKeysetRefresh(ctx context.Context) {
+	ticker := time.NewTicker(keysetRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.LoadKeyset(); err != nil {
+				logger.Warnf("Failed to refresh signing keys: %v", err)
+			}
+		}
+	}
+}
+
+func (s *SigningKeyService) ListKeys() ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	if err := s.db.Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get signing keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Rotate creates a new active key and retires the previous ones. Tokens
+// they signed stay valid until they expire.
+func (s *SigningKeyService) Rotate() (*models.SigningKey, error) {
+	key, err := s.createKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&models.SigningKey{}).
+		Where("id <> ? AND retired_at IS NULL AND revoked_at IS NULL", key.ID).
+		Update("retired_at", time.Now()).Error; err != nil {
+		logger.Errorf("Failed to retire signing keys: %v", err)
+		return nil, errors.New("failed to rotate signing key")
+	}
+
+	logger.Infof("Signing key rotated, %s is now active", key.KeyID)
+	s.keysChanged()
+	return key, nil
+}
+
+// RotateIfDue rotates when the active key is older than the rotation
+// interval. It reports whether it rotated.
+func (s *SigningKeyService) RotateIfDue() (bool, error) {
+	interval := config.AppConfig.JWT.KeyRotationInterval
+	if interval <= 0 {
+		return false, nil
+	}
+
+	var active models.SigningKey
+	err := s.db.Where("retired_at IS NULL AND revoked_at IS NULL").Order("created_at DESC").First(&active).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, fmt.Errorf("failed to get active signing key: %w", err)
+	}
+	if err == nil && time.Since(active.CreatedAt) < interval {
+		return false, nil
+	}
+
+	if _, err := s.Rotate(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Revoke stops a key from verifying at once, logging out every session it
+// signed; for keys that leaked. Revoking the active key rotates first.
+func (s *SigningKeyService) Revoke(keyID string) (*models.SigningKey, error) {
+	var key models.SigningKey
+	if err := s.db.Where("key_id = ?", keyID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("signing key not found")
+		}
+		return nil, errors.New("failed to get signing key")
+	}
+	if key.RevokedAt != nil {
+		return nil, errors.New("signing key is already revoked")
+	}
+
+	if key.Active() {
+		if _, err := s.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&key).Update("revoked_at", now).Error; err != nil {
+		logger.Errorf("Failed to revoke signing key: %v", err)
+		return nil, errors.New("failed to revoke signing key")
+	}
+	key.RevokedAt = &now
+
+	logger.Warnf("Signing key %s revoked", key.KeyID)
+	s.keysChanged()
+	return &key, nil
+}
+
+// PruneRetiredKeys deletes keys retired or revoked before the given time
+// that can no longer have valid tokens.
+func (s *SigningKeyService) PruneRetiredKeys(before time.Time) (int64, error) {
+	result := s.db.Where("(retired_at IS NOT NULL AND retired_at < ?) OR (revoked_at IS NOT NULL AND revoked_at < ?)",
+		before.Add(-verifyWindow()), before).Delete(&models.SigningKey{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune signing keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (s *SigningKeyService) createKey() (*models.SigningKey, error) {
+	generated, privatePEM, publicPEM, err := auth.NewSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := auth.EncryptSecret(privatePEM)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.SigningKey{
+		KeyID:      generated.ID,
+		Algorithm:  "ES256",
+		PrivateKey: encrypted,
+		PublicKey:  publicPEM,
+	}
+	if err := s.db.Create(key).Error; err != nil {
+		logger.Errorf("Failed to create signing key: %v", err)
+		return nil, errors.New("failed to create signing key")
+	}
+	return key, nil
+}
+
+// keysChanged has every instance reload the keyset.
+func (s *SigningKeyService) keysChanged() {
+	if err := eventbus.Publish(signingKeysChangedEvent, nil); err != nil {
+		logger.Warnf("Failed to announce signing key change: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+// tagBatchSize is how many clips are rewritten at a time
+const tagBatchSize = 500
+
+type TagService struct {
+	db *gorm.DB
+}
+
+func NewTagService() *TagService {
+	return &TagService{
+		db: database.GetDB(),
+	}
+}
+
+// tagLikePattern matches a normalized tag inside a clip's JSON tag array.
+func tagLikePattern(tag string) string {
+	return `%"` + strings.ReplaceAll(tag, "_", `\_`) + `"%`
+}
+
+// syncTags recounts the clips carrying each tag, creating tags on first use
+// and dropping those no clip carries any more.
+func syncTags(db *gorm.DB, names ...string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var count int64
+		if err := db.Model(&models.AtomicClip{}).Where("tags LIKE ?", tagLikePattern(name)).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to count clips tagged %q: %w", name, err)
+		}
+
+		if count == 0 {
+			if err := db.Where("name = ?", name).Delete(&models.Tag{}).Error; err != nil {
+				return fmt.Errorf("failed to delete tag %q: %w", name, err)
+			}
+			continue
+		}
+
+		tag := models.Tag{Name: name}
+		if err := db.Where(tag).Assign(models.Tag{UsageCount: int(count)}).FirstOrCreate(&tag).Error; err != nil {
+			return fmt.Errorf("failed to save tag %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Autocomplete suggests the most used tags starting with the prefix.
+func (s *TagService) Autocomplete(prefix string, limit int) ([]models.Tag, error) {
+	prefix = models.NormalizeTag(prefix)
+
+	query := s.db.Model(&models.Tag{}).Where("usage_count > 0")
+	if prefix != "" {
+		query = query.Where("name LIKE ?", strings.ReplaceAll(prefix, "_", `\_`)+"%")
+	}
+
+	var tags []models.Tag
+	if err := query.Order("usage_count DESC, name ASC").Limit(limit).Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	return tags, nil
+}
+
+// ListTags pages every tag, most used first.
+func (s *TagService) ListTags(req *models.TagListRequest) ([]models.Tag, int64, error) {
+	query := s.db.Model(&models.Tag{})
+	if prefix := models.NormalizeTag(req.Query); prefix != "" {
+		query = query.Where("name LIKE ?", strings.ReplaceAll(prefix, "_", `\_`)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count tags: %w", err)
+	}
+
+	var tags []models.Tag
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order("usage_count DESC, name ASC").Offset(offset).Limit(req.Limit).Find(&tags).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get tags: %w", err)
+	}
+	return tags, total, nil
+}
+
+func (s *TagService) getTag(tagID uint) (*models.Tag, error) {
+	var tag models.Tag
+	if err := s.db.First(&tag, tagID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("tag not found")
+		}
+		logger.Errorf("Failed to get tag: %v", err)
+		return nil, errors.New("failed to get tag")
+	}
+	return &tag, nil
+}
+
+// RenameTag renames the tag on every clip carrying it. Renaming to a tag
+// that exists is refused; merge the two instead.
+func (s *TagService) RenameTag(tagID uint, name string) (*models.Tag, error) {
+	tag, err := s.getTag(tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	name = models.NormalizeTag(name)
+	if name == "" {
+		return nil, errors.New("invalid tag name")
+	}
+	if name == tag.Name {
+		return tag, nil
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.Tag{}).Where("name = ?", name).Count(&existing).Error; err != nil {
+		logger.Errorf("Failed to check tag name: %v", err)
+		return nil, errors.New("failed to rename tag")
+	}
+	if existing > 0 {
+		return nil, errors.New("tag already exists")
+	}
+
+	var clipIDs []uint
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if clipIDs, err = replaceClipTag(tx, tag.Name, name); err != nil {
+			return err
+		}
+		return tx.Model(tag).Update("name", name).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to rename tag %q: %v", tag.Name, err)
+		return nil, errors.New("failed to rename tag")
+	}
+	invalidateClips(clipIDs)
+	indexClips(clipIDs...)
+
+	logger.Infof("Tag %q renamed to %q on %d clips", tag.Name, name, len(clipIDs))
+	tag.Name = name
+	return tag, nil
+}
+
+// MergeTag replaces the tag with the target tag on every clip and deletes
+// it.
+func (s *TagService) MergeTag(tagID, intoID uint) (*models.Tag, error) {
+	if tagID == intoID {
+		return nil, errors.New("cannot merge a tag into itself")
+	}
+	source, err := s.getTag(tagID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.getTag(intoID)
+	if err != nil {
+		return nil, err
+	}
+
+	var clipIDs []uint
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if clipIDs, err = replaceClipTag(tx, source.Name, target.Name); err != nil {
+			return err
+		}
+		if err := tx.Delete(source).Error; err != nil {
+			return err
+		}
+		return syncTags(tx, target.Name)
+	})
+	if err != nil {
+		logger.Errorf("Failed to merge tag %q into %q: %v", source.Name, target.Name, err)
+		return nil, errors.New("failed to merge tag")
+	}
+	invalidateClips(clipIDs)
+	indexClips(clipIDs...)
+
+	logger.Infof("Tag %q merged into %q on %d clips", source.Name, target.Name, len(clipIDs))
+	return s.getTag(target.ID)
+}
+
+// Reindex normalizes the tags of every clip, splitting those uploaded as
+// one comma-separated string, and rebuilds the tag table from them.
+func (s *TagService) Reindex() (int, error) {
+	counts := make(map[string]int)
+	var clipIDs []uint
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var clips []models.AtomicClip
+		result := tx.Select("id", "tags").Where("tags IS NOT NULL").FindInBatches(&clips, tagBatchSize, func(batch *gorm.DB, _ int) error {
+			for _, clip := range clips {
+				normalized := models.NormalizeTags(clip.Tags)
+				for _, tag := range normalized {
+					counts[tag]++
+				}
+				if equalTags(clip.Tags, normalized) {
+					continue
+				}
+				if err := tx.Model(&models.AtomicClip{}).Where("id = ?", clip.ID).UpdateColumn("tags", normalized).Error; err != nil {
+					return err
+				}
+				clipIDs = append(clipIDs, clip.ID)
+			}
+			return nil
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Tag{}).Error; err != nil {
+			return err
+		}
+		tags := make([]models.Tag, 0, len(counts))
+		for name, count := range counts {
+			tags = append(tags, models.Tag{Name: name, UsageCount: count})
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(tags, tagBatchSize).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to reindex tags: %v", err)
+		return 0, errors.New("failed to reindex tags")
+	}
+	invalidateClips(clipIDs)
+	indexClips(clipIDs...)
+
+	logger.Infof("Tags reindexed: %d tags, %d clips rewritten", len(counts), len(clipIDs))
+	return len(counts), nil
+}
+
+// replaceClipTag swaps one tag for another on every clip carrying it and
+// returns the clips changed.
+func replaceClipTag(tx *gorm.DB, from, to string) ([]uint, error) {
+	var clipIDs []uint
+	var clips []models.AtomicClip
+	result := tx.Select("id", "tags").Where("tags LIKE ?", tagLikePattern(from)).FindInBatches(&clips, tagBatchSize, func(batch *gorm.DB, _ int) error {
+		for _, clip := range clips {
+			tags := make([]string, len(clip.Tags))
+			for i, tag := range clip.Tags {
+				if tag == from {
+					tag = to
+				}
+				tags[i] = tag
+			}
+			if err := tx.Model(&models.AtomicClip{}).Where("id = ?", clip.ID).UpdateColumn("tags", models.NormalizeTags(tags)).Error; err != nil {
+				return err
+			}
+			clipIDs = append(clipIDs, clip.ID)
+		}
+		return nil
+	})
+	return clipIDs, result.Error
+}
+
+func equalTags(a, b models.StringArray) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func invalidateClips(clipIDs []uint) {
+	for _, clipID := range clipIDs {
+		cache.InvalidateClip(clipID)
+	}
+}
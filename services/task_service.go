@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+type TaskService struct {
+	db *gorm.DB
+}
+
+func NewTaskService() *TaskService {
+	return &TaskService{
+		db: database.GetDB(),
+	}
+}
+
+// TaskFilter narrows the task list; empty fields match everything.
+type TaskFilter struct {
+	Status   string
+	Type     string
+	Queue    string
+	WorkerID string
+	TraceID  string
+}
+
+func (s *TaskService) ListTasks(filter TaskFilter, page, limit int) ([]models.TaskRecord, int64, error) {
+	var tasks []models.TaskRecord
+	var total int64
+
+	query := s.db.Model(&models.TaskRecord{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Queue != "" {
+		query = query.Where("queue = ?", filter.Queue)
+	}
+	if filter.WorkerID != "" {
+		query = query.Where("worker_id = ?", filter.WorkerID)
+	}
+	if filter.TraceID != "" {
+		query = query.Where("trace_id = ?", filter.TraceID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&tasks).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	return tasks, total, nil
+}
+
+func (s *TaskService) GetTask(taskID string) (*models.TaskRecord, error) {
+	var task models.TaskRecord
+	if err := s.db.Where("task_id = ?", taskID).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("task not found")
+		}
+		logger.Errorf("Failed to get task: %v", err)
+		return nil, errors.New("failed to get task")
+	}
+
+	return &task, nil
+}
+
+// PruneFinishedTasks deletes succeeded and failed task records that
+// finished before the cutoff, returning how many were removed.
+func (s *TaskService) PruneFinishedTasks(before time.Time) (int64, error) {
+	result := s.db.Where("status IN ? AND finished_at < ?",
+		[]string{models.TaskStatusSucceeded, models.TaskStatusFailed}, before).
+		Delete(&models.TaskRecord{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune tasks: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
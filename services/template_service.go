@@ -0,0 +1,275 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+type TemplateService struct {
+	db             *gorm.DB
+	projectService *ProjectService
+}
+
+func NewTemplateService() *TemplateService {
+	return &TemplateService{
+		db:             database.GetDB(),
+		projectService: NewProjectService(),
+	}
+}
+
+// getAccessibleTemplate returns a template the user may see and start a
+// project from: a public one or one of their workspaces.
+func getAccessibleTemplate(db *gorm.DB, templateID, userID uint) (*models.Template, error) {
+	var template models.Template
+	if err := db.First(&template, templateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("template not found")
+		}
+		logger.Errorf("Failed to get template: %v", err)
+		return nil, errors.New("failed to get template")
+	}
+
+	if !template.IsPublic {
+		allowed, err := canAccess(db, userID, template.UserID, template.OrganizationID, false)
+		if err != nil {
+			logger.Errorf("Failed to check organization access: %v", err)
+			return nil, errors.New("failed to get template")
+		}
+		if !allowed {
+			return nil, errors.New("template not found")
+		}
+	}
+
+	return &template, nil
+}
+
+func (s *TemplateService) GetTemplate(templateID, userID uint) (*models.Template, error) {
+	return getAccessibleTemplate(s.db, templateID, userID)
+}
+
+// getWritableTemplate returns the template when the user may change it;
+// public templates of other workspaces are read-only.
+func (s *TemplateService) getWritableTemplate(templateID, userID uint) (*models.Template, error) {
+	template, err := s.GetTemplate(templateID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := canAccess(s.db, userID, template.UserID, template.OrganizationID, true)
+	if err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to get template")
+	}
+	if !allowed {
+		return nil, errors.New("insufficient organization role")
+	}
+
+	return template, nil
+}
+
+// validateTemplateTimeline checks the structure of a template timeline.
+// Its clips are not checked: they stand for clips users replace.
+func validateTemplateTimeline(data models.JSON) (*models.ProjectTimeline, error) {
+	timeline, err := models.ParseProjectTimeline(data)
+	if err != nil {
+		return nil, errors.New("invalid timeline: " + err.Error())
+	}
+	if err := timeline.Validate(); err != nil {
+		return nil, errors.New("invalid timeline: " + err.Error())
+	}
+	return timeline, nil
+}
+
+func (s *TemplateService) CreateTemplate(userID uint, organizationID *uint, req *models.TemplateCreateRequest) (*models.Template, error) {
+	if organizationID != nil {
+		allowed, err := canAccess(s.db, userID, userID, organizationID, true)
+		if err != nil {
+			logger.Errorf("Failed to check organization access: %v", err)
+			return nil, errors.New("failed to create template")
+		}
+		if !allowed {
+			return nil, errors.New("insufficient organization role")
+		}
+	}
+
+	timeline, err := validateTemplateTimeline(req.Timeline)
+	if err != nil {
+		return nil, err
+	}
+	timelineJSON, err := timeline.ToJSON()
+	if err != nil {
+		return nil, errors.New("failed to create template")
+	}
+
+	template := &models.Template{
+		Name:           req.Name,
+		Description:    req.Description,
+		Category:       req.Category,
+		Width:          1920,
+		Height:         1080,
+		FrameRate:      30,
+		Duration:       timeline.Duration,
+		Timeline:       timelineJSON,
+		Settings:       req.Settings,
+		Tags:           models.NormalizeTags(req.Tags),
+		IsPublic:       req.IsPublic,
+		UserID:         userID,
+		OrganizationID: organizationID,
+	}
+	if req.Width > 0 {
+		template.Width = req.Width
+	}
+	if req.Height > 0 {
+		template.Height = req.Height
+	}
+	if req.FrameRate > 0 {
+		template.FrameRate = req.FrameRate
+	}
+
+	if err := s.db.Create(template).Error; err != nil {
+		logger.Errorf("Failed to create template: %v", err)
+		return nil, errors.New("failed to create template")
+	}
+
+	logger.Infof("Template %d created by user %d", template.ID, userID)
+	return template, nil
+}
+
+// ListTemplates returns the templates of the workspace, published or not.
+func (s *TemplateService) ListTemplates(userID uint, organizationID *uint, req *models.TemplateListRequest) ([]models.Template, int64, error) {
+	return s.listTemplates(s.db.Model(&models.Template{}).Scopes(workspaceScope(userID, organizationID)), req)
+}
+
+// Gallery returns the published templates of every workspace.
+func (s *TemplateService) Gallery(req *models.TemplateListRequest) ([]models.Template, int64, error) {
+	return s.listTemplates(s.db.Model(&models.Template{}).Where("is_public = ?", true), req)
+}
+
+func (s *TemplateService) listTemplates(query *gorm.DB, req *models.TemplateListRequest) ([]models.Template, int64, error) {
+	if req.Query != "" {
+		query = query.Where("name LIKE ? OR description LIKE ?", "%"+req.Query+"%", "%"+req.Query+"%")
+	}
+	if req.Category != "" {
+		query = query.Where("category = ?", req.Category)
+	}
+	if tag := models.NormalizeTag(req.Tag); tag != "" {
+		query = query.Where("tags LIKE ?", tagLikePattern(tag))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count templates: %w", err)
+	}
+
+	order := "usage_count DESC, id DESC"
+	switch req.Sort {
+	case "newest":
+		order = "created_at DESC"
+	case "rating":
+		order = "rating_average DESC, rating_count DESC, id DESC"
+	}
+
+	var templates []models.Template
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order(order).Offset(offset).Limit(req.Limit).Find(&templates).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	return templates, total, nil
+}
+
+func (s *TemplateService) UpdateTemplate(templateID, userID uint, req *models.TemplateUpdateRequest) (*models.Template, error) {
+	template, err := s.getWritableTemplate(templateID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Timeline != nil {
+		timeline, err := validateTemplateTimeline(req.Timeline)
+		if err != nil {
+			return nil, err
+		}
+		if template.Timeline, err = timeline.ToJSON(); err != nil {
+			return nil, errors.New("failed to update template")
+		}
+		template.Duration = timeline.Duration
+	}
+	if req.Name != "" {
+		template.Name = req.Name
+	}
+	if req.Description != "" {
+		template.Description = req.Description
+	}
+	if req.Category != "" {
+		template.Category = req.Category
+	}
+	if req.Width > 0 {
+		template.Width = req.Width
+	}
+	if req.Height > 0 {
+		template.Height = req.Height
+	}
+	if req.FrameRate > 0 {
+		template.FrameRate = req.FrameRate
+	}
+	if req.Settings != nil {
+		template.Settings = req.Settings
+	}
+	if req.Tags != nil {
+		template.Tags = models.NormalizeTags(req.Tags)
+	}
+
+	if err := s.db.Omit("User", "Projects").Save(template).Error; err != nil {
+		logger.Errorf("Failed to update template: %v", err)
+		return nil, errors.New("failed to update template")
+	}
+
+	return template, nil
+}
+
+// SetPublic publishes the template to the gallery or withdraws it.
+// Projects already created from it are not affected.
+func (s *TemplateService) SetPublic(templateID, userID uint, public bool) (*models.Template, error) {
+	template, err := s.getWritableTemplate(templateID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(template).Update("is_public", public).Error; err != nil {
+		logger.Errorf("Failed to update template: %v", err)
+		return nil, errors.New("failed to update template")
+	}
+
+	logger.Infof("Template %d published=%t by user %d", template.ID, public, userID)
+	return template, nil
+}
+
+func (s *TemplateService) DeleteTemplate(templateID, userID uint) error {
+	template, err := s.getWritableTemplate(templateID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(template).Error; err != nil {
+		logger.Errorf("Failed to delete template: %v", err)
+		return errors.New("failed to delete template")
+	}
+
+	logger.Infof("Template %d deleted by user %d", template.ID, userID)
+	return nil
+}
+
+// UseTemplate creates a project in the workspace from the template and
+// counts the use.
+func (s *TemplateService) UseTemplate(templateID, userID uint, organizationID *uint, req *models.TemplateUseRequest) (*models.Project, error) {
+	return s.projectService.CreateProject(userID, organizationID, &models.ProjectCreateRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		TemplateID:  &templateID,
+	})
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/sync/singleflight"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/video_engine"
+)
+
+// defaultThumbnailTime is the offset, in seconds, of the frame shown when
+// none is asked for and none was chosen or picked by analysis
+const defaultThumbnailTime = 1.0
+
+// thumbnailRenders coalesces concurrent requests for the same thumbnail,
+// so a popular clip costs one ffmpeg run instead of one per request
+var thumbnailRenders singleflight.Group
+
+// Thumbnail is a JPEG frame of a clip.
+type Thumbnail struct {
+	Data []byte
+	// ETag identifies the image's content, for conditional requests
+	ETag string
+}
+
+type ThumbnailService struct {
+	atomicClipService *AtomicClipService
+	ffmpegProcessor   *video_engine.FFmpegProcessor
+}
+
+func NewThumbnailService() *ThumbnailService {
+	return &ThumbnailService{
+		atomicClipService: NewAtomicClipService(),
+		ffmpegProcessor:   video_engine.NewFFmpegProcessor(config.AppConfig),
+	}
+}
+
+// GetClipThumbnail returns a frame of a clip the user can open, scaled as
+// requested. Thumbnails are generated on first request and kept on disk
+// until the clip is purged, with Redis in front for the recent ones.
+func (s *ThumbnailService) GetClipThumbnail(clipID, userID uint, req *models.ClipThumbnailRequest) (*Thumbnail, error) {
+	clip, err := s.atomicClipService.GetAtomicClipByID(clipID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := defaultThumbnailTime
+	switch {
+	case req.Time != nil:
+		offset = *req.Time
+		if clip.Duration > 0 && offset >= clip.Duration {
+			return nil, errors.New("invalid time: past the end of the clip")
+		}
+	case clip.ThumbnailTime != nil:
+		offset = *clip.ThumbnailTime
+	case clip.VideoAnalysis != nil && clip.VideoAnalysis.ThumbnailTime != nil:
+		offset = *clip.VideoAnalysis.ThumbnailTime
+	case clip.Duration > 0 && offset >= clip.Duration:
+		offset = clip.Duration / 2
+	}
+	// Offsets a tenth of a second apart share their cache entries
+	offset = math.Floor(offset*10) / 10
+
+	name := fmt.Sprintf("%s_%dx%d.jpg", strconv.FormatFloat(offset, 'f', 1, 64), req.Width, req.Height)
+	key := fmt.Sprintf("thumbnail:%d:%s", clip.ID, name)
+	if data, ok := cachedThumbnail(key); ok {
+		return newThumbnail(data), nil
+	}
+
+	data, err, _ := thumbnailRenders.Do(key, func() (interface{}, error) {
+		path := filepath.Join(clipThumbnailDir(clip.ID), name)
+		if data, err := os.ReadFile(path); err == nil {
+			return data, nil
+		}
+		return s.render(clip, path, offset, req.Width, req.Height)
+	})
+	if err != nil {
+		logger.Errorf("Failed to generate thumbnail of clip %d: %v", clip.ID, err)
+		return nil, errors.New("failed to generate thumbnail")
+	}
+
+	if cache.Cache != nil && config.AppConfig.Cache.ThumbnailTTL > 0 {
+		if err := cache.Cache.Set(key, data, config.AppConfig.Cache.ThumbnailTTL); err != nil && !errors.Is(err, cache.ErrCircuitOpen) {
+			logger.Warnf("Failed to cache thumbnail %s: %v", key, err)
+		}
+	}
+	return newThumbnail(data.([]byte)), nil
+}
+
+// render extracts the frame into the disk cache. It writes to a temporary
+// file first, so concurrent readers never see a partial image.
+func (s *ThumbnailService) render(clip *models.AtomicClip, path string, offset float64, width, height int) ([]byte, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	tmp := path + ".tmp"
+	defer os.Remove(tmp)
+	if err := s.ffmpegProcessor.ExtractFrame(clip.FilePath, tmp, offset, width, height); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
+
+func cachedThumbnail(key string) ([]byte, bool) {
+	if cache.Cache == nil || config.AppConfig.Cache.ThumbnailTTL <= 0 {
+		return nil, false
+	}
+
+	data, err := cache.Cache.Get(key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrKeyNotFound) && !errors.Is(err, cache.ErrCircuitOpen) {
+			logger.Warnf("Failed to read thumbnail %s: %v", key, err)
+		}
+		return nil, false
+	}
+	return []byte(data), true
+}
+
+func newThumbnail(data []byte) *Thumbnail {
+	sum := sha256.Sum256(data)
+	return &Thumbnail{
+		Data: data,
+		ETag: `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}
+}
+
+// clipThumbnailDir holds the generated thumbnails of a clip.
+func clipThumbnailDir(clipID uint) string {
+	return filepath.Join(config.AppConfig.Storage.OutputPath, "thumbnails", strconv.FormatUint(uint64(clipID), 10))
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/interchange"
+	"creative-studio-server/pkg/logger"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type TimelineImportService struct {
+	db             *gorm.DB
+	projectService *ProjectService
+}
+
+func NewTimelineImportService() *TimelineImportService {
+	return &TimelineImportService{
+		db:             database.GetDB(),
+		projectService: NewProjectService(),
+	}
+}
+
+// ImportProject creates a project from a timeline exported by an editing
+// application. Its media is relinked to clips the user can read; uses of
+// media no clip stands in for are left out and reported.
+func (s *TimelineImportService) ImportProject(userID uint, organizationID *uint, req *models.TimelineImportRequest, file *multipart.FileHeader) (*models.TimelineImportResult, error) {
+	format := req.Format
+	if format == "" {
+		format = interchange.FormatOf(file.Filename)
+		if format == "" {
+			return nil, errors.New("invalid timeline file: unknown format, set format to fcpxml or otio")
+		}
+	}
+	// Real edit decision lists are a few megabytes at most
+	maxSize := config.AppConfig.Storage.TimelineImportMaxSize
+	if file.Size > maxSize {
+		return nil, errors.New("invalid timeline file: file is too large")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		logger.Errorf("Failed to open uploaded timeline: %v", err)
+		return nil, errors.New("failed to import timeline")
+	}
+	defer src.Close()
+	data, err := io.ReadAll(io.LimitReader(src, maxSize))
+	if err != nil {
+		logger.Errorf("Failed to read uploaded timeline: %v", err)
+		return nil, errors.New("failed to import timeline")
+	}
+
+	edit, err := interchange.Import(format, data)
+	if err != nil {
+		return nil, errors.New("invalid timeline file: " + err.Error())
+	}
+
+	timeline, unresolved, err := s.relink(userID, organizationID, edit)
+	if err != nil {
+		return nil, err
+	}
+	if err := timeline.Validate(); err != nil {
+		return nil, errors.New("invalid timeline file: " + err.Error())
+	}
+
+	title := req.Title
+	if title == "" {
+		title = edit.Name
+	}
+	if title == "" {
+		title = strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename))
+	}
+	if runes := []rune(title); len(runes) > 200 {
+		title = string(runes[:200])
+	}
+	create := &models.ProjectCreateRequest{Title: title}
+	// Canvas settings outside what projects allow keep the defaults
+	if edit.Width >= 320 && edit.Width <= 7680 && edit.Height >= 240 && edit.Height <= 4320 {
+		create.Width = edit.Width
+		create.Height = edit.Height
+	}
+	if edit.FrameRate >= 1 && edit.FrameRate <= 120 {
+		create.FrameRate = edit.FrameRate
+	}
+
+	project, err := s.projectService.createProject(userID, organizationID, create, timeline)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Project %d imported from %s by user %d with %d unresolved media", project.ID, format, userID, len(unresolved))
+	return &models.TimelineImportResult{
+		Project:         project,
+		ImportedClips:   len(timeline.Clips),
+		UnresolvedMedia: unresolved,
+	}, nil
+}
+
+// relink builds the project timeline, replacing the media of each clip by
+// the clip that stands in for it. Clips cut past the end of that clip by
+// more than a frame, which rounding to the file's frames can cause, are
+// left out too.
+func (s *TimelineImportService) relink(userID uint, organizationID *uint, edit *interchange.Timeline) (*models.ProjectTimeline, []models.UnresolvedMedia, error) {
+	tolerance := 1.0 / 24
+	if edit.FrameRate > 0 {
+		tolerance = 1 / edit.FrameRate
+	}
+
+	timeline := &models.ProjectTimeline{Clips: []models.TimelineClip{}}
+	unresolved := []models.UnresolvedMedia{}
+	unresolvedAt := make(map[string]int)
+	report := func(clip interchange.Clip, reason string) {
+		key := reason + "\x00" + clip.MediaURL + "\x00" + clip.Name
+		if i, ok := unresolvedAt[key]; ok {
+			unresolved[i].Uses++
+			return
+		}
+		unresolvedAt[key] = len(unresolved)
+		unresolved = append(unresolved, models.UnresolvedMedia{
+			Name:     clip.Name,
+			MediaURL: clip.MediaURL,
+			Uses:     1,
+			Reason:   reason,
+		})
+	}
+
+	matches := make(map[string]*models.AtomicClip)
+	end := 0.0
+	for _, clip := range edit.Clips {
+		key := clip.MediaURL + "\x00" + clip.Name
+		match, ok := matches[key]
+		if !ok {
+			var err error
+			if match, err = s.findClip(userID, organizationID, clip); err != nil {
+				logger.Errorf("Failed to match timeline media: %v", err)
+				return nil, nil, errors.New("failed to import timeline")
+			}
+			matches[key] = match
+		}
+		if match == nil {
+			report(clip, models.UnresolvedMediaNotFound)
+			continue
+		}
+
+		out := clip.Out
+		if match.Duration > 0 && out > match.Duration {
+			if out > match.Duration+tolerance || clip.In >= match.Duration {
+				report(clip, models.UnresolvedMediaOutsideSource)
+				continue
+			}
+			out = match.Duration
+		}
+		if clip.Position < 0 || out <= clip.In {
+			continue
+		}
+		timeline.Clips = append(timeline.Clips, models.TimelineClip{
+			ClipID:    match.ID,
+			Position:  clip.Position,
+			StartTime: clip.In,
+			EndTime:   out,
+			Duration:  out - clip.In,
+		})
+		if e := clip.Position + out - clip.In; e > end {
+			end = e
+		}
+	}
+
+	// Transitions of clips that were left out may no longer fit
+	for _, transition := range edit.Transitions {
+		if transition.Position < 0 || transition.Duration <= 0 || transition.Position+transition.Duration > end {
+			continue
+		}
+		timeline.Transitions = append(timeline.Transitions, models.TimelineTransition{
+			Type:     transition.Type,
+			Position: transition.Position,
+			Duration: transition.Duration,
+		})
+	}
+	return timeline, unresolved, nil
+}
+
+// findClip returns the clip that stands in for the media of an imported
+// clip, or nil when none does. The clip it was exported from comes first,
+// then a clip of the same file, then one with the media's name as its
+// title. Among equal matches, clips of the workspace and then the newest
+// win.
+func (s *TimelineImportService) findClip(userID uint, organizationID *uint, clip interchange.Clip) (*models.AtomicClip, error) {
+	name := mediaFileName(clip.MediaURL)
+	if clip.ID != 0 {
+		match, err := s.bestClip(userID, organizationID, func(db *gorm.DB) *gorm.DB {
+			return db.Where("id = ?", clip.ID)
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		// IDs from another server name other clips, which their file
+		// gives away
+		if match != nil && (name == "" || filepath.Base(match.FilePath) == name) {
+			return match, nil
+		}
+	}
+
+	if name != "" {
+		// Uploads are stored as <prefix>_<original name>
+		match, err := s.bestClip(userID, organizationID, func(db *gorm.DB) *gorm.DB {
+			escaped := likeEscaper.Replace(name)
+			return db.Where("file_path = ? OR file_path LIKE ? OR file_path LIKE ?", name, "%/"+escaped, `%\_`+escaped)
+		}, func(candidate *models.AtomicClip) bool {
+			return filepath.Base(candidate.FilePath) == name
+		})
+		if match != nil || err != nil {
+			return match, err
+		}
+	}
+
+	for _, title := range titleCandidates(clip) {
+		match, err := s.bestClip(userID, organizationID, func(db *gorm.DB) *gorm.DB {
+			return db.Where("LOWER(title) = ?", strings.ToLower(title))
+		}, nil)
+		if match != nil || err != nil {
+			return match, err
+		}
+	}
+	return nil, nil
+}
+
+// bestClip returns the best of the readable clips the query finds,
+// preferring exact ones when exact is set.
+func (s *TimelineImportService) bestClip(userID uint, organizationID *uint, query func(*gorm.DB) *gorm.DB, exact func(*models.AtomicClip) bool) (*models.AtomicClip, error) {
+	var clips []models.AtomicClip
+	if err := s.db.Scopes(readableScope(userID), query).Order("id DESC").Limit(50).Find(&clips).Error; err != nil {
+		return nil, err
+	}
+
+	var best *models.AtomicClip
+	bestScore := -1
+	for i := range clips {
+		candidate := &clips[i]
+		score := 0
+		if exact != nil && exact(candidate) {
+			score += 2
+		}
+		if organizationID != nil {
+			if candidate.OrganizationID != nil && *candidate.OrganizationID == *organizationID {
+				score++
+			}
+		} else if candidate.OrganizationID == nil && candidate.UserID == userID {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best, nil
+}
+
+// mediaFileName returns the file name of a media reference, which editors
+// write as a file URL or a path.
+func mediaFileName(reference string) string {
+	if reference == "" {
+		return ""
+	}
+	if u, err := url.Parse(reference); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		reference = u.Path
+	}
+	name := path.Base(strings.ReplaceAll(reference, `\`, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// titleCandidates returns the titles a clip standing in for the media may
+// have: the media's name, with and without its file extension.
+func titleCandidates(clip interchange.Clip) []string {
+	var titles []string
+	for _, title := range []string{clip.Name, strings.TrimSuffix(clip.Name, filepath.Ext(clip.Name))} {
+		title = strings.TrimSpace(title)
+		if title == "" {
+			continue
+		}
+		if len(titles) > 0 && titles[len(titles)-1] == title {
+			continue
+		}
+		titles = append(titles, title)
+	}
+	return titles
+}
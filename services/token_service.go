@@ -0,0 +1,348 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/auth"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+// sessionSeenInterval is how often a session's last seen time is written
+// while its access tokens are in use
+const sessionSeenInterval = 5 * time.Minute
+
+// TokenService issues access and refresh tokens, rotates and revokes
+// refresh tokens, and tracks the sessions they belong to.
+type TokenService struct {
+	db *gorm.DB
+}
+
+func NewTokenService() *TokenService {
+	return &TokenService{
+		db: database.GetDB(),
+	}
+}
+
+// IssueTokens starts a new session for the user, e.g. on login.
+func (s *TokenService) IssueTokens(user *models.User, userAgent, ipAddress string) (*models.TokenPair, error) {
+	familyID, err := auth.NewTokenID()
+	if err != nil {
+		logger.Errorf("Failed to generate token family: %v", err)
+		return nil, errors.New("failed to generate authentication token")
+	}
+
+	var pair *models.TokenPair
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		pair, _, err = s.issue(tx, user, familyID, userAgent, ipAddress)
+		return err
+	})
+	if err != nil {
+		logger.Errorf("Failed to issue tokens: %v", err)
+		return nil, errors.New("failed to generate authentication token")
+	}
+	return pair, nil
+}
+
+func (s *TokenService) issue(tx *gorm.DB, user *models.User, familyID, userAgent, ipAddress string) (*models.TokenPair, *models.RefreshToken, error) {
+	cfg := config.AppConfig
+
+	accessToken, err := auth.GenerateToken(user.ID, user.Username, user.Email, user.Role, familyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record := &models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(cfg.JWT.RefreshExpiresIn),
+		UserAgent: truncate(userAgent, 255),
+		IPAddress: ipAddress,
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return nil, nil, err
+	}
+
+	// Sessions of logins from before they were tracked start on refresh
+	now := time.Now()
+	session := models.Session{SessionID: familyID, UserID: user.ID}
+	if err := tx.Where(session).Assign(models.Session{
+		UserAgent:  record.UserAgent,
+		IPAddress:  ipAddress,
+		LastSeenAt: now,
+		ExpiresAt:  record.ExpiresAt,
+	}).FirstOrCreate(&session).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(cfg.JWT.ExpiresIn.Seconds()),
+	}, record, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented
+// token is used up; presenting it again revokes every token of its family.
+func (s *TokenService) Refresh(refreshToken, userAgent, ipAddress string) (*models.TokenPair, *models.User, error) {
+	var current models.RefreshToken
+	if err := s.db.Where("token_hash = ?", auth.HashRefreshToken(refreshToken)).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("invalid refresh token")
+		}
+		logger.Errorf("Failed to get refresh token: %v", err)
+		return nil, nil, errors.New("failed to refresh token")
+	}
+
+	if current.RevokedAt != nil {
+		if current.ReplacedByID != nil {
+			// A rotated token came back: someone else holds a copy
+			logger.Warnf("Refresh token reuse detected for user %d, revoking session %s", current.UserID, current.FamilyID)
+			s.revokeFamily(current.FamilyID)
+		}
+		return nil, nil, errors.New("invalid refresh token")
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, nil, errors.New("refresh token expired")
+	}
+
+	var user models.User
+	if err := s.db.First(&user, current.UserID).Error; err != nil {
+		return nil, nil, errors.New("invalid refresh token")
+	}
+	if !user.IsActive {
+		return nil, nil, errors.New("account is disabled")
+	}
+
+	var pair *models.TokenPair
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var next *models.RefreshToken
+		var err error
+		pair, next, err = s.issue(tx, &user, current.FamilyID, userAgent, ipAddress)
+		if err != nil {
+			return err
+		}
+
+		// Guarded so two concurrent refreshes cannot both rotate the token
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by_id": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRefreshTokenUsed
+		}
+		return nil
+	})
+	if errors.Is(err, errRefreshTokenUsed) {
+		return nil, nil, errors.New("invalid refresh token")
+	}
+	if err != nil {
+		logger.Errorf("Failed to rotate refresh token: %v", err)
+		return nil, nil, errors.New("failed to refresh token")
+	}
+
+	return pair, &user, nil
+}
+
+var errRefreshTokenUsed = errors.New("refresh token already used")
+
+// Logout revokes the access token of the request and its session, found
+// from the token or the refresh token, or every session of the user when
+// allDevices is set.
+func (s *TokenService) Logout(claims *auth.Claims, refreshToken string, allDevices bool) error {
+	if allDevices {
+		if err := s.RevokeAllForUser(claims.UserID); err != nil {
+			return err
+		}
+	} else if claims.SessionID != "" {
+		if err := s.revokeFamily(claims.SessionID); err != nil {
+			return errors.New("failed to revoke session")
+		}
+	} else if refreshToken != "" {
+		var current models.RefreshToken
+		err := s.db.Where("token_hash = ? AND user_id = ?", auth.HashRefreshToken(refreshToken), claims.UserID).
+			First(&current).Error
+		if err == nil {
+			if err := s.revokeFamily(current.FamilyID); err != nil {
+				return errors.New("failed to revoke session")
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Errorf("Failed to get refresh token: %v", err)
+			return errors.New("failed to revoke session")
+		}
+	}
+
+	if err := auth.RevokeToken(claims); err != nil {
+		logger.Errorf("Failed to revoke access token: %v", err)
+		return errors.New("failed to revoke session")
+	}
+	return nil
+}
+
+// RevokeAllForUser ends every session of the user: refresh tokens are
+// revoked and access tokens issued so far are rejected.
+func (s *TokenService) RevokeAllForUser(userID uint) error {
+	if err := s.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		logger.Errorf("Failed to revoke refresh tokens: %v", err)
+		return errors.New("failed to revoke sessions")
+	}
+	if err := s.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		logger.Errorf("Failed to revoke sessions: %v", err)
+		return errors.New("failed to revoke sessions")
+	}
+
+	if err := auth.RevokeUserTokens(userID, config.AppConfig.JWT.ExpiresIn); err != nil {
+		logger.Errorf("Failed to revoke access tokens: %v", err)
+		return errors.New("failed to revoke sessions")
+	}
+	return nil
+}
+
+// revokeFamily ends one session: its refresh tokens are revoked and the
+// access tokens issued to it are rejected.
+func (s *TokenService) revokeFamily(familyID string) error {
+	if err := s.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		logger.Errorf("Failed to revoke session %s: %v", familyID, err)
+		return err
+	}
+	if err := s.db.Model(&models.Session{}).
+		Where("session_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		logger.Errorf("Failed to revoke session %s: %v", familyID, err)
+		return err
+	}
+
+	if err := auth.RevokeSession(familyID, config.AppConfig.JWT.ExpiresIn); err != nil {
+		logger.Errorf("Failed to revoke access tokens of session %s: %v", familyID, err)
+		return err
+	}
+	return nil
+}
+
+// ListSessions returns the user's signed-in sessions, most recently seen
+// first, marking the one named by currentSessionID.
+func (s *TokenService) ListSessions(userID uint, currentSessionID string) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's sessions out.
+func (s *TokenService) RevokeSession(userID uint, sessionID string) error {
+	var session models.Session
+	if err := s.db.Where("session_id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("session not found")
+		}
+		logger.Errorf("Failed to get session: %v", err)
+		return errors.New("failed to revoke session")
+	}
+
+	if err := s.revokeFamily(session.SessionID); err != nil {
+		return errors.New("failed to revoke session")
+	}
+
+	logger.Infof("Session %s of user %d revoked", session.SessionID, userID)
+	return nil
+}
+
+// RevokeOtherSessions signs out every session of the user but the given
+// one, which keeps its tokens.
+func (s *TokenService) RevokeOtherSessions(userID uint, keepSessionID string) error {
+	var sessionIDs []string
+	if err := s.db.Model(&models.Session{}).
+		Where("user_id = ? AND session_id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Pluck("session_id", &sessionIDs).Error; err != nil {
+		logger.Errorf("Failed to get sessions: %v", err)
+		return errors.New("failed to revoke sessions")
+	}
+
+	for _, sessionID := range sessionIDs {
+		if err := s.revokeFamily(sessionID); err != nil {
+			return errors.New("failed to revoke sessions")
+		}
+	}
+
+	// Refresh tokens of logins from before sessions were tracked
+	if err := s.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		logger.Errorf("Failed to revoke refresh tokens: %v", err)
+		return errors.New("failed to revoke sessions")
+	}
+	return nil
+}
+
+// TouchSession records that the session was seen using an access token.
+// Writes are throttled to one per session every few minutes.
+func (s *TokenService) TouchSession(sessionID, ipAddress string) {
+	if sessionID == "" || cache.Cache == nil {
+		return
+	}
+	due, err := cache.Cache.SetNX(fmt.Sprintf("auth:session_seen:%s", sessionID), "1", sessionSeenInterval)
+	if err != nil || !due {
+		return
+	}
+
+	if err := s.db.Model(&models.Session{}).Where("session_id = ?", sessionID).
+		Updates(map[string]interface{}{"last_seen_at": time.Now(), "ip_address": ipAddress}).Error; err != nil {
+		logger.Warnf("Failed to update session %s: %v", sessionID, err)
+	}
+}
+
+// PruneExpiredRefreshTokens deletes refresh tokens that expired before the
+// cutoff, returning how many were removed.
+func (s *TokenService) PruneExpiredRefreshTokens(before time.Time) (int64, error) {
+	result := s.db.Where("expires_at < ?", before).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune refresh tokens: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// PruneExpiredSessions deletes sessions whose refresh tokens expired before
+// the cutoff, returning how many were removed.
+func (s *TokenService) PruneExpiredSessions(before time.Time) (int64, error) {
+	result := s.db.Where("expires_at < ?", before).Delete(&models.Session{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune sessions: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
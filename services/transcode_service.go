@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/video_engine"
+)
+
+const (
+	// transcodeWait is how long a download request waits for its variant
+	// before answering that it is still being transcoded
+	transcodeWait = 20 * time.Second
+	// transcodeTimeout bounds a single transcode
+	transcodeTimeout = time.Hour
+	// maxConcurrentTranscodes keeps downloads from starving the API process
+	maxConcurrentTranscodes = 2
+)
+
+// transcodes tracks the variants being transcoded, so concurrent requests
+// for one share its ffmpeg run
+var transcodes = struct {
+	sync.Mutex
+	running map[string]*transcodeJob
+	slots   chan struct{}
+}{
+	running: make(map[string]*transcodeJob),
+	slots:   make(chan struct{}, maxConcurrentTranscodes),
+}
+
+type transcodeJob struct {
+	done chan struct{}
+	err  error
+}
+
+// ClipDownload is the file to send for a clip download. Path is empty
+// while the requested variant is still being transcoded.
+type ClipDownload struct {
+	Path     string
+	Filename string
+}
+
+type TranscodeService struct {
+	atomicClipService *AtomicClipService
+	ffmpegProcessor   *video_engine.FFmpegProcessor
+}
+
+func NewTranscodeService() *TranscodeService {
+	return &TranscodeService{
+		atomicClipService: NewAtomicClipService(),
+		ffmpegProcessor:   video_engine.NewFFmpegProcessor(config.AppConfig),
+	}
+}
+
+// GetClipDownload returns the file of a clip the user can open, transcoded
+// to the requested variant. Variants are transcoded on first request and
+// kept on disk while they keep being downloaded; the first request waits
+// for a short while, after which the transcode goes on in the background.
+func (s *TranscodeService) GetClipDownload(clipID, userID uint, req *models.ClipDownloadRequest) (*ClipDownload, error) {
+	clip, err := s.atomicClipService.GetAtomicClipByID(clipID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := downloadName(clip)
+	if req.Format == "" && req.Resolution == "" && req.Quality == "" {
+		return &ClipDownload{Path: clip.FilePath, Filename: name + filepath.Ext(clip.FilePath)}, nil
+	}
+
+	format := req.Format
+	if format == "" {
+		format = "mp4"
+	}
+	quality := req.Quality
+	if quality == "" {
+		quality = "medium"
+	}
+	height := 0
+	variant := "source"
+	if req.Resolution != "" {
+		height, _ = strconv.Atoi(strings.TrimSuffix(req.Resolution, "p"))
+		variant = req.Resolution
+	}
+	variant += "_" + quality
+	path := filepath.Join(clipTranscodeDir(clip.ID), variant+"."+format)
+	download := &ClipDownload{Path: path, Filename: fmt.Sprintf("%s_%s.%s", name, variant, format)}
+
+	if _, err := os.Stat(path); err == nil {
+		// The modification time marks the last download, which pruning goes by
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return download, nil
+	}
+
+	job := s.startTranscode(clip.FilePath, path, height, &video_engine.RenderOptions{OutputFormat: format, Quality: quality})
+	select {
+	case <-job.done:
+		if job.err != nil {
+			logger.Errorf("Failed to transcode clip %d to %s: %v", clip.ID, filepath.Base(path), job.err)
+			return nil, errors.New("failed to transcode clip")
+		}
+		return download, nil
+	case <-time.After(transcodeWait):
+		return &ClipDownload{Filename: download.Filename}, nil
+	}
+}
+
+// startTranscode starts transcoding into outputPath unless it already is,
+// writing to a temporary file first so a partial file is never served.
+func (s *TranscodeService) startTranscode(inputPath, outputPath string, maxHeight int, options *video_engine.RenderOptions) *transcodeJob {
+	transcodes.Lock()
+	defer transcodes.Unlock()
+	if job, ok := transcodes.running[outputPath]; ok {
+		return job
+	}
+
+	job := &transcodeJob{done: make(chan struct{})}
+	transcodes.running[outputPath] = job
+	go func() {
+		defer func() {
+			transcodes.Lock()
+			delete(transcodes.running, outputPath)
+			transcodes.Unlock()
+			close(job.done)
+		}()
+
+		transcodes.slots <- struct{}{}
+		defer func() { <-transcodes.slots }()
+
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			job.err = err
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), transcodeTimeout)
+		defer cancel()
+
+		tmp := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".tmp" + filepath.Ext(outputPath)
+		defer os.Remove(tmp)
+		if job.err = s.ffmpegProcessor.Transcode(ctx, inputPath, tmp, maxHeight, options); job.err != nil {
+			return
+		}
+		job.err = os.Rename(tmp, outputPath)
+	}()
+	return job
+}
+
+// PruneTranscodes deletes transcoded downloads last downloaded before the
+// cutoff, returning how many were removed.
+func (s *TranscodeService) PruneTranscodes(before time.Time) (int, error) {
+	root := filepath.Join(config.AppConfig.Storage.OutputPath, "transcodes")
+	clipDirs, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read transcodes: %w", err)
+	}
+
+	removed := 0
+	for _, clipDir := range clipDirs {
+		dir := filepath.Join(root, clipDir.Name())
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		kept := 0
+		for _, file := range files {
+			info, err := file.Info()
+			if err != nil || info.ModTime().After(before) {
+				kept++
+				continue
+			}
+			if err := os.Remove(filepath.Join(dir, file.Name())); err != nil {
+				logger.Warnf("Failed to remove transcode %s: %v", file.Name(), err)
+				kept++
+				continue
+			}
+			removed++
+		}
+		if kept == 0 {
+			os.Remove(dir)
+		}
+	}
+
+	return removed, nil
+}
+
+// downloadName turns a clip's title into a file name, falling back to its
+// ID when nothing of the title is left.
+func downloadName(clip *models.AtomicClip) string {
+	name := strings.Trim(unsafeFilenameChars.ReplaceAllString(clip.Title, "_"), "_.")
+	if name == "" {
+		return fmt.Sprintf("clip_%d", clip.ID)
+	}
+	return name
+}
+
+// clipTranscodeDir holds the transcoded downloads of a clip.
+func clipTranscodeDir(clipID uint) string {
+	return filepath.Join(config.AppConfig.Storage.OutputPath, "transcodes", strconv.FormatUint(uint64(clipID), 10))
+}
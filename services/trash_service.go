@@ -0,0 +1,317 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/embedding"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/video_engine"
+)
+
+// purgeBatchSize is how many expired records are purged per query
+const purgeBatchSize = 100
+
+// TrashService lists and restores soft-deleted clips and projects, and
+// purges them with their files once the retention period is over.
+type TrashService struct {
+	db *gorm.DB
+}
+
+func NewTrashService() *TrashService {
+	return &TrashService{
+		db: database.GetDB(),
+	}
+}
+
+// ListClips returns a page of the workspace's deleted clips.
+func (s *TrashService) ListClips(userID uint, organizationID *uint, req *models.TrashListRequest) ([]models.TrashItem, int64, error) {
+	return s.list(&models.AtomicClip{}, models.TrashTypeClip, userID, organizationID, req)
+}
+
+// ListProjects returns a page of the workspace's deleted projects.
+func (s *TrashService) ListProjects(userID uint, organizationID *uint, req *models.TrashListRequest) ([]models.TrashItem, int64, error) {
+	return s.list(&models.Project{}, models.TrashTypeProject, userID, organizationID, req)
+}
+
+func (s *TrashService) list(model interface{}, trashType string, userID uint, organizationID *uint, req *models.TrashListRequest) ([]models.TrashItem, int64, error) {
+	query := s.db.Unscoped().Model(model).Where("deleted_at IS NOT NULL").Scopes(workspaceScope(userID, organizationID))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count deleted %ss: %w", trashType, err)
+	}
+
+	items := []models.TrashItem{}
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Select("id, title, thumbnail, deleted_at").
+		Order("deleted_at DESC, id DESC").Offset(offset).Limit(req.Limit).
+		Scan(&items).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get deleted %ss: %w", trashType, err)
+	}
+
+	retention := config.AppConfig.Storage.TrashRetention
+	for i := range items {
+		items[i].Type = trashType
+		if retention > 0 {
+			purgeAt := items[i].DeletedAt.Add(retention)
+			items[i].PurgeAt = &purgeAt
+		}
+	}
+	return items, total, nil
+}
+
+// RestoreClip brings a deleted clip back to its library. Collections it was
+// in do not get it back.
+func (s *TrashService) RestoreClip(clipID, userID uint) (*models.AtomicClip, error) {
+	var clip models.AtomicClip
+	if err := s.db.Unscoped().Preload("VideoAnalysis").Where("deleted_at IS NOT NULL").First(&clip, clipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("deleted clip not found")
+		}
+		logger.Errorf("Failed to get deleted clip: %v", err)
+		return nil, errors.New("failed to restore clip")
+	}
+	if err := s.checkAccess(clip.UserID, clip.OrganizationID, userID, "deleted clip not found"); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Unscoped().Model(&clip).Update("deleted_at", nil).Error; err != nil {
+		logger.Errorf("Failed to restore clip: %v", err)
+		return nil, errors.New("failed to restore clip")
+	}
+	clip.DeletedAt = gorm.DeletedAt{}
+
+	cache.InvalidateClip(clip.ID)
+	indexClips(clip.ID)
+	if clip.VideoAnalysis != nil {
+		if err := embedding.Restore(&clip, clip.VideoAnalysis.Embedding); err != nil {
+			logger.Warnf("Failed to restore embedding of clip %d: %v", clip.ID, err)
+		}
+	}
+	if err := syncTags(s.db, clip.Tags...); err != nil {
+		logger.Warnf("Failed to update tags of clip %d: %v", clip.ID, err)
+	}
+
+	logger.Infof("Clip %d restored by user %d", clip.ID, userID)
+	return &clip, nil
+}
+
+// RestoreProject brings a deleted project back, counting the clips of its
+// timeline as used again.
+func (s *TrashService) RestoreProject(projectID, userID uint) (*models.Project, error) {
+	var project models.Project
+	if err := s.db.Unscoped().Where("deleted_at IS NOT NULL").First(&project, projectID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("deleted project not found")
+		}
+		logger.Errorf("Failed to get deleted project: %v", err)
+		return nil, errors.New("failed to restore project")
+	}
+	if err := s.checkAccess(project.UserID, project.OrganizationID, userID, "deleted project not found"); err != nil {
+		return nil, err
+	}
+
+	var usedClips []uint
+	err := s.db.Transaction(func(tx *gorm.DB) (err error) {
+		// Counted from an empty timeline, as deleting counted it down to one
+		if usedClips, err = updateClipUsage(tx, 0, project.Timeline); err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&project).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to restore project: %v", err)
+		return nil, errors.New("failed to restore project")
+	}
+	project.DeletedAt = gorm.DeletedAt{}
+
+	cache.InvalidateProject(project.ID)
+	invalidateClips(usedClips)
+	logger.Infof("Project %d restored by user %d", project.ID, userID)
+	return &project, nil
+}
+
+// checkAccess hides deleted records the user cannot read, and lets only
+// those who may change the workspace restore them.
+func (s *TrashService) checkAccess(ownerID uint, organizationID *uint, userID uint, notFound string) error {
+	readable, err := canAccess(s.db, userID, ownerID, organizationID, false)
+	if err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return errors.New("failed to check access")
+	}
+	if !readable {
+		return errors.New(notFound)
+	}
+
+	writable, err := canAccess(s.db, userID, ownerID, organizationID, true)
+	if err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return errors.New("failed to check access")
+	}
+	if !writable {
+		return errors.New("insufficient organization role")
+	}
+	return nil
+}
+
+// PurgeExpired deletes for good the clips and projects deleted before the
+// cutoff, with their files and the records that only describe them. It
+// returns how many clips and projects were purged.
+func (s *TrashService) PurgeExpired(cutoff time.Time) (int, int, error) {
+	var purgedClips int
+	var clips []models.AtomicClip
+	err := s.db.Unscoped().Where("deleted_at < ?", cutoff).FindInBatches(&clips, purgeBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range clips {
+			if err := s.purgeClip(&clips[i]); err != nil {
+				// Left for the next run rather than holding back the rest
+				logger.Warnf("Failed to purge clip %d: %v", clips[i].ID, err)
+				continue
+			}
+			purgedClips++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return purgedClips, 0, fmt.Errorf("failed to get deleted clips: %w", err)
+	}
+
+	var purgedProjects int
+	var projects []models.Project
+	err = s.db.Unscoped().Where("deleted_at < ?", cutoff).FindInBatches(&projects, purgeBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range projects {
+			if err := s.purgeProject(&projects[i]); err != nil {
+				logger.Warnf("Failed to purge project %d: %v", projects[i].ID, err)
+				continue
+			}
+			purgedProjects++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return purgedClips, purgedProjects, fmt.Errorf("failed to get deleted projects: %w", err)
+	}
+
+	return purgedClips, purgedProjects, nil
+}
+
+func (s *TrashService) purgeClip(clip *models.AtomicClip) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("atomic_clip_id = ?", clip.ID).Delete(&models.VideoAnalysis{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("atomic_clip_id = ?", clip.ID).Delete(&models.ClipTranscript{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("atomic_clip_id = ?", clip.ID).Delete(&models.MusicAnalysis{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("clip_id = ?", clip.ID).Delete(&models.ClipUsage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("target_type = ? AND target_id = ?", models.FavoriteTargetClip, clip.ID).Delete(&models.Favorite{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("target_type = ? AND target_id = ?", models.FavoriteTargetClip, clip.ID).Delete(&models.Rating{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("resource_type = ? AND resource_id = ?", models.CommentResourceClip, clip.ID).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("resource_type = ? AND resource_id = ?", models.ShareResourceClip, clip.ID).Delete(&models.ShareLink{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(clip).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// Clips cut from the same source share its file
+	var sharing int64
+	if err := s.db.Unscoped().Model(&models.AtomicClip{}).Where("file_path = ?", clip.FilePath).Count(&sharing).Error; err != nil {
+		logger.Warnf("Failed to check other clips of %s: %v", clip.FilePath, err)
+	} else if sharing == 0 {
+		removeFile(clip.FilePath)
+	}
+	removeFile(clip.Thumbnail)
+	if err := os.RemoveAll(clipThumbnailDir(clip.ID)); err != nil {
+		logger.Warnf("Failed to delete thumbnails of clip %d: %v", clip.ID, err)
+	}
+	if err := os.RemoveAll(clipTranscodeDir(clip.ID)); err != nil {
+		logger.Warnf("Failed to delete transcodes of clip %d: %v", clip.ID, err)
+	}
+	return nil
+}
+
+func (s *TrashService) purgeProject(project *models.Project) error {
+	var renders []models.RenderTask
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("project_id = ?", project.ID).Find(&renders).Error; err != nil {
+			return err
+		}
+		renderIDs := make([]uint, len(renders))
+		for i, render := range renders {
+			renderIDs[i] = render.ID
+		}
+		compositionIDs := tx.Unscoped().Model(&models.Composition{}).Select("id").Where("project_id = ?", project.ID)
+
+		if len(renderIDs) > 0 {
+			if err := tx.Unscoped().Where("resource_type = ? AND resource_id IN ?", models.CommentResourceRender, renderIDs).Delete(&models.Comment{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("resource_type = ? AND resource_id IN ?", models.ShareResourceRender, renderIDs).Delete(&models.ShareLink{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("id IN ?", renderIDs).Delete(&models.RenderTask{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("resource_type = ? AND resource_id IN (?)", models.ShareResourceComposition, compositionIDs).Delete(&models.ShareLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("composition_id IN (?)", compositionIDs).Delete(&models.CompositionFeedback{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("project_id = ?", project.ID).Delete(&models.Composition{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.ProjectRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.ClipUsage{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(project).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, render := range renders {
+		if render.OutputPath != "" {
+			if err := video_engine.RemoveRenderOutput(render.OutputPath); err != nil && !os.IsNotExist(err) {
+				logger.Warnf("Failed to delete output of render %d: %v", render.ID, err)
+			}
+		}
+	}
+	removeFile(project.Thumbnail)
+	return nil
+}
+
+// removeFile deletes a media file; a missing one is already gone.
+func removeFile(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.Warnf("Failed to delete %s: %v", path, err)
+	}
+}
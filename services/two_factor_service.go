@@ -0,0 +1,286 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/auth"
+	"creative-studio-server/pkg/cache"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+// recoveryCodeCount is how many recovery codes a user gets at a time
+const recoveryCodeCount = 10
+
+// maxChallengeAttempts is how many codes, TOTP or recovery, a login
+// challenge may be tried with before the password must be entered again
+const maxChallengeAttempts = 5
+
+// TwoFactorService handles TOTP enrollment and the second step of logins.
+type TwoFactorService struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{
+		db: database.GetDB(),
+	}
+}
+
+func (s *TwoFactorService) getUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		logger.Errorf("Failed to get user: %v", err)
+		return nil, errors.New("failed to get user")
+	}
+	return &user, nil
+}
+
+// Setup starts enrollment with a new secret. Two-factor authentication is
+// only enabled once a code from the secret is confirmed with Enable.
+func (s *TwoFactorService) Setup(userID uint) (*models.TwoFactorSetupResponse, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabledAt != nil {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		logger.Errorf("Failed to generate TOTP secret: %v", err)
+		return nil, errors.New("failed to set up two-factor authentication")
+	}
+	encrypted, err := auth.EncryptSecret(secret)
+	if err != nil {
+		logger.Errorf("Failed to encrypt TOTP secret: %v", err)
+		return nil, errors.New("failed to set up two-factor authentication")
+	}
+
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"two_factor_secret":    encrypted,
+		"two_factor_last_step": 0,
+	}).Error; err != nil {
+		logger.Errorf("Failed to store TOTP secret: %v", err)
+		return nil, errors.New("failed to set up two-factor authentication")
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURL: auth.TOTPURL(secret, user.Email),
+	}, nil
+}
+
+// Enable confirms enrollment with a code from the authenticator and returns
+// the user's recovery codes, which are not shown again.
+func (s *TwoFactorService) Enable(userID uint, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabledAt != nil {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, errors.New("two-factor setup has not been started")
+	}
+
+	if err := s.checkTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.db.Model(user).Update("two_factor_enabled_at", now).Error; err != nil {
+		logger.Errorf("Failed to enable two-factor authentication: %v", err)
+		return nil, errors.New("failed to enable two-factor authentication")
+	}
+
+	logger.Infof("User %d enabled two-factor authentication", user.ID)
+	return s.replaceRecoveryCodes(user.ID)
+}
+
+// Disable turns two-factor authentication off. It takes the password and a
+// code, so a stolen session alone cannot do it.
+func (s *TwoFactorService) Disable(userID uint, password, code string) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.TwoFactorEnabledAt == nil {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if err := user.CheckPassword(password); err != nil {
+		return errors.New("password is incorrect")
+	}
+	if err := s.checkCode(user, code); err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"two_factor_secret":     "",
+			"two_factor_enabled_at": nil,
+			"two_factor_last_step":  0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to disable two-factor authentication: %v", err)
+		return errors.New("failed to disable two-factor authentication")
+	}
+
+	logger.Infof("User %d disabled two-factor authentication", user.ID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes.
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabledAt == nil {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+	if err := s.checkTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(user.ID)
+}
+
+// VerifyChallenge completes a login that passed the password check.
+func (s *TwoFactorService) VerifyChallenge(challengeToken, code string) (*models.User, error) {
+	userID, err := auth.ParseChallengeToken(challengeToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge")
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge")
+	}
+	if !user.IsActive {
+		return nil, errors.New("account is disabled")
+	}
+	if user.TwoFactorEnabledAt == nil {
+		return nil, errors.New("invalid or expired challenge")
+	}
+	if err := s.countChallengeAttempt(challengeToken); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCode(user, code); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// countChallengeAttempt counts a code tried against the challenge, and
+// turns the challenge away once it was tried too many times. Attempts are
+// counted before the code is checked so concurrent guesses count too.
+func (s *TwoFactorService) countChallengeAttempt(challengeToken string) error {
+	if cache.Cache == nil {
+		return nil
+	}
+
+	hash := sha256.Sum256([]byte(challengeToken))
+	attempts, err := cache.Cache.IncrementWithTTL("auth:2fa_attempts:"+hex.EncodeToString(hash[:]), auth.ChallengeTTL)
+	if err != nil {
+		logger.Errorf("Failed to count two-factor attempt: %v", err)
+		return errors.New("failed to verify two-factor code")
+	}
+	if attempts > maxChallengeAttempts {
+		return errors.New("too many attempts; sign in again")
+	}
+	return nil
+}
+
+// checkCode accepts a TOTP code or an unused recovery code.
+func (s *TwoFactorService) checkCode(user *models.User, code string) error {
+	if len(code) == 6 {
+		return s.checkTOTP(user, code)
+	}
+
+	result := s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, auth.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		logger.Errorf("Failed to use recovery code: %v", result.Error)
+		return errors.New("failed to verify two-factor code")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("invalid two-factor code")
+	}
+
+	logger.Infof("User %d signed in with a recovery code", user.ID)
+	return nil
+}
+
+// checkTOTP accepts a code once: the time step it matched must be newer
+// than the last one used. The update is conditional so concurrent
+// requests cannot both use one code.
+func (s *TwoFactorService) checkTOTP(user *models.User, code string) error {
+	secret, err := auth.DecryptSecret(user.TwoFactorSecret)
+	if err != nil {
+		logger.Errorf("Failed to decrypt TOTP secret of user %d: %v", user.ID, err)
+		return errors.New("failed to verify two-factor code")
+	}
+
+	step, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok || step <= user.TwoFactorLastStep {
+		return errors.New("invalid two-factor code")
+	}
+
+	result := s.db.Model(&models.User{}).
+		Where("id = ? AND two_factor_last_step < ?", user.ID, step).
+		Update("two_factor_last_step", step)
+	if result.Error != nil {
+		logger.Errorf("Failed to record TOTP use: %v", result.Error)
+		return errors.New("failed to verify two-factor code")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("invalid two-factor code")
+	}
+
+	user.TwoFactorLastStep = step
+	return nil
+}
+
+func (s *TwoFactorService) replaceRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := auth.NewRecoveryCode()
+		if err != nil {
+			logger.Errorf("Failed to generate recovery code: %v", err)
+			return nil, errors.New("failed to generate recovery codes")
+		}
+		codes[i] = code
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: auth.HashRecoveryCode(code)}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		logger.Errorf("Failed to store recovery codes: %v", err)
+		return nil, errors.New("failed to generate recovery codes")
+	}
+
+	return codes, nil
+}
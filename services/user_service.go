@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"creative-studio-server/config"
 	"creative-studio-server/models"
+	"creative-studio-server/pkg/cache"
 	"creative-studio-server/pkg/database"
 	"creative-studio-server/pkg/logger"
 )
@@ -35,7 +37,7 @@ func (s *UserService) CreateUser(req *models.UserCreateRequest) (*models.User, e
 		Username: req.Username,
 		Email:    req.Email,
 		Password: req.Password,
-		Role:     "user",
+		Role:     models.RoleUser,
 		IsActive: true,
 	}
 
@@ -71,6 +73,10 @@ func (s *UserService) AuthenticateUser(req *models.UserLoginRequest) (*models.Us
 		return nil, errors.New("invalid credentials")
 	}
 
+	if config.AppConfig.Account.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, errors.New("email address is not verified")
+	}
+
 	// Update last login
 	now := time.Now()
 	user.LastLogin = &now
@@ -92,6 +98,63 @@ func (s *UserService) GetUserByID(userID uint) (*models.User, error) {
 	return &user, nil
 }
 
+// userPlanTTL is how long a user's plan is cached; assigning a plan drops it
+const userPlanTTL = 5 * time.Minute
+
+// GetUserPlan returns the name of the rate limit plan of a user, the
+// default plan when none was assigned.
+func (s *UserService) GetUserPlan(userID uint) (string, error) {
+	var plan string
+	err := cache.ReadThrough(cache.UserPlanCacheKey(userID), userPlanTTL, &plan, func() error {
+		var user models.User
+		if err := s.db.Select("plan").First(&user, userID).Error; err != nil {
+			return err
+		}
+		plan = user.Plan
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get plan of user %d: %w", userID, err)
+	}
+
+	if _, ok := config.AppConfig.RateLimit.Plans[plan]; !ok {
+		plan = config.AppConfig.RateLimit.DefaultPlan
+	}
+	return plan, nil
+}
+
+// AssignUserPlan sets the rate limit plan of a user and returns the user
+// and their previous plan. An empty plan puts them back on the default one.
+func (s *UserService) AssignUserPlan(userID uint, plan string) (*models.User, string, error) {
+	if _, ok := config.AppConfig.RateLimit.Plans[plan]; plan != "" && !ok {
+		return nil, "", errors.New("plan not found")
+	}
+
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	previousPlan := user.Plan
+
+	if err := s.db.Model(user).Update("plan", plan).Error; err != nil {
+		logger.Errorf("Failed to assign user plan: %v", err)
+		return nil, "", errors.New("failed to assign plan")
+	}
+	cache.InvalidateUserPlan(user.ID)
+
+	return user, previousPlan, nil
+}
+
+// GetUsersByIDs returns the users among the IDs, in no particular order.
+// Deleted users are included, as what they made keeps its author.
+func (s *UserService) GetUsersByIDs(userIDs []uint) ([]models.User, error) {
+	var users []models.User
+	if err := s.db.Unscoped().Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	return users, nil
+}
+
 func (s *UserService) UpdateUser(userID uint, req *models.UserUpdateRequest) (*models.User, error) {
 	var user models.User
 	if err := s.db.First(&user, userID).Error; err != nil {
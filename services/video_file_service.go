@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"creative-studio-server/config"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/auth"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/logger"
+)
+
+// videoFileDirs hold the files of the video endpoints by kind
+var videoFileDirs = map[string]string{
+	models.VideoFileUpload: "./uploads",
+	models.VideoFileOutput: "./output",
+}
+
+// videoFilenamePattern is what names of video files may look like: no
+// separators, and no leading dot, so no name leaves its directory
+var videoFilenamePattern = regexp.MustCompile(`^\w[\w.-]*$`)
+
+// VideoFileService keeps the files of the video endpoints. Signed in
+// users' files are kept under a directory of their own and recorded in the
+// database, and only files recorded for the user are served or deleted.
+// Without the database, as in simple mode, files are shared.
+type VideoFileService struct {
+	db *gorm.DB
+}
+
+func NewVideoFileService() *VideoFileService {
+	return &VideoFileService{
+		db: database.GetDB(),
+	}
+}
+
+// ValidFilename reports whether a name may be used for a video file.
+func ValidFilename(filename string) bool {
+	return len(filename) <= 255 && videoFilenamePattern.MatchString(filename)
+}
+
+// SafeFilename turns an uploaded file's name into a valid one.
+func SafeFilename(filename string) string {
+	filename = strings.Trim(unsafeFilenameChars.ReplaceAllString(filepath.Base(filename), "_"), "_.-")
+	if len(filename) > 200 {
+		filename = filename[len(filename)-200:]
+	}
+	if filename == "" {
+		filename = "video"
+	}
+	return filename
+}
+
+// Dir returns the directory of the user's files of a kind.
+func (s *VideoFileService) Dir(kind string, userID uint) string {
+	if userID == 0 {
+		return videoFileDirs[kind]
+	}
+	return filepath.Join(videoFileDirs[kind], "users", strconv.FormatUint(uint64(userID), 10))
+}
+
+// Path returns the path of a file of the user, which must be theirs.
+func (s *VideoFileService) Path(kind string, userID uint, filename string) (string, error) {
+	if !ValidFilename(filename) {
+		return "", errors.New("invalid file name")
+	}
+
+	if s.db != nil {
+		if userID == 0 {
+			return "", errors.New("file not found")
+		}
+		var count int64
+		err := s.db.Model(&models.VideoFile{}).
+			Where("user_id = ? AND kind = ? AND filename = ?", userID, kind, filename).
+			Count(&count).Error
+		if err != nil {
+			logger.Errorf("Failed to get video file %s: %v", filename, err)
+			return "", errors.New("failed to get file")
+		}
+		if count == 0 {
+			return "", errors.New("file not found")
+		}
+	}
+
+	path := filepath.Join(s.Dir(kind, userID), filename)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", errors.New("file not found")
+		}
+		logger.Errorf("Failed to stat video file %s: %v", path, err)
+		return "", errors.New("failed to get file")
+	}
+	return path, nil
+}
+
+// Record records a file written to the user's directory as theirs.
+func (s *VideoFileService) Record(kind string, userID uint, filename string, size int64) error {
+	if s.db == nil || userID == 0 {
+		return nil
+	}
+
+	file := models.VideoFile{UserID: userID, Kind: kind, Filename: filename, Size: size}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "kind"}, {Name: "filename"}},
+		DoUpdates: clause.AssignmentColumns([]string{"size", "updated_at"}),
+	}).Create(&file).Error
+	if err != nil {
+		logger.Errorf("Failed to record video file %s: %v", filename, err)
+		return errors.New("failed to save file")
+	}
+	return nil
+}
+
+// Remove deletes a file of the user.
+func (s *VideoFileService) Remove(kind string, userID uint, filename string) error {
+	path, err := s.Path(kind, userID, filename)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.Errorf("Failed to delete video file %s: %v", path, err)
+		return errors.New("failed to delete file")
+	}
+	if s.db != nil {
+		err := s.db.Where("user_id = ? AND kind = ? AND filename = ?", userID, kind, filename).
+			Delete(&models.VideoFile{}).Error
+		if err != nil {
+			logger.Errorf("Failed to delete video file record %s: %v", filename, err)
+		}
+	}
+	return nil
+}
+
+// CreateDownloadURLs signs URLs that download and play an output video of
+// the user, valid for the configured time.
+func (s *VideoFileService) CreateDownloadURLs(filename string, userID uint) (*models.DownloadURLs, error) {
+	if _, err := s.Path(models.VideoFileOutput, userID, filename); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(config.AppConfig.Storage.DownloadURLTTL)
+	return &models.DownloadURLs{
+		Filename:    filename,
+		DownloadURL: auth.SignDownloadURL("/api/v1/videos/download/"+filename, userID, expiresAt),
+		PlayURL:     auth.SignDownloadURL("/api/v1/videos/play/"+filename, userID, expiresAt),
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// VerifyDownloadURL checks a signed URL of an output video and returns the
+// video's path. URLs stop working when the video is deleted.
+func (s *VideoFileService) VerifyDownloadURL(filename string, u *url.URL) (string, error) {
+	userID, err := auth.VerifyDownloadURL(u.Path, u.Query())
+	if err != nil {
+		return "", err
+	}
+	return s.Path(models.VideoFileOutput, userID, filename)
+}
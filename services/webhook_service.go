@@ -0,0 +1,317 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"creative-studio-server/models"
+	"creative-studio-server/pkg/database"
+	"creative-studio-server/pkg/download"
+	"creative-studio-server/pkg/logger"
+	"creative-studio-server/pkg/queue"
+)
+
+type WebhookService struct {
+	db *gorm.DB
+}
+
+func NewWebhookService() *WebhookService {
+	return &WebhookService{
+		db: database.GetDB(),
+	}
+}
+
+// CreateWebhook registers an endpoint for the events of the user's personal
+// workspace, or of the organization's when organizationID is set, which
+// takes an organization admin. The response carries the signing secret.
+func (s *WebhookService) CreateWebhook(userID uint, organizationID *uint, req *models.WebhookCreateRequest) (*models.WebhookSecretResponse, error) {
+	if allowed, err := s.canManage(userID, userID, organizationID); err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to create webhook")
+	} else if !allowed {
+		return nil, errors.New("insufficient organization role")
+	}
+
+	if _, err := download.CheckURL(req.URL); err != nil {
+		return nil, errors.New("invalid url: " + err.Error())
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		logger.Errorf("Failed to generate webhook secret: %v", err)
+		return nil, errors.New("failed to create webhook")
+	}
+
+	webhook := &models.Webhook{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		URL:            req.URL,
+		Description:    req.Description,
+		Events:         req.Events,
+		Secret:         secret,
+		Active:         true,
+	}
+	if err := s.db.Create(webhook).Error; err != nil {
+		logger.Errorf("Failed to create webhook: %v", err)
+		return nil, errors.New("failed to create webhook")
+	}
+
+	return &models.WebhookSecretResponse{Webhook: webhook, Secret: secret}, nil
+}
+
+// ListWebhooks returns a page of the workspace's endpoints, newest first.
+func (s *WebhookService) ListWebhooks(userID uint, organizationID *uint, req *models.WebhookListRequest) ([]models.Webhook, int64, error) {
+	query := s.db.Model(&models.Webhook{}).Scopes(workspaceScope(userID, organizationID))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+
+	var webhooks []models.Webhook
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(req.Limit).Find(&webhooks).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	return webhooks, total, nil
+}
+
+// GetWebhook returns an endpoint the user can read.
+func (s *WebhookService) GetWebhook(webhookID, userID uint) (*models.Webhook, error) {
+	return s.getWebhook(webhookID, userID, false)
+}
+
+func (s *WebhookService) getWebhook(webhookID, userID uint, manage bool) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := s.db.First(&webhook, webhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("webhook not found")
+		}
+		logger.Errorf("Failed to get webhook: %v", err)
+		return nil, errors.New("failed to get webhook")
+	}
+
+	readable, err := canAccess(s.db, userID, webhook.UserID, webhook.OrganizationID, false)
+	if err != nil {
+		logger.Errorf("Failed to check organization access: %v", err)
+		return nil, errors.New("failed to get webhook")
+	}
+	if !readable {
+		return nil, errors.New("webhook not found")
+	}
+
+	if manage {
+		allowed, err := s.canManage(userID, webhook.UserID, webhook.OrganizationID)
+		if err != nil {
+			logger.Errorf("Failed to check organization access: %v", err)
+			return nil, errors.New("failed to get webhook")
+		}
+		if !allowed {
+			return nil, errors.New("insufficient organization role")
+		}
+	}
+
+	return &webhook, nil
+}
+
+// canManage reports whether the user can change the endpoints of a
+// workspace. An organization's endpoints receive everything its members
+// do, so only its admins manage them.
+func (s *WebhookService) canManage(userID, ownerID uint, organizationID *uint) (bool, error) {
+	if organizationID == nil {
+		return userID == ownerID, nil
+	}
+
+	var membership models.Membership
+	if err := s.db.Where("organization_id = ? AND user_id = ?", *organizationID, userID).First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return membership.CanManage(), nil
+}
+
+func (s *WebhookService) UpdateWebhook(webhookID, userID uint, req *models.WebhookUpdateRequest) (*models.Webhook, error) {
+	webhook, err := s.getWebhook(webhookID, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if req.URL != "" {
+		if _, err := download.CheckURL(req.URL); err != nil {
+			return nil, errors.New("invalid url: " + err.Error())
+		}
+		updates["url"] = req.URL
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(req.Events) > 0 {
+		updates["events"] = models.StringArray(req.Events)
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+	if len(updates) > 0 {
+		if err := s.db.Model(webhook).Updates(updates).Error; err != nil {
+			logger.Errorf("Failed to update webhook: %v", err)
+			return nil, errors.New("failed to update webhook")
+		}
+	}
+
+	return s.GetWebhook(webhook.ID, userID)
+}
+
+// DeleteWebhook removes an endpoint. Deliveries still queued for it are
+// dropped when their turn comes; its delivery log is kept.
+func (s *WebhookService) DeleteWebhook(webhookID, userID uint) error {
+	webhook, err := s.getWebhook(webhookID, userID, true)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(webhook).Error; err != nil {
+		logger.Errorf("Failed to delete webhook: %v", err)
+		return errors.New("failed to delete webhook")
+	}
+
+	return nil
+}
+
+// RotateSecret replaces an endpoint's signing secret. Deliveries sent from
+// now on, retries included, are signed with the new one.
+func (s *WebhookService) RotateSecret(webhookID, userID uint) (*models.WebhookSecretResponse, error) {
+	webhook, err := s.getWebhook(webhookID, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		logger.Errorf("Failed to generate webhook secret: %v", err)
+		return nil, errors.New("failed to rotate webhook secret")
+	}
+	if err := s.db.Model(webhook).Update("secret", secret).Error; err != nil {
+		logger.Errorf("Failed to rotate webhook secret: %v", err)
+		return nil, errors.New("failed to rotate webhook secret")
+	}
+
+	return &models.WebhookSecretResponse{Webhook: webhook, Secret: secret}, nil
+}
+
+// TestWebhook sends a ping event to an endpoint, whatever it subscribes to.
+func (s *WebhookService) TestWebhook(webhookID, userID uint) (*models.WebhookDelivery, error) {
+	webhook, err := s.getWebhook(webhookID, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.Active {
+		return nil, errors.New("webhook is inactive")
+	}
+
+	payload, err := json.Marshal(queue.WebhookPayload{
+		Event:     models.WebhookEventPing,
+		CreatedAt: time.Now(),
+		Data:      map[string]interface{}{"webhook_id": webhook.ID},
+	})
+	if err != nil {
+		logger.Errorf("Failed to encode ping payload: %v", err)
+		return nil, errors.New("failed to test webhook")
+	}
+	delivery, err := queue.QueueWebhookDelivery(s.db, webhook.ID, models.WebhookEventPing, payload)
+	if err != nil {
+		logger.Errorf("Failed to queue webhook ping: %v", err)
+		return nil, errors.New("failed to test webhook")
+	}
+
+	return delivery, nil
+}
+
+// ListDeliveries returns a page of an endpoint's delivery log, newest
+// first.
+func (s *WebhookService) ListDeliveries(webhookID, userID uint, req *models.WebhookDeliveryListRequest) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.GetWebhook(webhookID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.Event != "" {
+		query = query.Where("event = ?", req.Event)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("Failed to count webhook deliveries: %v", err)
+		return nil, 0, errors.New("failed to get webhook deliveries")
+	}
+
+	var deliveries []models.WebhookDelivery
+	offset := (req.Page - 1) * req.Limit
+	if err := query.Order("id DESC").Offset(offset).Limit(req.Limit).Find(&deliveries).Error; err != nil {
+		logger.Errorf("Failed to get webhook deliveries: %v", err)
+		return nil, 0, errors.New("failed to get webhook deliveries")
+	}
+
+	return deliveries, total, nil
+}
+
+// Redeliver sends a logged delivery's payload again as a new delivery,
+// leaving the original in the log.
+func (s *WebhookService) Redeliver(webhookID, deliveryID, userID uint) (*models.WebhookDelivery, error) {
+	webhook, err := s.getWebhook(webhookID, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.Active {
+		return nil, errors.New("webhook is inactive")
+	}
+
+	var delivery models.WebhookDelivery
+	if err := s.db.Where("webhook_id = ?", webhook.ID).First(&delivery, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("webhook delivery not found")
+		}
+		logger.Errorf("Failed to get webhook delivery: %v", err)
+		return nil, errors.New("failed to redeliver webhook")
+	}
+
+	redelivery, err := queue.QueueWebhookDelivery(s.db, webhook.ID, delivery.Event, delivery.Payload)
+	if err != nil {
+		logger.Errorf("Failed to queue webhook redelivery: %v", err)
+		return nil, errors.New("failed to redeliver webhook")
+	}
+
+	return redelivery, nil
+}
+
+// PruneDeliveries deletes succeeded and failed deliveries created before
+// the cutoff, returning how many were removed.
+func (s *WebhookService) PruneDeliveries(before time.Time) (int64, error) {
+	result := s.db.Where("status IN ? AND created_at < ?",
+		[]string{models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed}, before).
+		Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune webhook deliveries: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
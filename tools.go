@@ -0,0 +1,10 @@
+//go:build tools
+
+// Package tools pins the code generators the Makefile runs, so go.mod
+// tracks their versions.
+package tools
+
+import (
+	_ "github.com/99designs/gqlgen"
+	_ "github.com/swaggo/swag/cmd/swag"
+)
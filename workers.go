@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"creative-studio-server/pkg/queue"
+	"creative-studio-server/services"
+)
+
+// Run modes
+const (
+	modeAll    = "all"    // HTTP API and background workers
+	modeAPI    = "api"    // HTTP API only
+	modeWorker = "worker" // background workers only, no HTTP listener
+)
+
+// workerQueue describes the consumers started for one queue.
+type workerQueue struct {
+	queue       string
+	handler     queue.TaskHandler
+	concurrency int
+	label       string
+}
+
+// workerQueues lists the consumed queues; concurrency comes from the queue
+// settings (QUEUE_CONCURRENCY) unless set with a flag.
+var workerQueues = []*workerQueue{
+	{queue: "video_processing", handler: queue.VideoProcessingHandler, label: "video processing"},
+	{queue: "smart_composition", handler: queue.SmartCompositionHandler, label: "smart composition"},
+	{queue: "render_tasks", handler: queue.RenderTaskHandler, label: "render task"},
+	{queue: "render_preview", handler: queue.RenderTaskHandler, label: "preview render"},
+	{queue: "analysis_tasks", handler: queue.AnalysisTaskHandler, label: "analysis task"},
+	{queue: "thumbnail_generation", handler: queue.ThumbnailTaskHandler, label: "thumbnail generation"},
+	{queue: "clip_imports", handler: services.ClipImportTaskHandler, label: "clip import"},
+	{queue: "search_index", handler: services.SearchIndexTaskHandler, label: "search index"},
+	{queue: "webhooks", handler: queue.WebhookDeliveryHandler, label: "webhook delivery"},
+	{queue: "transcriptions", handler: queue.TranscriptionTaskHandler, label: "transcription"},
+	{queue: "moderation", handler: queue.ModerationTaskHandler, label: "moderation"},
+	{queue: "music_analysis", handler: queue.MusicAnalysisTaskHandler, label: "music analysis"},
+}
+
+// registerWorkerFlags adds a --workers.<queue> concurrency flag per queue.
+// Setting a queue to 0 stops this process from consuming it, even when the
+// queue's settings are changed at runtime.
+func registerWorkerFlags(fs *flag.FlagSet) {
+	for _, wq := range workerQueues {
+		fs.IntVar(&wq.concurrency, "workers."+wq.queue, -1,
+			fmt.Sprintf("number of %s workers (0 disables the queue, default from queue settings)", wq.label))
+	}
+}
+
+func validateMode(mode string) error {
+	switch mode {
+	case modeAll, modeAPI, modeWorker:
+		return nil
+	}
+	return fmt.Errorf("unknown mode %q (expected %s, %s or %s)", mode, modeAll, modeAPI, modeWorker)
+}